@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -17,11 +18,13 @@ import (
 // network. A method such as `Transact` does require a Tx and thus will
 // be flagged `true`.
 // Input specifies the required input parameters for this gives method.
+// Outputs specifies the values returned by a call.
 type Method struct {
-	Name   string
-	Const  bool
-	Input  []Argument
-	Return Type // not yet implemented
+	Name    string
+	Const   bool
+	Input   []Argument
+	Outputs []Argument
+	Return  Type // not yet implemented
 }
 
 // Returns the methods string signature according to the ABI spec.
@@ -48,17 +51,40 @@ func (m Method) Id() []byte {
 	return crypto.Sha3([]byte(m.String()))[:4]
 }
 
+// Event is a log topic a contract can raise. Indexed inputs are stored
+// in the topics of the log, the remaining ones in its data.
+type Event struct {
+	Name   string
+	Inputs []Argument
+}
+
+// Returns the events string signature according to the ABI spec.
+func (e Event) String() string {
+	types := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		types[i] = input.Type.String()
+	}
+	return e.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+// Id returns the topic identifying the event in the logs.
+func (e Event) Id() common.Hash {
+	return common.BytesToHash(crypto.Sha3([]byte(e.String())))
+}
+
 // Argument holds the name of the argument and the corresponding type.
 // Types are used when packing and testing arguments.
 type Argument struct {
-	Name string
-	Type Type
+	Name    string
+	Type    Type
+	Indexed bool // only used by events
 }
 
 func (a *Argument) UnmarshalJSON(data []byte) error {
 	var extarg struct {
-		Name string
-		Type string
+		Name    string
+		Type    string
+		Indexed bool
 	}
 	err := json.Unmarshal(data, &extarg)
 	if err != nil {
@@ -70,6 +96,7 @@ func (a *Argument) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	a.Name = extarg.Name
+	a.Indexed = extarg.Indexed
 
 	return nil
 }
@@ -78,14 +105,18 @@ func (a *Argument) UnmarshalJSON(data []byte) error {
 // invokable methods. It will allow you to type check function calls and
 // packs data accordingly.
 type ABI struct {
-	Methods map[string]Method
+	Constructor Method
+	Methods     map[string]Method
+	Events      map[string]Event
 }
 
 // tests, tests whether the given input would result in a successful
 // call. Checks argument list count and matches input to `input`.
 func (abi ABI) pack(name string, args ...interface{}) ([]byte, error) {
-	method := abi.Methods[name]
+	return packArguments(name, abi.Methods[name], args...)
+}
 
+func packArguments(name string, method Method, args ...interface{}) ([]byte, error) {
 	var ret []byte
 	for i, a := range args {
 		input := method.Input[i]
@@ -129,15 +160,51 @@ func (abi ABI) Pack(name string, args ...interface{}) ([]byte, error) {
 	return packed, nil
 }
 
+// PackConstructor packs the constructor arguments. Unlike method calls
+// they are appended to the contract code without an id.
+func (abi ABI) PackConstructor(args ...interface{}) ([]byte, error) {
+	if len(args) != len(abi.Constructor.Input) {
+		return nil, fmt.Errorf("argument count mismatch: %d for %d", len(args), len(abi.Constructor.Input))
+	}
+	return packArguments("constructor", abi.Constructor, args...)
+}
+
+// UnmarshalJSON accepts both the ABI definitions emitted by the Solidity
+// compiler ("type", "constant", "inputs", "outputs") and the shorter
+// method list format ("const", "input").
 func (abi *ABI) UnmarshalJSON(data []byte) error {
-	var methods []Method
-	if err := json.Unmarshal(data, &methods); err != nil {
+	var fields []struct {
+		Type     string
+		Name     string
+		Const    bool
+		Constant bool
+		Input    []Argument
+		Inputs   []Argument
+		Outputs  []Argument
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
 		return err
 	}
 
 	abi.Methods = make(map[string]Method)
-	for _, method := range methods {
-		abi.Methods[method.Name] = method
+	abi.Events = make(map[string]Event)
+	for _, field := range fields {
+		inputs := append(field.Input, field.Inputs...)
+		switch field.Type {
+		case "constructor":
+			abi.Constructor = Method{Input: inputs}
+		case "event":
+			abi.Events[field.Name] = Event{Name: field.Name, Inputs: inputs}
+		case "", "function":
+			abi.Methods[field.Name] = Method{
+				Name:    field.Name,
+				Const:   field.Const || field.Constant,
+				Input:   inputs,
+				Outputs: field.Outputs,
+			}
+		default:
+			return fmt.Errorf("unknown abi entry type %q", field.Type)
+		}
 	}
 
 	return nil
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -73,12 +74,12 @@ func TestReader(t *testing.T) {
 	exp := ABI{
 		Methods: map[string]Method{
 			"balance": Method{
-				"balance", true, nil, Type{},
+				Name: "balance", Const: true,
 			},
 			"send": Method{
-				"send", false, []Argument{
-					Argument{"amount", Uint256},
-				}, Type{},
+				Name: "send", Const: false, Input: []Argument{
+					Argument{Name: "amount", Type: Uint256},
+				},
 			},
 		},
 	}
@@ -219,7 +220,7 @@ func TestTestAddress(t *testing.T) {
 func TestMethodSignature(t *testing.T) {
 	String, _ := NewType("string")
 	String32, _ := NewType("string32")
-	m := Method{"foo", false, []Argument{Argument{"bar", String32}, Argument{"baz", String}}, Type{}}
+	m := Method{Name: "foo", Input: []Argument{Argument{Name: "bar", Type: String32}, Argument{Name: "baz", Type: String}}}
 	exp := "foo(string32,string)"
 	if m.String() != exp {
 		t.Error("signature mismatch", exp, "!=", m.String())
@@ -231,7 +232,7 @@ func TestMethodSignature(t *testing.T) {
 	}
 
 	uintt, _ := NewType("uint")
-	m = Method{"foo", false, []Argument{Argument{"bar", uintt}}, Type{}}
+	m = Method{Name: "foo", Input: []Argument{Argument{Name: "bar", Type: uintt}}}
 	exp = "foo(uint256)"
 	if m.String() != exp {
 		t.Error("signature mismatch", exp, "!=", m.String())
@@ -328,3 +329,78 @@ func TestPackSliceBig(t *testing.T) {
 		t.Errorf("expected %x got %x", sig, packed)
 	}
 }
+
+const solidityJSON = `[
+	{"type": "constructor", "inputs": [{"name": "supply", "type": "uint256"}]},
+	{"type": "function", "name": "balance", "constant": true, "inputs": [{"name": "who", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}, {"name": "", "type": "bool"}]},
+	{"type": "event", "name": "Transfer", "inputs": [{"name": "from", "type": "address", "indexed": true}, {"name": "value", "type": "int256", "indexed": false}]}
+]`
+
+func TestSolidityJSON(t *testing.T) {
+	abi, err := JSON(strings.NewReader(solidityJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abi.Constructor.Input) != 1 {
+		t.Errorf("expected 1 constructor input, got %d", len(abi.Constructor.Input))
+	}
+	balance := abi.Methods["balance"]
+	if !balance.Const || len(balance.Input) != 1 || len(balance.Outputs) != 2 {
+		t.Errorf("balance parsed incorrectly: %+v", balance)
+	}
+	transfer, ok := abi.Events["Transfer"]
+	if !ok || !transfer.Inputs[0].Indexed || transfer.Inputs[1].Indexed {
+		t.Errorf("Transfer parsed incorrectly: %+v", transfer)
+	}
+	if transfer.String() != "Transfer(address,int256)" {
+		t.Errorf("wrong event signature %s", transfer.String())
+	}
+
+	packed, err := abi.PackConstructor(big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed, U256(big.NewInt(1))) {
+		t.Errorf("constructor packed to %x", packed)
+	}
+	packed, err = abi.Pack("balance", common.Address{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed[4:], common.LeftPadBytes(common.Address{1}.Bytes(), 32)) {
+		t.Errorf("address packed to %x", packed[4:])
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	abi, err := JSON(strings.NewReader(solidityJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := append(U256(big.NewInt(42)), U256(big.NewInt(1))...)
+	values, err := abi.Unpack("balance", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].(*big.Int).Cmp(big.NewInt(42)) != 0 || values[1].(bool) != true {
+		t.Errorf("unpacked %v, want [42 true]", values)
+	}
+	if _, err := abi.Unpack("balance", output[:32]); err == nil {
+		t.Errorf("expected error for short output")
+	}
+
+	event := abi.Events["Transfer"]
+	from := common.Address{0xaa}
+	topics := []common.Hash{event.Id(), common.BytesToHash(from.Bytes())}
+	minus5 := new(big.Int).Sub(common.BigPow(2, 256), big.NewInt(5)) // two's complement
+	values, err = event.Unpack(topics, common.LeftPadBytes(minus5.Bytes(), 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].(common.Address) != from || values[1].(*big.Int).Cmp(big.NewInt(-5)) != 0 {
+		t.Errorf("unpacked %v, want [%x -5]", values, from)
+	}
+	if _, err := event.Unpack(topics[1:], nil); err == nil {
+		t.Errorf("expected error for log of another event")
+	}
+}
@@ -0,0 +1,265 @@
+// Package backends provides an in-process simulated blockchain for unit
+// testing contract interactions without running a node.
+//
+// SimulatedBackend offers the same string based call and transact methods
+// as xeth, so code written against xeth can be tested against a private
+// in-memory chain. Transactions are collected in a pending block until
+// Commit mines it instantly.
+package backends
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+var (
+	// FundAmount is the balance of the accounts created by
+	// NewSimulatedBackend.
+	FundAmount = common.BigPow(2, 200)
+
+	defaultGasPrice = big.NewInt(10000000000000)
+	defaultGas      = big.NewInt(90000)
+)
+
+// SimulatedBackend is a private blockchain held in memory. Blocks use a
+// fake proof of work and are only created by Commit.
+type SimulatedBackend struct {
+	mu         sync.Mutex
+	db         common.Database
+	chain      *core.ChainManager
+	keys       map[common.Address]*ecdsa.PrivateKey
+	pendingTxs types.Transactions
+	logs       state.Logs
+}
+
+// NewSimulatedBackend creates a chain whose genesis block funds the
+// accounts of the given keys with FundAmount. The keys are used to sign
+// the transactions sent from these accounts.
+func NewSimulatedBackend(keys ...*ecdsa.PrivateKey) *SimulatedBackend {
+	db, _ := ethdb.NewMemDatabase()
+	mux := new(event.TypeMux)
+	chain := core.NewChainManager(db, db, mux)
+
+	self := &SimulatedBackend{db: db, chain: chain, keys: make(map[common.Address]*ecdsa.PrivateKey)}
+
+	genesis := core.GenesisBlock(db)
+	statedb := state.New(genesis.Root(), db)
+	for _, key := range keys {
+		addr := common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		self.keys[addr] = key
+		statedb.AddBalance(addr, FundAmount)
+	}
+	statedb.Update()
+	statedb.Sync()
+	header := genesis.Header()
+	header.Root = statedb.Root()
+	genesis = genesis.WithHeader(header)
+	chain.ResetWithGenesisBlock(genesis)
+
+	txpool := core.NewTxPool(mux, chain.State)
+	chain.SetProcessor(core.NewBlockProcessor(db, db, core.FakePow{}, txpool, chain, mux))
+
+	return self
+}
+
+// ChainManager returns the chain manager of the simulated chain.
+func (self *SimulatedBackend) ChainManager() *core.ChainManager {
+	return self.chain
+}
+
+// Commit mines the pending transactions into a new block.
+func (self *SimulatedBackend) Commit() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	block, err := self.pendingBlock(self.pendingTxs)
+	if err != nil {
+		return err
+	}
+	if err := self.chain.InsertChain(types.Blocks{block}); err != nil {
+		return err
+	}
+	for _, receipt := range block.Receipts() {
+		self.logs = append(self.logs, receipt.Logs()...)
+	}
+	self.pendingTxs = nil
+	return nil
+}
+
+// Logs returns the logs raised by the transactions of all committed
+// blocks.
+func (self *SimulatedBackend) Logs() state.Logs {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return append(state.Logs(nil), self.logs...)
+}
+
+// Rollback drops the pending transactions.
+func (self *SimulatedBackend) Rollback() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.pendingTxs = nil
+}
+
+// PendingTransactions returns the transactions which will be included by
+// the next Commit.
+func (self *SimulatedBackend) PendingTransactions() types.Transactions {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return append(types.Transactions(nil), self.pendingTxs...)
+}
+
+// StorageAt returns the storage slot of the account at the current block.
+func (self *SimulatedBackend) StorageAt(addr, storageAddr string) string {
+	return common.ToHex(self.chain.State().GetState(common.HexToAddress(addr), common.HexToHash(storageAddr)))
+}
+
+// BalanceAt returns the balance of the account at the current block.
+func (self *SimulatedBackend) BalanceAt(addr string) string {
+	return common.ToHex(self.chain.State().GetBalance(common.HexToAddress(addr)).Bytes())
+}
+
+// CodeAt returns the code of the account at the current block.
+func (self *SimulatedBackend) CodeAt(addr string) string {
+	return common.ToHex(self.chain.State().GetCode(common.HexToAddress(addr)))
+}
+
+// Call executes a message call against the state of the current block
+// without creating a transaction. The state is not modified.
+func (self *SimulatedBackend) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, error) {
+	statedb := self.chain.State()
+
+	from := common.HexToAddress(fromStr)
+	if len(fromStr) == 0 {
+		for addr := range self.keys {
+			from = addr
+			break
+		}
+	}
+	msg := callmsg{
+		from:     statedb.GetOrNewStateObject(from),
+		to:       common.HexToAddress(toStr),
+		gas:      common.Big(gasStr),
+		gasPrice: common.Big(gasPriceStr),
+		value:    common.Big(valueStr),
+		data:     common.FromHex(dataStr),
+	}
+	if msg.gas.Sign() == 0 {
+		msg.gas = new(big.Int).Set(defaultGas)
+	}
+	if msg.gasPrice.Sign() == 0 {
+		msg.gasPrice = new(big.Int).Set(defaultGasPrice)
+	}
+
+	vmenv := core.NewEnv(statedb, self.chain, msg, self.chain.CurrentBlock())
+	res, err := vmenv.Call(msg.from, msg.to, msg.data, msg.gas, msg.gasPrice, msg.value)
+	return common.ToHex(res), err
+}
+
+// Transact signs a transaction with the key of the sender and adds it to
+// the pending block. An empty toStr creates a contract, in which case the
+// contract address is returned instead of the transaction hash. The
+// transaction is rejected if it can't be executed on top of the pending
+// block.
+func (self *SimulatedBackend) Transact(fromStr, toStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var (
+		from  = common.HexToAddress(fromStr)
+		value = common.Big(valueStr)
+		gas   = common.Big(gasStr)
+		price = common.Big(gasPriceStr)
+		data  = common.FromHex(codeStr)
+	)
+	key, ok := self.keys[from]
+	if !ok {
+		return "", fmt.Errorf("unknown account %x", from)
+	}
+	if gas.Sign() == 0 {
+		gas = new(big.Int).Set(defaultGas)
+	}
+	if price.Sign() == 0 {
+		price = new(big.Int).Set(defaultGasPrice)
+	}
+
+	var tx *types.Transaction
+	if len(toStr) == 0 {
+		tx = types.NewContractCreationTx(value, gas, price, data)
+	} else {
+		tx = types.NewTransactionMessage(common.HexToAddress(toStr), value, gas, price, data)
+	}
+	tx.SetNonce(self.pendingNonce(from))
+	if err := tx.SignECDSA(key); err != nil {
+		return "", err
+	}
+
+	txs := append(append(types.Transactions(nil), self.pendingTxs...), tx)
+	if _, err := self.pendingBlock(txs); err != nil {
+		return "", err
+	}
+	self.pendingTxs = txs
+
+	if tx.To() == nil {
+		return core.AddressFromMessage(tx).Hex(), nil
+	}
+	return tx.Hash().Hex(), nil
+}
+
+// pendingNonce returns the nonce of the account after the pending
+// transactions.
+func (self *SimulatedBackend) pendingNonce(addr common.Address) uint64 {
+	nonce := self.chain.State().GetNonce(addr)
+	for _, tx := range self.pendingTxs {
+		if from, _ := tx.From(); from == addr {
+			nonce++
+		}
+	}
+	return nonce
+}
+
+// pendingBlock builds the block containing txs on top of the current block.
+func (self *SimulatedBackend) pendingBlock(txs types.Transactions) (block *types.Block, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	blocks := core.GenerateChain(self.chain.CurrentBlock(), self.db, 1, func(i int, gen *core.BlockGen) {
+		for _, tx := range txs {
+			gen.AddTx(tx)
+		}
+	})
+	return blocks[0], nil
+}
+
+// callmsg implements core.Message for calls which don't create a
+// transaction.
+type callmsg struct {
+	from          *state.StateObject
+	to            common.Address
+	gas, gasPrice *big.Int
+	value         *big.Int
+	data          []byte
+}
+
+func (m callmsg) From() (common.Address, error) { return m.from.Address(), nil }
+func (m callmsg) Nonce() uint64                 { return m.from.Nonce() }
+func (m callmsg) To() *common.Address           { return &m.to }
+func (m callmsg) GasPrice() *big.Int            { return m.gasPrice }
+func (m callmsg) Gas() *big.Int                 { return m.gas }
+func (m callmsg) Value() *big.Int               { return m.value }
+func (m callmsg) Data() []byte                  { return m.data }
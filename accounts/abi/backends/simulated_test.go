@@ -0,0 +1,76 @@
+package backends
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// returns42 is the creation code of a contract returning 42 on every call.
+const returns42 = "0x600a600c600039600a6000f3" + "602a60005260206000f3"
+
+// storeArg stores the first word of the call data in slot 0.
+const storeArg = "0x6007600c60003960076000f3" + "60003560005500"
+
+func TestSimulatedBackend(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey)).Hex()
+	sim := NewSimulatedBackend(key)
+
+	if balance := common.Big(sim.BalanceAt(from)); balance.Cmp(FundAmount) != 0 {
+		t.Fatalf("account not funded: balance %v", balance)
+	}
+
+	addr, err := sim.Transact(from, "", "", "", "", returns42)
+	if err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+	store, err := sim.Transact(from, "", "", "", "", storeArg)
+	if err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+	if code := sim.CodeAt(addr); common.Big(code).Sign() != 0 {
+		t.Errorf("contract deployed before commit: code %s", code)
+	}
+	if len(sim.PendingTransactions()) != 2 {
+		t.Errorf("expected 2 pending transactions, got %d", len(sim.PendingTransactions()))
+	}
+	if err := sim.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+	if n := sim.ChainManager().CurrentBlock().Number(); n.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("expected head #1, got #%v", n)
+	}
+
+	res, err := sim.Call(from, addr, "", "", "", "")
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if got := common.Big(res); got.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("call returned %s, want 42", res)
+	}
+
+	if _, err := sim.Transact(from, store, "", "", "", "0x07"); err != nil {
+		t.Fatalf("transact failed: %v", err)
+	}
+	sim.Rollback()
+	if _, err := sim.Transact(from, store, "", "", "", common.ToHex(common.LeftPadBytes([]byte{9}, 32))); err != nil {
+		t.Fatalf("transact failed: %v", err)
+	}
+	if err := sim.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+	if got := common.Big(sim.StorageAt(store, "0x0")); got.Cmp(big.NewInt(9)) != 0 {
+		t.Errorf("slot 0 is %v, want 9", got)
+	}
+
+	// transactions which can't be executed are rejected
+	if _, err := sim.Transact(from, addr, FundAmount.String(), "", "", ""); err == nil {
+		t.Errorf("expected error for transfer exceeding the balance")
+	}
+	if _, err := sim.Transact(common.Address{1}.Hex(), addr, "", "", "", ""); err == nil {
+		t.Errorf("expected error for unknown account")
+	}
+}
@@ -0,0 +1,142 @@
+package bind
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+)
+
+// ContractBackend is the interface generated bindings use to talk to the
+// chain. It is satisfied by xeth.XEth and backends.SimulatedBackend.
+//
+// Call executes a read only message call and returns the hex encoded
+// output. Transact sends a transaction and returns its hash, or the
+// address of the created contract if toStr is empty.
+type ContractBackend interface {
+	Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, error)
+	Transact(fromStr, toStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, error)
+}
+
+// CallOpts are the options of a read only contract call. A zero From uses
+// the backend's default account.
+type CallOpts struct {
+	From common.Address
+}
+
+// TransactOpts are the options of a transaction sent to a contract. Nil
+// values use the backend's defaults.
+type TransactOpts struct {
+	From     common.Address
+	Value    *big.Int
+	GasLimit *big.Int
+	GasPrice *big.Int
+}
+
+// BoundContract is the base wrapper of generated bindings. It packs the
+// arguments of calls and transactions and unpacks their results.
+type BoundContract struct {
+	address common.Address
+	abi     abi.ABI
+	backend ContractBackend
+}
+
+// NewBoundContract binds the contract at address.
+func NewBoundContract(address common.Address, abi abi.ABI, backend ContractBackend) *BoundContract {
+	return &BoundContract{address: address, abi: abi, backend: backend}
+}
+
+// DeployContract creates a contract from bytecode, passing params to its
+// constructor, and binds the new contract.
+func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (*BoundContract, error) {
+	if opts == nil {
+		opts = new(TransactOpts)
+	}
+	input, err := abi.PackConstructor(params...)
+	if err != nil {
+		return nil, err
+	}
+	code := append(append([]byte(nil), bytecode...), input...)
+	addr, err := backend.Transact(addressString(opts.From), "", bigString(opts.Value), bigString(opts.GasLimit), bigString(opts.GasPrice), common.ToHex(code))
+	if err != nil {
+		return nil, err
+	}
+	return NewBoundContract(common.HexToAddress(addr), abi, backend), nil
+}
+
+// Address returns the address of the bound contract.
+func (self *BoundContract) Address() common.Address {
+	return self.address
+}
+
+// Call invokes the constant method with the given params and returns its
+// unpacked outputs.
+func (self *BoundContract) Call(opts *CallOpts, method string, params ...interface{}) ([]interface{}, error) {
+	if opts == nil {
+		opts = new(CallOpts)
+	}
+	input, err := self.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := self.backend.Call(addressString(opts.From), self.address.Hex(), "", "", "", common.ToHex(input))
+	if err != nil {
+		return nil, err
+	}
+	return self.abi.Unpack(method, common.FromHex(output))
+}
+
+// Transact sends a transaction invoking the method with the given params.
+func (self *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (common.Hash, error) {
+	if opts == nil {
+		opts = new(TransactOpts)
+	}
+	input, err := self.abi.Pack(method, params...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash, err := self.backend.Transact(addressString(opts.From), self.address.Hex(), bigString(opts.Value), bigString(opts.GasLimit), bigString(opts.GasPrice), common.ToHex(input))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.HexToHash(hash), nil
+}
+
+// FilterLogs returns the unpacked inputs of the named event for all logs
+// raised by the contract.
+func (self *BoundContract) FilterLogs(logs state.Logs, name string) ([][]interface{}, error) {
+	event, exist := self.abi.Events[name]
+	if !exist {
+		return nil, fmt.Errorf("event '%s' not found", name)
+	}
+	id := event.Id()
+
+	var events [][]interface{}
+	for _, log := range logs {
+		if log.Address != self.address || len(log.Topics) == 0 || log.Topics[0] != id {
+			continue
+		}
+		values, err := event.Unpack(log.Topics, log.Data)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, values)
+	}
+	return events, nil
+}
+
+func addressString(addr common.Address) string {
+	if (addr == common.Address{}) {
+		return ""
+	}
+	return addr.Hex()
+}
+
+func bigString(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+	return n.String()
+}
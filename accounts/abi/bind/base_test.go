@@ -0,0 +1,74 @@
+package bind
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const storeABI = `[
+	{"type": "function", "name": "answer", "constant": true, "inputs": [], "outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "store", "constant": false, "inputs": [{"name": "value", "type": "uint256"}], "outputs": []},
+	{"type": "event", "name": "Stored", "inputs": [{"name": "value", "type": "uint256", "indexed": false}]}
+]`
+
+// storeCode returns the creation code of a contract raising Stored with
+// the first argument of the call and returning 42.
+func storeCode(event abi.Event) []byte {
+	runtime := []byte{0x60, 0x04, 0x35, 0x60, 0x00, 0x52, 0x7f} // PUSH1 4 CALLDATALOAD PUSH1 0 MSTORE PUSH32
+	runtime = append(runtime, event.Id().Bytes()...)
+	runtime = append(runtime,
+		0x60, 0x20, 0x60, 0x00, 0xa1, // PUSH1 32 PUSH1 0 LOG1
+		0x60, 0x2a, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3, // return 42
+	)
+	n := byte(len(runtime))
+	code := []byte{0x60, n, 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, n, 0x60, 0x00, 0xf3} // copy and return runtime
+	return append(code, runtime...)
+}
+
+func TestBoundContract(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sim := backends.NewSimulatedBackend(key)
+	opts := &TransactOpts{From: common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))}
+
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err := DeployContract(opts, parsed, storeCode(parsed.Events["Stored"]), sim)
+	if err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+	if _, err := contract.Transact(opts, "store", big.NewInt(7)); err != nil {
+		t.Fatalf("transact failed: %v", err)
+	}
+	if err := sim.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	out, err := contract.Call(nil, "answer")
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if len(out) != 1 || out[0].(*big.Int).Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("answer returned %v, want [42]", out)
+	}
+
+	events, err := contract.FilterLogs(sim.Logs(), "Stored")
+	if err != nil {
+		t.Fatalf("filter failed: %v", err)
+	}
+	if len(events) != 1 || events[0][0].(*big.Int).Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("got events %v, want [[7]]", events)
+	}
+	// logs of other contracts are ignored
+	other := NewBoundContract(common.Address{1}, parsed, sim)
+	if events, _ := other.FilterLogs(sim.Logs(), "Stored"); len(events) != 0 {
+		t.Errorf("got events %v for other contract", events)
+	}
+}
@@ -0,0 +1,181 @@
+// Package bind generates Go bindings for Ethereum contracts.
+//
+// The generated wrappers embed the contract ABI and optionally its
+// bytecode, and expose typed methods for deploying the contract, calling
+// its constant methods, sending transactions and filtering its events.
+// They talk to the chain through a ContractBackend, such as xeth.XEth or
+// backends.SimulatedBackend.
+package bind
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// Bind generates the Go wrapper of the contract typeName in package pkg
+// from its JSON ABI and hex encoded bytecode. Without bytecode no deploy
+// function is generated.
+func Bind(typeName, abiJSON, bytecode, pkg string) (string, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return "", err
+	}
+	data := &tmplData{
+		Package:  pkg,
+		Type:     capitalise(typeName),
+		InputABI: strings.Replace(strings.TrimSpace(abiJSON), "`", "", -1),
+		InputBin: strings.TrimSpace(bytecode),
+	}
+	if data.Constructor, err = bindMethod(parsed.Constructor, data); err != nil {
+		return "", fmt.Errorf("constructor: %v", err)
+	}
+
+	names := make([]string, 0, len(parsed.Methods))
+	for name := range parsed.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		method, err := bindMethod(parsed.Methods[name], data)
+		if err != nil {
+			return "", fmt.Errorf("method %s: %v", name, err)
+		}
+		if parsed.Methods[name].Const {
+			data.Calls = append(data.Calls, method)
+		} else {
+			data.Transacts = append(data.Transacts, method)
+		}
+	}
+
+	names = names[:0]
+	for name := range parsed.Events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		event := parsed.Events[name]
+		bound := &tmplEvent{Original: name, Normalized: capitalise(name)}
+		for i, input := range event.Inputs {
+			typ, err := bindType(input.Type, data)
+			if err != nil {
+				return "", fmt.Errorf("event %s: %v", name, err)
+			}
+			field := capitalise(input.Name)
+			if field == "" {
+				field = fmt.Sprintf("Arg%d", i)
+			}
+			bound.Fields = append(bound.Fields, tmplArg{Name: field, Type: typ})
+		}
+		data.Events = append(data.Events, bound)
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := bindTemplate.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("%v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+// tmplData is the data passed to bindTemplate.
+type tmplData struct {
+	Package  string
+	Type     string
+	InputABI string
+	InputBin string
+
+	Constructor *tmplMethod
+	Calls       []*tmplMethod
+	Transacts   []*tmplMethod
+	Events      []*tmplEvent
+
+	UsesBig bool // whether math/big must be imported
+}
+
+type tmplMethod struct {
+	Original   string
+	Normalized string
+	Inputs     []tmplArg
+	Outputs    []tmplArg
+}
+
+type tmplEvent struct {
+	Original   string
+	Normalized string
+	Fields     []tmplArg
+}
+
+type tmplArg struct {
+	Name string
+	Type string
+}
+
+// reservedNames are the identifiers used by the generated method bodies.
+var reservedNames = map[string]bool{"opts": true, "backend": true, "values": true, "err": true}
+
+func bindMethod(method abi.Method, data *tmplData) (*tmplMethod, error) {
+	bound := &tmplMethod{Original: method.Name, Normalized: capitalise(method.Name)}
+	for i, input := range method.Input {
+		typ, err := bindType(input.Type, data)
+		if err != nil {
+			return nil, err
+		}
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		} else if token.Lookup(name).IsKeyword() || reservedNames[name] || strings.HasPrefix(name, "ret") {
+			name += "_"
+		}
+		bound.Inputs = append(bound.Inputs, tmplArg{Name: name, Type: typ})
+	}
+	for i, output := range method.Outputs {
+		typ, err := bindType(output.Type, data)
+		if err != nil {
+			return nil, err
+		}
+		bound.Outputs = append(bound.Outputs, tmplArg{Name: fmt.Sprintf("ret%d", i), Type: typ})
+	}
+	return bound, nil
+}
+
+// bindType returns the Go type used for an ABI type. Dynamic types, arrays
+// and unsized strings, are rejected as abi.Unpack only decodes values of a
+// single word. Fixed size strings are rejected as abi.Pack doesn't encode
+// their value.
+func bindType(t abi.Type, data *tmplData) (string, error) {
+	kind := t.String()
+	switch {
+	case strings.HasSuffix(kind, "]"), kind == "string":
+		return "", fmt.Errorf("unsupported dynamic type %s", kind)
+	case strings.HasPrefix(kind, "string"):
+		return "", fmt.Errorf("unsupported fixed size string %s", kind)
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"):
+		data.UsesBig = true
+		return "*big.Int", nil
+	case kind == "bool":
+		return "bool", nil
+	case kind == "address":
+		return "common.Address", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", kind)
+}
+
+// capitalise makes the first character of an identifier upper case.
+func capitalise(name string) string {
+	if name == "" {
+		return ""
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
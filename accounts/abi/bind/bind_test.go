@@ -0,0 +1,175 @@
+package bind
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testABI = `[
+	{"type": "constructor", "inputs": [{"name": "owner", "type": "address"}]},
+	{"type": "function", "name": "answer", "constant": true, "inputs": [], "outputs": [{"name": "", "type": "uint256"}]},
+	{"type": "function", "name": "lookup", "constant": true, "inputs": [{"name": "", "type": "address"}, {"name": "type", "type": "uint8"}], "outputs": [{"name": "found", "type": "bool"}, {"name": "value", "type": "int256"}]},
+	{"type": "function", "name": "store", "constant": false, "inputs": [{"name": "value", "type": "uint256"}], "outputs": []},
+	{"type": "event", "name": "Stored", "inputs": [{"name": "from", "type": "address", "indexed": true}, {"name": "value", "type": "uint256", "indexed": false}]}
+]`
+
+func TestBind(t *testing.T) {
+	code, err := Bind("token", testABI, "0x6000", "tokens")
+	if err != nil {
+		t.Fatalf("bind failed: %v", err)
+	}
+	want := []string{
+		"package tokens",
+		"const TokenABI = `[",
+		"const TokenBin = `0x6000`",
+		"func NewToken(address common.Address, backend bind.ContractBackend) (*Token, error)",
+		"func DeployToken(opts *bind.TransactOpts, backend bind.ContractBackend, owner common.Address) (common.Address, *Token, error)",
+		"func (_Token *Token) Answer(opts *bind.CallOpts) (ret0 *big.Int, err error)",
+		"func (_Token *Token) Lookup(opts *bind.CallOpts, arg0 common.Address, type_ *big.Int) (ret0 bool, ret1 *big.Int, err error)",
+		"func (_Token *Token) Store(opts *bind.TransactOpts, value *big.Int) (common.Hash, error)",
+		"type TokenStored struct",
+		"func (_Token *Token) FilterStored(logs state.Logs) ([]*TokenStored, error)",
+	}
+	for _, s := range want {
+		if !strings.Contains(code, s) {
+			t.Errorf("generated code does not contain %q:\n%s", s, code)
+		}
+	}
+
+	buildBinding(t, code)
+
+	// without bytecode there is no deploy function and no unused imports
+	code, err = Bind("Registry", `[{"name": "enabled", "constant": true, "outputs": [{"type": "bool"}]}]`, "", "registry")
+	if err != nil {
+		t.Fatalf("bind failed: %v", err)
+	}
+	for _, s := range []string{"Deploy", "math/big", "core/state"} {
+		if strings.Contains(code, s) {
+			t.Errorf("generated code should not contain %q:\n%s", s, code)
+		}
+	}
+	buildBinding(t, code)
+}
+
+// buildBinding compiles the generated code with the go tool.
+func buildBinding(t *testing.T, code string) {
+	dir, files := writeSources(t, map[string]string{"binding.go": code})
+	defer os.RemoveAll(dir)
+	goTool(t, append([]string{"build", "-o", os.DevNull}, files...)...)
+}
+
+// runBinding runs the generated code of package main together with the
+// given main function and returns its output.
+func runBinding(t *testing.T, code, main string) string {
+	dir, files := writeSources(t, map[string]string{"binding.go": code, "main.go": main})
+	defer os.RemoveAll(dir)
+	return goTool(t, append([]string{"run"}, files...)...)
+}
+
+// writeSources writes the files to a new temporary directory.
+func writeSources(t *testing.T, files map[string]string) (dir string, paths []string) {
+	dir, err := ioutil.TempDir("", "bind-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, code := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(code), 0600); err != nil {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return dir, paths
+}
+
+// goTool runs the go tool with args and returns its output.
+func goTool(t *testing.T, args ...string) string {
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	out, err := exec.Command(gocmd, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("go %s of the generated code failed: %v\n%s", args[0], err, out)
+	}
+	return string(out)
+}
+
+const packMain = `package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// backend prints the calldata of calls and transactions.
+type backend struct{}
+
+func (backend) Call(from, to, value, gas, gasPrice, data string) (string, error) {
+	fmt.Println(data)
+	return common.ToHex(make([]byte, 64)), nil
+}
+
+func (backend) Transact(from, to, value, gas, gasPrice, data string) (string, error) {
+	fmt.Println(data)
+	return "0x01", nil
+}
+
+func main() {
+	token, err := NewToken(common.Address{1}, backend{})
+	if err != nil {
+		panic(err)
+	}
+	if _, err := token.Store(nil, big.NewInt(42)); err != nil {
+		panic(err)
+	}
+	if _, _, err := token.Lookup(nil, common.Address{2}, big.NewInt(7)); err != nil {
+		panic(err)
+	}
+}
+`
+
+func TestBindPackCall(t *testing.T) {
+	code, err := Bind("token", testABI, "", "main")
+	if err != nil {
+		t.Fatalf("bind failed: %v", err)
+	}
+	out := strings.Fields(runBinding(t, code, packMain))
+
+	addr := common.Address{2}
+	want := []string{
+		common.ToHex(append(crypto.Sha3([]byte("store(uint256)"))[:4], common.LeftPadBytes([]byte{42}, 32)...)),
+		common.ToHex(append(append(crypto.Sha3([]byte("lookup(address,uint8)"))[:4], common.LeftPadBytes(addr[:], 32)...), common.LeftPadBytes([]byte{7}, 32)...)),
+	}
+	if len(out) != len(want) {
+		t.Fatalf("got output %q, want calldata %q", out, want)
+	}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Errorf("calldata %d:\ngot  %s\nwant %s", i, out[i], want[i])
+		}
+	}
+}
+
+func TestBindUnsupportedType(t *testing.T) {
+	for _, typ := range []string{"bool[]", "uint256[]", "int8[2]", "string", "string32"} {
+		_, err := Bind("t", `[{"name": "f", "input": [{"name": "a", "type": "`+typ+`"}]}]`, "", "p")
+		if err == nil {
+			t.Errorf("expected error for unsupported type %s", typ)
+		}
+		_, err = Bind("t", `[{"name": "f", "constant": true, "outputs": [{"name": "a", "type": "`+typ+`"}]}]`, "", "p")
+		if err == nil {
+			t.Errorf("expected error for unsupported output type %s", typ)
+		}
+	}
+}
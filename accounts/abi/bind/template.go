@@ -0,0 +1,91 @@
+package bind
+
+import "text/template"
+
+var bindTemplate = template.Must(template.New("binding").Parse(`// This file is generated by abigen. Do not edit.
+
+package {{.Package}}
+
+import (
+	{{if .UsesBig}}"math/big"{{end}}
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	{{if .Events}}"github.com/ethereum/go-ethereum/core/state"{{end}}
+)
+
+// {{.Type}}ABI is the input ABI used to generate the binding from.
+const {{.Type}}ABI = ` + "`{{.InputABI}}`" + `
+
+// {{.Type}} is a Go binding around an Ethereum contract.
+type {{.Type}} struct {
+	contract *bind.BoundContract
+}
+
+// New{{.Type}} creates a new instance of {{.Type}}, bound to a deployed contract.
+func New{{.Type}}(address common.Address, backend bind.ContractBackend) (*{{.Type}}, error) {
+	parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{bind.NewBoundContract(address, parsed, backend)}, nil
+}
+{{if .InputBin}}
+// {{.Type}}Bin is the compiled bytecode used for deploying new contracts.
+const {{.Type}}Bin = ` + "`{{.InputBin}}`" + `
+
+// Deploy{{.Type}} deploys a new contract, binding an instance of {{.Type}} to it.
+func Deploy{{.Type}}(opts *bind.TransactOpts, backend bind.ContractBackend{{range .Constructor.Inputs}}, {{.Name}} {{.Type}}{{end}}) (common.Address, *{{.Type}}, error) {
+	parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	contract, err := bind.DeployContract(opts, parsed, common.FromHex({{.Type}}Bin), backend{{range .Constructor.Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return contract.Address(), &{{.Type}}{contract}, nil
+}
+{{end}}
+{{range .Calls}}
+// {{.Normalized}} is a free data retrieval call binding the contract method {{.Original}}.
+func (_{{$.Type}} *{{$.Type}}) {{.Normalized}}(opts *bind.CallOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) ({{range .Outputs}}{{.Name}} {{.Type}}, {{end}}err error) {
+	{{if .Outputs}}values, err :={{else}}_, err ={{end}} _{{$.Type}}.contract.Call(opts, "{{.Original}}"{{range .Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return
+	}
+	{{range $i, $out := .Outputs}}{{$out.Name}} = values[{{$i}}].({{$out.Type}})
+	{{end}}return
+}
+{{end}}
+{{range .Transacts}}
+// {{.Normalized}} is a paid mutator transaction binding the contract method {{.Original}}.
+func (_{{$.Type}} *{{$.Type}}) {{.Normalized}}(opts *bind.TransactOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) (common.Hash, error) {
+	return _{{$.Type}}.contract.Transact(opts, "{{.Original}}"{{range .Inputs}}, {{.Name}}{{end}})
+}
+{{end}}
+{{range .Events}}
+// {{$.Type}}{{.Normalized}} represents a {{.Original}} event raised by the {{$.Type}} contract.
+type {{$.Type}}{{.Normalized}} struct {
+	{{range .Fields}}{{.Name}} {{.Type}}
+	{{end}}
+}
+
+// Filter{{.Normalized}} returns the {{.Original}} events raised by the contract in logs.
+func (_{{$.Type}} *{{$.Type}}) Filter{{.Normalized}}(logs state.Logs) ([]*{{$.Type}}{{.Normalized}}, error) {
+	values, err := _{{$.Type}}.contract.FilterLogs(logs, "{{.Original}}")
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*{{$.Type}}{{.Normalized}}, len(values))
+	for i, value := range values {
+		events[i] = &{{$.Type}}{{.Normalized}}{ {{range $j, $field := .Fields}}
+			{{$field.Name}}: value[{{$j}}].({{$field.Type}}),{{end}}
+		}
+	}
+	return events, nil
+}
+{{end}}
+`))
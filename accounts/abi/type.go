@@ -134,6 +134,13 @@ func (t Type) String() (out string) {
 // * Integer are checked for size
 // * Strings, addresses and bytes are checks for type and size
 func (t Type) pack(v interface{}) ([]byte, error) {
+	if addr, ok := v.(common.Address); ok {
+		if t.T != AddressTy {
+			return nil, fmt.Errorf("type mismatch: %s for %T", t, v)
+		}
+		return common.LeftPadBytes(addr.Bytes(), 32), nil
+	}
+
 	value := reflect.ValueOf(v)
 	switch kind := value.Kind(); kind {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -0,0 +1,80 @@
+package abi
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Unpack decodes the output of a call to the named method. Numbers are
+// returned as *big.Int, addresses as common.Address. Only types with a
+// fixed size of one word are supported.
+func (abi ABI) Unpack(name string, output []byte) ([]interface{}, error) {
+	method, exist := abi.Methods[name]
+	if !exist {
+		return nil, fmt.Errorf("method '%s' not found", name)
+	}
+	if len(output) < 32*len(method.Outputs) {
+		return nil, fmt.Errorf("`%s` output too short: %d bytes for %d values", name, len(output), len(method.Outputs))
+	}
+	values := make([]interface{}, len(method.Outputs))
+	for i, out := range method.Outputs {
+		value, err := out.Type.unpack(output[i*32 : (i+1)*32])
+		if err != nil {
+			return nil, fmt.Errorf("`%s` %v", name, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Unpack decodes the inputs of the event from the topics and data of a
+// log. The first topic is the event id, the indexed inputs follow it.
+func (e Event) Unpack(topics []common.Hash, data []byte) ([]interface{}, error) {
+	if len(topics) == 0 || topics[0] != e.Id() {
+		return nil, fmt.Errorf("log is not a `%s` event", e.Name)
+	}
+	topics = topics[1:]
+
+	values := make([]interface{}, len(e.Inputs))
+	for i, input := range e.Inputs {
+		var word []byte
+		if input.Indexed {
+			if len(topics) == 0 {
+				return nil, fmt.Errorf("`%s` missing topic for %s", e.Name, input.Name)
+			}
+			word, topics = topics[0].Bytes(), topics[1:]
+		} else {
+			if len(data) < 32 {
+				return nil, fmt.Errorf("`%s` data too short for %s", e.Name, input.Name)
+			}
+			word, data = data[:32], data[32:]
+		}
+		value, err := input.Type.unpack(word)
+		if err != nil {
+			return nil, fmt.Errorf("`%s` %v", e.Name, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// unpack decodes a single word.
+func (t Type) unpack(word []byte) (interface{}, error) {
+	switch {
+	case t.T == AddressTy:
+		return common.BytesToAddress(word), nil
+	case t.Kind == reflect.Bool:
+		return new(big.Int).SetBytes(word).Sign() != 0, nil
+	case t.Kind == reflect.String:
+		return string(bytes.TrimRight(word, "\x00")), nil
+	case t.Kind == reflect.Ptr && t.T == UintTy:
+		return new(big.Int).SetBytes(word), nil
+	case t.Kind == reflect.Ptr && t.T == IntTy:
+		return common.S256(new(big.Int).SetBytes(word)), nil
+	}
+	return nil, fmt.Errorf("unpacking %s is not supported", t)
+}
@@ -45,17 +45,23 @@ import (
 )
 
 var (
-	ErrLocked = errors.New("account is locked")
-	ErrNoKeys = errors.New("no keys in store")
+	ErrLocked         = errors.New("account is locked")
+	ErrNoKeys         = errors.New("no keys in store")
+	ErrUnknownAccount = errors.New("unknown account")
+	ErrNoAddressBook  = errors.New("no address book")
 )
 
 type Account struct {
 	Address []byte
+	Label   string // set in the address book, may be empty
 }
 
 type Manager struct {
 	keyStore crypto.KeyStore2
 	unlocked map[string]*unlocked
+	cache    *addrCache   // nil if the key directory isn't watched
+	book     *addressBook // nil if accounts can't be labelled
+	wallets  []Wallet
 	mutex    sync.RWMutex
 }
 
@@ -82,7 +88,7 @@ func (am *Manager) HasAccount(addr []byte) bool {
 }
 
 func (am *Manager) Primary() (addr []byte, err error) {
-	addrs, err := am.keyStore.GetKeyAddresses()
+	addrs, err := am.addresses()
 	if os.IsNotExist(err) {
 		return nil, ErrNoKeys
 	} else if err != nil {
@@ -95,10 +101,24 @@ func (am *Manager) Primary() (addr []byte, err error) {
 }
 
 func (am *Manager) DeleteAccount(address []byte, auth string) error {
+	defer am.reload()
 	return am.keyStore.DeleteKey(address, auth)
 }
 
+// Update reencrypts the key of the account with a new passphrase. Keys
+// are also reencrypted with the scrypt parameters of the key store.
+func (am *Manager) Update(addr []byte, auth, newAuth string) error {
+	key, err := am.keyStore.GetKey(addr, auth)
+	if err != nil {
+		return err
+	}
+	return am.keyStore.StoreKey(key, newAuth)
+}
+
 func (am *Manager) Sign(a Account, toSign []byte) (signature []byte, err error) {
+	if w := am.findWallet(a.Address); w != nil {
+		return w.SignHash(a, toSign)
+	}
 	am.mutex.RLock()
 	unlockedKey, found := am.unlocked[string(a.Address)]
 	am.mutex.RUnlock()
@@ -109,6 +129,20 @@ func (am *Manager) Sign(a Account, toSign []byte) (signature []byte, err error)
 	return signature, err
 }
 
+// SignWithPassphrase signs toSign with the key of a, decrypting it with
+// auth. The account is not unlocked.
+func (am *Manager) SignWithPassphrase(a Account, auth string, toSign []byte) ([]byte, error) {
+	if w := am.findWallet(a.Address); w != nil {
+		return w.SignHash(a, toSign)
+	}
+	key, err := am.keyStore.GetKey(a.Address, auth)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key.PrivateKey)
+	return crypto.Sign(toSign, key.PrivateKey)
+}
+
 // TimedUnlock unlocks the account with the given address.
 // When timeout has passed, the account will be locked again.
 func (am *Manager) TimedUnlock(addr []byte, keyAuth string, timeout time.Duration) error {
@@ -138,23 +172,36 @@ func (am *Manager) NewAccount(auth string) (Account, error) {
 	if err != nil {
 		return Account{}, err
 	}
+	am.reload()
 	return Account{Address: key.Address}, nil
 }
 
+// Accounts returns the accounts of the key store in the order their keys
+// were created, followed by the accounts of the wallets.
 func (am *Manager) Accounts() ([]Account, error) {
-	addresses, err := am.keyStore.GetKeyAddresses()
-	if os.IsNotExist(err) {
-		return nil, ErrNoKeys
-	} else if err != nil {
+	addresses, err := am.addresses()
+	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	walletAccounts := am.walletAccounts()
+	if err != nil && len(walletAccounts) == 0 {
+		return nil, ErrNoKeys
+	}
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
 	accounts := make([]Account, len(addresses))
 	for i, addr := range addresses {
 		accounts[i] = Account{
 			Address: addr,
 		}
 	}
-	return accounts, err
+	accounts = append(accounts, walletAccounts...)
+	if am.book != nil {
+		for i := range accounts {
+			accounts[i].Label = am.book.label(accounts[i].Address)
+		}
+	}
+	return accounts, nil
 }
 
 func (am *Manager) addUnlocked(addr []byte, key *crypto.Key) *unlocked {
@@ -221,6 +268,7 @@ func (am *Manager) Import(path string, keyAuth string) (Account, error) {
 	if err = am.keyStore.StoreKey(key, keyAuth); err != nil {
 		return Account{}, err
 	}
+	am.reload()
 	return Account{Address: key.Address}, nil
 }
 
@@ -233,5 +281,6 @@ func (am *Manager) ImportPreSaleKey(keyJSON []byte, password string) (acc Accoun
 	if err = am.keyStore.StoreKey(key, password); err != nil {
 		return
 	}
+	am.reload()
 	return Account{Address: key.Address}, nil
 }
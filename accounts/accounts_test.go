@@ -1,8 +1,11 @@
 package accounts
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -67,3 +70,113 @@ func tmpKeyStore(t *testing.T, new func(string) crypto.KeyStore2) (string, crypt
 	}
 	return d, new(d)
 }
+
+func TestWatchKeyDir(t *testing.T) {
+	testWatchKeyDir(t, false)
+}
+
+func TestWatchKeyDirPolling(t *testing.T) {
+	testWatchKeyDir(t, true)
+}
+
+func testWatchKeyDir(t *testing.T, polling bool) {
+	dir, ks := tmpKeyStore(t, crypto.NewKeyStorePlain)
+	defer os.RemoveAll(dir)
+
+	am := NewManager(ks)
+	if polling {
+		defer func(d time.Duration) { keyDirPollInterval = d }(keyDirPollInterval)
+		keyDirPollInterval = 10 * time.Millisecond
+		am.cache = &addrCache{quit: make(chan struct{})}
+		am.reload()
+		go am.poll(am.cache.quit)
+	} else {
+		am.Watch(dir)
+	}
+	defer am.Close()
+
+	if accounts, _ := am.Accounts(); len(accounts) != 0 {
+		t.Fatalf("expected no accounts, got %d", len(accounts))
+	}
+	// a key written by another program
+	key, err := crypto.NewKeyStorePlain(dir).GenerateNewKey(crand.Reader, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; !am.HasAccount(key.Address); i++ {
+		if i == 100 {
+			t.Fatal("new key not picked up")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	os.RemoveAll(filepath.Join(dir, hex.EncodeToString(key.Address)))
+	for i := 0; am.HasAccount(key.Address); i++ {
+		if i == 100 {
+			t.Fatal("deleted key still listed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestAccountLabels(t *testing.T) {
+	dir, ks := tmpKeyStore(t, crypto.NewKeyStorePlain)
+	defer os.RemoveAll(dir)
+	bookPath := filepath.Join(dir, "addressbook.json")
+
+	am := NewManager(ks)
+	a1, _ := am.NewAccount("")
+	if err := am.SetLabel(a1.Address, "foo"); err != ErrNoAddressBook {
+		t.Fatalf("expected ErrNoAddressBook, got %v", err)
+	}
+	if err := am.SetAddressBook(bookPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.SetLabel([]byte{1}, "foo"); err != ErrUnknownAccount {
+		t.Fatalf("expected ErrUnknownAccount, got %v", err)
+	}
+	if err := am.SetLabel(a1.Address, "savings"); err != nil {
+		t.Fatal(err)
+	}
+
+	// labels survive reloading the address book
+	am = NewManager(ks)
+	if err := am.SetAddressBook(bookPath); err != nil {
+		t.Fatal(err)
+	}
+	accts, err := am.Accounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accts) != 1 || accts[0].Label != "savings" {
+		t.Fatalf("wrong accounts %v", accts)
+	}
+
+	am.SetLabel(a1.Address, "")
+	if accts, _ := am.Accounts(); accts[0].Label != "" {
+		t.Errorf("label not removed: %q", accts[0].Label)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir, ks := tmpKeyStore(t, crypto.NewKeyStorePassphrase)
+	defer os.RemoveAll(dir)
+
+	am := NewManager(ks)
+	a1, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.Update(a1.Address, "bar", "baz"); err == nil {
+		t.Error("updated with wrong passphrase")
+	}
+	if err := am.Update(a1.Address, "foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.Unlock(a1.Address, "foo"); err == nil {
+		t.Error("old passphrase still unlocks")
+	}
+	if err := am.Unlock(a1.Address, "bar"); err != nil {
+		t.Errorf("new passphrase doesn't unlock: %v", err)
+	}
+}
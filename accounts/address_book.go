@@ -0,0 +1,82 @@
+package accounts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// addressBook holds the labels of accounts. It contains no key material
+// and is kept outside of the key directory.
+type addressBook struct {
+	path   string
+	labels map[string]string // hex address -> label
+}
+
+func loadAddressBook(path string) (*addressBook, error) {
+	book := &addressBook{path: path, labels: make(map[string]string)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return book, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &book.labels); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (book *addressBook) label(addr []byte) string {
+	return book.labels[hex.EncodeToString(addr)]
+}
+
+// setLabel sets the label of addr, removing it if label is empty, and
+// writes the address book to disk.
+func (book *addressBook) setLabel(addr []byte, label string) error {
+	if label == "" {
+		delete(book.labels, hex.EncodeToString(addr))
+	} else {
+		book.labels[hex.EncodeToString(addr)] = label
+	}
+	data, err := json.MarshalIndent(book.labels, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first so a crash can't truncate the book
+	tmp := book.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, book.path)
+}
+
+// SetAddressBook loads the account labels from the file at path, which is
+// created once a label is set. Without an address book accounts can't be
+// labelled.
+func (am *Manager) SetAddressBook(path string) error {
+	book, err := loadAddressBook(path)
+	if err != nil {
+		return err
+	}
+	am.mutex.Lock()
+	am.book = book
+	am.mutex.Unlock()
+	return nil
+}
+
+// SetLabel sets the label of the account with the given address. An empty
+// label removes it.
+func (am *Manager) SetLabel(addr []byte, label string) error {
+	if !am.HasAccount(addr) {
+		return ErrUnknownAccount
+	}
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	if am.book == nil {
+		return ErrNoAddressBook
+	}
+	return am.book.setLabel(addr, label)
+}
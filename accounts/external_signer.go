@@ -0,0 +1,278 @@
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+const (
+	// signerListTimeout limits the time to wait for the account list.
+	// Signing requests have no timeout as they may wait for the user.
+	signerListTimeout = 5 * time.Second
+
+	// signerListRefresh is how long the account list is cached.
+	signerListRefresh = 3 * time.Second
+)
+
+var ErrDenied = errors.New("request denied by the signer")
+
+/*
+ExternalSigner is a Wallet which forwards signing requests to a separate
+signer process, so the node holds no key material. The signer listens on a
+unix socket and answers JSON-RPC 2.0 requests, one per line:
+
+	account_list                    -> ["0x<address>", ...]
+	account_signTransaction(tx)     -> {"approved": true, "signature": "0x<65 bytes>"}
+	account_signHash(address, hash) -> {"approved": false, "reason": "..."}
+
+Transactions are sent with all their fields so the signer can show them to
+the user before approving. Each signing request uses its own connection so
+that requests waiting for approval don't block others.
+*/
+type ExternalSigner struct {
+	path string
+
+	mu       sync.Mutex
+	conn     *signerConn // used for account_list
+	accounts []Account
+	listed   time.Time // time of the last account_list
+}
+
+// signerConn is a connection to the signer.
+type signerConn struct {
+	net.Conn
+	enc *json.Encoder
+	dec *json.Decoder
+	id  uint64
+}
+
+type signerRequest struct {
+	Id      uint64        `json:"id"`
+	Jsonrpc string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type signerResponse struct {
+	Id     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SignerTx is a transaction as sent to the signer.
+type SignerTx struct {
+	From     string  `json:"from"`
+	To       *string `json:"to"` // nil for contract creation
+	Nonce    uint64  `json:"nonce"`
+	GasPrice string  `json:"gasPrice"` // decimal
+	Gas      string  `json:"gas"`
+	Value    string  `json:"value"`
+	Data     string  `json:"data"`
+}
+
+// signerResult is the answer of the signer to a signing request.
+type signerResult struct {
+	Approved  bool   `json:"approved"`
+	Signature string `json:"signature"`
+	Reason    string `json:"reason"`
+}
+
+// NewExternalSigner connects to the signer listening on the unix socket at
+// path and loads its accounts.
+func NewExternalSigner(path string) (*ExternalSigner, error) {
+	conn, err := dialSigner(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &ExternalSigner{path: path, conn: conn}
+	if err := s.refresh(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewSignerManager creates a manager which holds no keys and signs with the
+// accounts of signer.
+func NewSignerManager(signer Wallet) *Manager {
+	am := NewManager(noKeyStore{})
+	am.AddWallet(signer)
+	return am
+}
+
+func (s *ExternalSigner) URL() string {
+	return "ipc://" + s.path
+}
+
+// Accounts returns the accounts of the signer. The list is reloaded at most
+// every signerListRefresh, the last known one is kept if the signer can't be
+// reached.
+func (s *ExternalSigner) Accounts() []Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.listed) >= signerListRefresh {
+		if err := s.refresh(); err != nil {
+			glog.V(logger.Debug).Infoln(err)
+		}
+	}
+	return append([]Account(nil), s.accounts...)
+}
+
+// Derive is not supported, accounts are managed by the signer.
+func (s *ExternalSigner) Derive(path crypto.DerivationPath) (Account, error) {
+	return Account{}, ErrNotSupported
+}
+
+func (s *ExternalSigner) SignHash(acct Account, hash []byte) ([]byte, error) {
+	return s.sign("account_signHash", common.ToHex(acct.Address), common.ToHex(hash))
+}
+
+func (s *ExternalSigner) SignTx(acct Account, tx *types.Transaction) ([]byte, error) {
+	req := SignerTx{
+		From:     common.ToHex(acct.Address),
+		Nonce:    tx.Nonce(),
+		GasPrice: tx.GasPrice().String(),
+		Gas:      tx.Gas().String(),
+		Value:    tx.Value().String(),
+		Data:     common.ToHex(tx.Data()),
+	}
+	if to := tx.To(); to != nil {
+		hex := to.Hex()
+		req.To = &hex
+	}
+	return s.sign("account_signTransaction", req)
+}
+
+func (s *ExternalSigner) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// sign sends a signing request on a new connection and waits for the
+// approval of the signer.
+func (s *ExternalSigner) sign(method string, params ...interface{}) ([]byte, error) {
+	conn, err := dialSigner(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var result signerResult
+	if err := conn.call(time.Time{}, &result, method, params...); err != nil {
+		return nil, err
+	}
+	if !result.Approved {
+		if result.Reason != "" {
+			return nil, fmt.Errorf("%v: %s", ErrDenied, result.Reason)
+		}
+		return nil, ErrDenied
+	}
+	sig := common.FromHex(result.Signature)
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("external signer: invalid signature length %d", len(sig))
+	}
+	return sig, nil
+}
+
+// refresh reloads the account list. The connection is reestablished if it
+// broke. It must be called with s.mu held, except in NewExternalSigner.
+func (s *ExternalSigner) refresh() error {
+	s.listed = time.Now()
+	if s.conn == nil {
+		conn, err := dialSigner(s.path)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	var addrs []string
+	if err := s.conn.call(time.Now().Add(signerListTimeout), &addrs, "account_list"); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	accts := make([]Account, len(addrs))
+	for i, addr := range addrs {
+		accts[i] = Account{Address: common.FromHex(addr)}
+	}
+	s.accounts = accts
+	return nil
+}
+
+func dialSigner(path string) (*signerConn, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("external signer: %v", err)
+	}
+	return &signerConn{Conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}, nil
+}
+
+// call sends a request and decodes the result into result. The connection
+// must not be used anymore if sending or receiving failed.
+func (c *signerConn) call(deadline time.Time, result interface{}, method string, params ...interface{}) error {
+	c.id++
+	if params == nil {
+		params = []interface{}{}
+	}
+	c.SetDeadline(deadline)
+	resp, err := c.roundTrip(&signerRequest{Id: c.id, Jsonrpc: "2.0", Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("external signer: %v", err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("external signer: %s (code %d)", resp.Error.Message, resp.Error.Code)
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+func (c *signerConn) roundTrip(req *signerRequest) (*signerResponse, error) {
+	if err := c.enc.Encode(req); err != nil {
+		return nil, err
+	}
+	for {
+		var resp signerResponse
+		if err := c.dec.Decode(&resp); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		// skip answers to requests which timed out
+		if resp.Id == req.Id {
+			return &resp, nil
+		}
+	}
+}
+
+// noKeyStore is the key store of a manager which holds no keys.
+type noKeyStore struct{}
+
+var errNoKeyStore = errors.New("keys are held by the external signer")
+
+func (noKeyStore) GenerateNewKey(io.Reader, string) (*crypto.Key, error) { return nil, errNoKeyStore }
+func (noKeyStore) GetKey([]byte, string) (*crypto.Key, error)            { return nil, errNoKeyStore }
+func (noKeyStore) GetKeyAddresses() ([][]byte, error)                    { return nil, nil }
+func (noKeyStore) StoreKey(*crypto.Key, string) error                    { return errNoKeyStore }
+func (noKeyStore) DeleteKey([]byte, string) error                        { return errNoKeyStore }
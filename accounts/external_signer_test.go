@@ -0,0 +1,225 @@
+package accounts
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeSigner serves the signer protocol with a single key.
+type fakeSigner struct {
+	key  *ecdsa.PrivateKey
+	hold chan struct{} // if set, approvals wait until it's closed
+
+	mu   sync.Mutex // protects deny and txs, set and read by the test
+	deny bool
+	txs  []SignerTx
+}
+
+func (s *fakeSigner) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSigner) handle(conn net.Conn) {
+	defer conn.Close()
+	in, out := bufio.NewReader(conn), json.NewEncoder(conn)
+	for {
+		var req struct {
+			Id     uint64
+			Method string
+			Params []json.RawMessage
+		}
+		line, err := in.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		if err := json.Unmarshal(line, &req); err != nil {
+			return
+		}
+		var result interface{}
+		switch req.Method {
+		case "account_list":
+			result = []string{common.ToHex(crypto.PubkeyToAddress(s.key.PublicKey))}
+		case "account_signTransaction":
+			var tx SignerTx
+			json.Unmarshal(req.Params[0], &tx)
+			s.mu.Lock()
+			s.txs = append(s.txs, tx)
+			s.mu.Unlock()
+			price, _ := new(big.Int).SetString(tx.GasPrice, 10)
+			gas, _ := new(big.Int).SetString(tx.Gas, 10)
+			value, _ := new(big.Int).SetString(tx.Value, 10)
+			unsigned := types.NewTransactionMessage(common.HexToAddress(*tx.To), value, gas, price, common.FromHex(tx.Data))
+			unsigned.SetNonce(tx.Nonce)
+			result = s.sign(unsigned.Hash().Bytes())
+		case "account_signHash":
+			var hash string
+			json.Unmarshal(req.Params[1], &hash)
+			result = s.sign(common.FromHex(hash))
+		}
+		out.Encode(map[string]interface{}{"id": req.Id, "jsonrpc": "2.0", "result": result})
+	}
+}
+
+func (s *fakeSigner) sign(hash []byte) signerResult {
+	if s.hold != nil {
+		<-s.hold
+	}
+	s.mu.Lock()
+	deny := s.deny
+	s.mu.Unlock()
+	if deny {
+		return signerResult{Reason: "no"}
+	}
+	sig, _ := crypto.Sign(hash, s.key)
+	return signerResult{Approved: true, Signature: common.ToHex(sig)}
+}
+
+// startFakeSigner serves fake on a unix socket in dir.
+func startFakeSigner(t *testing.T, dir string, fake *fakeSigner) (socket string, l net.Listener) {
+	socket = filepath.Join(dir, "signer.ipc")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go fake.serve(l)
+	return socket, l
+}
+
+func TestExternalSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	key, _ := crypto.GenerateKey()
+	fake := &fakeSigner{key: key}
+	socket, l := startFakeSigner(t, dir, fake)
+	defer l.Close()
+
+	signer, err := NewExternalSigner(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	am := NewSignerManager(signer)
+	defer am.Close()
+
+	accts, err := am.Accounts()
+	if err != nil || len(accts) != 1 {
+		t.Fatalf("signer account not listed: %v %v", accts, err)
+	}
+	acct := accts[0]
+	if !bytes.Equal(acct.Address, crypto.PubkeyToAddress(key.PublicKey)) {
+		t.Fatalf("wrong address %x", acct.Address)
+	}
+	if _, err := am.NewAccount("pass"); err == nil {
+		t.Error("manager without keys created an account")
+	}
+
+	tx := types.NewTransactionMessage(common.Address{1}, big.NewInt(2), big.NewInt(21000), big.NewInt(3), []byte{4})
+	tx.SetNonce(5)
+	sig, err := am.SignTx(acct, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetSignatureValues(sig)
+	if from, err := tx.From(); err != nil || !bytes.Equal(from.Bytes(), acct.Address) {
+		t.Errorf("wrong sender %x (%v)", from, err)
+	}
+	want := SignerTx{From: common.ToHex(acct.Address), Nonce: 5, GasPrice: "3", Gas: "21000", Value: "2", Data: "0x04"}
+	fake.mu.Lock()
+	got := fake.txs[0]
+	fake.mu.Unlock()
+	if got.From != want.From || got.Nonce != want.Nonce || got.GasPrice != want.GasPrice || got.Gas != want.Gas || got.Value != want.Value || got.Data != want.Data {
+		t.Errorf("signer got tx %+v, want %+v", got, want)
+	}
+
+	hash := crypto.Sha3([]byte("foo"))
+	if sig, err = am.Sign(acct, hash); err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := crypto.Ecrecover(hash, sig); err != nil || !bytes.Equal(crypto.Sha3(pub[1:])[12:], acct.Address) {
+		t.Errorf("wrong hash signature (%v)", err)
+	}
+
+	fake.mu.Lock()
+	fake.deny = true
+	fake.mu.Unlock()
+	if _, err := am.SignTx(acct, tx); err == nil || err.Error() != ErrDenied.Error()+": no" {
+		t.Errorf("expected denial, got %v", err)
+	}
+}
+
+// Tests that a signing request waiting for approval doesn't block the
+// account list or other requests.
+func TestExternalSignerPendingApproval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	key, _ := crypto.GenerateKey()
+	fake := &fakeSigner{key: key, hold: make(chan struct{})}
+	socket, l := startFakeSigner(t, dir, fake)
+	defer l.Close()
+
+	signer, err := NewExternalSigner(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	am := NewSignerManager(signer)
+	defer am.Close()
+	acct := signer.Accounts()[0]
+
+	signed := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := am.Sign(acct, crypto.Sha3([]byte("foo")))
+			signed <- err
+		}()
+	}
+	listed := make(chan int)
+	go func() {
+		accts, _ := am.Accounts()
+		listed <- len(accts)
+	}()
+	select {
+	case n := <-listed:
+		if n != 1 {
+			t.Errorf("listed %d accounts, want 1", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("account list blocked by pending approval")
+	}
+
+	close(fake.hold)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-signed:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("signing request not answered")
+		}
+	}
+}
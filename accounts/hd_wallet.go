@@ -0,0 +1,276 @@
+package accounts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// hdDiscoveryGap is the number of consecutive unused accounts after which
+// discovery stops.
+const hdDiscoveryGap = 20
+
+var (
+	ErrHDImport      = errors.New("keys can't be imported into an HD wallet")
+	ErrHDWalletExist = errors.New("HD wallet already exists")
+	ErrNotHDWallet   = errors.New("accounts are not in an HD wallet")
+)
+
+/*
+HDWallet is a key store which derives all keys from a single seed following
+BIP-32, so backing up the BIP-39 mnemonic of the seed backs up every
+account. It can be used by a Manager in place of the file key store.
+
+The wallet file holds the seed encrypted with the wallet passphrase, the
+derivation path and the addresses of the derived accounts. Keys are only
+derived when an account is created or unlocked, addresses of accounts the
+wallet doesn't know yet are found with Discover.
+*/
+type HDWallet struct {
+	file string
+
+	mu        sync.Mutex
+	path      crypto.DerivationPath
+	seed      []byte   // encrypted
+	addresses [][]byte // of the derived accounts, by index
+}
+
+type hdWalletJSON struct {
+	Path     string          `json:"path"`
+	Seed     json.RawMessage `json:"seed"`
+	Accounts []string        `json:"accounts"`
+}
+
+// NewHDWallet creates the wallet file for the seed of mnemonic. The seed
+// is derived with mnemonicPass and encrypted with auth. Accounts are
+// derived below path, DefaultHDPath if empty.
+func NewHDWallet(file, mnemonic, mnemonicPass, path, auth string) (*HDWallet, error) {
+	if _, err := crypto.MnemonicToEntropy(mnemonic); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = crypto.DefaultHDPath
+	}
+	dpath, err := crypto.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(file); err == nil {
+		return nil, ErrHDWalletExist
+	}
+	seed, err := crypto.EncryptPassphrase(crypto.MnemonicSeed(mnemonic, mnemonicPass), auth)
+	if err != nil {
+		return nil, err
+	}
+	w := &HDWallet{file: file, path: dpath, seed: seed}
+	if err := w.save(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// OpenHDWallet loads the wallet file.
+func OpenHDWallet(file string) (*HDWallet, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var enc hdWalletJSON
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, err
+	}
+	w := &HDWallet{file: file, seed: enc.Seed}
+	if w.path, err = crypto.ParseDerivationPath(enc.Path); err != nil {
+		return nil, err
+	}
+	for _, addr := range enc.Accounts {
+		if addr == "" { // deleted
+			w.addresses = append(w.addresses, nil)
+			continue
+		}
+		a, err := hex.DecodeString(addr)
+		if err != nil {
+			return nil, err
+		}
+		w.addresses = append(w.addresses, a)
+	}
+	return w, nil
+}
+
+// Path returns the derivation path of the accounts.
+func (w *HDWallet) Path() string {
+	return w.path.String()
+}
+
+// GenerateNewKey derives the account following the last known one. The
+// entropy source is not used.
+func (w *HDWallet) GenerateNewKey(rand io.Reader, auth string) (*crypto.Key, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	master, err := w.master(auth)
+	if err != nil {
+		return nil, err
+	}
+	key, err := w.derive(master, uint32(len(w.addresses)))
+	if err != nil {
+		return nil, err
+	}
+	w.addresses = append(w.addresses, key.Address)
+	if err := w.save(); err != nil {
+		w.addresses = w.addresses[:len(w.addresses)-1]
+		return nil, err
+	}
+	return key, nil
+}
+
+func (w *HDWallet) GetKey(addr []byte, auth string) (*crypto.Key, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	index := w.index(addr)
+	if index < 0 {
+		return nil, ErrUnknownAccount
+	}
+	master, err := w.master(auth)
+	if err != nil {
+		return nil, err
+	}
+	return w.derive(master, uint32(index))
+}
+
+func (w *HDWallet) GetKeyAddresses() ([][]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	addrs := make([][]byte, 0, len(w.addresses))
+	for _, addr := range w.addresses {
+		if addr != nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, nil
+}
+
+func (w *HDWallet) StoreKey(key *crypto.Key, auth string) error {
+	return ErrHDImport
+}
+
+// DeleteKey removes the account from the wallet. The key itself can't be
+// deleted as it is derived from the seed, the index of the account is not
+// reused.
+func (w *HDWallet) DeleteKey(addr []byte, auth string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	index := w.index(addr)
+	if index < 0 {
+		return ErrUnknownAccount
+	}
+	if _, err := w.master(auth); err != nil {
+		return err
+	}
+	w.addresses[index] = nil
+	return w.save()
+}
+
+// Discover derives the accounts following the known ones and adds those up
+// to the last one for which used returns true. It stops after
+// hdDiscoveryGap unused accounts in a row and returns the number of added
+// accounts.
+func (w *HDWallet) Discover(auth string, used func(addr []byte) bool) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	master, err := w.master(auth)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		next    = uint32(len(w.addresses))
+		pending [][]byte
+		added   int
+	)
+	for gap := 0; gap < hdDiscoveryGap; next++ {
+		key, err := w.derive(master, next)
+		if err != nil {
+			return added, err
+		}
+		pending = append(pending, key.Address)
+		if !used(key.Address) {
+			gap++
+			continue
+		}
+		w.addresses = append(w.addresses, pending...)
+		added += len(pending)
+		pending, gap = nil, 0
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, w.save()
+}
+
+// DiscoverAccounts adds the used accounts of an HD wallet key store, see
+// HDWallet.Discover.
+func (am *Manager) DiscoverAccounts(auth string, used func(addr []byte) bool) (int, error) {
+	w, ok := am.keyStore.(*HDWallet)
+	if !ok {
+		return 0, ErrNotHDWallet
+	}
+	added, err := w.Discover(auth, used)
+	am.reload()
+	return added, err
+}
+
+// master decrypts the seed and derives the key of the account path.
+func (w *HDWallet) master(auth string) (*crypto.HDKey, error) {
+	seed, err := crypto.DecryptPassphrase(w.seed, auth)
+	if err != nil {
+		return nil, err
+	}
+	master, err := crypto.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return master.Derive(w.path)
+}
+
+func (w *HDWallet) derive(master *crypto.HDKey, index uint32) (*crypto.Key, error) {
+	child, err := master.Child(index)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewKeyFromECDSA(child.PrivateKey()), nil
+}
+
+func (w *HDWallet) index(addr []byte) int {
+	for i, a := range w.addresses {
+		if a != nil && string(a) == string(addr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *HDWallet) save() error {
+	enc := hdWalletJSON{Path: w.path.String(), Seed: w.seed, Accounts: []string{}}
+	for _, addr := range w.addresses {
+		enc.Accounts = append(enc.Accounts, hex.EncodeToString(addr))
+	}
+	data, err := json.MarshalIndent(enc, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := w.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, w.file)
+}
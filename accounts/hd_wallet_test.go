@@ -0,0 +1,85 @@
+package accounts
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestHDWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdwallet-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "hdwallet.json")
+
+	if _, err := NewHDWallet(file, "abandon abandon", "", "", "pass"); err == nil {
+		t.Fatal("expected error for invalid mnemonic")
+	}
+	w, err := NewHDWallet(file, testMnemonic, "", "", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHDWallet(file, testMnemonic, "", "", "pass"); err != ErrHDWalletExist {
+		t.Fatalf("expected ErrHDWalletExist, got %v", err)
+	}
+
+	am := NewManager(w)
+	acct, err := am.NewAccount("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// first account of the default path
+	if addr := hex.EncodeToString(acct.Address); addr != "9858effd232b4033e47d90003d41ec34ecaeda94" {
+		t.Errorf("wrong first address %s", addr)
+	}
+	if _, err := am.NewAccount("wrong"); err == nil {
+		t.Error("expected error for wrong passphrase")
+	}
+	if err := w.StoreKey(nil, "pass"); err != ErrHDImport {
+		t.Errorf("expected ErrHDImport, got %v", err)
+	}
+
+	// the accounts are restored from the wallet file
+	w, err = OpenHDWallet(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	am = NewManager(w)
+	if err := am.Unlock(acct.Address, "pass"); err != nil {
+		t.Fatalf("unlock error: %v", err)
+	}
+	if _, err := am.Sign(acct, make([]byte, 32)); err != nil {
+		t.Errorf("sign error: %v", err)
+	}
+
+	// discovery adds accounts up to the last used one
+	var derived [][]byte
+	added, err := am.DiscoverAccounts("pass", func(addr []byte) bool {
+		derived = append(derived, addr)
+		return len(derived) == 3
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 3 || len(derived) != 3+hdDiscoveryGap {
+		t.Errorf("added %d accounts, derived %d", added, len(derived))
+	}
+	accts, _ := am.Accounts()
+	if len(accts) != 4 {
+		t.Fatalf("expected 4 accounts, got %d", len(accts))
+	}
+
+	if err := am.DeleteAccount(accts[1].Address, "pass"); err != nil {
+		t.Fatal(err)
+	}
+	w, _ = OpenHDWallet(file)
+	if addrs, _ := w.GetKeyAddresses(); len(addrs) != 3 {
+		t.Errorf("expected 3 accounts after delete, got %d", len(addrs))
+	}
+}
@@ -0,0 +1,66 @@
+package accounts
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// findHIDDevices returns the hidraw device files of the first interface of
+// the USB devices of the given vendor.
+func findHIDDevices(vendorID uint16) ([]string, error) {
+	uevents, err := filepath.Glob("/sys/class/hidraw/hidraw*/device/uevent")
+	if err != nil {
+		return nil, err
+	}
+	// HID_ID is bus:vendor:product, USB is bus 3
+	id := fmt.Sprintf("HID_ID=0003:%08X:", vendorID)
+
+	var devices []string
+	for _, uevent := range uevents {
+		data, err := ioutil.ReadFile(uevent)
+		if err != nil {
+			continue
+		}
+		var vendor, first bool
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, id) {
+				vendor = true
+			}
+			if strings.HasPrefix(line, "HID_PHYS=") && strings.HasSuffix(line, "/input0") {
+				first = true
+			}
+		}
+		if vendor && first {
+			name := filepath.Base(filepath.Dir(filepath.Dir(uevent)))
+			devices = append(devices, filepath.Join("/dev", name))
+		}
+	}
+	return devices, nil
+}
+
+// hidrawDevice reads and writes HID reports of a hidraw device file.
+type hidrawDevice struct {
+	*os.File
+}
+
+func openHIDDevice(path string) (io.ReadWriteCloser, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return hidrawDevice{f}, nil
+}
+
+// Write prepends the report ID, which is zero for devices without numbered
+// reports.
+func (d hidrawDevice) Write(report []byte) (int, error) {
+	n, err := d.File.Write(append([]byte{0}, report...))
+	if n > 0 {
+		n--
+	}
+	return n, err
+}
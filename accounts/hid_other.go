@@ -0,0 +1,17 @@
+// +build !linux
+
+package accounts
+
+import (
+	"errors"
+	"io"
+)
+
+// USB devices are only enumerated on Linux.
+func findHIDDevices(vendorID uint16) ([]string, error) {
+	return nil, nil
+}
+
+func openHIDDevice(path string) (io.ReadWriteCloser, error) {
+	return nil, errors.New("USB HID devices are not supported on this platform")
+}
@@ -0,0 +1,241 @@
+package accounts
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Ledger HID transport
+const (
+	ledgerVendorID   = 0x2c97
+	ledgerChannel    = 0x0101
+	ledgerTag        = 0x05
+	ledgerPacketSize = 64
+)
+
+// Instructions of the Ledger ethereum app
+const (
+	ledgerCla          = 0xe0
+	ledgerInsGetAddr   = 0x02
+	ledgerInsSignTx    = 0x04
+	ledgerP1FirstChunk = 0x00
+	ledgerP1MoreChunks = 0x80
+	ledgerMaxChunk     = 255
+)
+
+var errLedgerReply = errors.New("ledger: invalid reply")
+
+/*
+LedgerWallet is a Wallet backed by a Ledger device running the ethereum
+app. Commands are sent as APDUs framed into 64 byte HID reports. The user
+has to confirm every transaction on the device, which signs the RLP of the
+unsigned transaction.
+*/
+type LedgerWallet struct {
+	url string
+	dev io.ReadWriteCloser // HID reports without report ID
+
+	mu       sync.Mutex
+	accounts []Account
+	paths    map[string]crypto.DerivationPath // address -> derivation path
+}
+
+// NewLedgerWallet creates a wallet talking to the device dev.
+func NewLedgerWallet(url string, dev io.ReadWriteCloser) *LedgerWallet {
+	return &LedgerWallet{url: url, dev: dev, paths: make(map[string]crypto.DerivationPath)}
+}
+
+// OpenLedgers opens the connected Ledger devices. It returns no wallets on
+// platforms where USB devices can't be enumerated.
+func OpenLedgers() ([]*LedgerWallet, error) {
+	paths, err := findHIDDevices(ledgerVendorID)
+	if err != nil {
+		return nil, err
+	}
+	var wallets []*LedgerWallet
+	for _, path := range paths {
+		dev, err := openHIDDevice(path)
+		if err != nil {
+			if os.IsPermission(err) {
+				err = fmt.Errorf("%v (check the udev rules of the device)", err)
+			}
+			return wallets, err
+		}
+		wallets = append(wallets, NewLedgerWallet("ledger://"+path, dev))
+	}
+	return wallets, nil
+}
+
+func (w *LedgerWallet) URL() string {
+	return w.url
+}
+
+func (w *LedgerWallet) Accounts() []Account {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]Account(nil), w.accounts...)
+}
+
+func (w *LedgerWallet) Derive(path crypto.DerivationPath) (Account, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	reply, err := w.exchange(ledgerInsGetAddr, 0, 0, encodeLedgerPath(path))
+	if err != nil {
+		return Account{}, err
+	}
+	// public key length, public key, address length, hex address
+	if len(reply) < 1 || len(reply) < 1+int(reply[0])+1 {
+		return Account{}, errLedgerReply
+	}
+	reply = reply[1+int(reply[0]):]
+	if len(reply) < 1+int(reply[0]) {
+		return Account{}, errLedgerReply
+	}
+	addr, err := hex.DecodeString(string(reply[1 : 1+int(reply[0])]))
+	if err != nil || len(addr) != 20 {
+		return Account{}, errLedgerReply
+	}
+	acct := Account{Address: addr}
+	if _, ok := w.paths[string(addr)]; !ok {
+		w.accounts = append(w.accounts, acct)
+		w.paths[string(addr)] = path
+	}
+	return acct, nil
+}
+
+// SignHash is not supported, the ethereum app only signs transactions.
+func (w *LedgerWallet) SignHash(acct Account, hash []byte) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+func (w *LedgerWallet) SignTx(acct Account, tx *types.Transaction) ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	path, ok := w.paths[string(acct.Address)]
+	if !ok {
+		return nil, ErrUnknownAccount
+	}
+	txrlp, err := rlp.EncodeToBytes([]interface{}{
+		tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload := append(encodeLedgerPath(path), txrlp...)
+
+	var reply []byte
+	for p1 := byte(ledgerP1FirstChunk); len(payload) > 0; p1 = ledgerP1MoreChunks {
+		chunk := payload
+		if len(chunk) > ledgerMaxChunk {
+			chunk = chunk[:ledgerMaxChunk]
+		}
+		if reply, err = w.exchange(ledgerInsSignTx, p1, 0, chunk); err != nil {
+			return nil, err
+		}
+		payload = payload[len(chunk):]
+	}
+	// V, R, S
+	if len(reply) != 65 || reply[0] < 27 {
+		return nil, errLedgerReply
+	}
+	sig := append(reply[1:65:65], reply[0]-27)
+	return sig, nil
+}
+
+func (w *LedgerWallet) Close() error {
+	return w.dev.Close()
+}
+
+// encodeLedgerPath encodes path as its length followed by the indexes.
+func encodeLedgerPath(path crypto.DerivationPath) []byte {
+	enc := []byte{byte(len(path))}
+	for _, i := range path {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], i)
+		enc = append(enc, b[:]...)
+	}
+	return enc
+}
+
+// exchange sends an APDU and returns the reply without the status word.
+func (w *LedgerWallet) exchange(ins, p1, p2 byte, data []byte) ([]byte, error) {
+	apdu := append([]byte{ledgerCla, ins, p1, p2, byte(len(data))}, data...)
+	if err := writeLedgerFrames(w.dev, apdu); err != nil {
+		return nil, err
+	}
+	reply, err := readLedgerFrames(w.dev)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) < 2 {
+		return nil, errLedgerReply
+	}
+	switch sw := binary.BigEndian.Uint16(reply[len(reply)-2:]); sw {
+	case 0x9000:
+		return reply[:len(reply)-2], nil
+	case 0x6985:
+		return nil, errors.New("ledger: denied by the user")
+	case 0x6d00, 0x6e00:
+		return nil, errors.New("ledger: the ethereum app is not open")
+	default:
+		return nil, fmt.Errorf("ledger: error status %#x", sw)
+	}
+}
+
+// writeLedgerFrames writes msg in HID reports. Every report starts with
+// the channel, the tag and a sequence number, the first one also holds the
+// length of msg.
+func writeLedgerFrames(dev io.Writer, msg []byte) error {
+	data := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(data, uint16(len(msg)))
+	data = append(data, msg...)
+
+	for seq := uint16(0); len(data) > 0; seq++ {
+		report := make([]byte, ledgerPacketSize)
+		binary.BigEndian.PutUint16(report, ledgerChannel)
+		report[2] = ledgerTag
+		binary.BigEndian.PutUint16(report[3:], seq)
+		n := copy(report[5:], data)
+		if _, err := dev.Write(report); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// readLedgerFrames reads a message written by writeLedgerFrames.
+func readLedgerFrames(dev io.Reader) ([]byte, error) {
+	var (
+		msg    []byte
+		length = -1
+		report = make([]byte, ledgerPacketSize)
+	)
+	for seq := uint16(0); length < 0 || len(msg) < length; seq++ {
+		if _, err := io.ReadFull(dev, report); err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint16(report) != ledgerChannel || report[2] != ledgerTag || binary.BigEndian.Uint16(report[3:]) != seq {
+			return nil, errLedgerReply
+		}
+		data := report[5:]
+		if seq == 0 {
+			length = int(binary.BigEndian.Uint16(data))
+			data = data[2:]
+		}
+		msg = append(msg, data...)
+	}
+	return msg[:length], nil
+}
@@ -0,0 +1,124 @@
+package accounts
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeLedger emulates the ethereum app of a Ledger device with a single key.
+type fakeLedger struct {
+	key     *ecdsa.PrivateKey
+	in, out bytes.Buffer
+	txdata  []byte
+	deny    bool
+}
+
+func (d *fakeLedger) Read(p []byte) (int, error) { return d.out.Read(p) }
+func (d *fakeLedger) Close() error               { return nil }
+
+func (d *fakeLedger) Write(report []byte) (int, error) {
+	if len(report) != ledgerPacketSize {
+		panic("bad report size")
+	}
+	d.in.Write(report)
+	apdu, err := readLedgerFrames(bytes.NewReader(d.in.Bytes()))
+	if err != nil {
+		return len(report), nil // incomplete
+	}
+	d.in.Reset()
+	reply := d.handle(apdu[1], apdu[2], apdu[5:])
+	writeLedgerFrames(&d.out, reply)
+	return len(report), nil
+}
+
+func (d *fakeLedger) handle(ins, p1 byte, data []byte) []byte {
+	switch ins {
+	case ledgerInsGetAddr:
+		pub := crypto.FromECDSAPub(&d.key.PublicKey)
+		addr := hex.EncodeToString(crypto.PubkeyToAddress(d.key.PublicKey))
+		reply := append([]byte{byte(len(pub))}, pub...)
+		reply = append(reply, byte(len(addr)))
+		return append(append(reply, addr...), 0x90, 0x00)
+	case ledgerInsSignTx:
+		if p1 == ledgerP1FirstChunk {
+			data = data[1+4*int(data[0]):] // skip the path
+			d.txdata = nil
+		}
+		d.txdata = append(d.txdata, data...)
+		if d.deny {
+			return []byte{0x69, 0x85}
+		}
+		sig, _ := crypto.Sign(crypto.Sha3(d.txdata), d.key)
+		reply := append([]byte{sig[64] + 27}, sig[:64]...)
+		return append(reply, 0x90, 0x00)
+	}
+	return []byte{0x6d, 0x00}
+}
+
+func TestLedgerFrames(t *testing.T) {
+	msg := make([]byte, 300)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	var buf bytes.Buffer
+	if err := writeLedgerFrames(&buf, msg); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len()%ledgerPacketSize != 0 {
+		t.Fatalf("reports not padded: %d bytes", buf.Len())
+	}
+	got, err := readLedgerFrames(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %x", got)
+	}
+}
+
+func TestLedgerWallet(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	dev := &fakeLedger{key: key}
+	w := NewLedgerWallet("ledger://test", dev)
+
+	path, _ := crypto.ParseDerivationPath(crypto.DefaultHDPath + "/0")
+	acct, err := w.Derive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(acct.Address, crypto.PubkeyToAddress(key.PublicKey)) {
+		t.Fatalf("wrong address %x", acct.Address)
+	}
+
+	am := NewManager(crypto.NewKeyStorePlain("/nonexistent"))
+	am.AddWallet(w)
+	if accts, err := am.Accounts(); err != nil || len(accts) != 1 {
+		t.Fatalf("wallet account not listed: %v %v", accts, err)
+	}
+
+	// a payload spanning several APDUs
+	tx := types.NewTransactionMessage(common.Address{1}, big.NewInt(1), big.NewInt(100000), big.NewInt(1), make([]byte, 600))
+	sig, err := am.SignTx(acct, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetSignatureValues(sig)
+	if from, err := tx.From(); err != nil || !bytes.Equal(from.Bytes(), acct.Address) {
+		t.Errorf("wrong sender %x (%v)", from, err)
+	}
+
+	if _, err := am.Sign(acct, make([]byte, 32)); err != ErrNotSupported {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+	dev.deny = true
+	if _, err := am.SignTx(acct, tx); err == nil {
+		t.Error("expected error for denied transaction")
+	}
+}
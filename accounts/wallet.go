@@ -0,0 +1,89 @@
+package accounts
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var ErrNotSupported = errors.New("not supported by the wallet")
+
+/*
+Wallet is a custodian of keys outside of the key store, such as a hardware
+wallet. Its keys never leave it, the wallet signs on behalf of its accounts.
+Signatures are 65 bytes in the [R || S || V] format of crypto.Sign.
+
+Wallets added to a Manager provide accounts alongside those of the key
+store and are used for signing with them.
+*/
+type Wallet interface {
+	// URL identifies the wallet, e.g. ledger:///dev/hidraw0.
+	URL() string
+
+	// Accounts returns the accounts derived so far.
+	Accounts() []Account
+
+	// Derive derives the account at path and adds it to the accounts of
+	// the wallet.
+	Derive(path crypto.DerivationPath) (Account, error)
+
+	// SignHash signs hash with the key of acct. Wallets which can only sign
+	// transactions return ErrNotSupported.
+	SignHash(acct Account, hash []byte) ([]byte, error)
+
+	// SignTx signs tx with the key of acct. It may block until the user
+	// confirms the transaction on the device.
+	SignTx(acct Account, tx *types.Transaction) ([]byte, error)
+
+	Close() error
+}
+
+// AddWallet adds the accounts of w to the manager.
+func (am *Manager) AddWallet(w Wallet) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	am.wallets = append(am.wallets, w)
+}
+
+// Wallets returns the wallets added to the manager.
+func (am *Manager) Wallets() []Wallet {
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+
+	return append([]Wallet(nil), am.wallets...)
+}
+
+// SignTx signs tx with the key of a. Accounts of wallets are signed by their
+// wallet, others must be unlocked.
+func (am *Manager) SignTx(a Account, tx *types.Transaction) ([]byte, error) {
+	if w := am.findWallet(a.Address); w != nil {
+		return w.SignTx(a, tx)
+	}
+	return am.Sign(a, tx.Hash().Bytes())
+}
+
+// findWallet returns the wallet holding the account with the given address
+// or nil if the account is in the key store.
+func (am *Manager) findWallet(addr []byte) Wallet {
+	// Accounts may talk to the wallet, it's called without holding the lock.
+	for _, w := range am.Wallets() {
+		for _, acct := range w.Accounts() {
+			if bytes.Equal(acct.Address, addr) {
+				return w
+			}
+		}
+	}
+	return nil
+}
+
+// walletAccounts returns the accounts of all wallets.
+func (am *Manager) walletAccounts() []Account {
+	var accts []Account
+	for _, w := range am.Wallets() {
+		accts = append(accts, w.Accounts()...)
+	}
+	return accts
+}
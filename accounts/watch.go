@@ -0,0 +1,128 @@
+package accounts
+
+import (
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/howeyc/fsnotify"
+)
+
+// keyDirPollInterval is the time between two scans of the key directory
+// if it can't be watched for changes.
+var keyDirPollInterval = 3 * time.Second
+
+// addrCache holds the addresses of the key store while the key directory
+// is watched.
+type addrCache struct {
+	addrs [][]byte
+	err   error
+	quit  chan struct{}
+}
+
+/*
+Watch keeps the accounts of the manager in sync with the key directory dir,
+so keys added or removed by other programs show up without a restart. The
+directory is watched with fsnotify, or scanned periodically if that isn't
+supported. Addresses are served from memory until Close is called.
+*/
+func (am *Manager) Watch(dir string) {
+	am.mutex.Lock()
+	if am.cache != nil {
+		am.mutex.Unlock()
+		return
+	}
+	quit := make(chan struct{})
+	am.cache = &addrCache{quit: quit}
+	am.mutex.Unlock()
+	am.reload()
+
+	watcher, err := watchDir(dir)
+	if err != nil {
+		glog.V(logger.Info).Infof("Can't watch key directory %s (%v), scanning it every %v", dir, err, keyDirPollInterval)
+		go am.poll(quit)
+		return
+	}
+	go am.watch(watcher, quit)
+}
+
+// Close stops watching the key directory and closes the wallets.
+func (am *Manager) Close() {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	if am.cache != nil {
+		close(am.cache.quit)
+		am.cache = nil
+	}
+	for _, w := range am.wallets {
+		w.Close()
+	}
+	am.wallets = nil
+}
+
+func watchDir(dir string) (*fsnotify.Watcher, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Watch(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+func (am *Manager) watch(watcher *fsnotify.Watcher, quit chan struct{}) {
+	defer watcher.Close()
+	for {
+		select {
+		case <-watcher.Event:
+			am.reload()
+		case err := <-watcher.Error:
+			glog.V(logger.Debug).Infoln("key directory watcher:", err)
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (am *Manager) poll(quit chan struct{}) {
+	ticker := time.NewTicker(keyDirPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			am.reload()
+		case <-quit:
+			return
+		}
+	}
+}
+
+// reload rereads the addresses of the key store into the cache.
+func (am *Manager) reload() {
+	addrs, err := am.keyStore.GetKeyAddresses()
+
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	if am.cache != nil {
+		am.cache.addrs, am.cache.err = addrs, err
+	}
+}
+
+// addresses returns the addresses of the key store, from the cache if the
+// key directory is watched.
+func (am *Manager) addresses() ([][]byte, error) {
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+
+	if am.cache == nil {
+		return am.keyStore.GetKeyAddresses()
+	}
+	return am.cache.addrs, am.cache.err
+}
@@ -2,6 +2,7 @@ package blockpool
 
 import (
 	"fmt"
+	"math/big"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -47,10 +48,41 @@ func newStatus() *status {
 
 type Status struct {
 	statusValues
+	Sections int           // number of chain sections currently in the pool
+	PeerList []*PeerStatus // live peers registered with the block pool
+}
+
+// PeerStatus is the last known blockchain status of a peer
+type PeerStatus struct {
+	Id               string
+	Td               *big.Int
+	CurrentBlockHash common.Hash
+	Best             bool // peer is the current best peer
 }
 
 // blockpool status for reporting
 func (self *BlockPool) Status() *Status {
+	sections := make(map[*section]bool)
+	self.lock.RLock()
+	for _, e := range self.pool {
+		sections[e.section] = true
+	}
+	self.lock.RUnlock()
+
+	var peers []*PeerStatus
+	self.peers.lock.RLock()
+	for id, p := range self.peers.peers {
+		p.lock.RLock()
+		peers = append(peers, &PeerStatus{
+			Id:               id,
+			Td:               p.td,
+			CurrentBlockHash: p.currentBlockHash,
+			Best:             p == self.peers.best,
+		})
+		p.lock.RUnlock()
+	}
+	self.peers.lock.RUnlock()
+
 	self.status.lock.Lock()
 	defer self.status.lock.Unlock()
 	self.status.values.ActivePeers = len(self.status.activePeers)
@@ -59,7 +91,7 @@ func (self *BlockPool) Status() *Status {
 	self.status.values.LivePeers = len(self.peers.peers)
 	self.status.values.Peers = len(self.status.peers)
 	self.status.values.BlockHashesInPool = len(self.pool)
-	return &Status{self.status.values}
+	return &Status{self.status.values, len(sections), peers}
 }
 
 func (self *Status) String() string {
@@ -78,7 +110,8 @@ func (self *Status) String() string {
   ActivePeers:        %v
   BestPeers:          %v
   BadPeers:           %v
-`,
+  Sections:           %v
+%v`,
 		self.Syncing,
 		self.BlockHashes,
 		self.BlockHashesInPool,
@@ -93,9 +126,22 @@ func (self *Status) String() string {
 		self.ActivePeers,
 		self.BestPeers,
 		self.BadPeers,
+		self.Sections,
+		self.peerList(),
 	)
 }
 
+func (self *Status) peerList() (s string) {
+	for _, p := range self.PeerList {
+		best := ""
+		if p.Best {
+			best = " (best)"
+		}
+		s += fmt.Sprintf("  Peer <%s>%s: TD %v, head %s\n", p.Id, best, p.Td, hex(p.CurrentBlockHash))
+	}
+	return
+}
+
 func (self *BlockPool) syncing() {
 	self.status.lock.Lock()
 	defer self.status.lock.Unlock()
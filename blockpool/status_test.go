@@ -242,3 +242,40 @@ func testBlockPoolStatus(t *testing.T) (err error) {
 	}
 	return nil
 }
+
+func TestBlockPoolStatusPeers(t *testing.T) {
+	_, blockPool, blockPoolTester := newTestBlockPool(t)
+	blockPoolTester.blockChain[0] = nil
+	blockPoolTester.initRefBlockChain(4)
+	blockPool.Start()
+	defer blockPool.Stop()
+
+	peer1 := blockPoolTester.newPeer("peer1", 3, 3)
+	peer2 := blockPoolTester.newPeer("peer2", 4, 4)
+	peer1.AddPeer()
+	peer2.AddPeer()
+	peer2.serveBlocks(3, 4)
+	peer2.serveBlockHashes(4, 3, 2)
+
+	s := blockPool.Status()
+	if s.Sections != 1 {
+		t.Errorf("incorrect number of sections. expected 1, got %v", s.Sections)
+	}
+	if len(s.PeerList) != 2 {
+		t.Fatalf("incorrect number of peers. expected 2, got %v", len(s.PeerList))
+	}
+	for _, p := range s.PeerList {
+		switch p.Id {
+		case "peer1":
+			if p.Best || p.Td.Int64() != 3 {
+				t.Errorf("incorrect status for peer1: best %v, TD %v", p.Best, p.Td)
+			}
+		case "peer2":
+			if !p.Best || p.Td.Int64() != 4 || p.CurrentBlockHash != blockPoolTester.hashPool.IndexesToHashes([]int{4})[0] {
+				t.Errorf("incorrect status for peer2: best %v, TD %v, head %x", p.Best, p.Td, p.CurrentBlockHash)
+			}
+		default:
+			t.Errorf("unexpected peer %v", p.Id)
+		}
+	}
+}
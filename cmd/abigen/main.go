@@ -0,0 +1,111 @@
+/*
+	This file is part of go-ethereum
+
+	go-ethereum is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	go-ethereum is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with go-ethereum.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Command abigen generates Go bindings for Ethereum contracts.
+//
+// The input is a JSON file holding the contract ABI and optionally its
+// bytecode, in the form {"abi": [...], "bin": "0x..."}. The ABI may also
+// be given as a JSON encoded string, as emitted by the compilers.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+type contractJSON struct {
+	ABI  json.RawMessage `json:"abi"`
+	Bin  string          `json:"bin"`
+	Code string          `json:"code"`
+}
+
+func main() {
+	var (
+		jsonFile = flag.String("json", "", "contract ABI and bytecode JSON file (- for stdin)")
+		typeName = flag.String("type", "", "Go type name of the binding (defaults to the package name)")
+		pkgName  = flag.String("pkg", "", "Go package name of the generated file")
+		outFile  = flag.String("out", "", "output file (defaults to stdout)")
+	)
+	flag.Parse()
+	log.SetFlags(0)
+
+	if *jsonFile == "" || *pkgName == "" {
+		fmt.Fprintln(os.Stderr, "Usage: abigen -json <file> -pkg <package> [-type <name>] [-out <file>]")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if *typeName == "" {
+		*typeName = *pkgName
+	}
+
+	var (
+		input []byte
+		err   error
+	)
+	if *jsonFile == "-" {
+		input, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		input, err = ioutil.ReadFile(*jsonFile)
+	}
+	if err != nil {
+		log.Fatalf("Failed to read input: %v", err)
+	}
+	abiJSON, bytecode, err := parseContract(input)
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", *jsonFile, err)
+	}
+
+	code, err := bind.Bind(*typeName, abiJSON, bytecode, *pkgName)
+	if err != nil {
+		log.Fatalf("Failed to generate binding: %v", err)
+	}
+	if *outFile == "" {
+		fmt.Print(code)
+		return
+	}
+	if err := ioutil.WriteFile(*outFile, []byte(code), 0644); err != nil {
+		log.Fatalf("Failed to write binding: %v", err)
+	}
+}
+
+// parseContract extracts the ABI and bytecode from the input JSON.
+func parseContract(input []byte) (abiJSON, bytecode string, err error) {
+	var contract contractJSON
+	if err = json.Unmarshal(input, &contract); err != nil {
+		return "", "", err
+	}
+	if len(contract.ABI) == 0 {
+		return "", "", fmt.Errorf("no abi field")
+	}
+	abiJSON = string(contract.ABI)
+	// compilers emit the ABI as a string containing JSON
+	var str string
+	if json.Unmarshal(contract.ABI, &str) == nil {
+		abiJSON = str
+	}
+	bytecode = contract.Bin
+	if bytecode == "" {
+		bytecode = contract.Code
+	}
+	return abiJSON, bytecode, nil
+}
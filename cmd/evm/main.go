@@ -37,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 var (
@@ -115,18 +116,19 @@ func NewEnv(state *state.StateDB, transactor common.Address, value *big.Int) *VM
 	}
 }
 
-func (self *VMEnv) State() *state.StateDB    { return self.state }
-func (self *VMEnv) Origin() common.Address   { return *self.transactor }
-func (self *VMEnv) BlockNumber() *big.Int    { return common.Big0 }
-func (self *VMEnv) Coinbase() common.Address { return *self.transactor }
-func (self *VMEnv) Time() int64              { return self.time }
-func (self *VMEnv) Difficulty() *big.Int     { return common.Big1 }
-func (self *VMEnv) BlockHash() []byte        { return make([]byte, 32) }
-func (self *VMEnv) Value() *big.Int          { return self.value }
-func (self *VMEnv) GasLimit() *big.Int       { return big.NewInt(1000000000) }
-func (self *VMEnv) VmType() vm.Type          { return vm.StdVmTy }
-func (self *VMEnv) Depth() int               { return 0 }
-func (self *VMEnv) SetDepth(i int)           { self.depth = i }
+func (self *VMEnv) State() *state.StateDB            { return self.state }
+func (self *VMEnv) Origin() common.Address           { return *self.transactor }
+func (self *VMEnv) BlockNumber() *big.Int            { return common.Big0 }
+func (self *VMEnv) Coinbase() common.Address         { return *self.transactor }
+func (self *VMEnv) Time() int64                      { return self.time }
+func (self *VMEnv) Difficulty() *big.Int             { return common.Big1 }
+func (self *VMEnv) BlockHash() []byte                { return make([]byte, 32) }
+func (self *VMEnv) Value() *big.Int                  { return self.value }
+func (self *VMEnv) GasLimit() *big.Int               { return big.NewInt(1000000000) }
+func (self *VMEnv) VmType() vm.Type                  { return vm.StdVmTy }
+func (self *VMEnv) ChainConfig() *params.ChainConfig { return params.DefaultChainConfig }
+func (self *VMEnv) Depth() int                       { return 0 }
+func (self *VMEnv) SetDepth(i int)                   { self.depth = i }
 func (self *VMEnv) GetHash(n uint64) common.Hash {
 	if self.block.Number().Cmp(big.NewInt(int64(n))) == 0 {
 		return self.block.Hash()
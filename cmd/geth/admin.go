@@ -1,13 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -27,10 +30,12 @@ func (js *jsre) adminBindings() {
 	t, _ := js.re.Get("admin")
 	admin := t.Object()
 	admin.Set("suggestPeer", js.suggestPeer)
+	admin.Set("banPeer", js.banPeer)
 	admin.Set("startRPC", js.startRPC)
 	admin.Set("stopRPC", js.stopRPC)
 	admin.Set("nodeInfo", js.nodeInfo)
 	admin.Set("peers", js.peers)
+	admin.Set("peerStats", js.peerStats)
 	admin.Set("newAccount", js.newAccount)
 	admin.Set("unlock", js.unlock)
 	admin.Set("import", js.importChain)
@@ -38,6 +43,7 @@ func (js *jsre) adminBindings() {
 	admin.Set("verbosity", js.verbosity)
 	admin.Set("backtrace", js.backtrace)
 	admin.Set("progress", js.downloadProgress)
+	admin.Set("chainSyncStatus", js.chainSyncStatus)
 
 	admin.Set("miner", struct{}{})
 	t, _ = admin.Get("miner")
@@ -55,6 +61,77 @@ func (js *jsre) adminBindings() {
 	debug.Set("getBlockRlp", js.getBlockRlp)
 	debug.Set("setHead", js.setHead)
 	debug.Set("block", js.debugBlock)
+	debug.Set("vmStats", js.vmStats)
+
+	js.re.Set("personal", struct{}{})
+	t, _ = js.re.Get("personal")
+	personal := t.Object()
+	personal.Set("listAccounts", js.listAccounts)
+	personal.Set("setAccountLabel", js.setAccountLabel)
+	personal.Set("sign", js.signMessage)
+	personal.Set("ecRecover", js.ecRecover)
+}
+
+/*
+global registrar name bindings
+*/
+
+func (js *jsre) nameBindings() {
+	js.re.Set("name", struct{}{})
+	t, _ := js.re.Get("name")
+	name := t.Object()
+	name.Set("addr", js.nameAddr)
+	name.Set("owner", js.nameOwner)
+	name.Set("content", js.nameContent)
+	name.Set("flush", js.nameFlush)
+}
+
+func (js *jsre) nameArg(call otto.FunctionCall) (string, error) {
+	if len(call.ArgumentList) != 1 || !call.Argument(0).IsString() {
+		return "", errors.New("requires a name as argument")
+	}
+	return call.Argument(0).ToString()
+}
+
+func (js *jsre) nameAddr(call otto.FunctionCall) otto.Value {
+	name, err := js.nameArg(call)
+	if err == nil {
+		var addr common.Address
+		if addr, err = js.names.Addr(name); err == nil {
+			return js.re.ToVal(addr.Hex())
+		}
+	}
+	fmt.Println(err)
+	return otto.NullValue()
+}
+
+func (js *jsre) nameOwner(call otto.FunctionCall) otto.Value {
+	name, err := js.nameArg(call)
+	if err == nil {
+		var owner common.Address
+		if owner, err = js.names.Owner(name); err == nil {
+			return js.re.ToVal(owner.Hex())
+		}
+	}
+	fmt.Println(err)
+	return otto.NullValue()
+}
+
+func (js *jsre) nameContent(call otto.FunctionCall) otto.Value {
+	name, err := js.nameArg(call)
+	if err == nil {
+		var content common.Hash
+		if content, err = js.names.Content(name); err == nil {
+			return js.re.ToVal(content.Hex())
+		}
+	}
+	fmt.Println(err)
+	return otto.NullValue()
+}
+
+func (js *jsre) nameFlush(call otto.FunctionCall) otto.Value {
+	js.names.Flush()
+	return otto.TrueValue()
 }
 
 func (js *jsre) getBlock(call otto.FunctionCall) (*types.Block, error) {
@@ -98,6 +175,15 @@ func (js *jsre) debugBlock(call otto.FunctionCall) otto.Value {
 	return otto.UndefinedValue()
 }
 
+func (js *jsre) vmStats(call otto.FunctionCall) otto.Value {
+	if !vm.CollectStats {
+		fmt.Println("vm statistics are disabled (start with --vmstats)")
+		return otto.UndefinedValue()
+	}
+
+	return js.re.ToVal(vm.Stats())
+}
+
 func (js *jsre) setHead(call otto.FunctionCall) otto.Value {
 	block, err := js.getBlock(call)
 	if err != nil {
@@ -120,6 +206,162 @@ func (js *jsre) downloadProgress(call otto.FunctionCall) otto.Value {
 	return js.re.ToVal(fmt.Sprintf("%d/%d", current, max))
 }
 
+func (js *jsre) chainSyncStatus(call otto.FunctionCall) otto.Value {
+	return js.re.ToVal(js.ethereum.SyncStatus())
+}
+
+// txReceipt describes the inclusion of a transaction in the canonical chain
+type txReceipt struct {
+	TransactionHash   string
+	TransactionIndex  int
+	BlockHash         string
+	BlockNumber       uint64
+	GasUsed           string
+	CumulativeGasUsed string
+	ContractAddress   string // address of the created contract, empty if none
+	Logs              []rpc.LogRes
+}
+
+// newTxReceipt assembles the receipt of the transaction with the given index
+// in block. The gas fields are taken from the stored receipt.
+func (js *jsre) newTxReceipt(block *types.Block, index int, logs state.Logs) *txReceipt {
+	tx := block.Transactions()[index]
+	hash := tx.Hash()
+	var contract string
+	if core.MessageCreatesContract(tx) {
+		contract = core.AddressFromMessage(tx).Hex()
+	}
+	var txlogs state.Logs
+	for _, log := range logs {
+		if log.TxHash == hash {
+			txlogs = append(txlogs, log)
+		}
+	}
+	receipt := &txReceipt{
+		TransactionHash:  hash.Hex(),
+		TransactionIndex: index,
+		BlockHash:        block.Hash().Hex(),
+		BlockNumber:      block.NumberU64(),
+		ContractAddress:  contract,
+		Logs:             rpc.NewLogsRes(txlogs),
+	}
+	if stored := core.ReadReceipt(js.ethereum.ExtraDb(), hash); stored != nil {
+		receipt.GasUsed = stored.GasUsed().String()
+		receipt.CumulativeGasUsed = stored.CumulativeGasUsed.String()
+	}
+	return receipt
+}
+
+// awaitTransaction blocks until the transaction with the given hash is
+// included in a canonical block and returns its receipt, or null if the
+// timeout (in seconds, default 300) expires first.
+func (js *jsre) awaitTransaction(call otto.FunctionCall) otto.Value {
+	if len(call.ArgumentList) == 0 {
+		fmt.Println("requires a transaction hash")
+		return otto.NullValue()
+	}
+	hashStr, err := call.Argument(0).ToString()
+	if err != nil {
+		fmt.Println(err)
+		return otto.NullValue()
+	}
+	hash := common.HexToHash(hashStr)
+	timeout := 300 * time.Second
+	if len(call.ArgumentList) > 1 {
+		secs, err := call.Argument(1).ToInteger()
+		if err != nil {
+			fmt.Println(err)
+			return otto.NullValue()
+		}
+		timeout = time.Duration(secs) * time.Second
+	}
+
+	// subscribe before looking the transaction up so that an inclusion
+	// happening in between isn't missed
+	sub := js.ethereum.EventMux().Subscribe(core.ChainEvent{})
+	defer sub.Unsubscribe()
+
+	if _, blhash, _, txi := js.xeth.EthTransactionByHash(hash.Hex()); blhash != (common.Hash{}) {
+		if block := js.ethereum.ChainManager().GetBlock(blhash); block != nil && int(txi) < len(block.Transactions()) {
+			logs, _ := js.ethereum.BlockProcessor().GetLogs(block)
+			return js.re.ToVal(js.newTxReceipt(block, int(txi), logs))
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.Chan():
+			if !ok {
+				return otto.NullValue()
+			}
+			event := ev.(core.ChainEvent)
+			for i, tx := range event.Block.Transactions() {
+				if tx.Hash() == hash {
+					return js.re.ToVal(js.newTxReceipt(event.Block, i, event.Logs))
+				}
+			}
+		case <-timer.C:
+			fmt.Println("timeout waiting for transaction", hash.Hex())
+			return otto.NullValue()
+		}
+	}
+}
+
+// decodeLog decodes a log object, as returned by filters and receipts,
+// with the events of the given ABI (an array or its JSON encoding). It
+// returns {event: name, args: {...}} or null if no event matches.
+func (js *jsre) decodeLog(call otto.FunctionCall) otto.Value {
+	if len(call.ArgumentList) != 2 {
+		fmt.Println("requires 2 arguments: eth.decodeLog(abi, log)")
+		return otto.NullValue()
+	}
+	abiJSON, err := call.Argument(0).ToString()
+	if !call.Argument(0).IsString() {
+		abiJSON, err = exportJSON(call.Argument(0))
+	}
+	if err != nil {
+		fmt.Println(err)
+		return otto.NullValue()
+	}
+	logJSON, err := exportJSON(call.Argument(1))
+	if err != nil {
+		fmt.Println(err)
+		return otto.NullValue()
+	}
+	var logObj struct {
+		Address string
+		Topics  []string
+		Data    string
+	}
+	if err := json.Unmarshal([]byte(logJSON), &logObj); err != nil {
+		fmt.Println("invalid log:", err)
+		return otto.NullValue()
+	}
+	log := &state.Log{Address: common.HexToAddress(logObj.Address), Data: common.FromHex(logObj.Data)}
+	for _, topic := range logObj.Topics {
+		log.Topics = append(log.Topics, common.HexToHash(topic))
+	}
+
+	decoded, err := js.xeth.DecodeLog(abiJSON, log)
+	if err != nil {
+		fmt.Println(err)
+		return otto.NullValue()
+	}
+	return js.re.ToVal(map[string]interface{}{"event": decoded.Event, "args": decoded.Args})
+}
+
+// exportJSON returns the JSON encoding of a JS value.
+func exportJSON(value otto.Value) (string, error) {
+	exported, err := value.Export()
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(exported)
+	return string(encoded), err
+}
+
 func (js *jsre) getBlockRlp(call otto.FunctionCall) otto.Value {
 	block, err := js.getBlock(call)
 	if err != nil {
@@ -210,11 +452,9 @@ func (js *jsre) startRPC(call otto.FunctionCall) otto.Value {
 		return otto.FalseValue()
 	}
 
-	config := rpc.RpcConfig{
-		ListenAddress: addr,
-		ListenPort:    uint(port),
-		// CorsDomain:    ctx.GlobalString(RPCCORSDomainFlag.Name),
-	}
+	config := js.rpcConfig
+	config.ListenAddress = addr
+	config.ListenPort = uint(port)
 
 	xeth := xeth.New(js.ethereum, nil)
 	err = rpc.Start(xeth, config)
@@ -248,6 +488,19 @@ func (js *jsre) suggestPeer(call otto.FunctionCall) otto.Value {
 	return otto.TrueValue()
 }
 
+func (js *jsre) banPeer(call otto.FunctionCall) otto.Value {
+	node, err := call.Argument(0).ToString()
+	if err != nil {
+		fmt.Println(err)
+		return otto.FalseValue()
+	}
+	if err := js.ethereum.BanPeer(node); err != nil {
+		fmt.Println(err)
+		return otto.FalseValue()
+	}
+	return otto.TrueValue()
+}
+
 func (js *jsre) unlock(call otto.FunctionCall) otto.Value {
 	addr, err := call.Argument(0).ToString()
 	if err != nil {
@@ -287,6 +540,84 @@ func (js *jsre) unlock(call otto.FunctionCall) otto.Value {
 	return otto.TrueValue()
 }
 
+func (js *jsre) listAccounts(call otto.FunctionCall) otto.Value {
+	accts, err := js.ethereum.AccountManager().Accounts()
+	if err != nil && err != accounts.ErrNoKeys {
+		fmt.Println(err)
+		return otto.UndefinedValue()
+	}
+	list := make([]map[string]string, len(accts))
+	for i, acct := range accts {
+		list[i] = map[string]string{"address": common.ToHex(acct.Address), "label": acct.Label}
+	}
+	return js.re.ToVal(list)
+}
+
+func (js *jsre) setAccountLabel(call otto.FunctionCall) otto.Value {
+	addr, err := call.Argument(0).ToString()
+	if err != nil {
+		fmt.Println(err)
+		return otto.FalseValue()
+	}
+	var label string
+	if arg := call.Argument(1); !arg.IsUndefined() {
+		if label, err = arg.ToString(); err != nil {
+			fmt.Println(err)
+			return otto.FalseValue()
+		}
+	}
+	if err := js.ethereum.AccountManager().SetLabel(common.FromHex(addr), label); err != nil {
+		fmt.Printf("Could not set the label: %v\n", err)
+		return otto.FalseValue()
+	}
+	return otto.TrueValue()
+}
+
+func (js *jsre) signMessage(call otto.FunctionCall) otto.Value {
+	data, err := call.Argument(0).ToString()
+	if err != nil {
+		fmt.Println(err)
+		return otto.UndefinedValue()
+	}
+	addr, err := call.Argument(1).ToString()
+	if err != nil {
+		fmt.Println(err)
+		return otto.UndefinedValue()
+	}
+	var passphrase string
+	if arg := call.Argument(2); !arg.IsUndefined() {
+		if passphrase, err = arg.ToString(); err != nil {
+			fmt.Println(err)
+			return otto.UndefinedValue()
+		}
+	}
+	sig, err := js.xeth.SignMessage(addr, common.FromHex(data), passphrase)
+	if err != nil {
+		fmt.Printf("Could not sign: %v\n", err)
+		return otto.UndefinedValue()
+	}
+	return js.re.ToVal(common.ToHex(sig))
+}
+
+func (js *jsre) ecRecover(call otto.FunctionCall) otto.Value {
+	data, err := call.Argument(0).ToString()
+	if err != nil {
+		fmt.Println(err)
+		return otto.UndefinedValue()
+	}
+	sig, err := call.Argument(1).ToString()
+	if err != nil {
+		fmt.Println(err)
+		return otto.UndefinedValue()
+	}
+	addr, err := js.xeth.EcRecover(common.FromHex(data), common.FromHex(sig))
+	if err != nil {
+		fmt.Printf("Could not recover the address: %v\n", err)
+		return otto.UndefinedValue()
+	}
+	return js.re.ToVal(addr.Hex())
+}
+
 func (js *jsre) newAccount(call otto.FunctionCall) otto.Value {
 	arg := call.Argument(0)
 	var passphrase string
@@ -329,6 +660,10 @@ func (js *jsre) peers(call otto.FunctionCall) otto.Value {
 	return js.re.ToVal(js.ethereum.PeersInfo())
 }
 
+func (js *jsre) peerStats(call otto.FunctionCall) otto.Value {
+	return js.re.ToVal(js.ethereum.PeerStats())
+}
+
 func (js *jsre) importChain(call otto.FunctionCall) otto.Value {
 	if len(call.ArgumentList) == 0 {
 		fmt.Println("err: require file name")
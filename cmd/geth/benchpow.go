@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/ethash"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var benchPowCmd = cli.Command{
+	Action: benchPow,
+	Name:   "bench-pow",
+	Usage:  "measure the ethash mining speed of this machine",
+	Description: `
+Builds the ethash cache and DAG for the epoch of the current block and
+searches for a nonce on --minerthreads threads for the given --duration.
+The hash rate of a single thread and the estimated total rate are printed,
+which helps to choose a sensible value for --minerthreads.
+`,
+	Flags: []cli.Flag{utils.BenchDurationFlag},
+}
+
+func benchPow(ctx *cli.Context) {
+	threads := ctx.GlobalInt(utils.MinerThreadsFlag.Name)
+	if threads < 1 {
+		utils.Fatalf("Option %s: must be at least 1", utils.MinerThreadsFlag.Name)
+	}
+	duration := ctx.Duration(utils.BenchDurationFlag.Name)
+
+	chain, _, _ := utils.GetChain(ctx)
+	pow := ethash.New(chain)
+	number := chain.CurrentBlock().NumberU64()
+
+	start := time.Now()
+	pow.UpdateCache(number, true)
+	fmt.Printf("Made cache for block #%d (%d bytes) in %v\n", number, pow.CacheSize(), time.Since(start))
+	start = time.Now()
+	pow.UpdateDAG()
+	fmt.Printf("Made DAG (%d bytes) in %v\n", pow.DAGSize(), time.Since(start))
+
+	// No nonce satisfies the maximum difficulty, the searches only
+	// return when they are stopped.
+	block := types.NewBlockWithHeader(&types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Difficulty: new(big.Int).Sub(common.BigPow(2, 256), common.Big1),
+		GasLimit:   new(big.Int),
+		GasUsed:    new(big.Int),
+	})
+	fmt.Printf("Mining on %d thread(s) for %v...\n", threads, duration)
+
+	var (
+		stop = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pow.Search(block, stop)
+		}()
+	}
+
+	// The hash rate reported by ethash is the one of the thread that
+	// last updated it, average it over the run.
+	var (
+		ticker   = time.NewTicker(time.Second)
+		deadline = time.After(duration)
+		sum      int64
+		samples  int64
+	)
+sample:
+	for {
+		select {
+		case <-ticker.C:
+			sum += pow.GetHashrate()
+			samples++
+		case <-deadline:
+			break sample
+		}
+	}
+	ticker.Stop()
+	close(stop)
+	wg.Wait()
+
+	if samples == 0 {
+		utils.Fatalf("Option %s: must be at least 1s", utils.BenchDurationFlag.Name)
+	}
+	rate := sum / samples
+	fmt.Printf("Hash rate per thread: %d kH/s\n", rate)
+	fmt.Printf("Estimated total:      %d kH/s on %d thread(s)\n", rate*int64(threads), threads)
+}
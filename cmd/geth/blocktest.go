@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/codegangsta/cli"
 	"github.com/ethereum/go-ethereum/cmd/utils"
@@ -17,27 +19,41 @@ var blocktestCmd = cli.Command{
 	Usage:  `loads a block test file`,
 	Description: `
 The first argument should be a block test file.
-The second argument is the name of a block test from the file.
+The second argument is the name of a block test from the file, it may be
+omitted if the file contains a single test.
 
-The block test will be loaded into an in-memory database.
-If loading succeeds, the RPC server is started. Clients will
-be able to interact with the chain defined by the test.
+The block test will be loaded into an in-memory database, importing the
+test blocks through the regular block processing. If loading succeeds and
+the post state matches, the node keeps running with the RPC server when
+--rpc is given (or "rpc" is passed as third argument). Clients will be able
+to interact with the chain defined by the test.
 `,
 }
 
 func runblocktest(ctx *cli.Context) {
-	if len(ctx.Args()) != 3 {
-		utils.Fatalf("Usage: ethereum blocktest <path-to-test-file> <test-name> {rpc, norpc}")
+	if len(ctx.Args()) < 1 || len(ctx.Args()) > 3 {
+		utils.Fatalf("Usage: geth blocktest <path-to-test-file> [<test-name>] [rpc|norpc]")
+	}
+	file := ctx.Args()[0]
+	startrpc := ctx.GlobalBool(utils.RPCEnabledFlag.Name)
+	if len(ctx.Args()) == 3 {
+		switch ctx.Args()[2] {
+		case "rpc":
+			startrpc = true
+		case "norpc":
+			startrpc = false
+		default:
+			utils.Fatalf("third argument must be rpc or norpc, got %q", ctx.Args()[2])
+		}
 	}
-	file, testname, startrpc := ctx.Args()[0], ctx.Args()[1], ctx.Args()[2]
 
 	bt, err := tests.LoadBlockTests(file)
 	if err != nil {
 		utils.Fatalf("%v", err)
 	}
-	test, ok := bt[testname]
-	if !ok {
-		utils.Fatalf("Test file does not contain test named %q", testname)
+	test, err := selectBlockTest(bt, ctx.Args()[1:])
+	if err != nil {
+		utils.Fatalf("%v", err)
 	}
 
 	cfg := utils.MakeEthConfig(ClientIdentifier, Version, ctx)
@@ -48,33 +64,52 @@ func runblocktest(ctx *cli.Context) {
 		utils.Fatalf("%v", err)
 	}
 
-	// import the genesis block
+	// import the genesis block and pre accounts
 	ethereum.ResetWithGenesisBlock(test.Genesis)
-
-	// import pre accounts
-	statedb, err := test.InsertPreState(ethereum.StateDb())
-	if err != nil {
+	if _, err := test.InsertPreState(ethereum.StateDb()); err != nil {
 		utils.Fatalf("could not insert genesis accounts: %v", err)
 	}
 
 	// insert the test blocks, which will execute all transactions
 	chain := ethereum.ChainManager()
-	if err := chain.InsertChain(test.Blocks); err != nil {
-		utils.Fatalf("Block Test load error: %v %T", err, err)
-	} else {
-		fmt.Println("Block Test chain loaded")
+	if err := test.TryBlocksInsert(chain); err != nil {
+		utils.Fatalf("Block Test load error: %v", err)
 	}
+	fmt.Printf("Block Test chain loaded, head #%v %x\n", chain.CurrentBlock().Number(), chain.CurrentBlock().Hash().Bytes()[:4])
 
-	if err := test.ValidatePostState(statedb); err != nil {
+	if err := test.ValidatePostState(chain.State()); err != nil {
 		utils.Fatalf("post state validation failed: %v", err)
 	}
-	fmt.Println("Block Test post state validated, starting ethereum.")
+	fmt.Println("Block Test post state validated")
 
-	if startrpc == "rpc" {
-		startEth(ctx, ethereum)
+	if startrpc {
+		fmt.Println("Starting ethereum with RPC for inspection")
+		utils.StartEthereum(ethereum)
 		utils.StartRPC(ethereum, ctx)
 		ethereum.WaitForShutdown()
-	} else {
-		startEth(ctx, ethereum)
 	}
 }
+
+// selectBlockTest returns the test named by the first argument, or the only
+// test of the file if no name is given.
+func selectBlockTest(bt map[string]*tests.BlockTest, args []string) (*tests.BlockTest, error) {
+	if len(args) > 0 {
+		test, ok := bt[args[0]]
+		if !ok {
+			return nil, fmt.Errorf("Test file does not contain test named %q", args[0])
+		}
+		return test, nil
+	}
+	if len(bt) != 1 {
+		names := make([]string, 0, len(bt))
+		for name := range bt {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("Test file contains %d tests, pick one of: %s", len(bt), strings.Join(names, ", "))
+	}
+	for _, test := range bt {
+		return test, nil
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var hdWalletCommand = cli.Command{
+	Name:  "hd",
+	Usage: "manage the HD wallet",
+	Description: `
+
+An HD wallet derives all accounts from one seed, so only its mnemonic has to
+be backed up. The wallet is stored in <DATADIR>/hdwallet.json and is used
+instead of the key files when geth is started with --hdwallet.
+`,
+	Subcommands: []cli.Command{
+		{
+			Action: hdWalletNew,
+			Name:   "new",
+			Usage:  "create an HD wallet with a new mnemonic",
+			Description: `
+
+    geth account hd new
+
+Creates an HD wallet and its first account and prints the mnemonic of the
+wallet. Write the mnemonic down and keep it safe, everyone who knows it
+controls all accounts of the wallet.
+
+The accounts are derived below --hdpath. The seed is saved in encrypted
+format, you are prompted for a passphrase.
+`,
+		},
+		{
+			Action: hdWalletImport,
+			Name:   "import",
+			Usage:  "create an HD wallet from an existing mnemonic",
+			Description: `
+
+    geth account hd import
+
+Creates an HD wallet from a mnemonic you are prompted for. Accounts of the
+wallet with transactions or a balance in the local chain are added to it.
+`,
+		},
+	},
+}
+
+func hdWalletNew(ctx *cli.Context) {
+	mnemonic, err := crypto.NewMnemonic(crand.Reader, 256)
+	if err != nil {
+		utils.Fatalf("Could not generate the mnemonic: %v", err)
+	}
+	passphrase := getPassPhrase(ctx, "Your HD wallet is locked with a password. Please give a password. Do not forget this password.", true)
+	w, err := accounts.NewHDWallet(utils.HDWalletFile(ctx), mnemonic, "", ctx.GlobalString(utils.HDPathFlag.Name), passphrase)
+	if err != nil {
+		utils.Fatalf("Could not create the HD wallet: %v", err)
+	}
+	acct, err := accounts.NewManager(w).NewAccount(passphrase)
+	if err != nil {
+		utils.Fatalf("Could not create the account: %v", err)
+	}
+	fmt.Printf("Mnemonic: %s\n\n", mnemonic)
+	fmt.Println("Write the mnemonic down, it is the backup of all accounts of the wallet.")
+	fmt.Printf("Address: %x\n", acct.Address)
+}
+
+func hdWalletImport(ctx *cli.Context) {
+	mnemonic, err := readPassword("Mnemonic: ", true)
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	mnemonic = strings.TrimSpace(mnemonic)
+	if _, err := crypto.MnemonicToEntropy(mnemonic); err != nil {
+		utils.Fatalf("%v", err)
+	}
+	passphrase := getPassPhrase(ctx, "Your HD wallet is locked with a password. Please give a password. Do not forget this password.", true)
+	w, err := accounts.NewHDWallet(utils.HDWalletFile(ctx), mnemonic, "", ctx.GlobalString(utils.HDPathFlag.Name), passphrase)
+	if err != nil {
+		utils.Fatalf("Could not create the HD wallet: %v", err)
+	}
+	am := accounts.NewManager(w)
+
+	chain, _, _ := utils.GetChain(ctx)
+	statedb := chain.State()
+	added, err := am.DiscoverAccounts(passphrase, func(addr []byte) bool {
+		a := common.BytesToAddress(addr)
+		return statedb.GetNonce(a) > 0 || statedb.GetBalance(a).Sign() > 0
+	})
+	if err != nil {
+		utils.Fatalf("Account discovery failed: %v", err)
+	}
+	if added == 0 {
+		if _, err := am.NewAccount(passphrase); err != nil {
+			utils.Fatalf("Could not create the account: %v", err)
+		}
+	}
+	accts, _ := am.Accounts()
+	for _, acct := range accts {
+		fmt.Printf("Address: %x\n", acct.Address)
+	}
+}
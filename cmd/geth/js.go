@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common/docserver"
 	"github.com/ethereum/go-ethereum/common/natspec"
+	"github.com/ethereum/go-ethereum/common/resolver"
 	"github.com/ethereum/go-ethereum/eth"
 	re "github.com/ethereum/go-ethereum/jsre"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -62,18 +63,28 @@ type jsre struct {
 	re       *re.JSRE
 	ethereum *eth.Ethereum
 	xeth     *xeth.XEth
+	names    *resolver.Registry
+	natspec  *natspec.Options
 	ps1      string
 	atexit   func()
 
+	// rpcConfig is used for RPC servers started with admin.startRPC,
+	// the address and port are set by the caller.
+	rpcConfig rpc.RpcConfig
+
 	prompter
 }
 
 func newJSRE(ethereum *eth.Ethereum, libPath string, interactive bool) *jsre {
 	js := &jsre{ethereum: ethereum, ps1: "> "}
 	js.xeth = xeth.New(ethereum, js)
+	js.names = resolver.NewRegistry(js.xeth, globalRegistrarAddr)
+	js.names.Watch(js.xeth)
+	js.xeth.SetNameResolver(js.names)
 	js.re = re.New(libPath)
 	js.apiBindings()
 	js.adminBindings()
+	js.nameBindings()
 
 	if !liner.TerminalSupported() || !interactive {
 		js.prompter = dumbterm{bufio.NewReader(os.Stdin)}
@@ -81,6 +92,7 @@ func newJSRE(ethereum *eth.Ethereum, libPath string, interactive bool) *jsre {
 		lr := liner.NewLiner()
 		js.withHistory(func(hist *os.File) { lr.ReadHistory(hist) })
 		lr.SetCtrlCAborts(true)
+		lr.SetCompleter(js.re.CompleteKeywords)
 		js.prompter = lr
 		js.atexit = func() {
 			js.withHistory(func(hist *os.File) { hist.Truncate(0); lr.WriteHistory(hist) })
@@ -101,6 +113,7 @@ func (js *jsre) apiBindings() {
 	t, _ := js.re.Get("jeth")
 	jethObj := t.Object()
 	jethObj.Set("send", jeth.Send)
+	jethObj.Set("sendAsync", jeth.SendAsync)
 
 	err := js.re.Compile("bignumber.js", re.BigNumber_JS)
 	if err != nil {
@@ -136,6 +149,9 @@ var net = web3.net;
 	if err != nil {
 		utils.Fatalf("Error setting namespaces: %v", err)
 	}
+	t, _ = js.re.Get("eth")
+	t.Object().Set("awaitTransaction", js.awaitTransaction)
+	t.Object().Set("decodeLog", js.decodeLog)
 
 	js.re.Eval(globalRegistrar + "registrar = new GlobalRegistrar(\"" + globalRegistrarAddr + "\");")
 }
@@ -144,7 +160,7 @@ var ds, _ = docserver.New(utils.JSpathFlag.String())
 
 func (self *jsre) ConfirmTransaction(tx string) bool {
 	if self.ethereum.NatSpec {
-		notice := natspec.GetNotice(self.xeth, tx, ds)
+		notice := natspec.GetNoticeWithOptions(self.xeth, tx, ds, self.natspec)
 		fmt.Println(notice)
 		answer, _ := self.Prompt("Confirm Transaction\n[y/n] ")
 		return strings.HasPrefix(strings.Trim(answer, " "), "y")
@@ -168,13 +184,44 @@ func (self *jsre) UnlockAccount(addr []byte) bool {
 	}
 }
 
-func (self *jsre) exec(filename string) error {
-	if err := self.re.Exec(filename); err != nil {
+func (self *jsre) exec(filename string) (err error) {
+	self.re.Do(func() { err = self.re.Exec(filename) })
+	if err != nil {
 		return fmt.Errorf("Javascript Error: %v", err)
 	}
 	return nil
 }
 
+// preload executes the comma separated script files.
+func (self *jsre) preload(files string) error {
+	for _, file := range strings.Split(files, ",") {
+		if file = strings.TrimSpace(file); file == "" {
+			continue
+		}
+		if err := self.exec(file); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	return nil
+}
+
+// evaluate runs a statement and prints its value.
+func (self *jsre) evaluate(code string) (err error) {
+	self.re.Do(func() {
+		value, runErr := self.re.Run(code)
+		if runErr != nil {
+			if ottoErr, ok := runErr.(*otto.Error); ok {
+				err = fmt.Errorf("Javascript Error: %v", ottoErr.String())
+			} else {
+				err = fmt.Errorf("Javascript Error: %v", runErr)
+			}
+			return
+		}
+		self.printValue(value)
+	})
+	return err
+}
+
 func (self *jsre) interactive() {
 	for {
 		input, err := self.Prompt(self.ps1)
@@ -196,6 +243,12 @@ func (self *jsre) interactive() {
 			str = ""
 		}
 	}
+	self.close()
+}
+
+// close releases the resources of the console.
+func (self *jsre) close() {
+	self.names.Stop()
 	if self.atexit != nil {
 		self.atexit()
 	}
@@ -212,21 +265,23 @@ func (self *jsre) withHistory(op func(*os.File)) {
 }
 
 func (self *jsre) parseInput(code string) {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println("[native] error", r)
-		}
-	}()
-	value, err := self.re.Run(code)
-	if err != nil {
-		if ottoErr, ok := err.(*otto.Error); ok {
-			fmt.Println(ottoErr.String())
-		} else {
-			fmt.Println(err)
+	self.re.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Println("[native] error", r)
+			}
+		}()
+		value, err := self.re.Run(code)
+		if err != nil {
+			if ottoErr, ok := err.(*otto.Error); ok {
+				fmt.Println(ottoErr.String())
+			} else {
+				fmt.Println(err)
+			}
+			return
 		}
-		return
-	}
-	self.printValue(value)
+		self.printValue(value)
+	})
 }
 
 var indentCount = 0
@@ -3,15 +3,21 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/robertkrimen/otto"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/xeth"
 )
 
 var port = 30300
@@ -161,6 +167,77 @@ func TestAccounts(t *testing.T) {
 
 }
 
+func TestSignMessage(t *testing.T) {
+	repl, ethereum, err := testJEthRE(t)
+	if err != nil {
+		t.Errorf("error creating jsre, got %v", err)
+		return
+	}
+	err = ethereum.Start()
+	if err != nil {
+		t.Errorf("error starting ethereum: %v", err)
+		return
+	}
+	defer ethereum.Stop()
+
+	addr := "0xe273f01c99144c438695e10f24926dc1f9fbf62d"
+	val, err := repl.re.Run(`personal.ecRecover("0x68656c6c6f", personal.sign("0x68656c6c6f", "` + addr + `", "password"))`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got, _ := val.ToString(); got != addr {
+		t.Errorf("recovered address %v, want %s", got, addr)
+	}
+}
+
+func TestFilterLimits(t *testing.T) {
+	repl, ethereum, err := testJEthRE(t)
+	if err != nil {
+		t.Errorf("error creating jsre, got %v", err)
+		return
+	}
+	err = ethereum.Start()
+	if err != nil {
+		t.Errorf("error starting ethereum: %v", err)
+		return
+	}
+	defer ethereum.Stop()
+
+	repl.xeth.SetFilterLimits(time.Hour, 2)
+	first, err := repl.xeth.NewTransactionFilter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repl.xeth.NewFilterString("latest"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repl.xeth.NewTransactionFilter(); err != xeth.ErrTooManyFilters {
+		t.Fatalf("expected ErrTooManyFilters, got %v", err)
+	}
+	if !repl.xeth.UninstallFilter(first) {
+		t.Fatal("filter not uninstalled")
+	}
+	if repl.xeth.UninstallFilter(first) {
+		t.Error("filter uninstalled twice")
+	}
+	if _, err := repl.xeth.NewTransactionFilter(); err != nil {
+		t.Fatalf("no filter installed after uninstall: %v", err)
+	}
+
+	// filters not polled within the timeout are removed
+	repl.xeth.SetFilterLimits(time.Millisecond, 2)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := repl.xeth.NewTransactionFilter(); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("idle filters not expired")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
 func TestBlockChain(t *testing.T) {
 	repl, ethereum, err := testJEthRE(t)
 	if err != nil {
@@ -238,6 +315,224 @@ func TestMining(t *testing.T) {
 
 }
 
+func TestAwaitTransactionTimeout(t *testing.T) {
+	repl, ethereum, err := testJEthRE(t)
+	if err != nil {
+		t.Errorf("error creating jsre, got %v", err)
+		return
+	}
+	err = ethereum.Start()
+	if err != nil {
+		t.Errorf("error starting ethereum: %v", err)
+		return
+	}
+	defer ethereum.Stop()
+
+	val, err := repl.re.Run(`eth.awaitTransaction("0x0102", 1)`)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if !val.IsNull() {
+		t.Errorf("expected null for unknown transaction, got %v", val)
+	}
+}
+
+// awaitMined includes tx in a block and returns the receipt the console
+// awaits for it.
+func awaitMined(t *testing.T, repl *jsre, ethereum *eth.Ethereum, tx *types.Transaction) *otto.Object {
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	receipt := types.NewReceipt(nil, big.NewInt(42000))
+	receipt.SetGasUsed(big.NewInt(21000))
+	core.WriteReceipt(ethereum.ExtraDb(), tx.Hash(), receipt)
+
+	// post the inclusion until the console picks it up
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			ethereum.EventMux().Post(core.ChainEvent{Block: block})
+			select {
+			case <-done:
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	}()
+
+	val, err := repl.re.Run(`eth.awaitTransaction("` + tx.Hash().Hex() + `", 5)`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !val.IsObject() {
+		t.Fatalf("expected receipt object, got %v", val)
+	}
+	if hash, _ := val.Object().Get("BlockHash"); hash.String() != block.Hash().Hex() {
+		t.Errorf("receipt block hash %v, want %s", hash, block.Hash().Hex())
+	}
+	return val.Object()
+}
+
+func TestAwaitTransactionMined(t *testing.T) {
+	repl, ethereum, err := testJEthRE(t)
+	if err != nil {
+		t.Errorf("error creating jsre, got %v", err)
+		return
+	}
+	err = ethereum.Start()
+	if err != nil {
+		t.Errorf("error starting ethereum: %v", err)
+		return
+	}
+	defer ethereum.Stop()
+
+	key, _ := crypto.GenerateKey()
+	tx := types.NewTransactionMessage(common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	tx.SignECDSA(key)
+
+	receipt := awaitMined(t, repl, ethereum, tx)
+	want := map[string]string{
+		"TransactionHash":   tx.Hash().Hex(),
+		"GasUsed":           "21000",
+		"CumulativeGasUsed": "42000",
+		"ContractAddress":   "",
+	}
+	for field, value := range want {
+		if v, _ := receipt.Get(field); v.String() != value {
+			t.Errorf("receipt %s: got %v, want %q", field, v, value)
+		}
+	}
+}
+
+func TestAwaitContractCreation(t *testing.T) {
+	repl, ethereum, err := testJEthRE(t)
+	if err != nil {
+		t.Errorf("error creating jsre, got %v", err)
+		return
+	}
+	err = ethereum.Start()
+	if err != nil {
+		t.Errorf("error starting ethereum: %v", err)
+		return
+	}
+	defer ethereum.Stop()
+
+	key, _ := crypto.GenerateKey()
+	tx := types.NewContractCreationTx(big.NewInt(0), big.NewInt(100000), big.NewInt(1), []byte{0x60, 0x00})
+	tx.SetNonce(3)
+	tx.SignECDSA(key)
+
+	receipt := awaitMined(t, repl, ethereum, tx)
+	want := crypto.CreateAddress(common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey)), 3).Hex()
+	if v, _ := receipt.Get("ContractAddress"); v.String() != want {
+		t.Errorf("contract address %v, want %s", v, want)
+	}
+}
+
+func TestPreloadAndEvaluate(t *testing.T) {
+	repl, ethereum, err := testJEthRE(t)
+	if err != nil {
+		t.Errorf("error creating jsre, got %v", err)
+		return
+	}
+	err = ethereum.Start()
+	if err != nil {
+		t.Errorf("error starting ethereum: %v", err)
+		return
+	}
+	defer ethereum.Stop()
+
+	script := path.Join("/tmp/eth", "util.js")
+	if err = ioutil.WriteFile(script, []byte("function double(x) { return 2 * x; }"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = repl.preload(script + ", "); err != nil {
+		t.Errorf("expected no error preloading, got %v", err)
+	}
+	val, err := repl.re.Run("double(21)")
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if n, _ := val.ToInteger(); n != 42 {
+		t.Errorf("expected 42, got %v", val)
+	}
+
+	if err = repl.preload("/tmp/eth/missing.js"); err == nil {
+		t.Errorf("expected error preloading missing file")
+	}
+	if err = repl.evaluate("double(1)"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err = repl.evaluate("throw new Error('boom')"); err == nil {
+		t.Errorf("expected error for exception")
+	}
+}
+
+func TestAsyncCallback(t *testing.T) {
+	repl, ethereum, err := testJEthRE(t)
+	if err != nil {
+		t.Errorf("error creating jsre, got %v", err)
+		return
+	}
+	err = ethereum.Start()
+	if err != nil {
+		t.Errorf("error starting ethereum: %v", err)
+		return
+	}
+	defer ethereum.Stop()
+
+	if err = repl.evaluate(`eth.getBlock(0, false, function(err, block) { genesisNumber = block.number; })`); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	repl.re.Wait()
+
+	val, err := repl.re.Run("genesisNumber")
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if n, _ := val.ToInteger(); n != 0 || !val.IsNumber() {
+		t.Errorf("expected genesis block number 0, got %v", val)
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	repl, ethereum, err := testJEthRE(t)
+	if err != nil {
+		t.Errorf("error creating jsre, got %v", err)
+		return
+	}
+	err = ethereum.Start()
+	if err != nil {
+		t.Errorf("error starting ethereum: %v", err)
+		return
+	}
+	defer ethereum.Stop()
+
+	topic := common.ToHex(crypto.Sha3([]byte("Transfer(address,uint256)")))
+	from := "0x000000000000000000000000" + "aa00000000000000000000000000000000000001"
+	_, err = repl.re.Run(`
+		var transferABI = [{"type": "event", "name": "Transfer", "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256", "indexed": false}]}];
+		var decoded = eth.decodeLog(transferABI, {
+			topics: ["` + topic + `", "` + from + `"],
+			data: "0x0000000000000000000000000000000000000000000000000000000000000005"
+		});
+		var other = eth.decodeLog(JSON.stringify(transferABI), {topics: ["0x01"], data: "0x"});
+	`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	val, _ := repl.re.Run(`decoded.event + " " + decoded.args.from + " " + decoded.args.value`)
+	if got, _ := val.ToString(); got != "Transfer 0xaa00000000000000000000000000000000000001 5" {
+		t.Errorf("unexpected decoded log %q", got)
+	}
+	val, _ = repl.re.Run(`other`)
+	if !val.IsNull() {
+		t.Errorf("expected null for unknown event, got %v", val)
+	}
+}
+
 func TestRPC(t *testing.T) {
 	repl, ethereum, err := testJEthRE(t)
 	if err != nil {
@@ -22,6 +22,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -36,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	ver "github.com/ethereum/go-ethereum/common/version"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -57,6 +59,8 @@ func init() {
 	app.HideVersion = true // we have a command to print the version
 	app.Commands = []cli.Command{
 		blocktestCmd,
+		testCmd,
+		benchPowCmd,
 		{
 			Action: makedag,
 			Name:   "makedag",
@@ -133,6 +137,7 @@ Creates a new account. Prints the address.
 The account is saved in encrypted format, you are prompted for a passphrase.
 
 You must remember this passphrase to unlock your account in the future.
+Short or predictable passphrases are refused unless --weakpassword is given.
 
 For non-interactive use the passphrase can be specified with the --password flag:
 
@@ -169,6 +174,25 @@ this import mechanism is not needed when you transfer an account between
 nodes.
 					`,
 				},
+				{
+					Action: accountUpdate,
+					Name:   "update",
+					Usage:  "change the passphrase of all accounts",
+					Description: `
+
+    ethereum account update
+
+Reencrypts the keys of all accounts with a new passphrase. You are prompted
+for the current passphrase, accounts with a different one are skipped.
+
+Keys are encrypted with the scrypt parameters given by --keystore.scryptn and
+--keystore.scryptp. With the --password flag the passphrase is kept, which
+only reencrypts the keys with these parameters:
+
+    ethereum --password <passwordfile> --keystore.scryptn 1048576 account update
+					`,
+				},
+				hdWalletCommand,
 			},
 		},
 		{
@@ -197,7 +221,11 @@ See https://github.com/ethereum/go-ethereum/wiki/Frontier-Console
 			Description: `
 The JavaScript VM exposes a node admin interface as well as the DAPP
 JavaScript API. See https://github.com/ethereum/go-ethereum/wiki/Javascipt-Console
+
+With --exec the given statement is evaluated and its value printed instead.
+The command exits with a non-zero status if the JavaScript throws.
 `,
+			Flags: []cli.Flag{utils.ExecFlag},
 		},
 		{
 			Action: importchain,
@@ -208,48 +236,136 @@ JavaScript API. See https://github.com/ethereum/go-ethereum/wiki/Javascipt-Conso
 			Action: exportchain,
 			Name:   "export",
 			Usage:  `export blockchain into file`,
+			Description: `
+Writes the canonical chain to the given file as RLP, which can be read back
+with the import command.
+
+With --format=json each block is written as one JSON object per line, with
+the header fields, the decoded transactions and the uncle hashes, like the
+result of eth_getBlockByNumber. This is meant for loading the chain into
+analytics tools; it cannot be imported.
+`,
+			Flags: []cli.Flag{utils.ExportFormatFlag},
+		},
+		{
+			Action: exportReceipts,
+			Name:   "export-receipts",
+			Usage:  `export receipts and logs of a block range into file`,
+			Description: `
+    geth export-receipts <file> [first] [last]
+
+Writes the receipts, including the logs, of the canonical blocks first to
+last (default: the whole chain) to the given file. The receipts are derived
+by executing the blocks again, so the state of the blocks must be available.
+
+As RLP each block is written as a list [number, hash, receipts]. With
+--format=json each receipt is written as one JSON object per line, like the
+result of eth_getTransactionReceipt with an additional logs field.
+`,
+			Flags: []cli.Flag{utils.ExportFormatFlag},
 		},
 		{
 			Action: upgradeDb,
 			Name:   "upgradedb",
 			Usage:  "upgrade chainblock database",
+			Description: `
+Re-imports the block chain to upgrade it to the current version.
+
+With --merge the separate blockchain, state and extra databases of older
+versions are folded into a single database instead.
+`,
+			Flags: []cli.Flag{utils.MergeDbFlag},
 		},
 	}
 	app.Flags = []cli.Flag{
 		utils.IdentityFlag,
 		utils.UnlockedAccountFlag,
 		utils.PasswordFileFlag,
+		utils.WeakPasswordFlag,
+		utils.ScryptNFlag,
+		utils.ScryptPFlag,
+		utils.HDWalletFlag,
+		utils.HDPathFlag,
+		utils.UsbFlag,
+		utils.SignerFlag,
 		utils.BootnodesFlag,
+		utils.CheckpointFlag,
+		utils.TxLookupFlag,
+		utils.DatabaseEngineFlag,
+		utils.AncientThresholdFlag,
+		utils.GenesisFileFlag,
+		utils.ClockDriftFlag,
+		utils.FixedDifficultyFlag,
+		utils.DurationLimitFlag,
+		utils.MinGasLimitFlag,
+		utils.SubSecondBlocksFlag,
+		utils.CliqueSignersFlag,
+		utils.CliquePeriodFlag,
+		utils.MaxTxSizeFlag,
+		utils.MaxBlockSizeFlag,
+		utils.MaxMsgSizeFlag,
+		utils.NoDataDirLockFlag,
 		utils.DataDirFlag,
 		utils.BlockchainVersionFlag,
 		utils.JSpathFlag,
+		utils.PreloadJSFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
+		utils.DialRatioFlag,
 		utils.EtherbaseFlag,
 		utils.MinerThreadsFlag,
+		utils.MinerNoSyncPauseFlag,
 		utils.MiningEnabledFlag,
 		utils.NATFlag,
 		utils.NatspecEnabledFlag,
+		utils.NatspecOfflineFlag,
+		utils.NatspecNoticeFlag,
+		utils.NatspecCacheTTLFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.RPCEnabledFlag,
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.WhisperEnabledFlag,
+		utils.WhisperPasswordFileFlag,
 		utils.VMDebugFlag,
+		utils.VMStatsFlag,
 		utils.ProtocolVersionFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
+		utils.RPCFilterTimeoutFlag,
+		utils.RPCMaxFiltersFlag,
+		utils.RPCWorkersFlag,
+		utils.RPCReadOnlyFlag,
+		utils.RPCCertFlag,
+		utils.RPCKeyFlag,
+		utils.RPCAuthFlag,
+		utils.RPCLogFlag,
+		utils.RPCSlowCallFlag,
 		utils.LogLevelFlag,
 		utils.BacktraceAtFlag,
 		utils.LogToStdErrFlag,
 		utils.LogVModuleFlag,
 		utils.LogFileFlag,
+		utils.LogBackendFlag,
+		utils.LogDirFlag,
+		utils.LogMaxSizeFlag,
+		utils.LogMaxAgeFlag,
+		utils.LogMaxFilesFlag,
+		utils.LogCompressFlag,
 		utils.LogJSONFlag,
 		utils.PProfEanbledFlag,
+		utils.BuildInfoFlag,
+		utils.UpdateCheckFlag,
+		utils.UpdateSignerFlag,
 		utils.PProfPortFlag,
 	}
 	app.Before = func(ctx *cli.Context) error {
+		if ctx.GlobalBool(utils.BuildInfoFlag.Name) {
+			buildInfo()
+			os.Exit(0)
+		}
+		fmt.Printf("Welcome to the FRONTIER\n")
 		if ctx.GlobalBool(utils.PProfEanbledFlag.Name) {
 			utils.StartPProf(ctx)
 		}
@@ -268,7 +384,6 @@ JavaScript API. See https://github.com/ethereum/go-ethereum/wiki/Javascipt-Conso
 }
 
 func main() {
-	fmt.Printf("Welcome to the FRONTIER\n")
 	runtime.GOMAXPROCS(runtime.NumCPU())
 	defer logger.Flush()
 	if err := app.Run(os.Args); err != nil {
@@ -299,6 +414,11 @@ func console(ctx *cli.Context) {
 
 	startEth(ctx, ethereum)
 	repl := newJSRE(ethereum, ctx.String(utils.JSpathFlag.Name), true)
+	repl.natspec = utils.MakeNatSpecOptions(ctx)
+	repl.rpcConfig = utils.MakeRPCConfig(ctx)
+	if err := repl.preload(ctx.GlobalString(utils.PreloadJSFlag.Name)); err != nil {
+		fmt.Println(err)
+	}
 	repl.interactive()
 
 	ethereum.Stop()
@@ -314,12 +434,28 @@ func execJSFiles(ctx *cli.Context) {
 
 	startEth(ctx, ethereum)
 	repl := newJSRE(ethereum, ctx.String(utils.JSpathFlag.Name), false)
-	for _, file := range ctx.Args() {
-		repl.exec(file)
+	repl.natspec = utils.MakeNatSpecOptions(ctx)
+	repl.rpcConfig = utils.MakeRPCConfig(ctx)
+	err = repl.preload(ctx.GlobalString(utils.PreloadJSFlag.Name))
+	if err == nil {
+		if code := ctx.String(utils.ExecFlag.Name); code != "" {
+			err = repl.evaluate(code)
+		} else {
+			for _, file := range ctx.Args() {
+				if err = repl.exec(file); err != nil {
+					break
+				}
+			}
+		}
 	}
+	repl.re.Wait()
+	repl.close()
 
 	ethereum.Stop()
 	ethereum.WaitForShutdown()
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
 }
 
 func unlockAccount(ctx *cli.Context, am *accounts.Manager, account string) (passphrase string) {
@@ -370,7 +506,11 @@ func accountList(ctx *cli.Context) {
 		utils.Fatalf("Could not list accounts: %v", err)
 	}
 	for _, acct := range accts {
-		fmt.Printf("Address: %x\n", acct)
+		if acct.Label != "" {
+			fmt.Printf("Address: %x (%s)\n", acct.Address, acct.Label)
+		} else {
+			fmt.Printf("Address: %x\n", acct.Address)
+		}
 	}
 }
 
@@ -400,6 +540,12 @@ func getPassPhrase(ctx *cli.Context, desc string, confirmation bool) (passphrase
 		}
 		passphrase = string(passbytes)
 	}
+	// new passphrases are confirmed
+	if confirmation && !ctx.GlobalBool(utils.WeakPasswordFlag.Name) {
+		if err := utils.CheckPassphrase(passphrase); err != nil {
+			utils.Fatalf("Weak passphrase: %v (use --%s to allow it)", err, utils.WeakPasswordFlag.Name)
+		}
+	}
 	return
 }
 
@@ -410,7 +556,33 @@ func accountCreate(ctx *cli.Context) {
 	if err != nil {
 		utils.Fatalf("Could not create the account: %v", err)
 	}
-	fmt.Printf("Address: %x\n", acct)
+	fmt.Printf("Address: %x\n", acct.Address)
+}
+
+func accountUpdate(ctx *cli.Context) {
+	am := utils.GetAccountManager(ctx)
+	accts, err := am.Accounts()
+	if err != nil {
+		utils.Fatalf("Could not list accounts: %v", err)
+	}
+	if len(accts) == 0 {
+		utils.Fatalf("No accounts to update")
+	}
+	passphrase := getPassPhrase(ctx, "Please give the current passphrase of the accounts.", false)
+	newPassphrase := getPassPhrase(ctx, "Please give a new passphrase. Do not forget this passphrase.", true)
+
+	failed := 0
+	for _, acct := range accts {
+		if err := am.Update(acct.Address, passphrase, newPassphrase); err != nil {
+			fmt.Printf("Address: %x not updated: %v\n", acct.Address, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Address: %x updated\n", acct.Address)
+	}
+	if failed > 0 {
+		utils.Fatalf("%d of %d accounts not updated", failed, len(accts))
+	}
 }
 
 func importWallet(ctx *cli.Context) {
@@ -430,7 +602,7 @@ func importWallet(ctx *cli.Context) {
 	if err != nil {
 		utils.Fatalf("Could not create the account: %v", err)
 	}
-	fmt.Printf("Address: %x\n", acct)
+	fmt.Printf("Address: %x\n", acct.Address)
 }
 
 func accountImport(ctx *cli.Context) {
@@ -444,7 +616,7 @@ func accountImport(ctx *cli.Context) {
 	if err != nil {
 		utils.Fatalf("Could not create the account: %v", err)
 	}
-	fmt.Printf("Address: %x\n", acct)
+	fmt.Printf("Address: %x\n", acct.Address)
 }
 
 func importchain(ctx *cli.Context) {
@@ -468,9 +640,8 @@ func importchain(ctx *cli.Context) {
 	}
 
 	// force database flush
-	ethereum.BlockDb().Close()
-	ethereum.StateDb().Close()
-	ethereum.ExtraDb().Close()
+	ethereum.ChainManager().Stop()
+	ethereum.CloseDatabases()
 
 	fmt.Printf("Import done in %v", time.Since(start))
 
@@ -492,7 +663,7 @@ func exportchain(ctx *cli.Context) {
 
 	chainmgr := ethereum.ChainManager()
 	start := time.Now()
-	err = utils.ExportChain(chainmgr, ctx.Args().First())
+	err = utils.ExportChainFormat(chainmgr, ctx.Args().First(), ctx.String(utils.ExportFormatFlag.Name))
 	if err != nil {
 		utils.Fatalf("Export error: %v\n", err)
 	}
@@ -500,7 +671,44 @@ func exportchain(ctx *cli.Context) {
 	return
 }
 
+func exportReceipts(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) < 1 || len(args) > 3 {
+		utils.Fatalf("Usage: geth export-receipts <file> [first] [last]")
+	}
+
+	cfg := utils.MakeEthConfig(ClientIdentifier, Version, ctx)
+	cfg.SkipBcVersionCheck = true
+
+	ethereum, err := eth.New(cfg)
+	if err != nil {
+		utils.Fatalf("%v\n", err)
+	}
+
+	first, last := uint64(0), ethereum.ChainManager().CurrentBlock().NumberU64()
+	if len(args) > 1 {
+		if first, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+			utils.Fatalf("Invalid first block: %v", err)
+		}
+	}
+	if len(args) > 2 {
+		if last, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+			utils.Fatalf("Invalid last block: %v", err)
+		}
+	}
+	start := time.Now()
+	err = utils.ExportReceipts(ethereum.BlockProcessor(), args[0], ctx.String(utils.ExportFormatFlag.Name), first, last)
+	if err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v", time.Since(start))
+}
+
 func upgradeDb(ctx *cli.Context) {
+	if ctx.Bool(utils.MergeDbFlag.Name) {
+		mergeDb(ctx)
+		return
+	}
 	fmt.Println("Upgrade blockchain DB")
 
 	cfg := utils.MakeEthConfig(ClientIdentifier, Version, ctx)
@@ -526,11 +734,12 @@ func upgradeDb(ctx *cli.Context) {
 		utils.Fatalf("Unable to export chain for reimport %s\n", err)
 	}
 
-	ethereum.BlockDb().Close()
-	ethereum.StateDb().Close()
-	ethereum.ExtraDb().Close()
+	ethereum.ChainManager().Stop()
+	ethereum.CloseDatabases()
 
-	os.RemoveAll(path.Join(ctx.GlobalString(utils.DataDirFlag.Name), "blockchain"))
+	if err := eth.RemoveBlockChain(cfg.DataDir, cfg.DatabaseEngine); err != nil {
+		utils.Fatalf("Unable to remove old chain: %v\n", err)
+	}
 
 	ethereum, err = eth.New(cfg)
 	if err != nil {
@@ -545,15 +754,35 @@ func upgradeDb(ctx *cli.Context) {
 	}
 
 	// force database flush
-	ethereum.BlockDb().Close()
-	ethereum.StateDb().Close()
-	ethereum.ExtraDb().Close()
+	ethereum.ChainManager().Stop()
+	ethereum.CloseDatabases()
 
 	os.Remove(exportFile)
 
 	fmt.Println("Import finished")
 }
 
+func mergeDb(ctx *cli.Context) {
+	dataDir := ctx.GlobalString(utils.DataDirFlag.Name)
+	if !eth.IsSeparateLayout(dataDir) {
+		utils.Fatalf("%s doesn't contain separate databases, nothing to merge\n", dataDir)
+	}
+	if !ctx.GlobalBool(utils.NoDataDirLockFlag.Name) {
+		lock, err := eth.LockDataDir(dataDir)
+		if err != nil {
+			utils.Fatalf("%v\n", err)
+		}
+		defer lock.Release()
+	}
+	fmt.Println("Merging databases into", path.Join(dataDir, "chaindata"))
+
+	start := time.Now()
+	if err := eth.MergeDatabases(dataDir, ctx.GlobalString(utils.DatabaseEngineFlag.Name)); err != nil {
+		utils.Fatalf("Merge error: %v\n", err)
+	}
+	fmt.Printf("Merge done in %v\n", time.Since(start))
+}
+
 func dump(ctx *cli.Context) {
 	chainmgr, _, stateDb := utils.GetChain(ctx)
 	for _, arg := range ctx.Args() {
@@ -584,15 +813,29 @@ func makedag(ctx *cli.Context) {
 }
 
 func version(c *cli.Context) {
+	info := ver.New(ClientIdentifier, Version)
 	fmt.Printf(`%v
 Version: %v
-Protocol Version: %d
+`, info.Client, info.Version)
+	if info.GitCommit != "" {
+		fmt.Printf("Git Commit: %s\n", info.GitCommit)
+	}
+	if info.BuildDate != "" {
+		fmt.Printf("Build Date: %s\n", info.BuildDate)
+	}
+	fmt.Printf(`Protocol Versions: %s
 Network Id: %d
 GO: %s
 OS: %s
 GOPATH=%s
 GOROOT=%s
-`, ClientIdentifier, Version, c.GlobalInt(utils.ProtocolVersionFlag.Name), c.GlobalInt(utils.NetworkIdFlag.Name), runtime.Version(), runtime.GOOS, os.Getenv("GOPATH"), runtime.GOROOT())
+`, c.GlobalString(utils.ProtocolVersionFlag.Name), c.GlobalInt(utils.NetworkIdFlag.Name), info.GoVersion, info.OS, os.Getenv("GOPATH"), runtime.GOROOT())
+}
+
+// buildInfo prints the build information as JSON.
+func buildInfo() {
+	out, _ := json.MarshalIndent(ver.New(ClientIdentifier, Version), "", "  ")
+	fmt.Println(string(out))
 }
 
 // hashish returns true for strings that look like hashes.
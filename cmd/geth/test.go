@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/tests"
+	vmtests "github.com/ethereum/go-ethereum/tests/vm"
+)
+
+// testCmd has no usage string which hides it from the command listing. It
+// is meant for debugging consensus issues against other clients.
+var testCmd = cli.Command{
+	Action: runtest,
+	Name:   "test",
+	Description: `
+Runs JSON consensus tests. The first argument is the kind of test
+(state or block), the second the test file. If a test name is given
+as third argument only that test is run.
+
+Use --vmdebug to trace the execution.
+`,
+}
+
+func runtest(ctx *cli.Context) {
+	if len(ctx.Args()) < 2 || len(ctx.Args()) > 3 {
+		utils.Fatalf("Usage: geth test {state, block} <path-to-test-file> [<test-name>]")
+	}
+	kind, file := ctx.Args()[0], ctx.Args()[1]
+	vm.Debug = ctx.GlobalBool(utils.VMDebugFlag.Name)
+
+	var err error
+	switch {
+	case kind == "state":
+		err = runStateTests(file, ctx.Args()[2:])
+	case kind == "block" && len(ctx.Args()) == 3:
+		err = tests.RunBlockTest(file, ctx.Args()[2])
+	case kind == "block":
+		err = tests.RunBlockTests(file)
+	default:
+		utils.Fatalf("unknown test kind %q, want state or block", kind)
+	}
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	fmt.Println("All tests passed")
+}
+
+// runStateTests runs the state tests in file, or only the named one.
+func runStateTests(file string, names []string) error {
+	sts, err := vmtests.LoadTests(file)
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		test, ok := sts[names[0]]
+		if !ok {
+			return fmt.Errorf("test file does not contain test named %q", names[0])
+		}
+		sts = map[string]vmtests.VmTest{names[0]: test}
+	}
+	for name, test := range sts {
+		if err := vmtests.RunTest(test); err != nil {
+			return fmt.Errorf("bad test %s: %v", name, err)
+		}
+	}
+	return nil
+}
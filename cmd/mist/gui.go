@@ -336,13 +336,13 @@ func (gui *Gui) update() {
 	lastBlockLabel := gui.getObjectByName("lastBlockLabel")
 	//miningLabel := gui.getObjectByName("miningLabel")
 
-	events := gui.eth.EventMux().Subscribe(
-		core.ChainEvent{},
-		core.TxPreEvent{},
-		core.TxPostEvent{},
-	)
-
+	events := gui.eth.EventMux().Subscribe(core.ChainEvent{})
 	defer events.Unsubscribe()
+	preTxs := gui.eth.TxPool().SubscribeTxPreEvent()
+	defer preTxs.Unsubscribe()
+	postTxs := gui.eth.BlockProcessor().SubscribeTxPostEvent()
+	defer postTxs.Unsubscribe()
+
 	for {
 		select {
 		case ev, isopen := <-events.Chan():
@@ -352,12 +352,19 @@ func (gui *Gui) update() {
 			switch ev := ev.(type) {
 			case core.ChainEvent:
 				gui.processBlock(ev.Block, false)
-			case core.TxPreEvent:
-				gui.insertTransaction("pre", ev.Tx)
+			}
 
-			case core.TxPostEvent:
-				gui.getObjectByName("pendingTxView").Call("removeTx", xeth.NewTx(ev.Tx))
+		case ev, isopen := <-preTxs.Chan():
+			if !isopen {
+				return
+			}
+			gui.insertTransaction("pre", ev.(core.TxPreEvent).Tx)
+
+		case ev, isopen := <-postTxs.Chan():
+			if !isopen {
+				return
 			}
+			gui.getObjectByName("pendingTxView").Call("removeTx", xeth.NewTx(ev.(core.TxPostEvent).Tx))
 
 		case <-peerUpdateTicker.C:
 			gui.setPeerInfo()
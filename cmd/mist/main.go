@@ -62,9 +62,16 @@ func init() {
 		rpcCorsFlag,
 
 		utils.BootnodesFlag,
+		utils.CheckpointFlag,
 		utils.DataDirFlag,
 		utils.ListenPortFlag,
 		utils.LogFileFlag,
+		utils.LogBackendFlag,
+		utils.LogDirFlag,
+		utils.LogMaxSizeFlag,
+		utils.LogMaxAgeFlag,
+		utils.LogMaxFilesFlag,
+		utils.LogCompressFlag,
 		utils.LogLevelFlag,
 		utils.MaxPeersFlag,
 		utils.MinerThreadsFlag,
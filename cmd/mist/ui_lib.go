@@ -27,7 +27,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth"
-	"github.com/ethereum/go-ethereum/event/filter"
 	"github.com/ethereum/go-ethereum/xeth"
 	"github.com/obscuren/qml"
 )
@@ -48,7 +47,6 @@ type UiLib struct {
 	win *qml.Window
 
 	filterCallbacks map[int][]int
-	filterManager   *filter.FilterManager
 }
 
 func NewUiLib(engine *qml.Engine, eth *eth.Ethereum, assetPath, libPath string) *UiLib {
@@ -60,9 +58,6 @@ func NewUiLib(engine *qml.Engine, eth *eth.Ethereum, assetPath, libPath string)
 		assetPath:       assetPath,
 		filterCallbacks: make(map[int][]int),
 	}
-	lib.filterManager = filter.NewFilterManager(eth.EventMux())
-	go lib.filterManager.Start()
-
 	return lib
 }
 
@@ -72,7 +67,7 @@ func (self *UiLib) Notef(args []interface{}) {
 
 func (self *UiLib) ImportTx(rlpTx string) {
 	tx := types.NewTransactionFromBytes(common.Hex2Bytes(rlpTx))
-	err := self.eth.TxPool().Add(tx)
+	err := self.eth.TxPool().AddLocal(tx)
 	if err != nil {
 		guilogger.Infoln("import tx failed ", err)
 	}
@@ -225,7 +220,7 @@ func (self *UiLib) ReadFile(p string) string {
 }
 
 func (self *UiLib) UninstallFilter(id int) {
-	self.filterManager.UninstallFilter(id)
+	self.XEth.UninstallFilter(id)
 }
 
 func mapToTxParams(object map[string]interface{}) map[string]string {
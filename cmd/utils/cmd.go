@@ -22,11 +22,14 @@
 package utils
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
 	"regexp"
+	"syscall"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -35,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 var interruptCallbacks = []func(os.Signal){}
@@ -44,15 +48,20 @@ func RegisterInterrupt(cb func(os.Signal)) {
 	interruptCallbacks = append(interruptCallbacks, cb)
 }
 
-// go routine that call interrupt handlers in order of registering
+// go routine that call interrupt handlers in order of registering.
+// A second signal during shutdown exits immediately.
 func HandleInterrupt() {
 	c := make(chan os.Signal, 1)
 	go func() {
-		signal.Notify(c, os.Interrupt)
-		for sig := range c {
-			glog.V(logger.Error).Infof("Shutting down (%v) ... \n", sig)
-			RunInterruptCallbacks(sig)
-		}
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		sig := <-c
+		glog.V(logger.Error).Infof("Shutting down (%v) ... \n", sig)
+		go RunInterruptCallbacks(sig)
+
+		sig = <-c
+		glog.V(logger.Error).Infof("Forced exit (%v), recent blocks may have to be imported again\n", sig)
+		logger.Flush()
+		os.Exit(1)
 	}()
 }
 
@@ -145,6 +154,9 @@ func FormatTransactionData(data string) []byte {
 	return d
 }
 
+// maxImportBlockSize is the largest encoded block accepted by ImportChain.
+const maxImportBlockSize = 16 * 1024 * 1024
+
 func ImportChain(chainmgr *core.ChainManager, fn string) error {
 	fmt.Printf("importing blockchain '%s'\n", fn)
 	fh, err := os.OpenFile(fn, os.O_RDONLY, os.ModePerm)
@@ -154,18 +166,20 @@ func ImportChain(chainmgr *core.ChainManager, fn string) error {
 	defer fh.Close()
 
 	chainmgr.Reset()
-	stream := rlp.NewStream(fh, 0)
-	var i, n int
+	stream := rlp.NewValueReader(fh, 0, maxImportBlockSize)
+	var n int
 
 	batchSize := 2500
 	blocks := make(types.Blocks, batchSize)
+	// input offset of the first block in the current batch
+	batchPos := stream.Pos()
 
-	for ; ; i++ {
+	for {
 		var b types.Block
 		if err := stream.Decode(&b); err == io.EOF {
 			break
 		} else if err != nil {
-			return fmt.Errorf("at block %d: %v", i, err)
+			return fmt.Errorf("at block %d (offset %d): %v", stream.Count(), stream.Pos(), err)
 		}
 
 		blocks[n] = &b
@@ -173,33 +187,117 @@ func ImportChain(chainmgr *core.ChainManager, fn string) error {
 
 		if n == batchSize {
 			if err := chainmgr.InsertChain(blocks); err != nil {
-				return fmt.Errorf("invalid block %v", err)
+				return fmt.Errorf("invalid block in batch at offset %d: %v", batchPos, err)
 			}
 			n = 0
 			blocks = make(types.Blocks, batchSize)
+			batchPos = stream.Pos()
 		}
 	}
 
 	if n > 0 {
 		if err := chainmgr.InsertChain(blocks[:n]); err != nil {
-			return fmt.Errorf("invalid block %v", err)
+			return fmt.Errorf("invalid block in batch at offset %d: %v", batchPos, err)
 		}
 	}
 
-	fmt.Printf("imported %d blocks\n", i)
+	fmt.Printf("imported %d blocks\n", stream.Count())
 	return nil
 }
 
 func ExportChain(chainmgr *core.ChainManager, fn string) error {
+	return ExportChainFormat(chainmgr, fn, "rlp")
+}
+
+// ExportChainFormat writes the chain to fn as RLP, or with format "json" as
+// one JSON object per line, in the format of eth_getBlockByNumber with full
+// transactions.
+func ExportChainFormat(chainmgr *core.ChainManager, fn, format string) error {
+	if format != "rlp" && format != "json" {
+		return fmt.Errorf("unknown export format %q", format)
+	}
 	fmt.Printf("exporting blockchain '%s'\n", fn)
 	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
 	if err != nil {
 		return err
 	}
 	defer fh.Close()
-	if err := chainmgr.Export(fh); err != nil {
+	w := bufio.NewWriter(fh)
+	if format == "json" {
+		err = exportJSON(chainmgr, w)
+	} else {
+		err = chainmgr.Export(w)
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
 		return err
 	}
 	fmt.Printf("exported blockchain\n")
 	return nil
 }
+
+// exportedReceipts is the RLP export form of the receipts of one block.
+type exportedReceipts struct {
+	Number   uint64
+	Hash     common.Hash
+	Receipts types.Receipts
+}
+
+// ExportReceipts writes the receipts of the canonical blocks first to last
+// to fn. As RLP every block is a list [number, hash, receipts] with the
+// consensus encoding of the receipts; as json every receipt is one JSON
+// object per line, in the format of eth_getTransactionReceipt plus logs.
+// The receipts are derived by executing the blocks again, which needs the
+// state of their parents.
+func ExportReceipts(bproc *core.BlockProcessor, fn, format string, first, last uint64) error {
+	if format != "rlp" && format != "json" {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	if first > last {
+		return fmt.Errorf("first block %d is after last block %d", first, last)
+	}
+	fmt.Printf("exporting receipts of blocks %d-%d to '%s'\n", first, last, fn)
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	var (
+		w   = bufio.NewWriter(fh)
+		enc = json.NewEncoder(w)
+	)
+	chainmgr := bproc.ChainManager()
+	for nr := first; nr <= last; nr++ {
+		block := chainmgr.GetBlockByNumber(nr)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: not found", nr)
+		}
+		receipts, err := bproc.GetReceipts(block)
+		if err != nil {
+			return fmt.Errorf("export failed on #%d: %v", nr, err)
+		}
+		if format == "json" {
+			for _, res := range rpc.NewBlockReceiptsRes(block, receipts) {
+				if err := enc.Encode(res); err != nil {
+					return err
+				}
+			}
+		} else if err := rlp.Encode(w, exportedReceipts{nr, block.Hash(), receipts}); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("exported receipts\n")
+	return nil
+}
+
+func exportJSON(chainmgr *core.ChainManager, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return chainmgr.ExportEach(func(block *types.Block) error {
+		return enc.Encode(rpc.NewBlockRes(block, true))
+	})
+}
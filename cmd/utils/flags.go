@@ -2,17 +2,27 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
+	"math/big"
 	"net/http"
 	"os"
 	"path"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/codegangsta/cli"
 	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/flock"
+	"github.com/ethereum/go-ethereum/common/natspec"
+	"github.com/ethereum/go-ethereum/common/version"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
@@ -21,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/p2p/nat"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/xeth"
 )
@@ -32,8 +43,8 @@ VERSION:
    {{.Version}}
 
 COMMANDS:
-   {{range .Commands}}{{.Name}}{{with .ShortName}}, {{.}}{{end}}{{ "\t" }}{{.Usage}}
-   {{end}}{{if .Flags}}
+   {{range .Commands}}{{if .Usage}}{{.Name}}{{with .ShortName}}, {{.}}{{end}}{{ "\t" }}{{.Usage}}
+   {{end}}{{end}}{{if .Flags}}
 GLOBAL OPTIONS:
    {{range .Flags}}{{.}}
    {{end}}{{end}}
@@ -77,10 +88,10 @@ var (
 		Usage: "Data directory to be used",
 		Value: DirectoryString{common.DefaultDataDir()},
 	}
-	ProtocolVersionFlag = cli.IntFlag{
+	ProtocolVersionFlag = cli.StringFlag{
 		Name:  "protocolversion",
-		Usage: "ETH protocol version",
-		Value: eth.ProtocolVersion,
+		Usage: "Comma-separated ETH protocol versions, the highest one shared with a peer is used",
+		Value: joinInts(eth.ProtocolVersions),
 	}
 	NetworkIdFlag = cli.IntFlag{
 		Name:  "networkid",
@@ -100,6 +111,19 @@ var (
 		Name:  "natspec",
 		Usage: "Enable NatSpec confirmation notice",
 	}
+	NatspecOfflineFlag = cli.BoolFlag{
+		Name:  "natspecoffline",
+		Usage: "Only use cached NatSpec documents, never look them up in the registry",
+	}
+	NatspecNoticeFlag = cli.StringFlag{
+		Name:  "natspecnotice",
+		Usage: "Confirmation notice shown if no NatSpec document is found",
+	}
+	NatspecCacheTTLFlag = cli.DurationFlag{
+		Name:  "natspeccachettl",
+		Usage: "Time after which cached NatSpec documents are fetched again (0 = never)",
+		Value: 24 * time.Hour,
+	}
 
 	// miner settings
 	MinerThreadsFlag = cli.IntFlag{
@@ -111,6 +135,10 @@ var (
 		Name:  "mine",
 		Usage: "Enable mining",
 	}
+	MinerNoSyncPauseFlag = cli.BoolFlag{
+		Name:  "miner.nosyncpause",
+		Usage: "Keep mining while synchronising (for single node setups without peers to sync from)",
+	}
 	EtherbaseFlag = cli.StringFlag{
 		Name:  "etherbase",
 		Usage: "public address for block mining rewards. By default the address of your primary account is used",
@@ -122,6 +150,38 @@ var (
 		Usage: "unlock the account given until this program exits (prompts for password). '--unlock primary' unlocks the primary account",
 		Value: "",
 	}
+	HDWalletFlag = cli.BoolFlag{
+		Name:  "hdwallet",
+		Usage: "Use the accounts of the HD wallet instead of the key files",
+	}
+	HDPathFlag = cli.StringFlag{
+		Name:  "hdpath",
+		Usage: "Derivation path of the accounts of a new HD wallet",
+		Value: crypto.DefaultHDPath,
+	}
+	UsbFlag = cli.BoolFlag{
+		Name:  "usb",
+		Usage: "Sign with the accounts of Ledger hardware wallets connected over USB",
+	}
+	SignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "Hold no keys and forward signing requests to the external signer listening on this unix socket",
+		Value: "",
+	}
+	WeakPasswordFlag = cli.BoolFlag{
+		Name:  "weakpassword",
+		Usage: "Allow new passphrases failing the strength check",
+	}
+	ScryptNFlag = cli.IntFlag{
+		Name:  "keystore.scryptn",
+		Usage: "Scrypt N (CPU/memory cost, a power of two) used to encrypt keys",
+		Value: crypto.DefaultScryptN,
+	}
+	ScryptPFlag = cli.IntFlag{
+		Name:  "keystore.scryptp",
+		Usage: "Scrypt P (parallelization) used to encrypt keys",
+		Value: crypto.DefaultScryptP,
+	}
 	PasswordFileFlag = cli.StringFlag{
 		Name:  "password",
 		Usage: "Path to password file for (un)locking an existing account.",
@@ -133,6 +193,33 @@ var (
 		Name:  "logfile",
 		Usage: "Send log output to a file",
 	}
+	LogBackendFlag = cli.StringFlag{
+		Name:  "logbackend",
+		Usage: "Log output backend: stderr, syslog or journald",
+		Value: "stderr",
+	}
+	LogDirFlag = cli.StringFlag{
+		Name:  "logdir",
+		Usage: "Write log files to this directory in addition to standard error",
+	}
+	LogMaxSizeFlag = cli.IntFlag{
+		Name:  "logmaxsize",
+		Usage: "Rotate log files when they reach this size in MB",
+		Value: 100,
+	}
+	LogMaxAgeFlag = cli.DurationFlag{
+		Name:  "logmaxage",
+		Usage: "Rotate log files after this duration, e.g. 24h (default: no time based rotation)",
+	}
+	LogMaxFilesFlag = cli.IntFlag{
+		Name:  "logmaxfiles",
+		Usage: "Number of log files kept per severity level, 0 keeps all files",
+		Value: 10,
+	}
+	LogCompressFlag = cli.BoolFlag{
+		Name:  "logcompress",
+		Usage: "Compress rotated log files with gzip",
+	}
 	LogLevelFlag = cli.IntFlag{
 		Name:  "loglevel",
 		Usage: "0-5 (silent, error, warn, info, debug, debug detail)",
@@ -149,13 +236,17 @@ var (
 	}
 	LogVModuleFlag = cli.GenericFlag{
 		Name:  "vmodule",
-		Usage: "The syntax of the argument is a comma-separated list of pattern=N, where pattern is a literal file name (minus the \".go\" suffix) or \"glob\" pattern and N is a V level.",
+		Usage: "The syntax of the argument is a comma-separated list of pattern=N, where pattern is a literal file name (minus the \".go\" suffix), package directory name or \"glob\" pattern and N is a V level.",
 		Value: glog.GetVModule(),
 	}
 	VMDebugFlag = cli.BoolFlag{
 		Name:  "vmdebug",
 		Usage: "Virtual Machine debug output",
 	}
+	VMStatsFlag = cli.BoolFlag{
+		Name:  "vmstats",
+		Usage: "Collect per opcode execution statistics (see debug_vmStats)",
+	}
 	BacktraceAtFlag = cli.GenericFlag{
 		Name:  "backtrace_at",
 		Usage: "When set to a file and line number holding a logging statement a stack trace will be written to the Info log",
@@ -170,6 +261,20 @@ var (
 		Usage: "Port on which the profiler should listen",
 		Value: 6060,
 	}
+	UpdateCheckFlag = cli.StringFlag{
+		Name:  "updatecheck",
+		Usage: "URL of a signed release manifest to check for new versions (disabled if empty)",
+		Value: "",
+	}
+	UpdateSignerFlag = cli.StringFlag{
+		Name:  "updatecheck.signer",
+		Usage: "Address of the key which signs the release manifest",
+		Value: "",
+	}
+	BuildInfoFlag = cli.BoolFlag{
+		Name:  "buildinfo",
+		Usage: "Print the build information as JSON, e.g. for bug reports, and exit",
+	}
 
 	// RPC settings
 	RPCEnabledFlag = cli.BoolFlag{
@@ -188,15 +293,61 @@ var (
 	}
 	RPCCORSDomainFlag = cli.StringFlag{
 		Name:  "rpccorsdomain",
-		Usage: "Domain on which to send Access-Control-Allow-Origin header",
+		Usage: "Comma separated list of domains from which to accept cross origin requests (browser enforced), * for any",
 		Value: "",
 	}
+	RPCFilterTimeoutFlag = cli.DurationFlag{
+		Name:  "rpcfiltertimeout",
+		Usage: "Filters not polled for this long are uninstalled",
+		Value: 5 * time.Minute,
+	}
+	RPCLogFlag = cli.BoolFlag{
+		Name:  "rpclog",
+		Usage: "Log every JSON-RPC request with its duration and origin",
+	}
+	RPCSlowCallFlag = cli.DurationFlag{
+		Name:  "rpcslowcall",
+		Usage: "Log JSON-RPC calls taking longer than this as slow (0 = off), counters are available as debug_rpcStats",
+		Value: time.Second,
+	}
+	RPCWorkersFlag = cli.IntFlag{
+		Name:  "rpcworkers",
+		Usage: "Number of JSON-RPC requests executed at the same time",
+		Value: rpc.DefaultWorkers,
+	}
+	RPCReadOnlyFlag = cli.BoolFlag{
+		Name:  "rpc.readonly",
+		Usage: "Serve only JSON-RPC methods without side effects (no transactions, signing, accounts, mining or admin)",
+	}
+	RPCCertFlag = cli.StringFlag{
+		Name:  "rpccert",
+		Usage: "TLS certificate file (PEM) to serve JSON-RPC over HTTPS, requires --rpckey",
+	}
+	RPCKeyFlag = cli.StringFlag{
+		Name:  "rpckey",
+		Usage: "TLS private key file (PEM) of the --rpccert certificate",
+	}
+	RPCAuthFlag = cli.StringFlag{
+		Name:  "rpcauth",
+		Usage: "Comma separated JSON-RPC modules callable only with the secret in <datadir>/rpcsecret as bearer token or basic auth password (empty = none)",
+		Value: strings.Join(rpc.DefaultAuthModules, ","),
+	}
+	RPCMaxFiltersFlag = cli.IntFlag{
+		Name:  "rpcmaxfilters",
+		Usage: "Maximum number of filters installed over RPC at the same time (0 = no limit)",
+		Value: xeth.DefaultMaxFilters,
+	}
 	// Network Settings
 	MaxPeersFlag = cli.IntFlag{
 		Name:  "maxpeers",
 		Usage: "Maximum number of network peers",
 		Value: 16,
 	}
+	DialRatioFlag = cli.IntFlag{
+		Name:  "dialratio",
+		Usage: "Keep 1/N of the peer slots free for peers we dial, inbound connections can't take them",
+		Value: 5,
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -204,9 +355,85 @@ var (
 	}
 	BootnodesFlag = cli.StringFlag{
 		Name:  "bootnodes",
-		Usage: "Space-separated enode URLs for discovery bootstrap",
+		Usage: "Space-separated enode URLs for discovery bootstrap (IPv6 hosts in brackets, DNS names are re-resolved periodically)",
+		Value: "",
+	}
+	CheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "Comma-separated trusted blocks as number=hash, replacing the built-in checkpoints",
 		Value: "",
 	}
+	TxLookupFlag = cli.StringFlag{
+		Name:  "txlookup",
+		Usage: "Transaction indexes to maintain: basic (by hash) or full (also by sender and recipient address)",
+		Value: "basic",
+	}
+	DatabaseEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Database engine of new databases: leveldb or boltdb",
+		Value: ethdb.LevelDBEngine,
+	}
+	AncientThresholdFlag = cli.IntFlag{
+		Name:  "ancient.threshold",
+		Usage: "Move blocks this many blocks behind the head to the append-only ancient store (0 = disabled)",
+		Value: 0,
+	}
+	GenesisFileFlag = cli.StringFlag{
+		Name:  "genesis",
+		Usage: "Genesis JSON file with the chain configuration (config) and initial accounts (alloc) of a private chain",
+	}
+	ClockDriftFlag = cli.IntFlag{
+		Name:  "clockdrift",
+		Usage: "Number of seconds block timestamps may be ahead of the local clock (0 = default of 4)",
+		Value: 0,
+	}
+	FixedDifficultyFlag = cli.IntFlag{
+		Name:  "fixeddifficulty",
+		Usage: "Difficulty of every block after genesis, overrides the genesis config (0 = adjusted to the block time)",
+		Value: 0,
+	}
+	DurationLimitFlag = cli.IntFlag{
+		Name:  "durationlimit",
+		Usage: "Block time in seconds below which the difficulty goes up, overrides the genesis config (0 = default of 8)",
+		Value: 0,
+	}
+	MinGasLimitFlag = cli.IntFlag{
+		Name:  "mingaslimit",
+		Usage: "Lowest gas limit of a block, overrides the genesis config (0 = default of 125000)",
+		Value: 0,
+	}
+	SubSecondBlocksFlag = cli.BoolFlag{
+		Name:  "subsecondblocks",
+		Usage: "Allow blocks with the timestamp of their parent, for private chains with more than one block per second",
+	}
+	CliqueSignersFlag = cli.StringFlag{
+		Name:  "clique.signers",
+		Usage: "Comma separated addresses of the proof-of-authority signers, enables clique sealing instead of proof of work",
+	}
+	CliquePeriodFlag = cli.IntFlag{
+		Name:  "clique.period",
+		Usage: "Minimum number of seconds between proof-of-authority blocks, overrides the genesis config",
+		Value: 0,
+	}
+	MaxTxSizeFlag = cli.IntFlag{
+		Name:  "maxtxsize",
+		Usage: "Maximum encoded size in bytes of transactions accepted from peers (0 = default of 32KB)",
+		Value: 0,
+	}
+	MaxBlockSizeFlag = cli.IntFlag{
+		Name:  "maxblocksize",
+		Usage: "Maximum encoded size in bytes of blocks accepted from peers (0 = default of 1MB)",
+		Value: 0,
+	}
+	MaxMsgSizeFlag = cli.IntFlag{
+		Name:  "maxmsgsize",
+		Usage: "Maximum size in bytes of eth protocol messages accepted from peers (0 = default of 10MB)",
+		Value: 0,
+	}
+	NoDataDirLockFlag = cli.BoolFlag{
+		Name:  "datadir.nolock",
+		Usage: "Don't lock the data directory (only for setups deliberately sharing it between processes)",
+	}
 	NodeKeyFileFlag = cli.StringFlag{
 		Name:  "nodekey",
 		Usage: "P2P node key file",
@@ -224,13 +451,64 @@ var (
 		Name:  "shh",
 		Usage: "Whether the whisper sub-protocol is enabled",
 	}
+	WhisperPasswordFileFlag = cli.StringFlag{
+		Name:  "shhpassword",
+		Usage: "Path to a password file encrypting the saved whisper keys (default: encrypt with the node key)",
+		Value: "",
+	}
 	JSpathFlag = cli.StringFlag{
 		Name:  "jspath",
 		Usage: "JS library path to be used with console and js subcommands",
 		Value: ".",
 	}
+	PreloadJSFlag = cli.StringFlag{
+		Name:  "preload",
+		Usage: "Comma-separated JS files to load before the console and js subcommands run",
+		Value: "",
+	}
+	ExecFlag = cli.StringFlag{
+		Name:  "exec",
+		Usage: "JS statement to evaluate instead of executing files",
+		Value: "",
+	}
+	MergeDbFlag = cli.BoolFlag{
+		Name:  "merge",
+		Usage: "Fold the separate blockchain, state and extra databases into one",
+	}
+	ExportFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Export format: rlp, or json for one JSON object per block and line",
+		Value: "rlp",
+	}
+	BenchDurationFlag = cli.DurationFlag{
+		Name:  "duration",
+		Usage: "How long to mine for the benchmark",
+		Value: 30 * time.Second,
+	}
 )
 
+func joinInts(ints []int) string {
+	s := make([]string, len(ints))
+	for i, n := range ints {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ",")
+}
+
+// GetProtocolVersions parses the ETH protocol versions given on the
+// command line.
+func GetProtocolVersions(ctx *cli.Context) []int {
+	var versions []int
+	for _, s := range strings.Split(ctx.GlobalString(ProtocolVersionFlag.Name), ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || v <= 0 {
+			Fatalf("Option %s: invalid version %q", ProtocolVersionFlag.Name, s)
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
 func GetNAT(ctx *cli.Context) nat.Interface {
 	natif, err := nat.Parse(ctx.GlobalString(NATFlag.Name))
 	if err != nil {
@@ -257,14 +535,43 @@ func GetNodeKey(ctx *cli.Context) (key *ecdsa.PrivateKey) {
 	return key
 }
 
-func MakeEthConfig(clientID, version string, ctx *cli.Context) *eth.Config {
+// GetWhisperPassphrase returns the content of the whisper password file, or
+// an empty string if none is given.
+func GetWhisperPassphrase(ctx *cli.Context) string {
+	file := ctx.GlobalString(WhisperPasswordFileFlag.Name)
+	if file == "" {
+		return ""
+	}
+	pass, err := ioutil.ReadFile(file)
+	if err != nil {
+		Fatalf("Option %q: %v", WhisperPasswordFileFlag.Name, err)
+	}
+	return strings.TrimRight(string(pass), "\r\n")
+}
+
+func MakeEthConfig(clientID, clientVersion string, ctx *cli.Context) *eth.Config {
 	// Set verbosity on glog
 	glog.SetV(ctx.GlobalInt(LogLevelFlag.Name))
 	// Set the log type
 	//glog.SetToStderr(ctx.GlobalBool(LogToStdErrFlag.Name))
 	glog.SetToStderr(true)
-	// Set the log dir
-	glog.SetLogDir(ctx.GlobalString(LogFileFlag.Name))
+	// Set the log backend
+	if err := glog.SetBackend(ctx.GlobalString(LogBackendFlag.Name)); err != nil {
+		Fatalf("Could not set log backend: %v", err)
+	}
+	// Write rotated log files if a log dir is given
+	if dir := ctx.GlobalString(LogDirFlag.Name); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			Fatalf("Could not create log dir: %v", err)
+		}
+		glog.SetLogDir(dir)
+		glog.SetToStderr(false)
+		glog.SetAlsoToStderr(true)
+		glog.SetMaxSize(uint64(ctx.GlobalInt(LogMaxSizeFlag.Name)) * 1024 * 1024)
+		glog.SetMaxAge(ctx.GlobalDuration(LogMaxAgeFlag.Name))
+		glog.SetMaxFiles(ctx.GlobalInt(LogMaxFilesFlag.Name))
+		glog.SetCompress(ctx.GlobalBool(LogCompressFlag.Name))
+	}
 
 	customName := ctx.GlobalString(IdentityFlag.Name)
 	if len(customName) > 0 {
@@ -272,9 +579,9 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *eth.Config {
 	}
 
 	return &eth.Config{
-		Name:               common.MakeName(clientID, version),
+		Name:               common.MakeName(clientID, version.WithCommit(clientVersion)),
 		DataDir:            ctx.GlobalString(DataDirFlag.Name),
-		ProtocolVersion:    ctx.GlobalInt(ProtocolVersionFlag.Name),
+		ProtocolVersions:   GetProtocolVersions(ctx),
 		BlockChainVersion:  ctx.GlobalInt(BlockchainVersionFlag.Name),
 		SkipBcVersionCheck: false,
 		NetworkId:          ctx.GlobalInt(NetworkIdFlag.Name),
@@ -283,39 +590,189 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *eth.Config {
 		LogJSON:            ctx.GlobalString(LogJSONFlag.Name),
 		Etherbase:          ctx.GlobalString(EtherbaseFlag.Name),
 		MinerThreads:       ctx.GlobalInt(MinerThreadsFlag.Name),
+		MinerNoSyncPause:   ctx.GlobalBool(MinerNoSyncPauseFlag.Name),
 		AccountManager:     GetAccountManager(ctx),
 		VmDebug:            ctx.GlobalBool(VMDebugFlag.Name),
+		VmStats:            ctx.GlobalBool(VMStatsFlag.Name),
 		MaxPeers:           ctx.GlobalInt(MaxPeersFlag.Name),
+		DialRatio:          ctx.GlobalInt(DialRatioFlag.Name),
 		Port:               ctx.GlobalString(ListenPortFlag.Name),
 		NAT:                GetNAT(ctx),
 		NatSpec:            ctx.GlobalBool(NatspecEnabledFlag.Name),
 		NodeKey:            GetNodeKey(ctx),
 		Shh:                ctx.GlobalBool(WhisperEnabledFlag.Name),
+		ShhPassphrase:      GetWhisperPassphrase(ctx),
 		Dial:               true,
 		BootNodes:          ctx.GlobalString(BootnodesFlag.Name),
+		Checkpoints:        ctx.GlobalString(CheckpointFlag.Name),
+		TxLookup:           ctx.GlobalString(TxLookupFlag.Name),
+		DatabaseEngine:     ctx.GlobalString(DatabaseEngineFlag.Name),
+		AncientThreshold:   GetAncientThreshold(ctx),
+		NoDataDirLock:      ctx.GlobalBool(NoDataDirLockFlag.Name),
+		UpdateURL:          ctx.GlobalString(UpdateCheckFlag.Name),
+		UpdateSigner:       GetUpdateSigner(ctx),
+		Version:            clientVersion,
+		ChainConfig:        GetChainConfig(ctx),
+		MaxTxSize:          GetSizeLimit(ctx, MaxTxSizeFlag),
+		MaxBlockSize:       GetSizeLimit(ctx, MaxBlockSizeFlag),
+		MaxMsgSize:         uint32(GetSizeLimit(ctx, MaxMsgSizeFlag)),
 	}
 }
 
-func GetChain(ctx *cli.Context) (*core.ChainManager, common.Database, common.Database) {
-	dataDir := ctx.GlobalString(DataDirFlag.Name)
+// GetSizeLimit returns the size limit set by flag, 0 if unset.
+func GetSizeLimit(ctx *cli.Context, flag cli.IntFlag) uint64 {
+	size := ctx.GlobalInt(flag.Name)
+	if size < 0 || int64(size) > math.MaxUint32 {
+		Fatalf("Option %s: must be between 0 and %d", flag.Name, uint32(math.MaxUint32))
+	}
+	return uint64(size)
+}
 
-	blockDb, err := ethdb.NewLDBDatabase(path.Join(dataDir, "blockchain"))
-	if err != nil {
-		Fatalf("Could not open database: %v", err)
+// genesisSpec is the content of a genesis file.
+type genesisSpec struct {
+	Config *params.ChainConfig `json:"config"`
+	Alloc  json.RawMessage     `json:"alloc"`
+}
+
+// GetChainConfig returns the chain configuration of the genesis file, or
+// the default one, adjusted by the command line flags. The accounts of the
+// genesis file replace the default genesis accounts, it must be called
+// before the chain manager is created.
+func GetChainConfig(ctx *cli.Context) *params.ChainConfig {
+	config := *params.DefaultChainConfig
+	if file := ctx.GlobalString(GenesisFileFlag.Name); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			Fatalf("Could not read genesis file: %v", err)
+		}
+		var spec genesisSpec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			Fatalf("Invalid genesis file %s: %v", file, err)
+		}
+		if spec.Config != nil {
+			config = *spec.Config
+		}
+		if len(spec.Alloc) > 0 {
+			core.GenesisData = spec.Alloc
+		}
 	}
 
-	stateDb, err := ethdb.NewLDBDatabase(path.Join(dataDir, "state"))
-	if err != nil {
-		Fatalf("Could not open database: %v", err)
+	if drift := nonNegativeInt(ctx, ClockDriftFlag); drift > 0 {
+		config.ClockDrift = uint64(drift)
+	}
+	if diff := nonNegativeInt(ctx, FixedDifficultyFlag); diff > 0 {
+		config.FixedDifficulty = big.NewInt(int64(diff))
+	}
+	if limit := nonNegativeInt(ctx, DurationLimitFlag); limit > 0 {
+		config.DurationLimit = big.NewInt(int64(limit))
+	}
+	if limit := nonNegativeInt(ctx, MinGasLimitFlag); limit > 0 {
+		config.MinGasLimit = big.NewInt(int64(limit))
+	}
+	if ctx.GlobalBool(SubSecondBlocksFlag.Name) {
+		config.SubSecondBlocks = true
+	}
+
+	if list := ctx.GlobalString(CliqueSignersFlag.Name); list != "" {
+		hexes := strings.Split(list, ",")
+		for i := range hexes {
+			hexes[i] = strings.TrimSpace(hexes[i])
+		}
+		signers, err := params.ParseSigners(hexes)
+		if err != nil {
+			Fatalf("Option %s: %v", CliqueSignersFlag.Name, err)
+		}
+		clique := params.CliqueConfig{}
+		if config.Clique != nil {
+			clique = *config.Clique
+		}
+		clique.Signers = signers
+		config.Clique = &clique
+	}
+	if period := nonNegativeInt(ctx, CliquePeriodFlag); period > 0 {
+		if config.Clique == nil {
+			Fatalf("Option %s: requires %s or a clique section in the genesis config", CliquePeriodFlag.Name, CliqueSignersFlag.Name)
+		}
+		clique := *config.Clique
+		clique.Period = uint64(period)
+		config.Clique = &clique
+	}
+	return &config
+}
+
+// nonNegativeInt returns the value of flag, which must not be negative.
+func nonNegativeInt(ctx *cli.Context, flag cli.IntFlag) int {
+	v := ctx.GlobalInt(flag.Name)
+	if v < 0 {
+		Fatalf("Option %s: must not be negative", flag.Name)
+	}
+	return v
+}
+
+// GetUpdateSigner returns the signer of the release manifest. It is
+// required if update checks are enabled.
+func GetUpdateSigner(ctx *cli.Context) common.Address {
+	if ctx.GlobalString(UpdateCheckFlag.Name) == "" {
+		return common.Address{}
+	}
+	signer := common.FromHex(ctx.GlobalString(UpdateSignerFlag.Name))
+	if len(signer) != len(common.Address{}) {
+		Fatalf("Option %s: must be the address of the release signer when %s is set", UpdateSignerFlag.Name, UpdateCheckFlag.Name)
+	}
+	return common.BytesToAddress(signer)
+}
+
+// GetAncientThreshold returns the ancient store threshold set on the command
+// line.
+func GetAncientThreshold(ctx *cli.Context) uint64 {
+	threshold := ctx.GlobalInt(AncientThresholdFlag.Name)
+	if threshold < 0 {
+		Fatalf("Option %s: must not be negative", AncientThresholdFlag.Name)
+	}
+	return uint64(threshold)
+}
+
+// MakeNatSpecOptions creates the NatSpec document retrieval options, caching
+// documents in the data directory.
+func MakeNatSpecOptions(ctx *cli.Context) *natspec.Options {
+	return &natspec.Options{
+		Cache:         natspec.NewCache(path.Join(ctx.GlobalString(DataDirFlag.Name), "natspec"), ctx.GlobalDuration(NatspecCacheTTLFlag.Name)),
+		Offline:       ctx.GlobalBool(NatspecOfflineFlag.Name),
+		DefaultNotice: ctx.GlobalString(NatspecNoticeFlag.Name),
 	}
+}
+
+// chainDirLock keeps the data directory locked by GetChain until the
+// process exits.
+var chainDirLock *flock.Lock
 
-	extraDb, err := ethdb.NewLDBDatabase(path.Join(dataDir, "extra"))
+func GetChain(ctx *cli.Context) (*core.ChainManager, common.Database, common.Database) {
+	dataDir := ctx.GlobalString(DataDirFlag.Name)
+	engine := ctx.GlobalString(DatabaseEngineFlag.Name)
+	if !ctx.GlobalBool(NoDataDirLockFlag.Name) {
+		lock, err := eth.LockDataDir(dataDir)
+		if err != nil {
+			Fatalf("%v", err)
+		}
+		chainDirLock = lock
+	}
+	dbs, err := eth.OpenDatabases(dataDir, func(path string) (common.Database, error) {
+		return ethdb.Open(engine, path)
+	})
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
+	if eth.HasFreezer(dataDir) {
+		if err := dbs.OpenFreezer(dataDir); err != nil {
+			Fatalf("Could not open ancient store: %v", err)
+		}
+	}
+	blockDb, stateDb, extraDb := dbs.Block, dbs.State, dbs.Extra
 
+	config := GetChainConfig(ctx)
 	eventMux := new(event.TypeMux)
 	chainManager := core.NewChainManager(blockDb, stateDb, eventMux)
+	chainManager.SetConfig(config)
 	pow := ethash.New(chainManager)
 	txPool := core.NewTxPool(eventMux, chainManager.State)
 	blockProcessor := core.NewBlockProcessor(stateDb, extraDb, pow, txPool, chainManager, eventMux)
@@ -324,20 +781,104 @@ func GetChain(ctx *cli.Context) (*core.ChainManager, common.Database, common.Dat
 	return chainManager, blockDb, stateDb
 }
 
+// HDWalletFile returns the path of the HD wallet file.
+func HDWalletFile(ctx *cli.Context) string {
+	return path.Join(ctx.GlobalString(DataDirFlag.Name), "hdwallet.json")
+}
+
 func GetAccountManager(ctx *cli.Context) *accounts.Manager {
 	dataDir := ctx.GlobalString(DataDirFlag.Name)
-	ks := crypto.NewKeyStorePassphrase(path.Join(dataDir, "keys"))
-	return accounts.NewManager(ks)
+	keyDir := path.Join(dataDir, "keys")
+
+	var am *accounts.Manager
+	if socket := ctx.GlobalString(SignerFlag.Name); socket != "" {
+		signer, err := accounts.NewExternalSigner(socket)
+		if err != nil {
+			Fatalf("Could not connect to the signer: %v", err)
+		}
+		am = accounts.NewSignerManager(signer)
+	} else if ctx.GlobalBool(HDWalletFlag.Name) {
+		w, err := accounts.OpenHDWallet(HDWalletFile(ctx))
+		if os.IsNotExist(err) {
+			Fatalf("No HD wallet in %s, create one with geth account hd new", dataDir)
+		} else if err != nil {
+			Fatalf("Could not open HD wallet: %v", err)
+		}
+		am = accounts.NewManager(w)
+	} else {
+		ks, err := crypto.NewKeyStorePassphraseParams(keyDir, ctx.GlobalInt(ScryptNFlag.Name), ctx.GlobalInt(ScryptPFlag.Name))
+		if err != nil {
+			Fatalf("Invalid key store options: %v", err)
+		}
+		am = accounts.NewManager(ks)
+		// pick up keys added to the directory while running
+		am.Watch(keyDir)
+	}
+	if err := am.SetAddressBook(path.Join(dataDir, "addressbook.json")); err != nil {
+		Fatalf("Could not load address book: %v", err)
+	}
+	if ctx.GlobalBool(UsbFlag.Name) {
+		addLedgers(am)
+	}
+	return am
 }
 
-func StartRPC(eth *eth.Ethereum, ctx *cli.Context) {
+// addLedgers adds the first account of every connected Ledger device to am.
+func addLedgers(am *accounts.Manager) {
+	wallets, err := accounts.OpenLedgers()
+	if err != nil {
+		glog.V(logger.Warn).Infoln("Could not open USB wallets:", err)
+	}
+	path, _ := crypto.ParseDerivationPath(crypto.DefaultHDPath + "/0")
+	for _, w := range wallets {
+		acct, err := w.Derive(path)
+		if err != nil {
+			glog.V(logger.Warn).Infof("Could not get account of %s: %v", w.URL(), err)
+			w.Close()
+			continue
+		}
+		glog.V(logger.Info).Infof("Using account %x of %s", acct.Address, w.URL())
+		am.AddWallet(w)
+	}
+}
+
+// MakeRPCConfig creates the RPC server configuration from the command line
+// flags. It's used for servers started on the command line and in the
+// console.
+func MakeRPCConfig(ctx *cli.Context) rpc.RpcConfig {
 	config := rpc.RpcConfig{
 		ListenAddress: ctx.GlobalString(RPCListenAddrFlag.Name),
 		ListenPort:    uint(ctx.GlobalInt(RPCPortFlag.Name)),
 		CorsDomain:    ctx.GlobalString(RPCCORSDomainFlag.Name),
+		Workers:       ctx.GlobalInt(RPCWorkersFlag.Name),
+		ReadOnly:      ctx.GlobalBool(RPCReadOnlyFlag.Name),
+		CertFile:      ctx.GlobalString(RPCCertFlag.Name),
+		KeyFile:       ctx.GlobalString(RPCKeyFlag.Name),
+	}
+
+	for _, module := range strings.Split(ctx.GlobalString(RPCAuthFlag.Name), ",") {
+		if module = strings.TrimSpace(module); module != "" {
+			config.AuthModules = append(config.AuthModules, module)
+		}
+	}
+	if len(config.AuthModules) > 0 {
+		secret, err := rpc.LoadSecret(path.Join(ctx.GlobalString(DataDirFlag.Name), "rpcsecret"))
+		if err != nil {
+			Fatalf("Could not load RPC secret: %v", err)
+		}
+		config.AuthSecret = secret
 	}
+	return config
+}
+
+func StartRPC(eth *eth.Ethereum, ctx *cli.Context) {
+	config := MakeRPCConfig(ctx)
+
+	rpc.LogRequests = ctx.GlobalBool(RPCLogFlag.Name)
+	rpc.SlowCallThreshold = ctx.GlobalDuration(RPCSlowCallFlag.Name)
 
 	xeth := xeth.New(eth, nil)
+	xeth.SetFilterLimits(ctx.GlobalDuration(RPCFilterTimeoutFlag.Name), ctx.GlobalInt(RPCMaxFiltersFlag.Name))
 	_ = rpc.Start(xeth, config)
 }
 
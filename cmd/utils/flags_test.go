@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// newTestContext returns a context with the given global flags set.
+func newTestContext(t *testing.T, flags []cli.Flag, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cli.NewContext(nil, set, set)
+}
+
+var chainConfigFlags = []cli.Flag{
+	GenesisFileFlag, ClockDriftFlag, FixedDifficultyFlag, DurationLimitFlag, MinGasLimitFlag, SubSecondBlocksFlag,
+	CliqueSignersFlag, CliquePeriodFlag,
+}
+
+func TestChainConfigGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	genesis := filepath.Join(dir, "genesis.json")
+	alloc := `{"0000000000000000000000000000000000000001": {"balance": "5"}}`
+	err = ioutil.WriteFile(genesis, []byte(`{
+		"config": {"fixedDifficulty": 100, "durationLimit": 2, "minGasLimit": 7000, "subSecondBlocks": true},
+		"alloc": `+alloc+`
+	}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(data []byte) { core.GenesisData = data }(core.GenesisData)
+
+	config := GetChainConfig(newTestContext(t, chainConfigFlags, "--genesis", genesis))
+	if config.FixedDifficulty == nil || config.FixedDifficulty.Int64() != 100 {
+		t.Errorf("fixed difficulty %v, want 100", config.FixedDifficulty)
+	}
+	if config.DurationLimit == nil || config.DurationLimit.Int64() != 2 {
+		t.Errorf("duration limit %v, want 2", config.DurationLimit)
+	}
+	if config.MinGasLimit == nil || config.MinGasLimit.Int64() != 7000 {
+		t.Errorf("min gas limit %v, want 7000", config.MinGasLimit)
+	}
+	if !config.SubSecondBlocks {
+		t.Error("sub-second blocks not enabled")
+	}
+	if string(core.GenesisData) != alloc {
+		t.Errorf("genesis accounts not replaced: %s", core.GenesisData)
+	}
+
+	// flags override the genesis file
+	config = GetChainConfig(newTestContext(t, chainConfigFlags, "--genesis", genesis, "--fixeddifficulty", "200", "--mingaslimit", "9000"))
+	if config.FixedDifficulty.Int64() != 200 || config.MinGasLimit.Int64() != 9000 {
+		t.Errorf("flags didn't override genesis: difficulty %v, min gas limit %v", config.FixedDifficulty, config.MinGasLimit)
+	}
+	if config.DurationLimit.Int64() != 2 {
+		t.Errorf("duration limit %v, want 2 from genesis", config.DurationLimit)
+	}
+}
+
+func TestChainConfigFlags(t *testing.T) {
+	config := GetChainConfig(newTestContext(t, chainConfigFlags, "--durationlimit", "1", "--subsecondblocks", "--clockdrift", "10"))
+	if config.DurationLimit == nil || config.DurationLimit.Int64() != 1 {
+		t.Errorf("duration limit %v, want 1", config.DurationLimit)
+	}
+	if !config.SubSecondBlocks {
+		t.Error("sub-second blocks not enabled")
+	}
+	if config.ClockDrift != 10 {
+		t.Errorf("clock drift %d, want 10", config.ClockDrift)
+	}
+	if config.FixedDifficulty != nil || config.MinGasLimit != nil {
+		t.Errorf("unset flags changed the config: %+v", config)
+	}
+}
+
+func TestChainConfigClique(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	genesis := filepath.Join(dir, "genesis.json")
+	err = ioutil.WriteFile(genesis, []byte(`{"config": {"clique": {"period": 5, "signers": ["0x0000000000000000000000000000000000000001"]}}}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := GetChainConfig(newTestContext(t, chainConfigFlags, "--genesis", genesis))
+	if config.Clique == nil || config.Clique.Period != 5 || len(config.Clique.Signers) != 1 || config.Clique.Signers[0] != (common.Address{19: 1}) {
+		t.Fatalf("clique config from genesis: %+v", config.Clique)
+	}
+
+	// the signers flag replaces the signers of the genesis file
+	config = GetChainConfig(newTestContext(t, chainConfigFlags, "--genesis", genesis, "--clique.signers", "0x0000000000000000000000000000000000000002, 0x0000000000000000000000000000000000000003"))
+	if config.Clique.Period != 5 || len(config.Clique.Signers) != 2 || config.Clique.Signers[1] != (common.Address{19: 3}) {
+		t.Fatalf("clique config from flags: %+v", config.Clique)
+	}
+
+	// without a genesis file the flags enable clique
+	config = GetChainConfig(newTestContext(t, chainConfigFlags, "--clique.signers", "0x0000000000000000000000000000000000000002", "--clique.period", "3"))
+	if config.Clique == nil || config.Clique.Period != 3 || len(config.Clique.Signers) != 1 {
+		t.Fatalf("clique config from flags: %+v", config.Clique)
+	}
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+)
+
+// Passphrases of new accounts must be at least this long and have at least
+// this many bits of estimated entropy. Short or predictable passphrases are
+// found by brute force, as happened to many brain wallets.
+const (
+	minPassphraseLength  = 10
+	minPassphraseEntropy = 60
+)
+
+// CheckPassphrase returns an error if passphrase is too weak to protect a
+// key. The entropy is estimated from the character classes used, characters
+// repeating or continuing a sequence (like aaa or 123) don't count.
+func CheckPassphrase(passphrase string) error {
+	chars := []rune(passphrase)
+	if len(chars) < minPassphraseLength {
+		return fmt.Errorf("passphrase must have at least %d characters", minPassphraseLength)
+	}
+	var lower, upper, digit, other bool
+	effective := 0
+	for i, c := range chars {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			other = true
+		}
+		if i > 0 {
+			if d := c - chars[i-1]; d >= -1 && d <= 1 {
+				continue
+			}
+		}
+		effective++
+	}
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if other {
+		pool += 33
+	}
+	if bits := float64(effective) * math.Log2(float64(pool)); bits < minPassphraseEntropy {
+		return fmt.Errorf("passphrase is too predictable (about %.0f bits of entropy, need %d), use a longer one mixing letters, digits and symbols", bits, minPassphraseEntropy)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestCheckPassphrase(t *testing.T) {
+	tests := map[string]bool{
+		"":                             false,
+		"short":                        false,
+		"password12":                   false,
+		"aaaaaaaaaaaaaaaaaaaaaaaa":     false,
+		"abcdefghijklmnopqrstuvwxyz":   false,
+		"1234567890123456":             false,
+		"Password123!":                 false,
+		"Plum-Rain-42-Kettle":          true,
+		"correct horse battery staple": true,
+		"tr0ub4dor&3xq":                true,
+	}
+	for pass, ok := range tests {
+		if err := CheckPassphrase(pass); (err == nil) != ok {
+			t.Errorf("%q: got %v, want ok=%v", pass, err, ok)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Package flock implements advisory locking of files, used to keep several
+processes from opening the same data directory.
+
+The lock is held by the process until Release is called or the process
+exits, so a crashed process never leaves a stale lock behind.
+*/
+package flock
+
+import "errors"
+
+// ErrLocked is returned by New if another process holds the lock.
+var ErrLocked = errors.New("file is locked by another process")
+
+// Lock is an exclusive lock on a file.
+type Lock struct {
+	path    string
+	release func() error
+}
+
+// New creates the file at path if necessary and locks it. It returns
+// ErrLocked without blocking if the lock is already held.
+func New(path string) (*Lock, error) {
+	release, err := lock(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{path: path, release: release}, nil
+}
+
+// Path returns the path of the locked file.
+func (self *Lock) Path() string {
+	return self.path
+}
+
+// Release unlocks the file. The file itself is left in place.
+func (self *Lock) Release() error {
+	return self.release()
+}
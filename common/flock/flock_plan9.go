@@ -0,0 +1,16 @@
+package flock
+
+import "os"
+
+// lock opens the file in exclusive use mode, which fails while another
+// process has it open.
+func lock(path string) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, os.ModeExclusive|0600)
+	if err != nil {
+		if os.IsExist(err) || os.IsPermission(err) {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return f.Close, nil
+}
@@ -0,0 +1,33 @@
+package flock
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "LOCK")
+
+	l, err := New(path)
+	if err != nil {
+		t.Fatalf("lock error: %v", err)
+	}
+	if _, err := New(path); err != ErrLocked {
+		t.Fatalf("second lock: got %v, want ErrLocked", err)
+	}
+	if err := l.Release(); err != nil {
+		t.Fatalf("release error: %v", err)
+	}
+	l, err = New(path)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	l.Release()
+}
@@ -0,0 +1,23 @@
+package flock
+
+import "syscall"
+
+// ERROR_SHARING_VIOLATION
+const errSharingViolation syscall.Errno = 32
+
+// lock opens the file without sharing, which fails while another process
+// has it open.
+func lock(path string) (func() error, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if err == errSharingViolation {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return func() error { return syscall.CloseHandle(h) }, nil
+}
@@ -0,0 +1,53 @@
+package natspec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Cache stores NatSpec documents on disk keyed by the hash of the contract
+// code they describe so that the registry doesn't have to be consulted for
+// every transaction. Entries older than TTL are considered stale, a TTL of
+// zero keeps entries forever.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+}
+
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+func (self *Cache) path(codeHash common.Hash) string {
+	return filepath.Join(self.Dir, codeHash.Hex()[2:]+".json")
+}
+
+// Get returns the cached document for the contract code hash. Stale
+// documents are only returned if stale is true.
+func (self *Cache) Get(codeHash common.Hash, stale bool) (content []byte, ok bool) {
+	path := self.path(codeHash)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if !stale && self.TTL > 0 && time.Since(info.ModTime()) > self.TTL {
+		return nil, false
+	}
+	content, err = ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// Put stores the document for the contract code hash.
+func (self *Cache) Put(codeHash common.Hash, content []byte) error {
+	if err := os.MkdirAll(self.Dir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(self.path(codeHash), content, 0600)
+}
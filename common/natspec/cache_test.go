@@ -0,0 +1,44 @@
+package natspec
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "natspec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := NewCache(dir, time.Hour)
+	codeHash := common.HexToHash("0x01")
+	if _, ok := cache.Get(codeHash, false); ok {
+		t.Errorf("expected no document for unknown code hash")
+	}
+
+	doc := []byte(`{"userdoc":{}}`)
+	if err := cache.Put(codeHash, doc); err != nil {
+		t.Fatal(err)
+	}
+	if content, ok := cache.Get(codeHash, false); !ok || string(content) != string(doc) {
+		t.Errorf("expected cached document %s, got %s", doc, content)
+	}
+
+	// make the entry stale
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cache.path(codeHash), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get(codeHash, false); ok {
+		t.Errorf("expected stale document to be ignored")
+	}
+	if _, ok := cache.Get(codeHash, true); !ok {
+		t.Errorf("expected stale document to be returned if requested")
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/ethereum/go-ethereum/common/docserver"
 	"github.com/ethereum/go-ethereum/common/resolver"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/xeth"
 )
 
@@ -30,11 +32,25 @@ func getFallbackNotice(comment, tx string) string {
 
 }
 
+// Options configure how NatSpec documents are retrieved.
+type Options struct {
+	Cache         *Cache // documents fetched before, nil disables caching
+	Offline       bool   // only use cached documents, never the registry
+	DefaultNotice string // notice shown if no document is found
+}
+
 func GetNotice(xeth *xeth.XEth, tx string, http *docserver.DocServer) (notice string) {
+	return GetNoticeWithOptions(xeth, tx, http, nil)
+}
 
-	ns, err := New(xeth, tx, http)
+func GetNoticeWithOptions(xeth *xeth.XEth, tx string, http *docserver.DocServer, opts *Options) (notice string) {
+
+	ns, err := NewWithOptions(xeth, tx, http, opts)
 	if err != nil {
 		if ns == nil {
+			if opts != nil && opts.DefaultNotice != "" {
+				return opts.DefaultNotice + ": " + tx
+			}
 			return getFallbackNotice("no NatSpec info found for contract", tx)
 		} else {
 			return getFallbackNotice("invalid NatSpec info", tx)
@@ -52,6 +68,13 @@ func GetNotice(xeth *xeth.XEth, tx string, http *docserver.DocServer) (notice st
 }
 
 func New(xeth *xeth.XEth, tx string, http *docserver.DocServer) (self *NatSpec, err error) {
+	return NewWithOptions(xeth, tx, http, nil)
+}
+
+func NewWithOptions(xeth *xeth.XEth, tx string, http *docserver.DocServer, opts *Options) (self *NatSpec, err error) {
+	if opts == nil {
+		opts = new(Options)
+	}
 
 	// extract contract address from tx
 
@@ -74,7 +97,37 @@ func New(xeth *xeth.XEth, tx string, http *docserver.DocServer) (self *NatSpec,
 	}
 	codehex := xeth.CodeAt(contractAddress)
 	codeHash := common.BytesToHash(crypto.Sha3(common.Hex2Bytes(codehex[2:])))
-	// parse out host/domain
+
+	content, err := fetchContent(xeth, codeHash, http, opts)
+	if err != nil {
+		return
+	}
+
+	// get abi, userdoc
+	var obj2 map[string]json.RawMessage
+	err = json.Unmarshal(content, &obj2)
+	if err != nil {
+		return
+	}
+
+	abi := []byte(obj2["abi"])
+	userdoc := []byte(obj2["userdoc"])
+
+	self, err = NewWithDocs(abi, userdoc, tx)
+	return
+}
+
+// fetchContent returns the NatSpec document of the contract code, from the
+// cache if possible. In offline mode stale cached documents are used too.
+func fetchContent(xeth *xeth.XEth, codeHash common.Hash, http *docserver.DocServer, opts *Options) (content []byte, err error) {
+	if opts.Cache != nil {
+		if content, ok := opts.Cache.Get(codeHash, opts.Offline); ok {
+			return content, nil
+		}
+	}
+	if opts.Offline {
+		return nil, fmt.Errorf("NatSpec error: no cached document in offline mode")
+	}
 
 	// set up nameresolver with natspecreg + urlhint contract addresses
 	res := resolver.New(
@@ -90,23 +143,17 @@ func New(xeth *xeth.XEth, tx string, http *docserver.DocServer) (self *NatSpec,
 	}
 
 	// get content via http client and authenticate content using hash
-	content, err := http.GetAuthContent(uri, hash)
+	content, err = http.GetAuthContent(uri, hash)
 	if err != nil {
 		return
 	}
 
-	// get abi, userdoc
-	var obj2 map[string]json.RawMessage
-	err = json.Unmarshal(content, &obj2)
-	if err != nil {
-		return
+	if opts.Cache != nil {
+		if err := opts.Cache.Put(codeHash, content); err != nil {
+			glog.V(logger.Warn).Infoln("could not cache NatSpec document:", err)
+		}
 	}
-
-	abi := []byte(obj2["abi"])
-	userdoc := []byte(obj2["userdoc"])
-
-	self, err = NewWithDocs(abi, userdoc, tx)
-	return
+	return content, nil
 }
 
 func NewWithDocs(abiDocJson, userDocJson []byte, tx string) (self *NatSpec, err error) {
@@ -0,0 +1,227 @@
+// Package release checks a signed release manifest for new versions of the
+// client.
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+const (
+	checkInterval = 6 * time.Hour
+	fetchTimeout  = 30 * time.Second
+	maxManifest   = 64 * 1024
+)
+
+/*
+Manifest describes the current release. It is published as the JSON of a
+SignedManifest whose signature is made with the key of the release signer
+over the Keccak-256 hash of the manifest bytes:
+
+	{
+	  "manifest": {"latest": "0.9.12", "url": "...", "emergency": {"fixedIn": "0.9.12", "message": "..."}},
+	  "signature": "0x<65 bytes [R || S || V]>"
+	}
+*/
+type Manifest struct {
+	Latest    string     `json:"latest"`
+	URL       string     `json:"url"`
+	Emergency *Emergency `json:"emergency,omitempty"`
+}
+
+// Emergency announces a critical fix, e.g. of a consensus bug. Versions
+// before FixedIn are affected.
+type Emergency struct {
+	FixedIn string `json:"fixedIn"`
+	Message string `json:"message"`
+}
+
+type SignedManifest struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Signature string          `json:"signature"`
+}
+
+// Status is the result of the last check.
+type Status struct {
+	Checked   time.Time `json:"checked"`
+	Current   string    `json:"current"`
+	Latest    string    `json:"latest"`
+	URL       string    `json:"url"`
+	Outdated  bool      `json:"outdated"`
+	Emergency bool      `json:"emergency"`
+	Message   string    `json:"message"`
+	Error     string    `json:"error"`
+}
+
+// Checker periodically fetches the manifest and compares it to the running
+// version.
+type Checker struct {
+	url     string
+	signer  common.Address
+	current string
+
+	mu     sync.Mutex
+	status Status
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewChecker creates a checker for the manifest at url, which must be
+// signed by signer.
+func NewChecker(url string, signer common.Address, current string) *Checker {
+	return &Checker{
+		url:     url,
+		signer:  signer,
+		current: current,
+		status:  Status{Current: current},
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start checks for updates in the background.
+func (self *Checker) Start() {
+	self.wg.Add(1)
+	go func() {
+		defer self.wg.Done()
+
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			self.Check()
+			select {
+			case <-ticker.C:
+			case <-self.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (self *Checker) Stop() {
+	close(self.quit)
+	self.wg.Wait()
+}
+
+// Status returns the result of the last check.
+func (self *Checker) Status() Status {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.status
+}
+
+// Check fetches the manifest, updates the status and logs if the running
+// version is outdated.
+func (self *Checker) Check() Status {
+	status := Status{Checked: time.Now(), Current: self.current}
+	manifest, err := self.fetch()
+	if err != nil {
+		status.Error = err.Error()
+		glog.V(logger.Debug).Infoln("Update check failed:", err)
+	} else {
+		status.Latest, status.URL = manifest.Latest, manifest.URL
+		status.Outdated = CompareVersions(self.current, manifest.Latest) < 0
+		if e := manifest.Emergency; e != nil && CompareVersions(self.current, e.FixedIn) < 0 {
+			status.Emergency, status.Message = true, e.Message
+		}
+		switch {
+		case status.Emergency:
+			glog.V(logger.Error).Infof("CRITICAL: version %s fixes an emergency affecting this client: %s. Update now: %s", manifest.Latest, status.Message, status.URL)
+		case status.Outdated:
+			glog.V(logger.Warn).Infof("Version %s is available (running %s): %s", manifest.Latest, self.current, status.URL)
+		}
+	}
+
+	self.mu.Lock()
+	self.status = status
+	self.mu.Unlock()
+	return status
+}
+
+func (self *Checker) fetch() (*Manifest, error) {
+	client := http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(self.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", self.url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, maxManifest))
+	if err != nil {
+		return nil, err
+	}
+	return VerifyManifest(data, self.signer)
+}
+
+// VerifyManifest decodes a signed manifest and checks that it is signed by
+// signer.
+func VerifyManifest(data []byte, signer common.Address) (*Manifest, error) {
+	var signed SignedManifest
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	sig := common.FromHex(signed.Signature)
+	if len(sig) != 65 {
+		return nil, errors.New("invalid manifest signature")
+	}
+	pub, err := crypto.Ecrecover(crypto.Sha3(signed.Manifest), sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest signature: %v", err)
+	}
+	if !bytes.Equal(crypto.Sha3(pub[1:])[12:], signer.Bytes()) {
+		return nil, errors.New("manifest not signed by the release signer")
+	}
+	manifest := new(Manifest)
+	if err := json.Unmarshal(signed.Manifest, manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// CompareVersions compares two dotted version numbers like 0.9.11 and
+// returns -1, 0 or 1. Suffixes after a dash, like -1a2b3c4d, are ignored.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for len(pa) < len(pb) {
+		pa = append(pa, 0)
+	}
+	for len(pb) < len(pa) {
+		pb = append(pb, 0)
+	}
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1
+		case pa[i] > pb[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, s := range strings.Split(strings.TrimPrefix(v, "v"), ".") {
+		n, _ := strconv.Atoi(s)
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,82 @@
+package release
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func signManifest(t *testing.T, m *Manifest) ([]byte, common.Address) {
+	key, _ := crypto.GenerateKey()
+	data, _ := json.Marshal(m)
+	sig, err := crypto.Sign(crypto.Sha3(data), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, _ := json.Marshal(SignedManifest{Manifest: data, Signature: common.ToHex(sig)})
+	return signed, common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.9.11", "0.9.11", 0},
+		{"0.9.11", "0.9.12", -1},
+		{"0.9.11", "0.10.0", -1},
+		{"1.0", "0.9.11", 1},
+		{"1.0", "1.0.0", 0},
+		{"0.9.11-1a2b3c4d", "0.9.11", 0},
+		{"v1.0.1", "1.0.0", 1},
+	}
+	for _, test := range tests {
+		if got := CompareVersions(test.a, test.b); got != test.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestChecker(t *testing.T) {
+	manifest := &Manifest{
+		Latest:    "0.9.12",
+		URL:       "https://example.com/releases",
+		Emergency: &Emergency{FixedIn: "0.9.12", Message: "consensus fix"},
+	}
+	data, signer := signManifest(t, manifest)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	status := NewChecker(srv.URL, signer, "0.9.11").Check()
+	if status.Error != "" || !status.Outdated || !status.Emergency || status.Message != "consensus fix" || status.Latest != "0.9.12" {
+		t.Errorf("outdated client: wrong status %+v", status)
+	}
+	status = NewChecker(srv.URL, signer, "0.9.12").Check()
+	if status.Error != "" || status.Outdated || status.Emergency {
+		t.Errorf("current client: wrong status %+v", status)
+	}
+
+	// a manifest signed by another key is rejected
+	status = NewChecker(srv.URL, common.Address{1}, "0.9.11").Check()
+	if status.Error == "" || status.Outdated || status.Emergency {
+		t.Errorf("wrong signer: wrong status %+v", status)
+	}
+}
+
+func TestVerifyManifestTampered(t *testing.T) {
+	data, signer := signManifest(t, &Manifest{Latest: "0.9.12"})
+	var signed SignedManifest
+	json.Unmarshal(data, &signed)
+	signed.Manifest, _ = json.Marshal(&Manifest{Latest: "9.9.9"})
+	tampered, _ := json.Marshal(signed)
+
+	if _, err := VerifyManifest(tampered, signer); err == nil {
+		t.Error("tampered manifest accepted")
+	}
+}
@@ -0,0 +1,131 @@
+package resolver
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/xeth"
+)
+
+/*
+Registry resolves human readable names through the global registrar contract
+GlobalRegistrar : name -> address (addr), name -> content hash (content),
+name -> owner (owner)
+
+Lookups are answered by calling the constant accessors of the contract and
+are cached until the registrar contract raises an event.
+*/
+
+// Caller is the part of the backend needed to query the registrar contract.
+// It is satisfied by *xeth.XEth.
+type Caller interface {
+	Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, error)
+}
+
+type Registry struct {
+	backend Caller
+	address string
+
+	lock  sync.RWMutex
+	cache map[string]common.Hash // keyed by accessor and name
+	sub   *xeth.LogSubscription  // registrar events, set by Watch
+}
+
+func NewRegistry(backend Caller, address string) *Registry {
+	return &Registry{
+		backend: backend,
+		address: address,
+		cache:   make(map[string]common.Hash),
+	}
+}
+
+// Addr returns the primary address registered for name.
+func (self *Registry) Addr(name string) (common.Address, error) {
+	res, err := self.lookup("addr", name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(res[12:]), nil
+}
+
+// Owner returns the account owning name.
+func (self *Registry) Owner(name string) (common.Address, error) {
+	res, err := self.lookup("owner", name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(res[12:]), nil
+}
+
+// Content returns the content hash registered for name.
+func (self *Registry) Content(name string) (common.Hash, error) {
+	return self.lookup("content", name)
+}
+
+// Flush drops all cached lookups.
+func (self *Registry) Flush() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.cache = make(map[string]common.Hash)
+}
+
+// Watch flushes the cache whenever the registrar contract raises an event
+// in a new block. Watching continues until Stop is called.
+func (self *Registry) Watch(x *xeth.XEth) {
+	sub := x.SubscribeLogs([]string{self.address}, nil, false, func(state.Logs, bool) {
+		self.Flush()
+	})
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.sub != nil {
+		self.sub.Unsubscribe()
+	}
+	self.sub = sub
+}
+
+// Stop ends watching the registrar started by Watch.
+func (self *Registry) Stop() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.sub != nil {
+		self.sub.Unsubscribe()
+		self.sub = nil
+	}
+}
+
+func (self *Registry) lookup(method, name string) (res common.Hash, err error) {
+	if len(name) == 0 || len(name) > 32 {
+		return res, fmt.Errorf("invalid name %q", name)
+	}
+	key := method + ":" + name
+
+	self.lock.RLock()
+	res, cached := self.cache[key]
+	self.lock.RUnlock()
+	if cached {
+		return res, nil
+	}
+
+	data := append(crypto.Sha3([]byte(method + "(bytes32)"))[:4], common.RightPadBytes([]byte(name), 32)...)
+	out, err := self.backend.Call("", self.address, "", "", "", common.ToHex(data))
+	if err != nil {
+		return res, err
+	}
+	res = common.BytesToHash(common.FromHex(out))
+	if (res == common.Hash{}) {
+		return res, fmt.Errorf("name %q not registered", name)
+	}
+
+	self.lock.Lock()
+	self.cache[key] = res
+	self.lock.Unlock()
+
+	return res, nil
+}
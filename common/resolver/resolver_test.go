@@ -1,10 +1,19 @@
 package resolver
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/xeth"
 )
 
 type testBackend struct {
@@ -86,3 +95,103 @@ func TestKeyToUrl(t *testing.T) {
 		}
 	}
 }
+
+type testCaller struct {
+	calls   int
+	entries map[string]string // calldata -> result
+}
+
+func (self *testCaller) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, error) {
+	self.calls++
+	if res, ok := self.entries[dataStr]; ok {
+		return res, nil
+	}
+	return "0x", nil
+}
+
+func TestRegistryAddr(t *testing.T) {
+	addr := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	data := append(crypto.Sha3([]byte("addr(bytes32)"))[:4], common.RightPadBytes([]byte("myname"), 32)...)
+	caller := &testCaller{entries: map[string]string{
+		common.ToHex(data): common.ToHex(common.LeftPadBytes(addr[:], 32)),
+	}}
+	reg := NewRegistry(caller, "0xc6d9d2cd449a754c494264e1809c50e34d64562b")
+
+	for i := 0; i < 2; i++ {
+		got, err := reg.Addr("myname")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got != addr {
+			t.Errorf("incorrect result, expected %x, got %x", addr, got)
+		}
+	}
+	if caller.calls != 1 {
+		t.Errorf("expected cached lookup, got %d calls", caller.calls)
+	}
+	reg.Flush()
+	reg.Addr("myname")
+	if caller.calls != 2 {
+		t.Errorf("expected lookup after flush, got %d calls", caller.calls)
+	}
+
+	if _, err := reg.Addr("unknown"); err == nil {
+		t.Errorf("expected error for unregistered name")
+	}
+}
+
+func TestRegistryWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resolver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ethereum, err := eth.New(&eth.Config{
+		DataDir:        dir,
+		Name:           "test",
+		AccountManager: accounts.NewManager(crypto.NewKeyStorePlain(dir)),
+		NewDB:          func(string) (common.Database, error) { return ethdb.NewMemDatabase() },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := append(crypto.Sha3([]byte("addr(bytes32)"))[:4], common.RightPadBytes([]byte("myname"), 32)...)
+	caller := &testCaller{entries: map[string]string{
+		common.ToHex(data): common.ToHex(common.LeftPadBytes([]byte{1}, 32)),
+	}}
+	registrar := common.HexToAddress("0xc6d9d2cd449a754c494264e1809c50e34d64562b")
+	reg := NewRegistry(caller, registrar.Hex())
+	reg.Watch(xeth.New(ethereum, nil))
+
+	event := func(addr common.Address) core.ChainEvent {
+		return core.ChainEvent{Block: ethereum.ChainManager().Genesis(), Logs: state.Logs{&state.Log{Address: addr}}}
+	}
+	// the filters are installed asynchronously, post until the cache is flushed
+	reg.Addr("myname")
+	for end := time.Now().Add(2 * time.Second); caller.calls == 1; {
+		if time.Now().After(end) {
+			t.Fatal("cache not flushed by a registrar event")
+		}
+		ethereum.EventMux().Post(event(registrar))
+		time.Sleep(10 * time.Millisecond)
+		reg.Addr("myname")
+	}
+
+	// The second post is only delivered once the first one was handled.
+	calls := caller.calls
+	ethereum.EventMux().Post(event(common.Address{1}))
+	ethereum.EventMux().Post(event(common.Address{1}))
+	reg.Addr("myname")
+	if caller.calls != calls {
+		t.Errorf("expected cached lookup after unrelated event, got %d calls", caller.calls-calls)
+	}
+
+	reg.Stop()
+	ethereum.EventMux().Post(event(registrar))
+	ethereum.EventMux().Post(event(registrar))
+	reg.Addr("myname")
+	if caller.calls != calls {
+		t.Errorf("expected cached lookup after stop, got %d calls", caller.calls-calls)
+	}
+}
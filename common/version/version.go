@@ -0,0 +1,53 @@
+// Package version holds build metadata which is embedded into the binary
+// by the linker, e.g.
+//
+//	go build -ldflags "-X github.com/ethereum/go-ethereum/common/version.GitCommit $(git rev-parse HEAD)"
+//
+// The Makefile sets all variables. The build date is the date of the commit
+// so that builds of the same commit are identical.
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Set at build time.
+var (
+	GitCommit string
+	BuildDate string
+)
+
+// Info describes the build of a client.
+type Info struct {
+	Client    string `json:"client"`
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// New returns the build info of the running binary for the given client
+// name and version.
+func New(client, version string) Info {
+	return Info{
+		Client:    client,
+		Version:   version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+}
+
+// WithCommit appends the first 8 characters of the git commit to version
+// if it is known, e.g. 0.9.11-1a2b3c4d.
+func WithCommit(version string) string {
+	if len(GitCommit) < 8 {
+		return version
+	}
+	return fmt.Sprintf("%s-%s", version, GitCommit[:8])
+}
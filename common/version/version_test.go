@@ -0,0 +1,19 @@
+package version
+
+import "testing"
+
+func TestWithCommit(t *testing.T) {
+	defer func(c string) { GitCommit = c }(GitCommit)
+
+	GitCommit = ""
+	if v := WithCommit("1.0.0"); v != "1.0.0" {
+		t.Errorf("without commit: got %q", v)
+	}
+	GitCommit = "1a2b3c4d5e6f"
+	if v := WithCommit("1.0.0"); v != "1.0.0-1a2b3c4d" {
+		t.Errorf("with commit: got %q", v)
+	}
+	if info := New("Geth", "1.0.0"); info.GitCommit != GitCommit || info.Version != "1.0.0" {
+		t.Errorf("wrong info %+v", info)
+	}
+}
@@ -0,0 +1,355 @@
+// Package clique implements proof-of-authority sealing. Blocks are
+// signed by one of a fixed list of authorized accounts instead of
+// carrying a proof of work.
+//
+// The extra data of a block consists of 32 bytes of signer vanity,
+// the list of signer addresses (only in checkpoint blocks) and the
+// 65 byte signature of the sealer. Signers take turns: the in-turn
+// signer of a block seals it with difficulty 2, other signers may
+// seal it with difficulty 1 after a random delay. A signer may only
+// seal one of any len(signers)/2+1 consecutive blocks.
+package clique
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	extraVanity = 32 // bytes of extra data reserved for the signer vanity
+	extraSeal   = 65 // bytes of extra data reserved for the signature
+
+	defaultEpoch = 30000
+
+	// out-of-turn signers wait up to this long per half the number of
+	// signers before sealing, giving the in-turn signer precedence.
+	wiggleTime = 500 * time.Millisecond
+
+	authorCacheLimit = 4096
+)
+
+var (
+	diffInTurn = big.NewInt(2)
+	diffNoTurn = big.NewInt(1)
+
+	emptyUncleHash = rlpHash([]*types.Header(nil))
+)
+
+var (
+	errMissingSignature  = errors.New("extra data too short for vanity and signature")
+	errExtraSigners      = errors.New("non-checkpoint block lists signers")
+	errCheckpointSigners = errors.New("checkpoint block does not list the signers")
+	errInvalidUncleHash  = errors.New("proof-of-authority blocks can't have uncles")
+	errInvalidDifficulty = errors.New("invalid difficulty")
+	errWrongDifficulty   = errors.New("difficulty does not match signer turn")
+	errInvalidTimestamp  = errors.New("block sealed before the end of the period")
+	errInvalidSignature  = errors.New("invalid signature")
+	errUnauthorized      = errors.New("unauthorized signer")
+	errRecentlySigned    = errors.New("signer sealed a recent block")
+	errNoSigner          = errors.New("no local signer")
+)
+
+// SignerFn signs hash with the key of signer.
+type SignerFn func(signer common.Address, hash []byte) ([]byte, error)
+
+// Clique is the proof-of-authority consensus engine.
+type Clique struct {
+	period, epoch uint64
+	signers       []common.Address // sorted ascending
+	signerList    []byte           // as listed by checkpoint blocks
+
+	mu     sync.RWMutex
+	signer common.Address
+	signFn SignerFn
+
+	cacheMu sync.Mutex
+	authors map[common.Hash]common.Address
+	keys    []common.Hash // insertion order, for eviction
+}
+
+// New creates a proof-of-authority engine. Its configuration must be
+// the same for all nodes of the network.
+func New(config *params.CliqueConfig) *Clique {
+	c := &Clique{
+		period:  config.Period,
+		epoch:   config.Epoch,
+		signers: append([]common.Address(nil), config.Signers...),
+		authors: make(map[common.Hash]common.Address),
+	}
+	if c.epoch == 0 {
+		c.epoch = defaultEpoch
+	}
+	sort.Sort(addressesAscending(c.signers))
+	for _, signer := range c.signers {
+		c.signerList = append(c.signerList, signer[:]...)
+	}
+	return c
+}
+
+// Signers returns the accounts allowed to seal blocks.
+func (c *Clique) Signers() []common.Address {
+	return append([]common.Address(nil), c.signers...)
+}
+
+// Authorize sets the account used to seal blocks. signFn is called
+// with the hash of each sealed block.
+func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.signer, c.signFn = signer, signFn
+}
+
+// Author returns the account which sealed header.
+func (c *Clique) Author(header *types.Header) (common.Address, error) {
+	hash := header.Hash()
+	c.cacheMu.Lock()
+	author, ok := c.authors[hash]
+	c.cacheMu.Unlock()
+	if ok {
+		return author, nil
+	}
+
+	if len(header.Extra) < extraSeal {
+		return common.Address{}, errMissingSignature
+	}
+	sig := header.Extra[len(header.Extra)-extraSeal:]
+	pub, err := crypto.Ecrecover(sigHash(header).Bytes(), sig)
+	if err != nil || len(pub) != 65 || pub[0] != 4 {
+		return common.Address{}, errInvalidSignature
+	}
+	author = common.BytesToAddress(crypto.Sha3(pub[1:])[12:])
+
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if _, ok := c.authors[hash]; !ok {
+		if len(c.keys) >= authorCacheLimit {
+			delete(c.authors, c.keys[0])
+			c.keys = append(c.keys[:0], c.keys[1:]...)
+		}
+		c.keys = append(c.keys, hash)
+		c.authors[hash] = author
+	}
+	return author, nil
+}
+
+func (c *Clique) Prepare(chain consensus.ChainReader, header, parent *types.Header) error {
+	c.mu.RLock()
+	signer := c.signer
+	c.mu.RUnlock()
+
+	number := header.Number.Uint64()
+	if c.inTurn(number, signer) {
+		header.Difficulty = new(big.Int).Set(diffInTurn)
+	} else {
+		header.Difficulty = new(big.Int).Set(diffNoTurn)
+	}
+
+	// Keep the first extraVanity bytes of the miner's extra data.
+	extra := make([]byte, extraVanity)
+	copy(extra, header.Extra)
+	if c.checkpoint(number) {
+		extra = append(extra, c.signerList...)
+	}
+	header.Extra = append(extra, make([]byte, extraSeal)...)
+	header.MixDigest = common.Hash{}
+	header.Nonce = [8]byte{}
+
+	if min := parent.Time + c.period; header.Time < min {
+		header.Time = min
+	}
+	return nil
+}
+
+func (c *Clique) VerifyHeader(chain consensus.ChainReader, header, parent *types.Header) error {
+	if len(header.Extra) < extraVanity+extraSeal {
+		return errMissingSignature
+	}
+	list := header.Extra[extraVanity : len(header.Extra)-extraSeal]
+	if !c.checkpoint(header.Number.Uint64()) {
+		if len(list) != 0 {
+			return errExtraSigners
+		}
+	} else if !bytes.Equal(list, c.signerList) {
+		return errCheckpointSigners
+	}
+	if header.UncleHash != emptyUncleHash {
+		return errInvalidUncleHash
+	}
+	if header.Difficulty == nil || (header.Difficulty.Cmp(diffInTurn) != 0 && header.Difficulty.Cmp(diffNoTurn) != 0) {
+		return errInvalidDifficulty
+	}
+	if header.Time < parent.Time+c.period {
+		return errInvalidTimestamp
+	}
+
+	signer, err := c.Author(header)
+	if err != nil {
+		return err
+	}
+	return c.checkRecents(chain, signer, parent)
+}
+
+func (c *Clique) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	signer, err := c.Author(header)
+	if err != nil {
+		return err
+	}
+	if !c.authorized(signer) {
+		return errUnauthorized
+	}
+	want := diffNoTurn
+	if c.inTurn(header.Number.Uint64(), signer) {
+		want = diffInTurn
+	}
+	if header.Difficulty == nil || header.Difficulty.Cmp(want) != 0 {
+		return errWrongDifficulty
+	}
+	return nil
+}
+
+// VerifyUncles returns an error if block includes any uncles.
+func (c *Clique) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 0 {
+		return errInvalidUncleHash
+	}
+	return nil
+}
+
+// Finalize does nothing. Proof-of-authority blocks carry no rewards.
+func (c *Clique) Finalize(chain consensus.ChainReader, statedb *state.StateDB, block *types.Block) {
+}
+
+func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	c.mu.RLock()
+	signer, signFn := c.signer, c.signFn
+	c.mu.RUnlock()
+
+	header := block.Header()
+	if signFn == nil {
+		return nil, errNoSigner
+	}
+	if !c.authorized(signer) {
+		return nil, errUnauthorized
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return nil, errMissingSignature
+	}
+	parent := chain.GetBlock(header.ParentHash)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	if err := c.checkRecents(chain, signer, parent.Header()); err != nil {
+		return nil, err
+	}
+
+	delay := time.Unix(int64(header.Time), 0).Sub(time.Now())
+	if !c.inTurn(header.Number.Uint64(), signer) {
+		wiggle := time.Duration(len(c.signers)/2+1) * wiggleTime
+		delay += time.Duration(rand.Int63n(int64(wiggle)))
+	}
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+
+	sig, err := signFn(signer, sigHash(header).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	// The extra data may be shared with other copies of the block.
+	extra := make([]byte, len(header.Extra))
+	copy(extra, header.Extra)
+	copy(extra[len(extra)-extraSeal:], sig)
+	header.Extra = extra
+	return block.WithHeader(header), nil
+}
+
+// checkRecents returns an error if signer sealed one of the last
+// len(signers)/2 blocks up to and including parent.
+func (c *Clique) checkRecents(chain consensus.ChainReader, signer common.Address, parent *types.Header) error {
+	for i := 0; i < len(c.signers)/2; i++ {
+		if parent.Number.Sign() == 0 {
+			break
+		}
+		author, err := c.Author(parent)
+		if err != nil {
+			return err
+		}
+		if author == signer {
+			return errRecentlySigned
+		}
+		block := chain.GetBlock(parent.ParentHash)
+		if block == nil {
+			return consensus.ErrUnknownAncestor
+		}
+		parent = block.Header()
+	}
+	return nil
+}
+
+func (c *Clique) authorized(signer common.Address) bool {
+	for _, s := range c.signers {
+		if s == signer {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Clique) inTurn(number uint64, signer common.Address) bool {
+	return len(c.signers) > 0 && c.signers[number%uint64(len(c.signers))] == signer
+}
+
+func (c *Clique) checkpoint(number uint64) bool {
+	return number%c.epoch == 0
+}
+
+// sigHash returns the hash which is signed by the sealer. It covers
+// the whole header except for the signature.
+func sigHash(header *types.Header) common.Hash {
+	return rlpHash([]interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-extraSeal],
+		header.MixDigest,
+		header.Nonce,
+	})
+}
+
+func rlpHash(x interface{}) (h common.Hash) {
+	hw := sha3.NewKeccak256()
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
+
+type addressesAscending []common.Address
+
+func (s addressesAscending) Len() int           { return len(s) }
+func (s addressesAscending) Less(i, j int) bool { return bytes.Compare(s[i][:], s[j][:]) < 0 }
+func (s addressesAscending) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,156 @@
+package clique
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type testChain struct {
+	blocks map[common.Hash]*types.Block
+}
+
+func (c *testChain) Config() *params.ChainConfig              { return params.DefaultChainConfig }
+func (c *testChain) GetBlock(hash common.Hash) *types.Block   { return c.blocks[hash] }
+func (c *testChain) GetBlockByNumber(num uint64) *types.Block { return nil }
+
+type testSigners struct {
+	keys  map[common.Address]*ecdsa.PrivateKey
+	addrs []common.Address
+}
+
+func newTestSigners(n int) *testSigners {
+	s := &testSigners{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+	for i := 0; i < n; i++ {
+		key, _ := crypto.GenerateKey()
+		addr := common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		s.keys[addr] = key
+		s.addrs = append(s.addrs, addr)
+	}
+	return s
+}
+
+func (s *testSigners) sign(signer common.Address, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.keys[signer])
+}
+
+func newTestHeader(parentHash common.Hash, number int64, time uint64, extra []byte) *types.Header {
+	return &types.Header{
+		ParentHash: parentHash,
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(number),
+		GasLimit:   new(big.Int),
+		GasUsed:    new(big.Int),
+		Time:       time,
+		Extra:      extra,
+	}
+}
+
+func newTestGenesis() *types.Block {
+	return types.NewBlock(newTestHeader(common.Hash{}, 0, 1000, nil), nil, nil, nil)
+}
+
+// seal creates a block on top of parent sealed by signer.
+func seal(t *testing.T, c *Clique, chain *testChain, parent *types.Block, signers *testSigners, signer common.Address) *types.Block {
+	header := newTestHeader(parent.Hash(), parent.Number().Int64()+1, parent.Header().Time, []byte("vanity"))
+	header.Coinbase = signer
+
+	c.Authorize(signer, signers.sign)
+	if err := c.Prepare(chain, header, parent.Header()); err != nil {
+		t.Fatalf("prepare error: %v", err)
+	}
+	// avoid waiting in tests
+	header.Time = parent.Header().Time
+	sealed, err := c.Seal(chain, types.NewBlock(header, nil, nil, nil), nil)
+	if err != nil {
+		t.Fatalf("seal error: %v", err)
+	}
+	return sealed
+}
+
+func TestSealVerify(t *testing.T) {
+	signers := newTestSigners(3)
+	c := New(&params.CliqueConfig{Epoch: 2, Signers: signers.addrs})
+	genesis := newTestGenesis()
+	chain := &testChain{blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis}}
+
+	parent := genesis
+	for i := 1; i <= 4; i++ {
+		signer := c.signers[i%len(c.signers)]
+		block := seal(t, c, chain, parent, signers, signer)
+
+		if err := c.VerifyHeader(chain, block.Header(), parent.Header()); err != nil {
+			t.Fatalf("block %d: header verification failed: %v", i, err)
+		}
+		if err := c.VerifySeal(chain, block.Header()); err != nil {
+			t.Fatalf("block %d: seal verification failed: %v", i, err)
+		}
+		if author, _ := c.Author(block.Header()); author != signer {
+			t.Errorf("block %d: author mismatch: got %x, want %x", i, author, signer)
+		}
+		if block.Difficulty().Cmp(diffInTurn) != 0 {
+			t.Errorf("block %d: difficulty of in-turn block is %v", i, block.Difficulty())
+		}
+		if listed := len(block.Header().Extra) - extraVanity - extraSeal; (i%2 == 0) != (listed == len(c.signerList)) {
+			t.Errorf("block %d: %d bytes of signers in extra data", i, listed)
+		}
+		chain.blocks[block.Hash()] = block
+		parent = block
+	}
+}
+
+func TestVerifyUnauthorized(t *testing.T) {
+	signers := newTestSigners(2)
+	c := New(&params.CliqueConfig{Signers: signers.addrs[:1]})
+	genesis := newTestGenesis()
+	chain := &testChain{blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis}}
+
+	// Seal with an engine authorizing both signers, verify with c.
+	block := seal(t, New(&params.CliqueConfig{Signers: signers.addrs}), chain, genesis, signers, signers.addrs[1])
+	if err := c.VerifySeal(chain, block.Header()); err != errUnauthorized {
+		t.Errorf("got error %v, want %v", err, errUnauthorized)
+	}
+}
+
+func TestVerifyRecentlySigned(t *testing.T) {
+	signers := newTestSigners(3)
+	c := New(&params.CliqueConfig{Signers: signers.addrs})
+	genesis := newTestGenesis()
+	chain := &testChain{blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis}}
+
+	signer := c.signers[1]
+	block1 := seal(t, c, chain, genesis, signers, signer)
+	chain.blocks[block1.Hash()] = block1
+
+	header := newTestHeader(block1.Hash(), 2, block1.Header().Time, nil)
+	c.Prepare(chain, header, block1.Header())
+	block2 := types.NewBlock(header, nil, nil, nil)
+	if _, err := c.Seal(chain, block2, nil); err != errRecentlySigned {
+		t.Errorf("Seal: got error %v, want %v", err, errRecentlySigned)
+	}
+
+	header = block2.Header()
+	sig, _ := signers.sign(signer, sigHash(header).Bytes())
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+	if err := c.VerifyHeader(chain, header, block1.Header()); err != errRecentlySigned {
+		t.Errorf("VerifyHeader: got error %v, want %v", err, errRecentlySigned)
+	}
+}
+
+func TestVerifyTamperedHeader(t *testing.T) {
+	signers := newTestSigners(1)
+	c := New(&params.CliqueConfig{Signers: signers.addrs})
+	genesis := newTestGenesis()
+	chain := &testChain{blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis}}
+
+	header := seal(t, c, chain, genesis, signers, signers.addrs[0]).Header()
+	header.GasUsed = big.NewInt(1)
+	if err := c.VerifySeal(chain, header); err == nil {
+		t.Error("expected error for header modified after sealing")
+	}
+}
@@ -0,0 +1,50 @@
+// Package consensus defines the interface of block sealing engines.
+package consensus
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ErrUnknownAncestor is returned by engines when an ancestor needed to
+// verify a block is not known yet. The block may become valid later.
+var ErrUnknownAncestor = errors.New("unknown ancestor")
+
+// ChainReader gives engines access to the local block chain.
+type ChainReader interface {
+	Config() *params.ChainConfig
+	GetBlock(hash common.Hash) *types.Block
+	GetBlockByNumber(num uint64) *types.Block
+}
+
+// Engine implements the consensus rules which decide who may create
+// blocks, e.g. proof of work or proof of authority.
+type Engine interface {
+	// Prepare sets the consensus fields of a new header, e.g. the
+	// difficulty. It is called before transactions are added.
+	Prepare(chain ChainReader, header, parent *types.Header) error
+
+	// VerifyHeader checks the consensus fields of header except for
+	// the seal. The parent of header must be known to chain.
+	VerifyHeader(chain ChainReader, header, parent *types.Header) error
+
+	// VerifySeal checks the seal of header. It may be called before
+	// the ancestors of header are known.
+	VerifySeal(chain ChainReader, header *types.Header) error
+
+	// VerifyUncles checks the uncles included in block. The ancestors
+	// of block must be known to chain.
+	VerifyUncles(chain ChainReader, block *types.Block) error
+
+	// Finalize applies the block rewards to statedb. It does not
+	// update the state root of block.
+	Finalize(chain ChainReader, statedb *state.StateDB, block *types.Block)
+
+	// Seal returns a sealed version of block. It returns nil without
+	// error if stop is signalled before the block could be sealed.
+	Seal(chain ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error)
+}
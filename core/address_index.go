@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// MaxAddressLookupRange is the maximum number of blocks searched by a single
+// GetAddressTransactions call.
+const MaxAddressLookupRange = 100000
+
+var addrTxPre = []byte("addr-tx-")
+
+// The address index stores, for every address and block, the hashes of the
+// block's transactions sent or received by the address. Entries are keyed by
+// block hash so blocks which are no longer canonical are never returned.
+func addrTxKey(addr common.Address, blockHash common.Hash) []byte {
+	key := append(append([]byte{}, addrTxPre...), addr[:]...)
+	return append(key, blockHash[:]...)
+}
+
+// putAddressTxs adds the transactions of block to the index of their sender
+// and recipient. For contract creations the created contract is indexed as
+// the recipient.
+func putAddressTxs(db common.Database, block *types.Block) {
+	index := make(map[common.Address][]common.Hash)
+	for _, tx := range block.Transactions() {
+		from, err := tx.From()
+		if err != nil {
+			continue
+		}
+		to := AddressFromMessage(tx)
+		if tx.To() != nil {
+			to = *tx.To()
+		}
+		index[from] = append(index[from], tx.Hash())
+		if to != from {
+			index[to] = append(index[to], tx.Hash())
+		}
+	}
+	for addr, hashes := range index {
+		enc, err := rlp.EncodeToBytes(hashes)
+		if err != nil {
+			glog.V(logger.Debug).Infoln("Failed encoding address index", err)
+			continue
+		}
+		db.Put(addrTxKey(addr, block.Hash()), enc)
+	}
+}
+
+// GetAddressTransactions returns the hashes of all transactions sent or
+// received by addr in the canonical blocks from through to (inclusive). The
+// address index must have been enabled on the block processor when the
+// blocks were imported.
+func GetAddressTransactions(db common.Database, chain *ChainManager, addr common.Address, from, to uint64) ([]common.Hash, error) {
+	if to < from {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	if to-from >= MaxAddressLookupRange {
+		return nil, fmt.Errorf("block range %d-%d exceeds %d blocks", from, to, MaxAddressLookupRange)
+	}
+
+	var hashes []common.Hash
+	for num := from; num <= to; num++ {
+		blockHash := chain.GetHashByNumber(num)
+		if (blockHash == common.Hash{}) {
+			break
+		}
+		data, _ := db.Get(addrTxKey(addr, blockHash))
+		if len(data) == 0 {
+			continue
+		}
+		var txs []common.Hash
+		if err := rlp.DecodeBytes(data, &txs); err != nil {
+			return nil, fmt.Errorf("invalid address index of block %x: %v", blockHash[:4], err)
+		}
+		hashes = append(hashes, txs...)
+	}
+	return hashes, nil
+}
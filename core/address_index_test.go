@@ -0,0 +1,62 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestAddressTransactions(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr1   = common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		addr2   = common.Address{2}
+		db, _   = ethdb.NewMemDatabase()
+		bman, _ = newCanonical(0, db)
+		genesis = bman.bc.CurrentBlock()
+	)
+	bman.SetAddressIndex(true)
+
+	var txs []*types.Transaction
+	chain := GenerateChain(genesis, db, 4, func(i int, gen *BlockGen) {
+		if i == 0 {
+			gen.SetCoinbase(addr1)
+			return
+		}
+		// blocks 2 and 4 send ether from addr1 to addr2
+		if i%2 == 1 {
+			tx := types.NewTransactionMessage(addr2, big.NewInt(1000), big.NewInt(21000), big.NewInt(1), nil)
+			tx.SetNonce(gen.TxNonce(addr1))
+			tx.SignECDSA(key)
+			gen.AddTx(tx)
+			txs = append(txs, tx)
+		}
+	})
+	if err := bman.bc.InsertChain(chain); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+
+	for _, addr := range []common.Address{addr1, addr2} {
+		hashes, err := GetAddressTransactions(db, bman.bc, addr, 0, 4)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(hashes) != 2 || hashes[0] != txs[0].Hash() || hashes[1] != txs[1].Hash() {
+			t.Errorf("%x: wrong transactions %x", addr, hashes)
+		}
+	}
+	hashes, _ := GetAddressTransactions(db, bman.bc, addr2, 3, 10)
+	if len(hashes) != 1 || hashes[0] != txs[1].Hash() {
+		t.Errorf("wrong transactions in blocks 3-10: %x", hashes)
+	}
+	if hashes, _ := GetAddressTransactions(db, bman.bc, common.Address{3}, 0, 4); len(hashes) != 0 {
+		t.Errorf("expected no transactions for unrelated address, got %x", hashes)
+	}
+	if _, err := GetAddressTransactions(db, bman.bc, addr1, 0, MaxAddressLookupRange); err == nil {
+		t.Error("expected error for too large block range")
+	}
+}
@@ -11,8 +11,13 @@ import (
 func newChain(size int) (chain []*types.Block) {
 	var parentHash common.Hash
 	for i := 0; i < size; i++ {
-		block := types.NewBlock(parentHash, common.Address{}, common.Hash{}, new(big.Int), 0, nil)
-		block.Header().Number = big.NewInt(int64(i))
+		block := types.NewBlock(&types.Header{
+			ParentHash: parentHash,
+			Difficulty: new(big.Int),
+			Number:     big.NewInt(int64(i)),
+			GasLimit:   new(big.Int),
+			GasUsed:    new(big.Int),
+		}, nil, nil, nil)
 		chain = append(chain, block)
 		parentHash = block.Hash()
 	}
@@ -0,0 +1,97 @@
+package core
+
+import "github.com/ethereum/go-ethereum/core/types"
+
+// blockIteratorBatch is the number of blocks fetched ahead by BlockIterator.
+const blockIteratorBatch = 64
+
+// BlockIterator walks a range of the canonical chain by block number,
+// either forward or backward. Blocks are fetched in batches so walking a
+// long range doesn't pay for a chain lookup per block.
+//
+// The iterator reads the chain as it is while iterating. If the canonical
+// chain changes during the iteration, the returned blocks may belong to
+// different forks.
+type BlockIterator struct {
+	fetch     func(start, count uint64) []*types.Block
+	num       uint64 // number of the next block to be returned
+	remaining uint64 // blocks left in the range
+	reverse   bool
+
+	buf   []*types.Block
+	block *types.Block
+}
+
+// NewBlockIterator creates an iterator for the canonical blocks numbered
+// first to last, inclusive. The iteration runs backward if first > last.
+func (self *ChainManager) NewBlockIterator(first, last uint64) *BlockIterator {
+	return newBlockIterator(self.GetBlocksFromNumber, first, last)
+}
+
+func newBlockIterator(fetch func(start, count uint64) []*types.Block, first, last uint64) *BlockIterator {
+	it := &BlockIterator{fetch: fetch, num: first}
+	if first > last {
+		it.reverse = true
+		it.remaining = first - last + 1
+	} else {
+		it.remaining = last - first + 1
+	}
+	return it
+}
+
+// Next advances the iterator to the next block. It returns false when the
+// end of the range has been reached or a block in the range is missing.
+func (it *BlockIterator) Next() bool {
+	if len(it.buf) == 0 {
+		it.prefetch()
+	}
+	if len(it.buf) == 0 {
+		it.block = nil
+		return false
+	}
+	it.block, it.buf = it.buf[0], it.buf[1:]
+	it.remaining--
+	if it.reverse {
+		it.num--
+	} else {
+		it.num++
+	}
+	return true
+}
+
+// Block returns the current block.
+func (it *BlockIterator) Block() *types.Block {
+	return it.block
+}
+
+// prefetch fills the buffer with the next batch of blocks.
+func (it *BlockIterator) prefetch() {
+	count := it.remaining
+	if count > blockIteratorBatch {
+		count = blockIteratorBatch
+	}
+	if count == 0 {
+		return
+	}
+	if !it.reverse {
+		blocks := it.fetch(it.num, count)
+		if uint64(len(blocks)) < count {
+			// the chain ends within the batch, stop after it.
+			it.remaining = uint64(len(blocks))
+		}
+		it.buf = blocks
+		return
+	}
+
+	start := it.num - count + 1
+	blocks := it.fetch(start, count)
+	if uint64(len(blocks)) < count {
+		// the chain is shorter than the requested range.
+		it.remaining = 0
+		return
+	}
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	it.buf = blocks
+}
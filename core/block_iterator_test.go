@@ -0,0 +1,52 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestBlockIterator(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	bman, err := newCanonical(150, db)
+	if err != nil {
+		t.Fatal("could not make new canonical chain:", err)
+	}
+	bc := bman.bc
+
+	tests := []struct {
+		first, last uint64
+		want        []uint64 // first and last number returned
+		count       int
+	}{
+		{first: 0, last: 150, want: []uint64{0, 150}, count: 151},
+		{first: 150, last: 0, want: []uint64{150, 0}, count: 151},
+		{first: 10, last: 10, want: []uint64{10, 10}, count: 1},
+		{first: 100, last: 200, want: []uint64{100, 150}, count: 51},
+		{first: 200, last: 100, count: 0},
+		{first: 151, last: 160, count: 0},
+	}
+	for i, test := range tests {
+		var nums []uint64
+		for it := bc.NewBlockIterator(test.first, test.last); it.Next(); {
+			nums = append(nums, it.Block().NumberU64())
+		}
+		if len(nums) != test.count {
+			t.Errorf("test %d: got %d blocks, want %d", i, len(nums), test.count)
+			continue
+		}
+		if test.count > 0 && (nums[0] != test.want[0] || nums[len(nums)-1] != test.want[1]) {
+			t.Errorf("test %d: got range %d-%d, want %d-%d", i, nums[0], nums[len(nums)-1], test.want[0], test.want[1])
+		}
+		for j := 1; j < len(nums); j++ {
+			if d := int64(nums[j]) - int64(nums[j-1]); d != 1 && d != -1 {
+				t.Errorf("test %d: non-consecutive blocks %d, %d", i, nums[j-1], nums[j])
+				break
+			}
+		}
+	}
+
+	if blocks := bc.GetBlocksFromNumber(140, 20); len(blocks) != 11 {
+		t.Errorf("GetBlocksFromNumber returned %d blocks, want 11", len(blocks))
+	}
+}
@@ -3,10 +3,12 @@ package core
 import (
 	"fmt"
 	"math/big"
+	"runtime"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
@@ -14,14 +16,17 @@ import (
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/pow"
-	"github.com/ethereum/go-ethereum/rlp"
-	"gopkg.in/fatih/set.v0"
 )
 
 const (
 	// must be bumped when consensus algorithm is changed, this forces the upgradedb
 	// command to be run (forces the blocks to be imported again using the new algorithm)
 	BlockChainVersion = 2
+
+	badBlockCacheLimit = 256
+
+	// number of recent proof of work verification results remembered.
+	sealCacheLimit = 4096
 )
 
 var statelogger = logger.NewLogger("BLOCK")
@@ -37,33 +42,60 @@ type BlockProcessor struct {
 	mem map[string]*big.Int
 	// Proof of work used for validating
 	Pow pow.PoW
+	// consensus engine, proof of work using Pow by default
+	engine consensus.Engine
+	// concurrent, caching verifier for the seals of engine
+	verifier *pow.Verifier
 
 	txpool *TxPool
 
+	// whether transactions are indexed by sender and recipient
+	addressIndex bool
+	bloomIndexer *BloomIndexer
+
 	// The last attempted block is mainly used for debugging purposes
 	// This does not have to be a valid block and will be set during
 	// 'Process' & canonical validation.
 	lastAttemptedBlock *types.Block
 
+	// validation errors of recently rejected blocks. Blocks announced
+	// again are rejected without repeating the validation.
+	badBlocks *hashCache
+
 	events event.Subscription
 
-	eventMux *event.TypeMux
+	eventMux   *event.TypeMux
+	txPostFeed event.Feed
 }
 
 func NewBlockProcessor(db, extra common.Database, pow pow.PoW, txpool *TxPool, chainManager *ChainManager, eventMux *event.TypeMux) *BlockProcessor {
 	sm := &BlockProcessor{
-		db:       db,
-		extraDb:  extra,
-		mem:      make(map[string]*big.Int),
-		Pow:      pow,
-		bc:       chainManager,
-		eventMux: eventMux,
-		txpool:   txpool,
+		db:        db,
+		extraDb:   extra,
+		mem:       make(map[string]*big.Int),
+		Pow:       pow,
+		bc:        chainManager,
+		eventMux:  eventMux,
+		txpool:    txpool,
+		badBlocks: newHashCache(badBlockCacheLimit),
 	}
+	sm.SetEngine(NewPoWEngine(pow))
 
 	return sm
 }
 
+// SetEngine replaces the consensus engine. It must be called before any
+// blocks are processed.
+func (sm *BlockProcessor) SetEngine(engine consensus.Engine) {
+	sm.engine = engine
+	sm.verifier = pow.NewVerifier(sealCheck{sm.bc, engine}, runtime.NumCPU(), sealCacheLimit)
+}
+
+// Engine returns the consensus engine.
+func (sm *BlockProcessor) Engine() consensus.Engine {
+	return sm.engine
+}
+
 func (sm *BlockProcessor) TransitionState(statedb *state.StateDB, parent, block *types.Block, transientProcess bool) (receipts types.Receipts, err error) {
 	coinbase := statedb.GetOrNewStateObject(block.Header().Coinbase)
 	coinbase.SetGasPool(block.Header().GasLimit)
@@ -95,6 +127,8 @@ func (self *BlockProcessor) ApplyTransaction(coinbase *state.StateObject, stated
 
 	cumulative := new(big.Int).Set(usedGas.Add(usedGas, gas))
 	receipt := types.NewReceipt(statedb.Root().Bytes(), cumulative)
+	receipt.SetGasUsed(new(big.Int).Set(gas))
+	receipt.SetFailed(err != nil)
 
 	logs := statedb.GetLogs(tx.Hash())
 	receipt.SetLogs(logs)
@@ -104,12 +138,38 @@ func (self *BlockProcessor) ApplyTransaction(coinbase *state.StateObject, stated
 
 	// Notify all subscribers
 	if !transientProcess {
-		go self.eventMux.Post(TxPostEvent{tx})
-		go self.eventMux.Post(logs)
+		self.txPostFeed.Send(TxPostEvent{tx})
 	}
 
 	return receipt, gas, err
 }
+
+// SubscribeTxPostEvent registers a subscription of TxPostEvent.
+func (self *BlockProcessor) SubscribeTxPostEvent() *event.FeedSubscription {
+	return self.txPostFeed.Subscribe(eventBufferSize)
+}
+
+// SetAddressIndex enables or disables indexing the transactions of
+// processed blocks by address. See GetAddressTransactions.
+func (self *BlockProcessor) SetAddressIndex(enabled bool) {
+	self.addressIndex = enabled
+}
+
+// AddressIndex reports whether transactions are indexed by address.
+func (self *BlockProcessor) AddressIndex() bool {
+	return self.addressIndex
+}
+
+// SetBloomIndexer sets the bloom bit index used by log filters.
+func (self *BlockProcessor) SetBloomIndexer(indexer *BloomIndexer) {
+	self.bloomIndexer = indexer
+}
+
+// BloomIndexer returns the bloom bit index, nil if there is none.
+func (self *BlockProcessor) BloomIndexer() *BloomIndexer {
+	return self.bloomIndexer
+}
+
 func (self *BlockProcessor) ChainManager() *ChainManager {
 	return self.bc
 }
@@ -142,10 +202,6 @@ func (self *BlockProcessor) ApplyTransactions(coinbase *state.StateObject, state
 		return nil, ValidationError(fmt.Sprintf("gas used error (%v / %v)", block.GasUsed(), totalUsedGas))
 	}
 
-	if transientProcess {
-		go self.eventMux.Post(PendingBlockEvent{block, statedb.Logs()})
-	}
-
 	return receipts, err
 }
 
@@ -160,20 +216,54 @@ func (sm *BlockProcessor) RetryProcess(block *types.Block) (logs state.Logs, err
 	}
 	parent := sm.bc.GetBlock(header.ParentHash)
 
-	return sm.processWithParent(block, parent)
+	return sm.processWithParent(block, parent, true)
 }
 
 // Process block will attempt to process the given block's transactions and applies them
 // on top of the block's parent state (given it exists) and will return wether it was
 // successful or not.
 func (sm *BlockProcessor) Process(block *types.Block) (logs state.Logs, err error) {
+	return sm.process(block, true)
+}
+
+// ProcessVerified is like Process, but does not verify the proof of work
+// of the block. It must only be used for blocks checked by VerifySeals.
+func (sm *BlockProcessor) ProcessVerified(block *types.Block) (logs state.Logs, err error) {
+	return sm.process(block, false)
+}
+
+// VerifySeals starts verifying the proof of work of the given blocks
+// concurrently. The returned channel delivers the result for each block
+// in order. Closing abort stops the verification.
+//
+// Known blocks are not checked again.
+func (sm *BlockProcessor) VerifySeals(chain types.Blocks, abort <-chan struct{}) <-chan bool {
+	var (
+		blocks = make([]pow.Block, len(chain))
+		check  = make([]bool, len(chain))
+	)
+	for i, block := range chain {
+		if block == nil || sm.bc.HasBlock(block.Hash()) {
+			continue
+		}
+		blocks[i] = block
+		check[i] = true
+	}
+	return sm.verifier.VerifyAll(blocks, check, abort)
+}
+
+func (sm *BlockProcessor) process(block *types.Block, checkSeal bool) (logs state.Logs, err error) {
 	// Processing a blocks may never happen simultaneously
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 
 	header := block.Header()
-	if sm.bc.HasBlock(header.Hash()) {
-		return nil, &KnownBlockError{header.Number, header.Hash()}
+	hash := header.Hash()
+	if sm.bc.HasBlock(hash) {
+		return nil, &KnownBlockError{header.Number, hash}
+	}
+	if err, ok := sm.badBlocks.Get(hash); ok {
+		return nil, err.(error)
 	}
 
 	if !sm.bc.HasBlock(header.ParentHash) {
@@ -181,24 +271,41 @@ func (sm *BlockProcessor) Process(block *types.Block) (logs state.Logs, err erro
 	}
 	parent := sm.bc.GetBlock(header.ParentHash)
 
-	return sm.processWithParent(block, parent)
+	// Only header failures are remembered as the hash doesn't cover the
+	// body, a peer could otherwise get a valid block rejected by sending
+	// it with a tampered body.
+	sm.lastAttemptedBlock = block
+	if err = sm.validateHeader(header, parent.Header(), checkSeal); err != nil {
+		if isBadBlockErr(err) {
+			sm.badBlocks.Add(hash, err)
+		}
+		return nil, err
+	}
+	return sm.processBody(block, parent)
+}
+
+// isBadBlockErr reports whether err rejects a block for breaking the
+// consensus rules, so that it can be rejected again without validation.
+// Future blocks and blocks with unknown ancestors may become valid later.
+func isBadBlockErr(err error) bool {
+	return err != nil && err != BlockFutureErr && err != consensus.ErrUnknownAncestor && !IsParentErr(err)
 }
 
-func (sm *BlockProcessor) processWithParent(block, parent *types.Block) (logs state.Logs, err error) {
+func (sm *BlockProcessor) processWithParent(block, parent *types.Block, checkSeal bool) (logs state.Logs, err error) {
 	sm.lastAttemptedBlock = block
 
-	// Create a new state based on the parent's root (e.g., create copy)
-	state := state.New(parent.Root(), sm.db)
-
 	// Block validation
-	if err = sm.ValidateHeader(block.Header(), parent.Header()); err != nil {
+	if err = sm.validateHeader(block.Header(), parent.Header(), checkSeal); err != nil {
 		return
 	}
+	return sm.processBody(block, parent)
+}
 
-	// There can be at most two uncles
-	if len(block.Uncles()) > 2 {
-		return nil, ValidationError("Block can only contain one uncle (contained %v)", len(block.Uncles()))
-	}
+// processBody executes the transactions of block, whose header was
+// validated, and checks the result against the header.
+func (sm *BlockProcessor) processBody(block, parent *types.Block) (logs state.Logs, err error) {
+	// Create a new state based on the parent's root (e.g., create copy)
+	state := state.New(parent.Root(), sm.db)
 
 	receipts, err := sm.TransitionState(state, parent, block, false)
 	if err != nil {
@@ -223,6 +330,11 @@ func (sm *BlockProcessor) processWithParent(block, parent *types.Block) (logs st
 		return
 	}
 
+	if uncleHash := types.CalcUncleHash(block.Uncles()); uncleHash != header.UncleHash {
+		err = fmt.Errorf("validating uncle hash. received=%x got=%x", header.UncleHash, uncleHash)
+		return
+	}
+
 	// Tre receipt Trie's root (R = (Tr [[H1, R1], ... [Hn, R1]]))
 	receiptSha := types.DeriveSha(receipts)
 	if receiptSha != header.ReceiptHash {
@@ -231,11 +343,11 @@ func (sm *BlockProcessor) processWithParent(block, parent *types.Block) (logs st
 	}
 
 	// Verify uncles
-	if err = sm.VerifyUncles(state, block, parent); err != nil {
+	if err = sm.engine.VerifyUncles(sm.bc, block); err != nil {
 		return
 	}
-	// Accumulate static rewards; block reward, uncle's and uncle inclusion.
-	AccumulateRewards(state, block)
+	// Accumulate the rewards of the consensus engine.
+	sm.engine.Finalize(sm.bc, state, block)
 
 	// Commit state objects/accounts to a temporary trie (does not save)
 	// used to calculate the state root.
@@ -253,9 +365,13 @@ func (sm *BlockProcessor) processWithParent(block, parent *types.Block) (logs st
 	// Remove transactions from the pool
 	sm.txpool.RemoveSet(block.Transactions())
 
-	// This puts transactions in a extra db for rpc
+	// This puts transactions and their receipts in a extra db for rpc
 	for i, tx := range block.Transactions() {
-		putTx(sm.extraDb, tx, block, uint64(i))
+		WriteTransaction(sm.extraDb, tx, block, uint64(i))
+		WriteReceipt(sm.extraDb, tx.Hash(), receipts[i])
+	}
+	if sm.addressIndex {
+		putAddressTxs(sm.extraDb, block)
 	}
 
 	return state.Logs(), nil
@@ -265,25 +381,43 @@ func (sm *BlockProcessor) processWithParent(block, parent *types.Block) (logs st
 // an uncle or anything that isn't on the current block chain.
 // Validation validates easy over difficult (dagger takes longer time = difficult)
 func (sm *BlockProcessor) ValidateHeader(block, parent *types.Header) error {
-	if big.NewInt(int64(len(block.Extra))).Cmp(params.MaximumExtraDataSize) == 1 {
-		return fmt.Errorf("Block extra data too long (%d)", len(block.Extra))
+	return sm.validateHeader(block, parent, true)
+}
+
+func (sm *BlockProcessor) validateHeader(block, parent *types.Header, checkSeal bool) error {
+	if err := validateHeaderFields(sm.bc.Config(), block, parent); err != nil {
+		return err
+	}
+	if err := sm.engine.VerifyHeader(sm.bc, block, parent); err != nil {
+		return err
 	}
 
-	expd := CalcDifficulty(block, parent)
-	if expd.Cmp(block.Difficulty) != 0 {
-		return fmt.Errorf("Difficulty check failed for block %v, %v", block.Difficulty, expd)
+	// Verify the seal of the block. Return an error if it's not valid
+	if checkSeal && !sm.verifier.Verify(types.NewBlockWithHeader(block)) {
+		return ValidationError("Block's nonce is invalid (= %x)", block.Nonce)
+	}
+
+	return nil
+}
+
+// validateHeaderFields checks the parts of a header which don't depend
+// on the consensus engine.
+func validateHeaderFields(config *params.ChainConfig, block, parent *types.Header) error {
+	if big.NewInt(int64(len(block.Extra))).Cmp(params.MaximumExtraDataSize) == 1 {
+		return fmt.Errorf("Block extra data too long (%d)", len(block.Extra))
 	}
 
 	// block.gasLimit - parent.gasLimit <= parent.gasLimit / GasLimitBoundDivisor
 	a := new(big.Int).Sub(block.GasLimit, parent.GasLimit)
 	a.Abs(a)
 	b := new(big.Int).Div(parent.GasLimit, params.GasLimitBoundDivisor)
-	if !(a.Cmp(b) < 0) || (block.GasLimit.Cmp(params.MinGasLimit) == -1) {
+	minGasLimit, _ := config.GasLimitBounds()
+	if !(a.Cmp(b) < 0) || (block.GasLimit.Cmp(minGasLimit) == -1) {
 		return fmt.Errorf("GasLimit check failed for block %v (%v > %v)", block.GasLimit, a, b)
 	}
 
-	// Allow future blocks up to 10 seconds
-	if int64(block.Time) > time.Now().Unix()+4 {
+	// Allow future blocks up to the clock drift allowance
+	if int64(block.Time) > time.Now().Add(config.AllowedClockDrift()).Unix() {
 		return BlockFutureErr
 	}
 
@@ -291,73 +425,10 @@ func (sm *BlockProcessor) ValidateHeader(block, parent *types.Header) error {
 		return BlockNumberErr
 	}
 
-	if block.Time <= parent.Time {
+	if block.Time < parent.Time || (block.Time == parent.Time && (config == nil || !config.SubSecondBlocks)) {
 		return BlockEqualTSErr //ValidationError("Block timestamp equal or less than previous block (%v - %v)", block.Time, parent.Time)
 	}
 
-	// Verify the nonce of the block. Return an error if it's not valid
-	if !sm.Pow.Verify(types.NewBlockWithHeader(block)) {
-		return ValidationError("Block's nonce is invalid (= %x)", block.Nonce)
-	}
-
-	return nil
-}
-
-func AccumulateRewards(statedb *state.StateDB, block *types.Block) {
-	reward := new(big.Int).Set(BlockReward)
-
-	for _, uncle := range block.Uncles() {
-		num := new(big.Int).Add(big.NewInt(8), uncle.Number)
-		num.Sub(num, block.Number())
-
-		r := new(big.Int)
-		r.Mul(BlockReward, num)
-		r.Div(r, big.NewInt(8))
-
-		statedb.AddBalance(uncle.Coinbase, r)
-
-		reward.Add(reward, new(big.Int).Div(BlockReward, big.NewInt(32)))
-	}
-
-	// Get the account associated with the coinbase
-	statedb.AddBalance(block.Header().Coinbase, reward)
-}
-
-func (sm *BlockProcessor) VerifyUncles(statedb *state.StateDB, block, parent *types.Block) error {
-	ancestors := set.New()
-	uncles := set.New()
-	ancestorHeaders := make(map[common.Hash]*types.Header)
-	for _, ancestor := range sm.bc.GetAncestors(block, 7) {
-		ancestorHeaders[ancestor.Hash()] = ancestor.Header()
-		ancestors.Add(ancestor.Hash())
-		// Include ancestors uncles in the uncle set. Uncles must be unique.
-		for _, uncle := range ancestor.Uncles() {
-			uncles.Add(uncle.Hash())
-		}
-	}
-
-	uncles.Add(block.Hash())
-	for i, uncle := range block.Uncles() {
-		if uncles.Has(uncle.Hash()) {
-			// Error not unique
-			return UncleError("Uncle not unique")
-		}
-
-		uncles.Add(uncle.Hash())
-
-		if ancestors.Has(uncle.Hash()) {
-			return UncleError("Uncle is ancestor")
-		}
-
-		if !ancestors.Has(uncle.ParentHash) {
-			return UncleError(fmt.Sprintf("Uncle's parent unknown (%x)", uncle.ParentHash[0:4]))
-		}
-
-		if err := sm.ValidateHeader(uncle, ancestorHeaders[uncle.ParentHash]); err != nil {
-			return ValidationError(fmt.Sprintf("uncle[%d](%x) header invalid: %v", i, uncle.Hash().Bytes()[:4], err))
-		}
-	}
-
 	return nil
 }
 
@@ -378,26 +449,24 @@ func (sm *BlockProcessor) GetLogs(block *types.Block) (logs state.Logs, err erro
 	return state.Logs(), nil
 }
 
-func putTx(db common.Database, tx *types.Transaction, block *types.Block, i uint64) {
-	rlpEnc, err := rlp.EncodeToBytes(tx)
-	if err != nil {
-		glog.V(logger.Debug).Infoln("Failed encoding tx", err)
-		return
+// GetReceipts re-derives the receipts of a processed block, including the
+// logs, by executing its transactions on the parent state. The local receipt
+// store only keeps the gas and status fields. The result is checked against
+// the receipt root of the block header.
+func (sm *BlockProcessor) GetReceipts(block *types.Block) (types.Receipts, error) {
+	if len(block.Transactions()) == 0 {
+		return types.Receipts{}, nil
 	}
-	db.Put(tx.Hash().Bytes(), rlpEnc)
-
-	var txExtra struct {
-		BlockHash  common.Hash
-		BlockIndex uint64
-		Index      uint64
+	parent := sm.bc.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, ParentError(block.ParentHash())
 	}
-	txExtra.BlockHash = block.Hash()
-	txExtra.BlockIndex = block.NumberU64()
-	txExtra.Index = i
-	rlpMeta, err := rlp.EncodeToBytes(txExtra)
+	receipts, err := sm.TransitionState(state.New(parent.Root(), sm.db), parent, block, true)
 	if err != nil {
-		glog.V(logger.Debug).Infoln("Failed encoding tx meta data", err)
-		return
+		return nil, err
+	}
+	if sha := types.DeriveSha(receipts); sha != block.Header().ReceiptHash {
+		return nil, fmt.Errorf("receipt root of block #%d is %x, derived %x (state missing?)", block.NumberU64(), block.Header().ReceiptHash, sha)
 	}
-	db.Put(append(tx.Hash().Bytes(), 0x0001), rlpMeta)
+	return receipts, nil
 }
@@ -1,12 +1,17 @@
 package core
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/pow/ezp"
 )
 
@@ -20,18 +25,207 @@ func proc() (*BlockProcessor, *ChainManager) {
 
 func TestNumber(t *testing.T) {
 	bp, chain := proc()
-	block1 := chain.NewBlock(common.Address{})
-	block1.Header().Number = big.NewInt(3)
-	block1.Header().Time--
+	header := chain.NewBlock(common.Address{}).Header()
+	header.Number = big.NewInt(3)
+	header.Time--
 
-	err := bp.ValidateHeader(block1.Header(), chain.Genesis().Header())
+	err := bp.ValidateHeader(header, chain.Genesis().Header())
 	if err != BlockNumberErr {
 		t.Errorf("expected block number error %v", err)
 	}
 
-	block1 = chain.NewBlock(common.Address{})
+	block1 := chain.NewBlock(common.Address{})
 	err = bp.ValidateHeader(block1.Header(), chain.Genesis().Header())
 	if err == BlockNumberErr {
 		t.Errorf("didn't expect block number error")
 	}
 }
+
+func TestValidateHeaderChainConfig(t *testing.T) {
+	bp, chain := proc()
+	genesis := chain.Genesis().Header()
+	header := &types.Header{
+		ParentHash: genesis.Hash(),
+		Number:     big.NewInt(1),
+		Time:       genesis.Time,
+		Difficulty: big.NewInt(1),
+		GasLimit:   genesis.GasLimit,
+	}
+
+	if err := bp.validateHeader(header, genesis, false); err == nil {
+		t.Error("expected error with default config")
+	}
+
+	chain.SetConfig(&params.ChainConfig{
+		FixedDifficulty: big.NewInt(1),
+		SubSecondBlocks: true,
+	})
+	if err := bp.validateHeader(header, genesis, false); err != nil {
+		t.Errorf("unexpected error with private chain config: %v", err)
+	}
+	header.Difficulty = big.NewInt(2)
+	if err := bp.validateHeader(header, genesis, false); err == nil {
+		t.Error("expected error for wrong fixed difficulty")
+	}
+}
+
+func TestReceiptStorage(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr1   = common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		db, _   = ethdb.NewMemDatabase()
+		bman, _ = newCanonical(0, db)
+		genesis = bman.bc.CurrentBlock()
+	)
+
+	var transfer, failing *types.Transaction
+	chain := GenerateChain(genesis, db, 2, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			gen.SetCoinbase(addr1)
+		case 1:
+			transfer = types.NewTransactionMessage(common.Address{2}, big.NewInt(1000), big.NewInt(21000), big.NewInt(1), nil)
+			transfer.SetNonce(gen.TxNonce(addr1))
+			transfer.SignECDSA(key)
+			gen.AddTx(transfer)
+
+			// the init code jumps to an invalid destination
+			failing = types.NewContractCreationTx(big.NewInt(0), big.NewInt(100000), big.NewInt(1), common.FromHex("0x600056"))
+			failing.SetNonce(gen.TxNonce(addr1))
+			failing.SignECDSA(key)
+			gen.AddTx(failing)
+		}
+	})
+	if err := bman.bc.InsertChain(chain); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+
+	receipt := ReadReceipt(db, transfer.Hash())
+	if receipt == nil {
+		t.Fatal("transfer receipt not stored")
+	}
+	if receipt.Failed() || receipt.GasUsed().Cmp(big.NewInt(21000)) != 0 || receipt.CumulativeGasUsed.Cmp(big.NewInt(21000)) != 0 {
+		t.Errorf("wrong transfer receipt: failed %v, gas %v, cumulative %v", receipt.Failed(), receipt.GasUsed(), receipt.CumulativeGasUsed)
+	}
+	receipt = ReadReceipt(db, failing.Hash())
+	if receipt == nil {
+		t.Fatal("failing receipt not stored")
+	}
+	if !receipt.Failed() {
+		t.Error("expected failing creation to be marked as failed")
+	}
+	if receipt.CumulativeGasUsed.Cmp(chain[1].GasUsed()) != 0 {
+		t.Errorf("wrong cumulative gas: got %v, want %v", receipt.CumulativeGasUsed, chain[1].GasUsed())
+	}
+	if ReadReceipt(db, common.Hash{1}) != nil {
+		t.Error("expected nil receipt for unknown transaction")
+	}
+}
+
+func TestGetReceipts(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr1   = common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		db, _   = ethdb.NewMemDatabase()
+		bman, _ = newCanonical(0, db)
+		genesis = bman.bc.CurrentBlock()
+		// init code emitting a LOG0
+		logCode = common.FromHex("0x60006000a0")
+	)
+
+	var logTx *types.Transaction
+	chain := GenerateChain(genesis, db, 2, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			gen.SetCoinbase(addr1)
+		case 1:
+			transfer := types.NewTransactionMessage(common.Address{2}, big.NewInt(1000), big.NewInt(21000), big.NewInt(1), nil)
+			transfer.SetNonce(gen.TxNonce(addr1))
+			transfer.SignECDSA(key)
+			gen.AddTx(transfer)
+
+			logTx = types.NewContractCreationTx(big.NewInt(0), big.NewInt(100000), big.NewInt(1), logCode)
+			logTx.SetNonce(gen.TxNonce(addr1))
+			logTx.SignECDSA(key)
+			gen.AddTx(logTx)
+		}
+	})
+	if err := bman.bc.InsertChain(chain); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+
+	if receipts, err := bman.GetReceipts(chain[0]); err != nil || len(receipts) != 0 {
+		t.Errorf("block without transactions: got %d receipts (%v)", len(receipts), err)
+	}
+	receipts, err := bman.GetReceipts(chain[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(receipts) != 2 {
+		t.Fatalf("got %d receipts, want 2", len(receipts))
+	}
+	if len(receipts[0].Logs()) != 0 {
+		t.Errorf("transfer has %d logs", len(receipts[0].Logs()))
+	}
+	logs := receipts[1].Logs()
+	if len(logs) != 1 || logs[0].Address != AddressFromMessage(logTx) || logs[0].TxHash != logTx.Hash() {
+		t.Errorf("wrong logs of the creation: %v", logs)
+	}
+	if receipts[1].CumulativeGasUsed.Cmp(chain[1].GasUsed()) != 0 {
+		t.Errorf("wrong cumulative gas: got %v, want %v", receipts[1].CumulativeGasUsed, chain[1].GasUsed())
+	}
+}
+
+// failingEngine rejects all headers with err while it is set.
+type failingEngine struct {
+	consensus.Engine
+	err error
+}
+
+func (e *failingEngine) VerifyHeader(chain consensus.ChainReader, header, parent *types.Header) error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.Engine.VerifyHeader(chain, header, parent)
+}
+
+func TestBadBlockCache(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	bman, err := newCanonical(0, db)
+	if err != nil {
+		t.Fatal("Could not make new canonical chain:", err)
+	}
+	engine := &failingEngine{Engine: bman.Engine()}
+	bman.SetEngine(engine)
+	chain := GenerateChain(bman.bc.CurrentBlock(), db, 3, func(i int, gen *BlockGen) { gen.SetCoinbase(common.Address{1}) })
+
+	// blocks with unknown ancestors are validated again
+	engine.err = consensus.ErrUnknownAncestor
+	if err := bman.bc.InsertChain(chain[:1]); err == nil {
+		t.Fatal("expected error for unknown ancestor")
+	}
+	engine.err = nil
+	if err := bman.bc.InsertChain(chain[:1]); err != nil {
+		t.Fatalf("block rejected after its ancestors became known: %v", err)
+	}
+
+	// a tampered body doesn't get the genuine block rejected
+	tampered := chain[1].WithBody(nil, []*types.Header{chain[0].Header()})
+	if err := bman.bc.InsertChain(types.Blocks{tampered}); err == nil {
+		t.Fatal("expected error for tampered body")
+	}
+	if err := bman.bc.InsertChain(chain[1:2]); err != nil {
+		t.Fatalf("block rejected after a tampered copy: %v", err)
+	}
+
+	// blocks with invalid headers are rejected without validation
+	invalid := errors.New("invalid block")
+	engine.err = invalid
+	if err := bman.bc.InsertChain(chain[2:]); err == nil {
+		t.Fatal("expected error for invalid block")
+	}
+	engine.err = nil
+	if _, err := bman.Process(chain[2]); err != invalid {
+		t.Errorf("got %v, want cached error %v", err, invalid)
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const (
+	// BloomBitsSection is the number of blocks covered by one section of
+	// the bloom bit index.
+	BloomBitsSection = 4096
+
+	bloomBitLength = 2048 // number of bits in a header bloom
+	bloomConfirms  = 256  // blocks a section must be behind the head before it is indexed
+)
+
+var bloomBitsPre = []byte("bloom-bits-") // bit, section -> bit vector
+
+/*
+BloomIndexer maintains a transposed index of the header blooms. For every
+section of BloomBitsSection blocks and every one of the 2048 bloom bits it
+stores a bit vector with one bit per block of the section, set if the bloom
+of that block has the bit set. Looking up a value then only needs the three
+vectors of its bloom bits instead of every header of the section.
+*/
+type BloomIndexer struct {
+	*ChainIndexer
+	db common.Database
+}
+
+func NewBloomIndexer(db common.Database, chain *ChainManager, mux *event.TypeMux) *BloomIndexer {
+	backend := &bloomIndexerBackend{db: db}
+	return &BloomIndexer{
+		ChainIndexer: NewChainIndexer(db, chain, mux, backend, "bloom-", BloomBitsSection, bloomConfirms),
+		db:           db,
+	}
+}
+
+// Matches returns the numbers of the blocks in section whose blooms contain
+// all bits of at least one entry of every group. The boolean result is
+// false if the section isn't indexed.
+func (self *BloomIndexer) Matches(section uint64, groups [][][3]uint) ([]uint64, bool) {
+	if !self.Valid(section) {
+		return nil, false
+	}
+
+	var result []byte
+	for _, group := range groups {
+		groupVector := make([]byte, BloomBitsSection/8)
+		for _, bits := range group {
+			vector := self.vector(bits[0], section)
+			for _, bit := range bits[1:] {
+				andVector(vector, self.vector(bit, section))
+			}
+			orVector(groupVector, vector)
+		}
+		if result == nil {
+			result = groupVector
+		} else {
+			andVector(result, groupVector)
+		}
+	}
+
+	var matches []uint64
+	for i := uint64(0); i < BloomBitsSection; i++ {
+		if result == nil || result[i/8]&(1<<(7-i%8)) != 0 {
+			matches = append(matches, section*BloomBitsSection+i)
+		}
+	}
+	return matches, true
+}
+
+func (self *BloomIndexer) vector(bit uint, section uint64) []byte {
+	vector := make([]byte, BloomBitsSection/8)
+	data, _ := self.db.Get(bloomBitsKey(bit, section))
+	copy(vector, data)
+	return vector
+}
+
+// bloomIndexerBackend transposes the blooms of a section into bit vectors.
+type bloomIndexerBackend struct {
+	db      common.Database
+	vectors [bloomBitLength][]byte
+	count   uint64
+}
+
+func (self *bloomIndexerBackend) Reset(section uint64) {
+	self.vectors = [bloomBitLength][]byte{}
+	self.count = 0
+}
+
+func (self *bloomIndexerBackend) Process(block *types.Block) {
+	bloom := block.Bloom()
+	for bit := uint(0); bit < bloomBitLength; bit++ {
+		if bloom.TestBit(bit) {
+			if self.vectors[bit] == nil {
+				self.vectors[bit] = make([]byte, BloomBitsSection/8)
+			}
+			self.vectors[bit][self.count/8] |= 1 << (7 - self.count%8)
+		}
+	}
+	self.count++
+}
+
+func (self *bloomIndexerBackend) Commit(section uint64) error {
+	for bit, vector := range self.vectors {
+		if vector != nil {
+			self.db.Put(bloomBitsKey(uint(bit), section), vector)
+		} else {
+			self.db.Delete(bloomBitsKey(uint(bit), section))
+		}
+	}
+	return nil
+}
+
+func bloomBitsKey(bit uint, section uint64) []byte {
+	key := make([]byte, len(bloomBitsPre)+10)
+	copy(key, bloomBitsPre)
+	binary.BigEndian.PutUint16(key[len(bloomBitsPre):], uint16(bit))
+	binary.BigEndian.PutUint64(key[len(bloomBitsPre)+2:], section)
+	return key
+}
+
+func andVector(dst, src []byte) {
+	for i := range dst {
+		dst[i] &= src[i]
+	}
+}
+
+func orVector(dst, src []byte) {
+	for i := range dst {
+		dst[i] |= src[i]
+	}
+}
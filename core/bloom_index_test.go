@@ -0,0 +1,115 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// filterBackend is the Backend used to run filters in tests.
+type filterBackend struct{ bp *BlockProcessor }
+
+func (b filterBackend) BlockProcessor() *BlockProcessor { return b.bp }
+func (b filterBackend) ChainManager() *ChainManager     { return b.bp.bc }
+func (b filterBackend) TxPool() *TxPool                 { return b.bp.txpool }
+func (b filterBackend) PeerCount() int                  { return 0 }
+func (b filterBackend) IsListening() bool               { return false }
+func (b filterBackend) Peers() []*p2p.Peer              { return nil }
+func (b filterBackend) BlockDb() common.Database        { return b.bp.db }
+func (b filterBackend) StateDb() common.Database        { return b.bp.db }
+func (b filterBackend) EventMux() *event.TypeMux        { return b.bp.eventMux }
+
+func TestBloomBits(t *testing.T) {
+	addr := common.Address{1, 2, 3}
+	bloom := types.BytesToBloom(types.LogsBloom(state.Logs{&state.Log{Address: addr}}).Bytes())
+	for _, bit := range types.BloomBits(addr[:]) {
+		if !bloom.TestBit(bit) {
+			t.Errorf("bit %d not set in bloom", bit)
+		}
+	}
+}
+
+func TestBloomIndexer(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr1   = common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		db, _   = ethdb.NewMemDatabase()
+		bman, _ = newCanonical(0, db)
+		genesis = bman.bc.CurrentBlock()
+		// init code emitting a LOG0 of the created contract
+		logCode = common.FromHex("0x60006000a0")
+	)
+
+	// blocks 10 and 3000 create a contract which logs
+	var logTxs []*types.Transaction
+	chain := GenerateChain(genesis, db, BloomBitsSection+bloomConfirms, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			gen.SetCoinbase(addr1)
+		case 9, 2999:
+			tx := types.NewContractCreationTx(big.NewInt(0), big.NewInt(100000), big.NewInt(1), logCode)
+			tx.SetNonce(gen.TxNonce(addr1))
+			tx.SignECDSA(key)
+			gen.AddTx(tx)
+			logTxs = append(logTxs, tx)
+		}
+	})
+	if err := bman.bc.InsertChain(chain); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+
+	indexer := NewBloomIndexer(db, bman.bc, bman.eventMux)
+	indexer.update()
+	if indexer.Sections() != 1 {
+		t.Fatalf("expected 1 indexed section, got %d", indexer.Sections())
+	}
+
+	contract := AddressFromMessage(logTxs[1])
+	groups := [][][3]uint{{types.BloomBits(contract[:])}}
+	matches, ok := indexer.Matches(0, groups)
+	if !ok {
+		t.Fatal("section 0 not indexed")
+	}
+	found := false
+	for _, num := range matches {
+		if num == 3000 {
+			found = true
+		}
+	}
+	if !found || len(matches) > 10 {
+		t.Errorf("expected few matches including block 3000, got %v", matches)
+	}
+	if _, ok := indexer.Matches(1, groups); ok {
+		t.Error("section 1 should not be indexed")
+	}
+
+	// the filter must find the same logs with and without the index
+	for _, index := range []*BloomIndexer{nil, indexer} {
+		bman.SetBloomIndexer(index)
+		filter := NewFilter(filterBackend{bman})
+		filter.SetEarliestBlock(0)
+		filter.SetLatestBlock(-1)
+		filter.SetAddress([]common.Address{contract})
+		logs := filter.Find()
+		if len(logs) != 1 || logs[0].Address != contract {
+			t.Errorf("index %v: expected one log of %x, got %v", index != nil, contract, logs)
+		}
+	}
+
+	// a reorganised section is not used and indexed again on update
+	indexer.db.Put(indexer.sectionKey(0), common.Hash{1}.Bytes())
+	if _, ok := indexer.Matches(0, groups); ok {
+		t.Error("section with non-canonical head should not be used")
+	}
+	indexer.update()
+	if _, ok := indexer.Matches(0, groups); !ok {
+		t.Error("section should have been indexed again")
+	}
+}
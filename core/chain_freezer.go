@@ -0,0 +1,157 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	freezeInterval  = time.Minute // time between two freezer runs
+	freezeBatchSize = 1000        // blocks appended between syncs of the freezer
+)
+
+/*
+ChainFreezer moves the canonical blocks which are more than threshold blocks
+behind the head, and their receipts, from the block and extra database to
+the freezer of the databases. Blocks are only removed from the databases
+once the freezer has been synced.
+*/
+type ChainFreezer struct {
+	chain     *ChainManager
+	blockDb   *AncientDatabase
+	extraDb   *AncientDatabase
+	threshold uint64
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewChainFreezer creates a freezer job. blockDb and extraDb must share the
+// freezer.
+func NewChainFreezer(chain *ChainManager, blockDb, extraDb *AncientDatabase, threshold uint64) *ChainFreezer {
+	return &ChainFreezer{
+		chain:     chain,
+		blockDb:   blockDb,
+		extraDb:   extraDb,
+		threshold: threshold,
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start freezes old blocks in the background.
+func (self *ChainFreezer) Start() {
+	self.wg.Add(1)
+	go func() {
+		defer self.wg.Done()
+
+		ticker := time.NewTicker(freezeInterval)
+		defer ticker.Stop()
+		for {
+			if err := self.Freeze(); err != nil {
+				glog.V(logger.Error).Infoln("freezer:", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-self.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop terminates the background job, waiting for the batch in progress.
+func (self *ChainFreezer) Stop() {
+	close(self.quit)
+	self.wg.Wait()
+}
+
+// Freeze moves all blocks which are deep enough to the freezer.
+func (self *ChainFreezer) Freeze() error {
+	freezer := self.blockDb.Freezer()
+	for {
+		head := self.chain.CurrentBlock().NumberU64()
+		if head < self.threshold {
+			return nil
+		}
+		limit := head - self.threshold
+		start := freezer.Frozen()
+		if start >= limit {
+			return nil
+		}
+		if limit-start > freezeBatchSize {
+			limit = start + freezeBatchSize
+		}
+
+		var frozen []*types.Block
+		for number := start; number < limit; number++ {
+			block, err := self.freeze(freezer, number)
+			if err != nil {
+				return err
+			}
+			frozen = append(frozen, block)
+		}
+		if err := freezer.Sync(); err != nil {
+			return err
+		}
+		self.prune(frozen)
+		glog.V(logger.Debug).Infof("freezer: moved blocks %d-%d", start, limit-1)
+
+		select {
+		case <-self.quit:
+			return nil
+		default:
+		}
+	}
+}
+
+// freeze appends the canonical block with the given number and its receipts
+// to freezer. Blocks imported before receipts were stored locally are frozen
+// with an empty receipt list, their receipts are unavailable either way.
+func (self *ChainFreezer) freeze(freezer *Freezer, number uint64) (*types.Block, error) {
+	hash := ReadCanonicalHash(self.blockDb, number)
+	block := ReadBlock(self.blockDb, hash)
+	if block == nil {
+		return nil, fmt.Errorf("canonical block %d missing", number)
+	}
+	blockEnc, err := rlp.EncodeToBytes((*types.StorageBlock)(block))
+	if err != nil {
+		return nil, err
+	}
+	receipts := make([]receiptStorage, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		receipt := ReadReceipt(self.extraDb, tx.Hash())
+		if receipt == nil {
+			glog.V(logger.Debug).Infof("freezer: block %d has no receipt for tx %x, freezing it without receipts", number, tx.Hash().Bytes()[:4])
+			receipts = receipts[:0]
+			break
+		}
+		receipts = append(receipts, newReceiptStorage(receipt))
+	}
+	receiptsEnc, err := rlp.EncodeToBytes(receipts)
+	if err != nil {
+		return nil, err
+	}
+	return block, freezer.Append(number, blockEnc, receiptsEnc)
+}
+
+// prune removes frozen blocks from the databases. The lookup entries of the
+// blocks are written to disk before the blocks are deleted.
+func (self *ChainFreezer) prune(blocks []*types.Block) {
+	for _, block := range blocks {
+		writeFrozenNumber(self.blockDb, block.Hash(), block.NumberU64())
+	}
+	flushDatabase(self.blockDb)
+
+	for _, block := range blocks {
+		DeleteBlock(self.blockDb, block.Hash())
+		for _, tx := range block.Transactions() {
+			self.extraDb.Delete(receiptKey(tx.Hash()))
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// ChainIndexerBackend builds the index data of one section for a
+// ChainIndexer.
+type ChainIndexerBackend interface {
+	// Reset prepares the backend for processing the given section.
+	Reset(section uint64)
+
+	// Process adds the next block of the current section.
+	Process(block *types.Block)
+
+	// Commit writes the index data of the current section to the database.
+	Commit(section uint64) error
+}
+
+/*
+ChainIndexer runs an index over the canonical chain in the background. The
+chain is split into sections of a fixed number of blocks and a section is
+handed to the backend block by block once it is confirms blocks deep.
+
+The number of processed sections and the hash of the last block of every
+section are stored under the indexer's key prefix. When the last block of
+a processed section is no longer canonical, that section and all following
+ones are rolled back and processed again.
+*/
+type ChainIndexer struct {
+	db          common.Database
+	chain       *ChainManager
+	mux         *event.TypeMux
+	backend     ChainIndexerBackend
+	prefix      []byte
+	sectionSize uint64
+	confirms    uint64
+
+	mu       sync.RWMutex
+	sections uint64 // number of processed sections
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewChainIndexer creates an indexer storing its progress in db under
+// prefix. It doesn't process anything until started.
+func NewChainIndexer(db common.Database, chain *ChainManager, mux *event.TypeMux, backend ChainIndexerBackend, prefix string, sectionSize, confirms uint64) *ChainIndexer {
+	self := &ChainIndexer{
+		db:          db,
+		chain:       chain,
+		mux:         mux,
+		backend:     backend,
+		prefix:      []byte(prefix),
+		sectionSize: sectionSize,
+		confirms:    confirms,
+		quit:        make(chan struct{}),
+	}
+	if data, _ := db.Get(self.sectionsKey()); len(data) == 8 {
+		self.sections = binary.BigEndian.Uint64(data)
+	}
+	return self
+}
+
+// Start processes the available sections and keeps processing new ones as
+// the chain grows.
+func (self *ChainIndexer) Start() {
+	sub := self.mux.Subscribe(ChainHeadEvent{})
+	self.wg.Add(1)
+	go func() {
+		defer self.wg.Done()
+		defer sub.Unsubscribe()
+
+		self.update()
+		for {
+			select {
+			case _, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				self.update()
+			case <-self.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop terminates the indexer, waiting for the section in progress.
+func (self *ChainIndexer) Stop() {
+	close(self.quit)
+	self.wg.Wait()
+}
+
+// Sections returns the number of processed sections.
+func (self *ChainIndexer) Sections() uint64 {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	return self.sections
+}
+
+// SectionSize returns the number of blocks per section.
+func (self *ChainIndexer) SectionSize() uint64 {
+	return self.sectionSize
+}
+
+// Valid reports whether section has been processed and its last block is
+// still canonical.
+func (self *ChainIndexer) Valid(section uint64) bool {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	return section < self.sections && self.canonical(section)
+}
+
+// update rolls back sections which were reorganised away and processes all
+// sections which are deep enough.
+func (self *ChainIndexer) update() {
+	self.mu.Lock()
+	for self.sections > 0 && !self.canonical(self.sections-1) {
+		self.setSections(self.sections - 1)
+	}
+	self.mu.Unlock()
+
+	for {
+		select {
+		case <-self.quit:
+			return
+		default:
+		}
+		section := self.Sections()
+		last := (section+1)*self.sectionSize - 1
+		if self.chain.CurrentBlock().NumberU64() < last+self.confirms {
+			return
+		}
+		head, ok := self.process(section)
+		if !ok {
+			return
+		}
+		self.mu.Lock()
+		self.db.Put(self.sectionKey(section), head[:])
+		self.setSections(section + 1)
+		self.mu.Unlock()
+		glog.V(logger.Debug).Infof("chain index %s: processed section %d (blocks %d-%d)", self.prefix, section, section*self.sectionSize, last)
+	}
+}
+
+// process feeds the blocks of section to the backend and returns the hash
+// of its last block. It returns false if a block is missing or the backend
+// failed.
+func (self *ChainIndexer) process(section uint64) (head common.Hash, ok bool) {
+	self.backend.Reset(section)
+
+	var count uint64
+	for it := self.chain.NewBlockIterator(section*self.sectionSize, (section+1)*self.sectionSize-1); it.Next(); count++ {
+		self.backend.Process(it.Block())
+		head = it.Block().Hash()
+	}
+	if count != self.sectionSize {
+		return head, false
+	}
+	if err := self.backend.Commit(section); err != nil {
+		glog.V(logger.Error).Infof("chain index %s: section %d failed: %v", self.prefix, section, err)
+		return head, false
+	}
+	return head, true
+}
+
+// canonical reports whether the last block of a processed section is part
+// of the canonical chain. It must be called with mu held.
+func (self *ChainIndexer) canonical(section uint64) bool {
+	head, _ := self.db.Get(self.sectionKey(section))
+	return common.BytesToHash(head) == self.chain.GetHashByNumber((section+1)*self.sectionSize-1)
+}
+
+// setSections stores the number of processed sections. It must be called
+// with mu held.
+func (self *ChainIndexer) setSections(sections uint64) {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], sections)
+	self.db.Put(self.sectionsKey(), enc[:])
+	self.sections = sections
+}
+
+func (self *ChainIndexer) sectionsKey() []byte {
+	return append(append([]byte{}, self.prefix...), "sections"...)
+}
+
+func (self *ChainIndexer) sectionKey(section uint64) []byte {
+	key := append(append([]byte{}, self.prefix...), "section-"...)
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], section)
+	return append(key, enc[:]...)
+}
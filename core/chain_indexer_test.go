@@ -0,0 +1,69 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// testIndexerBackend records the blocks of every committed section.
+type testIndexerBackend struct {
+	current  []common.Hash
+	sections map[uint64][]common.Hash
+}
+
+func (b *testIndexerBackend) Reset(section uint64)       { b.current = nil }
+func (b *testIndexerBackend) Process(block *types.Block) { b.current = append(b.current, block.Hash()) }
+func (b *testIndexerBackend) Commit(section uint64) error {
+	b.sections[section] = b.current
+	return nil
+}
+
+func TestChainIndexer(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		bman, _ = newCanonical(0, db)
+		genesis = bman.bc.CurrentBlock()
+		backend = &testIndexerBackend{sections: make(map[uint64][]common.Hash)}
+		indexer = NewChainIndexer(db, bman.bc, bman.eventMux, backend, "test-", 4, 2)
+	)
+
+	// 10 blocks on top of genesis: sections 0 (0-3) and 1 (4-7) are deep enough
+	chain := GenerateChain(genesis, db, 10, func(i int, gen *BlockGen) { gen.SetCoinbase(common.Address{1}) })
+	if err := bman.bc.InsertChain(chain); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+	indexer.update()
+	if indexer.Sections() != 2 {
+		t.Fatalf("expected 2 sections, got %d", indexer.Sections())
+	}
+	if hashes := backend.sections[1]; len(hashes) != 4 || hashes[0] != chain[3].Hash() || hashes[3] != chain[6].Hash() {
+		t.Errorf("wrong blocks in section 1: %x", hashes)
+	}
+
+	// progress is persisted
+	if n := NewChainIndexer(db, bman.bc, bman.eventMux, backend, "test-", 4, 2).Sections(); n != 2 {
+		t.Errorf("expected 2 persisted sections, got %d", n)
+	}
+
+	// replace blocks 6 onwards by a longer fork, section 1 must be redone
+	fork := GenerateChain(chain[4], db, 8, func(i int, gen *BlockGen) { gen.SetCoinbase(common.Address{2}) })
+	if err := bman.bc.InsertChain(fork); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+	if indexer.Valid(1) {
+		t.Error("section 1 should be invalid after the reorg")
+	}
+	if !indexer.Valid(0) {
+		t.Error("section 0 should still be valid")
+	}
+	indexer.update()
+	if indexer.Sections() != 3 {
+		t.Fatalf("expected 3 sections after the reorg, got %d", indexer.Sections())
+	}
+	if hashes := backend.sections[1]; hashes[2] != fork[0].Hash() {
+		t.Errorf("section 1 not reprocessed on the new chain: %x", hashes)
+	}
+}
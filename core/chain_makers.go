@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/pow"
 )
 
@@ -30,7 +31,7 @@ var (
 // Utility functions for making chains on the fly
 // Exposed for sake of testing from other packages (eg. go-ethash)
 func NewBlockFromParent(addr common.Address, parent *types.Block) *types.Block {
-	return newBlockFromParent(addr, parent)
+	return newBlockFromParent(params.DefaultChainConfig, addr, parent)
 }
 
 func MakeBlock(bman *BlockProcessor, parent *types.Block, i int, db common.Database, seed int) *types.Block {
@@ -54,35 +55,41 @@ func NewCanonical(n int, db common.Database) (*BlockProcessor, error) {
 }
 
 // block time is fixed at 10 seconds
-func newBlockFromParent(addr common.Address, parent *types.Block) *types.Block {
-	block := types.NewBlock(parent.Hash(), addr, parent.Root(), common.BigPow(2, 32), 0, nil)
-	block.SetUncles(nil)
-	block.SetTransactions(nil)
-	block.SetReceipts(nil)
-
-	header := block.Header()
-	header.Difficulty = CalcDifficulty(block.Header(), parent.Header())
-	header.Number = new(big.Int).Add(parent.Header().Number, common.Big1)
-	header.Time = parent.Header().Time + 10
-	header.GasLimit = CalcGasLimit(parent, block)
-
+func newBlockFromParent(config *params.ChainConfig, addr common.Address, parent *types.Block) *types.Block {
+	block := types.NewBlock(newHeaderFromParent(config, addr, parent), nil, nil, nil)
 	block.Td = parent.Td
-
 	return block
 }
 
+func newHeaderFromParent(config *params.ChainConfig, addr common.Address, parent *types.Block) *types.Header {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   addr,
+		Root:       parent.Root(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasUsed:    new(big.Int),
+		Time:       parent.Header().Time + 10,
+	}
+	header.Difficulty = CalcDifficulty(config, header, parent.Header())
+	header.GasLimit = CalcGasLimit(config, parent, types.NewBlockWithHeader(header))
+	return header
+}
+
 // Actually make a block by simulating what miner would do
 // we seed chains by the first byte of the coinbase
 func makeBlock(bman *BlockProcessor, parent *types.Block, i int, db common.Database, seed int) *types.Block {
 	var addr common.Address
 	addr[0], addr[19] = byte(seed), byte(i)
-	block := newBlockFromParent(addr, parent)
-	state := state.New(block.Root(), db)
+	config := bman.bc.Config()
+	header := newHeaderFromParent(config, addr, parent)
+	state := state.New(header.Root, db)
 	cbase := state.GetOrNewStateObject(addr)
-	cbase.SetGasPool(CalcGasLimit(parent, block))
+	cbase.SetGasPool(header.GasLimit)
 	cbase.AddBalance(BlockReward)
 	state.Update()
-	block.SetRoot(state.Root())
+	header.Root = state.Root()
+	block := types.NewBlock(header, nil, nil, nil)
+	block.Td = parent.Td
 	return block
 }
 
@@ -93,7 +100,7 @@ func makeChain(bman *BlockProcessor, parent *types.Block, max int, db common.Dat
 	blocks := make(types.Blocks, max)
 	for i := 0; i < max; i++ {
 		block := makeBlock(bman, parent, i, db, seed)
-		_, err := bman.processWithParent(block, parent)
+		_, err := bman.processWithParent(block, parent, true)
 		if err != nil {
 			fmt.Println("process with parent failed", err)
 			panic(err)
@@ -105,11 +112,151 @@ func makeChain(bman *BlockProcessor, parent *types.Block, max int, db common.Dat
 	return blocks
 }
 
+// BlockGen creates blocks for testing.
+// See GenerateChain for a detailed explanation.
+type BlockGen struct {
+	i       int
+	parent  *types.Block
+	chain   []*types.Block
+	header  *types.Header
+	statedb *state.StateDB
+
+	coinbase *state.StateObject
+	gasUsed  *big.Int
+	txs      []*types.Transaction
+	receipts []*types.Receipt
+	uncles   []*types.Header
+}
+
+// SetCoinbase sets the coinbase of the generated block.
+// It can be called at most once and before any transaction is added.
+func (b *BlockGen) SetCoinbase(addr common.Address) {
+	if b.coinbase != nil {
+		if len(b.txs) > 0 {
+			panic("coinbase must be set before adding transactions")
+		}
+		panic("coinbase can only be set once")
+	}
+	b.header.Coinbase = addr
+	b.initCoinbase()
+}
+
+// SetExtra sets the extra data field of the generated block.
+func (b *BlockGen) SetExtra(data []byte) {
+	b.header.Extra = data
+}
+
+// AddTx adds a transaction to the generated block. If no coinbase has
+// been set, the block's coinbase is set to the zero address.
+//
+// AddTx panics if the transaction cannot be executed. In addition to the
+// protocol-imposed limitations (gas limit, etc.), there are some further
+// limitations on the content of transactions that can be added. Notably,
+// the BLOCKHASH instruction always returns the zero hash.
+func (b *BlockGen) AddTx(tx *types.Transaction) {
+	if b.coinbase == nil {
+		b.initCoinbase()
+	}
+	b.statedb.StartRecord(tx.Hash(), common.Hash{}, len(b.txs))
+	_, gas, err := ApplyMessage(NewEnv(b.statedb, nil, tx, types.NewBlockWithHeader(b.header)), tx, b.coinbase)
+	if err != nil && (IsNonceErr(err) || state.IsGasLimitErr(err) || IsInvalidTxErr(err)) {
+		panic(err)
+	}
+	b.statedb.Update()
+	b.gasUsed.Add(b.gasUsed, gas)
+
+	receipt := types.NewReceipt(b.statedb.Root().Bytes(), new(big.Int).Set(b.gasUsed))
+	receipt.SetLogs(b.statedb.GetLogs(tx.Hash()))
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+	b.txs = append(b.txs, tx)
+	b.receipts = append(b.receipts, receipt)
+}
+
+// AddUncle adds an uncle header to the generated block.
+func (b *BlockGen) AddUncle(h *types.Header) {
+	b.uncles = append(b.uncles, h)
+}
+
+// Number returns the block number of the block being generated.
+func (b *BlockGen) Number() *big.Int {
+	return new(big.Int).Set(b.header.Number)
+}
+
+// TxNonce returns the next valid transaction nonce for the
+// account at addr. It panics if the account does not exist.
+func (b *BlockGen) TxNonce(addr common.Address) uint64 {
+	if !b.statedb.HasAccount(addr) {
+		panic("account does not exist")
+	}
+	return b.statedb.GetNonce(addr)
+}
+
+// PrevBlock returns a previously generated block by number. It panics if
+// num is greater or equal to the number of the block being generated.
+// For index -1, PrevBlock returns the parent block given to GenerateChain.
+func (b *BlockGen) PrevBlock(index int) *types.Block {
+	if index >= b.i {
+		panic("block index out of range")
+	}
+	if index == -1 {
+		return b.parent
+	}
+	return b.chain[index]
+}
+
+func (b *BlockGen) initCoinbase() {
+	b.coinbase = b.statedb.GetOrNewStateObject(b.header.Coinbase)
+	b.coinbase.SetGasPool(b.header.GasLimit)
+}
+
+// GenerateChain creates a chain of n blocks. The first block's
+// parent will be the provided parent. db is used to store
+// intermediate states and should contain the parent's state trie.
+//
+// The generator function is called with a new block generator for
+// every block. Any transactions and uncles added to the generator
+// become part of the block. If gen is nil, the blocks will be empty
+// and their coinbase will be the zero address.
+//
+// Blocks created by GenerateChain do not contain valid proof of work
+// values. Inserting them into ChainManager requires use of FakePow or
+// a similar non-validating proof of work implementation.
+func GenerateChain(parent *types.Block, db common.Database, n int, gen func(int, *BlockGen)) []*types.Block {
+	statedb := state.New(parent.Root(), db)
+	blocks := make(types.Blocks, n)
+	for i := 0; i < n; i++ {
+		header := newHeaderFromParent(params.DefaultChainConfig, common.Address{}, parent)
+		b := &BlockGen{i: i, parent: parent, chain: blocks, header: header, statedb: statedb, gasUsed: new(big.Int)}
+		if gen != nil {
+			gen(i, b)
+		}
+		if b.coinbase == nil {
+			b.initCoinbase()
+		}
+
+		header.GasUsed = b.gasUsed
+		block := types.NewBlock(header, b.txs, b.uncles, b.receipts)
+
+		accumulateRewards(statedb, block, BlockReward)
+		statedb.Update()
+		statedb.Sync()
+		header = block.Header()
+		header.Root = statedb.Root()
+		block = block.WithHeader(header)
+		block.Td = CalculateTD(block, parent)
+
+		blocks[i] = block
+		parent = block
+	}
+	return blocks
+}
+
 // Create a new chain manager starting from given block
 // Effectively a fork factory
 func newChainManager(block *types.Block, eventMux *event.TypeMux, db common.Database) *ChainManager {
-	bc := &ChainManager{blockDb: db, stateDb: db, genesisBlock: GenesisBlock(db), eventMux: eventMux}
-	bc.futureBlocks = NewBlockCache(1000)
+	bc := &ChainManager{blockDb: db, stateDb: db, genesisBlock: GenesisBlock(db), eventMux: eventMux, knownBlocks: newHashCache(knownBlockCacheLimit)}
+	bc.futureBlocks = newFutureBlockQueue(maxFutureBlocks)
 	if block == nil {
 		bc.Reset()
 	} else {
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestGenerateChain(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		addr1    = common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		addr2    = common.Address{2}
+		addr3    = common.Address{3}
+		db, _    = ethdb.NewMemDatabase()
+		bman, _  = newCanonical(0, db)
+		genesis  = bman.bc.CurrentBlock()
+		sideHead = GenerateChain(genesis, db, 1, func(i int, gen *BlockGen) { gen.SetCoinbase(addr3) })[0]
+	)
+
+	chain := GenerateChain(genesis, db, 3, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			// addr1 mines the first block to get some ether
+			gen.SetCoinbase(addr1)
+		case 1:
+			tx := types.NewTransactionMessage(addr2, big.NewInt(1000), big.NewInt(21000), big.NewInt(1), nil)
+			tx.SetNonce(gen.TxNonce(addr1))
+			tx.SignECDSA(key)
+			gen.AddTx(tx)
+		case 2:
+			gen.SetExtra([]byte("uncle"))
+			gen.AddUncle(sideHead.Header())
+		}
+	})
+	if err := bman.bc.InsertChain(chain); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+
+	if n := bman.bc.CurrentBlock().Number(); n.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("wrong head number: got %v, want 3", n)
+	}
+	if len(chain[1].Transactions()) != 1 || chain[1].GasUsed().Cmp(big.NewInt(21000)) != 0 {
+		t.Errorf("block 2 should contain the transfer using 21000 gas, got %d txs using %v", len(chain[1].Transactions()), chain[1].GasUsed())
+	}
+	if td := CalculateTD(chain[2], chain[1]); chain[2].Td.Cmp(td) != 0 {
+		t.Errorf("wrong total difficulty: got %v, want %v", chain[2].Td, td)
+	}
+
+	statedb := bman.bc.State()
+	want := new(big.Int).Sub(BlockReward, big.NewInt(1000+21000))
+	if balance := statedb.GetBalance(addr1); balance.Cmp(want) != 0 {
+		t.Errorf("wrong balance of addr1: got %v, want %v", balance, want)
+	}
+	if balance := statedb.GetBalance(addr2); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("wrong balance of addr2: got %v, want 1000", balance)
+	}
+	if balance := statedb.GetBalance(addr3); balance.Sign() <= 0 {
+		t.Errorf("uncle coinbase should have been rewarded, balance %v", balance)
+	}
+}
@@ -1,7 +1,6 @@
 package core
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"math/big"
@@ -15,35 +14,41 @@ import (
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/params"
-	"github.com/ethereum/go-ethereum/rlp"
 )
 
 var (
 	chainlogger = logger.NewLogger("CHAIN")
 	jsonlogger  = logger.NewJsonLogger()
-
-	blockHashPre = []byte("block-hash-")
-	blockNumPre  = []byte("block-num-")
 )
 
-const blockCacheLimit = 10000
+const (
+	blockCacheLimit      = 10000
+	knownBlockCacheLimit = 1024
+	headWriteInterval    = time.Minute // time between writes of the head marker
+)
 
 type StateQuery interface {
 	GetAccount(addr []byte) *state.StateObject
 }
 
-func CalcDifficulty(block, parent *types.Header) *big.Int {
+// CalcDifficulty returns the difficulty that block must have according
+// to the difficulty algorithm selected by config.
+func CalcDifficulty(config *params.ChainConfig, block, parent *types.Header) *big.Int {
+	if config != nil && config.FixedDifficulty != nil {
+		return new(big.Int).Set(config.FixedDifficulty)
+	}
+	divisor, duration, minimum := config.DifficultyParams()
 	diff := new(big.Int)
 
-	adjust := new(big.Int).Div(parent.Difficulty, params.DifficultyBoundDivisor)
-	if big.NewInt(int64(block.Time)-int64(parent.Time)).Cmp(params.DurationLimit) < 0 {
+	adjust := new(big.Int).Div(parent.Difficulty, divisor)
+	if big.NewInt(int64(block.Time)-int64(parent.Time)).Cmp(duration) < 0 {
 		diff.Add(parent.Difficulty, adjust)
 	} else {
 		diff.Sub(parent.Difficulty, adjust)
 	}
 
-	if diff.Cmp(params.MinimumDifficulty) < 0 {
-		return params.MinimumDifficulty
+	if diff.Cmp(minimum) < 0 {
+		return new(big.Int).Set(minimum)
 	}
 
 	return diff
@@ -55,7 +60,9 @@ func CalculateTD(block, parent *types.Block) *big.Int {
 	return td
 }
 
-func CalcGasLimit(parent, block *types.Block) *big.Int {
+// CalcGasLimit returns the gas limit of block. It never drops below the
+// target gas limit of config.
+func CalcGasLimit(config *params.ChainConfig, parent, block *types.Block) *big.Int {
 	if block.Number().Cmp(big.NewInt(0)) == 0 {
 		return common.BigPow(10, 6)
 	}
@@ -68,7 +75,8 @@ func CalcGasLimit(parent, block *types.Block) *big.Int {
 	result := new(big.Int).Add(previous, curInt)
 	result.Div(result, big.NewInt(1024))
 
-	return common.BigMax(params.GenesisGasLimit, result)
+	_, target := config.GasLimitBounds()
+	return common.BigMax(target, result)
 }
 
 type ChainManager struct {
@@ -78,6 +86,7 @@ type ChainManager struct {
 	processor    types.BlockProcessor
 	eventMux     *event.TypeMux
 	genesisBlock *types.Block
+	config       *params.ChainConfig
 	// Last known total difficulty
 	mu            sync.RWMutex
 	tsmu          sync.RWMutex
@@ -88,14 +97,24 @@ type ChainManager struct {
 	transState *state.StateDB
 	txState    *state.ManagedState
 
+	rmLogsFeed event.Feed
+
 	cache        *BlockCache
-	futureBlocks *BlockCache
+	futureBlocks *futureBlockQueue
+	// hashes of recently written blocks, saves database lookups
+	// for blocks announced by several peers.
+	knownBlocks *hashCache
+	headWritten common.Hash // head marker stored in the database
 
 	quit chan struct{}
+	wg   sync.WaitGroup
 }
 
 func NewChainManager(blockDb, stateDb common.Database, mux *event.TypeMux) *ChainManager {
-	bc := &ChainManager{blockDb: blockDb, stateDb: stateDb, genesisBlock: GenesisBlock(stateDb), eventMux: mux, quit: make(chan struct{}), cache: NewBlockCache(blockCacheLimit)}
+	bc := &ChainManager{blockDb: blockDb, stateDb: stateDb, genesisBlock: GenesisBlock(stateDb), config: params.DefaultChainConfig, eventMux: mux, quit: make(chan struct{}), cache: NewBlockCache(blockCacheLimit), knownBlocks: newHashCache(knownBlockCacheLimit)}
+	if ReadDatabaseVersion(blockDb) == 0 {
+		WriteDatabaseVersion(blockDb, DatabaseVersion)
+	}
 	bc.setLastBlock()
 
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
@@ -116,9 +135,10 @@ func NewChainManager(blockDb, stateDb common.Database, mux *event.TypeMux) *Chai
 	// Take ownership of this particular state
 	bc.txState = state.ManageState(bc.State().Copy())
 
-	bc.futureBlocks = NewBlockCache(254)
+	bc.futureBlocks = newFutureBlockQueue(maxFutureBlocks)
 	bc.makeCache()
 
+	bc.wg.Add(1)
 	go bc.update()
 
 	return bc
@@ -141,7 +161,7 @@ func (bc *ChainManager) SetHead(head *types.Block) {
 	bc.transState = statedb.Copy()
 	bc.setTotalDifficulty(head.Td)
 	bc.insert(head)
-	bc.setLastBlock()
+	bc.writeHead()
 }
 
 func (self *ChainManager) Td() *big.Int {
@@ -172,10 +192,27 @@ func (self *ChainManager) Status() (td *big.Int, currentBlock common.Hash, genes
 	return self.td, self.currentBlock.Hash(), self.genesisBlock.Hash()
 }
 
+// SubscribeRemovedLogsEvent registers a subscription of RemovedLogsEvent.
+func (self *ChainManager) SubscribeRemovedLogsEvent() *event.FeedSubscription {
+	return self.rmLogsFeed.Subscribe(eventBufferSize)
+}
+
 func (self *ChainManager) SetProcessor(proc types.BlockProcessor) {
 	self.processor = proc
 }
 
+// Config returns the chain configuration that determines the active
+// protocol rules, such as the gas table, at a given block number.
+func (self *ChainManager) Config() *params.ChainConfig {
+	return self.config
+}
+
+// SetConfig replaces the chain configuration. It must be called before any
+// blocks are processed.
+func (self *ChainManager) SetConfig(config *params.ChainConfig) {
+	self.config = config
+}
+
 func (self *ChainManager) State() *state.StateDB {
 	return state.New(self.CurrentBlock().Root(), self.stateDb)
 }
@@ -205,14 +242,20 @@ func (self *ChainManager) setTransState(statedb *state.StateDB) {
 }
 
 func (bc *ChainManager) setLastBlock() {
-	data, _ := bc.blockDb.Get([]byte("LastBlock"))
-	if len(data) != 0 {
-		block := bc.GetBlock(common.BytesToHash(data))
+	if hash := ReadHeadBlockHash(bc.blockDb); (hash != common.Hash{}) {
+		block := bc.GetBlock(hash)
 		bc.currentBlock = block
 		bc.lastBlockHash = block.Hash()
+		bc.headWritten = block.Hash()
 
 		// Set the last know difficulty (might be 0x0 as initial value, Genesis)
-		bc.td = common.BigD(bc.blockDb.LastKnownTD())
+		bc.td = ReadHeadTd(bc.blockDb)
+
+		// Databases written by older versions may store a head whose state
+		// didn't make it to disk.
+		if !bc.hasState(block) {
+			bc.repairHead()
+		}
 	} else {
 		bc.Reset()
 	}
@@ -222,6 +265,48 @@ func (bc *ChainManager) setLastBlock() {
 	}
 }
 
+// hasState reports whether the state of block is in the state database.
+func (bc *ChainManager) hasState(block *types.Block) bool {
+	if block.Root() == (common.Hash{}) {
+		return true
+	}
+	data, _ := bc.stateDb.Get(block.Root().Bytes())
+	return len(data) != 0
+}
+
+// repairHead rewinds the head to the newest ancestor whose state is
+// available.
+func (bc *ChainManager) repairHead() {
+	head := bc.currentBlock
+	for head.NumberU64() > 0 && !bc.hasState(head) {
+		parent := bc.GetBlock(head.ParentHash())
+		if parent == nil {
+			break
+		}
+		head = parent
+	}
+	glog.V(logger.Error).Infof("State of head #%v (%x) missing, rewinding to #%v (%x)\n", bc.currentBlock.Number(), bc.currentBlock.Hash().Bytes()[:4], head.Number(), head.Hash().Bytes()[:4])
+
+	bc.currentBlock = head
+	bc.lastBlockHash = head.Hash()
+	if head.Td != nil {
+		bc.td = head.Td
+	}
+	bc.writeHead()
+}
+
+// writeHead stores the head marker and total difficulty. The state database
+// is flushed first, so the stored head never refers to a state which isn't
+// on disk. It must be called with mu held or before the chain manager is
+// shared.
+func (bc *ChainManager) writeHead() {
+	flushDatabase(bc.stateDb)
+	WriteHeadBlockHash(bc.blockDb, bc.currentBlock.Hash())
+	WriteHeadTd(bc.blockDb, bc.td)
+	flushDatabase(bc.blockDb)
+	bc.headWritten = bc.currentBlock.Hash()
+}
+
 func (bc *ChainManager) makeCache() {
 	if bc.cache == nil {
 		bc.cache = NewBlockCache(blockCacheLimit)
@@ -249,27 +334,25 @@ func (bc *ChainManager) NewBlock(coinbase common.Address) *types.Block {
 		parentHash = bc.lastBlockHash
 	}
 
-	block := types.NewBlock(
-		parentHash,
-		coinbase,
-		root,
-		common.BigPow(2, 32),
-		0,
-		nil)
-	block.SetUncles(nil)
-	block.SetTransactions(nil)
-	block.SetReceipts(nil)
+	header := &types.Header{
+		ParentHash: parentHash,
+		Coinbase:   coinbase,
+		Root:       root,
+		Difficulty: common.BigPow(2, 32),
+		Number:     new(big.Int),
+		GasLimit:   new(big.Int),
+		GasUsed:    new(big.Int),
+		Time:       uint64(time.Now().Unix()),
+	}
 
 	parent := bc.currentBlock
 	if parent != nil {
-		header := block.Header()
-		header.Difficulty = CalcDifficulty(block.Header(), parent.Header())
+		header.Difficulty = CalcDifficulty(bc.config, header, parent.Header())
 		header.Number = new(big.Int).Add(parent.Header().Number, common.Big1)
-		header.GasLimit = CalcGasLimit(parent, block)
-
+		header.GasLimit = CalcGasLimit(bc.config, parent, types.NewBlockWithHeader(header))
 	}
 
-	return block
+	return types.NewBlock(header, nil, nil, nil)
 }
 
 func (bc *ChainManager) Reset() {
@@ -291,10 +374,15 @@ func (bc *ChainManager) Reset() {
 	bc.makeCache()
 
 	bc.setTotalDifficulty(common.Big("0"))
+	bc.writeHead()
 }
 
 func (bc *ChainManager) removeBlock(block *types.Block) {
-	bc.blockDb.Delete(append(blockHashPre, block.Hash().Bytes()...))
+	bc.knownBlocks.Remove(block.Hash())
+	if ReadCanonicalHash(bc.blockDb, block.NumberU64()) == block.Hash() {
+		DeleteCanonicalHash(bc.blockDb, block.NumberU64())
+	}
+	DeleteBlock(bc.blockDb, block.Hash())
 }
 
 func (bc *ChainManager) ResetWithGenesisBlock(gb *types.Block) {
@@ -311,45 +399,53 @@ func (bc *ChainManager) ResetWithGenesisBlock(gb *types.Block) {
 	bc.insert(bc.genesisBlock)
 	bc.currentBlock = bc.genesisBlock
 	bc.makeCache()
+	bc.writeHead()
 }
 
 // Export writes the active chain to the given writer.
 func (self *ChainManager) Export(w io.Writer) error {
+	return self.ExportEach(func(block *types.Block) error {
+		return block.EncodeRLP(w)
+	})
+}
+
+// ExportEach calls fn for every block of the canonical chain, starting at
+// the genesis block. It stops at the first error returned by fn.
+func (self *ChainManager) ExportEach(fn func(*types.Block) error) error {
 	self.mu.RLock()
 	defer self.mu.RUnlock()
 	glog.V(logger.Info).Infof("exporting %v blocks...\n", self.currentBlock.Header().Number)
 
 	last := self.currentBlock.NumberU64()
 
-	for nr := uint64(0); nr <= last; nr++ {
-		block := self.GetBlockByNumber(nr)
-		if block == nil {
-			return fmt.Errorf("export failed on #%d: not found", nr)
-		}
-
-		if err := block.EncodeRLP(w); err != nil {
+	// the read lock is already held, use the non blocking accessor.
+	var nr uint64
+	for it := newBlockIterator(self.getBlocksFromNumber, 0, last); it.Next(); nr++ {
+		if err := fn(it.Block()); err != nil {
 			return err
 		}
 	}
+	if nr <= last {
+		return fmt.Errorf("export failed on #%d: not found", nr)
+	}
 
 	return nil
 }
 
+// insert makes block the head of the chain. The head marker is written later
+// by writeHead, after the state of the block has been flushed.
 func (bc *ChainManager) insert(block *types.Block) {
-	bc.blockDb.Put([]byte("LastBlock"), block.Hash().Bytes())
 	bc.currentBlock = block
 	bc.lastBlockHash = block.Hash()
 
-	key := append(blockNumPre, block.Number().Bytes()...)
-	bc.blockDb.Put(key, bc.lastBlockHash.Bytes())
+	WriteCanonicalHash(bc.blockDb, block.Hash(), block.NumberU64())
 	// Push block to cache
 	bc.cache.Push(block)
 }
 
 func (bc *ChainManager) write(block *types.Block) {
-	enc, _ := rlp.EncodeToBytes((*types.StorageBlock)(block))
-	key := append(blockHashPre, block.Hash().Bytes()...)
-	bc.blockDb.Put(key, enc)
+	WriteBlock(bc.blockDb, block)
+	bc.knownBlocks.Add(block.Hash(), nil)
 }
 
 // Accessors
@@ -359,8 +455,14 @@ func (bc *ChainManager) Genesis() *types.Block {
 
 // Block fetching methods
 func (bc *ChainManager) HasBlock(hash common.Hash) bool {
-	data, _ := bc.blockDb.Get(append(blockHashPre, hash[:]...))
-	return len(data) != 0
+	if bc.knownBlocks.Has(hash) {
+		return true
+	}
+	if !HasBlock(bc.blockDb, hash) {
+		return false
+	}
+	bc.knownBlocks.Add(hash, nil)
+	return true
 }
 
 func (self *ChainManager) GetBlockHashesFromHash(hash common.Hash, max uint64) (chain []common.Hash) {
@@ -389,34 +491,67 @@ func (self *ChainManager) GetBlock(hash common.Hash) *types.Block {
 		return block
 	}
 
-	data, _ := self.blockDb.Get(append(blockHashPre, hash[:]...))
-	if len(data) == 0 {
+	return ReadBlock(self.blockDb, hash)
+}
+
+// GetBlockByNumber returns the canonical block with the given number. The
+// database is read without holding the chain lock so that readers don't
+// queue up behind an insertion.
+func (self *ChainManager) GetBlockByNumber(num uint64) *types.Block {
+	if current := self.CurrentBlock(); current != nil && num > current.NumberU64() {
 		return nil
 	}
-	var block types.StorageBlock
-	if err := rlp.Decode(bytes.NewReader(data), &block); err != nil {
-		glog.V(logger.Error).Infof("invalid block RLP for hash %x: %v", hash, err)
+	hash := ReadCanonicalHash(self.blockDb, num)
+	if (hash == common.Hash{}) {
 		return nil
 	}
-	return (*types.Block)(&block)
+	return self.GetBlock(hash)
 }
 
-func (self *ChainManager) GetBlockByNumber(num uint64) *types.Block {
+// GetHashByNumber returns the hash of the canonical block with the given
+// number, or the zero hash if there is none.
+func (self *ChainManager) GetHashByNumber(num uint64) common.Hash {
 	self.mu.RLock()
 	defer self.mu.RUnlock()
 
-	return self.getBlockByNumber(num)
-
+	return ReadCanonicalHash(self.blockDb, num)
 }
 
-// non blocking version
+// non blocking version. The block is looked up in the number to hash index
+// of the canonical chain, which insert and merge keep up to date.
 func (self *ChainManager) getBlockByNumber(num uint64) *types.Block {
-	key, _ := self.blockDb.Get(append(blockNumPre, big.NewInt(int64(num)).Bytes()...))
-	if len(key) == 0 {
+	if self.currentBlock != nil && num > self.currentBlock.NumberU64() {
 		return nil
 	}
+	hash := ReadCanonicalHash(self.blockDb, num)
+	if (hash == common.Hash{}) {
+		return nil
+	}
+
+	return self.GetBlock(hash)
+}
+
+// GetBlocksFromNumber returns up to count consecutive blocks of the
+// canonical chain, starting at block number start. The returned slice
+// is shorter than count if the chain ends before.
+func (self *ChainManager) GetBlocksFromNumber(start, count uint64) []*types.Block {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
 
-	return self.GetBlock(common.BytesToHash(key))
+	return self.getBlocksFromNumber(start, count)
+}
+
+// non blocking version
+func (self *ChainManager) getBlocksFromNumber(start, count uint64) []*types.Block {
+	blocks := make([]*types.Block, 0, count)
+	for num := start; num < start+count; num++ {
+		block := self.getBlockByNumber(num)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
 }
 
 func (self *ChainManager) GetUnclesInChain(block *types.Block, length int) (uncles []*types.Header) {
@@ -442,7 +577,6 @@ func (self *ChainManager) GetAncestors(block *types.Block, length int) (blocks [
 }
 
 func (bc *ChainManager) setTotalDifficulty(td *big.Int) {
-	bc.blockDb.Put([]byte("LTD"), td.Bytes())
 	bc.td = td
 }
 
@@ -466,10 +600,19 @@ func (self *ChainManager) CalcTotalDiff(block *types.Block) (*big.Int, error) {
 	return td, nil
 }
 
+// Stop terminates the chain manager and writes the head marker of the
+// current head.
 func (bc *ChainManager) Stop() {
 	close(bc.quit)
+	bc.wg.Wait()
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.writeHead()
 }
 
+// queueEvent carries the events generated by a chain insertion
+// in the order they should be posted.
 type queueEvent struct {
 	queue          []interface{}
 	canonicalCount int
@@ -477,50 +620,74 @@ type queueEvent struct {
 	splitCount     int
 }
 
+// procFutureBlocks retries the insertion of queued future blocks
+// whose timestamp has become valid.
 func (self *ChainManager) procFutureBlocks() {
-	blocks := make([]*types.Block, len(self.futureBlocks.blocks))
-	self.futureBlocks.Each(func(i int, block *types.Block) {
-		blocks[i] = block
-	})
+	if blocks := self.futureBlocks.Ready(time.Now().Unix()); len(blocks) > 0 {
+		self.InsertChain(blocks)
+	}
+}
 
-	types.BlockBy(types.Number).Sort(blocks)
-	self.InsertChain(blocks)
+// sealVerifier is implemented by block processors that can verify the
+// proof of work of a chain segment ahead of processing it.
+type sealVerifier interface {
+	VerifySeals(chain types.Blocks, abort <-chan struct{}) <-chan bool
+	ProcessVerified(block *types.Block) (state.Logs, error)
 }
 
 func (self *ChainManager) InsertChain(chain types.Blocks) error {
 	// A queued approach to delivering events. This is generally faster than direct delivery and requires much less mutex acquiring.
 	var (
-		queue      = make([]interface{}, len(chain))
-		queueEvent = queueEvent{queue: queue}
+		queueEvent = queueEvent{queue: make([]interface{}, 0, len(chain))}
 		stats      struct{ queued, processed int }
 		tstart     = time.Now()
 	)
-	for i, block := range chain {
+	// Verify the seals of all blocks concurrently while processing them in order.
+	verifier, verifySeals := self.processor.(sealVerifier)
+	var seals <-chan bool
+	if verifySeals {
+		abort := make(chan struct{})
+		defer close(abort)
+		seals = verifier.VerifySeals(chain, abort)
+	}
+	for _, block := range chain {
+		bstart := time.Now()
+		sealed := true
+		if verifySeals {
+			sealed = <-seals
+		}
 		if block == nil {
 			continue
 		}
 		// Call in to the block processor and check for errors. It's likely that if one block fails
 		// all others will fail too (unless a known block is returned).
-		logs, err := self.processor.Process(block)
+		var (
+			logs state.Logs
+			err  error
+		)
+		switch {
+		case !verifySeals:
+			logs, err = self.processor.Process(block)
+		case !sealed:
+			err = ValidationError("Block's nonce is invalid (= %x)", block.Nonce())
+		default:
+			logs, err = verifier.ProcessVerified(block)
+		}
 		if err != nil {
 			if IsKnownBlockErr(err) {
 				continue
 			}
 
 			block.Td = new(big.Int)
-			// Do not penelise on future block. We'll need a block queue eventually that will queue
-			// future block for future use
-			if err == BlockFutureErr {
-				block.SetQueued(true)
-				self.futureBlocks.Push(block)
-				stats.queued++
-				continue
-			}
-
-			if IsParentErr(err) && self.futureBlocks.Has(block.ParentHash()) {
-				block.SetQueued(true)
-				self.futureBlocks.Push(block)
-				stats.queued++
+			// Do not penelise on future block. Such blocks (and their descendants)
+			// are queued and inserted once their timestamp becomes valid.
+			if err == BlockFutureErr || (IsParentErr(err) && self.futureBlocks.Has(block.ParentHash())) {
+				if self.futureBlocks.Push(block) {
+					block.SetQueued(true)
+					stats.queued++
+				} else {
+					glog.V(logger.Debug).Infof("future block queue full, dropped #%v (%x)\n", block.Number(), block.Hash().Bytes()[:4])
+				}
 				continue
 			}
 
@@ -544,6 +711,21 @@ func (self *ChainManager) InsertChain(chain types.Blocks) error {
 			// Compare the TD of the last known block in the canonical chain to make sure it's greater.
 			// At this point it's possible that a different chain (fork) becomes the new canonical chain.
 			if block.Td.Cmp(self.td) > 0 {
+				// Blocks of the old canonical chain which are not ancestors of
+				// block are removed from the canonical chain, their logs are gone.
+				if block.ParentHash() != cblock.Hash() {
+					jsonlogger.LogJson(&logger.EthChainReorg{
+						OldHeadHash:   cblock.Hash().Hex(),
+						OldHeadNumber: cblock.Number(),
+						NewHeadHash:   block.Hash().Hex(),
+						NewHeadNumber: block.Number(),
+					})
+					if logs := self.removedLogs(cblock, block); len(logs) > 0 {
+						queueEvent.queue = append(queueEvent.queue, RemovedLogsEvent{logs})
+					}
+					// merge the two chains and create the new canonical chain
+					self.merge(cblock, block)
+				}
 				//if block.Header().Number.Cmp(new(big.Int).Add(cblock.Header().Number, common.Big1)) < 0 {
 				if block.Number().Cmp(cblock.Number()) <= 0 {
 					chash := cblock.Hash()
@@ -552,10 +734,13 @@ func (self *ChainManager) InsertChain(chain types.Blocks) error {
 					if glog.V(logger.Info) {
 						glog.Infof("Split detected. New head #%v (%x) TD=%v, was #%v (%x) TD=%v\n", block.Header().Number, hash[:4], block.Td, cblock.Header().Number, chash[:4], self.td)
 					}
-					// during split we merge two different chains and create the new canonical chain
-					self.merge(self.getBlockByNumber(block.NumberU64()), block)
+					// the old chain above a shorter but heavier fork is no
+					// longer canonical
+					for n := block.NumberU64() + 1; n <= cblock.NumberU64(); n++ {
+						DeleteCanonicalHash(self.blockDb, n)
+					}
 
-					queue[i] = ChainSplitEvent{block, logs}
+					queueEvent.queue = append(queueEvent.queue, ChainSplitEvent{block, logs})
 					queueEvent.splitCount++
 				}
 
@@ -572,19 +757,29 @@ func (self *ChainManager) InsertChain(chain types.Blocks) error {
 				self.setTransState(state.New(block.Root(), self.stateDb))
 				self.setTxState(state.New(block.Root(), self.stateDb))
 
-				queue[i] = ChainEvent{block, logs}
+				queueEvent.queue = append(queueEvent.queue, ChainEvent{block, logs})
 				queueEvent.canonicalCount++
 
 				if glog.V(logger.Debug) {
 					glog.Infof("inserted block #%d (%d TXs %d UNCs) (%x...)\n", block.Number(), len(block.Transactions()), len(block.Uncles()), block.Hash().Bytes()[0:4])
 				}
 			} else {
-				queue[i] = ChainSideEvent{block, logs}
+				queueEvent.queue = append(queueEvent.queue, ChainSideEvent{block, logs})
 				queueEvent.sideCount++
 			}
 		}
+		canonical := self.currentBlock == block
 		self.mu.Unlock()
 
+		jsonlogger.LogJson(&logger.EthChainBlockImported{
+			BlockHash:   block.Hash().Hex(),
+			BlockNumber: block.Number(),
+			TxCount:     len(block.Transactions()),
+			GasUsed:     block.GasUsed(),
+			Elapsed:     float64(time.Since(bstart)) / float64(time.Millisecond),
+			Canonical:   canonical,
+		})
+
 		stats.processed++
 
 		self.futureBlocks.Delete(block.Hash())
@@ -602,20 +797,68 @@ func (self *ChainManager) InsertChain(chain types.Blocks) error {
 	return nil
 }
 
-// merge takes two blocks, an old chain and a new chain and will reconstruct the blocks and inserts them
-// to be part of the new canonical chain.
+// logsGetter is implemented by block processors that can recompute
+// the logs of a block.
+type logsGetter interface {
+	GetLogs(block *types.Block) (state.Logs, error)
+}
+
+// removedLogs returns the logs of the blocks in the chain ending in oldHead
+// which are not part of the chain ending in newHead, marked as removed.
+func (self *ChainManager) removedLogs(oldHead, newHead *types.Block) (logs state.Logs) {
+	getter, ok := self.processor.(logsGetter)
+	if !ok {
+		return nil
+	}
+	var removed types.Blocks
+	oldBlock, newBlock := oldHead, self.GetBlock(newHead.ParentHash())
+	for oldBlock != nil && newBlock != nil && oldBlock.NumberU64() > newBlock.NumberU64() {
+		removed = append(removed, oldBlock)
+		oldBlock = self.GetBlock(oldBlock.ParentHash())
+	}
+	for newBlock != nil && oldBlock != nil && newBlock.NumberU64() > oldBlock.NumberU64() {
+		newBlock = self.GetBlock(newBlock.ParentHash())
+	}
+	for oldBlock != nil && newBlock != nil && oldBlock.Hash() != newBlock.Hash() {
+		removed = append(removed, oldBlock)
+		oldBlock, newBlock = self.GetBlock(oldBlock.ParentHash()), self.GetBlock(newBlock.ParentHash())
+	}
+	// collect the logs from the oldest block on
+	for i := len(removed) - 1; i >= 0; i-- {
+		blockLogs, err := getter.GetLogs(removed[i])
+		if err != nil {
+			glog.V(logger.Error).Infof("failed to get logs of removed block #%v: %v\n", removed[i].Number(), err)
+			continue
+		}
+		for _, log := range blockLogs {
+			log.Removed = true
+		}
+		logs = append(logs, blockLogs...)
+	}
+	return logs
+}
+
+// merge takes the head of the old chain and the head of a new chain and makes
+// the ancestors of the new head part of the canonical chain. The new chain may
+// be shorter or longer than the old one.
 func (self *ChainManager) merge(oldBlock, newBlock *types.Block) {
 	glog.V(logger.Debug).Infof("Applying diff to %x & %x\n", oldBlock.Hash().Bytes()[:4], newBlock.Hash().Bytes()[:4])
 
 	var oldChain, newChain types.Blocks
 	// First find the split (common ancestor) so we can perform an adequate merge
-	for {
-		oldBlock, newBlock = self.GetBlock(oldBlock.ParentHash()), self.GetBlock(newBlock.ParentHash())
-		if oldBlock.Hash() == newBlock.Hash() {
-			break
-		}
+	newBlock = self.GetBlock(newBlock.ParentHash())
+	for newBlock.NumberU64() > oldBlock.NumberU64() {
+		newChain = append(newChain, newBlock)
+		newBlock = self.GetBlock(newBlock.ParentHash())
+	}
+	for oldBlock.NumberU64() > newBlock.NumberU64() {
+		oldChain = append(oldChain, oldBlock)
+		oldBlock = self.GetBlock(oldBlock.ParentHash())
+	}
+	for oldBlock.Hash() != newBlock.Hash() {
 		oldChain = append(oldChain, oldBlock)
 		newChain = append(newChain, newBlock)
+		oldBlock, newBlock = self.GetBlock(oldBlock.ParentHash()), self.GetBlock(newBlock.ParentHash())
 	}
 
 	// insert blocks
@@ -624,43 +867,60 @@ func (self *ChainManager) merge(oldBlock, newBlock *types.Block) {
 	}
 
 	if glog.V(logger.Detail) {
-		for i, oldBlock := range oldChain {
+		for _, oldBlock := range oldChain {
 			glog.Infof("- %.10v   = %x\n", oldBlock.Number(), oldBlock.Hash())
-			glog.Infof("+ %.10v   = %x\n", newChain[i].Number(), newChain[i].Hash())
+		}
+		for _, newBlock := range newChain {
+			glog.Infof("+ %.10v   = %x\n", newBlock.Number(), newBlock.Hash())
 		}
 	}
 }
 
 func (self *ChainManager) update() {
+	defer self.wg.Done()
+
 	events := self.eventMux.Subscribe(queueEvent{})
-	futureTimer := time.NewTicker(5 * time.Second)
+	futureTimer := time.NewTicker(time.Second)
+	defer futureTimer.Stop()
+	headTimer := time.NewTicker(headWriteInterval)
+	defer headTimer.Stop()
 out:
 	for {
 		select {
 		case ev := <-events.Chan():
 			switch ev := ev.(type) {
 			case queueEvent:
-				for i, event := range ev.queue {
+				var head *types.Block
+				for _, event := range ev.queue {
 					switch event := event.(type) {
 					case ChainEvent:
-						// We need some control over the mining operation. Acquiring locks and waiting for the miner to create new block takes too long
-						// and in most cases isn't even necessary.
-						if i+1 == ev.canonicalCount {
-							self.eventMux.Post(ChainHeadEvent{event.Block})
-						}
+						head = event.Block
 					case ChainSplitEvent:
 						// On chain splits we need to reset the transaction state. We can't be sure whether the actual
 						// state of the accounts are still valid.
-						if i == ev.splitCount {
-							self.setTxState(state.New(event.Block.Root(), self.stateDb))
-						}
+						self.setTxState(state.New(event.Block.Root(), self.stateDb))
+					case RemovedLogsEvent:
+						self.rmLogsFeed.Send(event)
+						continue
 					}
 
 					self.eventMux.Post(event)
 				}
+				// We need some control over the mining operation. Acquiring locks and waiting for the miner to create new block takes too long
+				// and in most cases isn't even necessary. The head event is only posted for the last
+				// canonical block of the insertion.
+				if head != nil {
+					self.eventMux.Post(ChainHeadEvent{head})
+				}
 			}
 		case <-futureTimer.C:
 			self.procFutureBlocks()
+		case <-headTimer.C:
+			self.mu.Lock()
+			if self.currentBlock.Hash() != self.headWritten {
+				self.writeHead()
+			}
+			self.mu.Unlock()
 		case <-self.quit:
 			break out
 		}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
@@ -8,8 +9,11 @@ import (
 	"runtime"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -344,3 +348,243 @@ func TestGetAncestors(t *testing.T) {
 	ancestors := chainMan.GetAncestors(chain[len(chain)-1], 4)
 	fmt.Println(ancestors)
 }
+
+func TestChainHeadEventDebounce(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	bman, err := newCanonical(0, db)
+	if err != nil {
+		t.Fatal("could not make new canonical chain:", err)
+	}
+	bc := bman.bc
+	bc.quit = make(chan struct{})
+	bc.wg.Add(1)
+	go bc.update()
+	defer bc.Stop()
+
+	sub := bc.eventMux.Subscribe(ChainEvent{}, ChainHeadEvent{})
+	defer sub.Unsubscribe()
+
+	chain := makeChain(bman, bc.CurrentBlock(), 5, db, CanonicalSeed)
+	if err := bc.InsertChain(chain); err != nil {
+		t.Fatal("insert error:", err)
+	}
+
+	for i := 0; i < len(chain)+1; i++ {
+		select {
+		case ev := <-sub.Chan():
+			switch ev := ev.(type) {
+			case ChainEvent:
+				if i >= len(chain) || ev.Block.Hash() != chain[i].Hash() {
+					t.Fatalf("event %d: unexpected chain event for block #%v", i, ev.Block.Number())
+				}
+			case ChainHeadEvent:
+				if i != len(chain) {
+					t.Fatalf("event %d: head event before all chain events", i)
+				}
+				if ev.Block.Hash() != chain[len(chain)-1].Hash() {
+					t.Errorf("head event for block #%v, want #%v", ev.Block.Number(), chain[len(chain)-1].Number())
+				}
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for event %d", i)
+		}
+	}
+}
+
+func TestHeadMarkerWrittenAfterState(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	bman, err := newCanonical(0, db)
+	if err != nil {
+		t.Fatal("could not make new canonical chain:", err)
+	}
+	bc := bman.bc
+	genesis := bc.CurrentBlock()
+
+	chain := makeChain(bman, genesis, 5, db, CanonicalSeed)
+	if err := bc.InsertChain(chain); err != nil {
+		t.Fatal("insert error:", err)
+	}
+	// the head marker is only moved by writeHead
+	if hash := ReadHeadBlockHash(db); hash != genesis.Hash() {
+		t.Errorf("head marker moved on insert: %x", hash)
+	}
+	bc.mu.Lock()
+	bc.writeHead()
+	bc.mu.Unlock()
+	head := chain[len(chain)-1]
+	if hash := ReadHeadBlockHash(db); hash != head.Hash() {
+		t.Errorf("head marker %x, want %x", hash, head.Hash())
+	}
+
+	// a head whose state is missing is rewound on startup
+	db.Delete(head.Root().Bytes())
+	bc = NewChainManager(db, db, bc.eventMux)
+	defer bc.Stop()
+	if bc.CurrentBlock().Hash() != chain[len(chain)-2].Hash() {
+		t.Errorf("head #%v after repair, want #%v", bc.CurrentBlock().Number(), chain[len(chain)-2].Number())
+	}
+	if hash := ReadHeadBlockHash(db); hash != chain[len(chain)-2].Hash() {
+		t.Errorf("repaired head not written: %x", hash)
+	}
+}
+
+func TestExportEach(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	bman, err := newCanonical(5, db)
+	if err != nil {
+		t.Fatal("Could not make new canonical chain:", err)
+	}
+	var numbers []uint64
+	err = bman.bc.ExportEach(func(block *types.Block) error {
+		if block.Hash() != bman.bc.GetBlockByNumber(block.NumberU64()).Hash() {
+			t.Errorf("block #%d is not canonical", block.NumberU64())
+		}
+		numbers = append(numbers, block.NumberU64())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 6 || numbers[0] != 0 || numbers[5] != 5 {
+		t.Errorf("exported blocks %v, want 0 to 5", numbers)
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err = bman.bc.ExportEach(func(*types.Block) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("export did not stop at the first error: %v after %d blocks", err, n)
+	}
+}
+
+func TestCanonicalIndexAfterSetHead(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	bman, err := newCanonical(5, db)
+	if err != nil {
+		t.Fatal("Could not make new canonical chain:", err)
+	}
+	bc := bman.bc
+	for n := uint64(0); n <= 5; n++ {
+		if block := bc.GetBlockByNumber(n); block == nil || block.NumberU64() != n || bc.GetHashByNumber(n) != block.Hash() {
+			t.Fatalf("block #%d not found by number", n)
+		}
+	}
+	if bc.GetBlockByNumber(6) != nil {
+		t.Error("found block above the head")
+	}
+
+	bc.SetHead(bc.GetBlockByNumber(2))
+	for n := uint64(3); n <= 5; n++ {
+		if bc.GetBlockByNumber(n) != nil {
+			t.Errorf("block #%d still found after rewinding to #2", n)
+		}
+		if hash := ReadCanonicalHash(db, n); (hash != common.Hash{}) {
+			t.Errorf("canonical hash of #%d not removed: %x", n, hash)
+		}
+	}
+	if block := bc.GetBlockByNumber(2); block == nil || block.Hash() != bc.CurrentBlock().Hash() {
+		t.Error("head not found by number")
+	}
+}
+
+func TestRemovedLogs(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr1   = common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		db, _   = ethdb.NewMemDatabase()
+		bman, _ = newCanonical(0, db)
+		genesis = bman.bc.CurrentBlock()
+		// init code emitting a LOG0
+		logCode = common.FromHex("0x60006000a0")
+	)
+
+	chain := GenerateChain(genesis, db, 2, func(i int, gen *BlockGen) {
+		switch i {
+		case 0:
+			gen.SetCoinbase(addr1)
+		case 1:
+			tx := types.NewContractCreationTx(big.NewInt(0), big.NewInt(100000), big.NewInt(1), logCode)
+			tx.SetNonce(gen.TxNonce(addr1))
+			tx.SignECDSA(key)
+			gen.AddTx(tx)
+		}
+	})
+	if err := bman.bc.InsertChain(chain); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+	fork := GenerateChain(genesis, db, 3, func(i int, gen *BlockGen) { gen.SetCoinbase(common.Address{2}) })
+	if err := bman.bc.InsertChain(fork); err != nil {
+		t.Fatalf("fork insert error: %v", err)
+	}
+	if bman.bc.CurrentBlock().Hash() != fork[2].Hash() {
+		t.Fatal("fork did not become canonical")
+	}
+
+	logs := bman.bc.removedLogs(chain[1], fork[2])
+	if len(logs) != 1 {
+		t.Fatalf("got %d removed logs, want 1", len(logs))
+	}
+	if !logs[0].Removed || logs[0].BlockHash != chain[1].Hash() {
+		t.Errorf("wrong removed log: removed %v, block %x", logs[0].Removed, logs[0].BlockHash)
+	}
+}
+
+func TestCanonicalIndexAfterLongerFork(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	bman, err := newCanonical(0, db)
+	if err != nil {
+		t.Fatal("Could not make new canonical chain:", err)
+	}
+	bc := bman.bc
+	chain := GenerateChain(bc.CurrentBlock(), db, 5, func(i int, gen *BlockGen) { gen.SetCoinbase(common.Address{1}) })
+	if err := bc.InsertChain(chain); err != nil {
+		t.Fatal("insert error:", err)
+	}
+	// a longer fork of block #2 replaces blocks 3-5 of the old chain
+	fork := GenerateChain(chain[1], db, 5, func(i int, gen *BlockGen) { gen.SetCoinbase(common.Address{2}) })
+	// insert the fork block by block, it takes over at block #6
+	for _, block := range fork {
+		if err := bc.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatal("insert error:", err)
+		}
+	}
+	for i, block := range fork {
+		if hash := bc.GetHashByNumber(block.NumberU64()); hash != block.Hash() {
+			t.Errorf("fork block %d: canonical hash of #%d is %x, want %x", i, block.NumberU64(), hash, block.Hash())
+		}
+	}
+}
+
+// BenchmarkInsertChain imports freshly decoded blocks, so that their hashes
+// are computed by the import like for blocks received from the network.
+func BenchmarkInsertChain(b *testing.B) {
+	db, _ := ethdb.NewMemDatabase()
+	bman, err := newCanonical(0, db)
+	if err != nil {
+		b.Fatal("Could not make new canonical chain:", err)
+	}
+	chain := GenerateChain(bman.bc.CurrentBlock(), db, 100, func(i int, gen *BlockGen) { gen.SetCoinbase(common.Address{1}) })
+	enc, err := rlp.EncodeToBytes(chain)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db, _ := ethdb.NewMemDatabase()
+		bman, _ := newCanonical(0, db)
+		var blocks types.Blocks
+		if err := rlp.DecodeBytes(enc, &blocks); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+
+		if err := bman.bc.InsertChain(blocks); err != nil {
+			b.Fatal("insert error:", err)
+		}
+	}
+}
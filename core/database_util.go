@@ -0,0 +1,317 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DatabaseVersion is the version of the key layout below. It must be bumped
+// whenever a key or the encoding of a value changes.
+const DatabaseVersion = 1
+
+// Keys and key prefixes of the chain database. Transaction keys are the
+// transaction hash followed by a one byte suffix.
+var (
+	databaseVersionKey = []byte("DatabaseVersion")
+	headBlockKey       = []byte("LastBlock")
+	headTdKey          = []byte("LTD")
+
+	blockHashPre   = []byte("block-hash-")   // block hash -> block RLP
+	blockNumPre    = []byte("block-num-")    // big endian number -> canonical hash
+	blockFrozenPre = []byte("block-frozen-") // block hash -> big endian uint64 number in the freezer
+
+	txMetaSuffix  = byte(0x01) // tx hash + suffix -> block hash, number and index
+	receiptSuffix = byte(0x02) // tx hash + suffix -> receiptStorage
+)
+
+/*
+AncientDatabase is a database whose old blocks and receipts have been moved
+to a freezer. The accessors below fall back to the freezer for data which
+isn't in the database.
+*/
+type AncientDatabase struct {
+	common.Database
+	freezer *Freezer
+}
+
+func NewAncientDatabase(db common.Database, freezer *Freezer) *AncientDatabase {
+	return &AncientDatabase{Database: db, freezer: freezer}
+}
+
+// Freezer returns the freezer of db.
+func (db *AncientDatabase) Freezer() *Freezer {
+	return db.freezer
+}
+
+// frozenNumber returns the number of the frozen block with the given hash.
+func frozenNumber(db common.Database, hash common.Hash) (*Freezer, uint64, bool) {
+	ancient, ok := db.(*AncientDatabase)
+	if !ok {
+		return nil, 0, false
+	}
+	data, _ := db.Get(append(append([]byte{}, blockFrozenPre...), hash[:]...))
+	if len(data) != 8 {
+		return nil, 0, false
+	}
+	return ancient.freezer, binary.BigEndian.Uint64(data), true
+}
+
+func writeFrozenNumber(db common.Database, hash common.Hash, number uint64) {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], number)
+	db.Put(append(append([]byte{}, blockFrozenPre...), hash[:]...), enc[:])
+}
+
+// flushDatabase writes the queued writes of db to disk if it queues writes.
+func flushDatabase(db common.Database) {
+	if ancient, ok := db.(*AncientDatabase); ok {
+		db = ancient.Database
+	}
+	if db, ok := db.(interface {
+		Flush() error
+	}); ok {
+		if err := db.Flush(); err != nil {
+			glog.V(logger.Error).Infoln("flush error:", err)
+		}
+	}
+}
+
+// ReadDatabaseVersion returns the key layout version of db, 0 if none has
+// been written.
+func ReadDatabaseVersion(db common.Database) int {
+	data, _ := db.Get(databaseVersionKey)
+	return int(common.BigD(data).Int64())
+}
+
+// WriteDatabaseVersion stores the key layout version of db.
+func WriteDatabaseVersion(db common.Database, version int) {
+	db.Put(databaseVersionKey, big.NewInt(int64(version)).Bytes())
+}
+
+// ReadHeadBlockHash returns the hash of the current head block.
+func ReadHeadBlockHash(db common.Database) common.Hash {
+	data, _ := db.Get(headBlockKey)
+	return common.BytesToHash(data)
+}
+
+// WriteHeadBlockHash stores the hash of the current head block.
+func WriteHeadBlockHash(db common.Database, hash common.Hash) {
+	db.Put(headBlockKey, hash.Bytes())
+}
+
+// ReadHeadTd returns the total difficulty of the current head block.
+func ReadHeadTd(db common.Database) *big.Int {
+	data, _ := db.Get(headTdKey)
+	return common.BigD(data)
+}
+
+// WriteHeadTd stores the total difficulty of the current head block.
+func WriteHeadTd(db common.Database, td *big.Int) {
+	db.Put(headTdKey, td.Bytes())
+}
+
+func canonicalKey(number uint64) []byte {
+	return append(append([]byte{}, blockNumPre...), new(big.Int).SetUint64(number).Bytes()...)
+}
+
+// ReadCanonicalHash returns the hash of the canonical block with the given
+// number, or the zero hash if there is none.
+func ReadCanonicalHash(db common.Database, number uint64) common.Hash {
+	data, _ := db.Get(canonicalKey(number))
+	return common.BytesToHash(data)
+}
+
+// WriteCanonicalHash makes hash the canonical block of the given number.
+func WriteCanonicalHash(db common.Database, hash common.Hash, number uint64) {
+	db.Put(canonicalKey(number), hash.Bytes())
+}
+
+// DeleteCanonicalHash removes the canonical block of the given number.
+func DeleteCanonicalHash(db common.Database, number uint64) {
+	db.Delete(canonicalKey(number))
+}
+
+func blockKey(hash common.Hash) []byte {
+	return append(append([]byte{}, blockHashPre...), hash[:]...)
+}
+
+// HasBlock reports whether the block with the given hash is stored.
+func HasBlock(db common.Database, hash common.Hash) bool {
+	if data, _ := db.Get(blockKey(hash)); len(data) != 0 {
+		return true
+	}
+	_, _, frozen := frozenNumber(db, hash)
+	return frozen
+}
+
+// ReadBlock returns the block with the given hash, or nil if it isn't
+// stored.
+func ReadBlock(db common.Database, hash common.Hash) *types.Block {
+	data, _ := db.Get(blockKey(hash))
+	if len(data) == 0 {
+		if freezer, number, ok := frozenNumber(db, hash); ok {
+			data = freezer.Block(number)
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var block types.StorageBlock
+	if err := rlp.Decode(bytes.NewReader(data), &block); err != nil {
+		glog.V(logger.Error).Infof("invalid block RLP for hash %x: %v", hash, err)
+		return nil
+	}
+	return (*types.Block)(&block)
+}
+
+// WriteBlock stores block, including its total difficulty.
+func WriteBlock(db common.Database, block *types.Block) {
+	enc, err := rlp.EncodeToBytes((*types.StorageBlock)(block))
+	if err != nil {
+		glog.V(logger.Error).Infof("failed encoding block %x: %v", block.Hash(), err)
+		return
+	}
+	db.Put(blockKey(block.Hash()), enc)
+}
+
+// DeleteBlock removes the block with the given hash.
+func DeleteBlock(db common.Database, hash common.Hash) {
+	db.Delete(blockKey(hash))
+}
+
+func txMetaKey(hash common.Hash) []byte {
+	return append(hash.Bytes(), txMetaSuffix)
+}
+
+// txMeta locates a transaction in the chain.
+type txMeta struct {
+	BlockHash  common.Hash
+	BlockIndex uint64
+	Index      uint64
+	// fields added by later versions are ignored
+	Rest []interface{} `rlp:"tail"`
+}
+
+// ReadTransaction returns the transaction with the given hash together with
+// the hash and number of its block and its index in the block. The
+// transaction is nil if it isn't stored.
+func ReadTransaction(db common.Database, hash common.Hash) (tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) {
+	data, _ := db.Get(hash.Bytes())
+	if len(data) == 0 {
+		return nil, common.Hash{}, 0, 0
+	}
+	tx = types.NewTransactionFromBytes(data)
+
+	var meta txMeta
+	data, _ = db.Get(txMetaKey(hash))
+	if err := rlp.DecodeBytes(data, &meta); err != nil {
+		glog.V(logger.Error).Infoln("Invalid tx meta RLP", hash.Hex(), err)
+		return tx, common.Hash{}, 0, 0
+	}
+	return tx, meta.BlockHash, meta.BlockIndex, meta.Index
+}
+
+// WriteTransaction stores tx and its position in block.
+func WriteTransaction(db common.Database, tx *types.Transaction, block *types.Block, index uint64) {
+	rlpEnc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		glog.V(logger.Debug).Infoln("Failed encoding tx", err)
+		return
+	}
+	db.Put(tx.Hash().Bytes(), rlpEnc)
+
+	rlpMeta, err := rlp.EncodeToBytes(txMeta{BlockHash: block.Hash(), BlockIndex: block.NumberU64(), Index: index})
+	if err != nil {
+		glog.V(logger.Debug).Infoln("Failed encoding tx meta data", err)
+		return
+	}
+	db.Put(txMetaKey(tx.Hash()), rlpMeta)
+}
+
+// receiptStorage is the locally stored form of a receipt. It only contains
+// the fields which can't be derived from the transaction and its block.
+type receiptStorage struct {
+	CumulativeGasUsed *big.Int
+	GasUsed           *big.Int
+	Status            uint // 1 if execution succeeded, 0 if it failed
+}
+
+func newReceiptStorage(receipt *types.Receipt) receiptStorage {
+	stored := receiptStorage{
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		GasUsed:           receipt.GasUsed(),
+	}
+	if !receipt.Failed() {
+		stored.Status = 1
+	}
+	return stored
+}
+
+func (stored receiptStorage) receipt() *types.Receipt {
+	receipt := &types.Receipt{CumulativeGasUsed: stored.CumulativeGasUsed}
+	receipt.SetGasUsed(stored.GasUsed)
+	receipt.SetFailed(stored.Status == 0)
+	return receipt
+}
+
+func receiptKey(txHash common.Hash) []byte {
+	return append(txHash.Bytes(), receiptSuffix)
+}
+
+// ReadReceipt returns the locally stored receipt of the given transaction or
+// nil if the transaction isn't part of a processed block. Only the gas and
+// status fields of the returned receipt are set.
+func ReadReceipt(db common.Database, txHash common.Hash) *types.Receipt {
+	data, _ := db.Get(receiptKey(txHash))
+	if len(data) == 0 {
+		return readFrozenReceipt(db, txHash)
+	}
+	var stored receiptStorage
+	if err := rlp.DecodeBytes(data, &stored); err != nil {
+		glog.V(logger.Error).Infoln("Invalid receipt RLP", txHash.Hex(), err)
+		return nil
+	}
+	return stored.receipt()
+}
+
+// readFrozenReceipt looks up the receipt of a transaction in a frozen block.
+func readFrozenReceipt(db common.Database, txHash common.Hash) *types.Receipt {
+	ancient, ok := db.(*AncientDatabase)
+	if !ok {
+		return nil
+	}
+	tx, _, number, index := ReadTransaction(db, txHash)
+	if tx == nil {
+		return nil
+	}
+	data := ancient.freezer.Receipts(number)
+	if len(data) == 0 {
+		return nil
+	}
+	var receipts []receiptStorage
+	if err := rlp.DecodeBytes(data, &receipts); err != nil {
+		glog.V(logger.Error).Infoln("Invalid frozen receipts RLP of block", number, err)
+		return nil
+	}
+	if index >= uint64(len(receipts)) {
+		return nil
+	}
+	return receipts[index].receipt()
+}
+
+// WriteReceipt stores the receipt of the given transaction.
+func WriteReceipt(db common.Database, txHash common.Hash, receipt *types.Receipt) {
+	rlpEnc, err := rlp.EncodeToBytes(newReceiptStorage(receipt))
+	if err != nil {
+		glog.V(logger.Debug).Infoln("Failed encoding receipt", err)
+		return
+	}
+	db.Put(receiptKey(txHash), rlpEnc)
+}
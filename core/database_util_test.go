@@ -0,0 +1,80 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestCanonicalAndHeadStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	if hash := ReadCanonicalHash(db, 5); (hash != common.Hash{}) {
+		t.Errorf("expected no canonical hash, got %x", hash)
+	}
+	WriteCanonicalHash(db, common.Hash{1}, 5)
+	if hash := ReadCanonicalHash(db, 5); hash != (common.Hash{1}) {
+		t.Errorf("wrong canonical hash %x", hash)
+	}
+	DeleteCanonicalHash(db, 5)
+	if hash := ReadCanonicalHash(db, 5); (hash != common.Hash{}) {
+		t.Errorf("canonical hash not deleted: %x", hash)
+	}
+
+	WriteHeadBlockHash(db, common.Hash{2})
+	if hash := ReadHeadBlockHash(db); hash != (common.Hash{2}) {
+		t.Errorf("wrong head hash %x", hash)
+	}
+	WriteHeadTd(db, big.NewInt(1000))
+	if td := ReadHeadTd(db); td.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("wrong head td %v", td)
+	}
+
+	if v := ReadDatabaseVersion(db); v != 0 {
+		t.Errorf("expected no database version, got %d", v)
+	}
+	WriteDatabaseVersion(db, DatabaseVersion)
+	if v := ReadDatabaseVersion(db); v != DatabaseVersion {
+		t.Errorf("wrong database version %d", v)
+	}
+}
+
+func TestBlockAndTransactionStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	key, _ := crypto.GenerateKey()
+
+	tx := types.NewTransactionMessage(common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	tx.SignECDSA(key)
+	header := &types.Header{Difficulty: big.NewInt(1), Number: new(big.Int), GasLimit: new(big.Int), GasUsed: new(big.Int)}
+	block := types.NewBlock(header, types.Transactions{tx}, nil, nil)
+	block.Td = big.NewInt(10)
+
+	if HasBlock(db, block.Hash()) || ReadBlock(db, block.Hash()) != nil {
+		t.Fatal("block found before it was written")
+	}
+	WriteBlock(db, block)
+	stored := ReadBlock(db, block.Hash())
+	if stored == nil || stored.Hash() != block.Hash() || stored.Td.Cmp(block.Td) != 0 {
+		t.Fatalf("wrong stored block %v", stored)
+	}
+	DeleteBlock(db, block.Hash())
+	if HasBlock(db, block.Hash()) {
+		t.Error("block not deleted")
+	}
+
+	WriteTransaction(db, tx, block, 0)
+	stx, blockHash, number, index := ReadTransaction(db, tx.Hash())
+	if stx == nil || stx.Hash() != tx.Hash() {
+		t.Fatalf("wrong stored transaction %v", stx)
+	}
+	if blockHash != block.Hash() || number != block.NumberU64() || index != 0 {
+		t.Errorf("wrong transaction position: block %x, number %d, index %d", blockHash, number, index)
+	}
+	if stx, _, _, _ := ReadTransaction(db, common.Hash{1}); stx != nil {
+		t.Error("expected no transaction for unknown hash")
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 )
 
-// TxPreEvent is posted when a transaction enters the transaction pool.
+// eventBufferSize is the channel buffer of subscriptions to the event
+// feeds of the core types. Events are dropped for subscribers that fall
+// behind by more than this many events.
+const eventBufferSize = 1024
+
+// TxPreEvent is sent when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
-// TxPostEvent is posted when a transaction has been processed.
+// TxPostEvent is sent when a transaction has been processed.
 type TxPostEvent struct{ Tx *types.Transaction }
 
 // NewBlockEvent is posted when a block has been imported.
@@ -23,25 +28,32 @@ type ChainSplitEvent struct {
 	Logs  state.Logs
 }
 
+// ChainEvent is posted for every block added to the canonical chain.
 type ChainEvent struct {
 	Block *types.Block
 	Logs  state.Logs
 }
 
+// ChainSideEvent is posted for blocks that are imported into a side chain.
 type ChainSideEvent struct {
 	Block *types.Block
 	Logs  state.Logs
 }
 
-type PendingBlockEvent struct {
-	Block *types.Block
-	Logs  state.Logs
-}
+// RemovedLogsEvent is sent when a reorganisation removes blocks from
+// the canonical chain. It carries the logs of the removed blocks.
+type RemovedLogsEvent struct{ Logs state.Logs }
+
+// PendingLogsEvent is sent when the pending block has been updated,
+// it carries the logs generated by the pending transactions.
+type PendingLogsEvent struct{ Logs state.Logs }
 
 type ChainUncleEvent struct {
 	Block *types.Block
 }
 
+// ChainHeadEvent is posted once per chain insertion when the head
+// of the canonical chain has changed.
 type ChainHeadEvent struct{ Block *types.Block }
 
 // Mining operation events
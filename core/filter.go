@@ -72,35 +72,57 @@ func (self *Filter) Find() state.Logs {
 		latestBlockNo = earliestBlock.NumberU64()
 	}
 
+	if earliestBlockNo > latestBlockNo {
+		return nil
+	}
+
 	var (
-		logs  state.Logs
-		block = self.eth.ChainManager().GetBlockByNumber(latestBlockNo)
-		quit  bool
+		logs    state.Logs
+		chain   = self.eth.ChainManager()
+		indexer = self.eth.BlockProcessor().BloomIndexer()
+		groups  = self.bloomBits()
 	)
-	for i := 0; !quit && block != nil; i++ {
-		// Quit on latest
-		switch {
-		case block.NumberU64() == earliestBlockNo, block.NumberU64() == 0:
-			quit = true
-		case self.max <= len(logs):
-			break
+	// Walk the range backwards one bloom index section at a time. Sections
+	// which are indexed only fetch the blocks which may match.
+	next := latestBlockNo + 1
+Sections:
+	for next > earliestBlockNo {
+		section := (next - 1) / BloomBitsSection
+		first := section * BloomBitsSection
+		if first < earliestBlockNo {
+			first = earliestBlockNo
 		}
 
-		// Use bloom filtering to see if this block is interesting given the
-		// current parameters
-		if self.bloomFilter(block) {
-			// Get the logs of the block
-			unfiltered, err := self.eth.BlockProcessor().GetLogs(block)
-			if err != nil {
-				chainlogger.Warnln("err: filter get logs ", err)
-
-				break
+		var matches []uint64
+		indexed := false
+		if indexer != nil && len(groups) > 0 {
+			matches, indexed = indexer.Matches(section, groups)
+		}
+		if indexed {
+			for i := len(matches) - 1; i >= 0; i-- {
+				if matches[i] < first || matches[i] >= next {
+					continue
+				}
+				block := chain.GetBlockByNumber(matches[i])
+				if block == nil {
+					continue
+				}
+				blockLogs, err := self.blockLogs(block)
+				if err != nil {
+					break Sections
+				}
+				logs = append(logs, blockLogs...)
+			}
+		} else {
+			for it := chain.NewBlockIterator(next-1, first); it.Next(); {
+				blockLogs, err := self.blockLogs(it.Block())
+				if err != nil {
+					break Sections
+				}
+				logs = append(logs, blockLogs...)
 			}
-
-			logs = append(logs, self.FilterLogs(unfiltered)...)
 		}
-
-		block = self.eth.ChainManager().GetBlock(block.ParentHash())
+		next = first
 	}
 
 	skip := int(math.Min(float64(len(logs)), float64(self.skip)))
@@ -108,6 +130,48 @@ func (self *Filter) Find() state.Logs {
 	return logs[skip:]
 }
 
+// blockLogs returns the matching logs of block.
+func (self *Filter) blockLogs(block *types.Block) (state.Logs, error) {
+	// Use bloom filtering to see if this block is interesting given the
+	// current parameters
+	if !self.bloomFilter(block) {
+		return nil, nil
+	}
+	// Get the logs of the block
+	unfiltered, err := self.eth.BlockProcessor().GetLogs(block)
+	if err != nil {
+		chainlogger.Warnln("err: filter get logs ", err)
+		return nil, err
+	}
+	return self.FilterLogs(unfiltered), nil
+}
+
+// bloomBits returns the bloom bits of the filter criteria for the bloom bit
+// index: a block may match if, for every group, its bloom contains all
+// three bits of one of the group's entries.
+func (self *Filter) bloomBits() [][][3]uint {
+	var groups [][][3]uint
+	if len(self.address) > 0 {
+		group := make([][3]uint, len(self.address))
+		for i, addr := range self.address {
+			group[i] = types.BloomBits(addr[:])
+		}
+		groups = append(groups, group)
+	}
+	for _, sub := range self.topics {
+		// an empty group matches any topic
+		if len(sub) == 0 {
+			continue
+		}
+		group := make([][3]uint, len(sub))
+		for i, topic := range sub {
+			group[i] = types.BloomBits(topic[:])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
 func includes(addresses []common.Address, a common.Address) bool {
 	for _, addr := range addresses {
 		if addr != a {
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+/*
+Freezer is an append-only store for the blocks and receipts of the chain
+which are too old to be reorganised. Keeping them in flat files instead of
+the key value store avoids compacting data which never changes.
+
+Items are stored by block number. Every table consists of a data file with
+the concatenated items and an index file with the end offset of each item
+as a big endian uint64. Blocks are appended in order starting at genesis,
+so the number of stored items is the number of frozen blocks.
+*/
+type Freezer struct {
+	mu       sync.RWMutex
+	blocks   *freezerTable // block number -> block RLP
+	receipts *freezerTable // block number -> RLP list of receiptStorage
+	frozen   uint64
+}
+
+// NewFreezer opens the freezer in dir, creating it if necessary. Items
+// which were only partially written are discarded.
+func NewFreezer(dir string) (*Freezer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	blocks, err := openFreezerTable(dir, "blocks")
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := openFreezerTable(dir, "receipts")
+	if err != nil {
+		blocks.close()
+		return nil, err
+	}
+	f := &Freezer{blocks: blocks, receipts: receipts}
+
+	// a crash between appending to the two tables leaves one longer
+	f.frozen = blocks.items
+	if receipts.items < f.frozen {
+		f.frozen = receipts.items
+	}
+	for _, t := range []*freezerTable{blocks, receipts} {
+		if err := t.truncate(f.frozen); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Frozen returns the number of frozen blocks. All blocks below it are
+// stored in the freezer.
+func (f *Freezer) Frozen() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.frozen
+}
+
+// Block returns the RLP of the frozen block with the given number or nil.
+func (f *Freezer) Block(number uint64) []byte {
+	return f.retrieve(f.blocks, number)
+}
+
+// Receipts returns the RLP encoded receipts of the frozen block with the
+// given number or nil.
+func (f *Freezer) Receipts(number uint64) []byte {
+	return f.retrieve(f.receipts, number)
+}
+
+func (f *Freezer) retrieve(t *freezerTable, number uint64) []byte {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if number >= f.frozen {
+		return nil
+	}
+	data, err := t.retrieve(number)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Append stores the next block. number must be the number of frozen
+// blocks.
+func (f *Freezer) Append(number uint64, block, receipts []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if number != f.frozen {
+		return fmt.Errorf("freezer: appending block %d, expected %d", number, f.frozen)
+	}
+	if err := f.blocks.append(block); err != nil {
+		return err
+	}
+	if err := f.receipts.append(receipts); err != nil {
+		return err
+	}
+	f.frozen++
+	return nil
+}
+
+// Sync writes the appended items to disk.
+func (f *Freezer) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, t := range []*freezerTable{f.blocks, f.receipts} {
+		if err := t.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close syncs and closes the freezer files.
+func (f *Freezer) Close() {
+	f.Sync()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.blocks.close()
+	f.receipts.close()
+}
+
+type freezerTable struct {
+	data, index *os.File
+	items       uint64 // number of items
+	size        uint64 // size of the data file
+}
+
+func openFreezerTable(dir, name string) (*freezerTable, error) {
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	t := &freezerTable{data: data, index: index}
+
+	istat, err := index.Stat()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	dstat, err := data.Stat()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	// drop index entries pointing past the end of the data file
+	items := uint64(istat.Size()) / 8
+	for items > 0 {
+		end, err := t.offset(items - 1)
+		if err != nil {
+			t.close()
+			return nil, err
+		}
+		if end <= uint64(dstat.Size()) {
+			break
+		}
+		items--
+	}
+	if err := t.truncate(items); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// offset returns the end offset of item i in the data file.
+func (t *freezerTable) offset(i uint64) (uint64, error) {
+	var enc [8]byte
+	if _, err := t.index.ReadAt(enc[:], int64(i*8)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(enc[:]), nil
+}
+
+func (t *freezerTable) retrieve(i uint64) ([]byte, error) {
+	var start uint64
+	if i > 0 {
+		var err error
+		if start, err = t.offset(i - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.offset(i)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, end-start)
+	if _, err := t.data.ReadAt(data, int64(start)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (t *freezerTable) append(item []byte) error {
+	if _, err := t.data.WriteAt(item, int64(t.size)); err != nil {
+		return err
+	}
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], t.size+uint64(len(item)))
+	if _, err := t.index.WriteAt(enc[:], int64(t.items*8)); err != nil {
+		return err
+	}
+	t.size += uint64(len(item))
+	t.items++
+	return nil
+}
+
+// truncate discards all items from the given one on.
+func (t *freezerTable) truncate(items uint64) error {
+	var size uint64
+	if items > 0 {
+		var err error
+		if size, err = t.offset(items - 1); err != nil {
+			return err
+		}
+	}
+	if err := t.index.Truncate(int64(items * 8)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items, t.size = items, size
+	return nil
+}
+
+func (t *freezerTable) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+func (t *freezerTable) close() {
+	t.data.Close()
+	t.index.Close()
+}
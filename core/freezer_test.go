@@ -0,0 +1,147 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 3; i++ {
+		if err := f.Append(i, []byte{byte(i), 1}, []byte{byte(i), 2}); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+	if err := f.Append(5, nil, nil); err == nil {
+		t.Error("expected error for out of order append")
+	}
+	f.Close()
+
+	// an item only written to one table is dropped on reopen
+	blocks, _ := os.OpenFile(filepath.Join(dir, "blocks.dat"), os.O_APPEND|os.O_WRONLY, 0600)
+	blocks.Write([]byte{3, 1})
+	blocks.Close()
+
+	f, err = NewFreezer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Frozen() != 3 {
+		t.Fatalf("expected 3 frozen blocks, got %d", f.Frozen())
+	}
+	for i := uint64(0); i < 3; i++ {
+		if block := f.Block(i); !bytes.Equal(block, []byte{byte(i), 1}) {
+			t.Errorf("block %d: got %x", i, block)
+		}
+		if receipts := f.Receipts(i); !bytes.Equal(receipts, []byte{byte(i), 2}) {
+			t.Errorf("receipts %d: got %x", i, receipts)
+		}
+	}
+	if f.Block(3) != nil {
+		t.Error("expected no block 3")
+	}
+	if err := f.Append(3, []byte{3, 1}, []byte{3, 2}); err != nil {
+		t.Errorf("append after reopen: %v", err)
+	}
+}
+
+func TestChainFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	freezer, err := NewFreezer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer freezer.Close()
+
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		mem, _  = ethdb.NewMemDatabase()
+		db      = NewAncientDatabase(mem, freezer)
+		bman, _ = newCanonical(0, db)
+		genesis = bman.bc.CurrentBlock()
+	)
+	chain := GenerateChain(genesis, db, 10, func(i int, gen *BlockGen) {
+		if i == 0 {
+			gen.SetCoinbase(addr)
+			return
+		}
+		tx := types.NewTransactionMessage(common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+		tx.SetNonce(gen.TxNonce(addr))
+		tx.SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	if err := bman.bc.InsertChain(chain); err != nil {
+		t.Fatalf("insert error: %v", err)
+	}
+	// block 3 was imported before receipts were stored
+	old := chain[2]
+	mem.Delete(receiptKey(old.Transactions()[0].Hash()))
+
+	// blocks 0-5 are more than 4 blocks behind head 10
+	if err := NewChainFreezer(bman.bc, db, db, 4).Freeze(); err != nil {
+		t.Fatalf("freeze error: %v", err)
+	}
+	if freezer.Frozen() != 6 {
+		t.Fatalf("expected 6 frozen blocks, got %d", freezer.Frozen())
+	}
+
+	frozen, live := chain[4], chain[5] // blocks 5 and 6
+	tx := frozen.Transactions()[0]
+	if data, _ := mem.Get(blockKey(frozen.Hash())); len(data) != 0 {
+		t.Error("frozen block still in the database")
+	}
+	if data, _ := mem.Get(receiptKey(tx.Hash())); len(data) != 0 {
+		t.Error("frozen receipt still in the database")
+	}
+	if data, _ := mem.Get(blockKey(live.Hash())); len(data) == 0 {
+		t.Error("recent block removed from the database")
+	}
+
+	if !HasBlock(db, frozen.Hash()) {
+		t.Error("frozen block not found")
+	}
+	if block := ReadBlock(db, frozen.Hash()); block == nil || block.Hash() != frozen.Hash() {
+		t.Errorf("wrong frozen block %v", block)
+	}
+	if block := ReadBlock(db, genesis.Hash()); block == nil || block.Hash() != genesis.Hash() {
+		t.Errorf("wrong frozen genesis %v", block)
+	}
+	receipt := ReadReceipt(db, tx.Hash())
+	if receipt == nil || receipt.Failed() || receipt.GasUsed().Cmp(big.NewInt(21000)) != 0 {
+		t.Errorf("wrong frozen receipt %v", receipt)
+	}
+	if ReadReceipt(db, live.Transactions()[0].Hash()) == nil {
+		t.Error("recent receipt not found")
+	}
+	if block := ReadBlock(db, old.Hash()); block == nil || block.Hash() != old.Hash() {
+		t.Errorf("wrong frozen block without receipts %v", block)
+	}
+	if receipt := ReadReceipt(db, old.Transactions()[0].Hash()); receipt != nil {
+		t.Errorf("unexpected receipt %v for block frozen without receipts", receipt)
+	}
+}
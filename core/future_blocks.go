@@ -0,0 +1,132 @@
+package core
+
+import (
+	"container/heap"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxFutureBlocks is the maximum number of blocks held in the future block queue.
+const maxFutureBlocks = 256
+
+// futureBlockQueue holds blocks that could not be inserted yet because their
+// timestamp lies in the future (or their parent is such a block). Blocks are
+// ordered by timestamp so they can be released once they become valid.
+//
+// The queue is bounded, when it is full the block furthest in the future is
+// dropped.
+type futureBlockQueue struct {
+	mu     sync.Mutex
+	size   int
+	blocks futureBlockHeap
+	hashes map[common.Hash]*types.Block
+}
+
+func newFutureBlockQueue(size int) *futureBlockQueue {
+	return &futureBlockQueue{size: size, hashes: make(map[common.Hash]*types.Block)}
+}
+
+// Push adds a block to the queue. It returns false if the block was not
+// queued because the queue is full of blocks that are due earlier.
+func (self *futureBlockQueue) Push(block *types.Block) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	hash := block.Hash()
+	if _, ok := self.hashes[hash]; ok {
+		return true
+	}
+	if len(self.blocks) >= self.size {
+		// evict the latest block, unless the new one is due even later.
+		i := self.blocks.latest()
+		if self.blocks[i].Time() <= block.Time() {
+			return false
+		}
+		delete(self.hashes, self.blocks[i].Hash())
+		heap.Remove(&self.blocks, i)
+	}
+	self.hashes[hash] = block
+	heap.Push(&self.blocks, block)
+	return true
+}
+
+// Has reports whether the block with the given hash is queued.
+func (self *futureBlockQueue) Has(hash common.Hash) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	_, ok := self.hashes[hash]
+	return ok
+}
+
+// Delete removes the block with the given hash from the queue.
+func (self *futureBlockQueue) Delete(hash common.Hash) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if _, ok := self.hashes[hash]; !ok {
+		return
+	}
+	delete(self.hashes, hash)
+	for i, block := range self.blocks {
+		if block.Hash() == hash {
+			heap.Remove(&self.blocks, i)
+			break
+		}
+	}
+}
+
+// Len returns the number of queued blocks.
+func (self *futureBlockQueue) Len() int {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return len(self.blocks)
+}
+
+// Ready removes and returns all blocks with a timestamp not after now,
+// sorted by block number.
+func (self *futureBlockQueue) Ready(now int64) types.Blocks {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var ready types.Blocks
+	for len(self.blocks) > 0 && self.blocks[0].Time() <= now {
+		block := heap.Pop(&self.blocks).(*types.Block)
+		delete(self.hashes, block.Hash())
+		ready = append(ready, block)
+	}
+	types.BlockBy(types.Number).Sort(ready)
+	return ready
+}
+
+// futureBlockHeap is a min-heap of blocks ordered by timestamp.
+type futureBlockHeap []*types.Block
+
+func (h futureBlockHeap) Len() int           { return len(h) }
+func (h futureBlockHeap) Less(i, j int) bool { return h[i].Time() < h[j].Time() }
+func (h futureBlockHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *futureBlockHeap) Push(x interface{}) { *h = append(*h, x.(*types.Block)) }
+
+func (h *futureBlockHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+// latest returns the index of the block with the highest timestamp.
+func (h futureBlockHeap) latest() int {
+	latest := 0
+	for i := range h {
+		if h[i].Time() > h[latest].Time() {
+			latest = i
+		}
+	}
+	return latest
+}
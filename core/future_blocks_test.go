@@ -0,0 +1,72 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func newFutureChain(times ...uint64) []*types.Block {
+	chain := newChain(len(times))
+	for i, block := range chain {
+		header := block.Header()
+		header.Time = times[i]
+		chain[i] = block.WithHeader(header)
+	}
+	return chain
+}
+
+func TestFutureBlockQueueReady(t *testing.T) {
+	chain := newFutureChain(30, 10, 20, 20)
+	queue := newFutureBlockQueue(10)
+	for _, block := range chain {
+		queue.Push(block)
+	}
+	if !queue.Has(chain[0].Hash()) {
+		t.Error("expected block to be queued")
+	}
+
+	if ready := queue.Ready(5); len(ready) != 0 {
+		t.Errorf("got %d ready blocks, want 0", len(ready))
+	}
+	ready := queue.Ready(20)
+	if len(ready) != 3 {
+		t.Fatalf("got %d ready blocks, want 3", len(ready))
+	}
+	// ready blocks are sorted by number
+	for i, want := range []*types.Block{chain[1], chain[2], chain[3]} {
+		if ready[i] != want {
+			t.Errorf("ready block %d is #%v, want #%v", i, ready[i].Number(), want.Number())
+		}
+	}
+	if queue.Len() != 1 || queue.Has(chain[1].Hash()) {
+		t.Error("ready blocks not removed from queue")
+	}
+}
+
+func TestFutureBlockQueueBound(t *testing.T) {
+	chain := newFutureChain(10, 30, 20, 40)
+	queue := newFutureBlockQueue(2)
+
+	queue.Push(chain[0])
+	queue.Push(chain[1])
+	// evicts the block with time 30
+	if !queue.Push(chain[2]) {
+		t.Error("block due earlier was not queued")
+	}
+	if queue.Has(chain[1].Hash()) {
+		t.Error("latest block not evicted")
+	}
+	// due later than all queued blocks
+	if queue.Push(chain[3]) {
+		t.Error("block due later was queued into full queue")
+	}
+	if queue.Len() != 2 {
+		t.Errorf("queue length %d, want 2", queue.Len())
+	}
+
+	queue.Delete(chain[0].Hash())
+	if queue.Has(chain[0].Hash()) || queue.Len() != 1 {
+		t.Error("block not deleted")
+	}
+}
@@ -20,17 +20,13 @@ var ZeroHash160 = make([]byte, 20)
 var ZeroHash512 = make([]byte, 64)
 
 func GenesisBlock(db common.Database) *types.Block {
-	genesis := types.NewBlock(common.Hash{}, common.Address{}, common.Hash{}, params.GenesisDifficulty, 42, nil)
-	genesis.Header().Number = common.Big0
-	genesis.Header().GasLimit = params.GenesisGasLimit
-	genesis.Header().GasUsed = common.Big0
-	genesis.Header().Time = 0
-
-	genesis.Td = common.Big0
-
-	genesis.SetUncles([]*types.Header{})
-	genesis.SetTransactions(types.Transactions{})
-	genesis.SetReceipts(types.Receipts{})
+	header := &types.Header{
+		Difficulty: params.GenesisDifficulty,
+		Number:     common.Big0,
+		GasLimit:   params.GenesisGasLimit,
+		GasUsed:    common.Big0,
+	}
+	header.SetNonce(42)
 
 	var accounts map[string]struct {
 		Balance string
@@ -42,7 +38,7 @@ func GenesisBlock(db common.Database) *types.Block {
 		os.Exit(1)
 	}
 
-	statedb := state.New(genesis.Root(), db)
+	statedb := state.New(common.Hash{}, db)
 	for addr, account := range accounts {
 		codedAddr := common.Hex2Bytes(addr)
 		accountState := statedb.CreateAccount(common.BytesToAddress(codedAddr))
@@ -51,7 +47,9 @@ func GenesisBlock(db common.Database) *types.Block {
 		statedb.UpdateStateObject(accountState)
 	}
 	statedb.Sync()
-	genesis.Header().Root = statedb.Root()
+	header.Root = statedb.Root()
+
+	genesis := types.NewBlock(header, nil, nil, nil)
 	genesis.Td = params.GenesisDifficulty
 
 	return genesis
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// hashCache is a bounded, least recently used cache keyed by hash.
+// It is safe for concurrent use.
+type hashCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // front is most recently used
+	items map[common.Hash]*list.Element
+}
+
+type hashCacheEntry struct {
+	hash  common.Hash
+	value interface{}
+}
+
+// newHashCache creates a cache holding at most size entries.
+func newHashCache(size int) *hashCache {
+	if size < 1 {
+		panic("hash cache size not allowed to be smaller than 1")
+	}
+	return &hashCache{size: size, order: list.New(), items: make(map[common.Hash]*list.Element)}
+}
+
+// Add adds or updates an entry, evicting the least recently used
+// entry if the cache is full.
+func (c *hashCache) Add(hash common.Hash, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[hash]; ok {
+		el.Value.(*hashCacheEntry).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*hashCacheEntry).hash)
+	}
+	c.items[hash] = c.order.PushFront(&hashCacheEntry{hash, value})
+}
+
+// Get returns the value stored for hash.
+func (c *hashCache) Get(hash common.Hash) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[hash]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*hashCacheEntry).value, true
+}
+
+// Has reports whether hash is in the cache.
+func (c *hashCache) Has(hash common.Hash) bool {
+	_, ok := c.Get(hash)
+	return ok
+}
+
+// Remove deletes the entry for hash.
+func (c *hashCache) Remove(hash common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[hash]; ok {
+		c.order.Remove(el)
+		delete(c.items, hash)
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *hashCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
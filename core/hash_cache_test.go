@@ -0,0 +1,35 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestHashCacheEviction(t *testing.T) {
+	cache := newHashCache(2)
+	a, b, c := common.Hash{1}, common.Hash{2}, common.Hash{3}
+
+	cache.Add(a, 1)
+	cache.Add(b, 2)
+	// touch a so that b becomes the least recently used entry
+	if v, ok := cache.Get(a); !ok || v.(int) != 1 {
+		t.Fatalf("Get(a) = %v, %v; want 1, true", v, ok)
+	}
+	cache.Add(c, 3)
+
+	if cache.Has(b) {
+		t.Error("least recently used entry not evicted")
+	}
+	if !cache.Has(a) || !cache.Has(c) {
+		t.Error("recently used entries evicted")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("cache length %d, want 2", cache.Len())
+	}
+
+	cache.Remove(a)
+	if cache.Has(a) || cache.Len() != 1 {
+		t.Error("entry not removed")
+	}
+}
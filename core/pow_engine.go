@@ -0,0 +1,165 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/pow"
+	"gopkg.in/fatih/set.v0"
+)
+
+var errInvalidPoW = errors.New("invalid proof of work")
+
+// powEngine implements the proof-of-work consensus rules.
+type powEngine struct {
+	pow pow.PoW
+}
+
+// NewPoWEngine returns a consensus engine for proof of work sealing
+// with the given algorithm.
+func NewPoWEngine(pow pow.PoW) consensus.Engine {
+	return &powEngine{pow}
+}
+
+func (e *powEngine) Prepare(chain consensus.ChainReader, header, parent *types.Header) error {
+	header.Difficulty = CalcDifficulty(chain.Config(), header, parent)
+	return nil
+}
+
+func (e *powEngine) VerifyHeader(chain consensus.ChainReader, header, parent *types.Header) error {
+	expd := CalcDifficulty(chain.Config(), header, parent)
+	if expd.Cmp(header.Difficulty) != 0 {
+		return fmt.Errorf("Difficulty check failed for block %v, %v", header.Difficulty, expd)
+	}
+	return nil
+}
+
+func (e *powEngine) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	if !e.pow.Verify(types.NewBlockWithHeader(header)) {
+		return errInvalidPoW
+	}
+	return nil
+}
+
+// VerifyUncles checks that block includes at most two uncles, which are
+// valid headers of recent side chains not included before.
+func (e *powEngine) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 2 {
+		return ValidationError("Block can only contain one uncle (contained %v)", len(block.Uncles()))
+	}
+
+	ancestors := set.New()
+	uncles := set.New()
+	ancestorHeaders := make(map[common.Hash]*types.Header)
+	ancestor := chain.GetBlock(block.ParentHash())
+	for i := 0; i < 7 && ancestor != nil; i++ {
+		ancestorHeaders[ancestor.Hash()] = ancestor.Header()
+		ancestors.Add(ancestor.Hash())
+		// Include ancestors uncles in the uncle set. Uncles must be unique.
+		for _, uncle := range ancestor.Uncles() {
+			uncles.Add(uncle.Hash())
+		}
+		ancestor = chain.GetBlock(ancestor.ParentHash())
+	}
+
+	uncles.Add(block.Hash())
+	for i, uncle := range block.Uncles() {
+		if uncles.Has(uncle.Hash()) {
+			// Error not unique
+			return UncleError("Uncle not unique")
+		}
+
+		uncles.Add(uncle.Hash())
+
+		if ancestors.Has(uncle.Hash()) {
+			return UncleError("Uncle is ancestor")
+		}
+
+		if !ancestors.Has(uncle.ParentHash) {
+			return UncleError(fmt.Sprintf("Uncle's parent unknown (%x)", uncle.ParentHash[0:4]))
+		}
+
+		if err := e.verifyUncle(chain, uncle, ancestorHeaders[uncle.ParentHash]); err != nil {
+			return ValidationError(fmt.Sprintf("uncle[%d](%x) header invalid: %v", i, uncle.Hash().Bytes()[:4], err))
+		}
+	}
+
+	return nil
+}
+
+func (e *powEngine) verifyUncle(chain consensus.ChainReader, uncle, parent *types.Header) error {
+	if err := validateHeaderFields(chain.Config(), uncle, parent); err != nil {
+		return err
+	}
+	if err := e.VerifyHeader(chain, uncle, parent); err != nil {
+		return err
+	}
+	return e.VerifySeal(chain, uncle)
+}
+
+// Finalize pays the block reward to the coinbase of block and its
+// uncles. The reward can be changed by the chain configuration.
+func (e *powEngine) Finalize(chain consensus.ChainReader, statedb *state.StateDB, block *types.Block) {
+	reward := BlockReward
+	if config := chain.Config(); config != nil && config.BlockReward != nil {
+		reward = config.BlockReward
+	}
+	accumulateRewards(statedb, block, reward)
+}
+
+func (e *powEngine) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	nonce, mixDigest, _ := e.pow.Search(block, stop)
+	if nonce == 0 {
+		return nil, nil
+	}
+	header := block.Header()
+	header.SetNonce(nonce)
+	header.MixDigest = common.BytesToHash(mixDigest)
+	return block.WithHeader(header), nil
+}
+
+// accumulateRewards credits the coinbase of block with blockReward plus
+// 1/32 of it for each uncle. The coinbase of each uncle u receives
+// (8 + u.Number - block.Number) / 8 of blockReward.
+func accumulateRewards(statedb *state.StateDB, block *types.Block, blockReward *big.Int) {
+	reward := new(big.Int).Set(blockReward)
+
+	for _, uncle := range block.Uncles() {
+		num := new(big.Int).Add(big.NewInt(8), uncle.Number)
+		num.Sub(num, block.Number())
+
+		r := new(big.Int)
+		r.Mul(blockReward, num)
+		r.Div(r, big.NewInt(8))
+
+		statedb.AddBalance(uncle.Coinbase, r)
+
+		reward.Add(reward, new(big.Int).Div(blockReward, big.NewInt(32)))
+	}
+
+	// Get the account associated with the coinbase
+	statedb.AddBalance(block.Header().Coinbase, reward)
+}
+
+// sealCheck adapts the seal check of a consensus engine to pow.PoW so
+// that seals of any engine can be verified by a pow.Verifier.
+type sealCheck struct {
+	chain  consensus.ChainReader
+	engine consensus.Engine
+}
+
+func (c sealCheck) Verify(block pow.Block) bool {
+	b, ok := block.(*types.Block)
+	return ok && c.engine.VerifySeal(c.chain, b.Header()) == nil
+}
+
+func (c sealCheck) Search(block pow.Block, stop <-chan struct{}) (uint64, []byte, []byte) {
+	return 0, nil, nil
+}
+func (c sealCheck) GetHashrate() int64 { return 0 }
+func (c sealCheck) Turbo(bool)         {}
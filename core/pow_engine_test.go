@@ -0,0 +1,45 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestPoWEngineRewards(t *testing.T) {
+	bp, chain := proc()
+	coinbase := common.Address{1}
+	block := types.NewBlock(&types.Header{Coinbase: coinbase, Number: big.NewInt(10)}, nil, nil, nil)
+
+	tests := []struct {
+		reward *big.Int
+		want   *big.Int
+	}{
+		{nil, BlockReward},
+		{big.NewInt(5e18), big.NewInt(5e18)},
+		{big.NewInt(0), big.NewInt(0)},
+	}
+	for _, test := range tests {
+		chain.SetConfig(&params.ChainConfig{BlockReward: test.reward})
+		db, _ := ethdb.NewMemDatabase()
+		statedb := state.New(common.Hash{}, db)
+		bp.Engine().Finalize(chain, statedb, block)
+		if have := statedb.GetBalance(coinbase); have.Cmp(test.want) != 0 {
+			t.Errorf("reward %v: coinbase balance %v, want %v", test.reward, have, test.want)
+		}
+	}
+}
+
+func TestPoWEngineTooManyUncles(t *testing.T) {
+	bp, chain := proc()
+	genesis := chain.Genesis().Header()
+	block := types.NewBlock(chain.NewBlock(common.Address{}).Header(), nil, []*types.Header{genesis, genesis, genesis}, nil)
+	if err := bp.Engine().VerifyUncles(chain, block); err == nil {
+		t.Error("expected error for block with three uncles")
+	}
+}
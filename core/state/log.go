@@ -18,6 +18,9 @@ type Log struct {
 	TxIndex   uint
 	BlockHash common.Hash
 	Index     uint
+
+	// Removed is set if the log was reverted by a chain reorganisation.
+	Removed bool
 }
 
 func NewLog(address common.Address, topics []common.Hash, data []byte, number uint64) *Log {
@@ -28,6 +31,19 @@ func (self *Log) EncodeRLP(w io.Writer) error {
 	return rlp.Encode(w, []interface{}{self.Address, self.Topics, self.Data})
 }
 
+func (self *Log) DecodeRLP(s *rlp.Stream) error {
+	var log struct {
+		Address common.Address
+		Topics  []common.Hash
+		Data    []byte
+	}
+	if err := s.Decode(&log); err != nil {
+		return err
+	}
+	self.Address, self.Topics, self.Data = log.Address, log.Topics, log.Data
+	return nil
+}
+
 func (self *Log) String() string {
 	return fmt.Sprintf(`log: %x %x %x`, self.Address, self.Topics, self.Data)
 }
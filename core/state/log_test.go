@@ -0,0 +1,33 @@
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestLogRLP(t *testing.T) {
+	log := NewLog(common.Address{1}, []common.Hash{{2}, {3}}, []byte{4, 5}, 6)
+	log.Removed = true
+
+	enc, err := rlp.EncodeToBytes(Logs{log})
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	var logs Logs
+	if err := rlp.DecodeBytes(enc, &logs); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("decoded %d logs, want 1", len(logs))
+	}
+	dec := logs[0]
+	if dec.Address != log.Address || len(dec.Topics) != 2 || dec.Topics[1] != log.Topics[1] || !bytes.Equal(dec.Data, log.Data) {
+		t.Errorf("decoded log mismatch: got %v, want %v", dec, log)
+	}
+	if dec.Number != 0 || dec.Removed {
+		t.Errorf("fields outside the consensus encoding were decoded: %+v", dec)
+	}
+}
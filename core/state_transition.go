@@ -74,9 +74,12 @@ func MessageGasValue(msg Message) *big.Int {
 	return new(big.Int).Mul(msg.Gas(), msg.GasPrice())
 }
 
-func IntrinsicGas(msg Message) *big.Int {
+// IntrinsicGas computes the gas a transaction with the given data pays
+// before any code is executed. Contract creations are currently charged the
+// same as message calls.
+func IntrinsicGas(data []byte, contractCreation bool) *big.Int {
 	igas := new(big.Int).Set(params.TxGas)
-	for _, byt := range msg.Data() {
+	for _, byt := range data {
 		if byt != 0 {
 			igas.Add(igas, params.TxDataNonZeroGas)
 		} else {
@@ -191,7 +194,7 @@ func (self *StateTransition) transitionState() (ret []byte, usedGas *big.Int, er
 	)
 
 	// Pay intrinsic gas
-	if err = self.UseGas(IntrinsicGas(self.msg)); err != nil {
+	if err = self.UseGas(IntrinsicGas(msg.Data(), MessageCreatesContract(msg))); err != nil {
 		return nil, nil, InvalidTxError(err)
 	}
 
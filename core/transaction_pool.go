@@ -21,6 +21,7 @@ var (
 	ErrNonExistentAccount = errors.New("Account does not exist")
 	ErrInsufficientFunds  = errors.New("Insufficient funds")
 	ErrIntrinsicGas       = errors.New("Intrinsic gas too low")
+	ErrOversizedData      = errors.New("Oversized data")
 )
 
 const txPoolQueueSize = 50
@@ -30,6 +31,10 @@ type TxMsg struct{ Tx *types.Transaction }
 
 const (
 	minGasPrice = 1000000
+
+	// DefaultMaxTxSize is the default limit of the encoded size of
+	// transactions accepted by the pool.
+	DefaultMaxTxSize = 32 * 1024
 )
 
 type TxProcessor interface {
@@ -52,9 +57,22 @@ type TxPool struct {
 	txs           map[common.Hash]*types.Transaction
 	invalidHashes *set.Set
 
+	// transactions submitted locally and the journal persisting them
+	locals  *set.Set
+	journal *txJournal
+
+	// syncing reports whether the chain is still catching up with
+	// the network. Remote transactions can't be validated against
+	// the stale state and are dropped while it returns true.
+	syncing func() bool
+
+	// maxTxSize is the largest encoded transaction accepted.
+	maxTxSize uint64
+
 	subscribers []chan TxMsg
 
 	eventMux *event.TypeMux
+	txFeed   event.Feed
 }
 
 func NewTxPool(eventMux *event.TypeMux, currentStateFn func() *state.StateDB) *TxPool {
@@ -64,11 +82,23 @@ func NewTxPool(eventMux *event.TypeMux, currentStateFn func() *state.StateDB) *T
 		quit:          make(chan bool),
 		eventMux:      eventMux,
 		invalidHashes: set.New(),
+		locals:        set.New(),
 		currentState:  currentStateFn,
+		maxTxSize:     DefaultMaxTxSize,
 	}
 }
 
+// SubscribeTxPreEvent registers a subscription of TxPreEvent.
+func (pool *TxPool) SubscribeTxPreEvent() *event.FeedSubscription {
+	return pool.txFeed.Subscribe(eventBufferSize)
+}
+
 func (pool *TxPool) ValidateTransaction(tx *types.Transaction) error {
+	// Reject oversized transactions before doing any expensive checks
+	if uint64(tx.Size()) > pool.maxTxSize {
+		return ErrOversizedData
+	}
+
 	// Validate sender
 	var (
 		from common.Address
@@ -93,7 +123,7 @@ func (pool *TxPool) ValidateTransaction(tx *types.Transaction) error {
 		return ErrInsufficientFunds
 	}
 
-	if tx.GasLimit.Cmp(IntrinsicGas(tx)) < 0 {
+	if tx.GasLimit.Cmp(IntrinsicGas(tx.Data(), tx.To() == nil)) < 0 {
 		return ErrIntrinsicGas
 	}
 
@@ -143,7 +173,7 @@ func (self *TxPool) add(tx *types.Transaction) error {
 	}
 
 	// Notify the subscribers
-	go self.eventMux.Post(TxPreEvent{tx})
+	self.txFeed.Send(TxPreEvent{tx})
 
 	return nil
 }
@@ -159,7 +189,51 @@ func (self *TxPool) Add(tx *types.Transaction) error {
 	return self.add(tx)
 }
 
+// AddLocal adds a locally submitted transaction. Local transactions are
+// written to the journal, if any, and re-added to the pool at startup.
+func (self *TxPool) AddLocal(tx *types.Transaction) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if err := self.add(tx); err != nil {
+		return err
+	}
+	self.locals.Add(tx.Hash())
+	if self.journal != nil {
+		if err := self.journal.insert(tx); err != nil {
+			glog.V(logger.Warn).Infoln("Failed to journal local transaction:", err)
+		}
+	}
+	return nil
+}
+
+// SetJournal enables journaling of local transactions to the file at path.
+// It must be called before the pool is started.
+func (self *TxPool) SetJournal(path string) {
+	self.journal = newTxJournal(path)
+}
+
+// SetSyncCheck sets the function reporting whether the chain is
+// syncing. It must be called before the pool is started.
+func (self *TxPool) SetSyncCheck(syncing func() bool) {
+	self.syncing = syncing
+}
+
+// SetMaxTxSize sets the largest encoded transaction size accepted by
+// the pool. Zero keeps the default.
+func (self *TxPool) SetMaxTxSize(size uint64) {
+	if size > 0 {
+		self.maxTxSize = size
+	}
+}
+
+// AddTransactions adds transactions received from the network. They
+// are dropped while the chain is syncing.
 func (self *TxPool) AddTransactions(txs []*types.Transaction) {
+	if self.syncing != nil && self.syncing() {
+		glog.V(logger.Detail).Infof("Dropping %d remote transactions while syncing\n", len(txs))
+		return
+	}
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
@@ -187,11 +261,81 @@ func (self *TxPool) GetTransactions() (txs types.Transactions) {
 	return
 }
 
+// GetTransaction returns the transaction with the given hash or nil if the
+// pool doesn't contain it.
+func (self *TxPool) GetTransaction(hash common.Hash) *types.Transaction {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	return self.txs[hash]
+}
+
+// Content returns the transactions in the pool grouped by sender and nonce.
+// Transactions executable on the current state are pending, the ones
+// waiting for a nonce gap to be filled are queued.
+func (self *TxPool) Content() (pending, queued map[common.Address]map[uint64]*types.Transaction) {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	senders := make(map[common.Address]map[uint64]*types.Transaction)
+	for _, tx := range self.txs {
+		from, err := tx.From()
+		if err != nil {
+			continue
+		}
+		if senders[from] == nil {
+			senders[from] = make(map[uint64]*types.Transaction)
+		}
+		senders[from][tx.Nonce()] = tx
+	}
+
+	pending = make(map[common.Address]map[uint64]*types.Transaction)
+	queued = make(map[common.Address]map[uint64]*types.Transaction)
+	state := self.currentState()
+	for from, txs := range senders {
+		// transactions with consecutive nonces starting at the
+		// account nonce can be executed in order
+		start := state.GetNonce(from)
+		next := start
+		for txs[next] != nil {
+			next++
+		}
+		for nonce, tx := range txs {
+			group := queued
+			if nonce >= start && nonce < next {
+				group = pending
+			}
+			if group[from] == nil {
+				group[from] = make(map[uint64]*types.Transaction)
+			}
+			group[from][nonce] = tx
+		}
+	}
+	return pending, queued
+}
+
+// Stats returns the number of pending and queued transactions, see Content.
+func (self *TxPool) Stats() (pending, queued int) {
+	p, q := self.Content()
+	for _, txs := range p {
+		pending += len(txs)
+	}
+	for _, txs := range q {
+		queued += len(txs)
+	}
+	return
+}
+
 func (self *TxPool) RemoveSet(txs types.Transactions) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
+	var locals bool
 	for _, tx := range txs {
 		delete(self.txs, tx.Hash())
+		locals = locals || self.locals.Has(tx.Hash())
+	}
+	if locals {
+		self.rotateJournal()
 	}
 }
 
@@ -199,21 +343,74 @@ func (self *TxPool) InvalidateSet(hashes *set.Set) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
+	var locals bool
 	hashes.Each(func(v interface{}) bool {
 		delete(self.txs, v.(common.Hash))
+		locals = locals || self.locals.Has(v)
 		return true
 	})
 	self.invalidHashes.Merge(hashes)
+	if locals {
+		self.rotateJournal()
+	}
+}
+
+// rotateJournal rewrites the journal with the local transactions still in
+// the pool. The caller must hold the lock.
+func (self *TxPool) rotateJournal() {
+	var (
+		txs   types.Transactions
+		stale []interface{}
+	)
+	self.locals.Each(func(v interface{}) bool {
+		if tx := self.txs[v.(common.Hash)]; tx != nil {
+			txs = append(txs, tx)
+		} else {
+			stale = append(stale, v)
+		}
+		return true
+	})
+	self.locals.Remove(stale...)
+	if self.journal == nil {
+		return
+	}
+	if err := self.journal.rotate(txs); err != nil {
+		glog.V(logger.Warn).Infoln("Failed to rotate transaction journal:", err)
+	}
 }
 
 func (pool *TxPool) Flush() {
 	pool.txs = make(map[common.Hash]*types.Transaction)
 }
 
+// Start adds the journaled local transactions which are still valid to the
+// pool.
 func (pool *TxPool) Start() {
+	if pool.journal == nil {
+		return
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	err := pool.journal.load(func(tx *types.Transaction) error {
+		if err := pool.add(tx); err != nil {
+			return err
+		}
+		pool.locals.Add(tx.Hash())
+		return nil
+	})
+	if err != nil {
+		glog.V(logger.Warn).Infoln("Failed to load transaction journal:", err)
+	}
+	pool.rotateJournal()
 }
 
 func (pool *TxPool) Stop() {
+	if pool.journal != nil {
+		pool.mu.Lock()
+		pool.journal.close()
+		pool.mu.Unlock()
+	}
 	pool.Flush()
 
 	glog.V(logger.Info).Infoln("TX Pool stopped")
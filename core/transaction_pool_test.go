@@ -2,7 +2,10 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -60,3 +63,155 @@ func TestInvalidTransactions(t *testing.T) {
 		t.Error("expected", ErrImpossibleNonce)
 	}
 }
+
+func TestOversizedTransaction(t *testing.T) {
+	pool, key := setupTxPool()
+
+	tx := types.NewTransactionMessage(common.Address{}, big.NewInt(100), big.NewInt(100), big.NewInt(100), make([]byte, DefaultMaxTxSize))
+	tx.SignECDSA(key)
+	if err := pool.Add(tx); err != ErrOversizedData {
+		t.Errorf("got error %v, want %v", err, ErrOversizedData)
+	}
+
+	pool.SetMaxTxSize(2 * DefaultMaxTxSize)
+	if err := pool.Add(tx); err == ErrOversizedData {
+		t.Error("transaction rejected after raising the size limit")
+	}
+}
+
+func TestIntrinsicGas(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		create bool
+		gas    int64
+	}{
+		{nil, false, 21000},
+		{nil, true, 21000},
+		{[]byte{0, 1}, false, 21000 + 4 + 68},
+		{[]byte{1, 1, 0}, true, 21000 + 68 + 68 + 4},
+	}
+	for i, test := range tests {
+		if gas := IntrinsicGas(test.data, test.create); gas.Cmp(big.NewInt(test.gas)) != 0 {
+			t.Errorf("test %d: expected %d, got %v", i, test.gas, gas)
+		}
+	}
+}
+
+func TestGetTransaction(t *testing.T) {
+	pool, key := setupTxPool()
+
+	tx := transaction()
+	tx.SignECDSA(key)
+	if pool.GetTransaction(tx.Hash()) != nil {
+		t.Error("expected unknown transaction to be nil")
+	}
+	pool.addTx(tx)
+	if pool.GetTransaction(tx.Hash()) != tx {
+		t.Error("expected transaction to be returned")
+	}
+}
+
+func TestDropRemoteWhileSyncing(t *testing.T) {
+	pool, key := setupTxPool()
+	syncing := true
+	pool.SetSyncCheck(func() bool { return syncing })
+
+	tx := transaction()
+	tx.GasLimit = big.NewInt(100000)
+	tx.Price = big.NewInt(1)
+	tx.SignECDSA(key)
+	from, _ := tx.From()
+	pool.currentState().AddBalance(from, big.NewInt(0xffffffffffffff))
+
+	pool.AddTransactions(types.Transactions{tx})
+	if pool.GetTransaction(tx.Hash()) != nil {
+		t.Error("remote transaction added while syncing")
+	}
+	syncing = false
+	pool.AddTransactions(types.Transactions{tx})
+	if pool.GetTransaction(tx.Hash()) == nil {
+		t.Error("remote transaction not added after sync")
+	}
+}
+
+func TestContent(t *testing.T) {
+	pool, key := setupTxPool()
+	from := common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+	pool.currentState().SetNonce(from, 1)
+
+	for _, nonce := range []uint64{1, 2, 4} {
+		tx := transaction()
+		tx.SetNonce(nonce)
+		tx.SignECDSA(key)
+		pool.addTx(tx)
+	}
+
+	pending, queued := pool.Content()
+	if len(pending[from]) != 2 || pending[from][1] == nil || pending[from][2] == nil {
+		t.Errorf("expected nonces 1 and 2 to be pending, got %v", pending[from])
+	}
+	if len(queued[from]) != 1 || queued[from][4] == nil {
+		t.Errorf("expected nonce 4 to be queued, got %v", queued[from])
+	}
+	if p, q := pool.Stats(); p != 2 || q != 1 {
+		t.Errorf("expected 2 pending and 1 queued, got %d and %d", p, q)
+	}
+}
+
+func TestTransactionJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txjournal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "transactions.rlp")
+
+	pool, key := setupTxPool()
+	from := common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+	pool.currentState().AddBalance(from, big.NewInt(1000000))
+	pool.SetJournal(journal)
+	pool.Start()
+
+	var txs types.Transactions
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx := types.NewTransactionMessage(common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+		tx.SetNonce(nonce)
+		tx.SignECDSA(key)
+		if err := pool.AddLocal(tx); err != nil {
+			t.Fatalf("could not add local transaction: %v", err)
+		}
+		txs = append(txs, tx)
+	}
+	// the first transaction gets mined
+	pool.RemoveSet(txs[:1])
+	pool.Stop()
+
+	restarted, _ := setupTxPool()
+	restarted.currentState().AddBalance(from, big.NewInt(1000000))
+	restarted.SetJournal(journal)
+	// subscribers registered before the start see the replayed transactions
+	sub := restarted.SubscribeTxPreEvent()
+	defer sub.Unsubscribe()
+	restarted.Start()
+	defer restarted.Stop()
+
+	if restarted.Size() != 2 {
+		t.Fatalf("expected 2 transactions after restart, got %d", restarted.Size())
+	}
+	// the journal is rewritten from the pool, its order is not kept
+	events := make(map[common.Hash]bool)
+	for len(sub.Chan()) > 0 {
+		events[(<-sub.Chan()).(TxPreEvent).Tx.Hash()] = true
+	}
+	if len(events) != 2 {
+		t.Errorf("got %d events for journaled transactions, want 2", len(events))
+	}
+	for _, tx := range txs[1:] {
+		if restarted.GetTransaction(tx.Hash()) == nil {
+			t.Errorf("journaled transaction %x missing", tx.Hash())
+		}
+		if !events[tx.Hash()] {
+			t.Errorf("no event for journaled transaction %x", tx.Hash())
+		}
+	}
+}
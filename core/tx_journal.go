@@ -0,0 +1,111 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var errNoActiveJournal = errors.New("no active journal")
+
+// txJournal is an append only log of the transactions submitted locally.
+// The journaled transactions are added to the pool again at startup so
+// that they aren't lost when the node restarts before they are mined.
+type txJournal struct {
+	path   string
+	writer io.WriteCloser
+}
+
+func newTxJournal(path string) *txJournal {
+	return &txJournal{path: path}
+}
+
+// load reads the journal and passes each transaction to add. It is not an
+// error if the journal doesn't exist.
+func (self *txJournal) load(add func(*types.Transaction) error) error {
+	input, err := os.Open(self.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	stream := rlp.NewStream(bufio.NewReader(input), 0)
+	var total, dropped int
+	for {
+		tx := new(types.Transaction)
+		if err = stream.Decode(tx); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			break
+		}
+		total++
+		if add(tx) != nil {
+			dropped++
+		}
+	}
+	glog.V(logger.Info).Infof("Loaded %d local transactions from journal, dropped %d\n", total, dropped)
+
+	return err
+}
+
+// insert appends a transaction to the journal.
+func (self *txJournal) insert(tx *types.Transaction) error {
+	if self.writer == nil {
+		return errNoActiveJournal
+	}
+	return rlp.Encode(self.writer, tx)
+}
+
+// rotate replaces the journal with one containing only the given
+// transactions, dropping the ones which have been mined in the meantime.
+func (self *txJournal) rotate(txs types.Transactions) error {
+	if self.writer != nil {
+		if err := self.writer.Close(); err != nil {
+			return err
+		}
+		self.writer = nil
+	}
+
+	replacement, err := os.OpenFile(self.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		if err = rlp.Encode(replacement, tx); err != nil {
+			replacement.Close()
+			return err
+		}
+	}
+	replacement.Close()
+
+	if err = os.Rename(self.path+".new", self.path); err != nil {
+		return err
+	}
+	sink, err := os.OpenFile(self.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	self.writer = sink
+	glog.V(logger.Detail).Infof("Rotated transaction journal, %d transactions kept\n", len(txs))
+
+	return nil
+}
+
+// close flushes the journal to disk and closes the file.
+func (self *txJournal) close() (err error) {
+	if self.writer != nil {
+		err = self.writer.Close()
+		self.writer = nil
+	}
+	return err
+}
@@ -6,7 +6,7 @@ import (
 	"io"
 	"math/big"
 	"sort"
-	"time"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto/sha3"
@@ -46,6 +46,9 @@ type Header struct {
 	Nonce [8]byte
 }
 
+// Hash returns the hash of the header. It is not cached because the
+// header fields may be modified directly, see Block.Hash for a cached
+// version.
 func (self *Header) Hash() common.Hash {
 	return rlpHash(self.rlpData(true))
 }
@@ -87,6 +90,11 @@ func rlpHash(x interface{}) (h common.Hash) {
 	return h
 }
 
+// Block represents a block of the chain. Blocks are immutable: the
+// header and body can't be modified after the block is created, only
+// copied into a new block by WithHeader and WithBody. This makes it
+// safe to share blocks between goroutines and to cache their hash.
+// Td and the queued flag are not part of the block and may be set.
 type Block struct {
 	// Preset Hash for mock (Tests)
 	HeaderHash       common.Hash
@@ -100,6 +108,9 @@ type Block struct {
 	queued       bool // flag for blockpool to skip TD check
 
 	receipts Receipts
+
+	// hash caches the header hash once it is computed.
+	hash atomic.Value
 }
 
 // StorageBlock defines the RLP encoding of a Block stored in the
@@ -122,31 +133,84 @@ type storageblock struct {
 	TD     *big.Int
 }
 
-func NewBlock(parentHash common.Hash, coinbase common.Address, root common.Hash, difficulty *big.Int, nonce uint64, extra []byte) *Block {
-	header := &Header{
-		Root:       root,
-		ParentHash: parentHash,
-		Coinbase:   coinbase,
-		Difficulty: difficulty,
-		Time:       uint64(time.Now().Unix()),
-		Extra:      extra,
-		GasUsed:    new(big.Int),
-		GasLimit:   new(big.Int),
-		Number:     new(big.Int),
+// NewBlock creates a new block. The header is copied and its TxHash,
+// UncleHash, ReceiptHash and Bloom are derived from the given
+// transactions, uncles and receipts. The lists are not copied and must
+// not be modified afterwards.
+func NewBlock(header *Header, txs []*Transaction, uncles []*Header, receipts []*Receipt) *Block {
+	block := &Block{
+		header:       CopyHeader(header),
+		transactions: txs,
+		uncles:       uncles,
+		receipts:     receipts,
+		Td:           new(big.Int),
 	}
-	header.SetNonce(nonce)
-	block := &Block{header: header}
-	block.Td = new(big.Int)
-
+	block.header.TxHash = DeriveSha(Transactions(txs))
+	block.header.UncleHash = CalcUncleHash(uncles)
+	block.header.ReceiptHash = DeriveSha(Receipts(receipts))
+	block.header.Bloom = CreateBloom(receipts)
 	return block
 }
 
+// CalcUncleHash returns the UncleHash of a header committing to uncles.
+func CalcUncleHash(uncles []*Header) common.Hash {
+	return rlpHash(uncles)
+}
+
+// NewBlockWithHeader creates a block without body from a copy of header.
+func NewBlockWithHeader(header *Header) *Block {
+	return &Block{header: CopyHeader(header)}
+}
+
+// CopyHeader creates a deep copy of a block header, so that modifying
+// the copy doesn't affect the original.
+func CopyHeader(h *Header) *Header {
+	cpy := *h
+	cpy.Difficulty = copyBig(h.Difficulty)
+	cpy.Number = copyBig(h.Number)
+	cpy.GasLimit = copyBig(h.GasLimit)
+	cpy.GasUsed = copyBig(h.GasUsed)
+	if len(h.Extra) > 0 {
+		cpy.Extra = common.CopyBytes(h.Extra)
+	}
+	return &cpy
+}
+
+func copyBig(x *big.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return new(big.Int).Set(x)
+}
+
 func (self *Header) SetNonce(nonce uint64) {
 	binary.BigEndian.PutUint64(self.Nonce[:], nonce)
 }
 
-func NewBlockWithHeader(header *Header) *Block {
-	return &Block{header: header}
+// WithHeader returns a new block with the body of self and a copy of
+// header. The header hashes are not checked against the body, the
+// caller must only change fields not derived from it, e.g. the seal.
+func (self *Block) WithHeader(header *Header) *Block {
+	return &Block{
+		header:       CopyHeader(header),
+		transactions: self.transactions,
+		uncles:       self.uncles,
+		receipts:     self.receipts,
+		Td:           self.Td,
+	}
+}
+
+// WithBody returns a new block with the header of self and the given
+// transactions and uncles, e.g. to assemble a block whose header and
+// body were retrieved separately. The header is not updated to match
+// the body.
+func (self *Block) WithBody(txs []*Transaction, uncles []*Header) *Block {
+	return &Block{
+		header:       CopyHeader(self.header),
+		transactions: txs,
+		uncles:       uncles,
+		Td:           self.Td,
+	}
 }
 
 func (self *Block) ValidateFields() error {
@@ -201,19 +265,15 @@ func (self StorageBlock) EncodeRLP(w io.Writer) error {
 	})
 }
 
+// Header returns a copy of the block header.
 func (self *Block) Header() *Header {
-	return self.header
+	return CopyHeader(self.header)
 }
 
 func (self *Block) Uncles() []*Header {
 	return self.uncles
 }
 
-func (self *Block) SetUncles(uncleHeaders []*Header) {
-	self.uncles = uncleHeaders
-	self.header.UncleHash = rlpHash(uncleHeaders)
-}
-
 func (self *Block) Transactions() Transactions {
 	return self.transactions
 }
@@ -227,29 +287,10 @@ func (self *Block) Transaction(hash common.Hash) *Transaction {
 	return nil
 }
 
-func (self *Block) SetTransactions(transactions Transactions) {
-	self.transactions = transactions
-	self.header.TxHash = DeriveSha(transactions)
-}
-func (self *Block) AddTransaction(transaction *Transaction) {
-	self.transactions = append(self.transactions, transaction)
-	self.SetTransactions(self.transactions)
-}
-
 func (self *Block) Receipts() Receipts {
 	return self.receipts
 }
 
-func (self *Block) SetReceipts(receipts Receipts) {
-	self.receipts = receipts
-	self.header.ReceiptHash = DeriveSha(receipts)
-	self.header.Bloom = CreateBloom(receipts)
-}
-func (self *Block) AddReceipt(receipt *Receipt) {
-	self.receipts = append(self.receipts, receipt)
-	self.SetReceipts(self.receipts)
-}
-
 func (self *Block) RlpData() interface{} {
 	return []interface{}{self.header, self.transactions, self.uncles}
 }
@@ -259,15 +300,12 @@ func (self *Block) RlpDataForStorage() interface{} {
 }
 
 // Header accessors (add as you need them)
-func (self *Block) Number() *big.Int       { return self.header.Number }
+func (self *Block) Number() *big.Int       { return copyBig(self.header.Number) }
 func (self *Block) NumberU64() uint64      { return self.header.Number.Uint64() }
 func (self *Block) MixDigest() common.Hash { return self.header.MixDigest }
 func (self *Block) Nonce() uint64 {
 	return binary.BigEndian.Uint64(self.header.Nonce[:])
 }
-func (self *Block) SetNonce(nonce uint64) {
-	self.header.SetNonce(nonce)
-}
 
 func (self *Block) Queued() bool     { return self.queued }
 func (self *Block) SetQueued(q bool) { self.queued = q }
@@ -275,10 +313,9 @@ func (self *Block) SetQueued(q bool) { self.queued = q }
 func (self *Block) Bloom() Bloom             { return self.header.Bloom }
 func (self *Block) Coinbase() common.Address { return self.header.Coinbase }
 func (self *Block) Time() int64              { return int64(self.header.Time) }
-func (self *Block) GasLimit() *big.Int       { return self.header.GasLimit }
-func (self *Block) GasUsed() *big.Int        { return self.header.GasUsed }
+func (self *Block) GasLimit() *big.Int       { return copyBig(self.header.GasLimit) }
+func (self *Block) GasUsed() *big.Int        { return copyBig(self.header.GasUsed) }
 func (self *Block) Root() common.Hash        { return self.header.Root }
-func (self *Block) SetRoot(root common.Hash) { self.header.Root = root }
 func (self *Block) GetTransaction(i int) *Transaction {
 	if len(self.transactions) > i {
 		return self.transactions[i]
@@ -306,15 +343,21 @@ func (c *writeCounter) Write(b []byte) (int, error) {
 }
 
 // Implement pow.Block
-func (self *Block) Difficulty() *big.Int     { return self.header.Difficulty }
+func (self *Block) Difficulty() *big.Int     { return copyBig(self.header.Difficulty) }
 func (self *Block) HashNoNonce() common.Hash { return self.header.HashNoNonce() }
 
+// Hash returns the hash of the block header. It is computed once and
+// cached.
 func (self *Block) Hash() common.Hash {
 	if (self.HeaderHash != common.Hash{}) {
 		return self.HeaderHash
-	} else {
-		return self.header.Hash()
 	}
+	if hash, ok := self.hash.Load().(common.Hash); ok {
+		return hash
+	}
+	hash := self.header.Hash()
+	self.hash.Store(hash)
+	return hash
 }
 
 func (self *Block) ParentHash() common.Hash {
@@ -325,27 +368,6 @@ func (self *Block) ParentHash() common.Hash {
 	}
 }
 
-func (self *Block) Copy() *Block {
-	block := NewBlock(self.header.ParentHash, self.Coinbase(), self.Root(), new(big.Int), self.Nonce(), self.header.Extra)
-	block.header.Bloom = self.header.Bloom
-	block.header.TxHash = self.header.TxHash
-	block.transactions = self.transactions
-	block.header.UncleHash = self.header.UncleHash
-	block.uncles = self.uncles
-	block.header.GasLimit.Set(self.header.GasLimit)
-	block.header.GasUsed.Set(self.header.GasUsed)
-	block.header.ReceiptHash = self.header.ReceiptHash
-	block.header.Difficulty.Set(self.header.Difficulty)
-	block.header.Number.Set(self.header.Number)
-	block.header.Time = self.header.Time
-	block.header.MixDigest = self.header.MixDigest
-	if self.Td != nil {
-		block.Td.Set(self.Td)
-	}
-
-	return block
-}
-
 func (self *Block) String() string {
 	return fmt.Sprintf(`Block(#%v): Size: %v TD: %v {
 MinerHash: %x
@@ -58,3 +58,64 @@ func TestBlockEncoding(t *testing.T) {
 		t.Errorf("encoded block mismatch:\ngot:  %x\nwant: %x", ourBlockEnc, blockEnc)
 	}
 }
+
+func testHeader() *Header {
+	return &Header{
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(1),
+		GasLimit:   big.NewInt(3141592),
+		GasUsed:    new(big.Int),
+		Extra:      make([]byte, 32),
+	}
+}
+
+func TestBlockImmutable(t *testing.T) {
+	block := NewBlock(testHeader(), nil, nil, nil)
+	hash := block.Hash()
+
+	header := block.Header()
+	header.Time++
+	header.Number.SetInt64(2)
+	header.Extra[0] = 1
+	if block.Hash() != hash || block.header.Hash() != hash {
+		t.Fatal("block modified through the returned header")
+	}
+
+	sealed := block.WithHeader(header)
+	if sealed.Hash() == hash {
+		t.Error("hash of new block not updated")
+	}
+	if sealed.Hash() != header.Hash() {
+		t.Errorf("hash mismatch: got %x, want %x", sealed.Hash(), header.Hash())
+	}
+	if block.Hash() != hash || block.NumberU64() != 1 {
+		t.Error("original block modified by WithHeader")
+	}
+}
+
+func TestNewBlockDerivesHashes(t *testing.T) {
+	uncles := []*Header{testHeader()}
+	block := NewBlock(testHeader(), nil, uncles, nil)
+	if block.Header().UncleHash != rlpHash(uncles) {
+		t.Errorf("uncle hash mismatch: got %x, want %x", block.Header().UncleHash, rlpHash(uncles))
+	}
+	if body := block.WithBody(nil, nil); body.Hash() != block.Hash() || len(body.Uncles()) != 0 {
+		t.Error("WithBody changed the header or kept the uncles")
+	}
+}
+
+func BenchmarkBlockHash(b *testing.B) {
+	block := NewBlock(testHeader(), nil, nil, nil)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		block.Hash()
+	}
+}
+
+func BenchmarkHeaderHash(b *testing.B) {
+	header := testHeader()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		header.Hash()
+	}
+}
@@ -55,6 +55,21 @@ func bloom9(b []byte) *big.Int {
 
 var Bloom9 = bloom9
 
+// BloomBits returns the indexes of the three bloom bits set for data. Bit
+// i is the i-th least significant bit of the bloom.
+func BloomBits(data []byte) (bits [3]uint) {
+	h := crypto.Sha3(data)
+	for i := 0; i < 6; i += 2 {
+		bits[i/2] = (uint(h[i+1]) + (uint(h[i]) << 8)) & 2047
+	}
+	return bits
+}
+
+// TestBit reports whether bit i of the bloom is set.
+func (b Bloom) TestBit(i uint) bool {
+	return b[len(b)-1-int(i/8)]&(1<<(i%8)) != 0
+}
+
 func BloomLookup(bin Bloom, topic bytesBacked) bool {
 	bloom := bin.Big()
 	cmp := bloom9(topic.Bytes()[:])
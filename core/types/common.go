@@ -31,6 +31,13 @@ func (b *Bloom) SetBytes(d []byte) {
 	copy(b[bloomLength-len(d):], d)
 }
 
+// Or adds the bits set in other to b.
+func (b *Bloom) Or(other Bloom) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
 func (b Bloom) Big() *big.Int {
 	return common.Bytes2Big(b[:])
 }
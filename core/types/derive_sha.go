@@ -22,3 +22,28 @@ func DeriveSha(list DerivableList) common.Hash {
 
 	return common.BytesToHash(trie.Root())
 }
+
+// ListHasher computes the same hash as DeriveSha for a list which is
+// built one element at a time, without inserting the earlier elements
+// again whenever the list grows.
+type ListHasher struct {
+	trie *trie.Trie
+	n    uint
+}
+
+func NewListHasher() *ListHasher {
+	db, _ := ethdb.NewMemDatabase()
+	return &ListHasher{trie: trie.New(nil, db)}
+}
+
+// Append adds the RLP encoding of the next list element.
+func (h *ListHasher) Append(enc []byte) {
+	key, _ := rlp.EncodeToBytes(h.n)
+	h.trie.Update(key, enc)
+	h.n++
+}
+
+// Hash returns the hash of the elements appended so far.
+func (h *ListHasher) Hash() common.Hash {
+	return common.BytesToHash(h.trie.Root())
+}
@@ -0,0 +1,26 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestListHasher(t *testing.T) {
+	var (
+		txs    Transactions
+		hasher = NewListHasher()
+	)
+	if hasher.Hash() != DeriveSha(txs) {
+		t.Fatalf("empty list: got %x, want %x", hasher.Hash(), DeriveSha(txs))
+	}
+	for i := 0; i < 20; i++ {
+		tx := NewTransactionMessage(common.Address{byte(i)}, big.NewInt(int64(i)), big.NewInt(21000), big.NewInt(1), nil)
+		txs = append(txs, tx)
+		hasher.Append(txs.GetRlp(i))
+		if hasher.Hash() != DeriveSha(txs) {
+			t.Fatalf("%d elements: got %x, want %x", i+1, hasher.Hash(), DeriveSha(txs))
+		}
+	}
+}
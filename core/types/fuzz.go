@@ -0,0 +1,31 @@
+// +build gofuzz
+
+package types
+
+import "github.com/ethereum/go-ethereum/rlp"
+
+// Fuzz is the go-fuzz entry point for block and transaction decoding.
+// The input is decoded as both a block and a transaction.
+func Fuzz(data []byte) int {
+	var ok int
+
+	block := new(Block)
+	if rlp.DecodeBytes(data, block) == nil {
+		block.Hash()
+		if _, err := rlp.EncodeToBytes(block); err != nil {
+			panic(err)
+		}
+		ok = 1
+	}
+
+	tx := new(Transaction)
+	if rlp.DecodeBytes(data, tx) == nil {
+		tx.Hash()
+		tx.From()
+		if _, err := rlp.EncodeToBytes(tx); err != nil {
+			panic(err)
+		}
+		ok = 1
+	}
+	return ok
+}
@@ -16,6 +16,10 @@ type Receipt struct {
 	CumulativeGasUsed *big.Int
 	Bloom             Bloom
 	logs              state.Logs
+
+	// not part of the consensus encoding, kept in the local receipt store
+	gasUsed *big.Int
+	failed  bool
 }
 
 func NewReceipt(root []byte, cumalativeGasUsed *big.Int) *Receipt {
@@ -26,6 +30,29 @@ func (self *Receipt) SetLogs(logs state.Logs) {
 	self.logs = logs
 }
 
+func (self *Receipt) Logs() state.Logs {
+	return self.logs
+}
+
+func (self *Receipt) SetGasUsed(gas *big.Int) {
+	self.gasUsed = gas
+}
+
+// GasUsed returns the gas used by the transaction alone.
+func (self *Receipt) GasUsed() *big.Int {
+	return self.gasUsed
+}
+
+func (self *Receipt) SetFailed(failed bool) {
+	self.failed = failed
+}
+
+// Failed reports whether execution of the transaction ran out of gas or
+// threw.
+func (self *Receipt) Failed() bool {
+	return self.failed
+}
+
 func (self *Receipt) EncodeRLP(w io.Writer) error {
 	return rlp.Encode(w, []interface{}{self.PostState, self.CumulativeGasUsed, self.Bloom, self.logs})
 }
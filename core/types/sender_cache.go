@@ -0,0 +1,58 @@
+package types
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// senderCacheLimit is the number of recovered senders kept in memory.
+const senderCacheLimit = 16384
+
+// senderKey identifies a signed transaction. The transaction hash does
+// not cover the signature, so it is part of the key.
+type senderKey struct {
+	hash common.Hash
+	v    byte
+	r, s [32]byte
+}
+
+func newSenderKey(hash common.Hash, v byte, r, s *big.Int) senderKey {
+	key := senderKey{hash: hash, v: v}
+	copy(key.r[:], common.LeftPadBytes(r.Bytes(), 32))
+	copy(key.s[:], common.LeftPadBytes(s.Bytes(), 32))
+	return key
+}
+
+// senderCache holds the senders recovered from transaction signatures.
+// The oldest entry is evicted when the cache is full.
+var senderCache = struct {
+	sync.Mutex
+	senders map[senderKey]common.Address
+	keys    []senderKey // ring of inserted keys, for eviction
+	next    int         // position of the oldest key once the ring is full
+}{senders: make(map[senderKey]common.Address)}
+
+func cachedSender(key senderKey) (common.Address, bool) {
+	senderCache.Lock()
+	defer senderCache.Unlock()
+	addr, ok := senderCache.senders[key]
+	return addr, ok
+}
+
+func cacheSender(key senderKey, addr common.Address) {
+	senderCache.Lock()
+	defer senderCache.Unlock()
+	if _, ok := senderCache.senders[key]; ok {
+		return
+	}
+	if len(senderCache.keys) < senderCacheLimit {
+		senderCache.keys = append(senderCache.keys, key)
+	} else {
+		delete(senderCache.senders, senderCache.keys[senderCache.next])
+		senderCache.keys[senderCache.next] = key
+		senderCache.next = (senderCache.next + 1) % senderCacheLimit
+	}
+	senderCache.senders[key] = addr
+}
@@ -68,6 +68,13 @@ func (tx *Transaction) Hash() common.Hash {
 	})
 }
 
+// Size returns the size of the RLP encoding of the transaction.
+func (tx *Transaction) Size() common.StorageSize {
+	c := writeCounter(0)
+	rlp.Encode(&c, tx)
+	return common.StorageSize(c)
+}
+
 func (self *Transaction) Data() []byte {
 	return self.Payload
 }
@@ -92,7 +99,13 @@ func (self *Transaction) SetNonce(AccountNonce uint64) {
 	self.AccountNonce = AccountNonce
 }
 
+// From returns the sender recovered from the signature. Recovered
+// senders are cached, so each signature is only recovered once.
 func (self *Transaction) From() (common.Address, error) {
+	key := newSenderKey(self.Hash(), self.V, self.R, self.S)
+	if addr, ok := cachedSender(key); ok {
+		return addr, nil
+	}
 	pubkey := self.PublicKey()
 	if len(pubkey) == 0 || pubkey[0] != 4 {
 		return common.Address{}, errors.New("invalid public key")
@@ -100,6 +113,7 @@ func (self *Transaction) From() (common.Address, error) {
 
 	var addr common.Address
 	copy(addr[:], crypto.Sha3(pubkey[1:])[12:])
+	cacheSender(key, addr)
 	return addr, nil
 }
 
@@ -109,3 +109,20 @@ func TestRecipientNormal(t *testing.T) {
 		t.Error("derived address doesn't match")
 	}
 }
+
+func TestSenderCache(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	tx := NewTransactionMessage(common.Address{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil)
+
+	// Re-signing keeps the hash, the sender must still change.
+	for _, key := range []*ecdsa.PrivateKey{key1, key2, key1} {
+		tx.SignECDSA(key)
+		want := common.BytesToAddress(crypto.PubkeyToAddress(key.PublicKey))
+		for i := 0; i < 2; i++ {
+			if from, err := tx.From(); err != nil || from != want {
+				t.Fatalf("got sender %x (error %v), want %x", from, err, want)
+			}
+		}
+	}
+}
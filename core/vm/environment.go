@@ -8,6 +8,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -25,6 +26,7 @@ type Environment interface {
 	AddLog(*state.Log)
 
 	VmType() Type
+	ChainConfig() *params.ChainConfig
 
 	Depth() int
 	SetDepth(i int)
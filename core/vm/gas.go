@@ -21,7 +21,7 @@ var (
 	GasContractByte = big.NewInt(200)
 )
 
-func baseCheck(op OpCode, stack *stack, gas *big.Int) error {
+func baseCheck(op OpCode, stack *stack, gas *big.Int, gasTable params.GasTable) error {
 	// PUSH and DUP are a bit special. They all cost the same but we do want to have checking on stack push limit
 	// PUSH is also allowed to calculate the same price for all PUSHes
 	// DUP requirements are handled elsewhere (except for the stack limit check)
@@ -42,11 +42,35 @@ func baseCheck(op OpCode, stack *stack, gas *big.Int) error {
 			return fmt.Errorf("stack limit reached %d (%d)", len(stack.data), params.StackLimit.Int64())
 		}
 
-		gas.Add(gas, r.gas)
+		if r.gas != nil {
+			gas.Add(gas, r.gas)
+		} else {
+			gas.Add(gas, repricedGas(op, gasTable))
+		}
 	}
 	return nil
 }
 
+// repricedGas returns the base price of an opcode whose price is defined
+// by the gas table rather than by _baseCheck.
+func repricedGas(op OpCode, gasTable params.GasTable) *big.Int {
+	switch op {
+	case BALANCE:
+		return gasTable.Balance
+	case EXTCODESIZE:
+		return gasTable.ExtcodeSize
+	case EXTCODECOPY:
+		return gasTable.ExtcodeCopy
+	case SLOAD:
+		return gasTable.SLoad
+	case CALL, CALLCODE:
+		return gasTable.Calls
+	case SUICIDE:
+		return gasTable.Suicide
+	}
+	return Zero
+}
+
 func toWordSize(size *big.Int) *big.Int {
 	tmp := new(big.Int)
 	tmp.Add(size, u256(31))
@@ -62,6 +86,7 @@ type req struct {
 
 var _baseCheck = map[OpCode]req{
 	// opcode  |  stack pop | gas price | stack push
+	// a nil gas price means the price is taken from the active params.GasTable
 	ADD:          {2, GasFastestStep, 1},
 	LT:           {2, GasFastestStep, 1},
 	GT:           {2, GasFastestStep, 1},
@@ -109,17 +134,17 @@ var _baseCheck = map[OpCode]req{
 	MSIZE:        {0, GasQuickStep, 1},
 	GAS:          {0, GasQuickStep, 1},
 	BLOCKHASH:    {1, GasExtStep, 1},
-	BALANCE:      {1, GasExtStep, 1},
-	EXTCODESIZE:  {1, GasExtStep, 1},
-	EXTCODECOPY:  {4, GasExtStep, 0},
-	SLOAD:        {1, params.SloadGas, 1},
+	BALANCE:      {1, nil, 1},
+	EXTCODESIZE:  {1, nil, 1},
+	EXTCODECOPY:  {4, nil, 0},
+	SLOAD:        {1, nil, 1},
 	SSTORE:       {2, Zero, 0},
 	SHA3:         {2, params.Sha3Gas, 1},
 	CREATE:       {3, params.CreateGas, 1},
-	CALL:         {7, params.CallGas, 1},
-	CALLCODE:     {7, params.CallGas, 1},
+	CALL:         {7, nil, 1},
+	CALLCODE:     {7, nil, 1},
 	JUMPDEST:     {0, params.JumpdestGas, 0},
-	SUICIDE:      {1, Zero, 0},
+	SUICIDE:      {1, nil, 0},
 	RETURN:       {2, Zero, 0},
 	PUSH1:        {0, GasFastestStep, 1},
 	DUP1:         {0, Zero, 1},
@@ -0,0 +1,55 @@
+package vm
+
+import (
+	"math/big"
+	"sync"
+)
+
+// Global flag enabling the collection of per opcode execution statistics
+var CollectStats bool
+
+// OpStats holds the aggregated execution count and gas usage of an opcode.
+type OpStats struct {
+	Count uint64   `json:"count"`
+	Gas   *big.Int `json:"gas"`
+}
+
+var (
+	statsMu sync.Mutex
+	opStats = make(map[OpCode]*OpStats)
+)
+
+// recordOp adds a single execution of op costing gas to the statistics.
+func recordOp(op OpCode, gas *big.Int) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	s := opStats[op]
+	if s == nil {
+		s = &OpStats{Gas: new(big.Int)}
+		opStats[op] = s
+	}
+	s.Count++
+	s.Gas.Add(s.Gas, gas)
+}
+
+// Stats returns a copy of the statistics collected since the last reset,
+// keyed by opcode name.
+func Stats() map[string]OpStats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	stats := make(map[string]OpStats, len(opStats))
+	for op, s := range opStats {
+		stats[op.String()] = OpStats{Count: s.Count, Gas: new(big.Int).Set(s.Gas)}
+	}
+	return stats
+}
+
+// ResetStats discards all collected statistics.
+func ResetStats() {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	opStats = make(map[OpCode]*OpStats)
+}
@@ -14,12 +14,17 @@ import (
 type Vm struct {
 	env Environment
 
+	// gas prices of the repriceable opcodes for the current block
+	gasTable params.GasTable
+
 	logTy  byte
 	logStr string
 
 	err error
 	// For logging
 	debug bool
+	// For opcode statistics
+	collectStats bool
 
 	BreakPoints []int64
 	Stepping    bool
@@ -34,7 +39,7 @@ type Vm struct {
 func New(env Environment) *Vm {
 	lt := LogTyPretty
 
-	return &Vm{debug: Debug, env: env, logTy: lt, Recoverable: true}
+	return &Vm{debug: Debug, env: env, gasTable: env.ChainConfig().GasTable(env.BlockNumber()), logTy: lt, Recoverable: true, collectStats: CollectStats}
 }
 
 func (self *Vm) Run(context *Context, callData []byte) (ret []byte, err error) {
@@ -125,6 +130,10 @@ func (self *Vm) Run(context *Context, callData []byte) (ret []byte, err error) {
 			return context.Return(nil), OOG(gas, tmp)
 		}
 
+		if self.collectStats {
+			recordOp(op, gas)
+		}
+
 		mem.Resize(newMemSize.Uint64())
 
 		switch op {
@@ -732,7 +741,7 @@ func (self *Vm) calculateGasAndSize(context *Context, caller ContextRef, op OpCo
 		gas                 = new(big.Int)
 		newMemSize *big.Int = new(big.Int)
 	)
-	err := baseCheck(op, stack, gas)
+	err := baseCheck(op, stack, gas, self.gasTable)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -768,7 +777,7 @@ func (self *Vm) calculateGasAndSize(context *Context, caller ContextRef, op OpCo
 
 		newMemSize = calcMemSize(mStart, mSize)
 	case EXP:
-		gas.Add(gas, new(big.Int).Mul(big.NewInt(int64(len(stack.data[stack.len()-2].Bytes()))), params.ExpByteGas))
+		gas.Add(gas, new(big.Int).Mul(big.NewInt(int64(len(stack.data[stack.len()-2].Bytes()))), self.gasTable.ExpByte))
 	case SSTORE:
 		err := stack.require(2)
 		if err != nil {
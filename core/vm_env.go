@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 type VMEnv struct {
@@ -40,7 +41,16 @@ func (self *VMEnv) Depth() int               { return self.depth }
 func (self *VMEnv) SetDepth(i int)           { self.depth = i }
 func (self *VMEnv) VmType() vm.Type          { return self.typ }
 func (self *VMEnv) SetVmType(t vm.Type)      { self.typ = t }
+func (self *VMEnv) ChainConfig() *params.ChainConfig {
+	if self.chain == nil {
+		return params.DefaultChainConfig
+	}
+	return self.chain.Config()
+}
 func (self *VMEnv) GetHash(n uint64) common.Hash {
+	if self.chain == nil {
+		return common.Hash{}
+	}
 	if block := self.chain.GetBlockByNumber(n); block != nil {
 		return block.Hash()
 	}
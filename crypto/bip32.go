@@ -0,0 +1,155 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+/*
+HDKey is an extended private key of a BIP-32 hierarchical deterministic
+wallet. Child keys are derived from their parent key and chain code, so
+all keys of a wallet can be recreated from its seed. Only private
+derivation is implemented.
+
+https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+*/
+type HDKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+// HardenedKeyStart is the index of the first hardened child key. Hardened
+// keys are derived from the private instead of the public parent key.
+const HardenedKeyStart uint32 = 0x80000000
+
+// DefaultHDPath is the BIP-44 derivation path of ethereum accounts. The
+// account index is appended to it.
+const DefaultHDPath = "m/44'/60'/0'/0"
+
+var ErrInvalidHDKey = errors.New("invalid HD key, use the next index")
+
+// NewMasterKey derives the master key of the wallet with the given seed.
+func NewMasterKey(seed []byte) (*HDKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(S256().N) >= 0 {
+		return nil, ErrInvalidHDKey
+	}
+	return &HDKey{key: key, chainCode: sum[32:]}, nil
+}
+
+// Child derives the child key with index i.
+func (k *HDKey) Child(i uint32) (*HDKey, error) {
+	var data []byte
+	if i >= HardenedKeyStart {
+		data = append([]byte{0}, k.keyBytes()...)
+	} else {
+		data = k.compressedPubkey()
+	}
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], i)
+	data = append(data, index[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := S256().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, ErrInvalidHDKey
+	}
+	key := tweak.Add(tweak, k.key)
+	key.Mod(key, n)
+	if key.Sign() == 0 {
+		return nil, ErrInvalidHDKey
+	}
+	return &HDKey{key: key, chainCode: sum[32:]}, nil
+}
+
+// Derive derives the key at path relative to k.
+func (k *HDKey) Derive(path DerivationPath) (*HDKey, error) {
+	key := k
+	for _, i := range path {
+		var err error
+		if key, err = key.Child(i); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// PrivateKey returns the ECDSA private key of k.
+func (k *HDKey) PrivateKey() *ecdsa.PrivateKey {
+	return ToECDSA(k.keyBytes())
+}
+
+// ChainCode returns the chain code of k.
+func (k *HDKey) ChainCode() []byte {
+	return k.chainCode
+}
+
+// keyBytes returns the private key as 32 bytes.
+func (k *HDKey) keyBytes() []byte {
+	enc := make([]byte, 32)
+	b := k.key.Bytes()
+	copy(enc[32-len(b):], b)
+	return enc
+}
+
+// compressedPubkey returns the public key in the 33 byte compressed form.
+func (k *HDKey) compressedPubkey() []byte {
+	x, y := S256().ScalarBaseMult(k.keyBytes())
+	enc := make([]byte, 33)
+	enc[0] = 2 + byte(y.Bit(0))
+	b := x.Bytes()
+	copy(enc[33-len(b):], b)
+	return enc
+}
+
+// DerivationPath is a list of child key indexes.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a path like m/44'/60'/0'/0. Hardened indexes
+// are marked with an apostrophe.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	elems := strings.Split(strings.TrimSpace(path), "/")
+	if len(elems) == 0 || elems[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with m", path)
+	}
+	result := make(DerivationPath, 0, len(elems)-1)
+	for _, elem := range elems[1:] {
+		hardened := strings.HasSuffix(elem, "'")
+		index, err := strconv.ParseUint(strings.TrimSuffix(elem, "'"), 10, 32)
+		if err != nil || uint32(index) >= HardenedKeyStart {
+			return nil, fmt.Errorf("invalid derivation path %q: bad index %q", path, elem)
+		}
+		if hardened {
+			index += uint64(HardenedKeyStart)
+		}
+		result = append(result, uint32(index))
+	}
+	return result, nil
+}
+
+func (path DerivationPath) String() string {
+	elems := []string{"m"}
+	for _, i := range path {
+		if i >= HardenedKeyStart {
+			elems = append(elems, fmt.Sprintf("%d'", i-HardenedKeyStart))
+		} else {
+			elems = append(elems, strconv.FormatUint(uint64(i), 10))
+		}
+	}
+	return strings.Join(elems, "/")
+}
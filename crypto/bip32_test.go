@@ -0,0 +1,70 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Test vector 1 of BIP-32.
+func TestHDKeyDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path, key, chainCode string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8", "c783e67b921d2beb8f6b389cc646d7263b4145701dadd2161548a8b078e65e9e"},
+	}
+	for _, test := range tests {
+		path, err := ParseDerivationPath(test.path)
+		if err != nil {
+			t.Fatalf("%s: %v", test.path, err)
+		}
+		key, err := master.Derive(path)
+		if err != nil {
+			t.Fatalf("%s: %v", test.path, err)
+		}
+		if k := hex.EncodeToString(key.keyBytes()); k != test.key {
+			t.Errorf("%s: key %s, want %s", test.path, k, test.key)
+		}
+		if c := hex.EncodeToString(key.ChainCode()); c != test.chainCode {
+			t.Errorf("%s: chain code %s, want %s", test.path, c, test.chainCode)
+		}
+	}
+}
+
+func TestHDKeyEthereumAccount(t *testing.T) {
+	master, _ := NewMasterKey(MnemonicSeed(bip39Tests[0].mnemonic, ""))
+	path, _ := ParseDerivationPath(DefaultHDPath + "/0")
+	key, err := master.Derive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := hex.EncodeToString(PubkeyToAddress(key.PrivateKey().PublicKey))
+	if addr != "9858effd232b4033e47d90003d41ec34ecaeda94" {
+		t.Errorf("wrong address %s", addr)
+	}
+}
+
+func TestDerivationPath(t *testing.T) {
+	path, err := ParseDerivationPath("m/44'/60'/0'/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 4 || path[0] != HardenedKeyStart+44 || path[3] != 0 {
+		t.Errorf("wrong path %v", path)
+	}
+	if path.String() != "m/44'/60'/0'/0" {
+		t.Errorf("wrong string %s", path)
+	}
+	for _, invalid := range []string{"", "44'/60'", "m/x", "m/2147483648"} {
+		if _, err := ParseDerivationPath(invalid); err == nil {
+			t.Errorf("no error for %q", invalid)
+		}
+	}
+}
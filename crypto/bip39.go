@@ -0,0 +1,106 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+/*
+BIP-39 mnemonics encode the entropy of an HD wallet seed as a list of words
+from Bip39Words. Every word encodes 11 bits, the last bits are a checksum
+of the entropy. The seed is derived from the mnemonic and an optional
+passphrase with PBKDF2, so every passphrase yields a different wallet.
+
+Passphrases are used as given. The reference implementation normalises
+them to NFKD, which only makes a difference for non-ASCII passphrases.
+
+https://github.com/bitcoin/bips/blob/master/bip-0039.mediawiki
+*/
+
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+var bip39WordIndex = make(map[string]int, len(Bip39Words))
+
+func init() {
+	for i, word := range Bip39Words {
+		bip39WordIndex[word] = i
+	}
+}
+
+// NewMnemonic generates a mnemonic for bits bits of entropy read from rand.
+// bits must be a multiple of 32 between 128 and 256.
+func NewMnemonic(rand io.Reader, bits int) (string, error) {
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return "", fmt.Errorf("invalid entropy size %d", bits)
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := io.ReadFull(rand, entropy); err != nil {
+		return "", err
+	}
+	return EntropyToMnemonic(entropy)
+}
+
+// EntropyToMnemonic encodes entropy of 16 to 32 bytes in multiples of 4 as
+// mnemonic.
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	if len(entropy)%4 != 0 || len(entropy) < 16 || len(entropy) > 32 {
+		return "", fmt.Errorf("invalid entropy length %d", len(entropy))
+	}
+	// append the checksum bits to the entropy
+	checksumBits := uint(len(entropy) / 4)
+	hash := sha256.Sum256(entropy)
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, checksumBits)
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	words := make([]string, (uint(len(entropy))*8+checksumBits)/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = Bip39Words[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes mnemonic, verifying its checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, ErrInvalidMnemonic
+	}
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := bip39WordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("%v: unknown word %q", ErrInvalidMnemonic, word)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+	checksumBits := uint(len(words) / 3)
+	checksum := new(big.Int).And(data, big.NewInt(1<<checksumBits-1)).Int64()
+	data.Rsh(data, checksumBits)
+
+	entropy := make([]byte, len(words)*4/3)
+	enc := data.Bytes()
+	copy(entropy[len(entropy)-len(enc):], enc)
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, fmt.Errorf("%v: checksum mismatch", ErrInvalidMnemonic)
+	}
+	return entropy, nil
+}
+
+// MnemonicSeed derives the 64 byte HD wallet seed from mnemonic and
+// passphrase. mnemonic isn't validated.
+func MnemonicSeed(mnemonic, passphrase string) []byte {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+}
@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Test vectors from the BIP-39 reference implementation.
+var bip39Tests = []struct {
+	entropy, mnemonic, seed string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"80808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		"d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		"f585c11aec520db57dd353c69554b21a89b20fb0650966fa0a9d6f74fd989d8f",
+		"void come effort suffer camp survey warrior heavy shoot primary clutch crush open amazing screen patrol group space point ten exist slush involve unfold",
+		"01f5bced59dec48e362f2c45b5de68b9fd6c92c6634f44d6d40aab69056506f0e35524a518034ddc1192e1dacd32c1ed3eaa3c3b131c88ed8e7e54c49a5d0998",
+	},
+}
+
+func TestBip39Vectors(t *testing.T) {
+	for i, test := range bip39Tests {
+		entropy, _ := hex.DecodeString(test.entropy)
+		mnemonic, err := EntropyToMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if mnemonic != test.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch\ngot  %s\nwant %s", i, mnemonic, test.mnemonic)
+		}
+		decoded, err := MnemonicToEntropy(test.mnemonic)
+		if err != nil {
+			t.Errorf("test %d: decode error: %v", i, err)
+		} else if !bytes.Equal(decoded, entropy) {
+			t.Errorf("test %d: decoded entropy %x", i, decoded)
+		}
+		if seed := hex.EncodeToString(MnemonicSeed(test.mnemonic, "TREZOR")); seed != test.seed {
+			t.Errorf("test %d: seed mismatch\ngot  %s\nwant %s", i, seed, test.seed)
+		}
+	}
+}
+
+func TestMnemonicToEntropyInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", // bad checksum
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon foobar",
+	}
+	for _, mnemonic := range invalid {
+		if _, err := MnemonicToEntropy(mnemonic); err == nil {
+			t.Errorf("no error for %q", mnemonic)
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	if _, err := NewMnemonic(bytes.NewReader(make([]byte, 32)), 100); err == nil {
+		t.Error("expected error for invalid entropy size")
+	}
+	mnemonic, err := NewMnemonic(bytes.NewReader(make([]byte, 32)), 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mnemonic != bip39Tests[0].mnemonic {
+		t.Errorf("got %q", mnemonic)
+	}
+}
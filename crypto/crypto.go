@@ -72,6 +72,13 @@ func Ecrecover(hash, sig []byte) ([]byte, error) {
 	return secp256k1.RecoverPubkey(hash, sig)
 }
 
+// MessageHash returns the hash which is signed for message by
+// personal_sign. The prefix ensures the signature can't be used to sign a
+// transaction.
+func MessageHash(message []byte) []byte {
+	return Sha3([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+}
+
 // New methods using proper ecdsa keys from the stdlib
 func ToECDSA(prv []byte) *ecdsa.PrivateKey {
 	if len(prv) == 0 {
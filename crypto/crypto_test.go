@@ -39,6 +39,12 @@ func TestRipemd160(t *testing.T) {
 	checkhash(t, "Ripemd160", Ripemd160, msg, exp)
 }
 
+func TestMessageHash(t *testing.T) {
+	msg := []byte("Hello World")
+	exp, _ := hex.DecodeString("a1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2")
+	checkhash(t, "MessageHash", MessageHash, msg, exp)
+}
+
 func checkhash(t *testing.T, name string, f func([]byte) []byte, msg, exp []byte) {
 	sum := f(msg)
 	if bytes.Compare(exp, sum) != 0 {
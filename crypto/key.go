@@ -51,6 +51,8 @@ type cipherJSON struct {
 	Salt       []byte
 	IV         []byte
 	CipherText []byte
+	ScryptN    int `json:",omitempty"` // DefaultScryptN if 0
+	ScryptP    int `json:",omitempty"`
 }
 
 type encryptedKeyJSON struct {
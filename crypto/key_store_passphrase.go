@@ -29,7 +29,8 @@ the private key is encrypted and on disk uses another JSON encoding.
 Cryptography:
 
 1. Encryption key is scrypt derived key from user passphrase. Scrypt parameters
-   (work factors) [1][2] are defined as constants below.
+   (work factors) [1][2] default to the constants below and are stored with
+   the ciphertext. Key files without them use the defaults.
 2. Scrypt salt is 32 random bytes from CSPRNG. It is appended to ciphertext.
 3. Checksum is SHA3 of the private key bytes.
 4. Plaintext is concatenation of private key bytes and checksum.
@@ -70,6 +71,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path"
@@ -81,18 +83,33 @@ import (
 
 const (
 	// 2^18 / 8 / 1 uses 256MB memory and approx 1s CPU time on a modern CPU.
-	scryptN     = 1 << 18
-	scryptr     = 8
-	scryptp     = 1
-	scryptdkLen = 32
+	DefaultScryptN = 1 << 18
+	DefaultScryptP = 1
+	scryptr        = 8
+	scryptdkLen    = 32
 )
 
 type keyStorePassphrase struct {
 	keysDirPath string
+	scryptN     int
+	scryptP     int
 }
 
 func NewKeyStorePassphrase(path string) KeyStore2 {
-	return &keyStorePassphrase{path}
+	return &keyStorePassphrase{path, DefaultScryptN, DefaultScryptP}
+}
+
+// NewKeyStorePassphraseParams creates a key store which encrypts new keys
+// with the scrypt parameters N and P. N must be a power of two, lower
+// values make keys faster to unlock but easier to brute force.
+func NewKeyStorePassphraseParams(path string, scryptN, scryptP int) (KeyStore2, error) {
+	if scryptN <= 1 || scryptN&(scryptN-1) != 0 {
+		return nil, fmt.Errorf("scrypt N must be a power of two greater than 1, got %d", scryptN)
+	}
+	if scryptP < 1 {
+		return nil, fmt.Errorf("scrypt P must be positive, got %d", scryptP)
+	}
+	return &keyStorePassphrase{path, scryptN, scryptP}, nil
 }
 
 func (ks keyStorePassphrase) GenerateNewKey(rand io.Reader, auth string) (key *Key, err error) {
@@ -117,36 +134,14 @@ func (ks keyStorePassphrase) GetKeyAddresses() (addresses [][]byte, err error) {
 }
 
 func (ks keyStorePassphrase) StoreKey(key *Key, auth string) (err error) {
-	authArray := []byte(auth)
-	salt := randentropy.GetEntropyMixed(32)
-	derivedKey, err := scrypt.Key(authArray, salt, scryptN, scryptr, scryptp, scryptdkLen)
+	cipherStruct, err := encryptPassphrase(FromECDSA(key.PrivateKey), auth, ks.scryptN, ks.scryptP)
 	if err != nil {
 		return err
 	}
-
-	keyBytes := FromECDSA(key.PrivateKey)
-	keyBytesHash := Sha3(keyBytes)
-	toEncrypt := PKCS7Pad(append(keyBytes, keyBytesHash...))
-
-	AES256Block, err := aes.NewCipher(derivedKey)
-	if err != nil {
-		return err
-	}
-
-	iv := randentropy.GetEntropyMixed(aes.BlockSize) // 16
-	AES256CBCEncrypter := cipher.NewCBCEncrypter(AES256Block, iv)
-	cipherText := make([]byte, len(toEncrypt))
-	AES256CBCEncrypter.CryptBlocks(cipherText, toEncrypt)
-
-	cipherStruct := cipherJSON{
-		salt,
-		iv,
-		cipherText,
-	}
 	keyStruct := encryptedKeyJSON{
 		key.Id,
 		key.Address,
-		cipherStruct,
+		*cipherStruct,
 	}
 	keyJSON, err := json.Marshal(keyStruct)
 	if err != nil {
@@ -177,24 +172,76 @@ func DecryptKey(ks keyStorePassphrase, keyAddr []byte, auth string) (keyBytes []
 	err = json.Unmarshal(fileContent, keyProtected)
 
 	keyId = keyProtected.Id
-	salt := keyProtected.Crypto.Salt
-	iv := keyProtected.Crypto.IV
-	cipherText := keyProtected.Crypto.CipherText
+	keyBytes, err = decryptPassphrase(&keyProtected.Crypto, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keyBytes, keyId, err
+}
+
+// EncryptPassphrase encrypts data with auth the way the passphrase key
+// store encrypts keys. The result is JSON encoded.
+func EncryptPassphrase(data []byte, auth string) ([]byte, error) {
+	c, err := encryptPassphrase(data, auth, DefaultScryptN, DefaultScryptP)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+// DecryptPassphrase decrypts the output of EncryptPassphrase.
+func DecryptPassphrase(enc []byte, auth string) ([]byte, error) {
+	c := new(cipherJSON)
+	if err := json.Unmarshal(enc, c); err != nil {
+		return nil, err
+	}
+	return decryptPassphrase(c, auth)
+}
 
+func encryptPassphrase(data []byte, auth string, scryptN, scryptP int) (*cipherJSON, error) {
 	authArray := []byte(auth)
-	derivedKey, err := scrypt.Key(authArray, salt, scryptN, scryptr, scryptp, scryptdkLen)
+	salt := randentropy.GetEntropyMixed(32)
+	derivedKey, err := scrypt.Key(authArray, salt, scryptN, scryptr, scryptP, scryptdkLen)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	plainText, err := aesCBCDecrypt(derivedKey, cipherText, iv)
+
+	toEncrypt := PKCS7Pad(append(data, Sha3(data)...))
+
+	AES256Block, err := aes.NewCipher(derivedKey)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	keyBytes = plainText[:len(plainText)-32]
-	keyBytesHash := plainText[len(plainText)-32:]
-	if !bytes.Equal(Sha3(keyBytes), keyBytesHash) {
-		err = errors.New("Decryption failed: checksum mismatch")
-		return nil, nil, err
+
+	iv := randentropy.GetEntropyMixed(aes.BlockSize) // 16
+	AES256CBCEncrypter := cipher.NewCBCEncrypter(AES256Block, iv)
+	cipherText := make([]byte, len(toEncrypt))
+	AES256CBCEncrypter.CryptBlocks(cipherText, toEncrypt)
+
+	return &cipherJSON{salt, iv, cipherText, scryptN, scryptP}, nil
+}
+
+func decryptPassphrase(c *cipherJSON, auth string) ([]byte, error) {
+	scryptN, scryptP := c.ScryptN, c.ScryptP
+	if scryptN == 0 {
+		scryptN, scryptP = DefaultScryptN, DefaultScryptP
 	}
-	return keyBytes, keyId, err
+	authArray := []byte(auth)
+	derivedKey, err := scrypt.Key(authArray, c.Salt, scryptN, scryptr, scryptP, scryptdkLen)
+	if err != nil {
+		return nil, err
+	}
+	plainText, err := aesCBCDecrypt(derivedKey, c.CipherText, c.IV)
+	if err != nil {
+		return nil, err
+	}
+	if len(plainText) < 32 {
+		return nil, errors.New("Decryption failed: plaintext too short")
+	}
+	data := plainText[:len(plainText)-32]
+	dataHash := plainText[len(plainText)-32:]
+	if !bytes.Equal(Sha3(data), dataHash) {
+		return nil, errors.New("Decryption failed: checksum mismatch")
+	}
+	return data, nil
 }
@@ -24,6 +24,7 @@
 package crypto
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -31,6 +32,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
+	"time"
 )
 
 // TODO: rename to KeyStore when replacing existing KeyStore
@@ -109,21 +112,63 @@ func WriteKeyFile(addr []byte, keysDirPath string, content []byte) (err error) {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(keyFilePath, content, 0600) // read, write for user
+	// write to a temporary file first so a failed rewrite can't lose the key
+	tmpPath := keyFilePath + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, content, 0600); err != nil { // read, write for user
+		return err
+	}
+	// rewritten keys keep their modification time, which orders the keys
+	if old, err := os.Stat(keyFilePath); err == nil {
+		if err := os.Chtimes(tmpPath, old.ModTime(), old.ModTime()); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+	return os.Rename(tmpPath, keyFilePath)
 }
 
+// GetKeyAddresses returns the addresses of the keys in keysDirPath ordered
+// by the modification time of their key files, which is the creation time
+// as WriteKeyFile keeps it when a key file is rewritten. Keys created at the same time are
+// ordered by address.
 func GetKeyAddresses(keysDirPath string) (addresses [][]byte, err error) {
 	fileInfos, err := ioutil.ReadDir(keysDirPath)
 	if err != nil {
 		return nil, err
 	}
-	addresses = make([][]byte, len(fileInfos))
-	for i, fileInfo := range fileInfos {
+	keys := make([]keyFileInfo, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
 		address, err := hex.DecodeString(fileInfo.Name())
 		if err != nil {
 			continue
 		}
-		addresses[i] = address
+		created := fileInfo.ModTime()
+		if stat, err := os.Stat(path.Join(keysDirPath, fileInfo.Name(), fileInfo.Name())); err == nil {
+			created = stat.ModTime()
+		}
+		keys = append(keys, keyFileInfo{address, created})
+	}
+	sort.Sort(keysByCreation(keys))
+
+	addresses = make([][]byte, len(keys))
+	for i, key := range keys {
+		addresses[i] = key.address
+	}
+	return addresses, nil
+}
+
+type keyFileInfo struct {
+	address []byte
+	created time.Time
+}
+
+type keysByCreation []keyFileInfo
+
+func (s keysByCreation) Len() int      { return len(s) }
+func (s keysByCreation) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s keysByCreation) Less(i, j int) bool {
+	if !s[i].created.Equal(s[j].created) {
+		return s[i].created.Before(s[j].created)
 	}
-	return addresses, err
+	return bytes.Compare(s[i].address, s[j].address) < 0
 }
@@ -1,10 +1,17 @@
 package crypto
 
 import (
-	"github.com/ethereum/go-ethereum/crypto/randentropy"
-	"github.com/ethereum/go-ethereum/common"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/randentropy"
 )
 
 func TestKeyStorePlain(t *testing.T) {
@@ -97,3 +104,73 @@ func TestImportPreSaleKey(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestGetKeyAddressesOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// write the keys in descending address order, oldest first
+	addrs := [][]byte{{3}, {2}, {1}}
+	for i, addr := range addrs {
+		if err := WriteKeyFile(addr, dir, []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+		name := hex.EncodeToString(addr)
+		mtime := time.Unix(int64(1000+i), 0)
+		os.Chtimes(path.Join(dir, name, name), mtime, mtime)
+	}
+	ioutil.WriteFile(path.Join(dir, "README"), nil, 0600)
+
+	got, err := GetKeyAddresses(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, addrs) {
+		t.Errorf("got %x, want %x", got, addrs)
+	}
+}
+
+func TestKeyStorePassphraseScryptParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := NewKeyStorePassphraseParams(dir, 1000, 1); err == nil {
+		t.Error("expected error for N not a power of two")
+	}
+	ks, err := NewKeyStorePassphraseParams(dir, 1<<10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1, err := ks.GenerateNewKey(randentropy.Reader, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the parameters are read from the key file
+	k2, err := NewKeyStorePassphrase(dir).GetKey(k1.Address, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(k1.PrivateKey, k2.PrivateKey) {
+		t.Error("wrong key")
+	}
+
+	// key files without parameters use the defaults
+	enc, err := encryptPassphrase(FromECDSA(k1.PrivateKey), "foo", DefaultScryptN, DefaultScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.ScryptN, enc.ScryptP = 0, 0
+	keyJSON, _ := json.Marshal(encryptedKeyJSON{k1.Id, k1.Address, *enc})
+	if err := WriteKeyFile(k1.Address, dir, keyJSON); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.GetKey(k1.Address, "foo"); err != nil {
+		t.Errorf("legacy key file: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package secp256k1
+
+// Pure Go implementation of the secp256k1 operations. It is used when the
+// package is built without cgo (or with the nocgo build tag) and is always
+// compiled so the tests can check it against the C library.
+//
+// The arithmetic uses math/big and is not constant time. Builds which sign
+// with long lived keys on shared machines should use the C library.
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/randentropy"
+)
+
+var (
+	curveP, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	curveN, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	curveGx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	curveGy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	curveB     = big.NewInt(7)
+	halfN      = new(big.Int).Rsh(curveN, 1)
+	// exponent of the square root modulo p, which is 3 mod 4
+	sqrtExp = new(big.Int).Rsh(new(big.Int).Add(curveP, big.NewInt(1)), 2)
+)
+
+// jacobianPoint is a point (x/z², y/z³), z = 0 is the point at infinity.
+type jacobianPoint struct {
+	x, y, z *big.Int
+}
+
+func newAffinePoint(x, y *big.Int) *jacobianPoint {
+	return &jacobianPoint{new(big.Int).Set(x), new(big.Int).Set(y), big.NewInt(1)}
+}
+
+func (pt *jacobianPoint) isInfinity() bool {
+	return pt.z.Sign() == 0
+}
+
+func (pt *jacobianPoint) affine() (x, y *big.Int) {
+	zinv := new(big.Int).ModInverse(pt.z, curveP)
+	zinv2 := new(big.Int).Mul(zinv, zinv)
+	x = new(big.Int).Mul(pt.x, zinv2)
+	x.Mod(x, curveP)
+	y = new(big.Int).Mul(pt.y, zinv2.Mul(zinv2, zinv))
+	y.Mod(y, curveP)
+	return x, y
+}
+
+// double uses the dbl-2009-l formulas for a = 0.
+func (pt *jacobianPoint) double() *jacobianPoint {
+	if pt.isInfinity() || pt.y.Sign() == 0 {
+		return &jacobianPoint{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	a := new(big.Int).Mul(pt.x, pt.x)
+	a.Mod(a, curveP)
+	b := new(big.Int).Mul(pt.y, pt.y)
+	b.Mod(b, curveP)
+	c := new(big.Int).Mul(b, b)
+	c.Mod(c, curveP)
+	d := new(big.Int).Add(pt.x, b)
+	d.Mul(d, d).Sub(d, a).Sub(d, c).Lsh(d, 1).Mod(d, curveP)
+	e := new(big.Int).Mul(a, big.NewInt(3))
+	f := new(big.Int).Mul(e, e)
+
+	x := new(big.Int).Sub(f, new(big.Int).Lsh(d, 1))
+	x.Mod(x, curveP)
+	y := new(big.Int).Sub(d, x)
+	y.Mul(y, e).Sub(y, c.Lsh(c, 3))
+	y.Mod(y, curveP)
+	z := new(big.Int).Mul(pt.y, pt.z)
+	z.Lsh(z, 1).Mod(z, curveP)
+	return &jacobianPoint{x, y, z}
+}
+
+// add uses the add-2007-bl formulas.
+func (pt *jacobianPoint) add(q *jacobianPoint) *jacobianPoint {
+	if pt.isInfinity() {
+		return q
+	}
+	if q.isInfinity() {
+		return pt
+	}
+	z1z1 := new(big.Int).Mul(pt.z, pt.z)
+	z1z1.Mod(z1z1, curveP)
+	z2z2 := new(big.Int).Mul(q.z, q.z)
+	z2z2.Mod(z2z2, curveP)
+	u1 := new(big.Int).Mul(pt.x, z2z2)
+	u1.Mod(u1, curveP)
+	u2 := new(big.Int).Mul(q.x, z1z1)
+	u2.Mod(u2, curveP)
+	s1 := new(big.Int).Mul(pt.y, q.z)
+	s1.Mul(s1, z2z2).Mod(s1, curveP)
+	s2 := new(big.Int).Mul(q.y, pt.z)
+	s2.Mul(s2, z1z1).Mod(s2, curveP)
+
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, curveP)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, curveP)
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return pt.double()
+		}
+		return &jacobianPoint{new(big.Int), new(big.Int), new(big.Int)}
+	}
+	i := new(big.Int).Lsh(h, 1)
+	i.Mul(i, i)
+	j := new(big.Int).Mul(h, i)
+	r.Lsh(r, 1)
+	v := new(big.Int).Mul(u1, i)
+
+	x := new(big.Int).Mul(r, r)
+	x.Sub(x, j).Sub(x, new(big.Int).Lsh(v, 1)).Mod(x, curveP)
+	y := new(big.Int).Sub(v, x)
+	y.Mul(y, r).Sub(y, s1.Mul(s1, j).Lsh(s1, 1)).Mod(y, curveP)
+	z := new(big.Int).Add(pt.z, q.z)
+	z.Mul(z, z).Sub(z, z1z1).Sub(z, z2z2).Mul(z, h).Mod(z, curveP)
+	return &jacobianPoint{x, y, z}
+}
+
+// mul computes k*pt with a fixed window of 4 bits.
+func (pt *jacobianPoint) mul(k *big.Int) *jacobianPoint {
+	var table [16]*jacobianPoint
+	table[0] = &jacobianPoint{new(big.Int), new(big.Int), new(big.Int)}
+	for i := 1; i < len(table); i++ {
+		table[i] = table[i-1].add(pt)
+	}
+	result := table[0]
+	for i := (k.BitLen() + 3) / 4 * 4; i > 0; i -= 4 {
+		result = result.double().double().double().double()
+		w := k.Bit(i-1)<<3 | k.Bit(i-2)<<2 | k.Bit(i-3)<<1 | k.Bit(i-4)
+		result = result.add(table[w])
+	}
+	return result
+}
+
+func baseMul(k *big.Int) *jacobianPoint {
+	return newAffinePoint(curveGx, curveGy).mul(k)
+}
+
+func marshalPubkey(x, y *big.Int) []byte {
+	pub := make([]byte, 65)
+	pub[0] = 4
+	xb, yb := x.Bytes(), y.Bytes()
+	copy(pub[33-len(xb):33], xb)
+	copy(pub[65-len(yb):], yb)
+	return pub
+}
+
+func isOnCurve(x, y *big.Int) bool {
+	if x.Cmp(curveP) >= 0 || y.Cmp(curveP) >= 0 {
+		return false
+	}
+	// y² = x³ + 7
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, curveP)
+	return y2.Cmp(curveRHS(x)) == 0
+}
+
+func curveRHS(x *big.Int) *big.Int {
+	x3 := new(big.Int).Mul(x, x)
+	x3.Mul(x3, x).Add(x3, curveB)
+	return x3.Mod(x3, curveP)
+}
+
+func goVerifySeckeyValidity(seckey []byte) error {
+	if len(seckey) != 32 {
+		return errors.New("priv key is not 32 bytes")
+	}
+	d := new(big.Int).SetBytes(seckey)
+	if d.Sign() == 0 || d.Cmp(curveN) >= 0 {
+		return errors.New("invalid seckey")
+	}
+	return nil
+}
+
+func goVerifyPubkeyValidity(pubkey []byte) error {
+	if len(pubkey) != 65 {
+		return errors.New("pub key is not 65 bytes")
+	}
+	if pubkey[0] != 4 || !isOnCurve(new(big.Int).SetBytes(pubkey[1:33]), new(big.Int).SetBytes(pubkey[33:])) {
+		return errors.New("invalid pubkey")
+	}
+	return nil
+}
+
+func goGeneratePubKey(seckey []byte) ([]byte, error) {
+	if err := goVerifySeckeyValidity(seckey); err != nil {
+		return nil, err
+	}
+	return marshalPubkey(baseMul(new(big.Int).SetBytes(seckey)).affine()), nil
+}
+
+func goGenerateKeyPair() ([]byte, []byte) {
+	for {
+		seckey := randentropy.GetEntropyMixed(32)
+		if pubkey, err := goGeneratePubKey(seckey); err == nil {
+			return pubkey, seckey
+		}
+	}
+}
+
+// goSign creates a signature [R || S || V] with S in the lower half of the
+// group order, like the C library.
+func goSign(msg []byte, seckey []byte) ([]byte, error) {
+	if len(msg) != 32 {
+		return nil, errors.New("message is not 32 bytes")
+	}
+	if err := goVerifySeckeyValidity(seckey); err != nil {
+		return nil, errors.New("Invalid secret key")
+	}
+	var (
+		d = new(big.Int).SetBytes(seckey)
+		z = new(big.Int).SetBytes(msg)
+	)
+	for {
+		k := new(big.Int).SetBytes(randentropy.GetEntropyMixed(32))
+		if k.Sign() == 0 || k.Cmp(curveN) >= 0 {
+			continue
+		}
+		rx, ry := baseMul(k).affine()
+		r := new(big.Int).Mod(rx, curveN)
+		if r.Sign() == 0 {
+			continue
+		}
+		// s = (z + r*d) / k
+		s := new(big.Int).Mul(r, d)
+		s.Add(s, z).Mul(s, k.ModInverse(k, curveN)).Mod(s, curveN)
+		if s.Sign() == 0 {
+			continue
+		}
+		recid := byte(ry.Bit(0))
+		if rx.Cmp(curveN) >= 0 {
+			recid |= 2
+		}
+		if s.Cmp(halfN) > 0 {
+			s.Sub(curveN, s)
+			recid ^= 1
+		}
+		sig := make([]byte, 65)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):64], sb)
+		sig[64] = recid
+		return sig, nil
+	}
+}
+
+func goRecoverPubkey(msg []byte, sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, errors.New("Invalid signature length")
+	}
+	if len(msg) != 32 {
+		return nil, errors.New("message is not 32 bytes")
+	}
+	recid := sig[64]
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if recid >= 4 || r.Sign() == 0 || r.Cmp(curveN) >= 0 || s.Sign() == 0 || s.Cmp(curveN) >= 0 {
+		return nil, errors.New("Failed to recover public key")
+	}
+
+	// the point R with x coordinate r (+ n) and the parity given by recid
+	rx := new(big.Int).Set(r)
+	if recid&2 != 0 {
+		rx.Add(rx, curveN)
+		if rx.Cmp(curveP) >= 0 {
+			return nil, errors.New("Failed to recover public key")
+		}
+	}
+	rhs := curveRHS(rx)
+	ry := new(big.Int).Exp(rhs, sqrtExp, curveP)
+	if ry2 := new(big.Int).Mul(ry, ry); ry2.Mod(ry2, curveP).Cmp(rhs) != 0 {
+		return nil, errors.New("Failed to recover public key")
+	}
+	if ry.Bit(0) != uint(recid&1) {
+		ry.Sub(curveP, ry)
+	}
+
+	// Q = (s*R - z*G) / r
+	rinv := new(big.Int).ModInverse(r, curveN)
+	u1 := new(big.Int).Mul(new(big.Int).SetBytes(msg), rinv)
+	u1.Neg(u1).Mod(u1, curveN)
+	u2 := new(big.Int).Mul(s, rinv)
+	u2.Mod(u2, curveN)
+	q := baseMul(u1).add(newAffinePoint(rx, ry).mul(u2))
+	if q.isInfinity() {
+		return nil, errors.New("Failed to recover public key")
+	}
+	return marshalPubkey(q.affine()), nil
+}
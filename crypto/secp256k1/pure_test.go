@@ -0,0 +1,79 @@
+// +build cgo,!nocgo
+
+package secp256k1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/randentropy"
+)
+
+// These tests check the pure Go implementation against the C library.
+
+func TestPureGoPubkey(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		_, seckey := GenerateKeyPair()
+		want, _ := GeneratePubKey(seckey)
+		got, err := goGeneratePubKey(seckey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("pubkey mismatch for seckey %x\ngot:  %x\nwant: %x", seckey, got, want)
+		}
+		if err := goVerifyPubkeyValidity(got); err != nil {
+			t.Errorf("valid pubkey %x rejected: %v", got, err)
+		}
+	}
+}
+
+func TestPureGoSignRecover(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		pubkey, seckey := GenerateKeyPair()
+		msg := randentropy.GetEntropyMixed(32)
+
+		// C signature, Go recovery
+		sig, _ := Sign(msg, seckey)
+		got, err := goRecoverPubkey(msg, sig)
+		if err != nil || !bytes.Equal(got, pubkey) {
+			t.Fatalf("Go recovery of C signature %x: %x, %v", sig, got, err)
+		}
+
+		// Go signature, C recovery
+		sig, err = goSign(msg, seckey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifySignatureValidity(sig) {
+			t.Fatalf("Go signature %x not valid", sig)
+		}
+		if err := VerifySignature(msg, sig, pubkey); err != nil {
+			t.Fatalf("C recovery of Go signature %x: %v", sig, err)
+		}
+	}
+}
+
+func TestPureGoInvalid(t *testing.T) {
+	_, seckey := GenerateKeyPair()
+	msg := randentropy.GetEntropyMixed(32)
+	sig, _ := Sign(msg, seckey)
+
+	for i := 0; i < 100; i++ {
+		bad := randSig()
+		want, werr := RecoverPubkey(msg, bad)
+		got, gerr := goRecoverPubkey(msg, bad)
+		if (werr == nil) != (gerr == nil) || !bytes.Equal(got, want) {
+			t.Fatalf("recovery of %x: got %x (%v), want %x (%v)", bad, got, gerr, want, werr)
+		}
+	}
+	if _, err := goRecoverPubkey(msg, append(sig[:64:64], 4)); err == nil {
+		t.Error("recovered with invalid recovery id")
+	}
+	if err := goVerifySeckeyValidity(make([]byte, 32)); err == nil {
+		t.Error("zero seckey accepted")
+	}
+	if err := goVerifyPubkeyValidity(append([]byte{4}, make([]byte, 64)...)); err == nil {
+		t.Error("pubkey not on the curve accepted")
+	}
+}
@@ -0,0 +1,34 @@
+// +build !cgo nocgo
+
+package secp256k1
+
+// The pure Go implementation is used when cgo is not available, e.g. when
+// cross compiling, or when building with the nocgo tag.
+
+func Stop() {}
+
+func GenerateKeyPair() ([]byte, []byte) {
+	return goGenerateKeyPair()
+}
+
+func GeneratePubKey(seckey []byte) ([]byte, error) {
+	return goGeneratePubKey(seckey)
+}
+
+func Sign(msg []byte, seckey []byte) ([]byte, error) {
+	return goSign(msg, seckey)
+}
+
+func VerifySeckeyValidity(seckey []byte) error {
+	return goVerifySeckeyValidity(seckey)
+}
+
+func VerifyPubkeyValidity(pubkey []byte) error {
+	return goVerifyPubkeyValidity(pubkey)
+}
+
+//recovers the public key from the signature
+//recovery of pubkey means correct signature
+func RecoverPubkey(msg []byte, sig []byte) ([]byte, error) {
+	return goRecoverPubkey(msg, sig)
+}
@@ -0,0 +1,60 @@
+package secp256k1
+
+import (
+	"bytes"
+	"errors"
+)
+
+func VerifySignatureValidity(sig []byte) bool {
+	//64+1
+	if len(sig) != 65 {
+		return false
+	}
+	//malleability check, highest bit must be 1
+	if (sig[32] & 0x80) == 0x80 {
+		return false
+	}
+	//recovery id check
+	if sig[64] >= 4 {
+		return false
+	}
+
+	return true
+}
+
+//for compressed signatures, does not need pubkey
+func VerifySignature(msg []byte, sig []byte, pubkey1 []byte) error {
+	if msg == nil || sig == nil || pubkey1 == nil {
+		return errors.New("inputs must be non-nil")
+	}
+	if len(sig) != 65 {
+		return errors.New("invalid signature length")
+	}
+	if len(pubkey1) != 65 {
+		return errors.New("Invalid public key length")
+	}
+
+	//to enforce malleability, highest bit of S must be 0
+	//S starts at 32nd byte
+	if (sig[32] & 0x80) == 0x80 { //highest bit must be 1
+		return errors.New("Signature not malleable")
+	}
+
+	if sig[64] >= 4 {
+		return errors.New("Recover byte invalid")
+	}
+
+	// if pubkey recovered, signature valid
+	pubkey2, err := RecoverPubkey(msg, sig)
+	if err != nil {
+		return err
+	}
+	if len(pubkey2) != 65 {
+		return errors.New("Invalid recovered public key length")
+	}
+	if !bytes.Equal(pubkey1, pubkey2) {
+		return errors.New("Public key does not match recovered public key")
+	}
+
+	return nil
+}
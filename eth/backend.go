@@ -3,13 +3,19 @@ package eth
 import (
 	"crypto/ecdsa"
 	"fmt"
-	"math"
 	"path"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/flock"
+	"github.com/ethereum/go-ethereum/common/release"
+	"github.com/ethereum/go-ethereum/common/version"
+	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -23,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/nat"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/whisper"
 )
 
@@ -35,30 +42,59 @@ var (
 		// ETH/DEV cpp-ethereum (poc-9.ethdev.com)
 		discover.MustParseNode("enode://487611428e6c99a11a9795a6abe7b529e81315ca6aad66e2a2fc76e3adf263faba0d35466c2f8f68d561dbefa8878d4df5f1f2ddb1fbeab7f42ffb8cd328bd4a@5.1.83.226:30303"),
 	}
+
+	// trusted block hashes of the main network by number
+	defaultCheckpoints = map[uint64]common.Hash{}
 )
 
 type Config struct {
-	Name            string
-	ProtocolVersion int
-	NetworkId       int
+	Name string
+	// ProtocolVersions are the eth protocol versions offered to peers.
+	// The highest one shared with a peer is used. If empty, all
+	// supported versions are offered.
+	ProtocolVersions []int
+	NetworkId        int
 
 	BlockChainVersion  int
 	SkipBcVersionCheck bool // e.g. blockchain export
 
+	// ChainConfig sets the protocol rules of the chain, e.g. the
+	// difficulty algorithm of a private network. If nil,
+	// params.DefaultChainConfig is used.
+	ChainConfig *params.ChainConfig
+
 	DataDir  string
 	LogFile  string
 	LogLevel int
 	LogJSON  string
 	VmDebug  bool
+	VmStats  bool
 	NatSpec  bool
 
-	MaxPeers int
-	Port     string
+	MaxPeers  int
+	DialRatio int // Inbound peers can't take the last 1/DialRatio of the peer slots
+	Port      string
 
 	// This should be a space-separated list of
 	// discovery node URLs.
 	BootNodes string
 
+	// This should be a comma-separated list of number=hash
+	// pairs of trusted blocks. If empty, the defaults are used.
+	Checkpoints string
+
+	// TxLookup selects the transaction indexes maintained during block
+	// processing: "basic" only indexes transactions by hash, "full" also
+	// indexes them by sender and recipient address.
+	TxLookup string
+
+	// MaxTxSize, MaxBlockSize and MaxMsgSize limit the encoded size of
+	// transactions, blocks and messages accepted from peers. Oversized
+	// data is rejected before it is decoded. Zero keeps the default.
+	MaxTxSize    uint64
+	MaxBlockSize uint64
+	MaxMsgSize   uint32
+
 	// This key is used to identify the node on the network.
 	// If nil, an ephemeral key is used.
 	NodeKey *ecdsa.PrivateKey
@@ -67,32 +103,119 @@ type Config struct {
 	Shh  bool
 	Dial bool
 
+	// ShhPassphrase encrypts the whisper identities and symmetric keys saved
+	// in the data directory. If empty, they are encrypted with the node key.
+	ShhPassphrase string
+
 	Etherbase      string
 	MinerThreads   int
 	AccountManager *accounts.Manager
 
+	// MinerNoSyncPause keeps the miner assembling blocks and mining while
+	// the chain is synchronising. By default both are paused during a sync.
+	MinerNoSyncPause bool
+
 	// NewDB is used to create databases.
-	// If nil, the default is to create leveldb databases on disk.
+	// If nil, the default is to create DatabaseEngine databases on disk.
 	NewDB func(path string) (common.Database, error)
+
+	// DatabaseEngine is one of the engines supported by ethdb.Open,
+	// LevelDB if empty.
+	DatabaseEngine string
+
+	// AncientThreshold is the number of blocks behind the head after which
+	// blocks and receipts are moved to the freezer. Zero disables freezing,
+	// already frozen blocks remain readable.
+	AncientThreshold uint64
+
+	// NoDataDirLock disables locking the data directory. Only for setups
+	// which deliberately let several processes use the same directory.
+	NoDataDirLock bool
+
+	// UpdateURL is the URL of a release manifest signed by UpdateSigner.
+	// If set, the manifest is checked periodically for releases newer than
+	// Version.
+	UpdateURL    string
+	UpdateSigner common.Address
+	Version      string
 }
 
-func (cfg *Config) parseBootNodes() []*discover.Node {
+// parseBootNodes parses the bootstrap node URLs. URLs naming
+// their host by DNS name are returned separately.
+func (cfg *Config) parseBootNodes() ([]*discover.Node, []*discover.DNSNode) {
 	if cfg.BootNodes == "" {
-		return defaultBootNodes
+		return defaultBootNodes, nil
 	}
-	var ns []*discover.Node
+	var (
+		ns  []*discover.Node
+		dns []*discover.DNSNode
+	)
 	for _, url := range strings.Split(cfg.BootNodes, " ") {
 		if url == "" {
 			continue
 		}
-		n, err := discover.ParseNode(url)
+		if dn, err := discover.ParseDNSNode(url); err == nil {
+			dns = append(dns, dn)
+			continue
+		}
+		n, err := parseNodeURL(url)
 		if err != nil {
 			glog.V(logger.Error).Infof("Bootstrap URL %s: %v\n", url, err)
 			continue
 		}
 		ns = append(ns, n)
 	}
-	return ns
+	return ns, dns
+}
+
+// parseNodeURL parses a node URL given by the user and checks
+// that the node can be contacted.
+func parseNodeURL(url string) (*discover.Node, error) {
+	n, err := discover.ParseNode(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// protocolVersions returns the configured protocol versions,
+// highest first.
+func (cfg *Config) protocolVersions() []int {
+	if len(cfg.ProtocolVersions) == 0 {
+		return ProtocolVersions
+	}
+	versions := append([]int{}, cfg.ProtocolVersions...)
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	return versions
+}
+
+func (cfg *Config) parseCheckpoints() (map[uint64]common.Hash, error) {
+	if cfg.Checkpoints == "" {
+		return defaultCheckpoints, nil
+	}
+	checkpoints := make(map[uint64]common.Hash)
+	for _, cp := range strings.Split(cfg.Checkpoints, ",") {
+		if cp = strings.TrimSpace(cp); cp == "" {
+			continue
+		}
+		parts := strings.Split(cp, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid checkpoint %q, expected number=hash", cp)
+		}
+		num, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint number %q: %v", parts[0], err)
+		}
+		hash := common.FromHex(parts[1])
+		if len(hash) != len(common.Hash{}) {
+			return nil, fmt.Errorf("invalid checkpoint hash %q", parts[1])
+		}
+		checkpoints[num] = common.BytesToHash(hash)
+	}
+	return checkpoints, nil
 }
 
 func (cfg *Config) nodeKey() (*ecdsa.PrivateKey, error) {
@@ -121,6 +244,8 @@ type Ethereum struct {
 	shutdownChan chan bool
 
 	// DB interfaces
+	dbs     *Databases
+	dirLock *flock.Lock     // nil if the data directory isn't locked
 	blockDb common.Database // Block chain database
 	stateDb common.Database // State changes database
 	extraDb common.Database // Extra database (txs, etc)
@@ -128,11 +253,15 @@ type Ethereum struct {
 	//*** SERVICES ***
 	// State manager for processing new blocks and managing the over all states
 	blockProcessor  *core.BlockProcessor
+	bloomIndexer    *core.BloomIndexer
+	chainFreezer    *core.ChainFreezer // nil if freezing is disabled
+	updateChecker   *release.Checker   // nil if update checks are disabled
 	txPool          *core.TxPool
 	chainManager    *core.ChainManager
 	accountManager  *accounts.Manager
 	whisper         *whisper.Whisper
 	pow             *ethash.Ethash
+	clique          *clique.Clique // nil unless the chain uses proof of authority
 	protocolManager *ProtocolManager
 	downloader      *downloader.Downloader
 
@@ -154,36 +283,75 @@ type Ethereum struct {
 	shhVersionId  int
 }
 
-func New(config *Config) (*Ethereum, error) {
+func New(config *Config) (_ *Ethereum, err error) {
 	// Bootstrap database
 	logger.New(config.DataDir, config.LogFile, config.LogLevel)
 	if len(config.LogJSON) > 0 {
 		logger.NewJSONsystem(config.DataDir, config.LogJSON)
 	}
 
+	// Validate the config before opening the databases
+	checkpoints, err := config.parseCheckpoints()
+	if err != nil {
+		return nil, err
+	}
+	switch config.TxLookup {
+	case "", "basic", "full":
+	default:
+		return nil, fmt.Errorf("invalid txlookup mode %q, expected basic or full", config.TxLookup)
+	}
+
+	// Lock the data directory before touching any of its databases
+	var dirLock *flock.Lock
+	if config.NewDB == nil && !config.NoDataDirLock {
+		if dirLock, err = LockDataDir(config.DataDir); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				dirLock.Release()
+			}
+		}()
+	}
+
 	newdb := config.NewDB
 	if newdb == nil {
-		newdb = func(path string) (common.Database, error) { return ethdb.NewLDBDatabase(path) }
+		if config.DatabaseEngine != ethdb.LevelDBEngine && config.DatabaseEngine != "" && IsSeparateLayout(config.DataDir) {
+			return nil, fmt.Errorf("separate databases can only be used with %s, run geth upgradedb --merge to convert them", ethdb.LevelDBEngine)
+		}
+		newdb = func(path string) (common.Database, error) { return ethdb.Open(config.DatabaseEngine, path) }
 	}
-	blockDb, err := newdb(path.Join(config.DataDir, "blockchain"))
+	dbs, err := OpenDatabases(config.DataDir, newdb)
 	if err != nil {
 		return nil, err
 	}
-	stateDb, err := newdb(path.Join(config.DataDir, "state"))
-	if err != nil {
-		return nil, err
+	defer func() {
+		if err != nil {
+			dbs.Close()
+		}
+	}()
+	if config.NewDB == nil && (config.AncientThreshold > 0 || HasFreezer(config.DataDir)) {
+		if err := dbs.OpenFreezer(config.DataDir); err != nil {
+			return nil, err
+		}
 	}
-	extraDb, err := ethdb.NewLDBDatabase(path.Join(config.DataDir, "extra"))
+	blockDb, stateDb, extraDb := dbs.Block, dbs.State, dbs.Extra
 
 	// Perform database sanity checks
+	versions := config.protocolVersions()
+	for _, v := range versions {
+		if _, ok := ProtocolLengths[v]; !ok {
+			return nil, fmt.Errorf("unsupported protocol version %d", v)
+		}
+	}
+	// Versions sharing the database format can be upgraded in place.
 	d, _ := blockDb.Get([]byte("ProtocolVersion"))
 	protov := int(common.NewValue(d).Uint())
-	if protov != config.ProtocolVersion && protov != 0 {
-		path := path.Join(config.DataDir, "blockchain")
-		return nil, fmt.Errorf("Database version mismatch. Protocol(%d / %d). `rm -rf %s`", protov, config.ProtocolVersion, path)
+	if _, ok := ProtocolLengths[protov]; !ok && protov != 0 {
+		return nil, fmt.Errorf("Database version mismatch. Protocol(%d / %d). Remove the databases in %s", protov, versions[0], config.DataDir)
 	}
-	saveProtocolVersion(blockDb, config.ProtocolVersion)
-	glog.V(logger.Info).Infof("Protocol Version: %v, Network Id: %v", config.ProtocolVersion, config.NetworkId)
+	saveProtocolVersion(blockDb, versions[0])
+	glog.V(logger.Info).Infof("Protocol Versions: %v, Network Id: %v", versions, config.NetworkId)
 
 	if !config.SkipBcVersionCheck {
 		b, _ := blockDb.Get([]byte("BlockchainVersion"))
@@ -197,6 +365,8 @@ func New(config *Config) (*Ethereum, error) {
 
 	eth := &Ethereum{
 		shutdownChan:   make(chan bool),
+		dbs:            dbs,
+		dirLock:        dirLock,
 		blockDb:        blockDb,
 		stateDb:        stateDb,
 		extraDb:        extraDb,
@@ -205,44 +375,98 @@ func New(config *Config) (*Ethereum, error) {
 		DataDir:        config.DataDir,
 		etherbase:      common.HexToAddress(config.Etherbase),
 		clientVersion:  config.Name, // TODO should separate from Name
-		ethVersionId:   config.ProtocolVersion,
+		ethVersionId:   versions[0],
 		netVersionId:   config.NetworkId,
 		NatSpec:        config.NatSpec,
 	}
 
 	eth.chainManager = core.NewChainManager(blockDb, stateDb, eth.EventMux())
+	if config.ChainConfig != nil {
+		eth.chainManager.SetConfig(config.ChainConfig)
+	}
 	eth.downloader = downloader.New(eth.chainManager.HasBlock, eth.chainManager.InsertChain, eth.chainManager.Td)
+	eth.downloader.SetCheckpoints(checkpoints)
+	eth.downloader.SetEventMux(eth.eventMux)
+	eth.downloader.SetDb(extraDb)
 	eth.pow = ethash.New(eth.chainManager)
 	eth.txPool = core.NewTxPool(eth.EventMux(), eth.chainManager.State)
+	eth.txPool.SetSyncCheck(eth.downloader.Synchronising)
+	eth.txPool.SetMaxTxSize(config.MaxTxSize)
+	eth.txPool.SetJournal(path.Join(config.DataDir, "transactions.rlp"))
 	eth.blockProcessor = core.NewBlockProcessor(stateDb, extraDb, eth.pow, eth.txPool, eth.chainManager, eth.EventMux())
+	if config.ChainConfig != nil && config.ChainConfig.Clique != nil {
+		eth.clique = clique.New(config.ChainConfig.Clique)
+		eth.blockProcessor.SetEngine(eth.clique)
+	}
+	engine := eth.blockProcessor.Engine()
+	eth.downloader.SetPoWCheck(func(block *types.Block) bool {
+		return engine.VerifySeal(eth.chainManager, block.Header()) == nil
+	})
+	eth.bloomIndexer = core.NewBloomIndexer(extraDb, eth.chainManager, eth.EventMux())
+	eth.blockProcessor.SetBloomIndexer(eth.bloomIndexer)
+	if dbs.Freezer() != nil && config.AncientThreshold > 0 {
+		eth.chainFreezer = core.NewChainFreezer(eth.chainManager, blockDb.(*core.AncientDatabase), extraDb.(*core.AncientDatabase), config.AncientThreshold)
+	}
+	if config.UpdateURL != "" {
+		eth.updateChecker = release.NewChecker(config.UpdateURL, config.UpdateSigner, config.Version)
+	}
+	if config.TxLookup == "full" {
+		eth.blockProcessor.SetAddressIndex(true)
+	}
 	eth.chainManager.SetProcessor(eth.blockProcessor)
 	eth.whisper = whisper.New()
 	eth.shhVersionId = int(eth.whisper.Version())
-	eth.miner = miner.New(eth, eth.pow, config.MinerThreads)
-	eth.protocolManager = NewProtocolManager(config.ProtocolVersion, config.NetworkId, eth.txPool, eth.chainManager, eth.downloader)
+	if eth.clique != nil {
+		eth.miner = miner.New(eth, eth.pow, 0)
+		eth.miner.Register(miner.NewSealAgent(eth.chainManager, eth.clique))
+	} else {
+		eth.miner = miner.New(eth, eth.pow, config.MinerThreads)
+	}
+	if !config.MinerNoSyncPause {
+		eth.miner.PauseOnSync()
+	}
+	eth.protocolManager = NewProtocolManager(versions, config.NetworkId, eth.txPool, eth.chainManager, eth.downloader)
+	eth.protocolManager.SetSizeLimits(config.MaxMsgSize, config.MaxBlockSize, config.MaxTxSize)
 
 	netprv, err := config.nodeKey()
 	if err != nil {
 		return nil, err
 	}
-	protocols := []p2p.Protocol{eth.protocolManager.SubProtocol}
+	protocols := append([]p2p.Protocol{}, eth.protocolManager.SubProtocols...)
 	if config.Shh {
+		secret := []byte(config.ShhPassphrase)
+		if len(secret) == 0 {
+			secret = crypto.FromECDSA(netprv)
+		}
+		if err := eth.whisper.OpenKeyStore(path.Join(config.DataDir, "whisper", "keys.json"), secret); err != nil {
+			return nil, fmt.Errorf("whisper key store: %v", err)
+		}
 		protocols = append(protocols, eth.whisper.Protocol())
 	}
+	blacklist, err := discover.OpenBlacklist(path.Join(config.DataDir, "blacklist.json"))
+	if err != nil {
+		return nil, fmt.Errorf("peer blacklist: %v", err)
+	}
+	bootnodes, dnsBootnodes := config.parseBootNodes()
 	eth.net = &p2p.Server{
-		PrivateKey:     netprv,
-		Name:           config.Name,
-		MaxPeers:       config.MaxPeers,
-		Protocols:      protocols,
-		NAT:            config.NAT,
-		NoDial:         !config.Dial,
-		BootstrapNodes: config.parseBootNodes(),
+		PrivateKey:        netprv,
+		Name:              config.Name,
+		MaxPeers:          config.MaxPeers,
+		DialRatio:         config.DialRatio,
+		Protocols:         protocols,
+		NAT:               config.NAT,
+		NoDial:            !config.Dial,
+		BootstrapNodes:    bootnodes,
+		BootstrapDNSNodes: dnsBootnodes,
+		Blacklist:         blacklist,
+		EventMux:          eth.eventMux,
 	}
 	if len(config.Port) > 0 {
 		eth.net.ListenAddr = ":" + config.Port
 	}
 
 	vm.Debug = config.VmDebug
+	vm.CollectStats = config.VmStats
 
 	return eth, nil
 }
@@ -256,6 +480,9 @@ type NodeInfo struct {
 	TCPPort    int // TCP listening port for RLPx
 	Td         string
 	ListenAddr string
+	GitCommit  string
+	BuildDate  string
+	GoVersion  string
 }
 
 func (s *Ethereum) NodeInfo() *NodeInfo {
@@ -270,15 +497,21 @@ func (s *Ethereum) NodeInfo() *NodeInfo {
 		TCPPort:    node.TCPPort,
 		ListenAddr: s.net.ListenAddr,
 		Td:         s.ChainManager().Td().String(),
+		GitCommit:  version.GitCommit,
+		BuildDate:  version.BuildDate,
+		GoVersion:  runtime.Version(),
 	}
 }
 
 type PeerInfo struct {
 	ID            string
 	Name          string
+	Client        string // client name from Name, e.g. "Geth"
+	ClientVersion string // client version from Name, e.g. "v0.9.20"
 	Caps          string
 	RemoteAddress string
 	LocalAddress  string
+	Protocols     map[string]p2p.ProtocolStats // Traffic per subprotocol
 }
 
 func newPeerInfo(peer *p2p.Peer) *PeerInfo {
@@ -286,12 +519,16 @@ func newPeerInfo(peer *p2p.Peer) *PeerInfo {
 	for _, cap := range peer.Caps() {
 		caps = append(caps, cap.String())
 	}
+	client := peer.Client()
 	return &PeerInfo{
 		ID:            peer.ID().String(),
 		Name:          peer.Name(),
+		Client:        client.Name,
+		ClientVersion: client.Version,
 		Caps:          strings.Join(caps, ", "),
 		RemoteAddress: peer.RemoteAddr().String(),
 		LocalAddress:  peer.LocalAddr().String(),
+		Protocols:     peer.ProtocolStats(),
 	}
 }
 
@@ -305,6 +542,73 @@ func (s *Ethereum) PeersInfo() (peersinfo []*PeerInfo) {
 	return
 }
 
+// PeerStats summarizes the client software of connected peers.
+type PeerStats struct {
+	Peers    int
+	Clients  map[string]int // number of peers per client name
+	Versions map[string]int // number of peers per client name and version
+}
+
+// PeerStats returns the client distribution among connected peers.
+func (s *Ethereum) PeerStats() *PeerStats {
+	stats := &PeerStats{Clients: make(map[string]int), Versions: make(map[string]int)}
+	for _, peer := range s.net.Peers() {
+		if peer == nil {
+			continue
+		}
+		client := peer.Client()
+		stats.Peers++
+		stats.Clients[client.Name]++
+		stats.Versions[client.String()]++
+	}
+	return stats
+}
+
+type SyncPeerInfo struct {
+	ID   string
+	Td   string
+	Head string // hash of the peer's last known head block
+	Idle bool
+}
+
+// SyncStatus describes the progress of the chain synchronisation
+type SyncStatus struct {
+	Syncing        bool
+	ActivePeer     string
+	HashesPending  int // hashes waiting to be fetched
+	HashesFetching int // hashes requested from peers
+	BlocksPending  int // blocks downloaded but not yet inserted
+	Number         uint64
+	Head           string
+	Td             string
+	Peers          []*SyncPeerInfo
+}
+
+// SyncStatus returns the status of the downloader and the local chain
+func (s *Ethereum) SyncStatus() *SyncStatus {
+	status := s.downloader.Status()
+	head := s.chainManager.CurrentBlock()
+	info := &SyncStatus{
+		Syncing:        status.Syncing,
+		ActivePeer:     status.ActivePeer,
+		HashesPending:  status.HashesPending,
+		HashesFetching: status.HashesFetching,
+		BlocksPending:  status.BlocksPending,
+		Number:         head.NumberU64(),
+		Head:           head.Hash().Hex(),
+		Td:             s.chainManager.Td().String(),
+	}
+	for _, p := range status.Peers {
+		info.Peers = append(info.Peers, &SyncPeerInfo{
+			ID:   p.Id,
+			Td:   p.Td.String(),
+			Head: p.CurrentBlockHash.Hex(),
+			Idle: p.Idle,
+		})
+	}
+	return info
+}
+
 func (s *Ethereum) ResetWithGenesisBlock(gb *types.Block) {
 	s.chainManager.ResetWithGenesisBlock(gb)
 	s.pow.UpdateCache(0, true)
@@ -318,6 +622,12 @@ func (s *Ethereum) StartMining() error {
 		return err
 
 	}
+	if s.clique != nil {
+		am := s.accountManager
+		s.clique.Authorize(eb, func(signer common.Address, hash []byte) ([]byte, error) {
+			return am.Sign(accounts.Account{Address: signer.Bytes()}, hash)
+		})
+	}
 
 	s.miner.Start(eb)
 	return nil
@@ -351,6 +661,7 @@ func (s *Ethereum) EventMux() *event.TypeMux             { return s.eventMux }
 func (s *Ethereum) BlockDb() common.Database             { return s.blockDb }
 func (s *Ethereum) StateDb() common.Database             { return s.stateDb }
 func (s *Ethereum) ExtraDb() common.Database             { return s.extraDb }
+func (s *Ethereum) Databases() *Databases                { return s.dbs }
 func (s *Ethereum) IsListening() bool                    { return true } // Always listening
 func (s *Ethereum) PeerCount() int                       { return s.net.PeerCount() }
 func (s *Ethereum) Peers() []*p2p.Peer                   { return s.net.Peers() }
@@ -361,6 +672,16 @@ func (s *Ethereum) NetVersion() int                      { return s.netVersionId
 func (s *Ethereum) ShhVersion() int                      { return s.shhVersionId }
 func (s *Ethereum) Downloader() *downloader.Downloader   { return s.downloader }
 
+// UpdateStatus returns the result of the last update check, nil if update
+// checks are disabled.
+func (s *Ethereum) UpdateStatus() *release.Status {
+	if s.updateChecker == nil {
+		return nil
+	}
+	status := s.updateChecker.Status()
+	return &status
+}
+
 // Start the ethereum
 func (s *Ethereum) Start() error {
 	jsonlogger.LogJson(&logger.LogStarting{
@@ -374,18 +695,27 @@ func (s *Ethereum) Start() error {
 			return err
 		}
 	}
+	go checkClockDrift(s.chainManager.Config().AllowedClockDrift())
+
+	// broadcast transactions, subscribed before the pool is started so that
+	// the transactions replayed from the journal are broadcast as well
+	s.txSub = s.txPool.SubscribeTxPreEvent()
+	go s.txBroadcastLoop()
 
 	// Start services
 	s.txPool.Start()
+	s.bloomIndexer.Start()
+	if s.chainFreezer != nil {
+		s.chainFreezer.Start()
+	}
+	if s.updateChecker != nil {
+		s.updateChecker.Start()
+	}
 
 	if s.whisper != nil {
 		s.whisper.Start()
 	}
 
-	// broadcast transactions
-	s.txSub = s.eventMux.Subscribe(core.TxPreEvent{})
-	go s.txBroadcastLoop()
-
 	// broadcast mined blocks
 	s.minedBlockSub = s.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go s.minedBroadcastLoop()
@@ -405,7 +735,7 @@ func (s *Ethereum) StartForTest() {
 }
 
 func (self *Ethereum) SuggestPeer(nodeURL string) error {
-	n, err := discover.ParseNode(nodeURL)
+	n, err := parseNodeURL(nodeURL)
 	if err != nil {
 		return fmt.Errorf("invalid node URL: %v", err)
 	}
@@ -413,20 +743,56 @@ func (self *Ethereum) SuggestPeer(nodeURL string) error {
 	return nil
 }
 
+// BanPeer bans a node, given by its node URL or its hex node ID, from
+// discovery and from connecting. Node URLs also ban the node's IP address.
+// Bans are saved in the data directory.
+func (self *Ethereum) BanPeer(node string) error {
+	if n, err := discover.ParseNode(node); err == nil {
+		return self.net.BanPeer(n.ID, n.IP)
+	}
+	id, err := discover.HexID(node)
+	if err != nil {
+		return fmt.Errorf("invalid node URL or ID: %v", err)
+	}
+	return self.net.BanPeer(id, nil)
+}
+
+// CloseDatabases closes the databases and unlocks the data directory. It is
+// used by commands which don't start the node.
+func (s *Ethereum) CloseDatabases() {
+	s.dbs.Close()
+	if s.dirLock != nil {
+		s.dirLock.Release()
+	}
+}
+
+// Stop shuts the node down. Services are stopped in dependency order: the
+// network first, so no new blocks or transactions arrive, then the services
+// producing and processing them, and finally the chain manager, which
+// flushes the state and writes the head marker before the databases are
+// closed.
 func (s *Ethereum) Stop() {
-	// Close the database
-	defer s.blockDb.Close()
-	defer s.stateDb.Close()
-	defer s.extraDb.Close()
+	s.net.Stop()
+	s.miner.Stop()
+	if s.whisper != nil {
+		s.whisper.Stop()
+	}
 
 	s.txSub.Unsubscribe()         // quits txBroadcastLoop
 	s.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
 
 	s.txPool.Stop()
-	s.eventMux.Stop()
-	if s.whisper != nil {
-		s.whisper.Stop()
+	s.bloomIndexer.Stop()
+	if s.chainFreezer != nil {
+		s.chainFreezer.Stop()
 	}
+	if s.updateChecker != nil {
+		s.updateChecker.Stop()
+	}
+	s.chainManager.Stop()
+	s.eventMux.Stop()
+
+	s.CloseDatabases()
 
 	glog.V(logger.Info).Infoln("Server stopped")
 	close(s.shutdownChan)
@@ -443,7 +809,7 @@ func (self *Ethereum) txBroadcastLoop() {
 	// automatically stops if unsubscribe
 	for obj := range self.txSub.Chan() {
 		event := obj.(core.TxPreEvent)
-		self.net.BroadcastLimited("eth", TxMsg, math.Sqrt, []*types.Transaction{event.Tx})
+		self.protocolManager.BroadcastTx(event.Tx.Hash(), event.Tx)
 		self.syncAccounts(event.Tx)
 	}
 }
@@ -0,0 +1,94 @@
+package eth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newTestEthereum creates a node on in-memory databases.
+func newTestEthereum(t *testing.T, dir string, chainConfig *params.ChainConfig) *Ethereum {
+	eth, err := New(&Config{
+		DataDir:        dir,
+		Name:           "test",
+		AccountManager: accounts.NewManager(crypto.NewKeyStorePlain(dir)),
+		NewDB:          func(string) (common.Database, error) { return ethdb.NewMemDatabase() },
+		ChainConfig:    chainConfig,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return eth
+}
+
+func TestEngineSelection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	eth := newTestEthereum(t, dir, nil)
+	if _, ok := eth.BlockProcessor().Engine().(*clique.Clique); ok {
+		t.Error("proof-of-authority engine selected without clique config")
+	}
+
+	signer := common.Address{1}
+	eth = newTestEthereum(t, dir, &params.ChainConfig{Clique: &params.CliqueConfig{Signers: []common.Address{signer}}})
+	engine, ok := eth.BlockProcessor().Engine().(*clique.Clique)
+	if !ok {
+		t.Fatalf("engine is %T, want clique", eth.BlockProcessor().Engine())
+	}
+	if signers := engine.Signers(); len(signers) != 1 || signers[0] != signer {
+		t.Errorf("engine signers %x, want %x", signers, signer)
+	}
+}
+
+// closeCountingDB counts how often it is closed.
+type closeCountingDB struct {
+	*ethdb.MemDatabase
+	closed *int
+}
+
+func (db closeCountingDB) Close() {
+	*db.closed++
+	db.MemDatabase.Close()
+}
+
+func TestNewClosesDatabasesOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// an unreadable peer blacklist fails New after the databases are open
+	if err := ioutil.WriteFile(filepath.Join(dir, "blacklist.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var opened, closed int
+	_, err = New(&Config{
+		DataDir:        dir,
+		Name:           "test",
+		AccountManager: accounts.NewManager(crypto.NewKeyStorePlain(dir)),
+		NewDB: func(string) (common.Database, error) {
+			db, err := ethdb.NewMemDatabase()
+			opened++
+			return closeCountingDB{db, &closed}, err
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error for invalid blacklist")
+	}
+	if opened == 0 || closed < opened {
+		t.Errorf("%d databases opened, %d closes", opened, closed)
+	}
+}
@@ -0,0 +1,236 @@
+package eth
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/flock"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// chainDataDir is the directory of the unified database within the data
+// directory.
+const chainDataDir = "chaindata"
+
+// ancientDir is the directory of the freezer within the data directory.
+const ancientDir = "ancient"
+
+// dataDirLockFile is the lock file of a data directory in use.
+const dataDirLockFile = "LOCK"
+
+// mergeFlushInterval is the number of keys copied by MergeDatabases between
+// writes to disk.
+const mergeFlushInterval = 10000
+
+// The separate databases of the old layout and the prefixes of their tables
+// in the unified database.
+var (
+	blockTable = dbTable{dir: "blockchain", prefix: "bc-"}
+	stateTable = dbTable{dir: "state", prefix: "st-"}
+	extraTable = dbTable{dir: "extra", prefix: "ex-"}
+)
+
+type dbTable struct {
+	dir    string
+	prefix string
+}
+
+// LockDataDir locks dataDir against use by another process, creating the
+// directory if necessary.
+func LockDataDir(dataDir string) (*flock.Lock, error) {
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, err
+	}
+	lock, err := flock.New(path.Join(dataDir, dataDirLockFile))
+	if err == flock.ErrLocked {
+		return nil, fmt.Errorf("datadir %s is in use by another geth process. Stop it, choose a different --datadir or, if the directory is deliberately shared, pass --datadir.nolock", dataDir)
+	}
+	return lock, err
+}
+
+/*
+Databases holds the block chain, state and extra database of a node.
+
+Data directories created by older versions contain a separate LevelDB
+instance for each of them. New data directories contain a single unified
+database in which the three are tables with distinct key prefixes. Old
+data directories can be converted with MergeDatabases.
+*/
+type Databases struct {
+	Block common.Database
+	State common.Database
+	Extra common.Database
+
+	shared  common.Database // unified database, nil in the old layout
+	freezer *core.Freezer   // nil if not opened
+}
+
+// OpenDatabases opens the databases in dataDir using newdb, picking the
+// layout the data directory was created with.
+func OpenDatabases(dataDir string, newdb func(path string) (common.Database, error)) (*Databases, error) {
+	if newdb == nil {
+		newdb = func(path string) (common.Database, error) { return ethdb.NewLDBDatabase(path) }
+	}
+	if IsSeparateLayout(dataDir) {
+		glog.V(logger.Info).Infoln("Using separate databases, run geth upgradedb --merge to unify them")
+		return openSeparate(dataDir, newdb)
+	}
+
+	shared, err := newdb(path.Join(dataDir, chainDataDir))
+	if err != nil {
+		return nil, err
+	}
+	return &Databases{
+		Block:  ethdb.NewTable(shared, blockTable.prefix),
+		State:  ethdb.NewTable(shared, stateTable.prefix),
+		Extra:  ethdb.NewTable(shared, extraTable.prefix),
+		shared: shared,
+	}, nil
+}
+
+func openSeparate(dataDir string, newdb func(path string) (common.Database, error)) (*Databases, error) {
+	dbs := new(Databases)
+	for _, t := range []struct {
+		table dbTable
+		db    *common.Database
+	}{{blockTable, &dbs.Block}, {stateTable, &dbs.State}, {extraTable, &dbs.Extra}} {
+		db, err := newdb(path.Join(dataDir, t.table.dir))
+		if err != nil {
+			dbs.Close()
+			return nil, err
+		}
+		*t.db = db
+	}
+	return dbs, nil
+}
+
+// Close flushes and closes all databases.
+func (self *Databases) Close() {
+	for _, db := range []common.Database{self.Block, self.State, self.Extra, self.shared} {
+		if db != nil {
+			db.Close()
+		}
+	}
+	if self.freezer != nil {
+		self.freezer.Close()
+	}
+}
+
+// HasFreezer reports whether dataDir contains a freezer.
+func HasFreezer(dataDir string) bool {
+	return common.FileExist(path.Join(dataDir, ancientDir))
+}
+
+// OpenFreezer opens the freezer in dataDir, creating it if necessary. The
+// block and extra database are replaced by databases which read blocks and
+// receipts from the freezer if they aren't in the database.
+func (self *Databases) OpenFreezer(dataDir string) error {
+	freezer, err := core.NewFreezer(path.Join(dataDir, ancientDir))
+	if err != nil {
+		return err
+	}
+	self.freezer = freezer
+	self.Block = core.NewAncientDatabase(self.Block, freezer)
+	self.Extra = core.NewAncientDatabase(self.Extra, freezer)
+	return nil
+}
+
+// Freezer returns the freezer, nil if it hasn't been opened.
+func (self *Databases) Freezer() *core.Freezer {
+	return self.freezer
+}
+
+// IsSeparateLayout reports whether dataDir contains the separate databases
+// of older versions and no unified database.
+func IsSeparateLayout(dataDir string) bool {
+	return common.FileExist(path.Join(dataDir, blockTable.dir)) && !common.FileExist(path.Join(dataDir, chainDataDir))
+}
+
+// RemoveBlockChain deletes the block chain database in dataDir, keeping the
+// state and extra databases. engine is the engine of the unified database.
+func RemoveBlockChain(dataDir, engine string) error {
+	if IsSeparateLayout(dataDir) {
+		return os.RemoveAll(path.Join(dataDir, blockTable.dir))
+	}
+	db, err := ethdb.Open(engine, path.Join(dataDir, chainDataDir))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	prefix := []byte(blockTable.prefix)
+	batch := db.NewBatch()
+	it := db.NewIterator()
+	for ok := it.Seek(prefix); ok && bytes.HasPrefix(it.Key(), prefix); ok = it.Next() {
+		batch.Delete(it.Key())
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// MergeDatabases copies the separate databases in dataDir into a new unified
+// database using engine and removes them once all keys have been written.
+func MergeDatabases(dataDir, engine string) error {
+	if !IsSeparateLayout(dataDir) {
+		return fmt.Errorf("%s doesn't contain separate databases", dataDir)
+	}
+	shared, err := ethdb.Open(engine, path.Join(dataDir, chainDataDir))
+	if err != nil {
+		return err
+	}
+	for _, t := range []dbTable{blockTable, stateTable, extraTable} {
+		if err := mergeTable(shared, dataDir, t); err != nil {
+			shared.Close()
+			os.RemoveAll(path.Join(dataDir, chainDataDir))
+			return err
+		}
+	}
+	shared.Close()
+
+	for _, t := range []dbTable{blockTable, stateTable, extraTable} {
+		if err := os.RemoveAll(path.Join(dataDir, t.dir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergeTable(shared ethdb.Database, dataDir string, t dbTable) error {
+	dir := path.Join(dataDir, t.dir)
+	if !common.FileExist(dir) {
+		return nil
+	}
+	db, err := ethdb.NewLDBDatabase(dir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	table := ethdb.NewTable(shared, t.prefix)
+	it := db.NewIterator()
+	defer it.Release()
+
+	var count int
+	for it.Next() {
+		table.Put(common.CopyBytes(it.Key()), common.CopyBytes(it.Value()))
+		if count++; count%mergeFlushInterval == 0 {
+			if err := shared.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("%s: %v", t.dir, err)
+	}
+	glog.V(logger.Info).Infof("merged %d keys of %s", count, t.dir)
+	return shared.Flush()
+}
@@ -0,0 +1,91 @@
+package eth
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestMergeDatabasesLevelDB(t *testing.T) { testMergeDatabases(t, ethdb.LevelDBEngine) }
+func TestMergeDatabasesBoltDB(t *testing.T)  { testMergeDatabases(t, ethdb.BoltDBEngine) }
+
+func testMergeDatabases(t *testing.T, engine string) {
+	dir, err := ioutil.TempDir("", "eth-merge-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the same key in every database must survive the merge
+	for _, table := range []dbTable{blockTable, stateTable, extraTable} {
+		db, _ := ethdb.NewLDBDatabase(path.Join(dir, table.dir))
+		db.Put([]byte("key"), []byte(table.dir))
+		db.Put([]byte("empty"), make([]byte, 32)) // compressed on disk
+		db.Close()
+	}
+	if !IsSeparateLayout(dir) {
+		t.Fatal("expected separate layout")
+	}
+	if err := MergeDatabases(dir, engine); err != nil {
+		t.Fatalf("merge error: %v", err)
+	}
+	if IsSeparateLayout(dir) || common.FileExist(path.Join(dir, blockTable.dir)) {
+		t.Fatal("separate databases not removed")
+	}
+
+	open := func(path string) (common.Database, error) { return ethdb.Open(engine, path) }
+	dbs, err := OpenDatabases(dir, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, db := range []common.Database{dbs.Block, dbs.State, dbs.Extra} {
+		want := []dbTable{blockTable, stateTable, extraTable}[i].dir
+		if v, _ := db.Get([]byte("key")); string(v) != want {
+			t.Errorf("%s: got %q", want, v)
+		}
+		if v, _ := db.Get([]byte("empty")); !bytes.Equal(v, make([]byte, 32)) {
+			t.Errorf("%s: wrong value %x", want, v)
+		}
+	}
+	dbs.Close()
+
+	// removing the chain keeps the other tables
+	if err := RemoveBlockChain(dir, engine); err != nil {
+		t.Fatalf("remove error: %v", err)
+	}
+	dbs, _ = OpenDatabases(dir, open)
+	defer dbs.Close()
+	if v, _ := dbs.Block.Get([]byte("key")); len(v) != 0 {
+		t.Errorf("block chain key not removed: %q", v)
+	}
+	if v, _ := dbs.State.Get([]byte("key")); string(v) != stateTable.dir {
+		t.Errorf("state key removed: %q", v)
+	}
+}
+
+func TestLockDataDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-lock-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dataDir := path.Join(dir, "data")
+
+	lock, err := LockDataDir(dataDir)
+	if err != nil {
+		t.Fatalf("lock error: %v", err)
+	}
+	if _, err := LockDataDir(dataDir); err == nil {
+		t.Fatal("expected error for locked data directory")
+	}
+	lock.Release()
+	if lock, err = LockDataDir(dataDir); err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	lock.Release()
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"gopkg.in/fatih/set.v0"
@@ -19,6 +20,9 @@ import (
 
 const (
 	maxBlockFetch    = 256              // Amount of max blocks to be fetched per chunk
+	minBlockFetch    = 16               // Amount of min blocks to be fetched per chunk from slow peers
+	targetChunkTime  = 2 * time.Second  // Chunks delivered faster than this grow the peer's chunk size
+	minPeerQuality   = 0.5              // Peers scoring lower are only selected if no better one is ahead
 	peerCountTimeout = 12 * time.Second // Amount of time it takes for the peer handler to ignore minDesiredPeerCount
 	hashTtl          = 20 * time.Second // The amount of time it takes for a hash request to time out
 )
@@ -26,6 +30,7 @@ const (
 var (
 	minDesiredPeerCount = 5                // Amount of peers desired to start syncing
 	blockTtl            = 20 * time.Second // The amount of time it takes for a block request to time out
+	headTtl             = 5 * time.Second  // The amount of time a peer has to deliver its head block
 
 	errLowTd            = errors.New("peer's TD is too low")
 	errBusy             = errors.New("busy")
@@ -34,12 +39,15 @@ var (
 	errTimeout          = errors.New("timeout")
 	errEmptyHashSet     = errors.New("empty hash set by peer")
 	errPeersUnavailable = errors.New("no peers available or all peers tried for block download process")
+	ErrCheckpoint       = errors.New("block contradicts checkpoint")
+	errBadHead          = errors.New("peer's head block doesn't match its status")
 )
 
 type hashCheckFn func(common.Hash) bool
 type chainInsertFn func(types.Blocks) error
 type hashIterFn func() (common.Hash, error)
 type currentTdFn func() *big.Int
+type powCheckFn func(*types.Block) bool
 
 type blockPack struct {
 	peerId string
@@ -58,15 +66,32 @@ type Downloader struct {
 	peers      peers
 	activePeer string
 
+	// trusted block hashes by number
+	checkpoints map[uint64]common.Hash
+
 	// Callbacks
 	hasBlock    hashCheckFn
 	insertChain chainInsertFn
 	currentTd   currentTdFn
+	verifyPoW   powCheckFn
+
+	// mux receives the sync start, done and failed events, if set
+	mux *event.TypeMux
+
+	// download progress, persisted if db is set
+	db             common.Database
+	restored       map[common.Hash]int // index of the restored hashes
+	restoredHashes []common.Hash       // hashes of an interrupted sync, newest first
+
+	// head block requests of sync target candidates, by peer id
+	headMu   sync.Mutex
+	headReqs map[string]chan []*types.Block
 
 	// Status
 	fetchingHashes    int32
 	downloadingBlocks int32
 	processingBlocks  int32
+	verifyingHead     int32 // set while the head of a sync candidate is verified
 
 	// Channels
 	newPeerCh chan *peer
@@ -83,6 +108,7 @@ func New(hasBlock hashCheckFn, insertChain chainInsertFn, currentTd currentTdFn)
 		hasBlock:    hasBlock,
 		insertChain: insertChain,
 		currentTd:   currentTd,
+		headReqs:    make(map[string]chan []*types.Block),
 		newPeerCh:   make(chan *peer, 1),
 		syncCh:      make(chan syncPack, 1),
 		hashCh:      make(chan []common.Hash, 1),
@@ -95,21 +121,80 @@ func New(hasBlock hashCheckFn, insertChain chainInsertFn, currentTd currentTdFn)
 	return downloader
 }
 
+// Synchronising reports whether the downloader is fetching hashes,
+// downloading or processing blocks, i.e. whether the local chain is
+// catching up with the network.
+func (d *Downloader) Synchronising() bool {
+	return d.isBusy()
+}
+
 func (d *Downloader) Stats() (current int, max int) {
 	return d.queue.blockHashes.Size(), d.queue.fetchPool.Size() + d.queue.hashPool.Size()
 }
 
+// Status is a snapshot of the synchronisation progress for monitoring.
+type Status struct {
+	Syncing        bool          // fetching hashes, downloading or processing blocks
+	ActivePeer     string        // peer hashes are fetched from
+	HashesPending  int           // hashes waiting to be fetched
+	HashesFetching int           // hashes requested from peers
+	BlocksPending  int           // blocks downloaded but not yet inserted
+	Peers          []*PeerStatus // registered peers
+}
+
+// PeerStatus is the last known chain head and the block delivery
+// quality of a peer.
+type PeerStatus struct {
+	Id               string
+	Td               *big.Int
+	CurrentBlockHash common.Hash
+	Idle             bool
+	Quality          float64 // score between 0 and 1, see peer.score
+	ChunkSize        int     // amount of blocks requested at once
+}
+
+// Status returns the current synchronisation status.
+func (d *Downloader) Status() *Status {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	status := &Status{
+		Syncing:        d.isBusy(),
+		ActivePeer:     d.activePeer,
+		HashesPending:  d.queue.hashPool.Size(),
+		HashesFetching: d.queue.fetchPool.Size(),
+	}
+	d.queue.mu.Lock()
+	status.BlocksPending = len(d.queue.blocks)
+	d.queue.mu.Unlock()
+
+	for id, p := range d.peers {
+		p.mu.RLock()
+		status.Peers = append(status.Peers, &PeerStatus{
+			Id:               id,
+			Td:               p.td,
+			CurrentBlockHash: p.recentHash,
+			Idle:             p.state == idleState,
+			Quality:          p.score(),
+			ChunkSize:        p.capacity,
+		})
+		p.mu.RUnlock()
+	}
+	return status
+}
+
 func (d *Downloader) RegisterPeer(id string, td *big.Int, hash common.Hash, getHashes hashFetcherFn, getBlocks blockFetcherFn) error {
 	d.mu.Lock()
-	defer d.mu.Unlock()
-
 	glog.V(logger.Detail).Infoln("Register peer", id, "TD =", td)
 
 	// Create a new peer and add it to the list of known peers
 	peer := newPeer(id, td, hash, getHashes, getBlocks)
 	// add peer to our peer set
 	d.peers[id] = peer
-	// broadcast new peer
+	d.mu.Unlock()
+
+	// broadcast new peer. The lock isn't held while waiting for the peer
+	// handler, deliveries it may be waiting for need it.
 	d.newPeerCh <- peer
 
 	return nil
@@ -133,19 +218,20 @@ out:
 		select {
 		case <-d.newPeerCh:
 			// Meet the `minDesiredPeerCount` before we select our best peer
-			if len(d.peers) < minDesiredPeerCount {
+			peer, count := d.bestPeer()
+			if count < minDesiredPeerCount {
 				break
 			}
 			itimer.Stop()
 
-			d.selectPeer(d.peers.bestPeer())
+			d.selectPeer(peer)
 		case <-itimer.C:
 			// The timer will make sure that the downloader keeps an active state
 			// in which it attempts to always check the network for highest td peers
 			// Either select the peer or restart the timer if no peers could
 			// be selected.
-			if peer := d.peers.bestPeer(); peer != nil {
-				d.selectPeer(d.peers.bestPeer())
+			if peer, _ := d.bestPeer(); peer != nil {
+				d.selectPeer(peer)
 			} else {
 				itimer.Reset(5 * time.Second)
 			}
@@ -155,6 +241,14 @@ out:
 	}
 }
 
+// bestPeer returns the best sync candidate and the number of registered peers.
+func (d *Downloader) bestPeer() (*peer, int) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.peers.bestPeer(d.currentTd()), len(d.peers)
+}
+
 func (d *Downloader) selectPeer(p *peer) {
 	// Make sure it's doing neither. Once done we can restart the
 	// downloading process if the TD is higher. For now just get on
@@ -168,10 +262,32 @@ func (d *Downloader) selectPeer(p *peer) {
 	if p.td.Cmp(d.currentTd()) <= 0 || d.hasBlock(p.recentHash) {
 		return
 	}
-
-	glog.V(logger.Detail).Infoln("New peer with highest TD =", p.td)
-	d.syncCh <- syncPack{p, p.recentHash, false}
-
+	// Don't commit to a peer before it proved that its head exists.
+	// The head is verified in the background so that the peer handler
+	// keeps accepting peers, one candidate at a time.
+	if !atomic.CompareAndSwapInt32(&d.verifyingHead, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&d.verifyingHead, 0)
+
+		if err := d.verifyHead(p); err != nil {
+			// Peers delivering a wrong head are lying about their chain and
+			// are dropped, slow ones may be selected again later.
+			if err == errTimeout {
+				glog.V(logger.Debug).Infof("Peer %s didn't deliver its head in time\n", p.id)
+				return
+			}
+			glog.V(logger.Debug).Infof("Dropping peer %s: %v\n", p.id, err)
+			d.UnregisterPeer(p.id)
+			return
+		}
+		if d.isBusy() {
+			return
+		}
+		glog.V(logger.Detail).Infoln("New peer with highest TD =", p.td)
+		d.syncCh <- syncPack{p, p.recentHash, false}
+	}()
 }
 
 func (d *Downloader) update() {
@@ -180,19 +296,82 @@ out:
 		select {
 		case sync := <-d.syncCh:
 			var peer *peer = sync.peer
+			d.post(StartEvent{})
 			err := d.getFromPeer(peer, sync.hash, sync.ignoreInitial)
 			if err != nil {
 				glog.V(logger.Detail).Infoln(err)
+				d.post(FailedEvent{err})
 				break
 			}
 
-			d.process()
+			if err := d.process(); err != nil {
+				d.post(FailedEvent{err})
+			} else {
+				d.post(DoneEvent{})
+			}
 		case <-d.quit:
 			break out
 		}
 	}
 }
 
+// verifyHead requests the head block advertised by the peer and checks
+// that it has the right hash, valid proof of work and a difficulty that
+// fits the peer's claimed TD.
+func (d *Downloader) verifyHead(p *peer) error {
+	p.mu.RLock()
+	hash, td := p.recentHash, p.td
+	p.mu.RUnlock()
+
+	reply := make(chan []*types.Block, 1)
+	d.headMu.Lock()
+	d.headReqs[p.id] = reply
+	d.headMu.Unlock()
+	defer func() {
+		d.headMu.Lock()
+		delete(d.headReqs, p.id)
+		d.headMu.Unlock()
+	}()
+
+	if err := p.getBlocks([]common.Hash{hash}); err != nil {
+		return err
+	}
+	var blocks []*types.Block
+	select {
+	case blocks = <-reply:
+	case <-time.After(headTtl):
+		return errTimeout
+	case <-d.quit:
+		return errTimeout
+	}
+	if len(blocks) != 1 || blocks[0] == nil || blocks[0].Hash() != hash {
+		return errBadHead
+	}
+	head := blocks[0]
+	if diff := head.Difficulty(); diff != nil && diff.Cmp(td) > 0 {
+		return errBadHead
+	}
+	if d.verifyPoW != nil && !d.verifyPoW(head) {
+		return fmt.Errorf("invalid proof of work in head block %x", hash[:4])
+	}
+	return nil
+}
+
+// deliverHead hands blocks to a pending head block request of the peer.
+// It reports whether there was such a request.
+func (d *Downloader) deliverHead(id string, blocks []*types.Block) bool {
+	d.headMu.Lock()
+	reply := d.headReqs[id]
+	delete(d.headReqs, id)
+	d.headMu.Unlock()
+
+	if reply == nil {
+		return false
+	}
+	reply <- blocks
+	return true
+}
+
 // XXX Make synchronous
 func (d *Downloader) startFetchingHashes(p *peer, hash common.Hash, ignoreInitial bool) error {
 	atomic.StoreInt32(&d.fetchingHashes, 1)
@@ -202,11 +381,15 @@ func (d *Downloader) startFetchingHashes(p *peer, hash common.Hash, ignoreInitia
 
 	start := time.Now()
 
+	// fetched holds all queued hashes newest first, they are persisted
+	// once the common ancestor is found.
+	var fetched []common.Hash
 	// We ignore the initial hash in some cases (e.g. we received a block without it's parent)
 	// In such circumstances we don't need to download the block so don't add it to the queue.
 	if !ignoreInitial {
 		// Add the hash to the queue first
 		d.queue.hashPool.Add(hash)
+		fetched = append(fetched, hash)
 	}
 	// Get the first batch of hashes
 	p.getHashes(hash)
@@ -228,8 +411,21 @@ out:
 					done = true
 					break
 				}
+				// The hashes below a restored one are known from an
+				// interrupted sync and don't need to be fetched again.
+				if resumed := d.resumeFrom(hash); resumed != nil {
+					glog.V(logger.Debug).Infof("Resuming download at %x (%d hashes)\n", hash[:4], len(resumed))
+					for _, hash := range resumed {
+						hashSet.Add(hash)
+					}
+					fetched = append(fetched, resumed...)
+
+					done = true
+					break
+				}
 
 				hashSet.Add(hash)
+				fetched = append(fetched, hash)
 			}
 			d.queue.put(hashSet)
 
@@ -256,6 +452,7 @@ out:
 		}
 	}
 	glog.V(logger.Detail).Infof("Downloaded hashes (%d) in %v\n", d.queue.hashPool.Size(), time.Since(start))
+	d.saveProgress(fetched)
 
 	return nil
 }
@@ -280,9 +477,12 @@ out:
 			// If the peer was previously banned and failed to deliver it's pack
 			// in a reasonable time frame, ignore it's message.
 			if d.peers[blockPack.peerId] != nil {
-				d.peers[blockPack.peerId].promote()
+				d.peers[blockPack.peerId].deliver(len(blockPack.blocks))
 				d.queue.deliver(blockPack.peerId, blockPack.blocks)
 				d.peers.setState(blockPack.peerId, idleState)
+			} else {
+				// Give the hashes requested from the peer back to the queue.
+				d.queue.deliver(blockPack.peerId, nil)
 			}
 		case <-ticker.C:
 			// If there are unrequested hashes left start fetching
@@ -292,7 +492,7 @@ out:
 				for _, peer := range availablePeers {
 					// Get a possible chunk. If nil is returned no chunk
 					// could be returned due to no hashes available.
-					chunk := d.queue.get(peer, maxBlockFetch)
+					chunk := d.queue.get(peer, peer.chunkSize())
 					if chunk == nil {
 						continue
 					}
@@ -341,15 +541,11 @@ out:
 				for _, pid := range badPeers {
 					// A nil chunk is delivered so that the chunk's hashes are given
 					// back to the queue objects. When hashes are put back in the queue
-					// other (decent) peers can pick them up.
-					// XXX We could make use of a reputation system here ranking peers
-					// in their performance
-					// 1) Time for them to respond;
-					// 2) Measure their speed;
-					// 3) Amount and availability.
+					// other (decent) peers can pick them up. The timeout lowers the
+					// peer's chunk size and its chance to be selected again.
 					d.queue.deliver(pid, nil)
 					if peer := d.peers[pid]; peer != nil {
-						peer.demote()
+						peer.timeout()
 						peer.reset()
 					}
 				}
@@ -363,10 +559,63 @@ out:
 	return nil
 }
 
+// SetPoWCheck sets the function verifying the proof of work of the head
+// blocks that peers advertise. It must be called before peers are
+// registered.
+func (d *Downloader) SetPoWCheck(verify powCheckFn) {
+	d.verifyPoW = verify
+}
+
+// SetEventMux sets the mux the sync start, done and failed events are
+// posted to. It must be called before peers are registered.
+func (d *Downloader) SetEventMux(mux *event.TypeMux) {
+	d.mux = mux
+}
+
+func (d *Downloader) post(ev interface{}) {
+	if d.mux != nil {
+		d.mux.Post(ev)
+	}
+}
+
+// SetCheckpoints sets the trusted block hashes the downloaded chain must match.
+func (d *Downloader) SetCheckpoints(checkpoints map[uint64]common.Hash) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.checkpoints = checkpoints
+}
+
+// VerifyCheckpoints returns ErrCheckpoint if any of the blocks has the number
+// of a checkpoint but a different hash.
+func (d *Downloader) VerifyCheckpoints(blocks []*types.Block) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, block := range blocks {
+		if hash, ok := d.checkpoints[block.NumberU64()]; ok && hash != block.Hash() {
+			return fmt.Errorf("%v: #%d %x (!= %x)", ErrCheckpoint, block.NumberU64(), block.Hash().Bytes()[:4], hash.Bytes()[:4])
+		}
+	}
+	return nil
+}
+
 // Deliver a chunk to the downloader. This is usually done through the BlocksMsg by
-// the protocol handler.
-func (d *Downloader) DeliverChunk(id string, blocks []*types.Block) {
+// the protocol handler. Chunks contradicting a checkpoint are dropped and the
+// peer is unregistered, the hashes requested from it are returned to the queue.
+func (d *Downloader) DeliverChunk(id string, blocks []*types.Block) error {
+	if err := d.VerifyCheckpoints(blocks); err != nil {
+		glog.V(logger.Info).Infof("Unregistering peer %s: %v\n", id, err)
+		d.UnregisterPeer(id)
+		d.queue.deliver(id, nil)
+		return err
+	}
+	if d.deliverHead(id, blocks) {
+		return nil
+	}
 	d.blockCh <- blockPack{id, blocks}
+
+	return nil
 }
 
 func (d *Downloader) AddHashes(id string, hashes []common.Hash) error {
@@ -406,7 +655,6 @@ func (d *Downloader) AddBlock(id string, block *types.Block, td *big.Int) error
 	peer.td = td
 	peer.recentHash = block.Hash()
 	peer.mu.Unlock()
-	peer.promote()
 
 	glog.V(logger.Detail).Infoln("Inserting new block from:", id)
 	d.queue.addBlock(id, block, td)
@@ -463,7 +711,7 @@ func (d *Downloader) process() error {
 			// TODO change this. This shite
 			for i, block := range blocks[:max] {
 				if !d.hasBlock(block.ParentHash()) {
-					d.syncCh <- syncPack{d.peers.bestPeer(), block.Hash(), true}
+					d.syncCh <- syncPack{d.peers.bestPeer(d.currentTd()), block.Hash(), true}
 					// remove processed blocks
 					blocks = blocks[i:]
 
@@ -482,6 +730,10 @@ func (d *Downloader) process() error {
 		blocks = blocks[max:]
 	}
 
+	// The persisted progress is kept only while missing parents are fetched.
+	if !core.IsParentErr(err) {
+		d.clearProgress()
+	}
 	// This will allow the GC to remove the in memory blocks
 	if len(blocks) == 0 {
 		d.queue.blocks = nil
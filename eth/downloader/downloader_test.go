@@ -8,8 +8,12 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+	"gopkg.in/fatih/set.v0"
 )
 
 var knownHash = common.Hash{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
@@ -130,6 +134,42 @@ success:
 	}
 }
 
+func TestSyncEvents(t *testing.T) {
+	minDesiredPeerCount = 4
+
+	hashes := createHashes(0, 1000)
+	blocks := createBlocksFromHashes(hashes)
+	tester := newTester(t, hashes, blocks)
+
+	mux := new(event.TypeMux)
+	tester.downloader.SetEventMux(mux)
+	sub := mux.Subscribe(StartEvent{}, DoneEvent{}, FailedEvent{})
+	defer sub.Unsubscribe()
+
+	tester.newPeer("peer1", big.NewInt(10000), hashes[0])
+	tester.newPeer("peer2", big.NewInt(0), common.Hash{})
+	tester.newPeer("peer3", big.NewInt(0), common.Hash{})
+	tester.newPeer("peer4", big.NewInt(0), common.Hash{})
+
+	var events []interface{}
+	timeout := time.After(10 * time.Second)
+	for len(events) < 2 {
+		select {
+		case ev := <-sub.Chan():
+			events = append(events, ev)
+		case <-tester.done:
+		case <-timeout:
+			t.Fatalf("timeout, got events %v", events)
+		}
+	}
+	if _, ok := events[0].(StartEvent); !ok {
+		t.Errorf("first event is %T, want StartEvent", events[0])
+	}
+	if _, ok := events[1].(DoneEvent); !ok {
+		t.Errorf("second event is %T, want DoneEvent", events[1])
+	}
+}
+
 func TestMissing(t *testing.T) {
 	t.Skip()
 
@@ -156,3 +196,211 @@ success1:
 
 	tester.downloader.AddBlock("peer2", blocks[hashes[len(hashes)-1]], big.NewInt(10001))
 }
+
+func TestCheckpoints(t *testing.T) {
+	hashes := createHashes(0, 10)
+	blocks := createBlocksFromHashes(hashes)
+	tester := newTester(t, hashes, blocks)
+	tester.newPeer("peer1", big.NewInt(10000), hashes[0])
+
+	var chunk []*types.Block
+	for _, hash := range hashes[:5] {
+		chunk = append(chunk, blocks[hash])
+	}
+	tester.downloader.SetCheckpoints(map[uint64]common.Hash{3: hashes[3]})
+	if err := tester.downloader.VerifyCheckpoints(chunk); err != nil {
+		t.Errorf("expected matching checkpoint to pass, got %v", err)
+	}
+
+	// reserve the chunk's hashes for the peer
+	queue := tester.downloader.queue
+	requested := set.New()
+	for _, hash := range hashes[:5] {
+		requested.Add(hash)
+	}
+	queue.put(requested)
+	if queue.get(tester.downloader.peers.getPeer("peer1"), len(chunk)) == nil {
+		t.Fatal("no chunk reserved for peer")
+	}
+
+	tester.downloader.SetCheckpoints(map[uint64]common.Hash{3: hashes[4]})
+	if err := tester.downloader.DeliverChunk("peer1", chunk); err == nil {
+		t.Error("expected chunk contradicting checkpoint to be rejected")
+	}
+	if tester.downloader.peers.getPeer("peer1") != nil {
+		t.Error("expected peer delivering a contradicting chunk to be unregistered")
+	}
+	if queue.hashPool.Size() != len(chunk) || queue.fetchPool.Size() != 0 || len(queue.fetching) != 0 {
+		t.Errorf("requested hashes not returned to the queue: %d pending, %d fetching", queue.hashPool.Size(), queue.fetchPool.Size())
+	}
+}
+
+func TestResumeProgress(t *testing.T) {
+	hashes := createHashes(0, 100)
+	blocks := createBlocksFromHashes(hashes)
+	tester := newTester(t, hashes, blocks)
+
+	// progress of an interrupted sync, down to the known block
+	db, _ := ethdb.NewMemDatabase()
+	data, _ := rlp.EncodeToBytes(hashes[50:])
+	db.Put(progressKey, data)
+	tester.downloader.SetDb(db)
+
+	// the peer only delivers the parents of its head down to the restored ones
+	p := newPeer("peer1", big.NewInt(10000), hashes[0], func(common.Hash) error {
+		tester.downloader.hashCh <- hashes[1:60]
+		return nil
+	}, tester.getBlocks("peer1"))
+	if err := tester.downloader.startFetchingHashes(p, hashes[0], false); err != nil {
+		t.Fatalf("hash fetching failed: %v", err)
+	}
+	if size := tester.downloader.queue.hashPool.Size(); size != len(hashes)-1 {
+		t.Errorf("queued %d hashes, want %d", size, len(hashes)-1)
+	}
+
+	var saved []common.Hash
+	data, err := db.Get(progressKey)
+	if err != nil {
+		t.Fatalf("progress not saved: %v", err)
+	}
+	if err := rlp.DecodeBytes(data, &saved); err != nil {
+		t.Fatalf("cannot decode progress: %v", err)
+	}
+	if len(saved) != len(hashes)-1 || saved[0] != hashes[0] || saved[len(saved)-1] != hashes[len(hashes)-2] {
+		t.Errorf("saved %d hashes, want %d newest first", len(saved), len(hashes)-1)
+	}
+}
+
+func TestBestPeerConflictingTd(t *testing.T) {
+	head := common.Hash{2}
+	ps := peers{
+		"liar":   newPeer("liar", big.NewInt(1000), head, nil, nil),
+		"honest": newPeer("honest", big.NewInt(10), head, nil, nil),
+		"other":  newPeer("other", big.NewInt(20), common.Hash{3}, nil, nil),
+	}
+	if best := ps.bestPeer(new(big.Int)); best.id != "other" {
+		t.Errorf("wrong best peer: got %s, want other", best.id)
+	}
+}
+
+func TestPeerChunkSize(t *testing.T) {
+	p := newPeer("peer", big.NewInt(10), common.Hash{}, nil, func([]common.Hash) error { return nil })
+	queue := newqueue()
+	set := set.New()
+	for _, hash := range createHashes(0, 1000) {
+		set.Add(hash)
+	}
+	queue.put(set)
+
+	// timed out chunks shrink the chunk size down to the minimum
+	for size := maxBlockFetch; size > minBlockFetch; size /= 2 {
+		chunk := queue.get(p, p.chunkSize())
+		if chunk.hashes.Size() != size {
+			t.Fatalf("chunk of %d hashes, want %d", chunk.hashes.Size(), size)
+		}
+		p.fetch(chunk)
+		p.timeout()
+		p.reset()
+		queue.deliver(p.id, nil)
+	}
+	if size := p.chunkSize(); size != minBlockFetch {
+		t.Fatalf("chunk size %d after timeouts, want %d", size, minBlockFetch)
+	}
+
+	// fast deliveries grow it, slow ones shrink it
+	p.started = time.Now()
+	p.deliver(1)
+	if size := p.chunkSize(); size != 2*minBlockFetch {
+		t.Errorf("chunk size %d after fast delivery, want %d", size, 2*minBlockFetch)
+	}
+	p.started = time.Now().Add(-3 * targetChunkTime)
+	p.deliver(1)
+	if size := p.chunkSize(); size != minBlockFetch {
+		t.Errorf("chunk size %d after slow delivery, want %d", size, minBlockFetch)
+	}
+}
+
+func TestBestPeerQuality(t *testing.T) {
+	ps := peers{
+		"good": newPeer("good", big.NewInt(20), common.Hash{2}, nil, nil),
+		"poor": newPeer("poor", big.NewInt(30), common.Hash{3}, nil, nil),
+	}
+	ps["poor"].requested = 100
+	ps["poor"].delivered = 10
+	if !ps["good"].good() || ps["poor"].good() {
+		t.Fatalf("wrong peer quality: good %v, poor %v", ps["good"].score(), ps["poor"].score())
+	}
+
+	if best := ps.bestPeer(big.NewInt(10)); best.id != "good" {
+		t.Errorf("wrong best peer: got %s, want good", best.id)
+	}
+	// poor peers are selected if no good peer is ahead
+	if best := ps.bestPeer(big.NewInt(25)); best.id != "poor" {
+		t.Errorf("wrong best peer: got %s, want poor", best.id)
+	}
+}
+
+func TestVerifyHead(t *testing.T) {
+	hashes := createHashes(0, 10)
+	blocks := createBlocksFromHashes(hashes)
+	tester := newTester(t, hashes, blocks)
+	tester.newPeer("peer1", big.NewInt(10000), hashes[0])
+	p := tester.downloader.peers.getPeer("peer1")
+
+	if err := tester.downloader.verifyHead(p); err != nil {
+		t.Errorf("expected valid head to pass, got %v", err)
+	}
+	tester.downloader.SetPoWCheck(func(*types.Block) bool { return false })
+	if err := tester.downloader.verifyHead(p); err == nil {
+		t.Error("expected head with invalid proof of work to be rejected")
+	}
+}
+
+func TestVerifyHeadWrongBlock(t *testing.T) {
+	hashes := createHashes(0, 10)
+	blocks := createBlocksFromHashes(hashes)
+	tester := newTester(t, hashes, blocks)
+
+	// This peer answers the head request with another block
+	tester.downloader.RegisterPeer("peer1", big.NewInt(10000), hashes[0], tester.getHashes, func([]common.Hash) error {
+		go tester.downloader.DeliverChunk("peer1", []*types.Block{blocks[hashes[1]]})
+		return nil
+	})
+	p := tester.downloader.peers.getPeer("peer1")
+	if err := tester.downloader.verifyHead(p); err != errBadHead {
+		t.Errorf("expected %v, got %v", errBadHead, err)
+	}
+}
+
+func TestVerifyHeadTimeout(t *testing.T) {
+	minDesiredPeerCount = 1
+	headTtl = 200 * time.Millisecond
+
+	hashes := createHashes(0, 10)
+	blocks := createBlocksFromHashes(hashes)
+	tester := newTester(t, hashes, blocks)
+
+	// This peer never answers the head request
+	tester.downloader.RegisterPeer("slow", big.NewInt(10000), hashes[0], tester.getHashes, func([]common.Hash) error {
+		return nil
+	})
+	// Registering more peers and delivering blocks must not wait for the
+	// head verification.
+	done := make(chan struct{})
+	go func() {
+		tester.downloader.RegisterPeer("peer2", big.NewInt(0), common.Hash{}, tester.getHashes, tester.getBlocks("peer2"))
+		tester.downloader.RegisterPeer("peer3", big.NewInt(0), common.Hash{}, tester.getHashes, tester.getBlocks("peer3"))
+		tester.downloader.DeliverChunk("peer2", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(headTtl / 2):
+		t.Fatal("peer registration blocked by head verification")
+	}
+
+	time.Sleep(2 * headTtl)
+	if tester.downloader.peers.getPeer("slow") == nil {
+		t.Error("peer dropped after head request timeout")
+	}
+}
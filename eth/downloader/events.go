@@ -0,0 +1,10 @@
+package downloader
+
+// StartEvent is posted when the downloader starts synchronising with a peer.
+type StartEvent struct{}
+
+// DoneEvent is posted when a synchronisation completed.
+type DoneEvent struct{}
+
+// FailedEvent is posted when a synchronisation was aborted.
+type FailedEvent struct{ Err error }
@@ -2,8 +2,10 @@ package downloader
 
 import (
 	"errors"
+	"math"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"gopkg.in/fatih/set.v0"
@@ -51,11 +53,32 @@ func (p peers) getPeer(id string) *peer {
 	return p[id]
 }
 
-func (p peers) bestPeer() *peer {
-	var peer *peer
+// bestPeer returns the peer with the highest TD. Peers advertising
+// the same head block must agree on its TD. If they don't, the lowest
+// claim counts for all of them, so a peer can't outbid the others by
+// lying about the TD of a head they share. Among the peers ahead of the
+// local td, those serving blocks well are preferred over poor ones.
+func (p peers) bestPeer(local *big.Int) *peer {
+	claims := make(map[common.Hash]*big.Int)
 	for _, cp := range p {
-		if peer == nil || cp.td.Cmp(peer.td) > 0 {
-			peer = cp
+		cp.mu.RLock()
+		if td := claims[cp.recentHash]; td == nil || cp.td.Cmp(td) < 0 {
+			claims[cp.recentHash] = cp.td
+		}
+		cp.mu.RUnlock()
+	}
+	var (
+		peer     *peer
+		bestTd   *big.Int
+		bestGood bool
+	)
+	for _, cp := range p {
+		cp.mu.RLock()
+		td := claims[cp.recentHash]
+		good := td.Cmp(local) > 0 && cp.good()
+		cp.mu.RUnlock()
+		if peer == nil || (good && !bestGood) || (good == bestGood && td.Cmp(bestTd) > 0) {
+			peer, bestTd, bestGood = cp, td, good
 		}
 	}
 	return peer
@@ -64,7 +87,6 @@ func (p peers) bestPeer() *peer {
 // peer represents an active peer
 type peer struct {
 	state int // Peer state (working, idle)
-	rep   int // TODO peer reputation
 
 	mu         sync.RWMutex
 	id         string
@@ -73,6 +95,14 @@ type peer struct {
 
 	ignored *set.Set
 
+	// block delivery quality, adapts the chunk size
+	capacity  int           // Amount of blocks requested per chunk
+	started   time.Time     // Time the pending chunk was requested
+	latency   time.Duration // Moving average of the chunk delivery time
+	requested int           // Amount of blocks requested
+	delivered int           // Amount of requested blocks delivered
+	timeouts  int           // Amount of chunks not delivered in time
+
 	getHashes hashFetcherFn
 	getBlocks blockFetcherFn
 }
@@ -87,6 +117,7 @@ func newPeer(id string, td *big.Int, hash common.Hash, getHashes hashFetcherFn,
 		getBlocks:  getBlocks,
 		state:      idleState,
 		ignored:    set.New(),
+		capacity:   maxBlockFetch,
 	}
 }
 
@@ -108,29 +139,79 @@ func (p *peer) fetch(chunk *chunk) error {
 		i++
 		return true
 	})
+	p.started = time.Now()
+	p.requested += len(hashes)
 	p.getBlocks(hashes)
 
 	return nil
 }
 
-// promote increases the peer's reputation
-func (p *peer) promote() {
+// chunkSize returns the amount of blocks to request from the peer at once.
+func (p *peer) chunkSize() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.capacity
+}
+
+// deliver records the delivery of n blocks of the pending chunk. Fast
+// deliveries grow the chunk size of the peer, slow or empty ones shrink it.
+func (p *peer) deliver(n int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.rep++
+	p.delivered += n
+	if p.started.IsZero() {
+		return
+	}
+	latency := time.Since(p.started)
+	p.started = time.Time{}
+	if p.latency == 0 {
+		p.latency = latency
+	} else {
+		p.latency += (latency - p.latency) / 8
+	}
+	switch {
+	case n == 0 || latency > 2*targetChunkTime:
+		p.shrink()
+	case latency < targetChunkTime:
+		p.capacity = int(math.Min(float64(2*p.capacity), maxBlockFetch))
+	}
 }
 
-// demote decreases the peer's reputation or leaves it at 0
-func (p *peer) demote() {
+// timeout records that the pending chunk wasn't delivered in time.
+func (p *peer) timeout() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.rep > 1 {
-		p.rep -= 2
-	} else {
-		p.rep = 0
+	p.timeouts++
+	p.started = time.Time{}
+	p.shrink()
+}
+
+func (p *peer) shrink() {
+	p.capacity = int(math.Max(float64(p.capacity/2), minBlockFetch))
+}
+
+// score returns the block delivery quality of the peer between 0 and 1.
+// It drops with undelivered blocks, timeouts and slow deliveries. The
+// peer lock must be held.
+func (p *peer) score() float64 {
+	score := 1.0
+	if p.requested > 0 {
+		score = float64(p.delivered) / float64(p.requested)
+	}
+	score /= float64(1 + p.timeouts)
+	if p.latency > targetChunkTime {
+		score *= float64(targetChunkTime) / float64(p.latency)
 	}
+	return score
+}
+
+// good reports whether the peer serves blocks well enough to be preferred
+// as sync target. The peer lock must be held.
+func (p *peer) good() bool {
+	return p.score() >= minPeerQuality
 }
 
 func (p *peer) reset() {
@@ -0,0 +1,76 @@
+package downloader
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// extraDb key of the persisted download progress
+var progressKey = []byte("downloader-progress")
+
+// SetDb sets the database the download progress is persisted to. The hashes
+// of an interrupted sync are restored from it and aren't fetched again once
+// a peer's chain reaches them. It must be called before peers are registered.
+func (d *Downloader) SetDb(db common.Database) {
+	d.db = db
+
+	data, err := db.Get(progressKey)
+	if err != nil {
+		return
+	}
+	var hashes []common.Hash
+	if err := rlp.DecodeBytes(data, &hashes); err != nil {
+		glog.V(logger.Warn).Infof("cannot decode download progress: %v", err)
+		return
+	}
+	d.restored = make(map[common.Hash]int, len(hashes))
+	for i, hash := range hashes {
+		d.restored[hash] = i
+	}
+	d.restoredHashes = hashes
+	glog.V(logger.Info).Infof("restored download progress: %d block hashes", len(hashes))
+}
+
+// saveProgress persists the hashes of the blocks to be downloaded, ordered
+// from the sync target down to the common ancestor.
+func (d *Downloader) saveProgress(hashes []common.Hash) {
+	if d.db == nil {
+		return
+	}
+	data, err := rlp.EncodeToBytes(hashes)
+	if err != nil {
+		glog.V(logger.Warn).Infof("cannot encode download progress: %v", err)
+		return
+	}
+	d.db.Put(progressKey, data)
+}
+
+// clearProgress removes the persisted progress once its blocks are inserted
+// or turned out to be bad.
+func (d *Downloader) clearProgress() {
+	if d.db != nil {
+		d.db.Delete(progressKey)
+	}
+}
+
+// resumeFrom returns the restored hashes from hash downwards which are not
+// yet in the chain. It returns nil if hash isn't part of the restored
+// progress. The restored progress is resumed at most once.
+func (d *Downloader) resumeFrom(hash common.Hash) []common.Hash {
+	i, ok := d.restored[hash]
+	if !ok {
+		return nil
+	}
+	defer func() { d.restored, d.restoredHashes = nil, nil }()
+
+	var hashes []common.Hash
+	for _, h := range d.restoredHashes[i:] {
+		if d.hasBlock(h) {
+			break
+		}
+		hashes = append(hashes, h)
+	}
+	return hashes
+}
@@ -27,7 +27,7 @@ func (d *Downloader) SynchroniseWithPeer(id string) (types.Blocks, error) {
 			return nil, errUnknownPeer
 		}
 	} else {
-		p = d.peers.bestPeer()
+		p = d.peers.bestPeer(d.currentTd())
 	}
 
 	// Make sure our td is lower than the peer's td
@@ -38,6 +38,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"math/rand"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -73,38 +74,67 @@ type ProtocolManager struct {
 	chainman       *core.ChainManager
 	downloader     *downloader.Downloader
 
+	// size limits of incoming messages and the blocks and
+	// transactions contained in them.
+	maxMsgSize   uint32
+	maxBlockSize uint64
+	maxTxSize    uint64
+
 	pmu   sync.Mutex
 	peers map[string]*peer
 
-	SubProtocol p2p.Protocol
+	// SubProtocols holds one protocol per supported version. The
+	// p2p server runs the highest version shared with each peer.
+	SubProtocols []p2p.Protocol
 }
 
 // NewProtocolManager returns a new ethereum sub protocol manager. The Ethereum sub protocol manages peers capable
-// with the ethereum network.
-func NewProtocolManager(protocolVersion, networkId int, txpool txPool, chainman *core.ChainManager, downloader *downloader.Downloader) *ProtocolManager {
+// with the ethereum network. A sub protocol is created for each of the given protocol versions.
+func NewProtocolManager(protocolVersions []int, networkId int, txpool txPool, chainman *core.ChainManager, downloader *downloader.Downloader) *ProtocolManager {
 	manager := &ProtocolManager{
-		txpool:     txpool,
-		chainman:   chainman,
-		downloader: downloader,
-		peers:      make(map[string]*peer),
+		txpool:       txpool,
+		chainman:     chainman,
+		downloader:   downloader,
+		maxMsgSize:   ProtocolMaxMsgSize,
+		maxBlockSize: defaultMaxBlockSize,
+		maxTxSize:    core.DefaultMaxTxSize,
+		peers:        make(map[string]*peer),
 	}
 
-	manager.SubProtocol = p2p.Protocol{
-		Name:    "eth",
-		Version: uint(protocolVersion),
-		Length:  ProtocolLength,
-		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
-			peer := manager.newPeer(protocolVersion, networkId, p, rw)
-			err := manager.handle(peer)
-			//glog.V(logger.Detail).Infof("[%s]: %v\n", peer.id, err)
-
-			return err
-		},
+	for _, version := range protocolVersions {
+		version := version
+		manager.SubProtocols = append(manager.SubProtocols, p2p.Protocol{
+			Name:    "eth",
+			Version: uint(version),
+			Length:  ProtocolLengths[version],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				peer := manager.newPeer(version, networkId, p, rw)
+				err := manager.handle(peer)
+				//glog.V(logger.Detail).Infof("[%s]: %v\n", peer.id, err)
+
+				return err
+			},
+		})
 	}
 
 	return manager
 }
 
+// SetSizeLimits sets the maximum size of messages received from peers
+// and of the blocks and transactions in them. Zero values keep the
+// current limit.
+func (pm *ProtocolManager) SetSizeLimits(msg uint32, block, tx uint64) {
+	if msg > 0 {
+		pm.maxMsgSize = msg
+	}
+	if block > 0 {
+		pm.maxBlockSize = block
+	}
+	if tx > 0 {
+		pm.maxTxSize = tx
+	}
+}
+
 func (pm *ProtocolManager) newPeer(pv, nv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 
 	td, current, genesis := pm.chainman.Status()
@@ -129,9 +159,12 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}()
 
 	// propagate existing transactions. new transactions appearing
-	// after this will be sent via broadcasts.
-	if err := p.sendTransactions(pm.txpool.GetTransactions()); err != nil {
-		return err
+	// after this will be sent via broadcasts. While syncing, the
+	// pool can't tell valid transactions apart, don't relay them.
+	if !pm.downloader.Synchronising() {
+		if err := p.sendTransactions(pm.txpool.GetTransactions()); err != nil {
+			return err
+		}
 	}
 
 	// main loop. handle incoming messages.
@@ -149,27 +182,73 @@ func (self *ProtocolManager) handleMsg(p *peer) error {
 	if err != nil {
 		return err
 	}
-	if msg.Size > ProtocolMaxMsgSize {
-		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	if msg.Size > self.maxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, self.maxMsgSize)
 	}
 	// make sure that the payload has been fully consumed
 	defer msg.Discard()
 
 	switch msg.Code {
-	case GetTxMsg: // ignore
+	case GetTxMsg:
+		if p.protv < eth61 {
+			return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+		}
+		var hashes []common.Hash
+		if err := msg.Decode(&hashes); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		var txs types.Transactions
+		for i, hash := range hashes {
+			if i == maxHashes {
+				break
+			}
+			if tx := self.txpool.GetTransaction(hash); tx != nil {
+				txs = append(txs, tx)
+			}
+		}
+		return p.sendTransactions(txs)
+
+	case NewTxHashesMsg:
+		if p.protv < eth61 {
+			return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+		}
+		var hashes []common.Hash
+		if err := msg.Decode(&hashes); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// the pool drops remote transactions while syncing,
+		// fetching them would be a waste of bandwidth.
+		if self.downloader.Synchronising() {
+			break
+		}
+		// request the transactions we don't know yet from the announcing peer
+		var unknown []common.Hash
+		for i, hash := range hashes {
+			if i == maxHashes {
+				break
+			}
+			p.markTransaction(hash)
+			if self.txpool.GetTransaction(hash) == nil {
+				unknown = append(unknown, hash)
+			}
+		}
+		if len(unknown) > 0 {
+			return p.requestTransactions(unknown)
+		}
+
 	case StatusMsg:
 		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
 
 	case TxMsg:
-		// TODO: rework using lazy RLP stream
-		var txs []*types.Transaction
-		if err := msg.Decode(&txs); err != nil {
+		txs, err := decodeTxs(msg, self.maxTxSize)
+		if err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
 		for i, tx := range txs {
 			if tx == nil {
 				return errResp(ErrDecode, "transaction %d is nil", i)
 			}
+			p.markTransaction(tx.Hash())
 			jsonlogger.LogJson(&logger.EthTxReceived{
 				TxHash:   tx.Hash().Hex(),
 				RemoteId: p.ID().String(),
@@ -237,27 +316,38 @@ func (self *ProtocolManager) handleMsg(p *peer) error {
 		}
 		return p.sendBlocks(blocks)
 	case BlocksMsg:
-		var blocks []*types.Block
-
-		msgStream := rlp.NewStream(msg.Payload, uint64(msg.Size))
-		if err := msgStream.Decode(&blocks); err != nil {
+		blocks, err := decodeBlocks(msg, self.maxBlockSize)
+		if err != nil {
 			glog.V(logger.Detail).Infoln("Decode error", err)
 			blocks = nil
 		}
-		self.downloader.DeliverChunk(p.id, blocks)
+		if p.announcedReply() {
+			for _, block := range blocks {
+				if err := self.importAnnouncedBlock(p, block); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err := self.downloader.DeliverChunk(p.id, blocks); err != nil {
+			return errResp(ErrCheckpointMismatch, "%v", err)
+		}
 
 	case NewBlockMsg:
-		var request newBlockMsgData
-		if err := msg.Decode(&request); err != nil {
+		request, err := decodeNewBlock(msg, self.maxBlockSize)
+		if err != nil {
 			return errResp(ErrDecode, "%v: %v", msg, err)
 		}
 		if err := request.Block.ValidateFields(); err != nil {
 			return errResp(ErrDecode, "block validation %v: %v", msg, err)
 		}
+		if err := self.downloader.VerifyCheckpoints(types.Blocks{request.Block}); err != nil {
+			return errResp(ErrCheckpointMismatch, "%v", err)
+		}
 		hash := request.Block.Hash()
 		// Add the block hash as a known hash to the peer. This will later be used to detirmine
 		// who should receive this.
-		p.blockHashes.Add(hash)
+		p.markBlock(hash)
 
 		_, chainHead, _ := self.chainman.Status()
 
@@ -304,32 +394,129 @@ func (self *ProtocolManager) handleMsg(p *peer) error {
 				//fmt.Println(request.Block.Hash().Hex(), "our calculated TD =", request.Block.Td, "their TD =", request.TD)
 			}()
 		}
+
+	case NewBlockHashesMsg:
+		if p.protv < eth62 {
+			return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+		}
+		var hashes []common.Hash
+		if err := msg.Decode(&hashes); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		// fetch the blocks we don't have yet from the announcing peer
+		var unknown []common.Hash
+		for i, hash := range hashes {
+			if i == maxBlocks {
+				break
+			}
+			p.markBlock(hash)
+			if !self.chainman.HasBlock(hash) {
+				unknown = append(unknown, hash)
+			}
+		}
+		if len(unknown) > 0 {
+			return p.requestAnnouncedBlocks(unknown)
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
 	return nil
 }
 
-// BroadcastBlock will propagate the block to its connected peers. It will sort
-// out which peers do not contain the block in their block set and will do a
-// sqrt(peers) to determine the amount of peers we broadcast to.
+// importAnnouncedBlock inserts a block fetched after a hash announcement
+// and propagates it further. Blocks whose parent is unknown are dropped,
+// the downloader will fetch them when syncing with a better peer.
+func (self *ProtocolManager) importAnnouncedBlock(p *peer, block *types.Block) error {
+	if err := block.ValidateFields(); err != nil {
+		return errResp(ErrDecode, "block validation: %v", err)
+	}
+	if err := self.downloader.VerifyCheckpoints(types.Blocks{block}); err != nil {
+		return errResp(ErrCheckpointMismatch, "%v", err)
+	}
+	hash := block.Hash()
+	p.markBlock(hash)
+	if self.chainman.HasBlock(hash) || !self.chainman.HasBlock(block.ParentHash()) {
+		return nil
+	}
+	if err := self.chainman.InsertChain(types.Blocks{block}); err != nil {
+		glog.V(logger.Debug).Infof("[%s] announced block %x: %v\n", p.id, hash[:4], err)
+		return nil
+	}
+	self.BroadcastBlock(hash, block)
+	return nil
+}
+
+// BroadcastBlock propagates a block to the peers which don't know it
+// yet. A random sqrt(peers) subset receives the full block, the
+// remaining peers only its hash and fetch the block if they need it.
+// Peers which don't support announcements always receive the block.
 func (pm *ProtocolManager) BroadcastBlock(hash common.Hash, block *types.Block) {
 	pm.pmu.Lock()
-	defer pm.pmu.Unlock()
-
-	// Find peers who don't know anything about the given hash. Peers that
-	// don't know about the hash will be a candidate for the broadcast loop
 	var peers []*peer
 	for _, peer := range pm.peers {
 		if !peer.blockHashes.Has(hash) {
 			peers = append(peers, peer)
 		}
 	}
-	// Broadcast block to peer set
-	// XXX due to the current shit state of the network disable the limit
-	peers = peers[:int(math.Sqrt(float64(len(peers))))]
-	for _, peer := range peers {
-		peer.sendNewBlock(block)
+	pm.pmu.Unlock()
+
+	for i := range peers {
+		j := rand.Intn(i + 1)
+		peers[i], peers[j] = peers[j], peers[i]
+	}
+	full := int(math.Sqrt(float64(len(peers))))
+	var announced int
+	for i, peer := range peers {
+		var err error
+		if i < full || peer.protv < eth62 {
+			err = peer.sendNewBlock(block)
+		} else {
+			err = peer.sendNewBlockHashes([]common.Hash{hash})
+			announced++
+		}
+		// a failed write means the peer is gone, its
+		// protocol handler removes it.
+		if err != nil {
+			glog.V(logger.Debug).Infof("[%s] block broadcast failed: %v\n", peer.id, err)
+		}
+	}
+	glog.V(logger.Detail).Infoln("broadcast block to", len(peers)-announced, "peers, announced to", announced)
+}
+
+// BroadcastTx propagates a transaction to the peers which don't know it
+// yet. A random sqrt(peers) subset receives the full transaction, the
+// remaining peers only its hash and pull the transaction if they need it.
+// Peers which don't support announcements always receive the transaction.
+func (pm *ProtocolManager) BroadcastTx(hash common.Hash, tx *types.Transaction) {
+	pm.pmu.Lock()
+	var peers []*peer
+	for _, peer := range pm.peers {
+		if !peer.txHashes.Has(hash) {
+			peers = append(peers, peer)
+		}
+	}
+	pm.pmu.Unlock()
+
+	for i := range peers {
+		j := rand.Intn(i + 1)
+		peers[i], peers[j] = peers[j], peers[i]
+	}
+	full := int(math.Sqrt(float64(len(peers))))
+	var announced int
+	for i, peer := range peers {
+		var err error
+		if i < full || peer.protv < eth61 {
+			err = peer.sendTransactions(types.Transactions{tx})
+		} else {
+			err = peer.sendTxHashes([]common.Hash{hash})
+			announced++
+		}
+		// a failed write means the peer is gone, its
+		// protocol handler removes it.
+		if err != nil {
+			glog.V(logger.Debug).Infof("[%s] tx broadcast failed: %v\n", peer.id, err)
+		}
 	}
-	glog.V(logger.Detail).Infoln("broadcast block to", len(peers), "peers")
+	glog.V(logger.Detail).Infoln("broadcast tx to", len(peers)-announced, "peers, announced to", announced)
 }
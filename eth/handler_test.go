@@ -0,0 +1,111 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p"
+	"gopkg.in/fatih/set.v0"
+)
+
+func newTestPeer(rw p2p.MsgReadWriter, protv int) *peer {
+	return &peer{rw: rw, protv: protv, id: "test", txHashes: set.New(), blockHashes: set.New()}
+}
+
+func TestTxAnnouncementsNeedEth61(t *testing.T) {
+	pm := NewProtocolManager(nil, NetworkId, nil, nil, nil)
+	for _, code := range []uint64{GetTxMsg, NewTxHashesMsg} {
+		app, net := p2p.MsgPipe()
+		go p2p.Send(net, code, []common.Hash{{1}})
+		if err := pm.handleMsg(newTestPeer(app, eth60)); err == nil {
+			t.Errorf("message %d accepted from eth/60 peer", code)
+		}
+		app.Close()
+	}
+}
+
+func TestBlockAnnouncementsNeedEth62(t *testing.T) {
+	pm := NewProtocolManager(nil, NetworkId, nil, nil, nil)
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+
+	go p2p.Send(net, NewBlockHashesMsg, []common.Hash{{1}})
+	if err := pm.handleMsg(newTestPeer(app, eth61)); err == nil {
+		t.Error("block announcement accepted from eth/61 peer")
+	}
+}
+
+func TestBlockAnnouncementFetch(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	chainman := core.NewChainManager(db, db, new(event.TypeMux))
+	defer chainman.Stop()
+	pm := NewProtocolManager(nil, NetworkId, nil, chainman, downloader.New(chainman.HasBlock, chainman.InsertChain, chainman.Td))
+
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	p := newTestPeer(app, eth62)
+
+	// only the unknown block is requested
+	header := &types.Header{ParentHash: common.Hash{0xff}, Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: new(big.Int), GasUsed: new(big.Int)}
+	block := types.NewBlock(header, nil, nil, nil)
+	errc := make(chan error, 1)
+	go func() { errc <- pm.handleMsg(p) }()
+	go p2p.Send(net, NewBlockHashesMsg, []common.Hash{chainman.Genesis().Hash(), block.Hash()})
+	if err := p2p.ExpectMsg(net, GetBlocksMsg, []common.Hash{block.Hash()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("announcement failed: %v", err)
+	}
+	if !p.blockHashes.Has(block.Hash()) {
+		t.Error("announced block not marked as known by the peer")
+	}
+	if len(p.blockReqs) != 1 || !p.blockReqs[0] {
+		t.Fatalf("pending requests %v, want one for announced blocks", p.blockReqs)
+	}
+
+	// the reply is imported by the protocol manager rather than handed
+	// to the downloader, the block is dropped as its parent is unknown
+	go func() { errc <- pm.handleMsg(p) }()
+	go p2p.Send(net, BlocksMsg, []*types.Block{block})
+	if err := <-errc; err != nil {
+		t.Fatalf("reply failed: %v", err)
+	}
+	if len(p.blockReqs) != 0 {
+		t.Errorf("%d requests pending after reply", len(p.blockReqs))
+	}
+	if chainman.HasBlock(block.Hash()) {
+		t.Error("block with unknown parent imported")
+	}
+}
+
+func TestAnnouncedReplyOrder(t *testing.T) {
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	go func() {
+		for {
+			msg, err := net.ReadMsg()
+			if err != nil {
+				return
+			}
+			msg.Discard()
+		}
+	}()
+
+	p := newTestPeer(app, eth62)
+	p.requestBlocks([]common.Hash{{1}})
+	p.requestAnnouncedBlocks([]common.Hash{{2}})
+	p.requestBlocks([]common.Hash{{3}})
+
+	for i, want := range []bool{false, true, false, false} {
+		if got := p.announcedReply(); got != want {
+			t.Errorf("reply %d: announced = %t, want %t", i, got, want)
+		}
+	}
+}
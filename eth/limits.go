@@ -0,0 +1,95 @@
+package eth
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// defaultMaxBlockSize is the default limit of the encoded size of
+	// blocks received from peers.
+	defaultMaxBlockSize = 1024 * 1024
+)
+
+// decodeList decodes the RLP list in the payload of msg element by
+// element, calling decode for each. The size of every element is checked
+// against limit before it is decoded, so oversized elements are
+// rejected without decoding them.
+func decodeList(msg p2p.Msg, limit uint64, decode func(*rlp.Stream) error) error {
+	s := rlp.NewStream(msg.Payload, uint64(msg.Size))
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	for i := 0; ; i++ {
+		_, size, err := s.Kind()
+		if err == rlp.EOL {
+			break
+		} else if err != nil {
+			return err
+		}
+		if size > limit {
+			return fmt.Errorf("element %d too large (%d > %d bytes)", i, size, limit)
+		}
+		if err := decode(s); err != nil {
+			return fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+	return s.ListEnd()
+}
+
+// decodeTxs decodes a TxMsg, rejecting transactions larger than limit.
+func decodeTxs(msg p2p.Msg, limit uint64) (txs []*types.Transaction, err error) {
+	err = decodeList(msg, limit, func(s *rlp.Stream) error {
+		tx := new(types.Transaction)
+		if err := s.Decode(tx); err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+		return nil
+	})
+	return txs, err
+}
+
+// decodeBlocks decodes a BlocksMsg, rejecting blocks larger than limit.
+func decodeBlocks(msg p2p.Msg, limit uint64) (blocks []*types.Block, err error) {
+	err = decodeList(msg, limit, func(s *rlp.Stream) error {
+		block := new(types.Block)
+		if err := s.Decode(block); err != nil {
+			return err
+		}
+		blocks = append(blocks, block)
+		return nil
+	})
+	return blocks, err
+}
+
+// decodeNewBlock decodes a NewBlockMsg, rejecting blocks larger than
+// limit.
+func decodeNewBlock(msg p2p.Msg, limit uint64) (*newBlockMsgData, error) {
+	var (
+		request newBlockMsgData
+		field   int
+	)
+	err := decodeList(msg, limit, func(s *rlp.Stream) error {
+		field++
+		switch field {
+		case 1:
+			request.Block = new(types.Block)
+			return s.Decode(request.Block)
+		case 2:
+			return s.Decode(&request.TD)
+		default:
+			return fmt.Errorf("unexpected field")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if field != 2 {
+		return nil, fmt.Errorf("expected 2 fields, got %d", field)
+	}
+	return &request, nil
+}
@@ -0,0 +1,54 @@
+package eth
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func encodeMsg(t *testing.T, code uint64, data interface{}) p2p.Msg {
+	enc, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p2p.Msg{Code: code, Size: uint32(len(enc)), Payload: bytes.NewReader(enc)}
+}
+
+func TestDecodeTxsLimit(t *testing.T) {
+	small := types.NewTransactionMessage(common.Address{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), nil)
+	large := types.NewTransactionMessage(common.Address{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), make([]byte, 1024))
+	limit := uint64(small.Size()) + 10
+
+	txs, err := decodeTxs(encodeMsg(t, TxMsg, []*types.Transaction{small, small}), limit)
+	if err != nil {
+		t.Fatalf("small transactions rejected: %v", err)
+	}
+	if len(txs) != 2 || txs[1].Hash() != small.Hash() {
+		t.Errorf("decoded %d transactions, want 2", len(txs))
+	}
+	if _, err := decodeTxs(encodeMsg(t, TxMsg, []*types.Transaction{small, large}), limit); err == nil {
+		t.Error("oversized transaction accepted")
+	}
+}
+
+func TestDecodeNewBlock(t *testing.T) {
+	header := &types.Header{Difficulty: big.NewInt(1), Number: big.NewInt(1), GasLimit: new(big.Int), GasUsed: new(big.Int)}
+	block := types.NewBlock(header, nil, nil, nil)
+	td := big.NewInt(100)
+
+	request, err := decodeNewBlock(encodeMsg(t, NewBlockMsg, []interface{}{block, td}), uint64(block.Size()))
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if request.Block.Hash() != block.Hash() || request.TD.Cmp(td) != 0 {
+		t.Errorf("decoded block %x with TD %v, want %x with TD %v", request.Block.Hash(), request.TD, block.Hash(), td)
+	}
+	if _, err := decodeNewBlock(encodeMsg(t, NewBlockMsg, []interface{}{block, td}), uint64(block.Size())/2); err == nil {
+		t.Error("oversized block accepted")
+	}
+}
@@ -0,0 +1,103 @@
+package eth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+const (
+	ntpPool   = "pool.ntp.org" // NTP servers queried for the current time
+	ntpChecks = 3              // number of measurements averaged
+)
+
+// ntpEpoch is the zero point of NTP timestamps.
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// checkClockDrift queries the NTP pool and warns if the local clock is
+// off by more than allowed. Blocks are rejected if their timestamp is
+// too far ahead of the local clock, so a clock running behind causes
+// valid blocks to be dropped.
+func checkClockDrift(allowed time.Duration) {
+	drift, err := sntpDrift(ntpChecks)
+	if err != nil {
+		glog.V(logger.Debug).Infoln("could not check the system clock:", err)
+		return
+	}
+	if drift < -allowed || drift > allowed {
+		glog.V(logger.Warn).Infof("System clock seems off by %v, which exceeds the allowed drift of %v.\n", drift, allowed)
+		glog.V(logger.Warn).Infoln("Blocks may be rejected. Please enable network time synchronisation in system settings.")
+	} else {
+		glog.V(logger.Debug).Infof("System clock drift: %v\n", drift)
+	}
+}
+
+// sntpDrift performs a number of SNTP requests and returns how far
+// the local clock is ahead of the server's. The fastest and slowest
+// measurement are discarded, the others are averaged.
+func sntpDrift(measurements int) (time.Duration, error) {
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(ntpPool, "123"))
+	if err != nil {
+		return 0, err
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	// SNTP version 3, client mode.
+	request := make([]byte, 48)
+	request[0] = 3<<3 | 3
+
+	drifts := make([]time.Duration, 0, measurements+2)
+	for i := 0; i < measurements+2; i++ {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		sent := time.Now()
+		if _, err = conn.Write(request); err != nil {
+			return 0, err
+		}
+		reply := make([]byte, 48)
+		if _, err = conn.Read(reply); err != nil {
+			return 0, err
+		}
+		elapsed := time.Since(sent)
+
+		t, err := ntpTime(reply)
+		if err != nil {
+			return 0, err
+		}
+		// The server's clock was read about halfway through the exchange.
+		drifts = append(drifts, sent.Add(elapsed/2).Sub(t))
+	}
+	sort.Sort(durationSlice(drifts))
+
+	var total time.Duration
+	for _, d := range drifts[1 : len(drifts)-1] {
+		total += d
+	}
+	return total / time.Duration(measurements), nil
+}
+
+// ntpTime returns the transmit timestamp of an SNTP reply.
+func ntpTime(reply []byte) (time.Time, error) {
+	if len(reply) < 48 {
+		return time.Time{}, fmt.Errorf("short NTP reply (%d bytes)", len(reply))
+	}
+	sec := uint64(binary.BigEndian.Uint32(reply[40:]))
+	frac := uint64(binary.BigEndian.Uint32(reply[44:]))
+	nsec := sec*1e9 + frac*1e9>>32
+	return ntpEpoch.Add(time.Duration(nsec)), nil
+}
+
+type durationSlice []time.Duration
+
+func (s durationSlice) Len() int           { return len(s) }
+func (s durationSlice) Less(i, j int) bool { return s[i] < s[j] }
+func (s durationSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
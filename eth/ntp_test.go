@@ -0,0 +1,25 @@
+package eth
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestNTPTime(t *testing.T) {
+	want := time.Date(2015, 6, 1, 12, 0, 0, 500000000, time.UTC)
+	reply := make([]byte, 48)
+	binary.BigEndian.PutUint32(reply[40:], uint32(want.Sub(ntpEpoch)/time.Second))
+	binary.BigEndian.PutUint32(reply[44:], 1<<31) // half a second
+
+	have, err := ntpTime(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !have.Equal(want) {
+		t.Errorf("got %v, want %v", have, want)
+	}
+	if _, err := ntpTime(reply[:40]); err == nil {
+		t.Error("expected error for short reply")
+	}
+}
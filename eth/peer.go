@@ -3,6 +3,7 @@ package eth
 import (
 	"fmt"
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -41,6 +42,12 @@ type peer struct {
 
 	txHashes    *set.Set
 	blockHashes *set.Set
+
+	// blockReqs has an entry for every GetBlocksMsg that wasn't
+	// answered yet. It is true if the request fetches announced
+	// blocks rather than blocks for the downloader.
+	reqMu     sync.Mutex
+	blockReqs []bool
 }
 
 func newPeer(protv, netid int, genesis, currentHash common.Hash, td *big.Int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -66,12 +73,36 @@ func newPeer(protv, netid int, genesis, currentHash common.Hash, td *big.Int, p
 // transaction
 func (p *peer) sendTransactions(txs types.Transactions) error {
 	for _, tx := range txs {
-		p.txHashes.Add(tx.Hash())
+		p.markTransaction(tx.Hash())
 	}
 
 	return p2p.Send(p.rw, TxMsg, txs)
 }
 
+// sendTxHashes announces transactions to the peer by their hashes. The
+// peer requests the ones it doesn't know yet with a GetTxMsg.
+func (p *peer) sendTxHashes(hashes []common.Hash) error {
+	for _, hash := range hashes {
+		p.markTransaction(hash)
+	}
+
+	return p2p.Send(p.rw, NewTxHashesMsg, hashes)
+}
+
+func (p *peer) requestTransactions(hashes []common.Hash) error {
+	glog.V(logger.Detail).Infof("[%s] fetching %v transactions\n", p.id, len(hashes))
+	return p2p.Send(p.rw, GetTxMsg, hashes)
+}
+
+// markTransaction records that the peer knows the transaction. Once the
+// set is full, random hashes are forgotten to bound its size.
+func (p *peer) markTransaction(hash common.Hash) {
+	for p.txHashes.Size() >= maxKnownTxs {
+		p.txHashes.Pop()
+	}
+	p.txHashes.Add(hash)
+}
+
 func (p *peer) sendBlockHashes(hashes []common.Hash) error {
 	return p2p.Send(p.rw, BlockHashesMsg, hashes)
 }
@@ -81,11 +112,30 @@ func (p *peer) sendBlocks(blocks []*types.Block) error {
 }
 
 func (p *peer) sendNewBlock(block *types.Block) error {
-	p.blockHashes.Add(block.Hash())
+	p.markBlock(block.Hash())
 
 	return p2p.Send(p.rw, NewBlockMsg, []interface{}{block, block.Td})
 }
 
+// sendNewBlockHashes announces new blocks to the peer by their hashes.
+// The peer requests the ones it doesn't know yet with a GetBlocksMsg.
+func (p *peer) sendNewBlockHashes(hashes []common.Hash) error {
+	for _, hash := range hashes {
+		p.markBlock(hash)
+	}
+
+	return p2p.Send(p.rw, NewBlockHashesMsg, hashes)
+}
+
+// markBlock records that the peer knows the block. Once the set
+// is full, random hashes are forgotten to bound its size.
+func (p *peer) markBlock(hash common.Hash) {
+	for p.blockHashes.Size() >= maxKnownBlocks {
+		p.blockHashes.Pop()
+	}
+	p.blockHashes.Add(hash)
+}
+
 func (p *peer) requestHashes(from common.Hash) error {
 	glog.V(logger.Debug).Infof("[%s] fetching hashes (%d) %x...\n", p.id, maxHashes, from[:4])
 	return p2p.Send(p.rw, GetBlockHashesMsg, getBlockHashesMsgData{from, maxHashes})
@@ -93,9 +143,38 @@ func (p *peer) requestHashes(from common.Hash) error {
 
 func (p *peer) requestBlocks(hashes []common.Hash) error {
 	glog.V(logger.Debug).Infof("[%s] fetching %v blocks\n", p.id, len(hashes))
+	return p.sendGetBlocks(hashes, false)
+}
+
+// requestAnnouncedBlocks fetches blocks the peer announced by hash.
+// The reply is imported by the protocol manager, not the downloader.
+func (p *peer) requestAnnouncedBlocks(hashes []common.Hash) error {
+	glog.V(logger.Detail).Infof("[%s] fetching %v announced blocks\n", p.id, len(hashes))
+	return p.sendGetBlocks(hashes, true)
+}
+
+func (p *peer) sendGetBlocks(hashes []common.Hash, announced bool) error {
+	p.reqMu.Lock()
+	p.blockReqs = append(p.blockReqs, announced)
+	p.reqMu.Unlock()
+
 	return p2p.Send(p.rw, GetBlocksMsg, hashes)
 }
 
+// announcedReply reports whether the BlocksMsg just received answers
+// a request for announced blocks. Peers reply to requests in order.
+func (p *peer) announcedReply() bool {
+	p.reqMu.Lock()
+	defer p.reqMu.Unlock()
+
+	if len(p.blockReqs) == 0 {
+		return false
+	}
+	announced := p.blockReqs[0]
+	p.blockReqs = p.blockReqs[1:]
+	return announced
+}
+
 func (p *peer) handleStatus() error {
 	errc := make(chan error, 1)
 	go func() {
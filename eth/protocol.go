@@ -7,25 +7,41 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// eth protocol versions
 const (
-	ProtocolVersion    = 60
+	eth60 = 60
+	eth61 = 61 // transaction announcements by hash
+	eth62 = 62 // block announcements by hash
+)
+
+const (
+	ProtocolVersion    = eth62
 	NetworkId          = 0
-	ProtocolLength     = uint64(8)
 	ProtocolMaxMsgSize = 10 * 1024 * 1024
 	maxHashes          = 512
 	maxBlocks          = 128
+	maxKnownTxs        = 32768 // per peer
+	maxKnownBlocks     = 1024  // per peer
 )
 
+// ProtocolVersions are the supported eth protocol versions, highest first.
+var ProtocolVersions = []int{eth62, eth61, eth60}
+
+// ProtocolLengths are the number of message codes used by each version.
+var ProtocolLengths = map[int]uint64{eth60: 8, eth61: 9, eth62: 10}
+
 // eth protocol message codes
 const (
 	StatusMsg = iota
-	GetTxMsg  // unused
+	GetTxMsg  // eth61
 	TxMsg
 	GetBlockHashesMsg
 	BlockHashesMsg
 	GetBlocksMsg
 	BlocksMsg
 	NewBlockMsg
+	NewTxHashesMsg    // eth61
+	NewBlockHashesMsg // eth62
 )
 
 type errCode int
@@ -40,6 +56,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrCheckpointMismatch
 )
 
 func (e errCode) String() string {
@@ -57,6 +74,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrCheckpointMismatch:      "Checkpoint mismatch",
 }
 
 // backend is the interface the ethereum protocol backend should implement
@@ -64,6 +82,7 @@ var errorToString = map[int]string{
 type txPool interface {
 	AddTransactions([]*types.Transaction)
 	GetTransactions() types.Transactions
+	GetTransaction(hash common.Hash) *types.Transaction
 }
 
 type chainManager interface {
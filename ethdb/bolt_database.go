@@ -0,0 +1,253 @@
+package ethdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// boltBucket is the bucket holding all keys.
+var boltBucket = []byte("ethdb")
+
+/*
+BoltDatabase stores the keys in a single BoltDB bucket. Unlike LevelDB,
+BoltDB doesn't compact in the background, so imports don't stall on slow
+disks. Like LDBDatabase, writes are queued in memory and written in a single
+transaction on Flush, which happens every minute and when the database is
+closed.
+*/
+type BoltDatabase struct {
+	fn string
+
+	mu sync.Mutex
+	db *bolt.DB
+
+	queue map[string][]byte
+
+	quit chan struct{}
+}
+
+func NewBoltDatabase(file string) (*BoltDatabase, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	database := &BoltDatabase{
+		fn:    file,
+		db:    db,
+		queue: make(map[string][]byte),
+		quit:  make(chan struct{}),
+	}
+
+	go database.update()
+
+	return database, nil
+}
+
+func (self *BoltDatabase) Put(key []byte, value []byte) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.queue[string(key)] = value
+}
+
+func (self *BoltDatabase) Get(key []byte) ([]byte, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if dat, ok := self.queue[string(key)]; ok {
+		return dat, nil
+	}
+
+	var dat []byte
+	err := self.db.View(func(tx *bolt.Tx) error {
+		// the value is only valid during the transaction
+		if v := tx.Bucket(boltBucket).Get(key); v != nil {
+			dat = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return dat, err
+}
+
+func (self *BoltDatabase) Delete(key []byte) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	delete(self.queue, string(key))
+
+	return self.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+func (self *BoltDatabase) LastKnownTD() []byte {
+	data, _ := self.Get([]byte("LTD"))
+
+	if len(data) == 0 {
+		data = []byte{0x0}
+	}
+
+	return data
+}
+
+// NewIterator returns an iterator holding a read transaction until it is
+// released. The database must not be written to from the goroutine using
+// the iterator before it is released.
+func (self *BoltDatabase) NewIterator() Iterator {
+	tx, err := self.db.Begin(false)
+	if err != nil {
+		return &boltIterator{err: err}
+	}
+	return &boltIterator{tx: tx, cursor: tx.Bucket(boltBucket).Cursor()}
+}
+
+func (self *BoltDatabase) NewBatch() Batch {
+	return &boltBatch{db: self}
+}
+
+func (self *BoltDatabase) Flush() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if len(self.queue) == 0 {
+		return nil
+	}
+	err := self.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for key, value := range self.queue {
+			if err := bucket.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	self.queue = make(map[string][]byte)
+	return nil
+}
+
+func (self *BoltDatabase) Close() {
+	self.quit <- struct{}{}
+	<-self.quit
+	glog.V(logger.Info).Infoln("flushed and closed db:", self.fn)
+}
+
+func (self *BoltDatabase) update() {
+	ticker := time.NewTicker(1 * time.Minute)
+done:
+	for {
+		select {
+		case <-ticker.C:
+			if err := self.Flush(); err != nil {
+				glog.V(logger.Error).Infof("error: flush '%s': %v\n", self.fn, err)
+			}
+		case <-self.quit:
+			break done
+		}
+	}
+
+	if err := self.Flush(); err != nil {
+		glog.V(logger.Error).Infof("error: flush '%s': %v\n", self.fn, err)
+	}
+
+	self.db.Close()
+
+	self.quit <- struct{}{}
+}
+
+type boltIterator struct {
+	tx     *bolt.Tx
+	cursor *bolt.Cursor
+	err    error
+
+	started    bool
+	key, value []byte
+}
+
+func (self *boltIterator) Next() bool {
+	if self.cursor == nil {
+		return false
+	}
+	if !self.started {
+		self.started = true
+		self.key, self.value = self.cursor.First()
+	} else if self.key != nil {
+		self.key, self.value = self.cursor.Next()
+	}
+	return self.key != nil
+}
+
+func (self *boltIterator) Seek(key []byte) bool {
+	if self.cursor == nil {
+		return false
+	}
+	self.started = true
+	self.key, self.value = self.cursor.Seek(key)
+	return self.key != nil
+}
+
+func (self *boltIterator) Key() []byte   { return self.key }
+func (self *boltIterator) Value() []byte { return self.value }
+func (self *boltIterator) Error() error  { return self.err }
+
+func (self *boltIterator) Release() {
+	if self.tx != nil {
+		self.tx.Rollback()
+		self.tx, self.cursor = nil, nil
+	}
+}
+
+type boltWrite struct {
+	key, value []byte
+	delete     bool
+}
+
+type boltBatch struct {
+	db     *BoltDatabase
+	writes []boltWrite
+}
+
+func (self *boltBatch) Put(key, value []byte) {
+	self.writes = append(self.writes, boltWrite{key: append([]byte{}, key...), value: append([]byte{}, value...)})
+}
+
+func (self *boltBatch) Delete(key []byte) {
+	self.writes = append(self.writes, boltWrite{key: append([]byte{}, key...), delete: true})
+}
+
+func (self *boltBatch) Write() error {
+	self.db.mu.Lock()
+	defer self.db.mu.Unlock()
+
+	return self.db.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, w := range self.writes {
+			// queued writes of the same key are older than the batch
+			delete(self.db.queue, string(w.key))
+			var err error
+			if w.delete {
+				err = bucket.Delete(w.key)
+			} else {
+				err = bucket.Put(w.key, w.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
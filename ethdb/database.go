@@ -96,8 +96,12 @@ func (self *LDBDatabase) LastKnownTD() []byte {
 	return data
 }
 
-func (self *LDBDatabase) NewIterator() iterator.Iterator {
-	return self.db.NewIterator(nil, nil)
+func (self *LDBDatabase) NewIterator() Iterator {
+	return &ldbIterator{self.db.NewIterator(nil, nil), nil}
+}
+
+func (self *LDBDatabase) NewBatch() Batch {
+	return &ldbBatch{db: self, batch: new(leveldb.Batch)}
 }
 
 func (self *LDBDatabase) Flush() error {
@@ -143,3 +147,51 @@ done:
 
 	self.quit <- struct{}{}
 }
+
+// ldbIterator decompresses the values of a LevelDB iterator.
+type ldbIterator struct {
+	iterator.Iterator
+	err error
+}
+
+func (self *ldbIterator) Value() []byte {
+	value, err := rle.Decompress(self.Iterator.Value())
+	if err != nil {
+		self.err = err
+	}
+	return value
+}
+
+func (self *ldbIterator) Error() error {
+	if self.err != nil {
+		return self.err
+	}
+	return self.Iterator.Error()
+}
+
+type ldbBatch struct {
+	db    *LDBDatabase
+	batch *leveldb.Batch
+	keys  []string
+}
+
+func (self *ldbBatch) Put(key, value []byte) {
+	self.batch.Put(key, rle.Compress(value))
+	self.keys = append(self.keys, string(key))
+}
+
+func (self *ldbBatch) Delete(key []byte) {
+	self.batch.Delete(key)
+	self.keys = append(self.keys, string(key))
+}
+
+func (self *ldbBatch) Write() error {
+	self.db.mu.Lock()
+	defer self.db.mu.Unlock()
+
+	// queued writes of the same keys are older than the batch
+	for _, key := range self.keys {
+		delete(self.db.queue, key)
+	}
+	return self.db.db.Write(self.batch, nil)
+}
@@ -0,0 +1,69 @@
+package ethdb
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Names of the database engines accepted by Open.
+const (
+	LevelDBEngine = "leveldb"
+	BoltDBEngine  = "boltdb"
+)
+
+// Database is a key value store which can be used as a chain database.
+type Database interface {
+	common.Database
+
+	// NewIterator returns an iterator over all keys in ascending order.
+	// Writes which haven't been flushed yet aren't visible to it.
+	NewIterator() Iterator
+
+	// NewBatch returns a batch of writes which are applied at once.
+	NewBatch() Batch
+
+	// Flush writes all queued writes to disk.
+	Flush() error
+}
+
+// Iterator iterates over the keys of a database in ascending order. It is
+// positioned before the first key until Next or Seek is called.
+type Iterator interface {
+	// Next moves to the next key and reports whether there is one.
+	Next() bool
+
+	// Seek moves to the first key greater than or equal to key and reports
+	// whether there is one.
+	Seek(key []byte) bool
+
+	Key() []byte
+	Value() []byte
+
+	// Error returns the error which stopped the iteration, if any.
+	Error() error
+
+	// Release frees the resources held by the iterator.
+	Release()
+}
+
+// Batch collects writes to a database.
+type Batch interface {
+	Put(key, value []byte)
+	Delete(key []byte)
+
+	// Write applies all collected writes.
+	Write() error
+}
+
+// Open opens the database file using the given engine.
+func Open(engine, file string) (Database, error) {
+	switch engine {
+	case LevelDBEngine, "":
+		return NewLDBDatabase(file)
+	case BoltDBEngine:
+		return NewBoltDatabase(file)
+	default:
+		return nil, fmt.Errorf("unknown database engine %q", engine)
+	}
+}
@@ -0,0 +1,117 @@
+package ethdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestMemDatabaseInterface(t *testing.T) {
+	db, _ := NewMemDatabase()
+	testDatabase(t, db)
+}
+
+func TestLDBDatabaseInterface(t *testing.T) {
+	testEngine(t, LevelDBEngine)
+}
+
+func TestBoltDatabaseInterface(t *testing.T) {
+	testEngine(t, BoltDBEngine)
+}
+
+func TestOpenUnknownEngine(t *testing.T) {
+	if _, err := Open("foo", "/nonexistent"); err == nil {
+		t.Error("expected error for unknown engine")
+	}
+}
+
+// testEngine runs testDatabase against a database of engine on disk and
+// checks that the data survives reopening it.
+func testEngine(t *testing.T, engine string) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "db")
+
+	db, err := Open(engine, file)
+	if err != nil {
+		t.Fatalf("open error: %v", err)
+	}
+	testDatabase(t, db)
+	db.Close()
+
+	db, err = Open(engine, file)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer db.Close()
+	if v, _ := db.Get([]byte("b")); string(v) != "2" {
+		t.Errorf("value not persisted, got %q", v)
+	}
+}
+
+func testDatabase(t *testing.T, db Database) {
+	db.Put([]byte("a"), []byte("1"))
+	db.Put([]byte("b"), []byte("2"))
+	db.Put([]byte("c"), []byte("3"))
+	db.Put([]byte("d"), make([]byte, 32))
+	if v, _ := db.Get([]byte("a")); string(v) != "1" {
+		t.Errorf("queued value: got %q", v)
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatalf("flush error: %v", err)
+	}
+	if v, _ := db.Get([]byte("d")); !bytes.Equal(v, make([]byte, 32)) {
+		t.Errorf("flushed value: got %x", v)
+	}
+	if err := db.Delete([]byte("c")); err != nil {
+		t.Fatalf("delete error: %v", err)
+	}
+	if v, _ := db.Get([]byte("c")); len(v) != 0 {
+		t.Errorf("deleted value: got %q", v)
+	}
+
+	// batches
+	batch := db.NewBatch()
+	batch.Put([]byte("e"), []byte("5"))
+	batch.Delete([]byte("a"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch error: %v", err)
+	}
+	if v, _ := db.Get([]byte("e")); string(v) != "5" {
+		t.Errorf("batch put: got %q", v)
+	}
+	if v, _ := db.Get([]byte("a")); len(v) != 0 {
+		t.Errorf("batch delete: got %q", v)
+	}
+
+	// iteration
+	var keys []string
+	it := db.NewIterator()
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+		if string(it.Key()) == "d" && !bytes.Equal(it.Value(), make([]byte, 32)) {
+			t.Errorf("iterated value: got %x", it.Value())
+		}
+	}
+	if err := it.Error(); err != nil {
+		t.Errorf("iterator error: %v", err)
+	}
+	it.Release()
+	if len(keys) != 3 || keys[0] != "b" || keys[1] != "d" || keys[2] != "e" {
+		t.Errorf("iterated keys: got %q", keys)
+	}
+
+	it = db.NewIterator()
+	if !it.Seek([]byte("c")) || string(it.Key()) != "d" || string(it.Value()) != string(make([]byte, 32)) {
+		t.Errorf("seek: got %q", it.Key())
+	}
+	if !it.Next() || string(it.Key()) != "e" || it.Next() {
+		t.Errorf("next after seek: got %q", it.Key())
+	}
+	it.Release()
+}
@@ -2,6 +2,7 @@ package ethdb
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -65,3 +66,85 @@ func (db *MemDatabase) LastKnownTD() []byte {
 
 	return data
 }
+
+func (db *MemDatabase) Flush() error {
+	return nil
+}
+
+// NewIterator returns an iterator over a snapshot of the keys.
+func (db *MemDatabase) NewIterator() Iterator {
+	keys := make([]string, 0, len(db.db))
+	for key := range db.db {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return &memIterator{db: db, keys: keys, pos: -1}
+}
+
+func (db *MemDatabase) NewBatch() Batch {
+	return &memBatch{db: db}
+}
+
+type memIterator struct {
+	db   *MemDatabase
+	keys []string
+	pos  int
+}
+
+func (it *memIterator) Next() bool {
+	if it.pos < len(it.keys) {
+		it.pos++
+	}
+	return it.pos < len(it.keys)
+}
+
+func (it *memIterator) Seek(key []byte) bool {
+	it.pos = sort.SearchStrings(it.keys, string(key))
+	return it.pos < len(it.keys)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.pos])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.db.db[it.keys[it.pos]]
+}
+
+func (it *memIterator) Error() error { return nil }
+func (it *memIterator) Release()     {}
+
+type memBatch struct {
+	db     *MemDatabase
+	writes []memWrite
+}
+
+type memWrite struct {
+	key, value []byte
+	delete     bool
+}
+
+func (b *memBatch) Put(key, value []byte) {
+	b.writes = append(b.writes, memWrite{key: common.CopyBytes(key), value: common.CopyBytes(value)})
+}
+
+func (b *memBatch) Delete(key []byte) {
+	b.writes = append(b.writes, memWrite{key: common.CopyBytes(key), delete: true})
+}
+
+func (b *memBatch) Write() error {
+	for _, w := range b.writes {
+		if w.delete {
+			b.db.Delete(w.key)
+		} else {
+			b.db.Put(w.key, w.value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package ethdb
+
+import "github.com/ethereum/go-ethereum/common"
+
+/*
+Table is a namespace within a database. All keys are prefixed with the
+table's prefix, so several logical databases can share a single LevelDB
+instance without their keys colliding.
+
+Closing a table does nothing, the shared database has to be closed by its
+owner.
+*/
+type Table struct {
+	db     common.Database
+	prefix string
+}
+
+func NewTable(db common.Database, prefix string) *Table {
+	return &Table{db: db, prefix: prefix}
+}
+
+func (self *Table) Put(key []byte, value []byte) {
+	self.db.Put(append([]byte(self.prefix), key...), value)
+}
+
+func (self *Table) Get(key []byte) ([]byte, error) {
+	return self.db.Get(append([]byte(self.prefix), key...))
+}
+
+func (self *Table) Delete(key []byte) error {
+	return self.db.Delete(append([]byte(self.prefix), key...))
+}
+
+func (self *Table) LastKnownTD() []byte {
+	data, _ := self.Get([]byte("LTD"))
+
+	if len(data) == 0 {
+		data = []byte{0x0}
+	}
+
+	return data
+}
+
+func (self *Table) Close() {
+}
+
+// Flush flushes the shared database if it queues writes.
+func (self *Table) Flush() error {
+	if db, ok := self.db.(interface {
+		Flush() error
+	}); ok {
+		return db.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrFeedClosed is returned when sending on a closed Feed.
+var ErrFeedClosed = errors.New("event: feed closed")
+
+// A Feed delivers events of a single type to any number of subscribers.
+// Unlike TypeMux, sending on a Feed never blocks: every subscriber owns a
+// buffered channel and events that don't fit into it are dropped. The
+// number of dropped events is recorded per subscription, which allows
+// slow consumers to detect that they lag behind.
+//
+// Feeds are meant for high frequency producers which must not be held
+// up by their consumers.
+//
+// The zero value is ready to use. The event type is fixed by the first
+// call to Send, sending values of any other type panics.
+type Feed struct {
+	mu     sync.Mutex
+	etype  reflect.Type
+	subs   []*FeedSubscription
+	closed bool
+}
+
+// Subscribe creates a subscription with a channel buffer of the given
+// size. The subscription's channel is closed when it is unsubscribed
+// or the feed is closed.
+func (f *Feed) Subscribe(bufsize int) *FeedSubscription {
+	sub := &FeedSubscription{feed: f, c: make(chan interface{}, bufsize)}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		sub.closed = true
+		close(sub.c)
+	} else {
+		f.subs = append(f.subs, sub)
+	}
+	return sub
+}
+
+// Send delivers ev to all subscribers without blocking and returns the
+// number of subscribers that received it.
+func (f *Feed) Send(ev interface{}) (int, error) {
+	rtyp := reflect.TypeOf(ev)
+
+	// Delivery doesn't block, holding the lock for all
+	// subscribers keeps the event order intact.
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, ErrFeedClosed
+	}
+	if f.etype == nil {
+		f.etype = rtyp
+	} else if rtyp != f.etype {
+		panic(fmt.Sprintf("event: Send value of type %v on feed of type %v", rtyp, f.etype))
+	}
+
+	sent := 0
+	for _, sub := range f.subs {
+		select {
+		case sub.c <- ev:
+			sent++
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+	return sent, nil
+}
+
+// Close closes the feed and all subscriptions.
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, sub := range f.subs {
+		sub.closed = true
+		close(sub.c)
+	}
+	f.subs = nil
+	f.closed = true
+}
+
+func (f *Feed) remove(sub *FeedSubscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if sub.closed {
+		return
+	}
+	for i, s := range f.subs {
+		if s == sub {
+			f.subs = append(f.subs[:i:i], f.subs[i+1:]...)
+			break
+		}
+	}
+	sub.closed = true
+	close(sub.c)
+}
+
+// FeedSubscription is a subscription to a Feed.
+// It implements the Subscription interface.
+type FeedSubscription struct {
+	feed    *Feed
+	c       chan interface{}
+	dropped uint64 // accessed atomically
+	closed  bool   // protected by feed.mu
+}
+
+// Chan returns the channel that carries events.
+func (s *FeedSubscription) Chan() <-chan interface{} {
+	return s.c
+}
+
+// Unsubscribe stops delivery of events and closes the channel.
+// It can be called more than once.
+func (s *FeedSubscription) Unsubscribe() {
+	s.feed.remove(s)
+}
+
+// Dropped returns the number of events which could not be delivered
+// because the subscription's buffer was full.
+func (s *FeedSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
@@ -0,0 +1,97 @@
+package event
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFeedDelivery(t *testing.T) {
+	var feed Feed
+	sub1 := feed.Subscribe(10)
+	sub2 := feed.Subscribe(10)
+
+	if n, err := feed.Send(testEvent(1)); n != 2 || err != nil {
+		t.Fatalf("Send returned (%d, %v), want (2, nil)", n, err)
+	}
+	for i, sub := range []*FeedSubscription{sub1, sub2} {
+		if ev := <-sub.Chan(); ev != testEvent(1) {
+			t.Errorf("sub%d: got %v, want %v", i+1, ev, testEvent(1))
+		}
+	}
+
+	sub1.Unsubscribe()
+	sub1.Unsubscribe()
+	if _, ok := <-sub1.Chan(); ok {
+		t.Error("channel not closed after Unsubscribe")
+	}
+	if n, _ := feed.Send(testEvent(2)); n != 1 {
+		t.Errorf("Send delivered to %d subscribers after unsubscribe, want 1", n)
+	}
+}
+
+func TestFeedDropsWhenFull(t *testing.T) {
+	var feed Feed
+	slow := feed.Subscribe(2)
+	fast := feed.Subscribe(10)
+
+	for i := 0; i < 5; i++ {
+		feed.Send(testEvent(i))
+	}
+	if slow.Dropped() != 3 {
+		t.Errorf("slow subscriber dropped %d events, want 3", slow.Dropped())
+	}
+	if fast.Dropped() != 0 {
+		t.Errorf("fast subscriber dropped %d events, want 0", fast.Dropped())
+	}
+	for i := 0; i < 2; i++ {
+		if ev := <-slow.Chan(); ev != testEvent(i) {
+			t.Errorf("slow subscriber got %v, want %v", ev, testEvent(i))
+		}
+	}
+}
+
+func TestFeedTypeCheck(t *testing.T) {
+	var feed Feed
+	feed.Send(testEvent(0))
+	defer func() {
+		if recover() == nil {
+			t.Error("Send with wrong type did not panic")
+		}
+	}()
+	feed.Send("foo")
+}
+
+func TestFeedClose(t *testing.T) {
+	var feed Feed
+	sub := feed.Subscribe(1)
+	feed.Close()
+	if _, ok := <-sub.Chan(); ok {
+		t.Error("channel not closed after Close")
+	}
+	if _, err := feed.Send(testEvent(0)); err != ErrFeedClosed {
+		t.Errorf("Send after Close returned %v, want ErrFeedClosed", err)
+	}
+	if _, ok := <-feed.Subscribe(1).Chan(); ok {
+		t.Error("subscription on closed feed not closed")
+	}
+	sub.Unsubscribe()
+}
+
+func TestFeedConcurrentSendUnsubscribe(t *testing.T) {
+	var (
+		feed Feed
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub := feed.Subscribe(1)
+			for j := 0; j < 100; j++ {
+				feed.Send(testEvent(j))
+			}
+			sub.Unsubscribe()
+		}()
+	}
+	wg.Wait()
+}
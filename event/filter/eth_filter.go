@@ -6,12 +6,13 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/core"
-	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/event"
 )
 
 type FilterManager struct {
-	eventMux *event.TypeMux
+	eventMux     *event.TypeMux
+	txPool       *core.TxPool
+	chainManager *core.ChainManager
 
 	filterMu sync.RWMutex
 	filterId int
@@ -20,10 +21,13 @@ type FilterManager struct {
 	quit chan struct{}
 }
 
-func NewFilterManager(mux *event.TypeMux) *FilterManager {
+func NewFilterManager(mux *event.TypeMux, txPool *core.TxPool, chainManager *core.ChainManager) *FilterManager {
 	return &FilterManager{
-		eventMux: mux,
-		filters:  make(map[int]*core.Filter),
+		eventMux:     mux,
+		txPool:       txPool,
+		chainManager: chainManager,
+		filters:      make(map[int]*core.Filter),
+		quit:         make(chan struct{}),
 	}
 }
 
@@ -63,11 +67,13 @@ func (self *FilterManager) GetFilter(id int) *core.Filter {
 
 func (self *FilterManager) filterLoop() {
 	// Subscribe to events
-	events := self.eventMux.Subscribe(
-		//core.PendingBlockEvent{},
-		core.ChainEvent{},
-		core.TxPreEvent{},
-		state.Logs(nil))
+	events := self.eventMux.Subscribe(core.ChainEvent{})
+	defer events.Unsubscribe()
+	txs := self.txPool.SubscribeTxPreEvent()
+	defer txs.Unsubscribe()
+	// logs of blocks reverted by a reorg are delivered again, marked as removed
+	rmLogs := self.chainManager.SubscribeRemovedLogsEvent()
+	defer rmLogs.Unsubscribe()
 
 out:
 	for {
@@ -82,23 +88,8 @@ out:
 					if filter.BlockCallback != nil {
 						filter.BlockCallback(event.Block, event.Logs)
 					}
-				}
-				self.filterMu.RUnlock()
-
-			case core.TxPreEvent:
-				self.filterMu.RLock()
-				for _, filter := range self.filters {
-					if filter.PendingCallback != nil {
-						filter.PendingCallback(event.Tx)
-					}
-				}
-				self.filterMu.RUnlock()
-
-			case state.Logs:
-				self.filterMu.RLock()
-				for _, filter := range self.filters {
 					if filter.LogsCallback != nil {
-						msgs := filter.FilterLogs(event)
+						msgs := filter.FilterLogs(event.Logs)
 						if len(msgs) > 0 {
 							filter.LogsCallback(msgs)
 						}
@@ -106,6 +97,35 @@ out:
 				}
 				self.filterMu.RUnlock()
 			}
+
+		case ev, ok := <-rmLogs.Chan():
+			if !ok {
+				break out
+			}
+			logs := ev.(core.RemovedLogsEvent).Logs
+			self.filterMu.RLock()
+			for _, filter := range self.filters {
+				if filter.LogsCallback != nil {
+					msgs := filter.FilterLogs(logs)
+					if len(msgs) > 0 {
+						filter.LogsCallback(msgs)
+					}
+				}
+			}
+			self.filterMu.RUnlock()
+
+		case ev, ok := <-txs.Chan():
+			if !ok {
+				break out
+			}
+			tx := ev.(core.TxPreEvent).Tx
+			self.filterMu.RLock()
+			for _, filter := range self.filters {
+				if filter.PendingCallback != nil {
+					filter.PendingCallback(tx)
+				}
+			}
+			self.filterMu.RUnlock()
 		}
 	}
 }
@@ -0,0 +1,37 @@
+package jsre
+
+// maxAsyncWorkers is the number of dispatched calls allowed to run at once,
+// further calls wait for a worker to become free.
+const maxAsyncWorkers = 8
+
+// Do runs fn with exclusive access to the vm. Hosts evaluating user input
+// must go through Do so that the callbacks of dispatched calls aren't run
+// concurrently with it. Native functions called from within fn already hold
+// the lock and use the vm directly.
+func (self *JSRE) Do(fn func()) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	fn()
+}
+
+// Dispatch runs work on the worker pool without blocking the caller. Its
+// result is handed to done, which runs with exclusive access to the vm and
+// typically invokes a JS callback.
+func (self *JSRE) Dispatch(work func() (interface{}, error), done func(interface{}, error)) {
+	self.pending.Add(1)
+	go func() {
+		defer self.pending.Done()
+
+		self.workers <- struct{}{}
+		result, err := work()
+		<-self.workers
+
+		self.Do(func() { done(result, err) })
+	}()
+}
+
+// Wait blocks until the callbacks of all dispatched calls have run. It must
+// not be called from within Do.
+func (self *JSRE) Wait() {
+	self.pending.Wait()
+}
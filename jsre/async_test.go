@@ -0,0 +1,73 @@
+package jsre
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/robertkrimen/otto"
+)
+
+func TestDispatch(t *testing.T) {
+	jsre := New("/tmp")
+	jsre.Run(`results = [];`)
+
+	release := make(chan struct{})
+	jsre.Bind("slow", func(call otto.FunctionCall) otto.Value {
+		n, _ := call.Argument(0).ToInteger()
+		callback := call.Argument(1)
+		jsre.Dispatch(func() (interface{}, error) {
+			<-release
+			if n < 0 {
+				return nil, errors.New("negative")
+			}
+			return n * 2, nil
+		}, func(result interface{}, err error) {
+			if err != nil {
+				callback.Call(otto.NullValue(), err.Error(), nil)
+				return
+			}
+			callback.Call(otto.NullValue(), nil, result)
+		})
+		return otto.UndefinedValue()
+	})
+
+	var err error
+	jsre.Do(func() {
+		_, err = jsre.Run(`
+			slow(21, function(err, res) { results.push(res); });
+			slow(-1, function(err, res) { results.push(err); });
+		`)
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// the calls must not have completed before they were released
+	val, _ := jsre.Run(`results.length`)
+	if n, _ := val.ToInteger(); n != 0 {
+		t.Errorf("expected no results before release, got %d", n)
+	}
+
+	close(release)
+	jsre.Wait()
+
+	val, _ = jsre.Run(`results.sort().join(",")`)
+	if got, _ := val.ToString(); got != "42,negative" {
+		t.Errorf("expected '42,negative', got '%v'", got)
+	}
+}
+
+func TestCompleteKeywordsDispatch(t *testing.T) {
+	jsre := New("/tmp")
+	jsre.Run(`admin = {}`)
+
+	for i := 0; i < 20; i++ {
+		jsre.Dispatch(func() (interface{}, error) { return nil, nil }, func(interface{}, error) {
+			jsre.vm.Run(`admin.n = (admin.n || 0) + 1`)
+		})
+		jsre.CompleteKeywords("admin.")
+	}
+	jsre.Wait()
+	if got := jsre.CompleteKeywords("admin."); len(got) != 1 || got[0] != "admin.n" {
+		t.Errorf("expected [admin.n], got %v", got)
+	}
+}
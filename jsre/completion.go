@@ -0,0 +1,63 @@
+package jsre
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/robertkrimen/otto"
+)
+
+// identPath matches the trailing dotted identifier of a console line, e.g.
+// "admin.ad" in "x = admin.ad".
+var identPath = regexp.MustCompile(`([A-Za-z_$][A-Za-z0-9_$]*\.)*[A-Za-z0-9_$]*$`)
+
+// propertyNames is a JS function returning the comma separated property
+// names of an object, or the empty string for primitive values.
+const propertyNames = `(function(o) {
+	var keys = [];
+	if (o === null || (typeof o !== "object" && typeof o !== "function")) {
+		return "";
+	}
+	for (var k in o) {
+		keys.push(k);
+	}
+	return keys.join(",");
+})`
+
+// CompleteKeywords returns the completions for the dotted identifier at the
+// end of line. Top level names complete against the global object, names
+// after a dot against the properties of the object left of it. The vm is
+// accessed through Do, so CompleteKeywords must not be called from within Do.
+func (self *JSRE) CompleteKeywords(line string) []string {
+	word := identPath.FindString(line)
+	head := line[:len(line)-len(word)]
+
+	obj, prefix := "this", word
+	if dot := strings.LastIndex(word, "."); dot >= 0 {
+		obj, prefix = word[:dot], word[dot+1:]
+		head += word[:dot+1]
+	}
+
+	var (
+		value otto.Value
+		err   error
+	)
+	self.Do(func() { value, err = self.vm.Run(propertyNames + "(" + obj + ")") })
+	if err != nil {
+		return nil
+	}
+	keys, _ := value.ToString()
+	if keys == "" {
+		return nil
+	}
+
+	var results []string
+	for _, key := range strings.Split(keys, ",") {
+		if strings.HasPrefix(key, prefix) {
+			results = append(results, head+key)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
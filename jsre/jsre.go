@@ -3,6 +3,7 @@ package jsre
 import (
 	"fmt"
 	"io/ioutil"
+	"sync"
 
 	"github.com/robertkrimen/otto"
 
@@ -16,16 +17,22 @@ It provides some helper functions to
 - run code snippets
 - require libraries
 - bind native go objects
+- dispatch slow native calls to a worker pool
 */
 type JSRE struct {
 	assetPath string
 	vm        *otto.Otto
+
+	lock    sync.Mutex    // serialises hosts and async callbacks on the vm
+	workers chan struct{} // bounds the number of concurrent async calls
+	pending sync.WaitGroup
 }
 
 func New(assetPath string) *JSRE {
 	re := &JSRE{
-		assetPath,
-		otto.New(),
+		assetPath: assetPath,
+		vm:        otto.New(),
+		workers:   make(chan struct{}, maxAsyncWorkers),
 	}
 
 	// load prettyprint func definition
@@ -82,3 +82,32 @@ func TestLoadScript(t *testing.T) {
 		t.Errorf("expected '%v', got '%v'", exp, got)
 	}
 }
+
+func TestCompleteKeywords(t *testing.T) {
+	jsre := New("/tmp")
+	jsre.Run(`admin = { addPeer: function() {}, nodeInfo: function() {} }; adminCount = 1;`)
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"adm", []string{"admin", "adminCount"}},
+		{"admin.", []string{"admin.addPeer", "admin.nodeInfo"}},
+		{"x = admin.no", []string{"x = admin.nodeInfo"}},
+		{"admin.nodeInfo.", nil},
+		{"missing.", nil},
+	}
+	for _, test := range tests {
+		got := jsre.CompleteKeywords(test.line)
+		if len(got) != len(test.want) {
+			t.Errorf("%q: expected %v, got %v", test.line, test.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%q: expected %v, got %v", test.line, test.want, got)
+				break
+			}
+		}
+	}
+}
@@ -118,11 +118,23 @@ func SetV(v int) {
 	logging.verbosity.set(Level(v))
 }
 
+// SetVModule sets the per-module verbosity levels. The syntax of spec is
+// the same as for the -vmodule flag, an empty spec clears all levels.
+func SetVModule(spec string) error {
+	return logging.vmodule.Set(spec)
+}
+
 // SetToStderr sets the global output style
 func SetToStderr(toStderr bool) {
 	logging.toStderr = toStderr
 }
 
+// SetAlsoToStderr sets whether log output written to files
+// is also written to standard error.
+func SetAlsoToStderr(alsoToStderr bool) {
+	logging.alsoToStderr = alsoToStderr
+}
+
 // GetTraceLocation returns the global TraceLocation object
 func GetTraceLocation() *TraceLocation {
 	return &logging.traceLocation
@@ -471,6 +483,10 @@ type loggingT struct {
 	// safely using atomic.LoadInt32.
 	vmodule   moduleSpec // The state of the -vmodule flag.
 	verbosity Level      // V logging level, the value of the -v flag/
+
+	// backend replaces standard error and the log files if set.
+	// It is modified only under mu.
+	backend backend
 }
 
 // buffer holds a byte Buffer for reuse. The zero value is ready for use.
@@ -694,7 +710,11 @@ func (l *loggingT) output(s severity, buf *buffer, file string, line int, alsoTo
 		}
 	}
 	data := buf.Bytes()
-	if l.toStderr {
+	if l.backend != nil {
+		if err := l.backend.output(s, file, line, stripHeader(data)); err != nil {
+			os.Stderr.Write(data) // Make sure the message appears somewhere.
+		}
+	} else if l.toStderr {
 		os.Stderr.Write(data)
 	} else {
 		if alsoToStderr || l.alsoToStderr || s >= l.stderrThreshold.get() {
@@ -731,7 +751,7 @@ func (l *loggingT) output(s severity, buf *buffer, file string, line int, alsoTo
 		// First, make sure we see the trace for the current goroutine on standard error.
 		// If -logtostderr has been specified, the loop below will do that anyway
 		// as the first stack in the full dump.
-		if !l.toStderr {
+		if !l.toStderr || l.backend != nil {
 			os.Stderr.Write(stacks(false))
 		}
 		// Write the stack trace for all goroutines to the files.
@@ -817,9 +837,10 @@ func (l *loggingT) exit(err error) {
 type syncBuffer struct {
 	logger *loggingT
 	*bufio.Writer
-	file   *os.File
-	sev    severity
-	nbytes uint64 // The number of bytes written to this file
+	file    *os.File
+	sev     severity
+	nbytes  uint64    // The number of bytes written to this file
+	created time.Time // The creation time of this file
 }
 
 func (sb *syncBuffer) Sync() error {
@@ -827,8 +848,9 @@ func (sb *syncBuffer) Sync() error {
 }
 
 func (sb *syncBuffer) Write(p []byte) (n int, err error) {
-	if sb.nbytes+uint64(len(p)) >= MaxSize {
-		if err := sb.rotateFile(time.Now()); err != nil {
+	now := time.Now()
+	if sb.nbytes+uint64(len(p)) >= MaxSize || (MaxAge > 0 && now.Sub(sb.created) >= MaxAge) {
+		if err := sb.rotateFile(now); err != nil {
 			sb.logger.exit(err)
 		}
 	}
@@ -841,17 +863,29 @@ func (sb *syncBuffer) Write(p []byte) (n int, err error) {
 }
 
 // rotateFile closes the syncBuffer's file and starts a new one.
+// The old file is compressed and old files beyond the retention
+// count are removed in the background.
 func (sb *syncBuffer) rotateFile(now time.Time) error {
+	var old string
 	if sb.file != nil {
 		sb.Flush()
 		sb.file.Close()
+		old = sb.file.Name()
 	}
-	var err error
-	sb.file, _, err = create(severityName[sb.sev], now)
+	var (
+		err   error
+		fname string
+		tag   = severityName[sb.sev]
+	)
+	sb.file, fname, err = create(tag, now)
 	sb.nbytes = 0
+	sb.created = now
 	if err != nil {
 		return err
 	}
+	if (old != "" && Compress) || MaxFiles > 0 {
+		go cleanupLogs(old, fname, tag, Compress, MaxFiles)
+	}
 
 	sb.Writer = bufio.NewWriterSize(sb.file, bufferSize)
 
@@ -976,15 +1010,20 @@ func (lb logBridge) Write(b []byte) (n int, err error) {
 func (l *loggingT) setV(pc uintptr) Level {
 	fn := runtime.FuncForPC(pc)
 	file, _ := fn.FileLine(pc)
-	// The file is something like /a/b/c/d.go. We want just the d.
+	// The file is something like /a/b/c/d.go. We want just the d,
+	// patterns may also match c, the directory of the package.
 	if strings.HasSuffix(file, ".go") {
 		file = file[:len(file)-3]
 	}
+	var dir string
 	if slash := strings.LastIndex(file, "/"); slash >= 0 {
-		file = file[slash+1:]
+		dir, file = file[:slash], file[slash+1:]
+		if slash := strings.LastIndex(dir, "/"); slash >= 0 {
+			dir = dir[slash+1:]
+		}
 	}
 	for _, filter := range l.vmodule.filter {
-		if filter.match(file) {
+		if filter.match(file) || (dir != "" && filter.match(dir)) {
 			l.vmap[pc] = filter.level
 			return filter.level
 		}
@@ -0,0 +1,60 @@
+package glog
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// backend is a log destination which replaces standard error and the
+// log files. It receives the message of each record without the glog
+// header, severity and source location are passed separately.
+type backend interface {
+	output(s severity, file string, line int, msg []byte) error
+	close() error
+}
+
+// SetBackend selects the destination of log output. Valid names are
+// "stderr", which writes to standard error and log files as configured,
+// "syslog" and "journald".
+func SetBackend(name string) error {
+	var (
+		b   backend
+		err error
+	)
+	switch name {
+	case "", "stderr":
+	case "syslog":
+		b, err = newSyslogBackend(program)
+	case "journald":
+		b, err = newJournalBackend(program)
+	default:
+		return fmt.Errorf("unknown log backend %q", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	logging.mu.Lock()
+	defer logging.mu.Unlock()
+	if logging.backend != nil {
+		logging.backend.close()
+	}
+	logging.backend = b
+	return nil
+}
+
+// syslogPriority maps severities to syslog priority levels.
+var syslogPriority = []int{
+	infoLog:    6, // LOG_INFO
+	warningLog: 4, // LOG_WARNING
+	errorLog:   3, // LOG_ERR
+	fatalLog:   2, // LOG_CRIT
+}
+
+// stripHeader returns the message of a formatted log record.
+func stripHeader(data []byte) []byte {
+	if i := bytes.Index(data, []byte("] ")); i >= 0 {
+		return data[i+2:]
+	}
+	return data
+}
@@ -0,0 +1,16 @@
+// +build windows plan9
+
+package glog
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func newSyslogBackend(tag string) (backend, error) {
+	return nil, fmt.Errorf("syslog is not supported on %s", runtime.GOOS)
+}
+
+func newJournalBackend(ident string) (backend, error) {
+	return nil, fmt.Errorf("journald is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,68 @@
+// +build !windows,!plan9
+
+package glog
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testBackend struct {
+	sev  []severity
+	msgs []string
+}
+
+func (b *testBackend) output(s severity, file string, line int, msg []byte) error {
+	b.sev = append(b.sev, s)
+	b.msgs = append(b.msgs, string(msg))
+	return nil
+}
+
+func (b *testBackend) close() error { return nil }
+
+func TestBackend(t *testing.T) {
+	setFlags()
+	defer logging.swap(logging.newBuffers())
+	b := new(testBackend)
+	logging.mu.Lock()
+	logging.backend = b
+	logging.mu.Unlock()
+	defer func() {
+		logging.mu.Lock()
+		logging.backend = nil
+		logging.mu.Unlock()
+	}()
+
+	Info("test info")
+	Warning("test warning")
+	if len(b.msgs) != 2 {
+		t.Fatalf("backend got %d records, want 2", len(b.msgs))
+	}
+	if b.sev[0] != infoLog || b.sev[1] != warningLog {
+		t.Errorf("severity mismatch: got %v", b.sev)
+	}
+	if b.msgs[0] != "test info\n" {
+		t.Errorf("header not stripped: %q", b.msgs[0])
+	}
+	if contents(infoLog) != "" {
+		t.Errorf("record was also written to log file: %q", contents(infoLog))
+	}
+}
+
+func TestSetBackendUnknown(t *testing.T) {
+	if err := SetBackend("foo"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestJournalField(t *testing.T) {
+	rec := appendJournalField(nil, "MESSAGE", "hello")
+	if string(rec) != "MESSAGE=hello\n" {
+		t.Errorf("simple field mismatch: %q", rec)
+	}
+	rec = appendJournalField(nil, "MESSAGE", "a\nb")
+	want := []byte("MESSAGE\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n")
+	if !bytes.Equal(rec, want) {
+		t.Errorf("multi-line field mismatch: got %q, want %q", rec, want)
+	}
+}
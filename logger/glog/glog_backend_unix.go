@@ -0,0 +1,97 @@
+// +build !windows,!plan9
+
+package glog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/syslog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type syslogBackend struct {
+	w *syslog.Writer
+}
+
+func newSyslogBackend(tag string) (backend, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to syslog: %v", err)
+	}
+	return &syslogBackend{w}, nil
+}
+
+func (b *syslogBackend) output(s severity, file string, line int, msg []byte) error {
+	m := fmt.Sprintf("%s:%d] %s", file, line, msg)
+	switch s {
+	case fatalLog:
+		return b.w.Crit(m)
+	case errorLog:
+		return b.w.Err(m)
+	case warningLog:
+		return b.w.Warning(m)
+	default:
+		return b.w.Info(m)
+	}
+}
+
+func (b *syslogBackend) close() error {
+	return b.w.Close()
+}
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// journalBackend sends records to journald using its native protocol,
+// which keeps the source location as separate fields.
+type journalBackend struct {
+	conn  *net.UnixConn
+	addr  *net.UnixAddr
+	ident string
+}
+
+func newJournalBackend(ident string) (backend, error) {
+	if _, err := os.Stat(journalSocket); err != nil {
+		return nil, fmt.Errorf("journald is not available: %v", err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: "", Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	addr := &net.UnixAddr{Name: journalSocket, Net: "unixgram"}
+	return &journalBackend{conn: conn, addr: addr, ident: ident}, nil
+}
+
+func (b *journalBackend) output(s severity, file string, line int, msg []byte) error {
+	var rec []byte
+	rec = appendJournalField(rec, "MESSAGE", strings.TrimSuffix(string(msg), "\n"))
+	rec = appendJournalField(rec, "PRIORITY", strconv.Itoa(syslogPriority[s]))
+	rec = appendJournalField(rec, "SYSLOG_IDENTIFIER", b.ident)
+	rec = appendJournalField(rec, "CODE_FILE", file)
+	rec = appendJournalField(rec, "CODE_LINE", strconv.Itoa(line))
+	_, err := b.conn.WriteToUnix(rec, b.addr)
+	return err
+}
+
+func (b *journalBackend) close() error {
+	return b.conn.Close()
+}
+
+// appendJournalField appends a field in the journald native protocol
+// encoding. Values containing newlines are sent length prefixed.
+func appendJournalField(rec []byte, key, value string) []byte {
+	rec = append(rec, key...)
+	if !strings.Contains(value, "\n") {
+		rec = append(rec, '=')
+		rec = append(rec, value...)
+		return append(rec, '\n')
+	}
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	rec = append(rec, '\n')
+	rec = append(rec, size[:]...)
+	rec = append(rec, value...)
+	return append(rec, '\n')
+}
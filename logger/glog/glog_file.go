@@ -19,11 +19,15 @@
 package glog
 
 import (
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +36,17 @@ import (
 // MaxSize is the maximum size of a log file in bytes.
 var MaxSize uint64 = 1024 * 1024 * 1800
 
+// MaxAge is the maximum age of a log file. A file older than MaxAge is
+// rotated on the next write. Zero disables time based rotation.
+var MaxAge time.Duration
+
+// MaxFiles is the number of log files kept per severity, including the
+// current one. Older files are removed after rotation. Zero keeps all files.
+var MaxFiles int
+
+// Compress enables gzip compression of rotated log files.
+var Compress bool
+
 // logDirs lists the candidate directories for new log files.
 var logDirs []string
 
@@ -44,6 +59,26 @@ func SetLogDir(str string) {
 	*logDir = str
 }
 
+// SetMaxSize sets the size in bytes at which log files are rotated.
+func SetMaxSize(size uint64) {
+	MaxSize = size
+}
+
+// SetMaxAge sets the age at which log files are rotated.
+func SetMaxAge(age time.Duration) {
+	MaxAge = age
+}
+
+// SetMaxFiles sets the number of log files kept per severity.
+func SetMaxFiles(n int) {
+	MaxFiles = n
+}
+
+// SetCompress sets whether rotated log files are compressed.
+func SetCompress(compress bool) {
+	Compress = compress
+}
+
 func createLogDirs() {
 	if *logDir != "" {
 		logDirs = append(logDirs, *logDir)
@@ -85,11 +120,8 @@ func shortHostname(hostname string) string {
 // logName returns a new log file name containing tag, with start time t, and
 // the name for the symlink for tag.
 func logName(tag string, t time.Time) (name, link string) {
-	name = fmt.Sprintf("%s.%s.%s.log.%s.%04d%02d%02d-%02d%02d%02d.%d",
-		program,
-		host,
-		userName,
-		tag,
+	name = fmt.Sprintf("%s%04d%02d%02d-%02d%02d%02d.%d",
+		logPrefix(tag),
 		t.Year(),
 		t.Month(),
 		t.Day(),
@@ -100,6 +132,12 @@ func logName(tag string, t time.Time) (name, link string) {
 	return name, program + "." + tag
 }
 
+// logPrefix returns the common prefix of the names of all log files
+// containing tag.
+func logPrefix(tag string) string {
+	return fmt.Sprintf("%s.%s.%s.log.%s.", program, host, userName, tag)
+}
+
 var onceLogDirs sync.Once
 
 // create creates a new log file and returns the file and its filename, which
@@ -126,3 +164,78 @@ func create(tag string, t time.Time) (f *os.File, filename string, err error) {
 	}
 	return nil, "", fmt.Errorf("log: cannot create log: %v", lastErr)
 }
+
+// cleanupMu serializes the cleanup of rotated log files.
+var cleanupMu sync.Mutex
+
+// cleanupLogs compresses the rotated log file old, if any, and removes
+// log files for tag beyond the retention count. The current log file
+// cur is never removed.
+func cleanupLogs(old, cur, tag string, compress bool, keep int) {
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+
+	if old != "" && compress {
+		if err := compressLog(old); err != nil {
+			fmt.Fprintf(os.Stderr, "log: cannot compress %s: %v\n", old, err)
+		}
+	}
+	if keep > 0 {
+		if err := pruneLogs(filepath.Dir(cur), filepath.Base(cur), tag, keep); err != nil {
+			fmt.Fprintf(os.Stderr, "log: cannot remove old logs: %v\n", err)
+		}
+	}
+}
+
+// compressLog replaces the file fname with a gzipped copy named fname.gz.
+func compressLog(fname string) error {
+	in, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(fname + ".gz")
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(fname + ".gz")
+		return err
+	}
+	return os.Remove(fname)
+}
+
+// pruneLogs removes all but the newest keep log files for tag in dir,
+// counting the current file cur. Log file names contain the creation
+// time, the names of older files sort before the names of newer ones.
+func pruneLogs(dir, cur, tag string, keep int) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	prefix := logPrefix(tag)
+	var names []string
+	for _, fi := range files {
+		if name := fi.Name(); name != cur && fi.Mode().IsRegular() && strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) < keep {
+		return nil
+	}
+	sort.Strings(names)
+	for _, name := range names[:len(names)-keep+1] {
+		if rerr := os.Remove(filepath.Join(dir, name)); rerr != nil {
+			err = rerr
+		}
+	}
+	return err
+}
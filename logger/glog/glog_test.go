@@ -18,8 +18,11 @@ package glog
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io/ioutil"
 	stdLog "log"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -306,6 +309,9 @@ var vGlobs = map[string]bool{
 	"m*=2":         false,
 	"??_*=2":       false,
 	"?[abc]?_*t=2": false,
+	// Patterns also match the package directory.
+	"glog=2":   true,
+	"logger=2": false,
 }
 
 // Test that vmodule globbing works as advertised.
@@ -371,6 +377,106 @@ func TestRollover(t *testing.T) {
 	}
 }
 
+func TestRolloverAge(t *testing.T) {
+	setFlags()
+	var err error
+	defer func(previous func(error)) { logExitFunc = previous }(logExitFunc)
+	logExitFunc = func(e error) {
+		err = e
+	}
+	defer func(previous time.Duration) { MaxAge = previous }(MaxAge)
+	MaxAge = time.Second
+
+	Info("x") // Be sure we have a file.
+	info, ok := logging.file[infoLog].(*syncBuffer)
+	if !ok {
+		t.Fatal("info wasn't created")
+	}
+	fname0 := info.file.Name()
+	time.Sleep(1100 * time.Millisecond)
+
+	Info("x") // age exceeded, rotate
+	if err != nil {
+		t.Fatalf("error after rotation: %v", err)
+	}
+	if fname1 := info.file.Name(); fname0 == fname1 {
+		t.Errorf("info.f.Name did not change: %v", fname0)
+	}
+}
+
+func TestCompressLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fname := filepath.Join(dir, "test.log")
+	content := strings.Repeat("log line\n", 100)
+	if err := ioutil.WriteFile(fname, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressLog(fname); err != nil {
+		t.Fatalf("compressLog error: %v", err)
+	}
+	if _, err := os.Stat(fname); !os.IsNotExist(err) {
+		t.Errorf("uncompressed file still exists")
+	}
+	f, err := os.Open(fname + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("decompressed content mismatch")
+	}
+}
+
+func TestPruneLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "glog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var names []string
+	start := time.Date(2015, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		name, _ := logName("INFO", start.Add(time.Duration(i)*time.Hour))
+		if i < 3 {
+			name += ".gz" // rotated and compressed
+		}
+		names = append(names, name)
+	}
+	names = append(names, "other.log")
+	warning, _ := logName("WARNING", start)
+	names = append(names, warning)
+	for _, name := range names {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// keep the current file and the two newest rotated ones
+	if err := pruneLogs(dir, names[4], "INFO", 3); err != nil {
+		t.Fatalf("pruneLogs error: %v", err)
+	}
+	for i, name := range names {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists, want := err == nil, i >= 2; exists != want {
+			t.Errorf("file %s: exists = %t, want %t", name, exists, want)
+		}
+	}
+}
+
 func TestLogBacktraceAt(t *testing.T) {
 	setFlags()
 	defer logging.swap(logging.newBuffers())
@@ -1,7 +1,9 @@
 package logger
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"math/big"
 	"math/rand"
 	"os"
 	"sync"
@@ -174,3 +176,31 @@ func TestConcurrentAddSystem(t *testing.T) {
 	}
 	close(stop)
 }
+
+func TestJsonLogEvent(t *testing.T) {
+	ev := &EthChainBlockImported{
+		BlockHash:   "0x01",
+		BlockNumber: big.NewInt(5),
+		TxCount:     2,
+		GasUsed:     big.NewInt(42000),
+		Elapsed:     1.5,
+		Canonical:   true,
+	}
+	enc, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec map[string]interface{}
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := dec["v"].(float64); !ok || int(v) != JsonLogVersion {
+		t.Errorf("version mismatch: got %v, want %d", dec["v"], JsonLogVersion)
+	}
+	if _, ok := dec["ts"].(string); !ok {
+		t.Errorf("timestamp missing: %s", enc)
+	}
+	if dec["block_number"] != 5.0 || dec["tx_count"] != 2.0 || dec["elapsed_ms"] != 1.5 || dec["canonical"] != true {
+		t.Errorf("field mismatch: %s", enc)
+	}
+}
@@ -2,9 +2,19 @@ package logger
 
 import (
 	"math/big"
+	"strconv"
 	"time"
 )
 
+// JsonLogVersion is the version of the JSON log format. It is included
+// in every event as "v" and is increased whenever fields of existing
+// events are renamed, removed or change their meaning.
+//
+// Every line written by JsonLogger is an object with a single key, the
+// event name, whose value holds the fields of the event. All events
+// carry the UTC timestamp "ts" and the format version "v".
+const JsonLogVersion = 1
+
 type utctime8601 struct{}
 
 func (utctime8601) MarshalJSON() ([]byte, error) {
@@ -16,13 +26,20 @@ func (utctime8601) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + timestr + `Z"`), nil
 }
 
+type jsonLogVersion struct{}
+
+func (jsonLogVersion) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(JsonLogVersion)), nil
+}
+
 type JsonLog interface {
 	EventName() string
 }
 
 type LogEvent struct {
 	// Guid string      `json:"guid"`
-	Ts utctime8601 `json:"ts"`
+	Ts utctime8601    `json:"ts"`
+	V  jsonLogVersion `json:"v"`
 	// Level string      `json:"level"`
 }
 
@@ -36,6 +53,7 @@ func (l *LogStarting) EventName() string {
 	return "starting"
 }
 
+// P2PConnected is logged when a peer has been added.
 type P2PConnected struct {
 	RemoteId            string `json:"remote_id"`
 	RemoteAddress       string `json:"remote_addr"`
@@ -48,9 +66,11 @@ func (l *P2PConnected) EventName() string {
 	return "p2p.connected"
 }
 
+// P2PDisconnected is logged when a peer has been dropped.
 type P2PDisconnected struct {
 	NumConnections int    `json:"num_connections"`
 	RemoteId       string `json:"remote_id"`
+	Reason         string `json:"reason"`
 	LogEvent
 }
 
@@ -83,6 +103,7 @@ func (l *EthChainReceivedNewBlock) EventName() string {
 	return "eth.chain.received.new_block"
 }
 
+// EthChainNewHead is logged when a block becomes the head of the chain.
 type EthChainNewHead struct {
 	BlockHash     string   `json:"block_hash"`
 	BlockNumber   *big.Int `json:"block_number"`
@@ -95,6 +116,7 @@ func (l *EthChainNewHead) EventName() string {
 	return "eth.chain.new_head"
 }
 
+// EthTxReceived is logged when a transaction has been received from a peer.
 type EthTxReceived struct {
 	TxHash   string `json:"tx_hash"`
 	RemoteId string `json:"remote_id"`
@@ -105,6 +127,37 @@ func (l *EthTxReceived) EventName() string {
 	return "eth.tx.received"
 }
 
+// EthChainBlockImported is logged for every block which has been processed
+// and written to the database. Canonical is false for side chain blocks.
+// Elapsed is the processing time in milliseconds.
+type EthChainBlockImported struct {
+	BlockHash   string   `json:"block_hash"`
+	BlockNumber *big.Int `json:"block_number"`
+	TxCount     int      `json:"tx_count"`
+	GasUsed     *big.Int `json:"gas_used"`
+	Elapsed     float64  `json:"elapsed_ms"`
+	Canonical   bool     `json:"canonical"`
+	LogEvent
+}
+
+func (l *EthChainBlockImported) EventName() string {
+	return "eth.chain.block_imported"
+}
+
+// EthChainReorg is logged when the canonical chain switches to a block
+// which is not a descendant of the previous head.
+type EthChainReorg struct {
+	OldHeadHash   string   `json:"old_head_hash"`
+	OldHeadNumber *big.Int `json:"old_head_number"`
+	NewHeadHash   string   `json:"new_head_hash"`
+	NewHeadNumber *big.Int `json:"new_head_number"`
+	LogEvent
+}
+
+func (l *EthChainReorg) EventName() string {
+	return "eth.chain.reorg"
+}
+
 //
 //
 // The types below are legacy and need to be converted to new format or deleted
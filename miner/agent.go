@@ -39,7 +39,16 @@ func (self *CpuMiner) Stop() {
 	close(self.quitCurrentOp)
 }
 
+// dagUpdater is implemented by PoW engines which need to generate data
+// ahead of mining, such as the ethash DAG.
+type dagUpdater interface {
+	UpdateDAG()
+}
+
 func (self *CpuMiner) Start() {
+	if updater, ok := self.pow.(dagUpdater); ok {
+		updater.UpdateDAG()
+	}
 	self.quit = make(chan struct{})
 	self.quitCurrentOp = make(chan struct{}, 1)
 	self.c = make(chan *types.Block, 1)
@@ -87,9 +96,10 @@ func (self *CpuMiner) mine(block *types.Block) {
 	// Mine
 	nonce, mixDigest, _ := self.pow.Search(block, self.quitCurrentOp)
 	if nonce != 0 {
-		block.SetNonce(nonce)
-		block.Header().MixDigest = common.BytesToHash(mixDigest)
-		self.returnCh <- block
+		header := block.Header()
+		header.SetNonce(nonce)
+		header.MixDigest = common.BytesToHash(mixDigest)
+		self.returnCh <- block.WithHeader(header)
 	} else {
 		self.returnCh <- nil
 	}
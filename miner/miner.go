@@ -2,64 +2,103 @@ package miner
 
 import (
 	"math/big"
+	"sync"
 
-	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/pow"
 )
 
+// pendingLogsBufferSize is the channel buffer of pending logs subscriptions.
+const pendingLogsBufferSize = 64
+
 type Miner struct {
 	worker *worker
 
 	MinAcceptedGasPrice *big.Int
 
-	threads int
-	mining  bool
-	eth     core.Backend
-	pow     pow.PoW
+	mu       sync.Mutex
+	mining   bool
+	coinbase common.Address
+	eth      core.Backend
 }
 
 func New(eth core.Backend, pow pow.PoW, minerThreads int) *Miner {
 	// note: minerThreads is currently ignored because
 	// ethash is not thread safe.
-	miner := &Miner{eth: eth, pow: pow, worker: newWorker(common.Address{}, eth)}
+	miner := &Miner{eth: eth, worker: newWorker(common.Address{}, eth)}
 	for i := 0; i < minerThreads; i++ {
 		miner.worker.register(NewCpuMiner(i, pow))
 	}
-	miner.threads = minerThreads
 
 	return miner
 }
 
+// PauseOnSync pauses the assembly of pending blocks and mining while the
+// downloader synchronises and resumes them once it is done, as mining on a
+// stale head only creates forks. It must be called once, before mining is
+// started.
+func (self *Miner) PauseOnSync() {
+	events := self.eth.EventMux().Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
+	go self.update(events)
+}
+
+func (self *Miner) update(events event.Subscription) {
+	for ev := range events.Chan() {
+		self.mu.Lock()
+		switch ev.(type) {
+		case downloader.StartEvent:
+			if self.mining {
+				glog.V(logger.Info).Infoln("Mining paused while synchronising")
+			}
+			self.worker.pause()
+		case downloader.DoneEvent, downloader.FailedEvent:
+			if self.mining {
+				glog.V(logger.Info).Infoln("Mining resumed")
+			}
+			self.worker.resume()
+		}
+		self.mu.Unlock()
+	}
+}
+
+// Mining reports whether the miner is mining, including while it is paused
+// for a sync.
 func (self *Miner) Mining() bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
 	return self.mining
 }
 
 func (self *Miner) Start(coinbase common.Address) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
 	self.mining = true
+	self.coinbase = coinbase
 	self.worker.coinbase = coinbase
-
-	if self.threads > 0 {
-		self.pow.(*ethash.Ethash).UpdateDAG()
-	}
-
 	self.worker.start()
 
 	self.worker.commitNewWork()
 }
 
 func (self *Miner) Register(agent Agent) {
-	if self.mining {
-		agent.Start()
-	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
 
 	self.worker.register(agent)
 }
 
 func (self *Miner) Stop() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
 	self.mining = false
 	self.worker.stop()
 }
@@ -72,6 +111,8 @@ func (self *Miner) SetExtra(extra []byte) {
 	self.worker.extra = extra
 }
 
+// PendingState returns the state of the pending block. It is shared
+// with other callers and must be copied before it is modified.
 func (self *Miner) PendingState() *state.StateDB {
 	return self.worker.pendingState()
 }
@@ -79,3 +120,9 @@ func (self *Miner) PendingState() *state.StateDB {
 func (self *Miner) PendingBlock() *types.Block {
 	return self.worker.pendingBlock()
 }
+
+// SubscribePendingLogsEvent registers a subscription of PendingLogsEvent,
+// sent whenever the pending block has been recreated.
+func (self *Miner) SubscribePendingLogsEvent() *event.FeedSubscription {
+	return self.worker.pendingLogsFeed.Subscribe(pendingLogsBufferSize)
+}
@@ -0,0 +1,78 @@
+package miner
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+)
+
+// testAgent records whether it runs and the work it receives.
+type testAgent struct {
+	running int32
+	work    chan *types.Block
+}
+
+func newTestAgent() *testAgent {
+	return &testAgent{work: make(chan *types.Block, 16)}
+}
+
+func (a *testAgent) Work() chan<- *types.Block          { return a.work }
+func (a *testAgent) SetReturnCh(ch chan<- *types.Block) {}
+func (a *testAgent) Start()                             { atomic.StoreInt32(&a.running, 1) }
+func (a *testAgent) Stop()                              { atomic.StoreInt32(&a.running, 0) }
+func (a *testAgent) GetHashRate() int64                 { return 0 }
+
+// waitRunning waits until the agent is started or stopped.
+func (a *testAgent) waitRunning(running bool) bool {
+	for end := time.Now().Add(time.Second); time.Now().Before(end); time.Sleep(10 * time.Millisecond) {
+		if (atomic.LoadInt32(&a.running) == 1) == running {
+			return true
+		}
+	}
+	return false
+}
+
+// nextWork waits for work pushed to the agent.
+func (a *testAgent) nextWork() bool {
+	select {
+	case <-a.work:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func TestMinerPauseOnSync(t *testing.T) {
+	backend := newTestBackend()
+	defer backend.chain.Stop()
+	miner := New(backend, core.FakePow{}, 0)
+	agent := newTestAgent()
+	miner.Register(agent)
+	miner.PauseOnSync()
+
+	miner.Start(common.Address{1})
+	if !agent.waitRunning(true) || !agent.nextWork() {
+		t.Fatal("agent didn't get work")
+	}
+
+	backend.mux.Post(downloader.StartEvent{})
+	if !agent.waitRunning(false) {
+		t.Fatal("agent not stopped during sync")
+	}
+	if !miner.Mining() {
+		t.Error("miner doesn't report mining while paused")
+	}
+
+	backend.mux.Post(downloader.DoneEvent{})
+	if !agent.waitRunning(true) {
+		t.Fatal("agent not restarted after sync")
+	}
+	if !agent.nextWork() {
+		t.Fatal("agent didn't get new work after sync")
+	}
+}
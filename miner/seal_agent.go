@@ -0,0 +1,74 @@
+package miner
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// SealAgent seals blocks using a consensus engine, e.g. by signing them
+// for proof of authority. Unlike CpuMiner it does not search for a
+// proof of work.
+type SealAgent struct {
+	mu       sync.Mutex
+	c        chan *types.Block
+	quit     chan struct{}
+	stop     chan struct{} // closed to abort the current operation
+	returnCh chan<- *types.Block
+
+	chain  consensus.ChainReader
+	engine consensus.Engine
+}
+
+func NewSealAgent(chain consensus.ChainReader, engine consensus.Engine) *SealAgent {
+	return &SealAgent{chain: chain, engine: engine}
+}
+
+func (self *SealAgent) Work() chan<- *types.Block          { return self.c }
+func (self *SealAgent) SetReturnCh(ch chan<- *types.Block) { self.returnCh = ch }
+func (self *SealAgent) GetHashRate() int64                 { return 0 }
+
+func (self *SealAgent) Start() {
+	self.quit = make(chan struct{})
+	self.c = make(chan *types.Block, 1)
+
+	go self.update()
+}
+
+func (self *SealAgent) Stop() {
+	close(self.quit)
+}
+
+func (self *SealAgent) update() {
+	for {
+		select {
+		case block := <-self.c:
+			self.mu.Lock()
+			if self.stop != nil {
+				close(self.stop)
+			}
+			self.stop = make(chan struct{})
+			go self.seal(block, self.stop)
+			self.mu.Unlock()
+		case <-self.quit:
+			self.mu.Lock()
+			if self.stop != nil {
+				close(self.stop)
+				self.stop = nil
+			}
+			self.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (self *SealAgent) seal(block *types.Block, stop <-chan struct{}) {
+	result, err := self.engine.Seal(self.chain, block, stop)
+	if err != nil {
+		glog.V(logger.Debug).Infof("could not seal block #%v: %v\n", block.Number(), err)
+	}
+	self.returnCh <- result
+}
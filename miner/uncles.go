@@ -0,0 +1,95 @@
+package miner
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/fatih/set.v0"
+)
+
+const (
+	maxUncles     = 2 // maximum number of uncles per block
+	maxUncleDepth = 7 // uncles must be children of one of this many ancestors
+)
+
+// unclePool holds side chain blocks which may be included as uncles
+// of the blocks we mine. It is fed by ChainSideEvent.
+type unclePool struct {
+	mu     sync.Mutex
+	blocks map[common.Hash]*types.Block
+}
+
+func newUnclePool() *unclePool {
+	return &unclePool{blocks: make(map[common.Hash]*types.Block)}
+}
+
+func (p *unclePool) add(block *types.Block) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blocks[block.Hash()] = block
+}
+
+func (p *unclePool) remove(hash common.Hash) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.blocks, hash)
+}
+
+// pick returns the uncles to include in a block with the given number
+// whose most recent ancestors, starting with its parent, are given.
+// A candidate qualifies if its parent is one of the ancestors and
+// neither the ancestors nor their uncles include it. Candidates which
+// are too old or already included are dropped from the pool.
+func (p *unclePool) pick(number uint64, ancestors []*types.Block) []*types.Header {
+	family := set.New()
+	parents := set.New()
+	for _, ancestor := range ancestors {
+		family.Add(ancestor.Hash())
+		parents.Add(ancestor.Hash())
+		for _, uncle := range ancestor.Uncles() {
+			family.Add(uncle.Hash())
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var candidates []*types.Block
+	for hash, block := range p.blocks {
+		if block.NumberU64()+maxUncleDepth <= number || family.Has(hash) {
+			delete(p.blocks, hash)
+			continue
+		}
+		if parents.Has(block.ParentHash()) {
+			candidates = append(candidates, block)
+		}
+	}
+	// Closer uncles earn a higher reward.
+	sort.Sort(unclesByNumber(candidates))
+
+	var uncles []*types.Header
+	for _, block := range candidates {
+		if len(uncles) == maxUncles {
+			break
+		}
+		uncles = append(uncles, block.Header())
+	}
+	return uncles
+}
+
+// unclesByNumber sorts blocks by descending number. Blocks of equal
+// number are ordered by hash to make the choice deterministic.
+type unclesByNumber []*types.Block
+
+func (s unclesByNumber) Len() int      { return len(s) }
+func (s unclesByNumber) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s unclesByNumber) Less(i, j int) bool {
+	if ni, nj := s[i].NumberU64(), s[j].NumberU64(); ni != nj {
+		return ni > nj
+	}
+	hi, hj := s[i].Hash(), s[j].Hash()
+	return bytes.Compare(hi[:], hj[:]) < 0
+}
@@ -0,0 +1,113 @@
+package miner
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// makeBlock creates a block with the given number on top of parent. The
+// extra data distinguishes siblings.
+func makeBlock(parent common.Hash, number int64, extra byte, uncles []*types.Header) *types.Block {
+	header := &types.Header{
+		ParentHash: parent,
+		Number:     big.NewInt(number),
+		Difficulty: big.NewInt(1),
+		GasLimit:   new(big.Int),
+		GasUsed:    new(big.Int),
+		Extra:      []byte{extra},
+	}
+	return types.NewBlock(header, nil, uncles, nil)
+}
+
+// makeAncestors creates a chain of n blocks and returns them newest first,
+// as expected by unclePool.pick.
+func makeAncestors(n int) []*types.Block {
+	ancestors := make([]*types.Block, n)
+	parent := common.Hash{}
+	for i := 0; i < n; i++ {
+		block := makeBlock(parent, int64(i+1), 0, nil)
+		ancestors[n-1-i] = block
+		parent = block.Hash()
+	}
+	return ancestors
+}
+
+func TestUnclePoolPickOrder(t *testing.T) {
+	ancestors := makeAncestors(3) // numbers 3, 2, 1
+	pool := newUnclePool()
+
+	far := makeBlock(ancestors[2].Hash(), 2, 1, nil)
+	near1 := makeBlock(ancestors[1].Hash(), 3, 1, nil)
+	near2 := makeBlock(ancestors[1].Hash(), 3, 2, nil)
+	for _, b := range []*types.Block{far, near1, near2} {
+		pool.add(b)
+	}
+
+	uncles := pool.pick(4, ancestors)
+	if len(uncles) != maxUncles {
+		t.Fatalf("picked %d uncles, want %d", len(uncles), maxUncles)
+	}
+	for _, u := range uncles {
+		if u.Number.Int64() != 3 {
+			t.Errorf("picked uncle %d, want the closest ones", u.Number)
+		}
+	}
+	if h0, h1 := uncles[0].Hash(), uncles[1].Hash(); bytes.Compare(h0[:], h1[:]) > 0 {
+		t.Error("uncles of equal number not ordered by hash")
+	}
+	// Picking doesn't remove candidates, they're only dropped once included.
+	if len(pool.blocks) != 3 {
+		t.Errorf("pool has %d blocks after pick, want 3", len(pool.blocks))
+	}
+}
+
+func TestUnclePoolPickParent(t *testing.T) {
+	ancestors := makeAncestors(2)
+	pool := newUnclePool()
+
+	orphan := makeBlock(common.Hash{0xff}, 2, 1, nil)
+	pool.add(orphan)
+	if uncles := pool.pick(3, ancestors); len(uncles) != 0 {
+		t.Errorf("picked uncle with unknown parent")
+	}
+	if _, ok := pool.blocks[orphan.Hash()]; !ok {
+		t.Error("block with unknown parent dropped from pool")
+	}
+}
+
+func TestUnclePoolEviction(t *testing.T) {
+	ancestors := makeAncestors(maxUncleDepth + 1)
+	oldest := ancestors[len(ancestors)-1]
+	number := ancestors[0].NumberU64() + 1
+
+	pool := newUnclePool()
+	// too old: its number plus the depth doesn't exceed the new block's
+	tooOld := makeBlock(oldest.ParentHash(), int64(oldest.NumberU64()), 1, nil)
+	// already included as the uncle of an ancestor
+	included := makeBlock(ancestors[3].Hash(), int64(ancestors[3].NumberU64())+1, 1, nil)
+	ancestors[0] = makeBlock(ancestors[1].Hash(), int64(ancestors[0].NumberU64()), 0, []*types.Header{included.Header()})
+	// part of the canonical chain
+	canonical := ancestors[2]
+	// valid candidate
+	valid := makeBlock(ancestors[2].Hash(), int64(ancestors[2].NumberU64())+1, 1, nil)
+
+	for _, b := range []*types.Block{tooOld, included, canonical, valid} {
+		pool.add(b)
+	}
+	uncles := pool.pick(number, ancestors)
+	if len(uncles) != 1 || uncles[0].Hash() != valid.Hash() {
+		t.Fatalf("picked %d uncles, want only the valid candidate", len(uncles))
+	}
+	for name, b := range map[string]*types.Block{"too old": tooOld, "included": included, "canonical": canonical} {
+		if _, ok := pool.blocks[b.Hash()]; ok {
+			t.Errorf("%s block not evicted", name)
+		}
+	}
+	if _, ok := pool.blocks[valid.Hash()]; !ok {
+		t.Error("valid candidate evicted")
+	}
+}
@@ -24,20 +24,27 @@ type environment struct {
 	totalUsedGas *big.Int
 	state        *state.StateDB
 	coinbase     *state.StateObject
-	block        *types.Block
-	family       *set.Set
-	uncles       *set.Set
+	header       *types.Header
+	txs          []*types.Transaction
+	block        *types.Block // assembled from the above once all work is committed
+
+	// header fields derived from the transactions and their receipts,
+	// updated as transactions are committed.
+	txHasher      *types.ListHasher
+	receiptHasher *types.ListHasher
+	bloom         types.Bloom
 }
 
-func env(block *types.Block, eth core.Backend) *environment {
-	state := state.New(block.Root(), eth.StateDb())
+func env(header *types.Header, eth core.Backend) *environment {
+	state := state.New(header.Root, eth.StateDb())
 	env := &environment{
-		totalUsedGas: new(big.Int),
-		state:        state,
-		block:        block,
-		family:       set.New(),
-		uncles:       set.New(),
-		coinbase:     state.GetOrNewStateObject(block.Coinbase()),
+		totalUsedGas:  new(big.Int),
+		state:         state,
+		header:        header,
+		block:         types.NewBlockWithHeader(header),
+		coinbase:      state.GetOrNewStateObject(header.Coinbase),
+		txHasher:      types.NewListHasher(),
+		receiptHasher: types.NewListHasher(),
 	}
 
 	return env
@@ -50,6 +57,11 @@ type Work struct {
 	SeedHash  []byte
 }
 
+// Agent seals the pending blocks assembled by the worker. New work is sent
+// on the Work channel and replaces the previous work, agents must receive
+// it promptly as the worker waits for them. Sealed blocks, or nil if
+// sealing failed, are sent to the return channel. Agents prepare their
+// sealing backend themselves when started.
 type Agent interface {
 	Work() chan<- *types.Block
 	SetReturnCh(chan<- *types.Block)
@@ -78,26 +90,40 @@ type worker struct {
 	currentMu sync.Mutex
 	current   *environment
 
-	uncleMu        sync.Mutex
-	possibleUncles map[common.Hash]*types.Block
+	// snapshot of the pending block and state, published once the work
+	// is committed so that readers don't wait for block assembly. The
+	// snapshot state must not be modified.
+	snapshotMu    sync.RWMutex
+	snapshotBlock *types.Block
+	snapshotState *state.StateDB
+
+	uncles *unclePool
 
 	txQueueMu sync.Mutex
 	txQueue   map[common.Hash]*types.Transaction
 
 	mining int64
+
+	// paused is set while the assembly of pending blocks is paused, the
+	// agents don't run meanwhile. pauseCh tells the update loop.
+	paused  int32
+	pauseCh chan bool
+
+	pendingLogsFeed event.Feed
 }
 
 func newWorker(coinbase common.Address, eth core.Backend) *worker {
 	worker := &worker{
-		eth:            eth,
-		mux:            eth.EventMux(),
-		recv:           make(chan *types.Block),
-		chain:          eth.ChainManager(),
-		proc:           eth.BlockProcessor(),
-		possibleUncles: make(map[common.Hash]*types.Block),
-		coinbase:       coinbase,
-		txQueue:        make(map[common.Hash]*types.Transaction),
-		quit:           make(chan struct{}),
+		eth:      eth,
+		mux:      eth.EventMux(),
+		recv:     make(chan *types.Block),
+		chain:    eth.ChainManager(),
+		proc:     eth.BlockProcessor(),
+		uncles:   newUnclePool(),
+		coinbase: coinbase,
+		txQueue:  make(map[common.Hash]*types.Transaction),
+		quit:     make(chan struct{}),
+		pauseCh:  make(chan bool),
 	}
 	go worker.update()
 	go worker.wait()
@@ -108,30 +134,49 @@ func newWorker(coinbase common.Address, eth core.Backend) *worker {
 }
 
 func (self *worker) pendingState() *state.StateDB {
-	self.currentMu.Lock()
-	defer self.currentMu.Unlock()
+	self.snapshotMu.RLock()
+	defer self.snapshotMu.RUnlock()
 
-	return self.current.state
+	return self.snapshotState
 }
 
 func (self *worker) pendingBlock() *types.Block {
-	self.currentMu.Lock()
-	defer self.currentMu.Unlock()
+	self.snapshotMu.RLock()
+	defer self.snapshotMu.RUnlock()
+
+	return self.snapshotBlock
+}
 
-	return self.current.block
+// updateSnapshot publishes the current pending block and state.
+func (self *worker) updateSnapshot() {
+	self.snapshotMu.Lock()
+	defer self.snapshotMu.Unlock()
+
+	self.snapshotBlock = self.current.block
+	self.snapshotState = self.current.state.Copy()
+}
+
+// running reports whether the agents run, i.e. mining was started and
+// isn't paused.
+func (self *worker) running() bool {
+	return atomic.LoadInt64(&self.mining) == 1 && atomic.LoadInt32(&self.paused) == 0
 }
 
+// start, stop, register, pause and resume must not be called concurrently,
+// the miner serialises them.
 func (self *worker) start() {
+	atomic.StoreInt64(&self.mining, 1)
+
 	// spin up agents
-	for _, agent := range self.agents {
-		agent.Start()
+	if self.running() {
+		for _, agent := range self.agents {
+			agent.Start()
+		}
 	}
-
-	atomic.StoreInt64(&self.mining, 1)
 }
 
 func (self *worker) stop() {
-	if atomic.LoadInt64(&self.mining) == 1 {
+	if self.running() {
 		// stop all agents
 		for _, agent := range self.agents {
 			agent.Stop()
@@ -145,10 +190,56 @@ func (self *worker) stop() {
 func (self *worker) register(agent Agent) {
 	self.agents = append(self.agents, agent)
 	agent.SetReturnCh(self.recv)
+	if self.running() {
+		agent.Start()
+	}
+}
+
+// pause stops the assembly of pending blocks and the agents, e.g. while
+// syncing as every imported block would restart them.
+func (self *worker) pause() {
+	self.mu.Lock()
+	if self.running() {
+		for _, agent := range self.agents {
+			agent.Stop()
+		}
+		atomic.StoreInt64(&self.atWork, 0)
+	}
+	atomic.StoreInt32(&self.paused, 1)
+	self.mu.Unlock()
+
+	select {
+	case self.pauseCh <- true:
+	case <-self.quit:
+	}
+}
+
+// resume restarts the assembly of pending blocks and the agents. Work
+// skipped while paused is assembled right away.
+func (self *worker) resume() {
+	self.mu.Lock()
+	atomic.StoreInt32(&self.paused, 0)
+	if self.running() {
+		for _, agent := range self.agents {
+			agent.Start()
+		}
+	}
+	self.mu.Unlock()
+
+	select {
+	case self.pauseCh <- false:
+	case <-self.quit:
+	}
 }
 
 func (self *worker) update() {
-	events := self.mux.Subscribe(core.ChainHeadEvent{}, core.ChainSideEvent{}, core.TxPreEvent{})
+	events := self.mux.Subscribe(core.ChainHeadEvent{}, core.ChainSideEvent{})
+	txs := self.eth.TxPool().SubscribeTxPreEvent()
+
+	var (
+		paused bool
+		stale  bool // set when new work was skipped while paused
+	)
 
 out:
 	for {
@@ -156,15 +247,27 @@ out:
 		case event := <-events.Chan():
 			switch ev := event.(type) {
 			case core.ChainHeadEvent:
+				if paused {
+					stale = true
+					continue
+				}
 				self.commitNewWork()
 			case core.ChainSideEvent:
-				self.uncleMu.Lock()
-				self.possibleUncles[ev.Block.Hash()] = ev.Block
-				self.uncleMu.Unlock()
-			case core.TxPreEvent:
-				if atomic.LoadInt64(&self.mining) == 0 {
-					self.commitNewWork()
+				self.uncles.add(ev.Block)
+			}
+		case <-txs.Chan():
+			if atomic.LoadInt64(&self.mining) == 0 {
+				if paused {
+					stale = true
+					continue
 				}
+				self.commitNewWork()
+			}
+		case paused = <-self.pauseCh:
+			// restarted agents need new work as well
+			if !paused && (stale || atomic.LoadInt64(&self.mining) == 1) {
+				stale = false
+				self.commitNewWork()
 			}
 		case <-self.quit:
 			break out
@@ -172,6 +275,7 @@ out:
 	}
 
 	events.Unsubscribe()
+	txs.Unsubscribe()
 }
 
 func (self *worker) wait() {
@@ -185,7 +289,7 @@ func (self *worker) wait() {
 
 			if err := self.chain.InsertChain(types.Blocks{block}); err == nil {
 				for _, uncle := range block.Uncles() {
-					delete(self.possibleUncles, uncle.Hash())
+					self.uncles.remove(uncle.Hash())
 				}
 				self.mux.Post(core.NewMinedBlockEvent{block})
 
@@ -205,16 +309,17 @@ func (self *worker) wait() {
 }
 
 func (self *worker) push() {
-	if atomic.LoadInt64(&self.mining) == 1 {
-		self.current.block.Header().GasUsed = self.current.totalUsedGas
-		self.current.block.SetRoot(self.current.state.Root())
+	if self.running() {
+		header := self.current.block.Header()
+		header.Root = self.current.state.Root()
+		self.current.block = self.current.block.WithHeader(header)
 
 		// push new work to agents
 		for _, agent := range self.agents {
 			atomic.AddInt64(&self.atWork, 1)
 
 			if agent.Work() != nil {
-				agent.Work() <- self.current.block.Copy()
+				agent.Work() <- self.current.block
 			} else {
 				common.Report(fmt.Sprintf("%v %T\n", agent, agent))
 			}
@@ -223,26 +328,24 @@ func (self *worker) push() {
 }
 
 func (self *worker) makeCurrent() {
-	block := self.chain.NewBlock(self.coinbase)
-	if block.Time() == self.chain.CurrentBlock().Time() {
-		block.Header().Time++
+	config := self.chain.Config()
+	header := self.chain.NewBlock(self.coinbase).Header()
+	parent := self.chain.GetBlock(header.ParentHash)
+	if header.Time == parent.Header().Time && (config == nil || !config.SubSecondBlocks) {
+		header.Time++
 	}
-	block.Header().Extra = self.extra
-
-	self.current = env(block, self.eth)
-	for _, ancestor := range self.chain.GetAncestors(block, 7) {
-		self.current.family.Add(ancestor.Hash())
+	header.Extra = self.extra
+	if err := self.proc.Engine().Prepare(self.chain, header, parent.Header()); err != nil {
+		glog.V(logger.Error).Infoln("could not prepare block:", err)
 	}
 
-	parent := self.chain.GetBlock(self.current.block.ParentHash())
-	self.current.coinbase.SetGasPool(core.CalcGasLimit(parent, self.current.block))
+	self.current = env(header, self.eth)
+	self.current.coinbase.SetGasPool(core.CalcGasLimit(config, parent, self.current.block))
 }
 
 func (self *worker) commitNewWork() {
 	self.mu.Lock()
 	defer self.mu.Unlock()
-	self.uncleMu.Lock()
-	defer self.uncleMu.Unlock()
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
 
@@ -281,25 +384,13 @@ func (self *worker) commitNewWork() {
 	}
 	//self.eth.TxPool().InvalidateSet(remove)
 
-	var (
-		uncles    []*types.Header
-		badUncles []common.Hash
-	)
-	for hash, uncle := range self.possibleUncles {
-		if len(uncles) == 2 {
-			break
-		}
-
-		if err := self.commitUncle(uncle.Header()); err != nil {
-			if glog.V(logger.Ridiculousness) {
-				glog.V(logger.Detail).Infof("Bad uncle found and will be removed (%x)\n", hash[:4])
-				glog.V(logger.Detail).Infoln(uncle)
-			}
-
-			badUncles = append(badUncles, hash)
-		} else {
-			glog.V(logger.Debug).Infof("commiting %x as uncle\n", hash[:4])
-			uncles = append(uncles, uncle.Header())
+	// Proof-of-authority blocks have no uncles.
+	var uncles []*types.Header
+	if config := self.chain.Config(); config == nil || config.Clique == nil {
+		ancestors := self.chain.GetAncestors(self.current.block, maxUncleDepth)
+		uncles = self.uncles.pick(self.current.header.Number.Uint64(), ancestors)
+		for _, uncle := range uncles {
+			glog.V(logger.Debug).Infof("commiting %x as uncle\n", uncle.Hash().Bytes()[:4])
 		}
 	}
 
@@ -308,17 +399,24 @@ func (self *worker) commitNewWork() {
 		glog.V(logger.Info).Infof("commit new work on block %v with %d txs & %d uncles\n", self.current.block.Number(), tcount, len(uncles))
 	}
 
-	for _, hash := range badUncles {
-		delete(self.possibleUncles, hash)
-	}
+	header := self.current.header
+	header.GasUsed = self.current.totalUsedGas
+	header.TxHash = self.current.txHasher.Hash()
+	header.ReceiptHash = self.current.receiptHasher.Hash()
+	header.Bloom = self.current.bloom
+	header.UncleHash = types.CalcUncleHash(uncles)
+	self.current.block = types.NewBlockWithHeader(header).WithBody(self.current.txs, uncles)
 
-	self.current.block.SetUncles(uncles)
-
-	core.AccumulateRewards(self.current.state, self.current.block)
+	self.proc.Engine().Finalize(self.chain, self.current.state, self.current.block)
 
 	self.current.state.Update()
 
+	if logs := self.current.state.Logs(); len(logs) > 0 {
+		self.pendingLogsFeed.Send(core.PendingLogsEvent{logs})
+	}
+
 	self.push()
+	self.updateSnapshot()
 }
 
 var (
@@ -327,24 +425,6 @@ var (
 	uncleReward     = new(big.Int).Div(_uncleReward, big.NewInt(16))
 )
 
-func (self *worker) commitUncle(uncle *types.Header) error {
-	if self.current.uncles.Has(uncle.Hash()) {
-		// Error not unique
-		return core.UncleError("Uncle not unique")
-	}
-	self.current.uncles.Add(uncle.Hash())
-
-	if !self.current.family.Has(uncle.ParentHash) {
-		return core.UncleError(fmt.Sprintf("Uncle's parent unknown (%x)", uncle.ParentHash[0:4]))
-	}
-
-	if self.current.family.Has(uncle.Hash()) {
-		return core.UncleError(fmt.Sprintf("Uncle already in family (%x)", uncle.Hash()))
-	}
-
-	return nil
-}
-
 func (self *worker) commitTransaction(tx *types.Transaction) error {
 	snap := self.current.state.Copy()
 	receipt, _, err := self.proc.ApplyTransaction(self.current.coinbase, self.current.state, self.current.block, tx, self.current.totalUsedGas, true)
@@ -353,8 +433,10 @@ func (self *worker) commitTransaction(tx *types.Transaction) error {
 		return err
 	}
 
-	self.current.block.AddTransaction(tx)
-	self.current.block.AddReceipt(receipt)
+	self.current.txs = append(self.current.txs, tx)
+	self.current.txHasher.Append(types.Transactions{tx}.GetRlp(0))
+	self.current.receiptHasher.Append(types.Receipts{receipt}.GetRlp(0))
+	self.current.bloom.Or(receipt.Bloom)
 
 	return nil
 }
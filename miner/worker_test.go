@@ -0,0 +1,82 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// testBackend is a core.Backend with an in-memory chain. Blocks and state
+// are kept in separate databases as the memory database can't be written
+// and read concurrently.
+type testBackend struct {
+	blockDb common.Database
+	stateDb common.Database
+	mux     *event.TypeMux
+	chain   *core.ChainManager
+	proc    *core.BlockProcessor
+	pool    *core.TxPool
+}
+
+func newTestBackend() *testBackend {
+	blockDb, _ := ethdb.NewMemDatabase()
+	stateDb, _ := ethdb.NewMemDatabase()
+	mux := new(event.TypeMux)
+	chain := core.NewChainManager(blockDb, stateDb, mux)
+	pool := core.NewTxPool(mux, chain.State)
+	proc := core.NewBlockProcessor(stateDb, blockDb, core.FakePow{}, pool, chain, mux)
+	chain.SetProcessor(proc)
+	return &testBackend{blockDb: blockDb, stateDb: stateDb, mux: mux, chain: chain, proc: proc, pool: pool}
+}
+
+func (b *testBackend) BlockProcessor() *core.BlockProcessor { return b.proc }
+func (b *testBackend) ChainManager() *core.ChainManager     { return b.chain }
+func (b *testBackend) TxPool() *core.TxPool                 { return b.pool }
+func (b *testBackend) PeerCount() int                       { return 0 }
+func (b *testBackend) IsListening() bool                    { return false }
+func (b *testBackend) Peers() []*p2p.Peer                   { return nil }
+func (b *testBackend) BlockDb() common.Database             { return b.blockDb }
+func (b *testBackend) StateDb() common.Database             { return b.stateDb }
+func (b *testBackend) EventMux() *event.TypeMux             { return b.mux }
+
+// waitPending waits until the pending block is built on parent.
+func waitPending(w *worker, parent common.Hash, timeout time.Duration) bool {
+	for end := time.Now().Add(timeout); time.Now().Before(end); time.Sleep(10 * time.Millisecond) {
+		if w.pendingBlock().ParentHash() == parent {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWorkerPause(t *testing.T) {
+	backend := newTestBackend()
+	defer backend.chain.Stop()
+	w := newWorker(common.Address{}, backend)
+	genesis := backend.chain.Genesis()
+	if parent := w.pendingBlock().ParentHash(); parent != genesis.Hash() {
+		t.Fatalf("pending block built on %x, want genesis", parent)
+	}
+
+	w.pause()
+	block := core.MakeBlock(backend.proc, genesis, 0, backend.stateDb, core.CanonicalSeed)
+	block.Td = core.CalculateTD(block, genesis)
+	if err := backend.chain.InsertChain(types.Blocks{block}); err != nil {
+		t.Fatal(err)
+	}
+	if waitPending(w, block.Hash(), 200*time.Millisecond) {
+		t.Fatal("pending block assembled while paused")
+	}
+
+	// the skipped work is assembled on resume
+	w.resume()
+	if !waitPending(w, block.Hash(), time.Second) {
+		t.Fatal("pending block not assembled on resume")
+	}
+}
@@ -0,0 +1,55 @@
+package p2p
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ClientInfo describes the software of a remote node as announced
+// in the name field of its protocol handshake. Names following the
+// convention of common.MakeName look like
+//
+//    Geth/v0.9.20/linux/go1.4.2
+//    Geth/myidentity/v0.9.20/linux/go1.4.2
+//
+// Fields which can't be determined from the name are left empty.
+type ClientInfo struct {
+	Name      string // client implementation, e.g. "Geth"
+	Version   string // e.g. "v0.9.20"
+	OS        string
+	GoVersion string
+}
+
+// String returns the client name and version, e.g. "Geth/v0.9.20".
+func (c ClientInfo) String() string {
+	if c.Version == "" {
+		return c.Name
+	}
+	return c.Name + "/" + c.Version
+}
+
+// parseClientName splits a node name into its parts. The version is
+// the first part starting with "v" and a digit. Parts between the
+// client name and the version (custom identities) are skipped.
+func parseClientName(name string) ClientInfo {
+	parts := strings.Split(name, "/")
+	info := ClientInfo{Name: parts[0]}
+	for i := 1; i < len(parts); i++ {
+		if !isVersion(parts[i]) {
+			continue
+		}
+		info.Version = parts[i]
+		if i+1 < len(parts) {
+			info.OS = parts[i+1]
+		}
+		if i+2 < len(parts) {
+			info.GoVersion = parts[i+2]
+		}
+		break
+	}
+	return info
+}
+
+func isVersion(s string) bool {
+	return len(s) > 1 && s[0] == 'v' && unicode.IsDigit(rune(s[1]))
+}
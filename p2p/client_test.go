@@ -0,0 +1,37 @@
+package p2p
+
+import "testing"
+
+var parseClientNameTests = []struct {
+	name string
+	want ClientInfo
+}{
+	{
+		name: "Geth/v0.9.20/linux/go1.4.2",
+		want: ClientInfo{Name: "Geth", Version: "v0.9.20", OS: "linux", GoVersion: "go1.4.2"},
+	},
+	{
+		name: "Geth/myidentity/v0.9.20-3f4a5b6c/darwin/go1.4.2",
+		want: ClientInfo{Name: "Geth", Version: "v0.9.20-3f4a5b6c", OS: "darwin", GoVersion: "go1.4.2"},
+	},
+	{
+		name: "++eth/v0.9.20",
+		want: ClientInfo{Name: "++eth", Version: "v0.9.20"},
+	},
+	{
+		name: "pyethapp",
+		want: ClientInfo{Name: "pyethapp"},
+	},
+	{
+		name: "",
+		want: ClientInfo{},
+	},
+}
+
+func TestParseClientName(t *testing.T) {
+	for _, test := range parseClientNameTests {
+		if got := parseClientName(test.name); got != test.want {
+			t.Errorf("%q: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
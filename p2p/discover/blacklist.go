@@ -0,0 +1,149 @@
+package discover
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+)
+
+// Blacklist is a set of banned node IDs and IP addresses. Packets
+// from banned nodes are dropped and banned nodes are never added to
+// the table. A Blacklist opened from a file saves every change back
+// to it, so bans survive restarts.
+//
+// A nil *Blacklist is valid and bans nothing.
+type Blacklist struct {
+	mu   sync.RWMutex
+	path string // empty if the list isn't saved
+	ids  map[NodeID]struct{}
+	ips  map[string]struct{}
+}
+
+// blacklistJSON is the on-disk form of a Blacklist.
+type blacklistJSON struct {
+	IDs []string `json:"ids"`
+	IPs []string `json:"ips"`
+}
+
+// NewBlacklist creates an empty, in-memory blacklist.
+func NewBlacklist() *Blacklist {
+	return &Blacklist{
+		ids: make(map[NodeID]struct{}),
+		ips: make(map[string]struct{}),
+	}
+}
+
+// OpenBlacklist loads the blacklist saved at path. A missing file
+// yields an empty list which is created on the first ban.
+func OpenBlacklist(path string) (*Blacklist, error) {
+	bl := NewBlacklist()
+	bl.path = path
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return bl, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var enc blacklistJSON
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, err
+	}
+	for _, s := range enc.IDs {
+		id, err := HexID(s)
+		if err != nil {
+			return nil, err
+		}
+		bl.ids[id] = struct{}{}
+	}
+	for _, s := range enc.IPs {
+		if ip := net.ParseIP(s); ip != nil {
+			bl.ips[ip.String()] = struct{}{}
+		}
+	}
+	return bl, nil
+}
+
+// BanID adds a node ID to the list.
+func (bl *Blacklist) BanID(id NodeID) error {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	bl.ids[id] = struct{}{}
+	return bl.save()
+}
+
+// BanIP adds an IP address to the list.
+func (bl *Blacklist) BanIP(ip net.IP) error {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	bl.ips[ip.String()] = struct{}{}
+	return bl.save()
+}
+
+// UnbanID removes a node ID from the list.
+func (bl *Blacklist) UnbanID(id NodeID) error {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	delete(bl.ids, id)
+	return bl.save()
+}
+
+// UnbanIP removes an IP address from the list.
+func (bl *Blacklist) UnbanIP(ip net.IP) error {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	delete(bl.ips, ip.String())
+	return bl.save()
+}
+
+// Banned reports whether the node ID or the IP address is banned.
+// The IP is not checked if it is nil.
+func (bl *Blacklist) Banned(id NodeID, ip net.IP) bool {
+	if bl == nil {
+		return false
+	}
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	_, ok := bl.ids[id]
+	return ok || bl.bannedIP(ip)
+}
+
+// BannedIP reports whether the IP address is banned.
+func (bl *Blacklist) BannedIP(ip net.IP) bool {
+	if bl == nil {
+		return false
+	}
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	return bl.bannedIP(ip)
+}
+
+func (bl *Blacklist) bannedIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	_, ok := bl.ips[ip.String()]
+	return ok
+}
+
+// save writes the list to its file. The caller must hold bl.mu.
+func (bl *Blacklist) save() error {
+	if bl.path == "" {
+		return nil
+	}
+	enc := blacklistJSON{IDs: []string{}, IPs: []string{}}
+	for id := range bl.ids {
+		enc.IDs = append(enc.IDs, id.String())
+	}
+	for ip := range bl.ips {
+		enc.IPs = append(enc.IPs, ip)
+	}
+	data, err := json.MarshalIndent(enc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(bl.path, data, 0600)
+}
@@ -0,0 +1,71 @@
+package discover
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlacklistPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blacklist-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "blacklist.json")
+
+	bl, err := OpenBlacklist(file)
+	if err != nil {
+		t.Fatalf("can't open missing blacklist: %v", err)
+	}
+	id, ip := NodeID{1, 2, 3}, net.IP{10, 0, 0, 1}
+	if err := bl.BanID(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := bl.BanIP(ip); err != nil {
+		t.Fatal(err)
+	}
+
+	bl, err = OpenBlacklist(file)
+	if err != nil {
+		t.Fatalf("can't reopen blacklist: %v", err)
+	}
+	if !bl.Banned(id, nil) {
+		t.Error("banned ID not restored")
+	}
+	if !bl.Banned(NodeID{4}, net.ParseIP("10.0.0.1")) {
+		t.Error("banned IP not restored")
+	}
+	if bl.Banned(NodeID{4}, net.IP{10, 0, 0, 2}) {
+		t.Error("unbanned node reported as banned")
+	}
+	if err := bl.UnbanID(id); err != nil {
+		t.Fatal(err)
+	}
+	if bl.Banned(id, nil) {
+		t.Error("unbanned ID still banned")
+	}
+}
+
+func TestNilBlacklist(t *testing.T) {
+	var bl *Blacklist
+	if bl.Banned(NodeID{1}, net.IP{10, 0, 0, 1}) || bl.BannedIP(net.IP{10, 0, 0, 1}) {
+		t.Error("nil blacklist bans nodes")
+	}
+}
+
+func TestTable_Ban(t *testing.T) {
+	tab := newTable(nil, NodeID{}, &net.UDPAddr{})
+	last := fillBucket(tab, 200)
+	if err := tab.Ban(last.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if contains(tab.buckets[200].entries, last.ID) {
+		t.Error("banned node still in table")
+	}
+	if l := len(tab.buckets[200].entries); l != bucketSize-1 {
+		t.Errorf("wrong bucket size after ban: got %d, want %d", l, bucketSize-1)
+	}
+}
@@ -0,0 +1,68 @@
+package discover
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// lookupIP is the resolver used by DNSNode. It is a variable
+// so tests can replace it.
+var lookupIP = net.LookupIP
+
+// DNSNode is a node whose URL names the host by its DNS name instead
+// of an IP address. This allows operators to move a node to another
+// address without changing the URL.
+type DNSNode struct {
+	ID   NodeID
+	Host string
+
+	DiscPort int // UDP listening port for discovery protocol
+	TCPPort  int // TCP listening port for RLPx
+}
+
+// ParseDNSNode parses a node URL whose host is a DNS name. The URL
+// format is the same as for ParseNode, e.g.
+//
+//    enode://<hex node id>@boot.example.org:30303?discport=30301
+//
+// URLs containing an IP address are rejected, use ParseNode for those.
+func ParseDNSNode(rawurl string) (*DNSNode, error) {
+	n, err := parseURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(n.Host) != nil {
+		return nil, errors.New("host is an IP address")
+	}
+	return n, nil
+}
+
+// Resolve looks up the host name and returns a node for
+// each of its addresses.
+func (n *DNSNode) Resolve() ([]*Node, error) {
+	ips, err := lookupIP(n.Host)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*Node, 0, len(ips))
+	for _, ip := range ips {
+		nodes = append(nodes, &Node{ID: n.ID, IP: ip, DiscPort: n.DiscPort, TCPPort: n.TCPPort})
+	}
+	return nodes, nil
+}
+
+// The string representation of a DNSNode is a URL.
+func (n *DNSNode) String() string {
+	u := url.URL{
+		Scheme: "enode",
+		User:   url.User(fmt.Sprintf("%x", n.ID[:])),
+		Host:   net.JoinHostPort(n.Host, strconv.Itoa(n.TCPPort)),
+	}
+	if n.DiscPort != n.TCPPort {
+		u.RawQuery = "discport=" + strconv.Itoa(n.DiscPort)
+	}
+	return u.String()
+}
@@ -0,0 +1,51 @@
+package discover
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+const testDNSNodeID = "1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439"
+
+func TestParseDNSNode(t *testing.T) {
+	rawurl := "enode://" + testDNSNodeID + "@boot.example.org:30303?discport=30301"
+	n, err := ParseDNSNode(rawurl)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &DNSNode{ID: MustHexID(testDNSNodeID), Host: "boot.example.org", DiscPort: 30301, TCPPort: 30303}
+	if !reflect.DeepEqual(n, want) {
+		t.Errorf("result mismatch:\ngot:  %#v\nwant: %#v", n, want)
+	}
+	if s := n.String(); s != rawurl {
+		t.Errorf("DNSNode.String() mismatch:\ngot:  %s\nwant: %s", s, rawurl)
+	}
+
+	if _, err := ParseDNSNode("enode://" + testDNSNodeID + "@127.0.0.1:30303"); err == nil {
+		t.Error("expected error for URL with IP address")
+	}
+}
+
+func TestDNSNodeResolve(t *testing.T) {
+	defer func(f func(string) ([]net.IP, error)) { lookupIP = f }(lookupIP)
+	lookupIP = func(host string) ([]net.IP, error) {
+		if host != "boot.example.org" {
+			t.Errorf("lookup of wrong host %q", host)
+		}
+		return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("2001:db8::1")}, nil
+	}
+
+	n := &DNSNode{ID: MustHexID(testDNSNodeID), Host: "boot.example.org", DiscPort: 30301, TCPPort: 30303}
+	nodes, err := n.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Node{
+		{ID: n.ID, IP: net.ParseIP("10.0.0.1"), DiscPort: 30301, TCPPort: 30303},
+		{ID: n.ID, IP: net.ParseIP("2001:db8::1"), DiscPort: 30301, TCPPort: 30303},
+	}
+	if !reflect.DeepEqual(nodes, want) {
+		t.Errorf("result mismatch:\ngot:  %v\nwant: %v", nodes, want)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
@@ -42,7 +43,22 @@ func newNode(id NodeID, addr *net.UDPAddr) *Node {
 
 func (n *Node) isValid() bool {
 	// TODO: don't accept localhost, LAN addresses from internet hosts
-	return !n.IP.IsMulticast() && !n.IP.IsUnspecified() && n.TCPPort != 0 && n.DiscPort != 0
+	return n.Validate() == nil
+}
+
+// Validate checks whether the node can be contacted. The IP address
+// must not be a multicast or unspecified address and both ports must
+// be valid, non-zero port numbers.
+func (n *Node) Validate() error {
+	switch {
+	case n.IP == nil || n.IP.IsMulticast() || n.IP.IsUnspecified():
+		return errors.New("invalid IP address (multicast or unspecified)")
+	case n.TCPPort <= 0 || n.TCPPort > 65535:
+		return errors.New("invalid TCP port")
+	case n.DiscPort <= 0 || n.DiscPort > 65535:
+		return errors.New("invalid discovery port")
+	}
+	return nil
 }
 
 func (n *Node) addr() *net.UDPAddr {
@@ -64,13 +80,24 @@ func (n *Node) String() string {
 	return u.String()
 }
 
+// NodeURL returns the URL of the node with the given public key
+// that listens on ip for RLPx connections on tcpPort and for
+// discovery packets on discPort. The result can be parsed with
+// ParseNode.
+func NodeURL(pub *ecdsa.PublicKey, ip net.IP, tcpPort, discPort int) string {
+	n := &Node{ID: PubkeyID(pub), IP: ip, TCPPort: tcpPort, DiscPort: discPort}
+	return n.String()
+}
+
 // ParseNode parses a node URL.
 //
 // A node URL has scheme "enode".
 //
 // The hexadecimal node ID is encoded in the username portion of the
 // URL, separated from the host by an @ sign. The hostname can only be
-// given as an IP address, DNS domain names are not allowed. The port
+// given as an IP address, DNS domain names are not allowed (see
+// ParseDNSNode for URLs containing those). IPv6
+// addresses must be enclosed in square brackets. The port
 // in the host name section is the TCP listening port. If the TCP and
 // UDP (discovery) ports differ, the UDP port is specified as query
 // parameter "discport".
@@ -80,9 +107,30 @@ func (n *Node) String() string {
 // and UDP discovery port 30301.
 //
 //    enode://<hex node id>@10.3.58.6:30303?discport=30301
+//
+// The same node reachable at IPv6 address 2001:db8::3a06:
+//
+//    enode://<hex node id>@[2001:db8::3a06]:30303?discport=30301
 func ParseNode(rawurl string) (*Node, error) {
-	var n Node
+	u, err := parseURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(u.Host)
+	if ip == nil {
+		return nil, errors.New("invalid IP address")
+	}
+	return &Node{ID: u.ID, IP: ip, TCPPort: u.TCPPort, DiscPort: u.DiscPort}, nil
+}
+
+// parseURL parses the parts of a node URL common to ParseNode and
+// ParseDNSNode. The host is returned without being checked.
+func parseURL(rawurl string) (*DNSNode, error) {
+	var n DNSNode
 	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
 	if u.Scheme != "enode" {
 		return nil, errors.New("invalid URL scheme, want \"enode\"")
 	}
@@ -92,13 +140,10 @@ func ParseNode(rawurl string) (*Node, error) {
 	if n.ID, err = HexID(u.User.String()); err != nil {
 		return nil, fmt.Errorf("invalid node ID (%v)", err)
 	}
-	ip, port, err := net.SplitHostPort(u.Host)
-	if err != nil {
+	var port string
+	if n.Host, port, err = net.SplitHostPort(u.Host); err != nil {
 		return nil, fmt.Errorf("invalid host: %v", err)
 	}
-	if n.IP = net.ParseIP(ip); n.IP == nil {
-		return nil, errors.New("invalid IP address")
-	}
 	if n.TCPPort, err = strconv.Atoi(port); err != nil {
 		return nil, errors.New("invalid port")
 	}
@@ -308,8 +353,9 @@ func randomID(a NodeID, n int) (b NodeID) {
 
 // nodeDB stores all nodes we know about.
 type nodeDB struct {
-	mu   sync.RWMutex
-	byID map[NodeID]*Node
+	mu    sync.RWMutex
+	byID  map[NodeID]*Node
+	since map[NodeID]time.Time // time of the last completed bond
 }
 
 func (db *nodeDB) get(id NodeID) *Node {
@@ -323,8 +369,29 @@ func (db *nodeDB) add(id NodeID, addr *net.UDPAddr, tcpPort uint16) *Node {
 	defer db.mu.Unlock()
 	if db.byID == nil {
 		db.byID = make(map[NodeID]*Node)
+		db.since = make(map[NodeID]time.Time)
 	}
 	n := &Node{ID: id, IP: addr.IP, DiscPort: addr.Port, TCPPort: int(tcpPort)}
 	db.byID[n.ID] = n
+	db.since[n.ID] = time.Now()
 	return n
 }
+
+// bonded reports whether the node completed the ping/pong bonding
+// from the given IP address within the last bondExpiration.
+func (db *nodeDB) bonded(id NodeID, ip net.IP) bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	n := db.byID[id]
+	if n == nil || !n.IP.Equal(ip) {
+		return false
+	}
+	return time.Since(db.since[id]) < bondExpiration
+}
+
+func (db *nodeDB) delete(id NodeID) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.byID, id)
+	delete(db.since, id)
+}
@@ -38,6 +38,10 @@ var parseNodeTests = []struct {
 		rawurl:    "enode://1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@hostname:3",
 		wantError: `invalid IP address`,
 	},
+	{
+		rawurl:    "enode://1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@2001:db8::3a06:3",
+		wantError: `invalid host: address 2001:db8::3a06:3: too many colons in address`,
+	},
 	{
 		rawurl:    "enode://1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@127.0.0.1:foo",
 		wantError: `invalid port`,
@@ -64,6 +68,15 @@ var parseNodeTests = []struct {
 			TCPPort:  52150,
 		},
 	},
+	{
+		rawurl: "enode://1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@[2001:db8::3a06]:52150?discport=52151",
+		wantResult: &Node{
+			ID:       MustHexID("0x1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439"),
+			IP:       net.ParseIP("2001:db8::3a06"),
+			DiscPort: 52151,
+			TCPPort:  52150,
+		},
+	},
 	{
 		rawurl: "enode://1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@127.0.0.1:52150?discport=223344",
 		wantResult: &Node{
@@ -104,6 +117,41 @@ func TestNodeString(t *testing.T) {
 	}
 }
 
+func TestNodeURL(t *testing.T) {
+	key := newkey()
+	url := NodeURL(&key.PublicKey, net.IP{10, 3, 58, 6}, 30303, 30301)
+	n, err := ParseNode(url)
+	if err != nil {
+		t.Fatalf("can't parse %s: %v", url, err)
+	}
+	if n.ID != PubkeyID(&key.PublicKey) || !n.IP.Equal(net.IP{10, 3, 58, 6}) || n.TCPPort != 30303 || n.DiscPort != 30301 {
+		t.Errorf("parsed node mismatch: %v", n)
+	}
+}
+
+var validateNodeTests = []struct {
+	n         Node
+	wantError string
+}{
+	{n: Node{IP: net.IP{10, 0, 0, 1}, TCPPort: 30303, DiscPort: 30301}},
+	{n: Node{IP: net.ParseIP("::"), TCPPort: 30303, DiscPort: 30303}, wantError: "invalid IP address (multicast or unspecified)"},
+	{n: Node{IP: net.IP{224, 0, 0, 1}, TCPPort: 30303, DiscPort: 30303}, wantError: "invalid IP address (multicast or unspecified)"},
+	{n: Node{IP: net.IP{10, 0, 0, 1}, TCPPort: 0, DiscPort: 30303}, wantError: "invalid TCP port"},
+	{n: Node{IP: net.IP{10, 0, 0, 1}, TCPPort: 30303, DiscPort: 223344}, wantError: "invalid discovery port"},
+}
+
+func TestNodeValidate(t *testing.T) {
+	for i, test := range validateNodeTests {
+		err := test.n.Validate()
+		if err == nil && test.wantError != "" {
+			t.Errorf("test %d: got nil error, expected %#q", i, test.wantError)
+		}
+		if err != nil && err.Error() != test.wantError {
+			t.Errorf("test %d: got error %#q, expected %#q", i, err.Error(), test.wantError)
+		}
+	}
+}
+
 func TestHexID(t *testing.T) {
 	ref := NodeID{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 128, 106, 217, 182, 31, 165, 174, 1, 67, 7, 235, 220, 150, 66, 83, 173, 205, 159, 44, 10, 57, 42, 161, 26, 188}
 	id1 := MustHexID("0x000000000000000000000000000000000000000000000000000000000000000000000000000000806ad9b61fa5ae014307ebdc964253adcd9f2c0a392aa11abc")
@@ -18,6 +18,7 @@ const (
 	bucketSize          = 16             // Kademlia bucket size
 	nBuckets            = nodeIDBits + 1 // Number of buckets
 	maxBondingPingPongs = 10
+	bondExpiration      = 24 * time.Hour // time after which a node must bond again
 )
 
 type Table struct {
@@ -29,9 +30,10 @@ type Table struct {
 	bonding   map[NodeID]*bondproc
 	bondslots chan struct{} // limits total number of active bonding processes
 
-	net  transport
-	self *Node // metadata of the local node
-	db   *nodeDB
+	net       transport
+	self      *Node // metadata of the local node
+	db        *nodeDB
+	blacklist *Blacklist
 }
 
 type bondproc struct {
@@ -62,6 +64,7 @@ func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr) *Table {
 	tab := &Table{
 		net:       t,
 		db:        new(nodeDB),
+		blacklist: NewBlacklist(),
 		self:      newNode(ourID, ourAddr),
 		bonding:   make(map[NodeID]*bondproc),
 		bondslots: make(chan struct{}, maxBondingPingPongs),
@@ -234,8 +237,11 @@ func (tab *Table) bondall(nodes []*Node) (result []*Node) {
 // If pinged is true, the remote node has just pinged us and one half
 // of the process can be skipped.
 func (tab *Table) bond(pinged bool, id NodeID, addr *net.UDPAddr, tcpPort uint16) (*Node, error) {
+	if tab.blacklist.Banned(id, addr.IP) {
+		return nil, errBanned
+	}
 	var n *Node
-	if n = tab.db.get(id); n == nil {
+	if n = tab.db.get(id); n == nil || !tab.db.bonded(id, addr.IP) {
 		tab.bondmu.Lock()
 		w := tab.bonding[id]
 		if w != nil {
@@ -300,6 +306,34 @@ func (tab *Table) pingreplace(new *Node, b *bucket) {
 	b.entries[0] = new
 }
 
+// Ban adds the node ID and, if it is not nil, the IP address to
+// the blacklist and removes the matching nodes from the table.
+func (tab *Table) Ban(id NodeID, ip net.IP) error {
+	if err := tab.blacklist.BanID(id); err != nil {
+		return err
+	}
+	if ip != nil {
+		if err := tab.blacklist.BanIP(ip); err != nil {
+			return err
+		}
+	}
+	tab.mutex.Lock()
+	defer tab.mutex.Unlock()
+	for _, b := range tab.buckets {
+		entries := b.entries[:0]
+		for _, n := range b.entries {
+			if tab.blacklist.Banned(n.ID, n.IP) {
+				tab.db.delete(n.ID)
+				continue
+			}
+			entries = append(entries, n)
+		}
+		b.entries = entries
+	}
+	tab.db.delete(id)
+	return nil
+}
+
 // add puts the entries into the table if their corresponding
 // bucket is not full. The caller must hold tab.mutex.
 func (tab *Table) add(entries []*Node) {
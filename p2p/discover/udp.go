@@ -27,6 +27,7 @@ var (
 	errUnknownNode      = errors.New("unknown node")
 	errTimeout          = errors.New("RPC timeout")
 	errClosed           = errors.New("socket closed")
+	errBanned           = errors.New("banned node")
 )
 
 // Timeouts
@@ -144,7 +145,9 @@ type reply struct {
 }
 
 // ListenUDP returns a new table that listens for UDP packets on laddr.
-func ListenUDP(priv *ecdsa.PrivateKey, laddr string, natm nat.Interface) (*Table, error) {
+// Packets from nodes in the blacklist are dropped. If blacklist is
+// nil, an empty in-memory list is used.
+func ListenUDP(priv *ecdsa.PrivateKey, laddr string, natm nat.Interface, blacklist *Blacklist) (*Table, error) {
 	addr, err := net.ResolveUDPAddr("udp", laddr)
 	if err != nil {
 		return nil, err
@@ -153,12 +156,12 @@ func ListenUDP(priv *ecdsa.PrivateKey, laddr string, natm nat.Interface) (*Table
 	if err != nil {
 		return nil, err
 	}
-	tab, _ := newUDP(priv, conn, natm)
+	tab, _ := newUDP(priv, conn, natm, blacklist)
 	glog.V(logger.Info).Infoln("Listening,", tab.self)
 	return tab, nil
 }
 
-func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface) (*Table, *udp) {
+func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface, blacklist *Blacklist) (*Table, *udp) {
 	udp := &udp{
 		conn:       c,
 		priv:       priv,
@@ -177,6 +180,9 @@ func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface) (*Table, *udp) {
 		}
 	}
 	udp.Table = newTable(udp, PubkeyID(&priv.PublicKey), realaddr)
+	if blacklist != nil {
+		udp.Table.blacklist = blacklist
+	}
 	go udp.loop()
 	go udp.readLoop()
 	return udp.Table, udp
@@ -189,15 +195,23 @@ func (t *udp) close() {
 }
 
 // ping sends a ping message to the given node and waits for a reply.
+// Only a pong carrying the hash of the ping is accepted as reply, which
+// proves that the node really received the ping at toaddr.
 func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) error {
-	// TODO: maybe check for ReplyTo field in callback to measure RTT
-	errc := t.pending(toid, pongPacket, func(interface{}) bool { return true })
-	t.send(toaddr, pingPacket, ping{
+	packet, err := encodePacket(t.priv, pingPacket, ping{
 		Version:    Version,
 		IP:         t.self.IP.String(),
 		Port:       uint16(t.self.TCPPort),
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
 	})
+	if err != nil {
+		return err
+	}
+	hash := packet[:macSize]
+	errc := t.pending(toid, pongPacket, func(p interface{}) bool {
+		return bytes.Equal(p.(*pong).ReplyTok, hash)
+	})
+	t.write(toaddr, "ping", packet)
 	return <-errc
 }
 
@@ -214,7 +228,7 @@ func (t *udp) findnode(toid NodeID, toaddr *net.UDPAddr, target NodeID) ([]*Node
 		reply := r.(*neighbors)
 		for _, n := range reply.Nodes {
 			nreceived++
-			if n.isValid() {
+			if n.isValid() && !t.blacklist.Banned(n.ID, n.IP) {
 				nodes = append(nodes, n)
 			}
 		}
@@ -336,8 +350,13 @@ func (t *udp) send(toaddr *net.UDPAddr, ptype byte, req interface{}) error {
 	if err != nil {
 		return err
 	}
-	glog.V(logger.Detail).Infof(">>> %v %T\n", toaddr, req)
-	if _, err = t.conn.WriteToUDP(packet, toaddr); err != nil {
+	return t.write(toaddr, fmt.Sprintf("%T", req), packet)
+}
+
+func (t *udp) write(toaddr *net.UDPAddr, what string, packet []byte) error {
+	glog.V(logger.Detail).Infof(">>> %v %s\n", toaddr, what)
+	_, err := t.conn.WriteToUDP(packet, toaddr)
+	if err != nil {
 		glog.V(logger.Detail).Infoln("UDP send failed:", err)
 	}
 	return err
@@ -374,19 +393,28 @@ func (t *udp) readLoop() {
 		if err != nil {
 			return
 		}
-		packet, fromID, hash, err := decodePacket(buf[:nbytes])
-		if err != nil {
-			glog.V(logger.Debug).Infof("Bad packet from %v: %v\n", from, err)
-			continue
-		}
-		status := "ok"
-		if err := packet.handle(t, from, fromID, hash); err != nil {
-			status = err.Error()
-		}
-		glog.V(logger.Detail).Infof("<<< %v %T: %s\n", from, packet, status)
+		t.handlePacket(from, buf[:nbytes])
 	}
 }
 
+func (t *udp) handlePacket(from *net.UDPAddr, buf []byte) error {
+	packet, fromID, hash, err := decodePacket(buf)
+	if err != nil {
+		glog.V(logger.Debug).Infof("Bad packet from %v: %v\n", from, err)
+		return err
+	}
+	if t.blacklist.Banned(fromID, from.IP) {
+		glog.V(logger.Detail).Infof("<<< %v %T: dropped, %v\n", from, packet, errBanned)
+		return errBanned
+	}
+	status := "ok"
+	if err = packet.handle(t, from, fromID, hash); err != nil {
+		status = err.Error()
+	}
+	glog.V(logger.Detail).Infof("<<< %v %T: %s\n", from, packet, status)
+	return err
+}
+
 func decodePacket(buf []byte) (packet, NodeID, []byte, error) {
 	if len(buf) < headSize+1 {
 		return nil, NodeID{}, nil, errPacketTooSmall
@@ -449,14 +477,15 @@ func (req *findnode) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte
 	if expired(req.Expiration) {
 		return errExpired
 	}
-	if t.db.get(fromID) == nil {
-		// No bond exists, we don't process the packet. This prevents
-		// an attack vector where the discovery protocol could be used
-		// to amplify traffic in a DDOS attack. A malicious actor
-		// would send a findnode request with the IP address and UDP
-		// port of the target as the source address. The recipient of
-		// the findnode packet would then send a neighbors packet
-		// (which is a much bigger packet than findnode) to the victim.
+	if !t.db.bonded(fromID, from.IP) {
+		// No bond exists from this address, we don't process the
+		// packet. This prevents an attack vector where the discovery
+		// protocol could be used to amplify traffic in a DDOS attack.
+		// A malicious actor would send a findnode request with the IP
+		// address and UDP port of the target as the source address.
+		// The recipient of the findnode packet would then send a
+		// neighbors packet (which is a much bigger packet than
+		// findnode) to the victim.
 		return errUnknownNode
 	}
 	t.mutex.Lock()
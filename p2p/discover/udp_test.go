@@ -41,7 +41,7 @@ func newUDPTest(t *testing.T) *udpTest {
 		remotekey:  newkey(),
 		remoteaddr: &net.UDPAddr{IP: net.IP{1, 2, 3, 4}, Port: 30303},
 	}
-	test.table, test.udp = newUDP(test.localkey, test.pipe, nil)
+	test.table, test.udp = newUDP(test.localkey, test.pipe, nil, nil)
 	return test
 }
 
@@ -59,21 +59,21 @@ func (test *udpTest) packetIn(wantError error, ptype byte, data packet) error {
 	return nil
 }
 
-// waits for a packet to be sent by the transport.
+// waits for a packet to be sent by the transport and returns its hash.
 // validate should have type func(*udpTest, X) error, where X is a packet type.
-func (test *udpTest) waitPacketOut(validate interface{}) error {
+func (test *udpTest) waitPacketOut(validate interface{}) ([]byte, error) {
 	dgram := test.pipe.waitPacketOut()
-	p, _, _, err := decodePacket(dgram)
+	p, _, hash, err := decodePacket(dgram)
 	if err != nil {
-		return test.errorf("sent packet decode error: %v", err)
+		return hash, test.errorf("sent packet decode error: %v", err)
 	}
 	fn := reflect.ValueOf(validate)
 	exptype := fn.Type().In(0)
 	if reflect.TypeOf(p) != exptype {
-		return test.errorf("sent packet type mismatch, got: %v, want: %v", reflect.TypeOf(p), exptype)
+		return hash, test.errorf("sent packet type mismatch, got: %v, want: %v", reflect.TypeOf(p), exptype)
 	}
 	fn.Call([]reflect.Value{reflect.ValueOf(p)})
-	return nil
+	return hash, nil
 }
 
 func (test *udpTest) errorf(format string, args ...interface{}) error {
@@ -240,8 +240,8 @@ func TestUDP_successfulPing(t *testing.T) {
 	})
 
 	// remote is unknown, the table pings back.
-	test.waitPacketOut(func(p *ping) error { return nil })
-	test.packetIn(nil, pongPacket, &pong{Expiration: futureExp})
+	pinghash, _ := test.waitPacketOut(func(p *ping) error { return nil })
+	test.packetIn(nil, pongPacket, &pong{ReplyTok: pinghash, Expiration: futureExp})
 
 	// ping should return shortly after getting the pong packet.
 	<-done
@@ -263,6 +263,55 @@ func TestUDP_successfulPing(t *testing.T) {
 	}
 }
 
+func TestUDP_pingWrongReplyTok(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- test.udp.ping(PubkeyID(&test.remotekey.PublicKey), test.remoteaddr)
+	}()
+	test.waitPacketOut(func(p *ping) error { return nil })
+
+	// a pong which doesn't carry the ping hash is no endpoint proof.
+	test.packetIn(nil, pongPacket, &pong{ReplyTok: []byte{1, 2, 3}, Expiration: futureExp})
+	if err := <-errc; err != errTimeout {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestUDP_findnodeBondedFromOtherIP(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	// the bond was made from another address, findnode must be
+	// refused to avoid reflecting neighbors to a spoofed source.
+	otheraddr := &net.UDPAddr{IP: net.IP{5, 6, 7, 8}, Port: 30303}
+	test.table.db.add(PubkeyID(&test.remotekey.PublicKey), otheraddr, 99)
+	test.packetIn(errUnknownNode, findnodePacket, &findnode{Target: testTarget, Expiration: futureExp})
+}
+
+func TestUDP_banned(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	rid := PubkeyID(&test.remotekey.PublicKey)
+	test.table.db.add(rid, test.remoteaddr, 99)
+	if err := test.table.Ban(rid, nil); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := encodePacket(test.remotekey, findnodePacket, &findnode{Target: testTarget, Expiration: futureExp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.udp.handlePacket(test.remoteaddr, enc); err != errBanned {
+		t.Errorf("expected banned error, got %v", err)
+	}
+	if _, err := test.table.bond(true, rid, test.remoteaddr, 99); err != errBanned {
+		t.Errorf("expected banned error from bond, got %v", err)
+	}
+}
+
 func find(tab *Table, id NodeID) *Node {
 	for _, b := range tab.buckets {
 		for _, e := range b.entries {
@@ -0,0 +1,18 @@
+// +build gofuzz
+
+package p2p
+
+// Fuzz is the go-fuzz entry point for the message framing layer. The
+// input is treated as decrypted frame content whose payload is decoded
+// generically.
+func Fuzz(data []byte) int {
+	msg, err := decodeFrame(data)
+	if err != nil {
+		return 0
+	}
+	var payload interface{}
+	if err := msg.Decode(&payload); err != nil {
+		return 0
+	}
+	return 1
+}
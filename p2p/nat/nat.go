@@ -40,6 +40,7 @@ type Interface interface {
 //
 //     "" or "none"         return nil
 //     "extip:77.12.33.4"   will assume the local machine is reachable on the given IP
+//     "extip:[2001:db8::1]" same for an IPv6 address, the brackets are optional
 //     "any"                uses the first auto-detected mechanism
 //     "upnp"               uses the Universal Plug and Play protocol
 //     "pmp"                uses NAT-PMP with an auto-detected gateway address
@@ -51,7 +52,7 @@ func Parse(spec string) (Interface, error) {
 		ip    net.IP
 	)
 	if len(parts) > 1 {
-		ip = net.ParseIP(parts[1])
+		ip = net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(parts[1], "["), "]"))
 		if ip == nil {
 			return nil, errors.New("invalid IP address")
 		}
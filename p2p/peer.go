@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"runtime"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -41,6 +44,8 @@ type Peer struct {
 	conn    net.Conn
 	rw      *conn
 	running map[string]*protoRW
+	inbound bool
+	events  *event.TypeMux // receives PeerErrorEvent, may be nil
 
 	wg       sync.WaitGroup
 	protoErr chan error
@@ -68,6 +73,12 @@ func (p *Peer) Name() string {
 	return p.rw.Name
 }
 
+// Client returns the client software and version that the
+// remote node advertised in its name.
+func (p *Peer) Client() ClientInfo {
+	return parseClientName(p.rw.Name)
+}
+
 // Caps returns the capabilities (supported subprotocols) of the remote peer.
 func (p *Peer) Caps() []Cap {
 	// TODO: maybe return copy
@@ -84,6 +95,22 @@ func (p *Peer) LocalAddr() net.Addr {
 	return p.conn.LocalAddr()
 }
 
+// ProtocolStats returns the message and payload byte counts of every
+// subprotocol running on the peer, keyed by protocol name.
+func (p *Peer) ProtocolStats() map[string]ProtocolStats {
+	stats := make(map[string]ProtocolStats, len(p.running))
+	for name, proto := range p.running {
+		stats[name] = proto.stats()
+	}
+	return stats
+}
+
+// Inbound reports whether the remote node connected to us, as
+// opposed to being dialed by us.
+func (p *Peer) Inbound() bool {
+	return p.inbound
+}
+
 // Disconnect terminates the peer connection with the given reason.
 // It returns immediately and does not wait until the connection is closed.
 func (p *Peer) Disconnect(reason DiscReason) {
@@ -205,6 +232,8 @@ func (p *Peer) handle(msg Msg) error {
 		if err != nil {
 			return fmt.Errorf("msg code out of range: %v", msg.Code)
 		}
+		atomic.AddUint64(&proto.inMsgs, 1)
+		atomic.AddUint64(&proto.inBytes, uint64(msg.Size))
 		select {
 		case proto.in <- msg:
 			return nil
@@ -216,6 +245,8 @@ func (p *Peer) handle(msg Msg) error {
 }
 
 // matchProtocols creates structures for matching named subprotocols.
+// If both sides support several versions of a protocol, the highest
+// shared version is used.
 func matchProtocols(protocols []Protocol, caps []Cap, rw MsgReadWriter) map[string]*protoRW {
 	sort.Sort(capsByName(caps))
 	offset := baseProtocolLength
@@ -223,7 +254,13 @@ func matchProtocols(protocols []Protocol, caps []Cap, rw MsgReadWriter) map[stri
 outer:
 	for _, cap := range caps {
 		for _, proto := range protocols {
-			if proto.Name == cap.Name && proto.Version == cap.Version && result[cap.Name] == nil {
+			if proto.Name == cap.Name && proto.Version == cap.Version {
+				// caps are sorted by version, so a previous match of
+				// the same protocol has a lower version. It was the
+				// last one added, drop it and reuse its offset.
+				if old := result[cap.Name]; old != nil {
+					offset -= old.Length
+				}
 				result[cap.Name] = &protoRW{Protocol: proto, offset: offset, in: make(chan Msg), w: rw}
 				offset += proto.Length
 				continue outer
@@ -240,12 +277,15 @@ func (p *Peer) startProtocols() {
 		proto.closed = p.closed
 		p.DebugDetailf("Starting protocol %s/%d\n", proto.Name, proto.Version)
 		go func() {
-			err := proto.Run(p, proto)
+			err := p.runProtocol(proto)
 			if err == nil {
 				p.DebugDetailf("Protocol %s/%d returned\n", proto.Name, proto.Version)
 				err = errors.New("protocol returned")
 			} else {
 				p.DebugDetailf("Protocol %s/%d error: %v\n", proto.Name, proto.Version, err)
+				if p.events != nil {
+					p.events.Post(PeerErrorEvent{ID: p.ID(), Protocol: proto.Name, Err: err})
+				}
 			}
 			p.protoErr <- err
 			p.wg.Done()
@@ -253,6 +293,21 @@ func (p *Peer) startProtocols() {
 	}
 }
 
+// runProtocol runs the protocol's handler. A panic in the handler is
+// logged and returned as an error so that it only disconnects the
+// peer instead of crashing the process.
+func (p *Peer) runProtocol(proto *protoRW) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			buf := make([]byte, 4096)
+			buf = buf[:runtime.Stack(buf, false)]
+			p.Errorf("Protocol %s/%d panic: %v\n%s", proto.Name, proto.Version, r, buf)
+			err = fmt.Errorf("protocol %s/%d panic: %v", proto.Name, proto.Version, r)
+		}
+	}()
+	return proto.Run(p, proto)
+}
+
 // getProto finds the protocol responsible for handling
 // the given message code.
 func (p *Peer) getProto(code uint64) (*protoRW, error) {
@@ -274,11 +329,24 @@ func (p *Peer) writeProtoMsg(protoName string, msg Msg) error {
 	if msg.Code >= proto.Length {
 		return newPeerError(errInvalidMsgCode, "code %x is out of range for protocol %q", msg.Code, protoName)
 	}
+	proto.countOut(msg)
 	msg.Code += proto.offset
 	return p.rw.WriteMsg(msg)
 }
 
+// ProtocolStats counts the messages and payload bytes exchanged by a
+// subprotocol with a peer. Message headers and framing are not included.
+type ProtocolStats struct {
+	InMessages  uint64
+	InBytes     uint64
+	OutMessages uint64
+	OutBytes    uint64
+}
+
 type protoRW struct {
+	// traffic counters, accessed atomically and kept first for alignment
+	inMsgs, inBytes, outMsgs, outBytes uint64
+
 	Protocol
 	in     chan Msg
 	closed <-chan struct{}
@@ -290,10 +358,25 @@ func (rw *protoRW) WriteMsg(msg Msg) error {
 	if msg.Code >= rw.Length {
 		return newPeerError(errInvalidMsgCode, "not handled")
 	}
+	rw.countOut(msg)
 	msg.Code += rw.offset
 	return rw.w.WriteMsg(msg)
 }
 
+func (rw *protoRW) countOut(msg Msg) {
+	atomic.AddUint64(&rw.outMsgs, 1)
+	atomic.AddUint64(&rw.outBytes, uint64(msg.Size))
+}
+
+func (rw *protoRW) stats() ProtocolStats {
+	return ProtocolStats{
+		InMessages:  atomic.LoadUint64(&rw.inMsgs),
+		InBytes:     atomic.LoadUint64(&rw.inBytes),
+		OutMessages: atomic.LoadUint64(&rw.outMsgs),
+		OutBytes:    atomic.LoadUint64(&rw.outBytes),
+	}
+}
+
 func (rw *protoRW) ReadMsg() (Msg, error) {
 	select {
 	case msg := <-rw.in:
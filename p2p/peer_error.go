@@ -2,6 +2,8 @@ package p2p
 
 import (
 	"fmt"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
 )
 
 const (
@@ -36,6 +38,15 @@ var errorToString = map[int]string{
 	errInvalidProtocolVersion: "invalid protocol version",
 }
 
+// PeerErrorEvent is posted on Server.EventMux when a protocol
+// handler of a peer returns an error or panics. The peer is
+// disconnected afterwards.
+type PeerErrorEvent struct {
+	ID       discover.NodeID
+	Protocol string
+	Err      error
+}
+
 type peerError struct {
 	Code    int
 	message string
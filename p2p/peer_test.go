@@ -9,6 +9,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/event"
 )
 
 var discard = Protocol{
@@ -111,6 +113,50 @@ func TestPeerProtoEncodeMsg(t *testing.T) {
 	}
 }
 
+func TestPeerProtocolStats(t *testing.T) {
+	defer testlog(t).detach()
+
+	done := make(chan struct{})
+	proto := Protocol{
+		Name:   "a",
+		Length: 5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			for i := 0; i < 2; i++ {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				msg.Discard()
+			}
+			if err := SendItems(rw, 1, "foo", "bar"); err != nil {
+				t.Errorf("write error: %v", err)
+			}
+			close(done)
+			return nil
+		},
+	}
+	closer, rw, peer, errc := testPeer([]Protocol{proto})
+	defer closer()
+
+	Send(rw, baseProtocolLength+2, []uint{1})
+	Send(rw, baseProtocolLength+3, "hello")
+	if err := ExpectMsg(rw, baseProtocolLength+1, []string{"foo", "bar"}); err != nil {
+		t.Error(err)
+	}
+	select {
+	case <-done:
+	case err := <-errc:
+		t.Fatalf("peer returned: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("receive timeout")
+	}
+	// [1] is 2 bytes, "hello" 6 bytes and ["foo", "bar"] 9 bytes of RLP
+	want := ProtocolStats{InMessages: 2, InBytes: 8, OutMessages: 1, OutBytes: 9}
+	if stats := peer.ProtocolStats()["a"]; stats != want {
+		t.Errorf("stats mismatch: have %+v, want %+v", stats, want)
+	}
+}
+
 func TestPeerWriteForBroadcast(t *testing.T) {
 	defer testlog(t).detach()
 
@@ -151,6 +197,51 @@ func TestPeerWriteForBroadcast(t *testing.T) {
 	}
 }
 
+func TestPeerProtocolPanic(t *testing.T) {
+	defer testlog(t).detach()
+
+	proto := Protocol{
+		Name:   "a",
+		Length: 5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			panic("boom")
+		},
+	}
+	mux := new(event.TypeMux)
+	sub := mux.Subscribe(PeerErrorEvent{})
+	defer sub.Unsubscribe()
+
+	fd1, _ := net.Pipe()
+	hs := &protoHandshake{ID: randomID(), Version: baseProtocolVersion, Caps: []Cap{proto.cap()}}
+	p1, p2 := MsgPipe()
+	defer p1.Close()
+	go func() {
+		// drain the disconnect message, then close the pipe
+		// to terminate the read loop.
+		if msg, err := p2.ReadMsg(); err == nil {
+			msg.Discard()
+		}
+		p2.Close()
+	}()
+	peer := newPeer(fd1, &conn{p1, hs}, []Protocol{proto})
+	peer.events = mux
+	disc := make(chan DiscReason, 1)
+	go func() { disc <- peer.run() }()
+
+	select {
+	case ev := <-sub.Chan():
+		perr := ev.(PeerErrorEvent)
+		if perr.ID != hs.ID || perr.Protocol != "a" {
+			t.Errorf("event mismatch: %+v", perr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no PeerErrorEvent posted")
+	}
+	if reason := <-disc; reason != DiscSubprotocolError {
+		t.Errorf("run returned wrong reason: got %v, want %v", reason, DiscSubprotocolError)
+	}
+}
+
 func TestPeerPing(t *testing.T) {
 	defer testlog(t).detach()
 
@@ -249,3 +340,28 @@ func TestNewPeer(t *testing.T) {
 
 	p.Disconnect(DiscAlreadyConnected) // Should not hang
 }
+
+func TestMatchProtocolsVersions(t *testing.T) {
+	protos := []Protocol{
+		{Name: "a", Version: 1, Length: 4},
+		{Name: "a", Version: 2, Length: 6},
+		{Name: "a", Version: 3, Length: 8},
+		{Name: "b", Version: 1, Length: 2},
+	}
+	// the remote side supports a/1 and a/2 but not a/3.
+	caps := []Cap{{"b", 1}, {"a", 2}, {"a", 1}}
+	result := matchProtocols(protos, caps, nil)
+
+	if len(result) != 2 {
+		t.Fatalf("wrong number of matched protocols: got %d, want 2", len(result))
+	}
+	if v := result["a"].Version; v != 2 {
+		t.Errorf("wrong version of a: got %d, want 2", v)
+	}
+	if off := result["a"].offset; off != baseProtocolLength {
+		t.Errorf("wrong offset of a: got %d, want %d", off, baseProtocolLength)
+	}
+	if off := result["b"].offset; off != baseProtocolLength+6 {
+		t.Errorf("wrong offset of b: got %d, want %d", off, baseProtocolLength+6)
+	}
+}
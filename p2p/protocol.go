@@ -45,6 +45,8 @@ func (cap Cap) String() string {
 
 type capsByName []Cap
 
-func (cs capsByName) Len() int           { return len(cs) }
-func (cs capsByName) Less(i, j int) bool { return cs[i].Name < cs[j].Name }
-func (cs capsByName) Swap(i, j int)      { cs[i], cs[j] = cs[j], cs[i] }
+func (cs capsByName) Len() int { return len(cs) }
+func (cs capsByName) Less(i, j int) bool {
+	return cs[i].Name < cs[j].Name || (cs[i].Name == cs[j].Name && cs[i].Version < cs[j].Version)
+}
+func (cs capsByName) Swap(i, j int) { cs[i], cs[j] = cs[j], cs[i] }
@@ -140,14 +140,18 @@ func (rw *rlpxFrameRW) ReadMsg() (msg Msg, err error) {
 
 	// decrypt frame content
 	rw.dec.XORKeyStream(framebuf, framebuf)
+	return decodeFrame(framebuf[:fsize])
+}
 
-	// decode message code
-	content := bytes.NewReader(framebuf[:fsize])
-	if err := rlp.Decode(content, &msg.Code); err != nil {
+// decodeFrame splits decrypted frame content into the message code
+// and payload.
+func decodeFrame(content []byte) (msg Msg, err error) {
+	r := bytes.NewReader(content)
+	if err := rlp.Decode(r, &msg.Code); err != nil {
 		return msg, err
 	}
-	msg.Size = uint32(content.Len())
-	msg.Payload = content
+	msg.Size = uint32(r.Len())
+	msg.Payload = r
 	return msg, nil
 }
 
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/p2p/discover"
@@ -21,6 +22,14 @@ const (
 	defaultDialTimeout   = 10 * time.Second
 	refreshPeersInterval = 30 * time.Second
 
+	// Interval at which the host names of BootstrapDNSNodes
+	// are resolved again.
+	bootnodeResolveInterval = 30 * time.Minute
+
+	// The default value of Server.DialRatio: inbound connections
+	// can't take the last fifth of the peer slots.
+	defaultDialRatio = 5
+
 	// This is the maximum number of inbound connection
 	// that are allowed to linger between 'accepted' and
 	// 'added as peer'.
@@ -51,6 +60,13 @@ type Server struct {
 	// connected. It must be greater than zero.
 	MaxPeers int
 
+	// DialRatio limits the share of inbound connections: they
+	// can't take the last MaxPeers/DialRatio slots, so that they
+	// don't crowd out our own dials. Dialed connections may take
+	// all MaxPeers slots. If zero, a ratio of 5 is used. If NoDial
+	// is set, all slots are available to inbound connections.
+	DialRatio int
+
 	// Name sets the node name of this server.
 	// Use common.MakeName to create a name that follows existing conventions.
 	Name string
@@ -59,6 +75,11 @@ type Server struct {
 	// with the rest of the network.
 	BootstrapNodes []*discover.Node
 
+	// BootstrapDNSNodes are bootstrap nodes given by host name.
+	// The names are resolved when the server starts and again
+	// every 30 minutes, so the nodes can change their address.
+	BootstrapDNSNodes []*discover.DNSNode
+
 	// Protocols should contain the protocols supported
 	// by the server. Matching protocols are launched for
 	// each peer.
@@ -70,6 +91,10 @@ type Server struct {
 	// If the port is zero, the operating system will pick a port. The
 	// ListenAddr field will be updated with the actual address when
 	// the server is started.
+	//
+	// If the host is empty, e.g. ":30303", the TCP listener and the
+	// discovery socket accept both IPv4 and IPv6 traffic. IPv6 hosts
+	// must be given in brackets, e.g. "[::1]:30303".
 	ListenAddr string
 
 	// If set to a non-nil value, the given NAT port mapper
@@ -84,6 +109,15 @@ type Server struct {
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool
 
+	// Blacklist holds the banned node IDs and IP addresses. Banned
+	// nodes are ignored by discovery and can't connect. If nil, an
+	// empty in-memory list is created when the server starts.
+	Blacklist *discover.Blacklist
+
+	// If EventMux is set, a PeerErrorEvent is posted on it whenever
+	// a protocol handler fails or panics.
+	EventMux *event.TypeMux
+
 	// Hooks for testing. These are useful because we can inhibit
 	// the whole protocol stack.
 	setupFunc
@@ -91,9 +125,10 @@ type Server struct {
 
 	ourHandshake *protoHandshake
 
-	lock    sync.RWMutex // protects running and peers
+	lock    sync.RWMutex // protects running, peers and inbound
 	running bool
 	peers   map[discover.NodeID]*Peer
+	inbound int // number of peers which connected to us
 
 	ntab     *discover.Table
 	listener net.Listener
@@ -189,15 +224,22 @@ func (srv *Server) Start() (err error) {
 	if srv.MaxPeers <= 0 {
 		return fmt.Errorf("Server.MaxPeers must be > 0")
 	}
+	if srv.DialRatio < 0 {
+		return fmt.Errorf("Server.DialRatio must be >= 0")
+	}
 	srv.quit = make(chan struct{})
 	srv.peers = make(map[discover.NodeID]*Peer)
+	srv.inbound = 0
 	srv.peerConnect = make(chan *discover.Node)
 	if srv.setupFunc == nil {
 		srv.setupFunc = setupConn
 	}
 
 	// node table
-	ntab, err := discover.ListenUDP(srv.PrivateKey, srv.ListenAddr, srv.NAT)
+	if srv.Blacklist == nil {
+		srv.Blacklist = discover.NewBlacklist()
+	}
+	ntab, err := discover.ListenUDP(srv.PrivateKey, srv.ListenAddr, srv.NAT, srv.Blacklist)
 	if err != nil {
 		return err
 	}
@@ -227,6 +269,7 @@ func (srv *Server) Start() (err error) {
 	}
 
 	srv.running = true
+	glog.V(logger.Info).Infoln("Node URL:", srv.Self())
 	return nil
 }
 
@@ -281,9 +324,43 @@ func (srv *Server) Stop() {
 	srv.peerWG.Wait()
 }
 
+// BanPeer adds the node ID and, if it is not nil, the IP address to the
+// blacklist and disconnects the peers matching them.
+func (srv *Server) BanPeer(id discover.NodeID, ip net.IP) error {
+	srv.lock.RLock()
+	defer srv.lock.RUnlock()
+	if !srv.running {
+		return errors.New("server not running")
+	}
+	if err := srv.ntab.Ban(id, ip); err != nil {
+		return err
+	}
+	for _, p := range srv.peers {
+		if srv.Blacklist.Banned(p.ID(), remoteIP(p.conn)) {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+	return nil
+}
+
+// remoteIP returns the IP address of a TCP connection's remote end,
+// or nil for other connections.
+func remoteIP(fd net.Conn) net.IP {
+	if addr, ok := fd.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
 // Self returns the local node's endpoint information.
+// The TCP port is the one the server accepts connections on,
+// which can differ from the discovery port.
 func (srv *Server) Self() *discover.Node {
-	return srv.ntab.Self()
+	self := *srv.ntab.Self()
+	if srv.listener != nil {
+		self.TCPPort = srv.listener.Addr().(*net.TCPAddr).Port
+	}
+	return &self
 }
 
 // main loop for adding connections via listening
@@ -331,9 +408,9 @@ func (srv *Server) dialLoop() {
 		// of work and we'd rather avoid doing that work for peers
 		// that can't be added.
 		srv.lock.RLock()
-		ok, _ := srv.checkPeer(dest.ID)
+		ok, _ := srv.checkPeer(dest.ID, false)
 		srv.lock.RUnlock()
-		if !ok || dialing[dest.ID] {
+		if !ok || dialing[dest.ID] || srv.Blacklist.Banned(dest.ID, dest.IP) {
 			return
 		}
 
@@ -345,9 +422,17 @@ func (srv *Server) dialLoop() {
 		}()
 	}
 
-	srv.ntab.Bootstrap(srv.BootstrapNodes)
+	srv.ntab.Bootstrap(srv.bootstrapNodes())
+	var resolve <-chan time.Time
+	if len(srv.BootstrapDNSNodes) > 0 {
+		ticker := time.NewTicker(bootnodeResolveInterval)
+		defer ticker.Stop()
+		resolve = ticker.C
+	}
 	for {
 		select {
+		case <-resolve:
+			go srv.ntab.Bootstrap(srv.bootstrapNodes())
 		case <-refresh.C:
 			// Grab some nodes to connect to if we're not at capacity.
 			srv.lock.RLock()
@@ -360,8 +445,8 @@ func (srv *Server) dialLoop() {
 					findresults <- srv.ntab.Lookup(target)
 				}()
 			} else {
-				// Make sure we check again if the peer count falls
-				// below MaxPeers.
+				// Make sure we check again if the peer count
+				// falls below the limit.
 				refresh.Reset(refreshPeersInterval)
 			}
 		case dest := <-srv.peerConnect:
@@ -384,6 +469,21 @@ func (srv *Server) dialLoop() {
 	}
 }
 
+// bootstrapNodes returns BootstrapNodes and the current
+// addresses of BootstrapDNSNodes.
+func (srv *Server) bootstrapNodes() []*discover.Node {
+	nodes := append([]*discover.Node{}, srv.BootstrapNodes...)
+	for _, dn := range srv.BootstrapDNSNodes {
+		resolved, err := dn.Resolve()
+		if err != nil {
+			glog.V(logger.Warn).Infof("Can't resolve bootstrap node %v: %v\n", dn, err)
+			continue
+		}
+		nodes = append(nodes, resolved...)
+	}
+	return nodes
+}
+
 func (srv *Server) dialNode(dest *discover.Node) {
 	addr := &net.TCPAddr{IP: dest.IP, Port: dest.TCPPort}
 	glog.V(logger.Debug).Infof("Dialing %v\n", dest)
@@ -406,9 +506,16 @@ func (srv *Server) startPeer(fd net.Conn, dest *discover.Node) {
 	// and run the capability exchange. Note that any early error
 	// returns during that exchange need to call peerWG.Done because
 	// the callers of startPeer added the peer to the wait group already.
+	inbound := dest == nil
+	if inbound && srv.Blacklist.BannedIP(remoteIP(fd)) {
+		fd.Close()
+		glog.V(logger.Debug).Infof("Rejected conn from banned IP %v", fd.RemoteAddr())
+		srv.peerWG.Done()
+		return
+	}
 	fd.SetDeadline(time.Now().Add(handshakeTimeout))
 	srv.lock.RLock()
-	atcap := len(srv.peers) == srv.MaxPeers
+	atcap := srv.atCap(inbound)
 	srv.lock.RUnlock()
 	conn, err := srv.setupFunc(fd, srv.PrivateKey, srv.ourHandshake, dest, atcap)
 	if err != nil {
@@ -422,6 +529,8 @@ func (srv *Server) startPeer(fd net.Conn, dest *discover.Node) {
 		conn:    fd, rtimeout: frameReadTimeout, wtimeout: frameWriteTimeout,
 	}
 	p := newPeer(fd, conn, srv.Protocols)
+	p.inbound = inbound
+	p.events = srv.EventMux
 	if ok, reason := srv.addPeer(conn.ID, p); !ok {
 		glog.V(logger.Detail).Infof("Not adding %v (%v)\n", p, reason)
 		p.politeDisconnect(reason)
@@ -450,25 +559,63 @@ func (srv *Server) runPeer(p *Peer) {
 	srvjslog.LogJson(&logger.P2PDisconnected{
 		RemoteId:       p.ID().String(),
 		NumConnections: srv.PeerCount(),
+		Reason:         discreason.String(),
 	})
 }
 
 func (srv *Server) addPeer(id discover.NodeID, p *Peer) (bool, DiscReason) {
 	srv.lock.Lock()
 	defer srv.lock.Unlock()
-	if ok, reason := srv.checkPeer(id); !ok {
+	if ok, reason := srv.checkPeer(id, p.inbound); !ok {
 		return false, reason
 	}
 	srv.peers[id] = p
+	if p.inbound {
+		srv.inbound++
+	}
 	return true, 0
 }
 
-func (srv *Server) checkPeer(id discover.NodeID) (bool, DiscReason) {
+// maxInboundConns returns the number of peer slots inbound
+// connections can take.
+func (srv *Server) maxInboundConns() int {
+	return srv.MaxPeers - srv.reservedDialConns()
+}
+
+// reservedDialConns returns the number of peer slots kept free
+// for connections we dial.
+func (srv *Server) reservedDialConns() int {
+	if srv.NoDial {
+		return 0
+	}
+	ratio := srv.DialRatio
+	if ratio == 0 {
+		ratio = defaultDialRatio
+	}
+	limit := srv.MaxPeers / ratio
+	if limit == 0 {
+		limit = 1
+	}
+	return limit
+}
+
+// atCap reports whether no more peers of the given direction
+// can be accepted. srv.lock must be held.
+func (srv *Server) atCap(inbound bool) bool {
+	if len(srv.peers) >= srv.MaxPeers {
+		return true
+	}
+	return inbound && srv.inbound >= srv.maxInboundConns()
+}
+
+func (srv *Server) checkPeer(id discover.NodeID, inbound bool) (bool, DiscReason) {
 	switch {
 	case !srv.running:
 		return false, DiscQuitting
-	case len(srv.peers) >= srv.MaxPeers:
+	case srv.atCap(inbound):
 		return false, DiscTooManyPeers
+	case srv.Blacklist.Banned(id, nil):
+		return false, DiscUselessPeer
 	case srv.peers[id] != nil:
 		return false, DiscAlreadyConnected
 	case id == srv.Self().ID:
@@ -481,6 +628,9 @@ func (srv *Server) checkPeer(id discover.NodeID) (bool, DiscReason) {
 func (srv *Server) removePeer(p *Peer) {
 	srv.lock.Lock()
 	delete(srv.peers, p.ID())
+	if p.inbound {
+		srv.inbound--
+	}
 	srv.lock.Unlock()
 	srv.peerWG.Done()
 }
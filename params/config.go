@@ -0,0 +1,157 @@
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultClockDrift is how far block timestamps may be ahead of the
+// local clock unless the chain configuration says otherwise.
+const DefaultClockDrift = 4 * time.Second
+
+// DefaultChainConfig is the chain configuration used when none is given
+// explicitly. It runs the frontier rules for every block.
+var DefaultChainConfig = &ChainConfig{}
+
+// GasRepricing schedules a gas table to become active at a block number.
+type GasRepricing struct {
+	Block *big.Int
+	Table GasTable
+}
+
+// ChainConfig is the core config which determines the protocol rules that
+// change at specific block numbers. It is the config section of a genesis
+// file.
+type ChainConfig struct {
+	// GasRepricings must be sorted by ascending block number. Blocks before
+	// the first repricing use GasTableFrontier.
+	GasRepricings []GasRepricing `json:"gasRepricings"`
+
+	// FixedDifficulty, if set, is the difficulty of every block after
+	// genesis. The difficulty does not adjust to the block time.
+	FixedDifficulty *big.Int `json:"fixedDifficulty"`
+
+	// These override the parameters of the difficulty adjustment if set.
+	// DurationLimit is the block time in seconds below which difficulty
+	// goes up.
+	DurationLimit          *big.Int `json:"durationLimit"`
+	DifficultyBoundDivisor *big.Int `json:"difficultyBoundDivisor"`
+	MinimumDifficulty      *big.Int `json:"minimumDifficulty"`
+
+	// BlockReward overrides the proof-of-work block reward if set. Zero
+	// disables rewards.
+	BlockReward *big.Int `json:"blockReward"`
+
+	// ClockDrift is the number of seconds a block timestamp may be ahead
+	// of the local clock. If zero, DefaultClockDrift is used.
+	ClockDrift uint64 `json:"clockDrift"`
+
+	// MinGasLimit overrides the lowest gas limit a block may have.
+	MinGasLimit *big.Int `json:"minGasLimit"`
+
+	// SubSecondBlocks allows blocks to have the same timestamp as their
+	// parent. Private chains need this to produce more than one block
+	// per second.
+	SubSecondBlocks bool `json:"subSecondBlocks"`
+
+	// Clique selects proof-of-authority sealing instead of proof of
+	// work if set.
+	Clique *CliqueConfig `json:"clique"`
+}
+
+// CliqueConfig is the configuration of the proof-of-authority engine.
+type CliqueConfig struct {
+	Period uint64 `json:"period"` // minimum number of seconds between blocks
+	Epoch  uint64 `json:"epoch"`  // number of blocks between checkpoints, default 30000
+
+	// Signers are the accounts allowed to seal blocks. Checkpoint
+	// blocks list them in their extra data.
+	Signers []common.Address `json:"signers"`
+}
+
+// UnmarshalJSON decodes the config, the signers are given as hex strings.
+func (c *CliqueConfig) UnmarshalJSON(data []byte) error {
+	var dec struct {
+		Period  uint64   `json:"period"`
+		Epoch   uint64   `json:"epoch"`
+		Signers []string `json:"signers"`
+	}
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	signers, err := ParseSigners(dec.Signers)
+	if err != nil {
+		return err
+	}
+	*c = CliqueConfig{Period: dec.Period, Epoch: dec.Epoch, Signers: signers}
+	return nil
+}
+
+// ParseSigners parses hex encoded signer addresses.
+func ParseSigners(hexes []string) ([]common.Address, error) {
+	signers := make([]common.Address, len(hexes))
+	for i, hex := range hexes {
+		addr := common.FromHex(hex)
+		if len(addr) != len(common.Address{}) {
+			return nil, fmt.Errorf("invalid signer address %q", hex)
+		}
+		signers[i] = common.BytesToAddress(addr)
+	}
+	return signers, nil
+}
+
+// GasTable returns the gas table active at block number num.
+func (c *ChainConfig) GasTable(num *big.Int) GasTable {
+	table := GasTableFrontier
+	if c == nil || num == nil {
+		return table
+	}
+	for _, r := range c.GasRepricings {
+		if r.Block.Cmp(num) > 0 {
+			break
+		}
+		table = r.Table
+	}
+	return table
+}
+
+// DifficultyParams returns the parameters of the difficulty adjustment,
+// i.e. the bound divisor, the duration limit and the minimum difficulty.
+func (c *ChainConfig) DifficultyParams() (divisor, duration, minimum *big.Int) {
+	divisor, duration, minimum = DifficultyBoundDivisor, DurationLimit, MinimumDifficulty
+	if c == nil {
+		return divisor, duration, minimum
+	}
+	if c.DifficultyBoundDivisor != nil {
+		divisor = c.DifficultyBoundDivisor
+	}
+	if c.DurationLimit != nil {
+		duration = c.DurationLimit
+	}
+	if c.MinimumDifficulty != nil {
+		minimum = c.MinimumDifficulty
+	}
+	return divisor, duration, minimum
+}
+
+// GasLimitBounds returns the lowest gas limit a block may have and the
+// value below which the miner does not lower the gas limit.
+func (c *ChainConfig) GasLimitBounds() (min, target *big.Int) {
+	if c == nil || c.MinGasLimit == nil {
+		return MinGasLimit, GenesisGasLimit
+	}
+	return c.MinGasLimit, c.MinGasLimit
+}
+
+// AllowedClockDrift returns how far block timestamps may be ahead of the
+// local clock.
+func (c *ChainConfig) AllowedClockDrift() time.Duration {
+	if c == nil || c.ClockDrift == 0 {
+		return DefaultClockDrift
+	}
+	return time.Duration(c.ClockDrift) * time.Second
+}
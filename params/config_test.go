@@ -0,0 +1,68 @@
+package params
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestChainConfigGasTable(t *testing.T) {
+	repriced := GasTableFrontier
+	repriced.Balance = big.NewInt(400)
+
+	config := &ChainConfig{GasRepricings: []GasRepricing{{Block: big.NewInt(10), Table: repriced}}}
+	tests := []struct {
+		num  int64
+		want *big.Int
+	}{
+		{0, GasTableFrontier.Balance},
+		{9, GasTableFrontier.Balance},
+		{10, repriced.Balance},
+		{1000, repriced.Balance},
+	}
+	for _, test := range tests {
+		if have := config.GasTable(big.NewInt(test.num)).Balance; have.Cmp(test.want) != 0 {
+			t.Errorf("block %d: balance gas mismatch: have %v, want %v", test.num, have, test.want)
+		}
+	}
+
+	var nilConfig *ChainConfig
+	if have := nilConfig.GasTable(big.NewInt(10)).Balance; have.Cmp(GasTableFrontier.Balance) != 0 {
+		t.Errorf("nil config: balance gas mismatch: have %v, want %v", have, GasTableFrontier.Balance)
+	}
+}
+
+func TestChainConfigDifficultyParams(t *testing.T) {
+	var nilConfig *ChainConfig
+	divisor, duration, minimum := nilConfig.DifficultyParams()
+	if divisor != DifficultyBoundDivisor || duration != DurationLimit || minimum != MinimumDifficulty {
+		t.Errorf("nil config: got %v %v %v, want frontier parameters", divisor, duration, minimum)
+	}
+
+	config := &ChainConfig{DurationLimit: big.NewInt(1), MinimumDifficulty: big.NewInt(1)}
+	divisor, duration, minimum = config.DifficultyParams()
+	if divisor != DifficultyBoundDivisor || duration.Int64() != 1 || minimum.Int64() != 1 {
+		t.Errorf("got %v %v %v, want %v 1 1", divisor, duration, minimum, DifficultyBoundDivisor)
+	}
+}
+
+func TestChainConfigGasLimitBounds(t *testing.T) {
+	var nilConfig *ChainConfig
+	if min, target := nilConfig.GasLimitBounds(); min != MinGasLimit || target != GenesisGasLimit {
+		t.Errorf("nil config: got %v %v, want %v %v", min, target, MinGasLimit, GenesisGasLimit)
+	}
+	config := &ChainConfig{MinGasLimit: big.NewInt(5000)}
+	if min, target := config.GasLimitBounds(); min.Int64() != 5000 || target.Int64() != 5000 {
+		t.Errorf("got %v %v, want 5000 5000", min, target)
+	}
+}
+
+func TestChainConfigAllowedClockDrift(t *testing.T) {
+	var nilConfig *ChainConfig
+	if have := nilConfig.AllowedClockDrift(); have != DefaultClockDrift {
+		t.Errorf("nil config: got %v, want %v", have, DefaultClockDrift)
+	}
+	if have := (&ChainConfig{ClockDrift: 15}).AllowedClockDrift(); have != 15*time.Second {
+		t.Errorf("got %v, want 15s", have)
+	}
+}
@@ -0,0 +1,30 @@
+package params
+
+import "math/big"
+
+// GasTable organises the gas prices of the opcodes that are subject to
+// repricing by protocol upgrades.
+type GasTable struct {
+	ExtcodeSize *big.Int
+	ExtcodeCopy *big.Int
+	Balance     *big.Int
+	SLoad       *big.Int
+	Calls       *big.Int
+	Suicide     *big.Int
+
+	// ExpByte is charged per byte of the EXP exponent
+	ExpByte *big.Int
+}
+
+var (
+	// GasTableFrontier contains the gas prices for the frontier phase.
+	GasTableFrontier = GasTable{
+		ExtcodeSize: big.NewInt(20),
+		ExtcodeCopy: big.NewInt(20),
+		Balance:     big.NewInt(20),
+		SLoad:       SloadGas,
+		Calls:       CallGas,
+		Suicide:     big.NewInt(0),
+		ExpByte:     ExpByteGas,
+	}
+)
@@ -0,0 +1,118 @@
+package pow
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Verifier verifies the proof of work of blocks using a number of
+// concurrent workers. Results are cached by (hash, nonce) so blocks
+// received several times are verified only once.
+type Verifier struct {
+	pow     PoW
+	workers int
+
+	mu    sync.Mutex
+	cache map[verifyKey]bool
+	keys  []verifyKey // insertion order, for eviction
+	size  int
+}
+
+type verifyKey struct {
+	hash  common.Hash
+	nonce uint64
+}
+
+// NewVerifier creates a verifier running up to workers verifications in
+// parallel and remembering the results of the last cacheSize blocks.
+// A cacheSize of zero disables caching.
+func NewVerifier(pow PoW, workers, cacheSize int) *Verifier {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Verifier{pow: pow, workers: workers, size: cacheSize, cache: make(map[verifyKey]bool)}
+}
+
+// Verify verifies the proof of work of a single block.
+func (self *Verifier) Verify(block Block) bool {
+	key := verifyKey{block.HashNoNonce(), block.Nonce()}
+
+	self.mu.Lock()
+	valid, ok := self.cache[key]
+	self.mu.Unlock()
+	if ok {
+		return valid
+	}
+
+	valid = self.pow.Verify(block)
+	if self.size == 0 {
+		return valid
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if _, ok := self.cache[key]; !ok {
+		if len(self.keys) >= self.size {
+			delete(self.cache, self.keys[0])
+			self.keys = append(self.keys[:0], self.keys[1:]...)
+		}
+		self.keys = append(self.keys, key)
+		self.cache[key] = valid
+	}
+	return valid
+}
+
+// VerifyAll verifies the blocks for which check is true concurrently.
+// The returned channel delivers one result per block, in the order of
+// blocks. Blocks which are not checked are reported as valid.
+//
+// Closing abort stops the verification, the channel is closed when all
+// results have been delivered or the verification was aborted.
+func (self *Verifier) VerifyAll(blocks []Block, check []bool, abort <-chan struct{}) <-chan bool {
+	var (
+		inputs  = make(chan int)
+		done    = make(chan int, len(blocks))
+		valid   = make([]bool, len(blocks))
+		results = make(chan bool, len(blocks))
+	)
+	workers := self.workers
+	if workers > len(blocks) {
+		workers = len(blocks)
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for n := range inputs {
+				valid[n] = !check[n] || self.Verify(blocks[n])
+				done <- n
+			}
+		}()
+	}
+	go func() {
+		defer close(inputs)
+		for n := range blocks {
+			select {
+			case inputs <- n:
+			case <-abort:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(results)
+		verified := make([]bool, len(blocks))
+		for next := 0; next < len(blocks); {
+			select {
+			case n := <-done:
+				verified[n] = true
+				for next < len(blocks) && verified[next] {
+					results <- valid[next]
+					next++
+				}
+			case <-abort:
+				return
+			}
+		}
+	}()
+	return results
+}
@@ -0,0 +1,100 @@
+package pow
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type testBlock struct {
+	hash  common.Hash
+	nonce uint64
+}
+
+func (b testBlock) Difficulty() *big.Int     { return common.Big1 }
+func (b testBlock) HashNoNonce() common.Hash { return b.hash }
+func (b testBlock) Nonce() uint64            { return b.nonce }
+func (b testBlock) MixDigest() common.Hash   { return common.Hash{} }
+func (b testBlock) NumberU64() uint64        { return 0 }
+
+// testPoW accepts blocks with even nonces and counts verifications.
+type testPoW struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *testPoW) Search(Block, <-chan struct{}) (uint64, []byte, []byte) { return 0, nil, nil }
+func (p *testPoW) GetHashrate() int64                                     { return 0 }
+func (p *testPoW) Turbo(bool)                                             {}
+func (p *testPoW) Verify(block Block) bool {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	return block.Nonce()%2 == 0
+}
+
+func TestVerifierCache(t *testing.T) {
+	pow := new(testPoW)
+	verifier := NewVerifier(pow, 1, 2)
+	a, b, c := testBlock{common.Hash{1}, 0}, testBlock{common.Hash{2}, 1}, testBlock{common.Hash{3}, 2}
+
+	if !verifier.Verify(a) || !verifier.Verify(a) {
+		t.Error("valid block not accepted")
+	}
+	if verifier.Verify(b) {
+		t.Error("invalid block accepted")
+	}
+	if pow.calls != 2 {
+		t.Errorf("got %d verifications, want 2", pow.calls)
+	}
+	// evicts a
+	verifier.Verify(c)
+	verifier.Verify(a)
+	if pow.calls != 4 {
+		t.Errorf("got %d verifications, want 4", pow.calls)
+	}
+}
+
+func TestVerifierVerifyAll(t *testing.T) {
+	var (
+		blocks = make([]Block, 100)
+		check  = make([]bool, 100)
+	)
+	for i := range blocks {
+		blocks[i] = testBlock{common.BigToHash(big.NewInt(int64(i))), uint64(i % 3)}
+		check[i] = i%5 != 0
+	}
+	pow := new(testPoW)
+	verifier := NewVerifier(pow, 4, 0)
+
+	i := 0
+	for valid := range verifier.VerifyAll(blocks, check, nil) {
+		if want := !check[i] || blocks[i].Nonce()%2 == 0; valid != want {
+			t.Errorf("block %d: got valid %t, want %t", i, valid, want)
+		}
+		i++
+	}
+	if i != len(blocks) {
+		t.Errorf("got %d results, want %d", i, len(blocks))
+	}
+	if pow.calls != 80 {
+		t.Errorf("got %d verifications, want 80", pow.calls)
+	}
+}
+
+func TestVerifierAbort(t *testing.T) {
+	blocks := make([]Block, 1000)
+	check := make([]bool, 1000)
+	for i := range blocks {
+		blocks[i] = testBlock{common.BigToHash(big.NewInt(int64(i))), 0}
+		check[i] = true
+	}
+	abort := make(chan struct{})
+	results := NewVerifier(new(testPoW), 4, 0).VerifyAll(blocks, check, abort)
+	<-results
+	close(abort)
+	for range results {
+	}
+}
@@ -47,8 +47,8 @@ type Decoder interface {
 // must contain an element for each decoded field. Decode returns an
 // error if there are too few or too many elements.
 //
-// The decoding of struct fields honours one particular struct tag,
-// "nil". This tag applies to pointer-typed fields and changes the
+// The decoding of struct fields honours two struct tags, "nil" and
+// "tail". The "nil" tag applies to pointer-typed fields and changes the
 // decoding rules for the field such that input values of size zero
 // decode as a nil pointer. This tag can be useful when decoding recursive
 // types.
@@ -57,6 +57,17 @@ type Decoder interface {
 //         Foo *[20]byte `rlp:"nil"`
 //     }
 //
+// The "tail" tag can only be used on the last exported struct field,
+// which must be of slice type. It allows the input list to contain more
+// elements than the struct has fields: all remaining elements are
+// decoded into the slice. This is useful for types that may gain
+// additional trailing fields in the future.
+//
+//     type StructWithTail struct {
+//         A, B uint
+//         Rest []interface{} `rlp:"tail"`
+//     }
+//
 // To decode into a slice, the input must be a list and the resulting
 // slice will contain the input elements in order. For byte slices,
 // the input must be an RLP string. Array types decode similarly, with
@@ -82,9 +93,11 @@ type Decoder interface {
 // signed integers, floating point numbers, maps, channels and
 // functions.
 //
-// Note that Decode does not set an input limit for all readers
-// and may be vulnerable to panics cause by huge value sizes. If
-// you need an input limit, use
+// Lists may be nested at most 1024 levels deep.
+//
+// Note that Decode does not set an input limit for all readers.
+// Values whose size exceeds the available input are only detected
+// after reading all of it. If you need an input limit, use
 //
 //     NewStream(r, limit).Decode(val)
 func Decode(r io.Reader, val interface{}) error {
@@ -162,7 +175,7 @@ func makeDecoder(typ reflect.Type, tags tags) (dec decoder, err error) {
 	case kind == reflect.String:
 		return decodeString, nil
 	case kind == reflect.Slice || kind == reflect.Array:
-		return makeListDecoder(typ)
+		return makeListDecoder(typ, tags)
 	case kind == reflect.Struct:
 		return makeStructDecoder(typ)
 	case kind == reflect.Ptr:
@@ -218,7 +231,7 @@ func decodeBigInt(s *Stream, val reflect.Value) error {
 	return nil
 }
 
-func makeListDecoder(typ reflect.Type) (decoder, error) {
+func makeListDecoder(typ reflect.Type, tag tags) (decoder, error) {
 	etype := typ.Elem()
 	if etype.Kind() == reflect.Uint8 && !reflect.PtrTo(etype).Implements(decoderInterface) {
 		if typ.Kind() == reflect.Array {
@@ -234,9 +247,16 @@ func makeListDecoder(typ reflect.Type) (decoder, error) {
 
 	isArray := typ.Kind() == reflect.Array
 	return func(s *Stream, val reflect.Value) error {
-		if isArray {
+		switch {
+		case isArray:
 			return decodeListArray(s, val, etypeinfo.decoder)
-		} else {
+		case tag.tail:
+			// A slice with "tail" tag can occur as the last field
+			// of a struct and is supposed to swallow all remaining
+			// list elements. The struct decoder already called s.List,
+			// proceed directly to decoding the elements.
+			return decodeSliceElems(s, val, etypeinfo.decoder)
+		default:
 			return decodeListSlice(s, val, etypeinfo.decoder)
 		}
 	}, nil
@@ -251,7 +271,15 @@ func decodeListSlice(s *Stream, val reflect.Value, elemdec decoder) error {
 		val.Set(reflect.MakeSlice(val.Type(), 0, 0))
 		return s.ListEnd()
 	}
+	if err := decodeSliceElems(s, val, elemdec); err != nil {
+		return err
+	}
+	return s.ListEnd()
+}
 
+// decodeSliceElems decodes the elements of the current list into val
+// until the end of the list is reached.
+func decodeSliceElems(s *Stream, val reflect.Value, elemdec decoder) error {
 	i := 0
 	for ; ; i++ {
 		// grow slice if necessary
@@ -277,7 +305,7 @@ func decodeListSlice(s *Stream, val reflect.Value, elemdec decoder) error {
 	if i < val.Len() {
 		val.SetLen(i)
 	}
-	return s.ListEnd()
+	return nil
 }
 
 func decodeListArray(s *Stream, val reflect.Value, elemdec decoder) error {
@@ -500,6 +528,7 @@ var (
 	ErrCanonSize      = errors.New("rlp: non-canonical size information")
 	ErrElemTooLarge   = errors.New("rlp: element is larger than containing list")
 	ErrValueTooLarge  = errors.New("rlp: value size exceeds available input length")
+	ErrTooDeep        = errors.New("rlp: lists nested too deeply")
 
 	// internal errors
 	errNotInList    = errors.New("rlp: call of ListEnd outside of any list")
@@ -545,6 +574,17 @@ type Stream struct {
 
 type listpos struct{ pos, size uint64 }
 
+const (
+	// maxDepth is the maximum nesting depth of lists accepted by Stream.
+	// It protects recursive decoders from exhausting the goroutine stack.
+	maxDepth = 1024
+
+	// maxPrealloc is the largest buffer allocated up front when reading a
+	// value from a stream without input limit. Larger values are read in
+	// chunks so a bogus size prefix cannot exhaust memory.
+	maxPrealloc = 1 << 20
+)
+
 // NewStream creates a new decoding stream reading from r.
 //
 // If r implements the ByteReader interface, Stream will
@@ -591,8 +631,8 @@ func (s *Stream) Bytes() ([]byte, error) {
 		s.kind = -1 // rearm Kind
 		return []byte{s.byteval}, nil
 	case String:
-		b := make([]byte, size)
-		if err = s.readFull(b); err != nil {
+		b, err := s.readBytes(0, size)
+		if err != nil {
 			return nil, err
 		}
 		if size == 1 && b[0] < 56 {
@@ -617,8 +657,8 @@ func (s *Stream) Raw() ([]byte, error) {
 	// the original header has already been read and is no longer
 	// available. read content and put a new header in front of it.
 	start := headsize(size)
-	buf := make([]byte, uint64(start)+size)
-	if err := s.readFull(buf[start:]); err != nil {
+	buf, err := s.readBytes(start, size)
+	if err != nil {
 		return nil, err
 	}
 	if kind == String {
@@ -680,6 +720,9 @@ func (s *Stream) List() (size uint64, err error) {
 	if kind != List {
 		return 0, ErrExpectedList
 	}
+	if len(s.stack) >= maxDepth {
+		return 0, ErrTooDeep
+	}
 	s.stack = append(s.stack, listpos{0, size})
 	s.kind = -1
 	s.size = 0
@@ -914,6 +957,30 @@ func (s *Stream) readFull(buf []byte) (err error) {
 	return err
 }
 
+// readBytes reads a value of the given size into a new buffer, leaving
+// head bytes of space in front of it.
+func (s *Stream) readBytes(head int, size uint64) ([]byte, error) {
+	if s.limited || size <= maxPrealloc {
+		buf := make([]byte, uint64(head)+size)
+		return buf, s.readFull(buf[head:])
+	}
+	// The input length is unknown. Grow the buffer as the data
+	// arrives instead of trusting size.
+	buf := make([]byte, head, head+maxPrealloc)
+	for remaining := size; remaining > 0; {
+		n := remaining
+		if n > maxPrealloc {
+			n = maxPrealloc
+		}
+		buf = append(buf, make([]byte, n)...)
+		if err := s.readFull(buf[len(buf)-int(n):]); err != nil {
+			return nil, err
+		}
+		remaining -= n
+	}
+	return buf, nil
+}
+
 func (s *Stream) readByte() (byte, error) {
 	if s.limited && s.remaining == 0 {
 		return 0, io.EOF
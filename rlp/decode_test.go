@@ -144,6 +144,8 @@ func TestStreamErrors(t *testing.T) {
 		{"81", calls{"Bytes"}, withoutInputLimit, io.ErrUnexpectedEOF},
 		{"81", calls{"Uint"}, withoutInputLimit, io.ErrUnexpectedEOF},
 		{"BFFFFFFFFFFFFFFF", calls{"Bytes"}, withoutInputLimit, io.ErrUnexpectedEOF},
+		{"BF7FFFFFFFFFFFFFFF", calls{"Bytes"}, withoutInputLimit, io.ErrUnexpectedEOF},
+		{"BF7FFFFFFFFFFFFFFF", calls{"Raw"}, withoutInputLimit, io.ErrUnexpectedEOF},
 		{"C801", calls{"List", "Uint", "Uint"}, withoutInputLimit, io.ErrUnexpectedEOF},
 
 		// This test verifies that the input position is advanced
@@ -197,6 +199,28 @@ testfor:
 	}
 }
 
+func TestStreamDeepNesting(t *testing.T) {
+	nested := func(depth int) []byte {
+		var v interface{} = []interface{}{}
+		for i := 1; i < depth; i++ {
+			v = []interface{}{v}
+		}
+		enc, err := EncodeToBytes(v)
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		return enc
+	}
+
+	var v interface{}
+	if err := DecodeBytes(nested(maxDepth), &v); err != nil {
+		t.Errorf("unexpected error at maximum depth: %v", err)
+	}
+	if err := DecodeBytes(nested(maxDepth+1), &v); err != ErrTooDeep {
+		t.Errorf("got error %v, want %v", err, ErrTooDeep)
+	}
+}
+
 func TestStreamList(t *testing.T) {
 	s := NewStream(bytes.NewReader(unhex("C80102030405060708")), 0)
 
@@ -283,6 +307,29 @@ type recstruct struct {
 	Child *recstruct `rlp:"nil"`
 }
 
+type tailstruct struct {
+	A    uint
+	Tail []uint `rlp:"tail"`
+}
+
+type invalidTail1 struct {
+	A uint `rlp:"tail"`
+	B string
+}
+
+type invalidTail2 struct {
+	A uint
+	B string `rlp:"tail"`
+}
+
+type invalidNil struct {
+	A uint `rlp:"nil"`
+}
+
+type unknownTag struct {
+	A uint `rlp:"foo"`
+}
+
 var (
 	veryBigInt = big.NewInt(0).Add(
 		big.NewInt(0).Lsh(big.NewInt(0xFFFFFFFFFFFFFF), 16),
@@ -401,6 +448,51 @@ var decodeTests = []decodeTest{
 		ptr:   new(recstruct),
 		error: "rlp: expected input string or byte for uint, decoding into (rlp.recstruct).Child.I",
 	},
+	{
+		input: "C101",
+		ptr:   new(tailstruct),
+		value: tailstruct{A: 1, Tail: []uint{}},
+	},
+	{
+		input: "C401020304",
+		ptr:   new(tailstruct),
+		value: tailstruct{A: 1, Tail: []uint{2, 3, 4}},
+	},
+	{
+		input: "C3010280",
+		ptr:   new(tailstruct),
+		value: tailstruct{A: 1, Tail: []uint{2, 0}},
+	},
+	{
+		input: "C401820001",
+		ptr:   new(tailstruct),
+		error: "rlp: non-canonical integer (leading zero bytes) for uint, decoding into (rlp.tailstruct).Tail[0]",
+	},
+	{
+		input: "C0",
+		ptr:   new(tailstruct),
+		error: "rlp: too few elements for rlp.tailstruct",
+	},
+	{
+		input: "C0",
+		ptr:   new(invalidTail1),
+		error: `rlp: invalid struct tag "tail" for rlp.invalidTail1.A (must be on last field)`,
+	},
+	{
+		input: "C0",
+		ptr:   new(invalidTail2),
+		error: `rlp: invalid struct tag "tail" for rlp.invalidTail2.B (field type is not a list slice)`,
+	},
+	{
+		input: "C0",
+		ptr:   new(invalidNil),
+		error: `rlp: invalid struct tag "nil" for rlp.invalidNil.A (field type is not a pointer)`,
+	},
+	{
+		input: "C0",
+		ptr:   new(unknownTag),
+		error: `rlp: unknown struct tag "foo" on rlp.unknownTag.A`,
+	},
 
 	// pointers
 	{input: "00", ptr: new(*[]byte), value: &[]byte{0}},
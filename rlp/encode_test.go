@@ -188,6 +188,9 @@ var encTests = []encTest{
 	{val: simplestruct{A: 3, B: "foo"}, output: "C50383666F6F"},
 	{val: &recstruct{5, nil}, output: "C205C0"},
 	{val: &recstruct{5, &recstruct{4, &recstruct{3, nil}}}, output: "C605C404C203C0"},
+	{val: &tailstruct{A: 1, Tail: nil}, output: "C101"},
+	{val: &tailstruct{A: 1, Tail: []uint{2, 3}}, output: "C3010203"},
+	{val: &invalidTail1{}, error: `rlp: invalid struct tag "tail" for rlp.invalidTail1.A (must be on last field)`},
 
 	// flat
 	{val: Flat(uint(1)), error: "rlp.Flat: uint did not encode as list"},
@@ -0,0 +1,100 @@
+package rlp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// ErrValueLimit is returned by ValueReader for values exceeding
+// the configured size limit.
+var ErrValueLimit = errors.New("rlp: value exceeds size limit")
+
+// ValueReader reads a sequence of toplevel RLP values, such as an
+// exported chain of blocks, one value at a time. Only the value being
+// decoded is held in memory, which makes ValueReader suitable for
+// inputs of arbitrary length.
+//
+// ValueReader keeps track of the input offset of the next value.
+// Reading can be resumed later by positioning a new reader at that
+// offset, e.g. by seeking the underlying file.
+//
+// ValueReader is not safe for concurrent use.
+type ValueReader struct {
+	cr    *countingReader
+	s     *Stream
+	limit uint64
+	pos   uint64 // input offset of the next value
+	count uint64 // number of values read
+}
+
+// NewValueReader creates a reader for the values contained in r.
+// The input offset reported by Pos starts at offset, which should be
+// the position of r in the underlying input.
+//
+// If limit is non-zero, values with an encoded size larger than limit
+// are rejected with ErrValueLimit before any of their content is read.
+func NewValueReader(r io.Reader, offset, limit uint64) *ValueReader {
+	cr := &countingReader{r: bufio.NewReader(r), n: offset}
+	return &ValueReader{
+		cr:    cr,
+		s:     NewStream(cr, 0),
+		limit: limit,
+		pos:   offset,
+	}
+}
+
+// Decode decodes the next value into val, following the rules of the
+// Decode function. It returns io.EOF if the input ends cleanly before
+// the next value. The reader should not be used anymore after Decode
+// has returned any other error, Pos and Count then describe the value
+// that could not be decoded.
+func (vr *ValueReader) Decode(val interface{}) error {
+	_, size, err := vr.s.Kind()
+	if err != nil {
+		return err
+	}
+	// Kind has consumed the type tag, the size of the whole
+	// value is the tag size plus the content size.
+	if total := vr.cr.n - vr.pos + size; vr.limit > 0 && total > vr.limit {
+		return ErrValueLimit
+	}
+	if err := vr.s.Decode(val); err != nil {
+		return err
+	}
+	vr.pos = vr.cr.n
+	vr.count++
+	return nil
+}
+
+// Pos returns the input offset of the next value.
+func (vr *ValueReader) Pos() uint64 {
+	return vr.pos
+}
+
+// Count returns the number of values decoded so far.
+func (vr *ValueReader) Count() uint64 {
+	return vr.count
+}
+
+// countingReader counts the bytes consumed from a buffered reader.
+// Since Stream only reads as much as needed when given a ByteReader,
+// the count is the exact offset of the stream in the input.
+type countingReader struct {
+	r *bufio.Reader
+	n uint64
+}
+
+func (cr *countingReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += uint64(n)
+	return n, err
+}
+
+func (cr *countingReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.n++
+	}
+	return b, err
+}
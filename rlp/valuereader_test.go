@@ -0,0 +1,66 @@
+package rlp
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestValueReader(t *testing.T) {
+	input := unhex("C20102" + "83666F6F" + "05" + "C3010203")
+	vr := NewValueReader(bytes.NewReader(input), 10, 0)
+
+	var (
+		list []uint
+		str  string
+		num  uint
+	)
+	check := func(err error, count, pos uint64) {
+		if err != nil {
+			t.Fatalf("value %d: unexpected error: %v", count, err)
+		}
+		if vr.Count() != count {
+			t.Errorf("count mismatch: got %d, want %d", vr.Count(), count)
+		}
+		if vr.Pos() != pos {
+			t.Errorf("value %d: pos mismatch: got %d, want %d", count, vr.Pos(), pos)
+		}
+	}
+	check(vr.Decode(&list), 1, 13)
+	check(vr.Decode(&str), 2, 17)
+	check(vr.Decode(&num), 3, 18)
+	check(vr.Decode(&list), 4, 22)
+	if err := vr.Decode(&num); err != io.EOF {
+		t.Fatalf("expected io.EOF at end of input, got %v", err)
+	}
+	if str != "foo" || num != 5 || len(list) != 3 {
+		t.Errorf("wrong values decoded: %q %d %v", str, num, list)
+	}
+}
+
+func TestValueReaderLimit(t *testing.T) {
+	input := unhex("C20102" + "C401020304" + "05")
+	vr := NewValueReader(bytes.NewReader(input), 0, 4)
+
+	var list []uint
+	if err := vr.Decode(&list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := vr.Decode(&list); err != ErrValueLimit {
+		t.Fatalf("expected ErrValueLimit, got %v", err)
+	}
+	if vr.Pos() != 3 || vr.Count() != 1 {
+		t.Errorf("wrong position after limit error: pos %d, count %d", vr.Pos(), vr.Count())
+	}
+}
+
+func TestValueReaderTruncated(t *testing.T) {
+	vr := NewValueReader(bytes.NewReader(unhex("05C301")), 0, 0)
+	var v interface{}
+	if err := vr.Decode(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := vr.Decode(&v); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
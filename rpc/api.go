@@ -2,11 +2,11 @@ package rpc
 
 import (
 	"encoding/json"
-	"fmt"
 	"math/big"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -16,6 +16,8 @@ import (
 type EthereumApi struct {
 	eth    *xeth.XEth
 	xethMu sync.RWMutex
+
+	readOnly bool
 }
 
 func NewEthereumApi(xeth *xeth.XEth) *EthereumApi {
@@ -26,6 +28,12 @@ func NewEthereumApi(xeth *xeth.XEth) *EthereumApi {
 	return api
 }
 
+// SetReadOnly restricts the API to the methods without side effects. It
+// must be called before requests are served.
+func (api *EthereumApi) SetReadOnly(readOnly bool) {
+	api.readOnly = readOnly
+}
+
 func (api *EthereumApi) xeth() *xeth.XEth {
 	api.xethMu.RLock()
 	defer api.xethMu.RUnlock()
@@ -41,6 +49,10 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 	// Spec at https://github.com/ethereum/wiki/wiki/JSON-RPC
 	glog.V(logger.Debug).Infof("%s %s", req.Method, req.Params)
 
+	if api.readOnly && !readOnlyMethods[req.Method] {
+		return NewNotAvailableError(req.Method)
+	}
+
 	switch req.Method {
 	case "web3_sha3":
 		args := new(Sha3Args)
@@ -62,6 +74,8 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 		*reply = newHexData(api.xeth().Coinbase())
 	case "eth_mining":
 		*reply = api.xeth().IsMining()
+	case "eth_getUpdateStatus":
+		*reply = api.xeth().UpdateStatus()
 	case "eth_gasPrice":
 		v := xeth.DefaultGas()
 		*reply = newHexData(v.Bytes())
@@ -159,6 +173,10 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 		if err := json.Unmarshal(req.Params, &args); err != nil {
 			return err
 		}
+		// don't silently fall back to the genesis state for unknown blocks
+		if api.xeth().EthBlockByNumber(args.BlockNumber) == nil {
+			return NewValidationError("blockNumber", "unknown block")
+		}
 		v := api.xethAtStateNum(args.BlockNumber).CodeAtBytes(args.Address)
 		*reply = newHexData(v)
 	case "eth_sendTransaction", "eth_transact":
@@ -170,10 +188,21 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 		// call ConfirmTransaction first
 		tx, _ := json.Marshal(req)
 		if !api.xeth().ConfirmTransaction(string(tx)) {
-			return fmt.Errorf("Transaction not confirmed")
+			return ErrNotConfirmed
 		}
 
 		v, err := api.xeth().Transact(args.From, args.To, args.Value.String(), args.Gas.String(), args.GasPrice.String(), args.Data)
+		if err != nil {
+			return NewTransactionError(err)
+		}
+		*reply = v
+	case "eth_resend":
+		args := new(ResendArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+
+		v, err := api.xeth().Resend(args.Hash, args.GasPrice, args.GasLimit)
 		if err != nil {
 			return err
 		}
@@ -186,7 +215,7 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 
 		v, err := api.xethAtStateNum(args.BlockNumber).Call(args.From, args.To, args.Value.String(), args.Gas.String(), args.GasPrice.String(), args.Data)
 		if err != nil {
-			return err
+			return NewExecutionError(err, newHexData(common.FromHex(v)))
 		}
 		// TODO unwrap the parent method's ToHex call
 		*reply = newHexData(common.FromHex(v))
@@ -225,6 +254,34 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 			v.TxIndex = newHexNum(txi)
 			*reply = v
 		}
+	case "eth_getTransactionReceipt":
+		args := new(HashArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		tx, bhash, bnum, txi := api.xeth().EthTransactionByHash(args.Hash)
+		receipt := api.xeth().EthTransactionReceipt(args.Hash)
+		if tx != nil && receipt != nil {
+			v := NewReceiptRes(tx, receipt)
+			v.BlockHash = newHexData(bhash)
+			v.BlockNumber = newHexNum(bnum)
+			v.TxIndex = newHexNum(txi)
+			*reply = v
+		}
+	case "eth_getTransactionsByAddress":
+		args := new(AddressTransactionsArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		hashes, err := api.xeth().TransactionsByAddress(args.Address, args.FromBlock, args.ToBlock)
+		if err != nil {
+			return err
+		}
+		v := make([]*hexdata, len(hashes))
+		for i, hash := range hashes {
+			v[i] = newHexData(hash)
+		}
+		*reply = v
 	case "eth_getTransactionByBlockHashAndIndex":
 		args := new(HashIndexArgs)
 		if err := json.Unmarshal(req.Params, &args); err != nil {
@@ -312,14 +369,27 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 			return err
 		}
 
-		id := api.xeth().RegisterFilter(args.Earliest, args.Latest, args.Skip, args.Max, args.Address, args.Topics)
+		id, err := api.xeth().RegisterFilter(args.Earliest, args.Latest, args.Skip, args.Max, args.Address, args.Topics)
+		if err != nil {
+			return err
+		}
 		*reply = newHexNum(big.NewInt(int64(id)).Bytes())
 	case "eth_newBlockFilter":
 		args := new(FilterStringArgs)
 		if err := json.Unmarshal(req.Params, &args); err != nil {
 			return err
 		}
-		*reply = newHexNum(api.xeth().NewFilterString(args.Word))
+		id, err := api.xeth().NewFilterString(args.Word)
+		if err != nil {
+			return err
+		}
+		*reply = newHexNum(id)
+	case "eth_newPendingTransactionFilter":
+		id, err := api.xeth().NewTransactionFilter()
+		if err != nil {
+			return err
+		}
+		*reply = newHexNum(id)
 	case "eth_uninstallFilter":
 		args := new(FilterIdArgs)
 		if err := json.Unmarshal(req.Params, &args); err != nil {
@@ -331,6 +401,14 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 		if err := json.Unmarshal(req.Params, &args); err != nil {
 			return err
 		}
+		if hashes, ok := api.xeth().TransactionFilterChanged(args.Id); ok {
+			res := make([]*hexdata, len(hashes))
+			for i, hash := range hashes {
+				res[i] = newHexData(hash)
+			}
+			*reply = res
+			break
+		}
 		*reply = NewLogsRes(api.xeth().FilterChanged(args.Id))
 	case "eth_getFilterLogs":
 		args := new(FilterIdArgs)
@@ -410,7 +488,7 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 			return err
 		}
 
-		err := api.xeth().Whisper().Post(args.Payload, args.To, args.From, args.Topics, args.Priority, args.Ttl)
+		err := api.xeth().Whisper().Post(args.Payload, args.To, args.SymKeyID, args.From, args.Topics, args.Priority, args.Ttl)
 		if err != nil {
 			return err
 		}
@@ -418,12 +496,60 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 		*reply = true
 	case "shh_newIdentity":
 		*reply = api.xeth().Whisper().NewIdentity()
-	// case "shh_removeIdentity":
-	// 	args := new(WhisperIdentityArgs)
-	// 	if err := json.Unmarshal(req.Params, &args); err != nil {
-	// 		return err
-	// 	}
-	// 	*reply = api.xeth().Whisper().RemoveIdentity(args.Identity)
+	case "shh_addIdentity":
+		args := new(WhisperKeyArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		pub, err := api.xeth().Whisper().AddIdentity(args.Key)
+		if err != nil {
+			return err
+		}
+		*reply = pub
+	case "shh_removeIdentity":
+		args := new(WhisperIdentityArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		*reply = api.xeth().Whisper().RemoveIdentity(args.Identity)
+	case "shh_newSymKey":
+		id, err := api.xeth().Whisper().NewSymKey()
+		if err != nil {
+			return err
+		}
+		*reply = id
+	case "shh_addSymKey":
+		args := new(WhisperKeyArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		id, err := api.xeth().Whisper().AddSymKey(args.Key)
+		if err != nil {
+			return err
+		}
+		*reply = id
+	case "shh_generateSymKeyFromPassword":
+		args := new(WhisperKeyArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		id, err := api.xeth().Whisper().SymKeyFromPassword(args.Key)
+		if err != nil {
+			return err
+		}
+		*reply = id
+	case "shh_hasSymKey":
+		args := new(WhisperKeyArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		*reply = api.xeth().Whisper().HasSymKey(args.Key)
+	case "shh_deleteSymKey":
+		args := new(WhisperKeyArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		*reply = api.xeth().Whisper().RemoveSymKey(args.Key)
 	case "shh_hasIdentity":
 		args := new(WhisperIdentityArgs)
 		if err := json.Unmarshal(req.Params, &args); err != nil {
@@ -441,7 +567,10 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 		// opts.From = args.From
 		opts.To = args.To
 		opts.Topics = args.Topics
-		id := api.xeth().NewWhisperFilter(opts)
+		id, err := api.xeth().NewWhisperFilter(opts)
+		if err != nil {
+			return err
+		}
 		*reply = newHexNum(big.NewInt(int64(id)).Bytes())
 	case "shh_uninstallFilter":
 		args := new(FilterIdArgs)
@@ -461,6 +590,66 @@ func (api *EthereumApi) GetRequestReply(req *RpcRequest, reply *interface{}) err
 			return err
 		}
 		*reply = api.xeth().Whisper().Messages(args.Id)
+	case "debug_vmStats":
+		*reply = vm.Stats()
+	case "debug_rpcStats":
+		*reply = Stats()
+	case "debug_verbosity":
+		args := new(VerbosityArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		glog.SetV(args.Level)
+		*reply = true
+	case "debug_vmodule":
+		args := new(VmoduleArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		if err := glog.SetVModule(args.Pattern); err != nil {
+			return NewValidationError("pattern", err.Error())
+		}
+		*reply = true
+	case "txpool_status":
+		pending, queued := api.xeth().TxPool().Stats()
+		*reply = &TxPoolStatusRes{Pending: newHexNum(pending), Queued: newHexNum(queued)}
+	case "txpool_content":
+		*reply = NewTxPoolContentRes(api.xeth().TxPool().Content())
+	case "txpool_inspect":
+		*reply = NewTxPoolInspectRes(api.xeth().TxPool().Content())
+	case "admin_chainSyncStatus":
+		*reply = api.xeth().SyncStatus()
+	case "personal_listAccounts":
+		*reply = NewAccountsRes(api.xeth().AccountList())
+	case "personal_setAccountLabel":
+		args := new(SetAccountLabelArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		if err := api.xeth().SetAccountLabel(args.Address, args.Label); err != nil {
+			return NewValidationError("address", err.Error())
+		}
+		*reply = true
+	case "personal_sign":
+		args := new(PersonalSignArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		sig, err := api.xeth().SignMessage(args.Address, args.Data, args.Passphrase)
+		if err != nil {
+			return err
+		}
+		*reply = newHexData(sig)
+	case "personal_ecRecover":
+		args := new(EcRecoverArgs)
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return err
+		}
+		addr, err := api.xeth().EcRecover(args.Data, args.Signature)
+		if err != nil {
+			return NewValidationError("signature", err.Error())
+		}
+		*reply = newHexData(addr)
 
 	// case "eth_register":
 	// 	// Placeholder for actual type
@@ -2,11 +2,19 @@ package rpc
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"os"
 	// "sync"
 	"testing"
 	// "time"
 
-	// "github.com/ethereum/go-ethereum/xeth"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/xeth"
 )
 
 func TestWeb3Sha3(t *testing.T) {
@@ -99,3 +107,65 @@ func TestWeb3Sha3(t *testing.T) {
 // 		t.Error("expected messages to be empty")
 // 	}
 // }
+
+func TestReadOnly(t *testing.T) {
+	api := &EthereumApi{}
+	api.SetReadOnly(true)
+
+	var response interface{}
+	req := RpcRequest{Method: "eth_sendTransaction", Params: []byte("[]")}
+	err := api.GetRequestReply(&req, &response)
+	if _, ok := err.(*NotAvailableError); !ok {
+		t.Fatalf("eth_sendTransaction: expected NotAvailableError, got %v", err)
+	}
+	if code := errorCode(err); code != ErrCodeMethodNotFound {
+		t.Errorf("expected code %d, got %d", ErrCodeMethodNotFound, code)
+	}
+
+	req = RpcRequest{Method: "web3_sha3", Params: []byte(`["0x68656c6c6f"]`)}
+	if err := api.GetRequestReply(&req, &response); err != nil {
+		t.Errorf("web3_sha3: unexpected error %v", err)
+	}
+}
+
+func TestGetCode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(data []byte) { core.GenesisData = data }(core.GenesisData)
+	core.GenesisData = []byte(`{"0000000000000000000000000000000000000001": {"balance": "0", "code": "0x6001"}}`)
+
+	ethereum, err := eth.New(&eth.Config{
+		DataDir:        dir,
+		Name:           "test",
+		AccountManager: accounts.NewManager(crypto.NewKeyStorePlain(dir)),
+		NewDB:          func(string) (common.Database, error) { return ethdb.NewMemDatabase() },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewEthereumApi(xeth.New(ethereum, nil))
+
+	for _, block := range []string{"latest", "earliest", "pending", "0x0"} {
+		var response interface{}
+		req := RpcRequest{Method: "eth_getCode", Params: []byte(`["0x0000000000000000000000000000000000000001", "` + block + `"]`)}
+		if err := api.GetRequestReply(&req, &response); err != nil {
+			t.Errorf("block %s: unexpected error %v", block, err)
+			continue
+		}
+		if code, _ := json.Marshal(response); string(code) != `"0x6001"` {
+			t.Errorf("block %s: code %s, want 0x6001", block, code)
+		}
+	}
+
+	// unknown blocks don't fall back to the genesis state
+	var response interface{}
+	req := RpcRequest{Method: "eth_getCode", Params: []byte(`["0x0000000000000000000000000000000000000001", "0x5"]`)}
+	if err := api.GetRequestReply(&req, &response); err == nil {
+		t.Errorf("expected error for unknown block, got code %v", response)
+	} else if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+}
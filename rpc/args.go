@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -71,6 +72,23 @@ func numString(raw interface{}, number *int64) error {
 	return nil
 }
 
+// isHex reports whether str is a non empty string of hex digits with an
+// optional 0x prefix.
+func isHex(str string) bool {
+	if common.HasHexPrefix(str) {
+		str = str[2:]
+	}
+	return len(str) > 0 && strings.Trim(str, "0123456789abcdefABCDEF") == ""
+}
+
+// isHexAddress reports whether str is a hex encoded 20 byte address.
+func isHexAddress(str string) bool {
+	if common.HasHexPrefix(str) {
+		str = str[2:]
+	}
+	return len(str) == 40 && isHex(str)
+}
+
 // func toNumber(v interface{}) (int64, error) {
 // 	var str string
 // 	if v != nil {
@@ -124,7 +142,11 @@ func (args *GetBlockByHashArgs) UnmarshalJSON(b []byte) (err error) {
 	}
 	args.BlockHash = argstr
 
-	args.IncludeTxs = obj[1].(bool)
+	includeTxs, ok := obj[1].(bool)
+	if !ok {
+		return NewInvalidTypeError("includeTxs", "not a boolean")
+	}
+	args.IncludeTxs = includeTxs
 
 	return nil
 }
@@ -144,15 +166,15 @@ func (args *GetBlockByNumberArgs) UnmarshalJSON(b []byte) (err error) {
 		return NewInsufficientParamsError(len(obj), 2)
 	}
 
-	if v, ok := obj[0].(float64); ok {
-		args.BlockNumber = int64(v)
-	} else if v, ok := obj[0].(string); ok {
-		args.BlockNumber = common.Big(v).Int64()
-	} else {
-		return NewInvalidTypeError("blockNumber", "not a number or string")
+	if err := blockHeight(obj[0], &args.BlockNumber); err != nil {
+		return err
 	}
 
-	args.IncludeTxs = obj[1].(bool)
+	includeTxs, ok := obj[1].(bool)
+	if !ok {
+		return NewInvalidTypeError("includeTxs", "not a boolean")
+	}
+	args.IncludeTxs = includeTxs
 
 	return nil
 }
@@ -197,6 +219,13 @@ func (args *NewTxArgs) UnmarshalJSON(b []byte) (err error) {
 	if len(ext.From) == 0 {
 		return NewValidationError("from", "is required")
 	}
+	if !isHexAddress(ext.From) {
+		return NewValidationError("from", "is not a valid address")
+	}
+	// names are resolved later on, only hex recipients are checked here
+	if isHex(ext.To) && !isHexAddress(ext.To) {
+		return NewValidationError("to", "is not a valid address")
+	}
 
 	args.From = ext.From
 	args.To = ext.To
@@ -210,6 +239,9 @@ func (args *NewTxArgs) UnmarshalJSON(b []byte) (err error) {
 			return err
 		}
 	}
+	if num < 0 {
+		return NewValidationError("value", "must not be negative")
+	}
 	args.Value = big.NewInt(num)
 
 	if ext.Gas == nil {
@@ -219,6 +251,9 @@ func (args *NewTxArgs) UnmarshalJSON(b []byte) (err error) {
 			return err
 		}
 	}
+	if num < 0 {
+		return NewValidationError("gas", "must not be negative")
+	}
 	args.Gas = big.NewInt(num)
 
 	if ext.GasPrice == nil {
@@ -228,6 +263,9 @@ func (args *NewTxArgs) UnmarshalJSON(b []byte) (err error) {
 			return err
 		}
 	}
+	if num < 0 {
+		return NewValidationError("gasPrice", "must not be negative")
+	}
 	args.GasPrice = big.NewInt(num)
 
 	// Check for optional BlockNumber param
@@ -463,6 +501,47 @@ func (args *GetBalanceArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+type AddressTransactionsArgs struct {
+	Address   string
+	FromBlock int64
+	ToBlock   int64
+}
+
+func (args *AddressTransactionsArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return NewInsufficientParamsError(len(obj), 1)
+	}
+
+	addstr, ok := obj[0].(string)
+	if !ok {
+		return NewInvalidTypeError("address", "not a string")
+	}
+	if !isHexAddress(addstr) {
+		return NewValidationError("address", "is not a valid address")
+	}
+	args.Address = addstr
+
+	args.FromBlock = 0
+	if len(obj) > 1 {
+		if err := blockHeight(obj[1], &args.FromBlock); err != nil {
+			return err
+		}
+	}
+	args.ToBlock = -1
+	if len(obj) > 2 {
+		if err := blockHeight(obj[2], &args.ToBlock); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type GetDataArgs struct {
 	Address     string
 	BlockNumber int64
@@ -862,6 +941,7 @@ func (a *DbHexArgs) requirements() error {
 type WhisperMessageArgs struct {
 	Payload  string
 	To       string
+	SymKeyID string
 	From     string
 	Topics   []string
 	Priority uint32
@@ -872,6 +952,7 @@ func (args *WhisperMessageArgs) UnmarshalJSON(b []byte) (err error) {
 	var obj []struct {
 		Payload  string
 		To       string
+		SymKeyID string
 		From     string
 		Topics   []string
 		Priority interface{}
@@ -887,6 +968,7 @@ func (args *WhisperMessageArgs) UnmarshalJSON(b []byte) (err error) {
 	}
 	args.Payload = obj[0].Payload
 	args.To = obj[0].To
+	args.SymKeyID = obj[0].SymKeyID
 	args.From = obj[0].From
 	args.Topics = obj[0].Topics
 
@@ -1004,6 +1086,31 @@ func (args *WhisperIdentityArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+// WhisperKeyArgs is the single string parameter of the whisper key
+// management methods: a private key, a symmetric key, a key id or a password.
+type WhisperKeyArgs struct {
+	Key string
+}
+
+func (args *WhisperKeyArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return NewInsufficientParamsError(len(obj), 1)
+	}
+
+	argstr, ok := obj[0].(string)
+	if !ok {
+		return NewInvalidTypeError("arg0", "not a string")
+	}
+	args.Key = argstr
+
+	return nil
+}
+
 type WhisperFilterArgs struct {
 	To     string `json:"to"`
 	From   string
@@ -1084,3 +1191,198 @@ func (args *SubmitWorkArgs) UnmarshalJSON(b []byte) (err error) {
 
 	return nil
 }
+
+type ResendArgs struct {
+	Hash     string
+	GasPrice *big.Int
+	GasLimit *big.Int
+}
+
+func (args *ResendArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return NewInsufficientParamsError(len(obj), 2)
+	}
+
+	hash, ok := obj[0].(string)
+	if !ok {
+		return NewInvalidTypeError("hash", "not a string")
+	}
+	args.Hash = hash
+
+	var num int64
+	if err := numString(obj[1], &num); err != nil {
+		return NewInvalidTypeError("gasPrice", "not a number or string")
+	}
+	if num <= 0 {
+		return NewValidationError("gasPrice", "must be positive")
+	}
+	args.GasPrice = big.NewInt(num)
+
+	// keep the gas limit of the original transaction if omitted
+	num = 0
+	if len(obj) > 2 {
+		if err := numString(obj[2], &num); err != nil {
+			return NewInvalidTypeError("gasLimit", "not a number or string")
+		}
+		if num < 0 {
+			return NewValidationError("gasLimit", "must not be negative")
+		}
+	}
+	args.GasLimit = big.NewInt(num)
+
+	return nil
+}
+
+type VerbosityArgs struct {
+	Level int
+}
+
+func (args *VerbosityArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return NewInsufficientParamsError(len(obj), 1)
+	}
+
+	var level int64
+	if err := numString(obj[0], &level); err != nil {
+		return NewInvalidTypeError("level", "not a number or string")
+	}
+	if level < 0 {
+		return NewValidationError("level", "must not be negative")
+	}
+	args.Level = int(level)
+
+	return nil
+}
+
+type SetAccountLabelArgs struct {
+	Address string
+	Label   string
+}
+
+func (args *SetAccountLabelArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return NewInsufficientParamsError(len(obj), 2)
+	}
+
+	addstr, ok := obj[0].(string)
+	if !ok {
+		return NewInvalidTypeError("address", "not a string")
+	}
+	args.Address = addstr
+
+	label, ok := obj[1].(string)
+	if !ok {
+		return NewInvalidTypeError("label", "not a string")
+	}
+	args.Label = label
+
+	return nil
+}
+
+type PersonalSignArgs struct {
+	Data       []byte
+	Address    string
+	Passphrase string
+}
+
+func (args *PersonalSignArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return NewInsufficientParamsError(len(obj), 2)
+	}
+
+	datastr, ok := obj[0].(string)
+	if !ok {
+		return NewInvalidTypeError("data", "not a string")
+	}
+	args.Data = common.FromHex(datastr)
+
+	addstr, ok := obj[1].(string)
+	if !ok {
+		return NewInvalidTypeError("address", "not a string")
+	}
+	args.Address = addstr
+
+	// the passphrase is optional
+	if len(obj) > 2 {
+		pass, ok := obj[2].(string)
+		if !ok {
+			return NewInvalidTypeError("passphrase", "not a string")
+		}
+		args.Passphrase = pass
+	}
+
+	return nil
+}
+
+type EcRecoverArgs struct {
+	Data      []byte
+	Signature []byte
+}
+
+func (args *EcRecoverArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return NewInsufficientParamsError(len(obj), 2)
+	}
+
+	datastr, ok := obj[0].(string)
+	if !ok {
+		return NewInvalidTypeError("data", "not a string")
+	}
+	args.Data = common.FromHex(datastr)
+
+	sigstr, ok := obj[1].(string)
+	if !ok {
+		return NewInvalidTypeError("signature", "not a string")
+	}
+	args.Signature = common.FromHex(sigstr)
+
+	return nil
+}
+
+type VmoduleArgs struct {
+	Pattern string
+}
+
+func (args *VmoduleArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return NewInsufficientParamsError(len(obj), 1)
+	}
+
+	pattern, ok := obj[0].(string)
+	if !ok {
+		return NewInvalidTypeError("pattern", "not a string")
+	}
+	args.Pattern = pattern
+
+	return nil
+}
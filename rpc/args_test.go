@@ -176,6 +176,58 @@ func TestGetBalanceArgs(t *testing.T) {
 	}
 }
 
+func TestAddressTransactionsArgs(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1", "0x1f", "latest"]`
+	expected := new(AddressTransactionsArgs)
+	expected.Address = "0x407d73d8a49eeb85d32cf465507dd71d507100c1"
+	expected.FromBlock = 31
+	expected.ToBlock = -1
+
+	args := new(AddressTransactionsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Address != expected.Address {
+		t.Errorf("Address should be %v but is %v", expected.Address, args.Address)
+	}
+
+	if args.FromBlock != expected.FromBlock {
+		t.Errorf("FromBlock should be %v but is %v", expected.FromBlock, args.FromBlock)
+	}
+
+	if args.ToBlock != expected.ToBlock {
+		t.Errorf("ToBlock should be %v but is %v", expected.ToBlock, args.ToBlock)
+	}
+}
+
+func TestAddressTransactionsArgsBlocksMissing(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1"]`
+
+	args := new(AddressTransactionsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.FromBlock != 0 {
+		t.Errorf("FromBlock should be %v but is %v", 0, args.FromBlock)
+	}
+
+	if args.ToBlock != -1 {
+		t.Errorf("ToBlock should be %v but is %v", -1, args.ToBlock)
+	}
+}
+
+func TestAddressTransactionsArgsAddressInvalid(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100"]`
+
+	args := new(AddressTransactionsArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestGetBalanceArgsBlocknumMissing(t *testing.T) {
 	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1"]`
 	expected := new(GetBalanceArgs)
@@ -276,6 +328,16 @@ func TestGetBlockByHashArgs(t *testing.T) {
 	}
 }
 
+func TestGetBlockByHashArgsIncludeTxsString(t *testing.T) {
+	input := `["0xe670ec64341771606e55d6b4ca35a1a6b75ee3d5145a99d05921026d1527331", "true"]`
+
+	args := new(GetBlockByHashArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestGetBlockByHashArgsEmpty(t *testing.T) {
 	input := `[]`
 
@@ -356,6 +418,33 @@ func TestGetBlockByNumberArgsBlockHex(t *testing.T) {
 	}
 }
 
+func TestGetBlockByNumberArgsLatest(t *testing.T) {
+	input := `["latest", true]`
+
+	args := new(GetBlockByNumberArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.BlockNumber != -1 {
+		t.Errorf("BlockNumber should be %v but is %v", -1, args.BlockNumber)
+	}
+
+	if !args.IncludeTxs {
+		t.Errorf("IncludeTxs should be %v but is %v", true, args.IncludeTxs)
+	}
+}
+
+func TestGetBlockByNumberArgsIncludeTxsNumber(t *testing.T) {
+	input := `["0x1b4", 1]`
+
+	args := new(GetBlockByNumberArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestGetBlockByNumberEmpty(t *testing.T) {
 	input := `[]`
 
@@ -397,7 +486,7 @@ func TestGetBlockByNumberBlockObject(t *testing.T) {
 
 func TestNewTxArgs(t *testing.T) {
 	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gas": "0x76c0",
   "gasPrice": "0x9184e72a000",
   "value": "0x9184e72a000",
@@ -405,7 +494,7 @@ func TestNewTxArgs(t *testing.T) {
   "0x10"]`
 	expected := new(NewTxArgs)
 	expected.From = "0xb60e8dd61c5d32be8058bb8eb970870f07233155"
-	expected.To = "0xd46e8dd67c5d32be8058bb8eb970870f072445675"
+	expected.To = "0xd46e8dd67c5d32be8058bb8eb970870f07244567"
 	expected.Gas = big.NewInt(30400)
 	expected.GasPrice = big.NewInt(10000000000000)
 	expected.Value = big.NewInt(10000000000000)
@@ -448,7 +537,7 @@ func TestNewTxArgs(t *testing.T) {
 
 func TestNewTxArgsInt(t *testing.T) {
 	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gas": 100,
   "gasPrice": 50,
   "value": 8765456789,
@@ -484,7 +573,7 @@ func TestNewTxArgsInt(t *testing.T) {
 
 func TestNewTxArgsBlockBool(t *testing.T) {
 	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gas": "0x76c0",
   "gasPrice": "0x9184e72a000",
   "value": "0x9184e72a000",
@@ -500,7 +589,7 @@ func TestNewTxArgsBlockBool(t *testing.T) {
 
 func TestNewTxArgsGasInvalid(t *testing.T) {
 	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gas": false,
   "gasPrice": "0x9184e72a000",
   "value": "0x9184e72a000",
@@ -516,7 +605,7 @@ func TestNewTxArgsGasInvalid(t *testing.T) {
 
 func TestNewTxArgsGaspriceInvalid(t *testing.T) {
 	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gas": "0x76c0",
   "gasPrice": false,
   "value": "0x9184e72a000",
@@ -532,7 +621,7 @@ func TestNewTxArgsGaspriceInvalid(t *testing.T) {
 
 func TestNewTxArgsValueInvalid(t *testing.T) {
 	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gas": "0x76c0",
   "gasPrice": "0x9184e72a000",
   "value": false,
@@ -548,7 +637,7 @@ func TestNewTxArgsValueInvalid(t *testing.T) {
 
 func TestNewTxArgsGasMissing(t *testing.T) {
 	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gasPrice": "0x9184e72a000",
   "value": "0x9184e72a000",
   "data": "0xd46e8dd67c5d32be8d46e8dd67c5d32be8058bb8eb970870f072445675058bb8eb970870f072445675"
@@ -569,7 +658,7 @@ func TestNewTxArgsGasMissing(t *testing.T) {
 func TestNewTxArgsBlockGaspriceMissing(t *testing.T) {
 	input := `[{
 	"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gas": "0x76c0",
   "value": "0x9184e72a000",
   "data": "0xd46e8dd67c5d32be8d46e8dd67c5d32be8058bb8eb970870f072445675058bb8eb970870f072445675"
@@ -591,7 +680,7 @@ func TestNewTxArgsBlockGaspriceMissing(t *testing.T) {
 func TestNewTxArgsValueMissing(t *testing.T) {
 	input := `[{
 	"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
-  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f07244567",
   "gas": "0x76c0",
   "gasPrice": "0x9184e72a000",
   "data": "0xd46e8dd67c5d32be8d46e8dd67c5d32be8058bb8eb970870f072445675058bb8eb970870f072445675"
@@ -649,6 +738,49 @@ func TestNewTxArgsFromEmpty(t *testing.T) {
 	}
 }
 
+func TestNewTxArgsFromInvalid(t *testing.T) {
+	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f0723315"}]`
+
+	args := new(NewTxArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestNewTxArgsToInvalid(t *testing.T) {
+	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
+  "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675"}]`
+
+	args := new(NewTxArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestNewTxArgsToName(t *testing.T) {
+	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155", "to": "myname"}]`
+
+	args := new(NewTxArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+	if args.To != "myname" {
+		t.Errorf("To shoud be %#v but is %#v", "myname", args.To)
+	}
+}
+
+func TestNewTxArgsValueNegative(t *testing.T) {
+	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155", "value": -1}]`
+
+	args := new(NewTxArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestCallArgs(t *testing.T) {
 	input := `[{"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
   "to": "0xd46e8dd67c5d32be8058bb8eb970870f072445675",
@@ -1785,6 +1917,26 @@ func TestWhisperMessageArgs(t *testing.T) {
 	// }
 }
 
+func TestWhisperMessageArgsSymKey(t *testing.T) {
+	input := `[{"symKeyID":"0x1f2e3d4c5b6a7988",
+  "topics": ["0x68656c6c6f20776f726c64"],
+  "payload":"0x68656c6c6f20776f726c64",
+  "ttl": "0x64",
+  "priority": "0x64"}]`
+
+	args := new(WhisperMessageArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.SymKeyID != "0x1f2e3d4c5b6a7988" {
+		t.Errorf("SymKeyID shoud be %#v but is %#v", "0x1f2e3d4c5b6a7988", args.SymKeyID)
+	}
+	if args.To != "" {
+		t.Errorf("To shoud be empty but is %#v", args.To)
+	}
+}
+
 func TestWhisperMessageArgsInt(t *testing.T) {
 	input := `[{"from":"0xc931d93e97ab07fe42d923478ba2465f2",
   "topics": ["0x68656c6c6f20776f726c64"],
@@ -2150,6 +2302,41 @@ func TestWhisperIdentityArgsInt(t *testing.T) {
 	}
 }
 
+func TestWhisperKeyArgs(t *testing.T) {
+	input := `["0x4c1b35ac8d3c1a1c6d10b13a5a6d9d4f2e3b1a0f9e8d7c6b5a4f3e2d1c0b0a09"]`
+	expected := new(WhisperKeyArgs)
+	expected.Key = "0x4c1b35ac8d3c1a1c6d10b13a5a6d9d4f2e3b1a0f9e8d7c6b5a4f3e2d1c0b0a09"
+
+	args := new(WhisperKeyArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if expected.Key != args.Key {
+		t.Errorf("Key shoud be %#v but is %#v", expected.Key, args.Key)
+	}
+}
+
+func TestWhisperKeyArgsEmpty(t *testing.T) {
+	input := `[]`
+
+	args := new(WhisperKeyArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Errorf(str)
+	}
+}
+
+func TestWhisperKeyArgsInt(t *testing.T) {
+	input := `[4]`
+
+	args := new(WhisperKeyArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Errorf(str)
+	}
+}
+
 func TestBlockNumArgs(t *testing.T) {
 	input := `["0x29a"]`
 	expected := new(BlockNumIndexArgs)
@@ -2440,3 +2627,237 @@ func TestBlockHeightFromJsonInvalid(t *testing.T) {
 		t.Error(str)
 	}
 }
+
+func TestResendArgs(t *testing.T) {
+	input := `["0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3", "0x2540be400", 30000]`
+
+	args := new(ResendArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Hash != "0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3" {
+		t.Errorf("Hash should be %#v but is %#v", "0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3", args.Hash)
+	}
+	if args.GasPrice.Cmp(big.NewInt(10000000000)) != 0 {
+		t.Errorf("GasPrice should be %v but is %v", 10000000000, args.GasPrice)
+	}
+	if args.GasLimit.Cmp(big.NewInt(30000)) != 0 {
+		t.Errorf("GasLimit should be %v but is %v", 30000, args.GasLimit)
+	}
+}
+
+func TestResendArgsNoGasLimit(t *testing.T) {
+	input := `["0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3", 10]`
+
+	args := new(ResendArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.GasLimit.Cmp(big.NewInt(0)) != 0 {
+		t.Errorf("GasLimit should be %v but is %v", 0, args.GasLimit)
+	}
+}
+
+func TestResendArgsZeroGasPrice(t *testing.T) {
+	input := `["0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3", 0]`
+
+	args := new(ResendArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestResendArgsInvalidHash(t *testing.T) {
+	input := `[5, 10]`
+
+	args := new(ResendArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestResendArgsEmpty(t *testing.T) {
+	input := `["0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"]`
+
+	args := new(ResendArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestVerbosityArgs(t *testing.T) {
+	input := `[5]`
+
+	args := new(VerbosityArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Level != 5 {
+		t.Errorf("Level should be 5 but is %d", args.Level)
+	}
+}
+
+func TestVerbosityArgsNegative(t *testing.T) {
+	input := `[-1]`
+
+	args := new(VerbosityArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestVerbosityArgsEmpty(t *testing.T) {
+	input := `[]`
+
+	args := new(VerbosityArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestVmoduleArgs(t *testing.T) {
+	input := `["p2p=5,core=3"]`
+
+	args := new(VmoduleArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Pattern != "p2p=5,core=3" {
+		t.Errorf("Pattern should be %q but is %q", "p2p=5,core=3", args.Pattern)
+	}
+}
+
+func TestVmoduleArgsInvalid(t *testing.T) {
+	input := `[5]`
+
+	args := new(VmoduleArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestSetAccountLabelArgs(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1", "savings"]`
+
+	args := new(SetAccountLabelArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Address != "0x407d73d8a49eeb85d32cf465507dd71d507100c1" {
+		t.Errorf("Address should be 0x407d73d8a49eeb85d32cf465507dd71d507100c1 but is %s", args.Address)
+	}
+	if args.Label != "savings" {
+		t.Errorf("Label should be savings but is %s", args.Label)
+	}
+}
+
+func TestSetAccountLabelArgsInsufficient(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1"]`
+
+	args := new(SetAccountLabelArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestSetAccountLabelArgsInvalidLabel(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1", 5]`
+
+	args := new(SetAccountLabelArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestPersonalSignArgs(t *testing.T) {
+	input := `["0x68656c6c6f", "0x407d73d8a49eeb85d32cf465507dd71d507100c1", "foo"]`
+
+	args := new(PersonalSignArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if string(args.Data) != "hello" {
+		t.Errorf("Data should be hello but is %q", args.Data)
+	}
+	if args.Address != "0x407d73d8a49eeb85d32cf465507dd71d507100c1" {
+		t.Errorf("Address should be 0x407d73d8a49eeb85d32cf465507dd71d507100c1 but is %s", args.Address)
+	}
+	if args.Passphrase != "foo" {
+		t.Errorf("Passphrase should be foo but is %s", args.Passphrase)
+	}
+}
+
+func TestPersonalSignArgsNoPassphrase(t *testing.T) {
+	input := `["0x68656c6c6f", "0x407d73d8a49eeb85d32cf465507dd71d507100c1"]`
+
+	args := new(PersonalSignArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Passphrase != "" {
+		t.Errorf("Passphrase should be empty but is %s", args.Passphrase)
+	}
+}
+
+func TestPersonalSignArgsInsufficient(t *testing.T) {
+	input := `["0x68656c6c6f"]`
+
+	args := new(PersonalSignArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestPersonalSignArgsInvalidAddress(t *testing.T) {
+	input := `["0x68656c6c6f", 5]`
+
+	args := new(PersonalSignArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestEcRecoverArgs(t *testing.T) {
+	input := `["0x68656c6c6f", "0x0102"]`
+
+	args := new(EcRecoverArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if string(args.Data) != "hello" {
+		t.Errorf("Data should be hello but is %q", args.Data)
+	}
+	if !bytes.Equal(args.Signature, []byte{1, 2}) {
+		t.Errorf("Signature should be 0x0102 but is %x", args.Signature)
+	}
+}
+
+func TestEcRecoverArgsInsufficient(t *testing.T) {
+	input := `["0x68656c6c6f"]`
+
+	args := new(EcRecoverArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestEcRecoverArgsInvalidSignature(t *testing.T) {
+	input := `["0x68656c6c6f", 5]`
+
+	args := new(EcRecoverArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// DefaultAuthModules are the modules requiring authentication unless
+// configured otherwise.
+var DefaultAuthModules = []string{"admin", "personal", "miner", "debug"}
+
+// UnauthorizedError is returned for calls of a module requiring
+// authentication if the request doesn't carry the secret.
+type UnauthorizedError struct {
+	Method string
+}
+
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("%s method requires authentication", e.Method)
+}
+
+func NewUnauthorizedError(method string) *UnauthorizedError {
+	return &UnauthorizedError{
+		Method: method,
+	}
+}
+
+// authenticator checks that calls of protected modules come with the
+// shared secret, given as bearer token or basic auth password in the
+// Authorization header.
+type authenticator struct {
+	secret  []byte
+	modules map[string]bool
+}
+
+// newAuthenticator returns nil if no module is protected.
+func newAuthenticator(secret string, modules []string) *authenticator {
+	if len(modules) == 0 {
+		return nil
+	}
+	a := &authenticator{secret: []byte(secret), modules: make(map[string]bool)}
+	for _, module := range modules {
+		a.modules[module] = true
+	}
+	return a
+}
+
+// authenticated reports whether req carries the secret.
+func (a *authenticator) authenticated(req *http.Request) bool {
+	if a == nil || len(a.secret) == 0 {
+		return false
+	}
+	var token string
+	if _, password, ok := req.BasicAuth(); ok {
+		token = password
+	} else if header := req.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), a.secret) == 1
+}
+
+// allow returns an UnauthorizedError if method belongs to a protected
+// module and the request isn't authenticated.
+func (a *authenticator) allow(method string, authenticated bool) error {
+	if a == nil || authenticated {
+		return nil
+	}
+	module := method
+	if i := strings.Index(method, "_"); i >= 0 {
+		module = method[:i]
+	}
+	if a.modules[module] {
+		return NewUnauthorizedError(method)
+	}
+	return nil
+}
+
+// LoadSecret reads the RPC authentication secret from the file at path.
+// If the file doesn't exist, a random secret is generated and saved,
+// readable only by the current user.
+func LoadSecret(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(buf)
+	if err := ioutil.WriteFile(path, []byte(secret+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuthenticator(t *testing.T) {
+	auth := newAuthenticator("secret", []string{"admin", "personal"})
+
+	tests := []struct {
+		setup func(*http.Request)
+		want  bool
+	}{
+		{func(r *http.Request) {}, false},
+		{func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }, true},
+		{func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }, false},
+		{func(r *http.Request) { r.SetBasicAuth("user", "secret") }, true},
+		{func(r *http.Request) { r.SetBasicAuth("secret", "") }, false},
+	}
+	for i, test := range tests {
+		req, _ := http.NewRequest("POST", "/", nil)
+		test.setup(req)
+		if got := auth.authenticated(req); got != test.want {
+			t.Errorf("test %d: authenticated is %v, want %v", i, got, test.want)
+		}
+	}
+
+	if err := auth.allow("personal_sign", false); err == nil {
+		t.Error("personal_sign allowed without authentication")
+	} else if code := errorCode(err); code != ErrCodeUnauthorized {
+		t.Errorf("expected code %d, got %d", ErrCodeUnauthorized, code)
+	}
+	if err := auth.allow("personal_sign", true); err != nil {
+		t.Errorf("personal_sign denied with authentication: %v", err)
+	}
+	if err := auth.allow("eth_blockNumber", false); err != nil {
+		t.Errorf("eth_blockNumber denied: %v", err)
+	}
+	if newAuthenticator("secret", nil).allow("admin_peers", false) != nil {
+		t.Error("method denied without protected modules")
+	}
+}
+
+func TestLoadSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpcsecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rpcsecret")
+
+	secret, err := LoadSecret(path)
+	if err != nil {
+		t.Fatalf("could not generate secret: %v", err)
+	}
+	if len(secret) != 64 {
+		t.Errorf("generated secret %q has length %d, want 64", secret, len(secret))
+	}
+	loaded, err := LoadSecret(path)
+	if err != nil {
+		t.Fatalf("could not load secret: %v", err)
+	}
+	if loaded != secret {
+		t.Errorf("loaded secret %q, want %q", loaded, secret)
+	}
+}
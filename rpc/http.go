@@ -1,11 +1,13 @@
 package rpc
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -19,6 +21,11 @@ var rpclistener *stoppableTCPListener
 const (
 	jsonrpcver       = "2.0"
 	maxSizeReqLength = 1024 * 1024 // 1MB
+	corsMaxAge       = 600         // seconds browsers may cache preflight results
+
+	// DefaultWorkers is the number of requests executed at the same time
+	// unless configured otherwise.
+	DefaultWorkers = 16
 )
 
 func Start(pipe *xeth.XEth, config RpcConfig) error {
@@ -29,6 +36,12 @@ func Start(pipe *xeth.XEth, config RpcConfig) error {
 		return nil // RPC service already running on given host/port
 	}
 
+	tlsConfig, err := config.tlsConfig()
+	if err != nil {
+		rpclogger.Errorf("Can't load TLS certificate: %v", err)
+		return err
+	}
+
 	l, err := newStoppableTCPListener(fmt.Sprintf("%s:%d", config.ListenAddress, config.ListenPort))
 	if err != nil {
 		rpclogger.Errorf("Can't listen on %s:%d: %v", config.ListenAddress, config.ListenPort, err)
@@ -36,23 +49,40 @@ func Start(pipe *xeth.XEth, config RpcConfig) error {
 	}
 	rpclistener = l
 
-	var handler http.Handler
-	if len(config.CorsDomain) > 0 {
-		var opts cors.Options
-		opts.AllowedMethods = []string{"POST"}
-		opts.AllowedOrigins = []string{config.CorsDomain}
+	handler := newStoppableHandler(newCorsHandler(newJSONRPC(pipe, config), config.CorsDomain), l.stop)
 
-		c := cors.New(opts)
-		handler = newStoppableHandler(c.Handler(JSONRPC(pipe)), l.stop)
+	if tlsConfig != nil {
+		go http.Serve(tls.NewListener(l, tlsConfig), handler)
 	} else {
-		handler = newStoppableHandler(JSONRPC(pipe), l.stop)
+		go http.Serve(l, handler)
 	}
 
-	go http.Serve(l, handler)
-
 	return nil
 }
 
+// newCorsHandler wraps h with a handler answering CORS preflight requests
+// and adding the CORS headers for the comma separated list of allowed
+// origins. The origin "*" allows all origins. If no origin is given, h is
+// returned as is.
+func newCorsHandler(h http.Handler, allowedOrigins string) http.Handler {
+	var origins []string
+	for _, origin := range strings.Split(allowedOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		return h
+	}
+	c := cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"POST"},
+		AllowedHeaders: []string{"Accept", "Content-Type", "Authorization"},
+		MaxAge:         corsMaxAge,
+	})
+	return c.Handler(h)
+}
+
 func Stop() error {
 	if rpclistener != nil {
 		rpclistener.Stop()
@@ -62,16 +92,48 @@ func Stop() error {
 	return nil
 }
 
+// workerPool bounds the number of requests executed at the same time.
+type workerPool chan struct{}
+
+func newWorkerPool(size int) workerPool {
+	if size <= 0 {
+		size = DefaultWorkers
+	}
+	return make(workerPool, size)
+}
+
+// run executes f once a worker is available.
+func (p workerPool) run(f func()) {
+	p <- struct{}{}
+	defer func() { <-p }()
+	f()
+}
+
 // JSONRPC returns a handler that implements the Ethereum JSON-RPC API.
 func JSONRPC(pipe *xeth.XEth) http.Handler {
+	return newJSONRPC(pipe, RpcConfig{})
+}
+
+// newJSONRPC returns a JSON-RPC handler executing at most config.Workers
+// requests at the same time. A batch occupies one worker and its requests
+// are executed sequentially.
+func newJSONRPC(pipe *xeth.XEth, config RpcConfig) http.Handler {
 	api := NewEthereumApi(pipe)
+	api.SetReadOnly(config.ReadOnly)
+	pool := newWorkerPool(config.Workers)
+	auth := newAuthenticator(config.AuthSecret, config.AuthModules)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Preflight requests are answered by the CORS handler, an OPTIONS
+		// request reaching this point has nothing to do.
+		if req.Method == "OPTIONS" {
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
 
 		// Limit request size to resist DoS
 		if req.ContentLength > maxSizeReqLength {
-			jsonerr := &RpcErrorObject{-32700, "Request too large"}
+			jsonerr := &RpcErrorObject{Code: ErrCodeParse, Message: "Request too large"}
 			send(w, &RpcErrorResponse{Jsonrpc: jsonrpcver, Id: nil, Error: jsonerr})
 			return
 		}
@@ -80,14 +142,25 @@ func JSONRPC(pipe *xeth.XEth) http.Handler {
 		defer req.Body.Close()
 		body, err := ioutil.ReadAll(req.Body)
 		if err != nil {
-			jsonerr := &RpcErrorObject{-32700, "Could not read request body"}
+			jsonerr := &RpcErrorObject{Code: ErrCodeParse, Message: "Could not read request body"}
 			send(w, &RpcErrorResponse{Jsonrpc: jsonrpcver, Id: nil, Error: jsonerr})
 		}
 
+		authenticated := auth.authenticated(req)
+		respond := func(request *RpcRequest) *interface{} {
+			if err := auth.allow(request.Method, authenticated); err != nil {
+				return errorResponse(request.Id, err)
+			}
+			return rpcResponse(api, request, requestOrigin(req))
+		}
+
 		// Try to parse the request as a single
 		var reqSingle RpcRequest
 		if err := json.Unmarshal(body, &reqSingle); err == nil {
-			response := RpcResponse(api, &reqSingle)
+			var response *interface{}
+			pool.run(func() {
+				response = respond(&reqSingle)
+			})
 			send(w, &response)
 			return
 		}
@@ -95,36 +168,43 @@ func JSONRPC(pipe *xeth.XEth) http.Handler {
 		// Try to parse the request to batch
 		var reqBatch []RpcRequest
 		if err := json.Unmarshal(body, &reqBatch); err == nil {
-			// Build response batch
+			// Build response batch. Its requests are executed in order, a
+			// later one may depend on the effects of an earlier one.
 			resBatch := make([]*interface{}, len(reqBatch))
-			for i, request := range reqBatch {
-				response := RpcResponse(api, &request)
-				resBatch[i] = response
-			}
+			pool.run(func() {
+				for i := range reqBatch {
+					resBatch[i] = respond(&reqBatch[i])
+				}
+			})
 			send(w, resBatch)
 			return
 		}
 
 		// Not a batch or single request, error
-		jsonerr := &RpcErrorObject{-32600, "Could not decode request"}
+		jsonerr := &RpcErrorObject{Code: ErrCodeInvalidRequest, Message: "Could not decode request"}
 		send(w, &RpcErrorResponse{Jsonrpc: jsonrpcver, Id: nil, Error: jsonerr})
 	})
 }
 
+// requestOrigin describes the client sending req for the logs.
+func requestOrigin(req *http.Request) string {
+	if origin := req.Header.Get("Origin"); origin != "" {
+		return req.RemoteAddr + "/" + origin
+	}
+	return req.RemoteAddr
+}
+
 func RpcResponse(api *EthereumApi, request *RpcRequest) *interface{} {
+	return rpcResponse(api, request, "")
+}
+
+func rpcResponse(api *EthereumApi, request *RpcRequest, origin string) *interface{} {
 	var reply, response interface{}
-	reserr := api.GetRequestReply(request, &reply)
-	switch reserr.(type) {
-	case nil:
+	reserr := callAPI(api, request, &reply, origin)
+	if reserr == nil {
 		response = &RpcSuccessResponse{Jsonrpc: jsonrpcver, Id: request.Id, Result: reply}
-	case *NotImplementedError:
-		jsonerr := &RpcErrorObject{-32601, reserr.Error()}
-		response = &RpcErrorResponse{Jsonrpc: jsonrpcver, Id: request.Id, Error: jsonerr}
-	case *DecodeParamError, *InsufficientParamsError, *ValidationError, *InvalidTypeError:
-		jsonerr := &RpcErrorObject{-32602, reserr.Error()}
-		response = &RpcErrorResponse{Jsonrpc: jsonrpcver, Id: request.Id, Error: jsonerr}
-	default:
-		jsonerr := &RpcErrorObject{-32603, reserr.Error()}
+	} else {
+		jsonerr := newErrorObject(reserr)
 		response = &RpcErrorResponse{Jsonrpc: jsonrpcver, Id: request.Id, Error: jsonerr}
 	}
 
@@ -132,6 +212,12 @@ func RpcResponse(api *EthereumApi, request *RpcRequest) *interface{} {
 	return &response
 }
 
+// errorResponse returns the response to a request failing with err.
+func errorResponse(id interface{}, err error) *interface{} {
+	var response interface{} = &RpcErrorResponse{Jsonrpc: jsonrpcver, Id: id, Error: newErrorObject(err)}
+	return &response
+}
+
 func send(writer io.Writer, v interface{}) (n int, err error) {
 	var payload []byte
 	payload, err = json.MarshalIndent(v, "", "\t")
@@ -0,0 +1,170 @@
+package rpc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/xeth"
+)
+
+func TestCorsPreflight(t *testing.T) {
+	var called bool
+	h := newCorsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}), "http://a.example, http://b.example")
+
+	tests := []struct {
+		origin, allowed string
+	}{
+		{"http://a.example", "http://a.example"},
+		{"http://b.example", "http://b.example"},
+		{"http://c.example", ""},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("OPTIONS", "/", nil)
+		req.Header.Set("Origin", test.origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "content-type")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != test.allowed {
+			t.Errorf("%s: Access-Control-Allow-Origin is %q, want %q", test.origin, got, test.allowed)
+		}
+		if test.allowed != "" && w.Header().Get("Access-Control-Allow-Methods") != "POST" {
+			t.Errorf("%s: Access-Control-Allow-Methods is %q, want POST", test.origin, w.Header().Get("Access-Control-Allow-Methods"))
+		}
+	}
+	if called {
+		t.Error("preflight request passed to the RPC handler")
+	}
+}
+
+// Tests that browsers may send the shared secret of the sensitive modules.
+func TestCorsPreflightAuthorization(t *testing.T) {
+	h := newCorsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), "http://a.example")
+
+	req, _ := http.NewRequest("OPTIONS", "/", nil)
+	req.Header.Set("Origin", "http://a.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "http://a.example" {
+		t.Errorf("Access-Control-Allow-Origin is %q, want http://a.example", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Authorization" {
+		t.Errorf("Access-Control-Allow-Headers is %q, want Authorization", got)
+	}
+}
+
+func TestCorsWildcard(t *testing.T) {
+	h := newCorsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), "*")
+
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Origin", "http://any.example")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "http://any.example" {
+		t.Errorf("Access-Control-Allow-Origin is %q, want http://any.example", got)
+	}
+}
+
+func TestWorkerPoolLimit(t *testing.T) {
+	var (
+		pool    = newWorkerPool(2)
+		running int32
+		max     int32
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.run(func() {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&max)
+					if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			})
+		}()
+	}
+	wg.Wait()
+
+	if max != 2 {
+		t.Errorf("%d requests executed at the same time, want 2", max)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	if c, err := (&RpcConfig{}).tlsConfig(); c != nil || err != nil {
+		t.Errorf("no certificate: got config %v, error %v", c, err)
+	}
+	if _, err := (&RpcConfig{CertFile: "cert.pem"}).tlsConfig(); err == nil {
+		t.Error("certificate without key accepted")
+	}
+	if _, err := (&RpcConfig{CertFile: "does-not-exist.pem", KeyFile: "does-not-exist.key"}).tlsConfig(); err == nil {
+		t.Error("missing certificate file accepted")
+	}
+}
+
+// Read-heavy load, half balance and half block requests.
+var benchRequests = [][]byte{
+	[]byte(`{"jsonrpc":"2.0","method":"eth_getBalance","params":["0x0000000000000000000000000000000000000001","latest"],"id":1}`),
+	[]byte(`{"jsonrpc":"2.0","method":"eth_getBlockByNumber","params":["latest",true],"id":2}`),
+}
+
+func BenchmarkReadRequests1Worker(b *testing.B)   { benchmarkReadRequests(b, 1) }
+func BenchmarkReadRequests16Workers(b *testing.B) { benchmarkReadRequests(b, 16) }
+
+func benchmarkReadRequests(b *testing.B, workers int) {
+	dir, err := ioutil.TempDir("", "rpc-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ethereum, err := eth.New(&eth.Config{
+		DataDir:        dir,
+		Name:           "bench",
+		AccountManager: accounts.NewManager(crypto.NewKeyStorePlain(dir)),
+		NewDB:          func(string) (common.Database, error) { return ethdb.NewMemDatabase() },
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	srv := httptest.NewServer(newJSONRPC(xeth.New(ethereum, nil), RpcConfig{Workers: workers}))
+	defer srv.Close()
+
+	var n uint32
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			body := benchRequests[atomic.AddUint32(&n, 1)%uint32(len(benchRequests))]
+			resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				b.Fatal(err)
+			}
+			ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+	})
+}
@@ -19,7 +19,10 @@ func NewJeth(ethApi *EthereumApi, toVal func(interface{}) otto.Value, re *jsre.J
 }
 
 func (self *Jeth) err(code int, msg string, id interface{}) (response otto.Value) {
-	rpcerr := &RpcErrorObject{code, msg}
+	return self.errObject(&RpcErrorObject{Code: code, Message: msg}, id)
+}
+
+func (self *Jeth) errObject(rpcerr *RpcErrorObject, id interface{}) (response otto.Value) {
 	self.re.Set("ret_jsonrpc", jsonrpcver)
 	self.re.Set("ret_id", id)
 	self.re.Set("ret_error", rpcerr)
@@ -32,7 +35,7 @@ func (self *Jeth) err(code int, msg string, id interface{}) (response otto.Value
 func (self *Jeth) Send(call otto.FunctionCall) (response otto.Value) {
 	reqif, err := call.Argument(0).Export()
 	if err != nil {
-		return self.err(-32700, err.Error(), nil)
+		return self.err(ErrCodeParse, err.Error(), nil)
 	}
 
 	jsonreq, err := json.Marshal(reqif)
@@ -41,18 +44,63 @@ func (self *Jeth) Send(call otto.FunctionCall) (response otto.Value) {
 	err = json.Unmarshal(jsonreq, &req)
 
 	var respif interface{}
-	err = self.ethApi.GetRequestReply(&req, &respif)
+	err = callAPI(self.ethApi, &req, &respif, "console")
 	if err != nil {
 		fmt.Printf("error: %s\n", err)
-		return self.err(-32603, err.Error(), req.Id)
+		return self.errObject(newErrorObject(err), req.Id)
+	}
+	return self.response(req.Id, respif)
+}
+
+// SendAsync executes the request on the jsre worker pool and passes the
+// response to the callback given as second argument once it's available.
+func (self *Jeth) SendAsync(call otto.FunctionCall) otto.Value {
+	callback := call.Argument(1)
+	if !callback.IsFunction() {
+		return self.err(ErrCodeInvalidRequest, "callback must be a function", nil)
 	}
+
+	reqif, err := call.Argument(0).Export()
+	if err != nil {
+		self.callback(callback, self.err(ErrCodeParse, err.Error(), nil))
+		return otto.UndefinedValue()
+	}
+	jsonreq, err := json.Marshal(reqif)
+
+	var req RpcRequest
+	if err = json.Unmarshal(jsonreq, &req); err != nil {
+		self.callback(callback, self.err(ErrCodeInvalidRequest, err.Error(), nil))
+		return otto.UndefinedValue()
+	}
+
+	self.re.Dispatch(func() (interface{}, error) {
+		var respif interface{}
+		err := callAPI(self.ethApi, &req, &respif, "console")
+		return respif, err
+	}, func(respif interface{}, err error) {
+		if err != nil {
+			self.callback(callback, self.errObject(newErrorObject(err), req.Id))
+			return
+		}
+		self.callback(callback, self.response(req.Id, respif))
+	})
+	return otto.UndefinedValue()
+}
+
+func (self *Jeth) response(id interface{}, result interface{}) (response otto.Value) {
 	self.re.Set("ret_jsonrpc", jsonrpcver)
-	self.re.Set("ret_id", req.Id)
+	self.re.Set("ret_id", id)
 
-	res, _ := json.Marshal(respif)
+	res, _ := json.Marshal(result)
 	self.re.Set("ret_result", string(res))
-	response, err = self.re.Run(`
+	response, _ = self.re.Run(`
 		ret_response = { jsonrpc: ret_jsonrpc, id: ret_id, result: JSON.parse(ret_result) };
 	`)
 	return
 }
+
+func (self *Jeth) callback(callback otto.Value, response otto.Value) {
+	if _, err := callback.Call(otto.NullValue(), otto.NullValue(), response); err != nil {
+		fmt.Println("callback error:", err)
+	}
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import "fmt"
+
+// readOnlyMethods are the methods served in read-only mode. They neither
+// change the chain, the node or its accounts nor reveal the accounts.
+var readOnlyMethods = map[string]bool{
+	"web3_sha3":          true,
+	"web3_clientVersion": true,
+	"net_version":        true,
+	"net_listening":      true,
+	"net_peerCount":      true,
+
+	"eth_version":                             true,
+	"eth_mining":                              true,
+	"eth_getUpdateStatus":                     true,
+	"eth_gasPrice":                            true,
+	"eth_blockNumber":                         true,
+	"eth_getBalance":                          true,
+	"eth_getStorage":                          true,
+	"eth_storageAt":                           true,
+	"eth_getStorageAt":                        true,
+	"eth_getTransactionCount":                 true,
+	"eth_getBlockTransactionCountByHash":      true,
+	"eth_getBlockTransactionCountByNumber":    true,
+	"eth_getUncleCountByBlockHash":            true,
+	"eth_getUncleCountByBlockNumber":          true,
+	"eth_getData":                             true,
+	"eth_getCode":                             true,
+	"eth_call":                                true,
+	"eth_getBlockByHash":                      true,
+	"eth_getBlockByNumber":                    true,
+	"eth_getTransactionByHash":                true,
+	"eth_getTransactionReceipt":               true,
+	"eth_getTransactionsByAddress":            true,
+	"eth_getTransactionByBlockHashAndIndex":   true,
+	"eth_getTransactionByBlockNumberAndIndex": true,
+	"eth_getUncleByBlockHashAndIndex":         true,
+	"eth_getUncleByBlockNumberAndIndex":       true,
+	"eth_getCompilers":                        true,
+	"eth_getLogs":                             true,
+
+	// filters only live for the filter timeout and their number is limited
+	"eth_newFilter":                   true,
+	"eth_newBlockFilter":              true,
+	"eth_newPendingTransactionFilter": true,
+	"eth_uninstallFilter":             true,
+	"eth_getFilterChanges":            true,
+	"eth_getFilterLogs":               true,
+
+	"txpool_status":  true,
+	"txpool_content": true,
+	"txpool_inspect": true,
+}
+
+// NotAvailableError is returned for methods which are not served in
+// read-only mode.
+type NotAvailableError struct {
+	Method string
+}
+
+func (e *NotAvailableError) Error() string {
+	return fmt.Sprintf("%s method not available in read-only mode", e.Method)
+}
+
+func NewNotAvailableError(method string) *NotAvailableError {
+	return &NotAvailableError{
+		Method: method,
+	}
+}
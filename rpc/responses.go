@@ -2,7 +2,12 @@ package rpc
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 )
@@ -30,99 +35,61 @@ type BlockRes struct {
 	Uncles          []*UncleRes       `json:"uncles"`
 }
 
+// MarshalJSON renders the block with full transaction objects if the block
+// result was created with fullTx, or with the transaction hashes otherwise.
+// Uncles are always rendered as hashes.
 func (b *BlockRes) MarshalJSON() ([]byte, error) {
-	if b.fullTx {
-		var ext struct {
-			BlockNumber     *hexnum           `json:"number"`
-			BlockHash       *hexdata          `json:"hash"`
-			ParentHash      *hexdata          `json:"parentHash"`
-			Nonce           *hexdata          `json:"nonce"`
-			Sha3Uncles      *hexdata          `json:"sha3Uncles"`
-			LogsBloom       *hexdata          `json:"logsBloom"`
-			TransactionRoot *hexdata          `json:"transactionsRoot"`
-			StateRoot       *hexdata          `json:"stateRoot"`
-			Miner           *hexdata          `json:"miner"`
-			Difficulty      *hexnum           `json:"difficulty"`
-			TotalDifficulty *hexnum           `json:"totalDifficulty"`
-			Size            *hexnum           `json:"size"`
-			ExtraData       *hexdata          `json:"extraData"`
-			GasLimit        *hexnum           `json:"gasLimit"`
-			GasUsed         *hexnum           `json:"gasUsed"`
-			UnixTimestamp   *hexnum           `json:"timestamp"`
-			Transactions    []*TransactionRes `json:"transactions"`
-			Uncles          []*hexdata        `json:"uncles"`
-		}
+	var ext struct {
+		BlockNumber     *hexnum     `json:"number"`
+		BlockHash       *hexdata    `json:"hash"`
+		ParentHash      *hexdata    `json:"parentHash"`
+		Nonce           *hexdata    `json:"nonce"`
+		Sha3Uncles      *hexdata    `json:"sha3Uncles"`
+		LogsBloom       *hexdata    `json:"logsBloom"`
+		TransactionRoot *hexdata    `json:"transactionsRoot"`
+		StateRoot       *hexdata    `json:"stateRoot"`
+		Miner           *hexdata    `json:"miner"`
+		Difficulty      *hexnum     `json:"difficulty"`
+		TotalDifficulty *hexnum     `json:"totalDifficulty"`
+		Size            *hexnum     `json:"size"`
+		ExtraData       *hexdata    `json:"extraData"`
+		GasLimit        *hexnum     `json:"gasLimit"`
+		GasUsed         *hexnum     `json:"gasUsed"`
+		UnixTimestamp   *hexnum     `json:"timestamp"`
+		Transactions    interface{} `json:"transactions"`
+		Uncles          []*hexdata  `json:"uncles"`
+	}
 
-		ext.BlockNumber = b.BlockNumber
-		ext.BlockHash = b.BlockHash
-		ext.ParentHash = b.ParentHash
-		ext.Nonce = b.Nonce
-		ext.Sha3Uncles = b.Sha3Uncles
-		ext.LogsBloom = b.LogsBloom
-		ext.TransactionRoot = b.TransactionRoot
-		ext.StateRoot = b.StateRoot
-		ext.Miner = b.Miner
-		ext.Difficulty = b.Difficulty
-		ext.TotalDifficulty = b.TotalDifficulty
-		ext.Size = b.Size
-		ext.ExtraData = b.ExtraData
-		ext.GasLimit = b.GasLimit
-		ext.GasUsed = b.GasUsed
-		ext.UnixTimestamp = b.UnixTimestamp
+	ext.BlockNumber = b.BlockNumber
+	ext.BlockHash = b.BlockHash
+	ext.ParentHash = b.ParentHash
+	ext.Nonce = b.Nonce
+	ext.Sha3Uncles = b.Sha3Uncles
+	ext.LogsBloom = b.LogsBloom
+	ext.TransactionRoot = b.TransactionRoot
+	ext.StateRoot = b.StateRoot
+	ext.Miner = b.Miner
+	ext.Difficulty = b.Difficulty
+	ext.TotalDifficulty = b.TotalDifficulty
+	ext.Size = b.Size
+	ext.ExtraData = b.ExtraData
+	ext.GasLimit = b.GasLimit
+	ext.GasUsed = b.GasUsed
+	ext.UnixTimestamp = b.UnixTimestamp
+	if b.fullTx {
 		ext.Transactions = b.Transactions
-		ext.Uncles = make([]*hexdata, len(b.Uncles))
-		for i, u := range b.Uncles {
-			ext.Uncles[i] = u.BlockHash
-		}
-		return json.Marshal(ext)
 	} else {
-		var ext struct {
-			BlockNumber     *hexnum    `json:"number"`
-			BlockHash       *hexdata   `json:"hash"`
-			ParentHash      *hexdata   `json:"parentHash"`
-			Nonce           *hexdata   `json:"nonce"`
-			Sha3Uncles      *hexdata   `json:"sha3Uncles"`
-			LogsBloom       *hexdata   `json:"logsBloom"`
-			TransactionRoot *hexdata   `json:"transactionsRoot"`
-			StateRoot       *hexdata   `json:"stateRoot"`
-			Miner           *hexdata   `json:"miner"`
-			Difficulty      *hexnum    `json:"difficulty"`
-			TotalDifficulty *hexnum    `json:"totalDifficulty"`
-			Size            *hexnum    `json:"size"`
-			ExtraData       *hexdata   `json:"extraData"`
-			GasLimit        *hexnum    `json:"gasLimit"`
-			GasUsed         *hexnum    `json:"gasUsed"`
-			UnixTimestamp   *hexnum    `json:"timestamp"`
-			Transactions    []*hexdata `json:"transactions"`
-			Uncles          []*hexdata `json:"uncles"`
-		}
-
-		ext.BlockNumber = b.BlockNumber
-		ext.BlockHash = b.BlockHash
-		ext.ParentHash = b.ParentHash
-		ext.Nonce = b.Nonce
-		ext.Sha3Uncles = b.Sha3Uncles
-		ext.LogsBloom = b.LogsBloom
-		ext.TransactionRoot = b.TransactionRoot
-		ext.StateRoot = b.StateRoot
-		ext.Miner = b.Miner
-		ext.Difficulty = b.Difficulty
-		ext.TotalDifficulty = b.TotalDifficulty
-		ext.Size = b.Size
-		ext.ExtraData = b.ExtraData
-		ext.GasLimit = b.GasLimit
-		ext.GasUsed = b.GasUsed
-		ext.UnixTimestamp = b.UnixTimestamp
-		ext.Transactions = make([]*hexdata, len(b.Transactions))
+		hashes := make([]*hexdata, len(b.Transactions))
 		for i, tx := range b.Transactions {
-			ext.Transactions[i] = tx.Hash
-		}
-		ext.Uncles = make([]*hexdata, len(b.Uncles))
-		for i, u := range b.Uncles {
-			ext.Uncles[i] = u.BlockHash
+			hashes[i] = tx.Hash
 		}
-		return json.Marshal(ext)
+		ext.Transactions = hashes
+	}
+	ext.Uncles = make([]*hexdata, len(b.Uncles))
+	for i, u := range b.Uncles {
+		ext.Uncles[i] = u.BlockHash
 	}
+	return json.Marshal(ext)
 }
 
 func NewBlockRes(block *types.Block, fullTx bool) *BlockRes {
@@ -200,6 +167,54 @@ func NewTransactionRes(tx *types.Transaction) *TransactionRes {
 	return v
 }
 
+type ReceiptRes struct {
+	TxHash            *hexdata `json:"transactionHash"`
+	TxIndex           *hexnum  `json:"transactionIndex"`
+	BlockHash         *hexdata `json:"blockHash"`
+	BlockNumber       *hexnum  `json:"blockNumber"`
+	CumulativeGasUsed *hexnum  `json:"cumulativeGasUsed"`
+	GasUsed           *hexnum  `json:"gasUsed"`
+	ContractAddress   *hexdata `json:"contractAddress"`
+	Status            *hexnum  `json:"status"`
+	Logs              []LogRes `json:"logs,omitempty"`
+}
+
+func NewReceiptRes(tx *types.Transaction, receipt *types.Receipt) *ReceiptRes {
+	if tx == nil || receipt == nil {
+		return nil
+	}
+
+	var v = new(ReceiptRes)
+	v.TxHash = newHexData(tx.Hash())
+	v.CumulativeGasUsed = newHexNum(receipt.CumulativeGasUsed)
+	v.GasUsed = newHexNum(receipt.GasUsed())
+	if tx.To() == nil {
+		v.ContractAddress = newHexData(core.AddressFromMessage(tx))
+	} else {
+		v.ContractAddress = newHexData(nil)
+	}
+	if receipt.Failed() {
+		v.Status = newHexNum(0)
+	} else {
+		v.Status = newHexNum(1)
+	}
+	return v
+}
+
+// NewBlockReceiptsRes returns the receipts of all transactions of block,
+// with the block fields and the logs set.
+func NewBlockReceiptsRes(block *types.Block, receipts types.Receipts) []*ReceiptRes {
+	res := make([]*ReceiptRes, len(receipts))
+	for i, receipt := range receipts {
+		res[i] = NewReceiptRes(block.Transactions()[i], receipt)
+		res[i].TxIndex = newHexNum(i)
+		res[i].BlockHash = newHexData(block.Hash())
+		res[i].BlockNumber = newHexNum(block.Number())
+		res[i].Logs = NewLogsRes(receipt.Logs())
+	}
+	return res
+}
+
 type UncleRes struct {
 	BlockNumber     *hexnum  `json:"number"`
 	BlockHash       *hexdata `json:"hash"`
@@ -275,6 +290,7 @@ type LogRes struct {
 	BlockHash        *hexdata   `json:"blockHash"`
 	TransactionHash  *hexdata   `json:"transactionHash"`
 	TransactionIndex *hexnum    `json:"transactionIndex"`
+	Removed          bool       `json:"removed"`
 }
 
 func NewLogRes(log *state.Log) LogRes {
@@ -290,6 +306,7 @@ func NewLogRes(log *state.Log) LogRes {
 	l.TransactionHash = newHexData(log.TxHash)
 	l.TransactionIndex = newHexNum(log.TxIndex)
 	l.BlockHash = newHexData(log.BlockHash)
+	l.Removed = log.Removed
 
 	return l
 }
@@ -303,3 +320,61 @@ func NewLogsRes(logs state.Logs) (ls []LogRes) {
 
 	return
 }
+
+// AccountRes is an account of the node with its label
+type AccountRes struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+}
+
+func NewAccountsRes(accts []accounts.Account) []*AccountRes {
+	res := make([]*AccountRes, len(accts))
+	for i, acct := range accts {
+		res[i] = &AccountRes{Address: common.ToHex(acct.Address), Label: acct.Label}
+	}
+	return res
+}
+
+// TxPoolStatusRes holds the number of transactions in the pool
+type TxPoolStatusRes struct {
+	Pending *hexnum `json:"pending"`
+	Queued  *hexnum `json:"queued"`
+}
+
+// TxPoolRes holds transactions in the pool keyed by sender address and nonce
+type TxPoolRes struct {
+	Pending map[string]map[string]interface{} `json:"pending"`
+	Queued  map[string]map[string]interface{} `json:"queued"`
+}
+
+// NewTxPoolContentRes returns the full transactions of the pool
+func NewTxPoolContentRes(pending, queued map[common.Address]map[uint64]*types.Transaction) *TxPoolRes {
+	return newTxPoolRes(pending, queued, func(tx *types.Transaction) interface{} {
+		return NewTransactionRes(tx)
+	})
+}
+
+// NewTxPoolInspectRes returns a textual summary of each transaction of the pool
+func NewTxPoolInspectRes(pending, queued map[common.Address]map[uint64]*types.Transaction) *TxPoolRes {
+	return newTxPoolRes(pending, queued, func(tx *types.Transaction) interface{} {
+		to := "contract creation"
+		if tx.To() != nil {
+			to = tx.To().Hex()
+		}
+		return fmt.Sprintf("%s: %v wei + %v gas × %v wei", to, tx.Value(), tx.Gas(), tx.GasPrice())
+	})
+}
+
+func newTxPoolRes(pending, queued map[common.Address]map[uint64]*types.Transaction, format func(*types.Transaction) interface{}) *TxPoolRes {
+	group := func(txs map[common.Address]map[uint64]*types.Transaction) map[string]map[string]interface{} {
+		res := make(map[string]map[string]interface{})
+		for from, nonces := range txs {
+			res[from.Hex()] = make(map[string]interface{})
+			for nonce, tx := range nonces {
+				res[from.Hex()][strconv.FormatUint(nonce, 10)] = format(tx)
+			}
+		}
+		return res
+	}
+	return &TxPoolRes{Pending: group(pending), Queued: group(queued)}
+}
@@ -95,6 +95,50 @@ func TestNewBlockResTxFull(t *testing.T) {
 	}
 }
 
+func TestBlockResTransactions(t *testing.T) {
+	block := makeBlock()
+	hash := block.Transactions()[0].Hash().Hex()
+
+	j, _ := json.Marshal(NewBlockRes(block, false))
+	var hashes struct {
+		Transactions []string `json:"transactions"`
+	}
+	if err := json.Unmarshal(j, &hashes); err != nil {
+		t.Fatalf("transactions are not rendered as hashes: %v", err)
+	}
+	if len(hashes.Transactions) != 1 || hashes.Transactions[0] != hash {
+		t.Errorf("transactions should be [%s] but are %v", hash, hashes.Transactions)
+	}
+
+	j, _ = json.Marshal(NewBlockRes(block, true))
+	var full struct {
+		Transactions []map[string]interface{} `json:"transactions"`
+	}
+	if err := json.Unmarshal(j, &full); err != nil {
+		t.Fatalf("transactions are not rendered as objects: %v", err)
+	}
+	if len(full.Transactions) != 1 {
+		t.Fatalf("expected 1 transaction but got %d", len(full.Transactions))
+	}
+	tx := full.Transactions[0]
+	if tx["hash"] != hash {
+		t.Errorf("hash should be %s but is %v", hash, tx["hash"])
+	}
+	expected := map[string]string{
+		"nonce":            "0x0",
+		"blockNumber":      "0x0",
+		"transactionIndex": "0x0",
+		"value":            "0x1",
+		"gas":              "0x1",
+		"gasPrice":         "0x1",
+	}
+	for k, v := range expected {
+		if tx[k] != v {
+			t.Errorf("%s should be %s but is %v", k, v, tx[k])
+		}
+	}
+}
+
 func TestBlockNil(t *testing.T) {
 	var block *types.Block
 	block = nil
@@ -151,6 +195,71 @@ func TestTransactionNil(t *testing.T) {
 	}
 }
 
+func TestNewReceiptRes(t *testing.T) {
+	tx := types.NewContractCreationTx(big.NewInt(0), big.NewInt(100000), big.NewInt(1), []byte{1, 2, 3})
+	receipt := types.NewReceipt(nil, big.NewInt(53000))
+	receipt.SetGasUsed(big.NewInt(21000))
+	receipt.SetFailed(true)
+
+	tests := map[string]string{
+		"transactionHash":   reHash,
+		"transactionIndex":  reNum,
+		"blockHash":         reHash,
+		"blockNumber":       reNum,
+		"cumulativeGasUsed": reNumNonZero,
+		"gasUsed":           reNumNonZero,
+		"contractAddress":   reAddress,
+		"status":            `"0x0"`,
+	}
+
+	v := NewReceiptRes(tx, receipt)
+	v.BlockHash = newHexData(common.HexToHash("0x030201"))
+	v.BlockNumber = newHexNum(5)
+	v.TxIndex = newHexNum(1)
+	j, _ := json.Marshal(v)
+	for k, re := range tests {
+		match, _ := regexp.MatchString(fmt.Sprintf(`{.*"%s":%s.*}`, k, re), string(j))
+		if !match {
+			t.Error(fmt.Sprintf("`%s` output json does not match format %s. Source %s", k, re, j))
+		}
+	}
+}
+
+func TestReceiptNil(t *testing.T) {
+	u := NewReceiptRes(nil, nil)
+	j, _ := json.Marshal(u)
+	if string(j) != "null" {
+		t.Errorf("Expected null but got %v", string(j))
+	}
+}
+
+func TestNewBlockReceiptsRes(t *testing.T) {
+	block := makeBlock()
+	receipt := types.NewReceipt(nil, big.NewInt(21000))
+	receipt.SetGasUsed(big.NewInt(21000))
+	receipt.SetLogs(state.Logs{makeStateLog(0)})
+
+	res := NewBlockReceiptsRes(block, types.Receipts{receipt})
+	if len(res) != 1 {
+		t.Fatalf("got %d receipts, want 1", len(res))
+	}
+	j, _ := json.Marshal(res[0])
+	tests := map[string]string{
+		"transactionHash":  fmt.Sprintf(`"%s"`, block.Transactions()[0].Hash().Hex()),
+		"transactionIndex": `"0x0"`,
+		"blockHash":        fmt.Sprintf(`"%s"`, block.Hash().Hex()),
+		"blockNumber":      reNum,
+		"status":           `"0x1"`,
+		"logs":             `\[{"address":` + reAddress,
+	}
+	for k, re := range tests {
+		match, _ := regexp.MatchString(fmt.Sprintf(`{.*"%s":%s.*}`, k, re), string(j))
+		if !match {
+			t.Error(fmt.Sprintf("`%s` output json does not match format %s. Source %s", k, re, j))
+		}
+	}
+}
+
 func TestNewUncleRes(t *testing.T) {
 	header := makeHeader()
 	u := NewUncleRes(header)
@@ -216,6 +325,17 @@ func TestNewLogRes(t *testing.T) {
 
 }
 
+func TestNewLogResRemoved(t *testing.T) {
+	log := makeStateLog(0)
+	if j, _ := json.Marshal(NewLogRes(log)); !regexp.MustCompile(`"removed":false`).Match(j) {
+		t.Errorf("expected removed false, got %s", j)
+	}
+	log.Removed = true
+	if j, _ := json.Marshal(NewLogRes(log)); !regexp.MustCompile(`"removed":true`).Match(j) {
+		t.Errorf("expected removed true, got %s", j)
+	}
+}
+
 func TestNewLogsRes(t *testing.T) {
 	logs := make([]*state.Log, 3)
 	logs[0] = makeStateLog(1)
@@ -274,7 +394,16 @@ func makeBlock() *types.Block {
 	root := common.HexToHash("0x01")
 	difficulty := common.Big1
 	nonce := uint64(1)
-	block := types.NewBlock(parentHash, coinbase, root, difficulty, nonce, nil)
+	header := &types.Header{
+		ParentHash: parentHash,
+		Coinbase:   coinbase,
+		Root:       root,
+		Difficulty: difficulty,
+		Number:     new(big.Int),
+		GasLimit:   new(big.Int),
+		GasUsed:    new(big.Int),
+	}
+	header.SetNonce(nonce)
 
 	txto := common.HexToAddress("0x02")
 	txamount := big.NewInt(1)
@@ -285,11 +414,26 @@ func makeBlock() *types.Block {
 	tx := types.NewTransactionMessage(txto, txamount, txgasAmount, txgasPrice, txdata)
 	txs := make([]*types.Transaction, 1)
 	txs[0] = tx
-	block.SetTransactions(txs)
 
 	uncles := make([]*types.Header, 1)
 	uncles[0] = makeHeader()
-	block.SetUncles(uncles)
 
-	return block
+	return types.NewBlock(header, txs, uncles, nil)
+}
+
+func TestNewTxPoolInspectRes(t *testing.T) {
+	from := common.HexToAddress("0x01")
+	to := common.HexToAddress("0x02")
+	tx := types.NewTransactionMessage(to, big.NewInt(1), big.NewInt(2), big.NewInt(3), nil)
+	create := types.NewContractCreationTx(big.NewInt(0), big.NewInt(2), big.NewInt(3), nil)
+
+	pending := map[common.Address]map[uint64]*types.Transaction{from: {0: tx}}
+	queued := map[common.Address]map[uint64]*types.Transaction{from: {5: create}}
+	j, _ := json.Marshal(NewTxPoolInspectRes(pending, queued))
+
+	exp := `{"pending":{"0x0000000000000000000000000000000000000001":{"0":"0x0000000000000000000000000000000000000002: 1 wei + 2 gas × 3 wei"}},` +
+		`"queued":{"0x0000000000000000000000000000000000000001":{"5":"contract creation: 0 wei + 2 gas × 3 wei"}}}`
+	if string(j) != exp {
+		t.Errorf("output json does not match. Expected %s, got %s", exp, j)
+	}
 }
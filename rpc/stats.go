@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// LogRequests enables logging of every RPC request.
+var LogRequests bool
+
+// SlowCallThreshold is the duration after which a call is logged as slow.
+// Zero disables slow call tracing.
+var SlowCallThreshold time.Duration
+
+// unknownMethod is the statistics key of calls to methods which don't
+// exist, so that clients can't grow the statistics without bound.
+const unknownMethod = "unknown"
+
+// MethodStats holds the call counters of an RPC method.
+type MethodStats struct {
+	Calls  uint64        `json:"calls"`
+	Errors uint64        `json:"errors"`
+	Slow   uint64        `json:"slow"`
+	Time   time.Duration `json:"time"` // total, in nanoseconds
+}
+
+var (
+	statsMu     sync.Mutex
+	methodStats = make(map[string]*MethodStats)
+)
+
+// recordCall adds a call of method to the statistics.
+func recordCall(method string, elapsed time.Duration, failed, slow bool) {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	s := methodStats[method]
+	if s == nil {
+		s = new(MethodStats)
+		methodStats[method] = s
+	}
+	s.Calls++
+	s.Time += elapsed
+	if failed {
+		s.Errors++
+	}
+	if slow {
+		s.Slow++
+	}
+}
+
+// Stats returns a copy of the statistics collected since the last reset,
+// keyed by method name.
+func Stats() map[string]MethodStats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	stats := make(map[string]MethodStats, len(methodStats))
+	for method, s := range methodStats {
+		stats[method] = *s
+	}
+	return stats
+}
+
+// ResetStats discards all collected statistics.
+func ResetStats() {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	methodStats = make(map[string]*MethodStats)
+}
+
+// callAPI executes the request, recording it in the statistics and logging
+// it if enabled. origin describes the client for the log.
+func callAPI(api *EthereumApi, req *RpcRequest, reply *interface{}, origin string) error {
+	start := time.Now()
+	err := api.GetRequestReply(req, reply)
+	elapsed := time.Since(start)
+
+	method := req.Method
+	if _, ok := err.(*NotImplementedError); ok {
+		method = unknownMethod
+	}
+	slow := SlowCallThreshold > 0 && elapsed > SlowCallThreshold
+	recordCall(method, elapsed, err != nil, slow)
+
+	switch {
+	case slow:
+		glog.V(logger.Warn).Infof("Slow RPC call method=%s duration=%v error=%v origin=%s params=%s", req.Method, elapsed, err, origin, req.Params)
+	case LogRequests:
+		glog.V(logger.Info).Infof("RPC call method=%s duration=%v error=%v origin=%s", req.Method, elapsed, err, origin)
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCallStats(t *testing.T) {
+	ResetStats()
+	defer ResetStats()
+
+	api := &EthereumApi{}
+	for _, jsonstr := range []string{
+		`{"jsonrpc":"2.0","method":"web3_sha3","params":["0x68656c6c6f"],"id":1}`,
+		`{"jsonrpc":"2.0","method":"web3_sha3","params":[],"id":2}`,
+		`{"jsonrpc":"2.0","method":"foo_bar","params":[],"id":3}`,
+	} {
+		var req RpcRequest
+		json.Unmarshal([]byte(jsonstr), &req)
+		var reply interface{}
+		callAPI(api, &req, &reply, "test")
+	}
+
+	stats := Stats()
+	if s := stats["web3_sha3"]; s.Calls != 2 || s.Errors != 1 {
+		t.Errorf("web3_sha3: got %d calls and %d errors, want 2 and 1", s.Calls, s.Errors)
+	}
+	if s := stats[unknownMethod]; s.Calls != 1 || s.Errors != 1 {
+		t.Errorf("unknown methods: got %d calls and %d errors, want 1 and 1", s.Calls, s.Errors)
+	}
+	if _, ok := stats["foo_bar"]; ok {
+		t.Error("unknown method recorded under its name")
+	}
+}
@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"crypto/tls"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -30,7 +31,9 @@ import (
 
 	"io"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -161,6 +164,34 @@ type RpcConfig struct {
 	ListenAddress string
 	ListenPort    uint
 	CorsDomain    string
+	Workers       int  // requests executed at the same time, DefaultWorkers if 0
+	ReadOnly      bool // serve only methods without side effects
+
+	// AuthModules are the modules whose methods can only be called with
+	// AuthSecret in the Authorization header of the request.
+	AuthModules []string
+	AuthSecret  string
+
+	// CertFile and KeyFile are the PEM encoded certificate and private
+	// key to serve HTTPS with. Plain HTTP is served if both are empty.
+	CertFile string
+	KeyFile  string
+}
+
+// tlsConfig loads the TLS certificate of the config. It returns nil if no
+// certificate is configured.
+func (config *RpcConfig) tlsConfig() (*tls.Config, error) {
+	if config.CertFile == "" && config.KeyFile == "" {
+		return nil, nil
+	}
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, errors.New("both certificate and key file are required for TLS")
+	}
+	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
 }
 
 type InvalidTypeError struct {
@@ -240,6 +271,108 @@ func NewValidationError(param string, msg string) error {
 	}
 }
 
+// ExecutionError is returned when a call fails in the EVM. Data holds the
+// output of the call up to the failure, if any.
+type ExecutionError struct {
+	msg  string
+	Data interface{}
+}
+
+func (e *ExecutionError) Error() string {
+	return fmt.Sprintf("execution error: %s", e.msg)
+}
+
+func NewExecutionError(err error, data interface{}) error {
+	return &ExecutionError{
+		msg:  err.Error(),
+		Data: data,
+	}
+}
+
+// JSON-RPC 2.0 error codes.
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
+// JSON-RPC error codes for failed calls and rejected transactions, taken
+// from the range reserved for implementation defined server errors.
+const (
+	ErrCodeInsufficientFunds = -32010
+	ErrCodeNonceTooLow       = -32011
+	ErrCodeIntrinsicGas      = -32012
+	ErrCodeExecution         = -32015
+	ErrCodeAccountLocked     = -32020
+	ErrCodeNotConfirmed      = -32021
+	ErrCodeUnauthorized      = -32030
+)
+
+// ErrNotConfirmed is returned when the frontend rejects a transaction.
+var ErrNotConfirmed = &TransactionError{Code: ErrCodeNotConfirmed, err: errors.New("Transaction not confirmed")}
+
+// TransactionError is returned when a transaction is rejected by the sender
+// checks or the transaction pool.
+type TransactionError struct {
+	Code int
+	err  error
+}
+
+func (e *TransactionError) Error() string {
+	return e.err.Error()
+}
+
+// NewTransactionError wraps the known transaction rejection errors with
+// their error code. Other errors are returned unchanged.
+func NewTransactionError(err error) error {
+	var code int
+	switch err {
+	case core.ErrInsufficientFunds, core.ErrNonExistentAccount:
+		code = ErrCodeInsufficientFunds
+	case core.ErrImpossibleNonce:
+		code = ErrCodeNonceTooLow
+	case core.ErrIntrinsicGas:
+		code = ErrCodeIntrinsicGas
+	case accounts.ErrLocked:
+		code = ErrCodeAccountLocked
+	default:
+		return err
+	}
+	return &TransactionError{Code: code, err: err}
+}
+
+// errorCode returns the JSON-RPC error code reported for err.
+func errorCode(err error) int {
+	switch e := err.(type) {
+	case *NotImplementedError, *NotAvailableError:
+		return ErrCodeMethodNotFound
+	case *DecodeParamError, *InsufficientParamsError, *ValidationError, *InvalidTypeError:
+		return ErrCodeInvalidParams
+	case *json.SyntaxError, *json.UnmarshalTypeError:
+		// params which don't fit the argument type of the method
+		return ErrCodeInvalidParams
+	case *ExecutionError:
+		return ErrCodeExecution
+	case *UnauthorizedError:
+		return ErrCodeUnauthorized
+	case *TransactionError:
+		return e.Code
+	default:
+		return ErrCodeInternal
+	}
+}
+
+// newErrorObject returns the error object of a response failing with err.
+func newErrorObject(err error) *RpcErrorObject {
+	obj := &RpcErrorObject{Code: errorCode(err), Message: err.Error()}
+	if e, ok := err.(*ExecutionError); ok {
+		obj.Data = e.Data
+	}
+	return obj
+}
+
 type RpcRequest struct {
 	Id      interface{}     `json:"id"`
 	Jsonrpc string          `json:"jsonrpc"`
@@ -260,9 +393,9 @@ type RpcErrorResponse struct {
 }
 
 type RpcErrorObject struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	// Data    interface{} `json:"data"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 type listenerHasStoppedError struct {
@@ -289,7 +422,7 @@ func newStoppableHandler(h http.Handler, stop chan struct{}) http.Handler {
 		select {
 		case <-stop:
 			w.Header().Set("Content-Type", "application/json")
-			jsonerr := &RpcErrorObject{-32603, "RPC service stopped"}
+			jsonerr := &RpcErrorObject{Code: ErrCodeInternal, Message: "RPC service stopped"}
 			send(w, &RpcErrorResponse{Jsonrpc: jsonrpcver, Id: nil, Error: jsonerr})
 		default:
 			h.ServeHTTP(w, r)
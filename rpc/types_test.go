@@ -6,7 +6,9 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -55,6 +57,66 @@ func TestValidationError(t *testing.T) {
 	}
 }
 
+func TestTransactionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{core.ErrInsufficientFunds, ErrCodeInsufficientFunds},
+		{core.ErrImpossibleNonce, ErrCodeNonceTooLow},
+		{core.ErrIntrinsicGas, ErrCodeIntrinsicGas},
+		{accounts.ErrLocked, ErrCodeAccountLocked},
+		{core.ErrInvalidSender, ErrCodeInternal},
+		{NewValidationError("to", "is not a valid address"), ErrCodeInvalidParams},
+	}
+	for _, test := range tests {
+		err := NewTransactionError(test.err)
+		if err.Error() != test.err.Error() {
+			t.Errorf("message mismatch, expected %q, got %q", test.err, err)
+		}
+		if code := errorCode(err); code != test.code {
+			t.Errorf("%v: expected code %d, got %d", test.err, test.code, code)
+		}
+	}
+}
+
+func TestErrorObject(t *testing.T) {
+	var syntaxErr, typeErr error
+	syntaxErr = json.Unmarshal([]byte("[1,"), new([]int))
+	typeErr = json.Unmarshal([]byte(`"foo"`), new(int))
+
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{NewNotImplementedError("foo"), ErrCodeMethodNotFound},
+		{NewInsufficientParamsError(0, 1), ErrCodeInvalidParams},
+		{syntaxErr, ErrCodeInvalidParams},
+		{typeErr, ErrCodeInvalidParams},
+		{ErrNotConfirmed, ErrCodeNotConfirmed},
+		{NewExecutionError(core.ErrInvalidSender, nil), ErrCodeExecution},
+	}
+	for _, test := range tests {
+		obj := newErrorObject(test.err)
+		if obj.Code != test.code {
+			t.Errorf("%v: expected code %d, got %d", test.err, test.code, obj.Code)
+		}
+		if obj.Message != test.err.Error() {
+			t.Errorf("message mismatch, expected %q, got %q", test.err, obj.Message)
+		}
+	}
+
+	obj := newErrorObject(NewExecutionError(core.ErrInvalidSender, newHexData([]byte{1, 2})))
+	enc, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"code":-32015,"message":"execution error: Invalid sender","data":"0x0102"}`
+	if string(enc) != expected {
+		t.Errorf("expected %s, got %s", expected, enc)
+	}
+}
+
 func TestHexdataMarshalNil(t *testing.T) {
 	hd := newHexData([]byte{})
 	hd.isNil = true
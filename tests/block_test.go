@@ -0,0 +1,41 @@
+package tests
+
+import "testing"
+
+func runBlockTestFile(t *testing.T, file string) {
+	if err := RunBlockTests(file); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBcValidBlockTests(t *testing.T) {
+	runBlockTestFile(t, "./files/BlockTests/bcValidBlockTest.json")
+}
+
+func TestBcUncleTests(t *testing.T) {
+	runBlockTestFile(t, "./files/BlockTests/bcUncleTest.json")
+}
+
+func TestBcUncleHeaderValidityTests(t *testing.T) {
+	runBlockTestFile(t, "./files/BlockTests/bcUncleHeaderValiditiy.json")
+}
+
+func TestBcInvalidRLPTests(t *testing.T) {
+	runBlockTestFile(t, "./files/BlockTests/bcInvalidRLPTest.json")
+}
+
+func TestBcInvalidHeaderTests(t *testing.T) {
+	runBlockTestFile(t, "./files/BlockTests/bcInvalidHeaderTest.json")
+}
+
+func TestBcForkBlockTests(t *testing.T) {
+	runBlockTestFile(t, "./files/BlockTests/bcForkBlockTest.json")
+}
+
+func TestBcJSAPITests(t *testing.T) {
+	runBlockTestFile(t, "./files/BlockTests/bcJS_API_Test.json")
+}
+
+func TestBcRPCAPITests(t *testing.T) {
+	runBlockTestFile(t, "./files/BlockTests/bcRPC_API_Test.json")
+}
@@ -11,9 +11,13 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -75,7 +79,9 @@ type BlockTest struct {
 	Genesis *types.Block
 	Blocks  []*types.Block
 
-	preAccounts map[string]btAccount
+	json         *btJSON
+	preAccounts  map[string]btAccount
+	postAccounts map[string]btAccount
 }
 
 // LoadBlockTests loads a block test JSON file.
@@ -88,7 +94,7 @@ func LoadBlockTests(file string) (map[string]*BlockTest, error) {
 	for name, in := range bt {
 		var err error
 		if out[name], err = convertTest(in); err != nil {
-			return nil, fmt.Errorf("bad test %q: %v", err)
+			return nil, fmt.Errorf("bad test %q: %v", name, err)
 		}
 	}
 	return out, nil
@@ -103,7 +109,7 @@ func (t *BlockTest) InsertPreState(db common.Database) (*state.StateDB, error) {
 		//addr, _ := hex.DecodeString(addrString)
 		code, _ := hex.DecodeString(strings.TrimPrefix(acct.Code, "0x"))
 		balance, _ := new(big.Int).SetString(acct.Balance, 0)
-		nonce, _ := strconv.ParseUint(acct.Nonce, 16, 64)
+		nonce, _ := strconv.ParseUint(acct.Nonce, 0, 64)
 
 		obj := statedb.CreateAccount(common.HexToAddress(addrString))
 		obj.SetCode(code)
@@ -124,13 +130,48 @@ func (t *BlockTest) InsertPreState(db common.Database) (*state.StateDB, error) {
 	return statedb, nil
 }
 
+// TryBlocksInsert inserts the test blocks into the chain one by one.
+// Blocks without a header in the test are expected to be rejected, either
+// while decoding or on insertion.
+func (t *BlockTest) TryBlocksInsert(chainManager *core.ChainManager) error {
+	for i, b := range t.json.Blocks {
+		block, err := convertBlock(b)
+		if err == nil {
+			err = chainManager.InsertChain(types.Blocks{block})
+		}
+		switch {
+		case err != nil && b.BlockHeader != nil:
+			return fmt.Errorf("block %d: unexpected failure: %v", i, err)
+		case err == nil && b.BlockHeader == nil:
+			return fmt.Errorf("block %d: insertion should have failed", i)
+		}
+	}
+	return nil
+}
+
+// ValidateHead checks that the chain ends at the highest valid block of
+// the test. The post state of tests containing invalid blocks assumes the
+// invalid blocks were applied and can't be used to validate the chain.
+func (t *BlockTest) ValidateHead(chainManager *core.ChainManager) error {
+	want := t.Genesis
+	for _, block := range t.Blocks {
+		if block.NumberU64() > want.NumberU64() {
+			want = block
+		}
+	}
+	if head := chainManager.CurrentBlock(); head.Hash() != want.Hash() {
+		return fmt.Errorf("chain head mismatch: have #%d %x, want #%d %x", head.NumberU64(), head.Hash().Bytes()[:4], want.NumberU64(), want.Hash().Bytes()[:4])
+	}
+	return nil
+}
+
 func (t *BlockTest) ValidatePostState(statedb *state.StateDB) error {
-	for addrString, acct := range t.preAccounts {
+	for addrString, acct := range t.postAccounts {
 		// XXX: is is worth it checking for errors here?
 		addr, _ := hex.DecodeString(addrString)
 		code, _ := hex.DecodeString(strings.TrimPrefix(acct.Code, "0x"))
 		balance, _ := new(big.Int).SetString(acct.Balance, 0)
-		nonce, _ := strconv.ParseUint(acct.Nonce, 16, 64)
+		nonce, _ := strconv.ParseUint(acct.Nonce, 0, 64)
 
 		// address is indirectly verified by the other fields, as it's the db key
 		code2 := statedb.GetCode(common.BytesToAddress(addr))
@@ -160,7 +201,7 @@ func convertTest(in *btJSON) (out *BlockTest, err error) {
 			err = fmt.Errorf("%v\n%s", recovered, buf)
 		}
 	}()
-	out = &BlockTest{preAccounts: in.Pre}
+	out = &BlockTest{json: in, preAccounts: in.Pre, postAccounts: in.PostState}
 	out.Genesis = mustConvertGenesis(in.GenesisBlockHeader)
 	out.Blocks = mustConvertBlocks(in.Blocks)
 	return out, err
@@ -197,19 +238,38 @@ func mustConvertHeader(in btHeader) *types.Header {
 	return header
 }
 
+// mustConvertBlocks decodes the blocks which the test considers valid.
 func mustConvertBlocks(testBlocks []btBlock) []*types.Block {
 	var out []*types.Block
 	for i, inb := range testBlocks {
-		var b types.Block
-		r := bytes.NewReader(mustConvertBytes(inb.Rlp))
-		if err := rlp.Decode(r, &b); err != nil {
+		if inb.BlockHeader == nil {
+			continue
+		}
+		b, err := convertBlock(inb)
+		if err != nil {
 			panic(fmt.Errorf("invalid block %d: %q", i, inb.Rlp))
 		}
-		out = append(out, &b)
+		out = append(out, b)
 	}
 	return out
 }
 
+func convertBlock(testBlock btBlock) (*types.Block, error) {
+	var b types.Block
+	raw, err := hex.DecodeString(strings.TrimPrefix(testBlock.Rlp, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	r := bytes.NewReader(raw)
+	if err := rlp.Decode(r, &b); err != nil {
+		return nil, err
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("%d trailing bytes after block", r.Len())
+	}
+	return &b, nil
+}
+
 func mustConvertBytes(in string) []byte {
 	if in == "0x" {
 		return []byte{}
@@ -296,8 +356,70 @@ func unfuckCPPHexInts(s string) string {
 	if s == "0x" { // no respect for the empty value :(
 		return "0x00"
 	}
+	if !strings.HasPrefix(s, "0x") { // decimal, nothing to fix
+		return s
+	}
 	if (len(s) % 2) != 0 { // motherfucking nibbles
 		return "0x0" + s[2:]
 	}
 	return s
 }
+
+// RunBlockTests runs all block tests contained in file against an
+// in-memory chain.
+func RunBlockTests(file string) error {
+	bt, err := LoadBlockTests(file)
+	if err != nil {
+		return err
+	}
+	// The ethash verification cache only depends on the block number, so
+	// all tests of the file share a single instance.
+	var pow *ethash.Ethash
+	for name, test := range bt {
+		if err := runBlockTest(test, &pow); err != nil {
+			return fmt.Errorf("bad test %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// RunBlockTest runs the block test called name from file.
+func RunBlockTest(file, name string) error {
+	bt, err := LoadBlockTests(file)
+	if err != nil {
+		return err
+	}
+	test, ok := bt[name]
+	if !ok {
+		return fmt.Errorf("test file does not contain test named %q", name)
+	}
+	var pow *ethash.Ethash
+	return runBlockTest(test, &pow)
+}
+
+func runBlockTest(test *BlockTest, pow **ethash.Ethash) error {
+	db, _ := ethdb.NewMemDatabase()
+	mux := new(event.TypeMux)
+	chainManager := core.NewChainManager(db, db, mux)
+	defer chainManager.Stop()
+
+	chainManager.ResetWithGenesisBlock(test.Genesis)
+	if _, err := test.InsertPreState(db); err != nil {
+		return fmt.Errorf("could not insert genesis accounts: %v", err)
+	}
+
+	if *pow == nil {
+		*pow = ethash.New(chainManager)
+	}
+	txPool := core.NewTxPool(mux, chainManager.State)
+	processor := core.NewBlockProcessor(db, db, *pow, txPool, chainManager, mux)
+	chainManager.SetProcessor(processor)
+
+	if err := test.TryBlocksInsert(chainManager); err != nil {
+		return err
+	}
+	if len(test.Blocks) < len(test.json.Blocks) {
+		return test.ValidateHead(chainManager)
+	}
+	return test.ValidatePostState(chainManager.State())
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 type Env struct {
@@ -66,6 +67,7 @@ func (self *Env) VmType() vm.Type          { return vm.StdVmTy }
 func (self *Env) GetHash(n uint64) common.Hash {
 	return common.BytesToHash(crypto.Sha3([]byte(big.NewInt(int64(n)).String())))
 }
+func (self *Env) ChainConfig() *params.ChainConfig { return params.DefaultChainConfig }
 func (self *Env) AddLog(log *state.Log) {
 	self.logs = append(self.logs, log)
 }
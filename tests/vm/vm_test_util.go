@@ -0,0 +1,211 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/tests/helper"
+)
+
+type Account struct {
+	Balance string
+	Code    string
+	Nonce   string
+	Storage map[string]string
+}
+
+type Log struct {
+	AddressF string   `json:"address"`
+	DataF    string   `json:"data"`
+	TopicsF  []string `json:"topics"`
+	BloomF   string   `json:"bloom"`
+}
+
+func (self Log) Address() []byte      { return common.Hex2Bytes(self.AddressF) }
+func (self Log) Data() []byte         { return common.Hex2Bytes(self.DataF) }
+func (self Log) RlpData() interface{} { return nil }
+func (self Log) Topics() [][]byte {
+	t := make([][]byte, len(self.TopicsF))
+	for i, topic := range self.TopicsF {
+		t[i] = common.Hex2Bytes(topic)
+	}
+	return t
+}
+
+func StateObjectFromAccount(db common.Database, addr string, account Account) *state.StateObject {
+	obj := state.NewStateObject(common.HexToAddress(addr), db)
+	obj.SetBalance(common.Big(account.Balance))
+
+	if common.IsHex(account.Code) {
+		account.Code = account.Code[2:]
+	}
+	obj.SetCode(common.Hex2Bytes(account.Code))
+	obj.SetNonce(common.Big(account.Nonce).Uint64())
+
+	return obj
+}
+
+type Env struct {
+	CurrentCoinbase   string
+	CurrentDifficulty string
+	CurrentGasLimit   string
+	CurrentNumber     string
+	CurrentTimestamp  interface{}
+	PreviousHash      string
+}
+
+type VmTest struct {
+	Callcreates interface{}
+	//Env         map[string]string
+	Env           Env
+	Exec          map[string]string
+	Transaction   map[string]string
+	Logs          []Log
+	Gas           string
+	Out           string
+	Post          map[string]Account
+	Pre           map[string]Account
+	PostStateRoot string
+}
+
+// LoadTests reads the VM or state tests contained in file.
+func LoadTests(file string) (map[string]VmTest, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tests := make(map[string]VmTest)
+	if err := json.Unmarshal(content, &tests); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return tests, nil
+}
+
+// RunTest executes a VM test, or a state test if it has no exec section,
+// and compares the result with the expected output, gas, post state and logs.
+func RunTest(test VmTest) error {
+	db, _ := ethdb.NewMemDatabase()
+	statedb := state.New(common.Hash{}, db)
+	for addr, account := range test.Pre {
+		obj := StateObjectFromAccount(db, addr, account)
+		statedb.SetStateObject(obj)
+		for a, v := range account.Storage {
+			obj.SetState(common.HexToHash(a), common.NewValue(helper.FromHex(v)))
+		}
+	}
+
+	// XXX Yeah, yeah...
+	env := make(map[string]string)
+	env["currentCoinbase"] = test.Env.CurrentCoinbase
+	env["currentDifficulty"] = test.Env.CurrentDifficulty
+	env["currentGasLimit"] = test.Env.CurrentGasLimit
+	env["currentNumber"] = test.Env.CurrentNumber
+	env["previousHash"] = test.Env.PreviousHash
+	if n, ok := test.Env.CurrentTimestamp.(float64); ok {
+		env["currentTimestamp"] = strconv.Itoa(int(n))
+	} else {
+		env["currentTimestamp"] = test.Env.CurrentTimestamp.(string)
+	}
+
+	var (
+		ret  []byte
+		gas  *big.Int
+		err  error
+		logs state.Logs
+	)
+
+	isVmTest := len(test.Exec) > 0
+	if isVmTest {
+		ret, logs, gas, err = helper.RunVm(statedb, env, test.Exec)
+	} else {
+		ret, logs, gas, err = helper.RunState(statedb, env, test.Transaction)
+	}
+
+	rexp := helper.FromHex(test.Out)
+	if bytes.Compare(rexp, ret) != 0 {
+		return fmt.Errorf("return failed. Expected %x, got %x", rexp, ret)
+	}
+
+	if isVmTest {
+		if len(test.Gas) == 0 && err == nil {
+			return fmt.Errorf("gas unspecified, indicating an error. VM returned (incorrectly) successfull")
+		} else {
+			gexp := common.Big(test.Gas)
+			if gexp.Cmp(gas) != 0 {
+				return fmt.Errorf("gas failed. Expected %v, got %v", gexp, gas)
+			}
+		}
+	}
+
+	for addr, account := range test.Post {
+		obj := statedb.GetStateObject(common.HexToAddress(addr))
+		if obj == nil {
+			continue
+		}
+
+		if len(test.Exec) == 0 {
+			if obj.Balance().Cmp(common.Big(account.Balance)) != 0 {
+				return fmt.Errorf("(%x) balance failed. Expected %v, got %v => %v", obj.Address().Bytes()[:4], account.Balance, obj.Balance(), new(big.Int).Sub(common.Big(account.Balance), obj.Balance()))
+			}
+
+			if obj.Nonce() != common.String2Big(account.Nonce).Uint64() {
+				return fmt.Errorf("(%x) nonce failed. Expected %v, got %v", obj.Address().Bytes()[:4], account.Nonce, obj.Nonce())
+			}
+
+		}
+
+		for addr, value := range account.Storage {
+			v := obj.GetState(common.HexToHash(addr)).Bytes()
+			vexp := helper.FromHex(value)
+
+			if bytes.Compare(v, vexp) != 0 {
+				return fmt.Errorf("(%x: %s) storage failed. Expected %x, got %x (%v %v)", obj.Address().Bytes()[0:4], addr, vexp, v, common.BigD(vexp), common.BigD(v))
+			}
+		}
+	}
+
+	if !isVmTest {
+		statedb.Sync()
+		if common.HexToHash(test.PostStateRoot) != statedb.Root() {
+			return fmt.Errorf("post state root error. Expected %s, got %x", test.PostStateRoot, statedb.Root())
+		}
+	}
+
+	if len(test.Logs) > 0 {
+		if len(test.Logs) != len(logs) {
+			return fmt.Errorf("log length mismatch. Expected %d, got %d", len(test.Logs), len(logs))
+		}
+		for i, log := range test.Logs {
+			if common.HexToAddress(log.AddressF) != logs[i].Address {
+				return fmt.Errorf("log address expected %v got %x", log.AddressF, logs[i].Address)
+			}
+
+			if !bytes.Equal(logs[i].Data, helper.FromHex(log.DataF)) {
+				return fmt.Errorf("log data expected %v got %x", log.DataF, logs[i].Data)
+			}
+
+			if len(log.TopicsF) != len(logs[i].Topics) {
+				return fmt.Errorf("log topics length expected %d got %d", len(log.TopicsF), len(logs[i].Topics))
+			}
+			for j, topic := range log.TopicsF {
+				if common.HexToHash(topic) != logs[i].Topics[j] {
+					return fmt.Errorf("log topic[%d] expected %v got %x", j, topic, logs[i].Topics[j])
+				}
+			}
+			genBloom := common.LeftPadBytes(types.LogsBloom(state.Logs{logs[i]}).Bytes(), 256)
+
+			if !bytes.Equal(genBloom, common.Hex2Bytes(log.BloomF)) {
+				return fmt.Errorf("bloom mismatch")
+			}
+		}
+	}
+	return nil
+}
@@ -59,6 +59,16 @@ func (self *Envelope) Seal(pow time.Duration) {
 	}
 }
 
+// PoW returns the number of leading zero bits of the proof of work hash, as
+// maximised by Seal.
+func (self *Envelope) PoW() int {
+	d := make([]byte, 64)
+	copy(d[:32], self.rlpWithoutNonce())
+	binary.BigEndian.PutUint32(d[60:], self.Nonce)
+
+	return common.FirstBitSet(common.BigD(crypto.Sha3(d)))
+}
+
 // rlpWithoutNonce returns the RLP encoded envelope contents, except the nonce.
 func (self *Envelope) rlpWithoutNonce() []byte {
 	enc, _ := rlp.EncodeToBytes([]interface{}{self.Expiry, self.TTL, self.Topics, self.Data})
@@ -67,22 +77,10 @@ func (self *Envelope) rlpWithoutNonce() []byte {
 
 // Open extracts the message contained within a potentially encrypted envelope.
 func (self *Envelope) Open(key *ecdsa.PrivateKey) (msg *Message, err error) {
-	// Split open the payload into a message construct
-	data := self.Data
-
-	message := &Message{
-		Flags: data[0],
-	}
-	data = data[1:]
-
-	if message.Flags&signatureFlag == signatureFlag {
-		if len(data) < signatureLength {
-			return nil, fmt.Errorf("unable to open envelope. First bit set but len(data) < len(signature)")
-		}
-		message.Signature, data = data[:signatureLength], data[signatureLength:]
+	message, err := self.split()
+	if err != nil {
+		return nil, err
 	}
-	message.Payload = data
-
 	// Decrypt the message, if requested
 	if key == nil {
 		return message, nil
@@ -100,6 +98,39 @@ func (self *Envelope) Open(key *ecdsa.PrivateKey) (msg *Message, err error) {
 	}
 }
 
+// OpenSymmetric extracts the message contained within an envelope encrypted
+// with the given symmetric key.
+func (self *Envelope) OpenSymmetric(key []byte) (*Message, error) {
+	message, err := self.split()
+	if err != nil {
+		return nil, err
+	}
+	if err := message.decryptSymmetric(key); err != nil {
+		return nil, fmt.Errorf("unable to open envelope, decrypt failed: %v", err)
+	}
+	return message, nil
+}
+
+// split splits the envelope payload into a message construct.
+func (self *Envelope) split() (*Message, error) {
+	data := self.Data
+
+	message := &Message{
+		Flags: data[0],
+	}
+	data = data[1:]
+
+	if message.Flags&signatureFlag == signatureFlag {
+		if len(data) < signatureLength {
+			return nil, fmt.Errorf("unable to open envelope. First bit set but len(data) < len(signature)")
+		}
+		message.Signature, data = data[:signatureLength], data[signatureLength:]
+	}
+	message.Payload = data
+
+	return message, nil
+}
+
 // Hash returns the SHA3 hash of the envelope, calculating it if not yet done.
 func (self *Envelope) Hash() common.Hash {
 	if (self.hash == common.Hash{}) {
@@ -0,0 +1,170 @@
+package whisper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/randentropy"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keyFileVersion = 1
+	gcmNonceLength = 12
+
+	// scrypt parameters of the key file encryption. The key is derived once
+	// when the store is opened.
+	keyFileScryptN = 1 << 16
+	keyFileScryptR = 8
+	keyFileScryptP = 1
+)
+
+var errKeyFileDecrypt = errors.New("could not decrypt whisper key file (wrong node key or passphrase?)")
+
+// keyFile is the on-disk form of the key store.
+type keyFile struct {
+	Version    int    `json:"version"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// keyFileContent is the plaintext of a key file.
+type keyFileContent struct {
+	Identities []string          `json:"identities"`
+	SymKeys    map[string]string `json:"symKeys"`
+}
+
+// keyStore persists the identities and symmetric keys of a Whisper node in a
+// single file, encrypted with AES-GCM under a key derived with scrypt from a
+// secret (the node key or a passphrase).
+type keyStore struct {
+	path string
+	salt []byte
+	key  []byte
+}
+
+// openKeyStore opens the key file at path and returns its keys. A missing
+// file is created on the first save.
+func openKeyStore(path string, secret []byte) (*keyStore, map[string]*ecdsa.PrivateKey, map[string][]byte, error) {
+	ks := &keyStore{path: path}
+	identities := make(map[string]*ecdsa.PrivateKey)
+	symKeys := make(map[string][]byte)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		ks.salt = randentropy.GetEntropyCSPRNG(32)
+		if ks.key, err = scrypt.Key(secret, ks.salt, keyFileScryptN, keyFileScryptR, keyFileScryptP, 32); err != nil {
+			return nil, nil, nil, err
+		}
+		return ks, identities, symKeys, nil
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid whisper key file: %v", err)
+	}
+	if file.Version != keyFileVersion {
+		return nil, nil, nil, fmt.Errorf("unsupported whisper key file version %d", file.Version)
+	}
+	ks.salt = common.FromHex(file.Salt)
+	if ks.key, err = scrypt.Key(secret, ks.salt, keyFileScryptN, keyFileScryptR, keyFileScryptP, 32); err != nil {
+		return nil, nil, nil, err
+	}
+	plain, err := aesGCMOpen(ks.key, common.FromHex(file.Nonce), common.FromHex(file.Ciphertext))
+	if err != nil {
+		return nil, nil, nil, errKeyFileDecrypt
+	}
+	var content keyFileContent
+	if err := json.Unmarshal(plain, &content); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid whisper key file content: %v", err)
+	}
+	for _, hex := range content.Identities {
+		key := crypto.ToECDSA(common.FromHex(hex))
+		if key == nil {
+			return nil, nil, nil, fmt.Errorf("invalid whisper identity in key file")
+		}
+		identities[string(crypto.FromECDSAPub(&key.PublicKey))] = key
+	}
+	for id, hex := range content.SymKeys {
+		symKeys[id] = common.FromHex(hex)
+	}
+	return ks, identities, symKeys, nil
+}
+
+// save writes all keys to the key file, replacing its previous content.
+func (ks *keyStore) save(identities map[string]*ecdsa.PrivateKey, symKeys map[string][]byte) error {
+	content := keyFileContent{SymKeys: make(map[string]string)}
+	for _, key := range identities {
+		content.Identities = append(content.Identities, common.ToHex(crypto.FromECDSA(key)))
+	}
+	for id, key := range symKeys {
+		content.SymKeys[id] = common.ToHex(key)
+	}
+	plain, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	nonce, ciphertext, err := aesGCMSeal(ks.key, plain)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(keyFile{
+		Version:    keyFileVersion,
+		Salt:       common.ToHex(ks.salt),
+		Nonce:      common.ToHex(nonce),
+		Ciphertext: common.ToHex(ciphertext),
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ks.path), 0700); err != nil {
+		return err
+	}
+	tmp := ks.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ks.path)
+}
+
+// aesGCMSeal encrypts plain with a random nonce.
+func aesGCMSeal(key, plain []byte) (nonce, ciphertext []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = randentropy.GetEntropyCSPRNG(gcmNonceLength)
+	return nonce, gcm.Seal(nil, nonce, plain, nil), nil
+}
+
+// aesGCMOpen decrypts and authenticates ciphertext.
+func aesGCMOpen(key, nonce, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce length")
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,90 @@
+package whisper
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyStorePersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "whisper-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys.json")
+
+	w := New()
+	if err := w.OpenKeyStore(path, []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	identity := w.NewIdentity()
+	removed := w.NewIdentity()
+	symID, err := w.NewSymKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pwID, err := w.SymKeyFromPassword("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.RemoveIdentity(&removed.PublicKey) {
+		t.Fatal("identity not removed")
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if bytes.Contains(data, identity.D.Bytes()) || bytes.Contains(data, w.GetSymKey(symID)) {
+		t.Fatal("key file contains plaintext keys")
+	}
+
+	// a new node with the same secret has the same keys
+	w2 := New()
+	if err := w2.OpenKeyStore(path, []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	if key := w2.GetIdentity(&identity.PublicKey); key == nil || key.D.Cmp(identity.D) != 0 {
+		t.Error("identity not restored")
+	}
+	if w2.HasIdentity(&removed.PublicKey) {
+		t.Error("removed identity restored")
+	}
+	if !bytes.Equal(w2.GetSymKey(symID), w.GetSymKey(symID)) {
+		t.Error("symmetric key not restored")
+	}
+	if !w2.RemoveSymKey(pwID) || w2.HasSymKey(pwID) {
+		t.Error("symmetric key not removed")
+	}
+
+	if err := New().OpenKeyStore(path, []byte("wrong")); err != errKeyFileDecrypt {
+		t.Errorf("expected decryption error with the wrong secret, got %v", err)
+	}
+}
+
+func TestSymKeyFromPassword(t *testing.T) {
+	w1, w2 := New(), New()
+	id1, err := w1.SymKeyFromPassword("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, _ := w2.SymKeyFromPassword("password")
+	if id1 != id2 || !bytes.Equal(w1.GetSymKey(id1), w2.GetSymKey(id2)) {
+		t.Error("password derived different keys")
+	}
+	if id3, _ := w2.SymKeyFromPassword("other"); id3 == id1 {
+		t.Error("different passwords derived the same key")
+	}
+	if _, err := w1.AddSymKey([]byte{1, 2, 3}); err == nil {
+		t.Error("accepted short symmetric key")
+	}
+
+	// the node opens messages encrypted with its symmetric keys
+	envelope, err := NewMessage([]byte("hi")).Wrap(DefaultPoW, Options{SymKey: w1.GetSymKey(id1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := w2.open(envelope); msg == nil || string(msg.Payload) != "hi" {
+		t.Errorf("symmetric message not opened: %v", msg)
+	}
+}
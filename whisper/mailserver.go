@@ -0,0 +1,113 @@
+// Contains the mail server hooks, allowing a node to archive envelopes and to
+// serve them to peers which were offline when they were circulating.
+
+package whisper
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MailServer is implemented by nodes archiving whisper envelopes for later
+// delivery. Both methods are called from the networking code, so they should
+// return quickly.
+type MailServer interface {
+	// Archive is called with every new envelope entering the local pool.
+	Archive(envelope *Envelope)
+
+	// Deliver returns the archived envelopes matching a history request of a
+	// remote peer.
+	Deliver(request *MailRequest) []*Envelope
+}
+
+// MailRequest asks a mail server for the archived envelopes which were sent
+// between From and Until (Unix timestamps, inclusive) and carry at least one
+// of the requested topics. An empty topic list matches every envelope.
+type MailRequest struct {
+	From   uint32
+	Until  uint32
+	Topics []Topic
+}
+
+// Match checks whether an envelope falls within the request.
+func (self *MailRequest) Match(envelope *Envelope) bool {
+	sent := envelope.Expiry - envelope.TTL
+	if sent < self.From || sent > self.Until {
+		return false
+	}
+	return matchTopics(self.Topics, envelope.Topics)
+}
+
+// matchTopics checks whether any of the wanted topics is contained in topics.
+// An empty wanted list matches everything.
+func matchTopics(wanted, topics []Topic) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	set := newTopicSet(topics)
+	for _, topic := range wanted {
+		if _, ok := set[topic.String()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryMailServer is a simple in-memory MailServer archiving the envelopes
+// on a configured set of topics, dropping the oldest ones beyond a limit.
+type MemoryMailServer struct {
+	topics []Topic
+	limit  int
+
+	envelopes []*Envelope
+	hashes    map[common.Hash]struct{}
+	lock      sync.RWMutex
+}
+
+// NewMemoryMailServer creates an in-memory mail server archiving at most limit
+// envelopes on any of the given topics (all envelopes if none are given).
+func NewMemoryMailServer(topics []Topic, limit int) *MemoryMailServer {
+	return &MemoryMailServer{
+		topics: topics,
+		limit:  limit,
+		hashes: make(map[common.Hash]struct{}),
+	}
+}
+
+// Archive implements MailServer, storing the envelope if it is on one of the
+// configured topics.
+func (self *MemoryMailServer) Archive(envelope *Envelope) {
+	if !matchTopics(self.topics, envelope.Topics) {
+		return
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	hash := envelope.Hash()
+	if _, ok := self.hashes[hash]; ok {
+		return
+	}
+	self.envelopes = append(self.envelopes, envelope)
+	self.hashes[hash] = struct{}{}
+
+	if self.limit > 0 && len(self.envelopes) > self.limit {
+		delete(self.hashes, self.envelopes[0].Hash())
+		self.envelopes = self.envelopes[1:]
+	}
+}
+
+// Deliver implements MailServer, returning the archived envelopes matching the
+// request in arrival order.
+func (self *MemoryMailServer) Deliver(request *MailRequest) []*Envelope {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	envelopes := make([]*Envelope, 0)
+	for _, envelope := range self.envelopes {
+		if request.Match(envelope) {
+			envelopes = append(envelopes, envelope)
+		}
+	}
+	return envelopes
+}
@@ -0,0 +1,217 @@
+package whisper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func wrapTestEnvelope(t *testing.T, payload string, topics []Topic) *Envelope {
+	envelope, err := NewMessage([]byte(payload)).Wrap(DefaultPoW, Options{
+		TTL:    DefaultTTL,
+		Topics: topics,
+	})
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	return envelope
+}
+
+func TestMemoryMailServer(t *testing.T) {
+	topics := NewTopicsFromStrings("archived")
+	server := NewMemoryMailServer(topics, 2)
+
+	first := wrapTestEnvelope(t, "first", topics)
+	second := wrapTestEnvelope(t, "second", topics)
+	third := wrapTestEnvelope(t, "third", topics)
+	other := wrapTestEnvelope(t, "other", NewTopicsFromStrings("ignored"))
+
+	server.Archive(first)
+	server.Archive(first)
+	server.Archive(other)
+
+	all := &MailRequest{From: 0, Until: ^uint32(0)}
+	if envelopes := server.Deliver(all); len(envelopes) != 1 || envelopes[0].Hash() != first.Hash() {
+		t.Fatalf("archive mismatch: have %v, want [first]", envelopes)
+	}
+	// Check that the oldest envelope is dropped beyond the limit
+	server.Archive(second)
+	server.Archive(third)
+	envelopes := server.Deliver(all)
+	if len(envelopes) != 2 || envelopes[0].Hash() != second.Hash() || envelopes[1].Hash() != third.Hash() {
+		t.Fatalf("archive mismatch: have %v, want [second third]", envelopes)
+	}
+	// Check the time and topic constraints of the requests
+	sent := third.Expiry - third.TTL
+	if envelopes := server.Deliver(&MailRequest{From: sent + 1, Until: ^uint32(0)}); len(envelopes) != 0 {
+		t.Errorf("future request delivered %d envelopes", len(envelopes))
+	}
+	if envelopes := server.Deliver(&MailRequest{From: 0, Until: sent, Topics: NewTopicsFromStrings("ignored")}); len(envelopes) != 0 {
+		t.Errorf("topic mismatch delivered %d envelopes", len(envelopes))
+	}
+	if envelopes := server.Deliver(&MailRequest{From: 0, Until: sent, Topics: topics}); len(envelopes) != 2 {
+		t.Errorf("topic match delivered %d envelopes, want 2", len(envelopes))
+	}
+}
+
+func TestPeerMailRequest(t *testing.T) {
+	// Start a tester and execute the handshake
+	tester, err := startTestPeerInited()
+	if err != nil {
+		t.Fatalf("failed to start initialized peer: %v", err)
+	}
+	defer tester.stream.Close()
+
+	topics := NewTopicsFromStrings("archived")
+	tester.client.RegisterServer(NewMemoryMailServer(topics, 0))
+
+	// Deliver an envelope to the tester, archiving it
+	envelope := wrapTestEnvelope(t, "archived message", topics)
+	if err := p2p.Send(tester.stream, messagesCode, []*Envelope{envelope}); err != nil {
+		t.Fatalf("failed to transfer message: %v", err)
+	}
+	// Request the history and wait for the response, skipping broadcasts
+	request := &MailRequest{From: 0, Until: ^uint32(0), Topics: topics}
+	if err := p2p.Send(tester.stream, mailRequestCode, request); err != nil {
+		t.Fatalf("failed to send mail request: %v", err)
+	}
+	for {
+		packet, err := tester.stream.ReadMsg()
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		if packet.Code != mailResponseCode {
+			packet.Discard()
+			continue
+		}
+		var envelopes []*Envelope
+		if err := packet.Decode(&envelopes); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(envelopes) != 1 || envelopes[0].Hash() != envelope.Hash() {
+			t.Fatalf("response mismatch: have %v, want [%v]", envelopes, envelope)
+		}
+		break
+	}
+}
+
+// requestHistory sends a mail request to the tester once it is registered
+// as a peer and waits for the request to arrive.
+func requestHistory(t *testing.T, tester *testPeer) {
+	errc := make(chan error, 1)
+	go func() {
+		request := &MailRequest{From: 0, Until: ^uint32(0)}
+		for end := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+			err := tester.client.RequestHistory(discover.NodeID{}, request)
+			if err == nil || time.Now().After(end) {
+				errc <- err
+				return
+			}
+		}
+	}()
+	for {
+		packet, err := tester.stream.ReadMsg()
+		if err != nil {
+			t.Fatalf("failed to read request: %v", err)
+		}
+		packet.Discard()
+		if packet.Code == mailRequestCode {
+			break
+		}
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to request history: %v", err)
+	}
+}
+
+func TestPeerMailResponse(t *testing.T) {
+	// Start a tester and execute the handshake
+	tester, err := startTestPeerInited()
+	if err != nil {
+		t.Fatalf("failed to start initialized peer: %v", err)
+	}
+	defer tester.stream.Close()
+
+	// Watch for all inbound messages
+	arrived := make(chan []byte, 3)
+	tester.client.Watch(Filter{
+		Fn: func(message *Message) {
+			arrived <- message.Payload
+		},
+	})
+	valid := wrapTestEnvelope(t, "archived message", nil)
+	expired := wrapTestEnvelope(t, "expired message", nil)
+	expired.Expiry = uint32(time.Now().Add(-time.Minute).Unix())
+	unsolicited := wrapTestEnvelope(t, "unsolicited message", nil)
+
+	// Responses without a request are dropped
+	if err := p2p.Send(tester.stream, mailResponseCode, []*Envelope{unsolicited}); err != nil {
+		t.Fatalf("failed to transfer message: %v", err)
+	}
+	// Deliver archived envelopes and check that only the valid one arrives upstream
+	requestHistory(t, tester)
+	if err := p2p.Send(tester.stream, mailResponseCode, []*Envelope{expired, valid}); err != nil {
+		t.Fatalf("failed to transfer message: %v", err)
+	}
+	select {
+	case payload := <-arrived:
+		if string(payload) != "archived message" {
+			t.Fatalf("unexpected message delivered: %q", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("message delivery timeout")
+	}
+	select {
+	case payload := <-arrived:
+		t.Fatalf("unexpected message delivered: %q", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// Archived envelopes must not enter the pool for forwarding
+	if pooled := tester.client.envelopes(); len(pooled) != 0 {
+		t.Fatalf("archived envelope pooled: %v", pooled)
+	}
+}
+
+func TestValidateEnvelope(t *testing.T) {
+	envelope := wrapTestEnvelope(t, "valid", nil)
+	if err := validateEnvelope(envelope); err != nil {
+		t.Fatalf("valid envelope rejected: %v", err)
+	}
+	expired := *envelope
+	expired.Expiry = uint32(time.Now().Add(-time.Second).Unix())
+	if err := validateEnvelope(&expired); err == nil {
+		t.Error("expired envelope accepted")
+	}
+	// find a nonce without proof of work
+	unsealed := *envelope
+	for unsealed.Nonce = 0; unsealed.PoW() >= MinimumPoW; unsealed.Nonce++ {
+	}
+	if err := validateEnvelope(&unsealed); err == nil {
+		t.Error("envelope without proof of work accepted")
+	}
+}
+
+func TestLimitEnvelopes(t *testing.T) {
+	var envelopes []*Envelope
+	for i := 0; i < 3; i++ {
+		envelopes = append(envelopes, wrapTestEnvelope(t, "message", nil))
+	}
+	// the encoded sizes vary with the nonce and expiry
+	var sizes []int
+	for _, envelope := range envelopes {
+		enc, _ := rlp.EncodeToBytes(envelope)
+		sizes = append(sizes, len(enc))
+	}
+	if limited := limitEnvelopes(envelopes, sizes[0]+sizes[1]); len(limited) != 2 {
+		t.Errorf("limited to %d envelopes, want 2", len(limited))
+	}
+	if limited := limitEnvelopes(envelopes, sizes[0]+sizes[1]+sizes[2]-1); len(limited) != 2 {
+		t.Errorf("limited to %d envelopes, want 2", len(limited))
+	}
+	if limited := limitEnvelopes(envelopes, sizes[0]+sizes[1]+sizes[2]); len(limited) != 3 {
+		t.Errorf("limited to %d envelopes, want 3", len(limited))
+	}
+}
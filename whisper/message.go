@@ -5,9 +5,11 @@ package whisper
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"math/rand"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -25,10 +27,25 @@ type Message struct {
 	To *ecdsa.PublicKey
 }
 
+const (
+	symKeyLength  = 32 // AES-256
+	symKeyScryptN = 1 << 16
+)
+
+// symKeySalt is the scrypt salt of keys derived from passwords. It is fixed
+// so that all nodes derive the same key.
+var symKeySalt = []byte("whisper symmetric key")
+
+// symKeyID returns the id of a symmetric key, the first bytes of its hash.
+func symKeyID(key []byte) string {
+	return common.ToHex(crypto.Sha3(key)[:8])
+}
+
 // Options specifies the exact way a message should be wrapped into an Envelope.
 type Options struct {
 	From   *ecdsa.PrivateKey
 	To     *ecdsa.PublicKey
+	SymKey []byte // Encrypts with AES-GCM instead of To
 	TTL    time.Duration
 	Topics []Topic
 }
@@ -59,7 +76,13 @@ func NewMessage(payload []byte) *Message {
 //   - options.From != nil && options.To == nil: signed broadcast (known sender)
 //   - options.From == nil && options.To != nil: encrypted anonymous message
 //   - options.From != nil && options.To != nil: encrypted signed message
+//
+// With options.SymKey instead of options.To the message is encrypted with
+// that symmetric key.
 func (self *Message) Wrap(pow time.Duration, options Options) (*Envelope, error) {
+	if options.To != nil && options.SymKey != nil {
+		return nil, errors.New("message can't be encrypted with both a public and a symmetric key")
+	}
 	// Use the default TTL if non was specified
 	if options.TTL == 0 {
 		options.TTL = DefaultTTL
@@ -75,6 +98,11 @@ func (self *Message) Wrap(pow time.Duration, options Options) (*Envelope, error)
 			return nil, err
 		}
 	}
+	if options.SymKey != nil {
+		if err := self.encryptSymmetric(options.SymKey); err != nil {
+			return nil, err
+		}
+	}
 	// Wrap the processed message, seal it and return
 	envelope := NewEnvelope(options.TTL, options.Topics, self)
 	envelope.Seal(pow)
@@ -119,6 +147,30 @@ func (self *Message) decrypt(key *ecdsa.PrivateKey) (err error) {
 	return
 }
 
+// encryptSymmetric encrypts a message payload with AES-GCM, the nonce is
+// prepended to the ciphertext.
+func (self *Message) encryptSymmetric(key []byte) error {
+	nonce, ciphertext, err := aesGCMSeal(key, self.Payload)
+	if err != nil {
+		return err
+	}
+	self.Payload = append(nonce, ciphertext...)
+	return nil
+}
+
+// decryptSymmetric decrypts a payload encrypted by encryptSymmetric.
+func (self *Message) decryptSymmetric(key []byte) error {
+	if len(self.Payload) < gcmNonceLength {
+		return errors.New("payload too short")
+	}
+	plain, err := aesGCMOpen(key, self.Payload[:gcmNonceLength], self.Payload[gcmNonceLength:])
+	if err != nil {
+		return err
+	}
+	self.Payload = plain
+	return nil
+}
+
 // hash calculates the SHA3 checksum of the message flags and payload.
 func (self *Message) hash() []byte {
 	return crypto.Sha3(append([]byte{self.Flags}, self.Payload...))
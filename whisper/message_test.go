@@ -136,3 +136,37 @@ func TestMessageFullCrypto(t *testing.T) {
 		t.Fatalf("public key mismatch: have 0x%x, want 0x%x", p2, p1)
 	}
 }
+
+// Tests whether a message can be encrypted and decrypted with a symmetric key.
+func TestMessageSymmetricEncryptDecrypt(t *testing.T) {
+	key := make([]byte, symKeyLength)
+	key[0] = 1
+	payload := []byte("hello world")
+
+	msg := NewMessage(payload)
+	envelope, err := msg.Wrap(DefaultPoW, Options{
+		SymKey: key,
+	})
+	if err != nil {
+		t.Fatalf("failed to encrypt message: %v", err)
+	}
+	if bytes.Contains(envelope.Data, payload) {
+		t.Fatal("payload not encrypted")
+	}
+
+	out, err := envelope.OpenSymmetric(key)
+	if err != nil {
+		t.Fatalf("failed to open encrypted message: %v", err)
+	}
+	if !bytes.Equal(out.Payload, payload) {
+		t.Errorf("payload mismatch: have 0x%x, want 0x%x", out.Payload, payload)
+	}
+	key[0] = 2
+	if _, err := envelope.OpenSymmetric(key); err == nil {
+		t.Error("opened message with the wrong key")
+	}
+	to, _ := crypto.GenerateKey()
+	if _, err := NewMessage(payload).Wrap(DefaultPoW, Options{SymKey: key, To: &to.PublicKey}); err == nil {
+		t.Error("wrapped message for both a public and a symmetric key")
+	}
+}
@@ -16,7 +16,8 @@ type peer struct {
 	peer *p2p.Peer
 	ws   p2p.MsgReadWriter
 
-	known *set.Set // Messages already known by the peer to avoid wasting bandwidth
+	known    *set.Set // Messages already known by the peer to avoid wasting bandwidth
+	requests int32    // Number of mail requests awaiting a response (atomic access)
 
 	quit chan struct{}
 }
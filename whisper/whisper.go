@@ -2,24 +2,32 @@ package whisper
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"github.com/ethereum/go-ethereum/crypto/randentropy"
 	"github.com/ethereum/go-ethereum/event/filter"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/crypto/scrypt"
 	"gopkg.in/fatih/set.v0"
 )
 
 const (
-	statusCode   = 0x00
-	messagesCode = 0x01
+	statusCode       = 0x00
+	messagesCode     = 0x01
+	mailRequestCode  = 0x02
+	mailResponseCode = 0x03
 
-	protocolVersion uint64 = 0x02
+	protocolVersion uint64 = 0x03
 	protocolName           = "shh"
 
 	signatureFlag   = byte(1 << 7)
@@ -27,11 +35,17 @@ const (
 
 	expirationCycle   = 800 * time.Millisecond
 	transmissionCycle = 300 * time.Millisecond
+
+	mailResponseLimit = 512 * 1024 // Maximum size of the envelopes in a mail response
 )
 
 const (
 	DefaultTTL = 50 * time.Second
 	DefaultPoW = 50 * time.Millisecond
+
+	// MinimumPoW is the proof of work, in leading zero bits, which envelopes
+	// received from peers must carry. Any non-zero sealing time reaches it.
+	MinimumPoW = 4
 )
 
 type MessageEvent struct {
@@ -46,7 +60,10 @@ type Whisper struct {
 	protocol p2p.Protocol
 	filters  *filter.Filters
 
-	keys map[string]*ecdsa.PrivateKey
+	keys    map[string]*ecdsa.PrivateKey // Identities, by public key
+	symKeys map[string][]byte            // Symmetric keys, by id
+	store   *keyStore                    // Persistent key store, nil if keys aren't saved
+	keyMu   sync.RWMutex                 // Mutex to sync the keys and the key store
 
 	messages    map[common.Hash]*Envelope // Pool of messages currently tracked by this node
 	expirations map[uint32]*set.SetNonTS  // Message expiration pool (TODO: something lighter)
@@ -55,6 +72,8 @@ type Whisper struct {
 	peers  map[*peer]struct{} // Set of currently active peers
 	peerMu sync.RWMutex       // Mutex to sync the active peer set
 
+	mailServer MailServer // Archive of envelopes served to other peers, nil if none
+
 	quit chan struct{}
 }
 
@@ -62,6 +81,7 @@ func New() *Whisper {
 	whisper := &Whisper{
 		filters:     filter.New(),
 		keys:        make(map[string]*ecdsa.PrivateKey),
+		symKeys:     make(map[string][]byte),
 		messages:    make(map[common.Hash]*Envelope),
 		expirations: make(map[uint32]*set.SetNonTS),
 		peers:       make(map[*peer]struct{}),
@@ -73,7 +93,7 @@ func New() *Whisper {
 	whisper.protocol = p2p.Protocol{
 		Name:    protocolName,
 		Version: uint(protocolVersion),
-		Length:  2,
+		Length:  4,
 		Run:     whisper.handlePeer,
 	}
 
@@ -90,6 +110,40 @@ func (self *Whisper) Version() uint {
 	return self.protocol.Version
 }
 
+// OpenKeyStore loads the identities and symmetric keys saved in the key file
+// at path and saves all later key changes to it. The file is encrypted with a
+// key derived from secret, e.g. the node key or a passphrase.
+func (self *Whisper) OpenKeyStore(path string, secret []byte) error {
+	store, keys, symKeys, err := openKeyStore(path, secret)
+	if err != nil {
+		return err
+	}
+	self.keyMu.Lock()
+	defer self.keyMu.Unlock()
+
+	for pub, key := range keys {
+		self.keys[pub] = key
+	}
+	for id, key := range symKeys {
+		self.symKeys[id] = key
+	}
+	self.store = store
+	return self.saveKeys()
+}
+
+// saveKeys writes the keys to the key store, if there is one. keyMu must be
+// held.
+func (self *Whisper) saveKeys() error {
+	if self.store == nil {
+		return nil
+	}
+	if err := self.store.save(self.keys, self.symKeys); err != nil {
+		glog.V(logger.Error).Infof("could not save whisper keys: %v", err)
+		return err
+	}
+	return nil
+}
+
 // NewIdentity generates a new cryptographic identity for the client, and injects
 // it into the known identities for message decryption.
 func (self *Whisper) NewIdentity() *ecdsa.PrivateKey {
@@ -97,22 +151,109 @@ func (self *Whisper) NewIdentity() *ecdsa.PrivateKey {
 	if err != nil {
 		panic(err)
 	}
-	self.keys[string(crypto.FromECDSAPub(&key.PublicKey))] = key
+	self.AddIdentity(key)
 
 	return key
 }
 
+// AddIdentity injects an existing private key into the known identities.
+func (self *Whisper) AddIdentity(key *ecdsa.PrivateKey) error {
+	self.keyMu.Lock()
+	defer self.keyMu.Unlock()
+
+	self.keys[string(crypto.FromECDSAPub(&key.PublicKey))] = key
+	return self.saveKeys()
+}
+
+// RemoveIdentity deletes the private key of the specified public identity.
+// It returns false if there is no such identity.
+func (self *Whisper) RemoveIdentity(key *ecdsa.PublicKey) bool {
+	self.keyMu.Lock()
+	defer self.keyMu.Unlock()
+
+	k := string(crypto.FromECDSAPub(key))
+	if _, ok := self.keys[k]; !ok {
+		return false
+	}
+	delete(self.keys, k)
+	self.saveKeys()
+	return true
+}
+
 // HasIdentity checks if the the whisper node is configured with the private key
 // of the specified public pair.
 func (self *Whisper) HasIdentity(key *ecdsa.PublicKey) bool {
+	self.keyMu.RLock()
+	defer self.keyMu.RUnlock()
+
 	return self.keys[string(crypto.FromECDSAPub(key))] != nil
 }
 
 // GetIdentity retrieves the private key of the specified public identity.
 func (self *Whisper) GetIdentity(key *ecdsa.PublicKey) *ecdsa.PrivateKey {
+	self.keyMu.RLock()
+	defer self.keyMu.RUnlock()
+
 	return self.keys[string(crypto.FromECDSAPub(key))]
 }
 
+// NewSymKey generates a random symmetric key and returns its id.
+func (self *Whisper) NewSymKey() (string, error) {
+	return self.AddSymKey(randentropy.GetEntropyCSPRNG(symKeyLength))
+}
+
+// AddSymKey injects a symmetric key for message encryption and decryption
+// and returns its id, which is derived from the key.
+func (self *Whisper) AddSymKey(key []byte) (string, error) {
+	if len(key) != symKeyLength {
+		return "", fmt.Errorf("symmetric key must be %d bytes, got %d", symKeyLength, len(key))
+	}
+	id := symKeyID(key)
+
+	self.keyMu.Lock()
+	defer self.keyMu.Unlock()
+
+	self.symKeys[id] = common.CopyBytes(key)
+	return id, self.saveKeys()
+}
+
+// SymKeyFromPassword derives a symmetric key from password, injects it and
+// returns its id. All nodes derive the same key from the same password.
+func (self *Whisper) SymKeyFromPassword(password string) (string, error) {
+	key, err := scrypt.Key([]byte(password), symKeySalt, symKeyScryptN, 8, 1, symKeyLength)
+	if err != nil {
+		return "", err
+	}
+	return self.AddSymKey(key)
+}
+
+// HasSymKey checks if the symmetric key with the given id is known.
+func (self *Whisper) HasSymKey(id string) bool {
+	return self.GetSymKey(id) != nil
+}
+
+// GetSymKey retrieves the symmetric key with the given id.
+func (self *Whisper) GetSymKey(id string) []byte {
+	self.keyMu.RLock()
+	defer self.keyMu.RUnlock()
+
+	return self.symKeys[id]
+}
+
+// RemoveSymKey deletes the symmetric key with the given id. It returns false
+// if there is no such key.
+func (self *Whisper) RemoveSymKey(id string) bool {
+	self.keyMu.Lock()
+	defer self.keyMu.Unlock()
+
+	if _, ok := self.symKeys[id]; !ok {
+		return false
+	}
+	delete(self.symKeys, id)
+	self.saveKeys()
+	return true
+}
+
 // Watch installs a new message handler to run in case a matching packet arrives
 // from the whisper network.
 func (self *Whisper) Watch(options Filter) int {
@@ -138,6 +279,33 @@ func (self *Whisper) Send(envelope *Envelope) error {
 	return self.add(envelope)
 }
 
+// RegisterServer sets the mail server archiving the envelopes seen by this node
+// and answering the history requests of its peers. It must be called before
+// the node is started.
+func (self *Whisper) RegisterServer(server MailServer) {
+	self.mailServer = server
+}
+
+// RequestHistory asks a connected peer running a mail server for the archived
+// envelopes matching the request. The returned envelopes are delivered to the
+// installed message handlers, but they are not forwarded to other peers.
+func (self *Whisper) RequestHistory(id discover.NodeID, request *MailRequest) error {
+	self.peerMu.RLock()
+	defer self.peerMu.RUnlock()
+
+	for peer, _ := range self.peers {
+		if peer.peer.ID() == id {
+			atomic.AddInt32(&peer.requests, 1)
+			if err := p2p.Send(peer.ws, mailRequestCode, request); err != nil {
+				atomic.AddInt32(&peer.requests, -1)
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown whisper peer %x", id[:8])
+}
+
 func (self *Whisper) Start() {
 	glog.V(logger.Info).Infoln("Whisper started")
 	go self.update()
@@ -163,15 +331,6 @@ func (self *Whisper) Messages(id int) []*Message {
 	return messages
 }
 
-// func (self *Whisper) RemoveIdentity(key *ecdsa.PublicKey) bool {
-// 	k := string(crypto.FromECDSAPub(key))
-// 	if _, ok := self.keys[k]; ok {
-// 		delete(self.keys, k)
-// 		return true
-// 	}
-// 	return false
-// }
-
 // handlePeer is called by the underlying P2P layer when the whisper sub-protocol
 // connection is negotiated.
 func (self *Whisper) handlePeer(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
@@ -200,20 +359,98 @@ func (self *Whisper) handlePeer(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
 		if err != nil {
 			return err
 		}
-		var envelopes []*Envelope
-		if err := packet.Decode(&envelopes); err != nil {
-			peer.Infof("failed to decode enveloped: %v", err)
-			continue
-		}
-		// Inject all envelopes into the internal pool
-		for _, envelope := range envelopes {
-			if err := self.add(envelope); err != nil {
-				// TODO Punish peer here. Invalid envelope.
-				peer.Debugf("failed to pool envelope: %f", err)
+		switch packet.Code {
+		case messagesCode:
+			var envelopes []*Envelope
+			if err := packet.Decode(&envelopes); err != nil {
+				peer.Infof("failed to decode enveloped: %v", err)
+				continue
+			}
+			// Inject all envelopes into the internal pool
+			for _, envelope := range envelopes {
+				if err := validateEnvelope(envelope); err != nil {
+					peer.Debugf("invalid envelope: %v", err)
+					continue
+				}
+				if err := self.add(envelope); err != nil {
+					// TODO Punish peer here. Invalid envelope.
+					peer.Debugf("failed to pool envelope: %f", err)
+				}
+				whisperPeer.mark(envelope)
+			}
+
+		case mailRequestCode:
+			var request MailRequest
+			if err := packet.Decode(&request); err != nil {
+				peer.Infof("failed to decode mail request: %v", err)
+				continue
+			}
+			if self.mailServer == nil {
+				peer.Debugf("mail request ignored, no mail server registered")
+				continue
+			}
+			if err := p2p.Send(rw, mailResponseCode, limitEnvelopes(self.mailServer.Deliver(&request), mailResponseLimit)); err != nil {
+				return err
+			}
+
+		case mailResponseCode:
+			// Only accept the responses to our own requests
+			if atomic.LoadInt32(&whisperPeer.requests) <= 0 {
+				packet.Discard()
+				peer.Debugf("unsolicited mail response ignored")
+				continue
+			}
+			atomic.AddInt32(&whisperPeer.requests, -1)
+
+			var envelopes []*Envelope
+			if err := packet.Decode(&envelopes); err != nil {
+				peer.Infof("failed to decode archived envelopes: %v", err)
+				continue
+			}
+			// Deliver the archived envelopes not already seen through the pool
+			for _, envelope := range envelopes {
+				if err := validateEnvelope(envelope); err != nil {
+					peer.Debugf("invalid archived envelope: %v", err)
+					continue
+				}
+				if !self.pooled(envelope) {
+					self.postEvent(envelope)
+				}
 			}
-			whisperPeer.mark(envelope)
+
+		default:
+			packet.Discard()
+			peer.Debugf("unknown whisper message code %d", packet.Code)
+		}
+	}
+}
+
+// validateEnvelope checks that an envelope received from a peer isn't expired
+// and carries enough proof of work.
+func validateEnvelope(envelope *Envelope) error {
+	if envelope.Expiry < envelope.TTL {
+		return fmt.Errorf("expiry %d before ttl %d", envelope.Expiry, envelope.TTL)
+	}
+	if now := uint32(time.Now().Unix()); envelope.Expiry < now {
+		return fmt.Errorf("expired %ds ago", now-envelope.Expiry)
+	}
+	if pow := envelope.PoW(); pow < MinimumPoW {
+		return fmt.Errorf("proof of work %d below %d", pow, MinimumPoW)
+	}
+	return nil
+}
+
+// limitEnvelopes returns the longest prefix of envelopes whose encoded size
+// stays within limit.
+func limitEnvelopes(envelopes []*Envelope, limit int) []*Envelope {
+	size := 0
+	for i, envelope := range envelopes {
+		enc, _ := rlp.EncodeToBytes(envelope)
+		if size += len(enc); size > limit {
+			return envelopes[:i]
 		}
 	}
+	return envelopes
 }
 
 // add inserts a new envelope into the message pool to be distributed within the
@@ -238,8 +475,12 @@ func (self *Whisper) add(envelope *Envelope) error {
 	if !self.expirations[envelope.Expiry].Has(hash) {
 		self.expirations[envelope.Expiry].Add(hash)
 
-		// Notify the local node of a message arrival
+		// Notify the local node of a message arrival and archive it
 		go self.postEvent(envelope)
+
+		if self.mailServer != nil {
+			self.mailServer.Archive(envelope)
+		}
 	}
 	glog.V(logger.Detail).Infof("cached whisper envelope %x\n", envelope)
 
@@ -254,10 +495,20 @@ func (self *Whisper) postEvent(envelope *Envelope) {
 	}
 }
 
-// open tries to decrypt a whisper envelope with all the configured identities,
-// returning the decrypted message and the key used to achieve it. If not keys
-// are configured, open will return the payload as if non encrypted.
+// open tries to decrypt a whisper envelope with all the configured symmetric
+// keys and identities, returning the decrypted message and the key used to
+// achieve it. If not keys are configured, open will return the payload as if
+// non encrypted.
 func (self *Whisper) open(envelope *Envelope) *Message {
+	self.keyMu.RLock()
+	defer self.keyMu.RUnlock()
+
+	// Symmetric encryption is authenticated, try it first
+	for _, key := range self.symKeys {
+		if message, err := envelope.OpenSymmetric(key); err == nil {
+			return message
+		}
+	}
 	// Short circuit if no identity is set, and assume clear-text
 	if len(self.keys) == 0 {
 		if message, err := envelope.Open(nil); err == nil {
@@ -324,6 +575,15 @@ func (self *Whisper) expire() {
 	}
 }
 
+// pooled checks whether an envelope is currently tracked by the message pool.
+func (self *Whisper) pooled(envelope *Envelope) bool {
+	self.poolMu.RLock()
+	defer self.poolMu.RUnlock()
+
+	_, ok := self.messages[envelope.Hash()]
+	return ok
+}
+
 // envelopes retrieves all the messages currently pooled by the node.
 func (self *Whisper) envelopes() []*Envelope {
 	self.poolMu.RLock()
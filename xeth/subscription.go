@@ -0,0 +1,73 @@
+package xeth
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// pendingLogsBufferSize is the channel buffer of the pending logs
+// subscription of a LogSubscription.
+const pendingLogsBufferSize = 16
+
+// LogsCallback receives the logs matched by a LogSubscription. pending is
+// true for logs of the block being mined, which may still change, and
+// false for logs of imported blocks.
+type LogsCallback func(logs state.Logs, pending bool)
+
+// LogSubscription delivers the logs matching an address and topic
+// criteria to a callback as blocks are imported and, optionally, as the
+// pending block is updated.
+type LogSubscription struct {
+	xeth    *XEth
+	id      int
+	pending *event.FeedSubscription
+
+	once sync.Once
+}
+
+// SubscribeLogs calls callback with the logs of new blocks matching the
+// given addresses and topics, like the filters of RegisterFilter. If
+// pending is true the logs of the pending block are delivered as well.
+func (self *XEth) SubscribeLogs(address []string, topics [][]string, pending bool, callback LogsCallback) *LogSubscription {
+	filter := core.NewFilter(self.backend)
+	filter.SetAddress(cAddress(address))
+	filter.SetTopics(cTopics(topics))
+	return self.subscribeLogs(filter, pending, callback)
+}
+
+func (self *XEth) subscribeLogs(filter *core.Filter, pending bool, callback LogsCallback) *LogSubscription {
+	filter.LogsCallback = func(logs state.Logs) {
+		callback(logs, false)
+	}
+	sub := &LogSubscription{xeth: self, id: self.filterManager.InstallFilter(filter)}
+	if pending {
+		sub.pending = self.backend.Miner().SubscribePendingLogsEvent()
+		go func() {
+			for ev := range sub.pending.Chan() {
+				if logs := filter.FilterLogs(ev.(core.PendingLogsEvent).Logs); len(logs) > 0 {
+					callback(logs, true)
+				}
+			}
+		}()
+	}
+	return sub
+}
+
+// Id returns the id of the filter installed for the subscription. Its
+// logs can be queried with XEth.Logs.
+func (s *LogSubscription) Id() int {
+	return s.id
+}
+
+// Unsubscribe stops the delivery of logs. It can be called more than once.
+func (s *LogSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.xeth.filterManager.UninstallFilter(s.id)
+		if s.pending != nil {
+			s.pending.Unsubscribe()
+		}
+	})
+}
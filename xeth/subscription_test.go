@@ -0,0 +1,142 @@
+package xeth
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// newTestXEth creates an XEth for a node on in-memory databases.
+func newTestXEth(t *testing.T) (*XEth, *eth.Ethereum, func()) {
+	dir, err := ioutil.TempDir("", "xeth-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ethereum, err := eth.New(&eth.Config{
+		DataDir:        dir,
+		Name:           "test",
+		AccountManager: accounts.NewManager(crypto.NewKeyStorePlain(dir)),
+		NewDB:          func(string) (common.Database, error) { return ethdb.NewMemDatabase() },
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	x := New(ethereum, nil)
+	return x, ethereum, func() {
+		x.stop()
+		x.filterManager.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+// postUntil posts ev until done is closed, as the filter loop and the
+// miner subscribe to the event mux asynchronously.
+func postUntil(ethereum *eth.Ethereum, ev interface{}, done chan struct{}) {
+	for {
+		ethereum.EventMux().Post(ev)
+		select {
+		case <-done:
+			return
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestSubscribeLogs(t *testing.T) {
+	x, ethereum, cleanup := newTestXEth(t)
+	defer cleanup()
+
+	addr := common.Address{1}
+	logs := make(chan state.Logs, 16)
+	sub := x.SubscribeLogs([]string{addr.Hex()}, nil, false, func(l state.Logs, pending bool) {
+		if pending {
+			t.Error("pending logs delivered without subscribing to them")
+		}
+		logs <- l
+	})
+	if f := x.filterManager.GetFilter(sub.Id()); f == nil {
+		t.Fatalf("no filter installed for subscription %d", sub.Id())
+	}
+
+	ev := core.ChainEvent{
+		Block: ethereum.ChainManager().Genesis(),
+		Logs:  state.Logs{&state.Log{Address: common.Address{2}}, &state.Log{Address: addr}},
+	}
+	done := make(chan struct{})
+	go postUntil(ethereum, ev, done)
+	select {
+	case l := <-logs:
+		if len(l) != 1 || l[0].Address != addr {
+			t.Errorf("got logs %v, want only the log of %x", l, addr)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("logs of the imported block not delivered")
+	}
+	close(done)
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	if f := x.filterManager.GetFilter(sub.Id()); f != nil {
+		t.Error("filter still installed after unsubscribing")
+	}
+	// logs posted before unsubscribing may still be buffered
+	for len(logs) > 0 {
+		<-logs
+	}
+	ethereum.EventMux().Post(ev)
+	ethereum.EventMux().Post(ev)
+	if len(logs) > 0 {
+		t.Error("logs delivered after unsubscribing")
+	}
+}
+
+func TestSubscribePendingLogs(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	defer func(data []byte) { core.GenesisData = data }(core.GenesisData)
+	core.GenesisData = []byte(`{"` + common.Bytes2Hex(sender) + `": {"balance": "1000000000000000000"}}`)
+
+	x, ethereum, cleanup := newTestXEth(t)
+	defer cleanup()
+
+	// the init code raises a LOG0 from the created contract
+	contract := crypto.CreateAddress(common.BytesToAddress(sender), 0)
+	logs := make(chan bool, 16)
+	sub := x.SubscribeLogs([]string{contract.Hex()}, nil, true, func(l state.Logs, pending bool) {
+		if len(l) != 1 || l[0].Address != contract {
+			t.Errorf("got logs %v, want the log of %x", l, contract)
+		}
+		logs <- pending
+	})
+	defer sub.Unsubscribe()
+
+	tx := types.NewContractCreationTx(big.NewInt(0), big.NewInt(100000), big.NewInt(1), []byte{0x60, 0x00, 0x60, 0x00, 0xa0})
+	tx.SignECDSA(key)
+	if err := ethereum.TxPool().Add(tx); err != nil {
+		t.Fatal(err)
+	}
+	// new heads make the miner assemble the pending block with the pool
+	done := make(chan struct{})
+	defer close(done)
+	go postUntil(ethereum, core.ChainHeadEvent{Block: ethereum.ChainManager().Genesis()}, done)
+	select {
+	case pending := <-logs:
+		if !pending {
+			t.Error("logs of the pending block not marked as pending")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("logs of the pending block not delivered")
+	}
+}
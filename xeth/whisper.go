@@ -2,6 +2,8 @@ package xeth
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -20,7 +22,9 @@ func NewWhisper(w *whisper.Whisper) *Whisper {
 	return &Whisper{w}
 }
 
-func (self *Whisper) Post(payload string, to, from string, topics []string, priority, ttl uint32) error {
+// Post sends a message, encrypted to the public key to or, if symKeyID is
+// set, with that symmetric key.
+func (self *Whisper) Post(payload string, to, symKeyID, from string, topics []string, priority, ttl uint32) error {
 	if priority == 0 {
 		priority = 1000
 	}
@@ -29,12 +33,19 @@ func (self *Whisper) Post(payload string, to, from string, topics []string, prio
 		ttl = 100
 	}
 
+	var symKey []byte
+	if len(symKeyID) > 0 {
+		if symKey = self.Whisper.GetSymKey(symKeyID); symKey == nil {
+			return fmt.Errorf("unknown symmetric key %s", symKeyID)
+		}
+	}
 	pk := crypto.ToECDSAPub(common.FromHex(from))
 	if key := self.Whisper.GetIdentity(pk); key != nil || len(from) == 0 {
 		msg := whisper.NewMessage(common.FromHex(payload))
 		envelope, err := msg.Wrap(time.Duration(priority*100000), whisper.Options{
 			TTL:    time.Duration(ttl) * time.Second,
 			To:     crypto.ToECDSAPub(common.FromHex(to)),
+			SymKey: symKey,
 			From:   key,
 			Topics: whisper.NewTopicsFromStrings(topics...),
 		})
@@ -63,9 +74,26 @@ func (self *Whisper) HasIdentity(key string) bool {
 	return self.Whisper.HasIdentity(crypto.ToECDSAPub(common.FromHex(key)))
 }
 
-// func (self *Whisper) RemoveIdentity(key string) bool {
-// 	return self.Whisper.RemoveIdentity(crypto.ToECDSAPub(common.FromHex(key)))
-// }
+// AddIdentity imports a hex encoded private key and returns its public key.
+func (self *Whisper) AddIdentity(privateKey string) (string, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return "", err
+	}
+	if err := self.Whisper.AddIdentity(key); err != nil {
+		return "", err
+	}
+	return common.ToHex(crypto.FromECDSAPub(&key.PublicKey)), nil
+}
+
+func (self *Whisper) RemoveIdentity(key string) bool {
+	return self.Whisper.RemoveIdentity(crypto.ToECDSAPub(common.FromHex(key)))
+}
+
+// AddSymKey imports a hex encoded symmetric key and returns its id.
+func (self *Whisper) AddSymKey(key string) (string, error) {
+	return self.Whisper.AddSymKey(common.FromHex(key))
+}
 
 func (self *Whisper) Watch(opts *Options) int {
 	filter := whisper.Filter{
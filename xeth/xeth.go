@@ -4,13 +4,18 @@ package xeth
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/release"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -20,9 +25,16 @@ import (
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/miner"
-	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// DefaultMaxFilters is the number of filters an XEth keeps installed at
+// the same time unless changed with SetFilterLimits.
+const DefaultMaxFilters = 1000
+
+// ErrTooManyFilters is returned when a filter is created while the filter
+// limit is reached.
+var ErrTooManyFilters = errors.New("too many installed filters, uninstall unused filters or wait for them to time out")
+
 var (
 	filterTickerTime = 5 * time.Minute
 	defaultGasPrice  = big.NewInt(10000000000000) //150000000000
@@ -42,9 +54,16 @@ type XEth struct {
 	quit          chan struct{}
 	filterManager *filter.FilterManager
 
+	// filterTimeout and maxFilters are guarded by logMut
+	filterTimeout time.Duration
+	maxFilters    int
+
 	logMut sync.RWMutex
 	logs   map[int]*logFilter
 
+	transactionMut sync.RWMutex
+	transactions   map[int]*hashFilter
+
 	messagesMut sync.RWMutex
 	messages    map[int]*whisperFilter
 
@@ -52,6 +71,13 @@ type XEth struct {
 	// register map[string][]*interface{} // TODO improve return type
 
 	agent *miner.RemoteAgent
+
+	names NameResolver
+}
+
+// NameResolver maps human readable names to account addresses.
+type NameResolver interface {
+	Addr(name string) (common.Address, error)
 }
 
 // New creates an XEth that uses the given frontend.
@@ -63,9 +89,12 @@ func New(eth *eth.Ethereum, frontend Frontend) *XEth {
 		frontend:      frontend,
 		whisper:       NewWhisper(eth.Whisper()),
 		quit:          make(chan struct{}),
-		filterManager: filter.NewFilterManager(eth.EventMux()),
+		filterManager: filter.NewFilterManager(eth.EventMux(), eth.TxPool(), eth.ChainManager()),
 		logs:          make(map[int]*logFilter),
+		transactions:  make(map[int]*hashFilter),
 		messages:      make(map[int]*whisperFilter),
+		filterTimeout: filterTickerTime,
+		maxFilters:    DefaultMaxFilters,
 		agent:         miner.NewRemoteAgent(),
 	}
 	eth.Miner().Register(xeth.agent)
@@ -87,23 +116,7 @@ done:
 	for {
 		select {
 		case <-timer.C:
-			self.logMut.Lock()
-			self.messagesMut.Lock()
-			for id, filter := range self.logs {
-				if time.Since(filter.timeout) > filterTickerTime {
-					self.filterManager.UninstallFilter(id)
-					delete(self.logs, id)
-				}
-			}
-
-			for id, filter := range self.messages {
-				if time.Since(filter.timeout) > filterTickerTime {
-					self.Whisper().Unwatch(id)
-					delete(self.messages, id)
-				}
-			}
-			self.messagesMut.Unlock()
-			self.logMut.Unlock()
+			self.expireFilters()
 		case <-self.quit:
 			break done
 		}
@@ -114,6 +127,73 @@ func (self *XEth) stop() {
 	close(self.quit)
 }
 
+// expireFilters uninstalls the filters which haven't been polled within the
+// filter timeout. The filter manager is called without holding the filter
+// locks because the filter callbacks acquire them.
+func (self *XEth) expireFilters() {
+	var expired, expiredMsgs []int
+	self.logMut.Lock()
+	self.transactionMut.Lock()
+	self.messagesMut.Lock()
+	timeout := self.filterTimeout
+	for id, filter := range self.logs {
+		if time.Since(filter.timeout) > timeout {
+			expired = append(expired, id)
+			delete(self.logs, id)
+		}
+	}
+	for id, filter := range self.transactions {
+		if time.Since(filter.timeout) > timeout {
+			expired = append(expired, id)
+			delete(self.transactions, id)
+		}
+	}
+	for id, filter := range self.messages {
+		if time.Since(filter.timeout) > timeout {
+			expiredMsgs = append(expiredMsgs, id)
+			delete(self.messages, id)
+		}
+	}
+	self.messagesMut.Unlock()
+	self.transactionMut.Unlock()
+	self.logMut.Unlock()
+
+	for _, id := range expired {
+		self.filterManager.UninstallFilter(id)
+	}
+	for _, id := range expiredMsgs {
+		self.Whisper().Unwatch(id)
+	}
+}
+
+// SetFilterLimits sets how long filters live without being polled and how
+// many filters can be installed at the same time. A max of 0 means no limit.
+func (self *XEth) SetFilterLimits(timeout time.Duration, max int) {
+	self.logMut.Lock()
+	defer self.logMut.Unlock()
+
+	self.filterTimeout = timeout
+	self.maxFilters = max
+}
+
+// checkFilterLimit returns ErrTooManyFilters if no more filters can be
+// installed.
+func (self *XEth) checkFilterLimit() error {
+	self.logMut.RLock()
+	self.transactionMut.RLock()
+	self.messagesMut.RLock()
+	n := len(self.logs) + len(self.transactions) + len(self.messages)
+	max := self.maxFilters
+	self.messagesMut.RUnlock()
+	self.transactionMut.RUnlock()
+	self.logMut.RUnlock()
+
+	if max > 0 && n >= max {
+		return ErrTooManyFilters
+	}
+	return nil
+}
+
 func cAddress(a []string) []common.Address {
 	bslice := make([]common.Address, len(a))
 	for i, addr := range a {
@@ -135,6 +215,26 @@ func cTopics(t [][]string) [][]common.Hash {
 
 func (self *XEth) RemoteMining() *miner.RemoteAgent { return self.agent }
 
+// SetNameResolver installs the resolver used to translate non-hex
+// recipients given to Call and Transact.
+func (self *XEth) SetNameResolver(names NameResolver) { self.names = names }
+
+// resolveAddr returns the address for a hex string or, if a name resolver
+// is installed, for a registered name.
+func (self *XEth) resolveAddr(str string) (common.Address, error) {
+	digits := str
+	if common.HasHexPrefix(digits) {
+		digits = digits[2:]
+	}
+	if strings.Trim(digits, "0123456789abcdefABCDEF") == "" {
+		return common.HexToAddress(str), nil
+	}
+	if self.names == nil {
+		return common.Address{}, fmt.Errorf("invalid address %q", str)
+	}
+	return self.names.Addr(str)
+}
+
 func (self *XEth) AtStateNum(num int64) *XEth {
 	var st *state.StateDB
 	switch num {
@@ -164,6 +264,8 @@ func (self *XEth) State() *State { return self.state }
 
 func (self *XEth) Whisper() *Whisper { return self.whisper }
 
+func (self *XEth) TxPool() *core.TxPool { return self.backend.TxPool() }
+
 func (self *XEth) getBlockByHeight(height int64) *types.Block {
 	var num uint64
 
@@ -198,30 +300,34 @@ func (self *XEth) EthBlockByHash(strHash string) *types.Block {
 }
 
 func (self *XEth) EthTransactionByHash(hash string) (tx *types.Transaction, blhash common.Hash, blnum *big.Int, txi uint64) {
-	data, _ := self.backend.ExtraDb().Get(common.FromHex(hash))
-	if len(data) != 0 {
-		tx = types.NewTransactionFromBytes(data)
+	tx, blhash, num, txi := core.ReadTransaction(self.backend.ExtraDb(), common.HexToHash(hash))
+	if (blhash != common.Hash{}) {
+		blnum = new(big.Int).SetUint64(num)
 	}
+	return
+}
 
-	// meta
-	var txExtra struct {
-		BlockHash  common.Hash
-		BlockIndex uint64
-		Index      uint64
-	}
+// EthTransactionReceipt returns the locally stored receipt of the
+// transaction with the given hash, or nil if it hasn't been mined.
+func (self *XEth) EthTransactionReceipt(hash string) *types.Receipt {
+	return core.ReadReceipt(self.backend.ExtraDb(), common.HexToHash(hash))
+}
 
-	v, _ := self.backend.ExtraDb().Get(append(common.FromHex(hash), 0x0001))
-	r := bytes.NewReader(v)
-	err := rlp.Decode(r, &txExtra)
-	if err == nil {
-		blhash = txExtra.BlockHash
-		blnum = big.NewInt(int64(txExtra.BlockIndex))
-		txi = txExtra.Index
-	} else {
-		glog.V(logger.Error).Infoln(err)
+// TransactionsByAddress returns the hashes of the transactions sent or
+// received by addr between the given blocks. Negative block numbers refer
+// to the current block.
+func (self *XEth) TransactionsByAddress(addr string, from, to int64) ([]common.Hash, error) {
+	if !self.backend.BlockProcessor().AddressIndex() {
+		return nil, fmt.Errorf("transaction address index is not enabled")
 	}
-
-	return
+	current := self.CurrentBlock().NumberU64()
+	if from < 0 {
+		from = int64(current)
+	}
+	if to < 0 {
+		to = int64(current)
+	}
+	return core.GetAddressTransactions(self.backend.ExtraDb(), self.backend.ChainManager(), common.HexToAddress(addr), uint64(from), uint64(to))
 }
 
 func (self *XEth) BlockByNumber(num int64) *Block {
@@ -258,6 +364,59 @@ func (self *XEth) Accounts() []string {
 	return accountAddresses
 }
 
+// AccountList returns the accounts of the node with their labels in the
+// order they were created.
+func (self *XEth) AccountList() []accounts.Account {
+	accts, _ := self.backend.AccountManager().Accounts()
+	return accts
+}
+
+func (self *XEth) SetAccountLabel(addr, label string) error {
+	return self.backend.AccountManager().SetLabel(common.FromHex(addr), label)
+}
+
+// SignMessage signs the hash of message given by crypto.MessageHash with
+// the key of addr. The key is decrypted with passphrase if given, otherwise
+// the account must be unlocked. The V value of the signature is 27 or 28.
+func (self *XEth) SignMessage(addr string, message []byte, passphrase string) ([]byte, error) {
+	am := self.backend.AccountManager()
+	acct := accounts.Account{Address: common.FromHex(addr)}
+	hash := crypto.MessageHash(message)
+
+	var sig []byte
+	var err error
+	if passphrase != "" {
+		sig, err = am.SignWithPassphrase(acct, passphrase, hash)
+	} else {
+		sig, err = am.Sign(acct, hash)
+		if err == accounts.ErrLocked && self.frontend.UnlockAccount(acct.Address) {
+			sig, err = am.Sign(acct, hash)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// EcRecover returns the address of the account which signed message with
+// SignMessage.
+func (self *XEth) EcRecover(message, sig []byte) (common.Address, error) {
+	if len(sig) != 65 {
+		return common.Address{}, fmt.Errorf("signature must be 65 bytes long")
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		return common.Address{}, fmt.Errorf("invalid signature recovery id %d, must be 27 or 28", sig[64])
+	}
+	rsv := append(sig[:64:64], sig[64]-27)
+	pub, err := crypto.Ecrecover(crypto.MessageHash(message), rsv)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(crypto.Sha3(pub[1:])[12:]), nil
+}
+
 func (self *XEth) DbPut(key, val []byte) bool {
 	self.backend.ExtraDb().Put(key, val)
 	return true
@@ -268,6 +427,12 @@ func (self *XEth) DbGet(key []byte) ([]byte, error) {
 	return val, err
 }
 
+// UpdateStatus returns the result of the last release check, nil if
+// update checks are disabled.
+func (self *XEth) UpdateStatus() *release.Status {
+	return self.backend.UpdateStatus()
+}
+
 func (self *XEth) PeerCount() int {
 	return self.backend.PeerCount()
 }
@@ -284,6 +449,10 @@ func (self *XEth) NetworkVersion() string {
 	return fmt.Sprintf("%d", self.backend.NetVersion())
 }
 
+func (self *XEth) SyncStatus() *eth.SyncStatus {
+	return self.backend.SyncStatus()
+}
+
 func (self *XEth) WhisperVersion() string {
 	return fmt.Sprintf("%d", self.backend.ShhVersion())
 }
@@ -352,8 +521,11 @@ func (self *XEth) SecretToAddress(key string) string {
 	return common.ToHex(pair.Address())
 }
 
-func (self *XEth) RegisterFilter(earliest, latest int64, skip, max int, address []string, topics [][]string) int {
-	var id int
+func (self *XEth) RegisterFilter(earliest, latest int64, skip, max int, address []string, topics [][]string) (int, error) {
+	if err := self.checkFilterLimit(); err != nil {
+		return 0, err
+	}
+	id := -1 // set under the lock once the filter is installed
 	filter := core.NewFilter(self.backend)
 	filter.SetEarliestBlock(earliest)
 	filter.SetLatestBlock(latest)
@@ -361,30 +533,86 @@ func (self *XEth) RegisterFilter(earliest, latest int64, skip, max int, address
 	filter.SetMax(max)
 	filter.SetAddress(cAddress(address))
 	filter.SetTopics(cTopics(topics))
-	filter.LogsCallback = func(logs state.Logs) {
+	sub := self.subscribeLogs(filter, false, func(logs state.Logs, pending bool) {
 		self.logMut.Lock()
 		defer self.logMut.Unlock()
 
-		self.logs[id].add(logs...)
-	}
-	id = self.filterManager.InstallFilter(filter)
+		if f := self.logs[id]; f != nil {
+			f.add(logs...)
+		}
+	})
+
+	self.logMut.Lock()
+	defer self.logMut.Unlock()
+	id = sub.Id()
 	self.logs[id] = &logFilter{timeout: time.Now()}
 
-	return id
+	return id, nil
 }
 
+// UninstallFilter removes a log or transaction filter and releases its
+// subscription. It returns false if there is no such filter.
 func (self *XEth) UninstallFilter(id int) bool {
-	if _, ok := self.logs[id]; ok {
-		delete(self.logs, id)
-		self.filterManager.UninstallFilter(id)
-		return true
+	self.logMut.Lock()
+	_, isLog := self.logs[id]
+	delete(self.logs, id)
+	self.logMut.Unlock()
+
+	self.transactionMut.Lock()
+	_, isTx := self.transactions[id]
+	delete(self.transactions, id)
+	self.transactionMut.Unlock()
+
+	if !isLog && !isTx {
+		return false
 	}
+	self.filterManager.UninstallFilter(id)
+	return true
+}
+
+// NewTransactionFilter installs a filter collecting the hashes of the
+// transactions entering the transaction pool.
+func (self *XEth) NewTransactionFilter() (int, error) {
+	if err := self.checkFilterLimit(); err != nil {
+		return 0, err
+	}
+	id := -1 // set under the lock once the filter is installed
+	filter := core.NewFilter(self.backend)
+	filter.PendingCallback = func(tx *types.Transaction) {
+		self.transactionMut.Lock()
+		defer self.transactionMut.Unlock()
 
-	return false
+		if f := self.transactions[id]; f != nil {
+			f.add(tx.Hash())
+		}
+	}
+	fid := self.filterManager.InstallFilter(filter)
+
+	self.transactionMut.Lock()
+	defer self.transactionMut.Unlock()
+	id = fid
+	self.transactions[id] = &hashFilter{timeout: time.Now()}
+
+	return id, nil
 }
 
-func (self *XEth) NewFilterString(word string) int {
-	var id int
+// TransactionFilterChanged returns the transaction hashes collected since
+// the last call. ok is false if id is not a transaction filter.
+func (self *XEth) TransactionFilterChanged(id int) (hashes []common.Hash, ok bool) {
+	self.transactionMut.Lock()
+	defer self.transactionMut.Unlock()
+
+	if self.transactions[id] == nil {
+		return nil, false
+	}
+	return self.transactions[id].get(), true
+}
+
+func (self *XEth) NewFilterString(word string) (int, error) {
+	if err := self.checkFilterLimit(); err != nil {
+		return 0, err
+	}
+	id := -1 // set under the lock once the filter is installed
 	filter := core.NewFilter(self.backend)
 
 	switch word {
@@ -393,24 +621,29 @@ func (self *XEth) NewFilterString(word string) int {
 			self.logMut.Lock()
 			defer self.logMut.Unlock()
 
-			self.logs[id].add(&state.Log{})
+			if f := self.logs[id]; f != nil {
+				f.add(&state.Log{})
+			}
 		}
 	case "latest":
 		filter.BlockCallback = func(block *types.Block, logs state.Logs) {
 			self.logMut.Lock()
 			defer self.logMut.Unlock()
 
-			for _, log := range logs {
-				self.logs[id].add(log)
+			if f := self.logs[id]; f != nil {
+				f.add(logs...)
+				f.add(&state.Log{})
 			}
-			self.logs[id].add(&state.Log{})
 		}
 	}
+	fid := self.filterManager.InstallFilter(filter)
 
-	id = self.filterManager.InstallFilter(filter)
+	self.logMut.Lock()
+	defer self.logMut.Unlock()
+	id = fid
 	self.logs[id] = &logFilter{timeout: time.Now()}
 
-	return id
+	return id, nil
 }
 
 func (self *XEth) FilterChanged(id int) state.Logs {
@@ -424,10 +657,9 @@ func (self *XEth) FilterChanged(id int) state.Logs {
 	return nil
 }
 
+// Logs returns all logs matching the filter. The filter locks aren't held
+// while searching, the filter manager guards its filters itself.
 func (self *XEth) Logs(id int) state.Logs {
-	self.logMut.Lock()
-	defer self.logMut.Unlock()
-
 	filter := self.filterManager.GetFilter(id)
 	if filter != nil {
 		return filter.Find()
@@ -448,25 +680,104 @@ func (self *XEth) AllLogs(earliest, latest int64, skip, max int, address []strin
 	return filter.Find()
 }
 
-func (p *XEth) NewWhisperFilter(opts *Options) int {
-	var id int
+// DecodedLog is a log decoded with the ABI of the contract raising it.
+// Numbers are given as decimal strings and addresses as hex strings.
+type DecodedLog struct {
+	Event string                 `json:"event"`
+	Args  map[string]interface{} `json:"args"`
+}
+
+// DecodeLog decodes the log with the matching event of the JSON ABI.
+// Unnamed arguments are keyed by their position.
+func (self *XEth) DecodeLog(abiJSON string, log *state.Log) (*DecodedLog, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, err
+	}
+	return decodeLog(parsed, log)
+}
+
+// DecodeLogs decodes the logs matching one of the events of the JSON ABI,
+// such as the results of a log filter. Other logs are skipped.
+func (self *XEth) DecodeLogs(abiJSON string, logs state.Logs) ([]*DecodedLog, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, err
+	}
+	var decoded []*DecodedLog
+	for _, log := range logs {
+		if d, err := decodeLog(parsed, log); err == nil {
+			decoded = append(decoded, d)
+		}
+	}
+	return decoded, nil
+}
+
+func decodeLog(parsed abi.ABI, log *state.Log) (*DecodedLog, error) {
+	if len(log.Topics) == 0 {
+		return nil, fmt.Errorf("log without topics can't be decoded")
+	}
+	for _, event := range parsed.Events {
+		if event.Id() != log.Topics[0] {
+			continue
+		}
+		values, err := event.Unpack(log.Topics, log.Data)
+		if err != nil {
+			return nil, err
+		}
+		decoded := &DecodedLog{Event: event.Name, Args: make(map[string]interface{})}
+		for i, input := range event.Inputs {
+			name := input.Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			switch value := values[i].(type) {
+			case *big.Int:
+				decoded.Args[name] = value.String()
+			case common.Address:
+				decoded.Args[name] = value.Hex()
+			default:
+				decoded.Args[name] = value
+			}
+		}
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("no event with topic %x in abi", log.Topics[0])
+}
+
+func (p *XEth) NewWhisperFilter(opts *Options) (int, error) {
+	if err := p.checkFilterLimit(); err != nil {
+		return 0, err
+	}
+	id := -1 // set under the lock once the filter is installed
 	opts.Fn = func(msg WhisperMessage) {
 		p.messagesMut.Lock()
 		defer p.messagesMut.Unlock()
-		p.messages[id].add(msg) // = append(p.messages[id], msg)
+		if f := p.messages[id]; f != nil {
+			f.add(msg)
+		}
 	}
-	id = p.Whisper().Watch(opts)
+	wid := p.Whisper().Watch(opts)
+
+	p.messagesMut.Lock()
+	defer p.messagesMut.Unlock()
+	id = wid
 	p.messages[id] = &whisperFilter{timeout: time.Now()}
-	return id
+	return id, nil
 }
 
+// UninstallWhisperFilter removes a whisper filter and stops watching for
+// its messages. It returns false if there is no such filter.
 func (p *XEth) UninstallWhisperFilter(id int) bool {
-	if _, ok := p.messages[id]; ok {
-		delete(p.messages, id)
-		return true
-	}
+	p.messagesMut.Lock()
+	_, ok := p.messages[id]
+	delete(p.messages, id)
+	p.messagesMut.Unlock()
 
-	return false
+	if ok {
+		p.Whisper().Unwatch(id)
+	}
+	return ok
 }
 
 func (self *XEth) MessagesChanged(id int) []WhisperMessage {
@@ -558,7 +869,7 @@ func (self *XEth) FromNumber(str string) string {
 
 func (self *XEth) PushTx(encodedTx string) (string, error) {
 	tx := types.NewTransactionFromBytes(common.FromHex(encodedTx))
-	err := self.backend.TxPool().Add(tx)
+	err := self.backend.TxPool().AddLocal(tx)
 	if err != nil {
 		return "", err
 	}
@@ -584,9 +895,14 @@ func (self *XEth) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr st
 		from = statedb.GetOrNewStateObject(common.HexToAddress(fromStr))
 	}
 
+	to, err := self.resolveAddr(toStr)
+	if err != nil {
+		return "", err
+	}
+
 	msg := callmsg{
 		from:     from,
-		to:       common.HexToAddress(toStr),
+		to:       to,
 		gas:      common.Big(gasStr),
 		gasPrice: common.Big(gasPriceStr),
 		value:    common.Big(valueStr),
@@ -617,7 +933,6 @@ func (self *XEth) ConfirmTransaction(tx string) bool {
 func (self *XEth) Transact(fromStr, toStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, error) {
 	var (
 		from             = common.HexToAddress(fromStr)
-		to               = common.HexToAddress(toStr)
 		value            = common.NewValue(valueStr)
 		gas              = common.Big(gasStr)
 		price            = common.Big(gasPriceStr)
@@ -661,6 +976,10 @@ func (self *XEth) Transact(fromStr, toStr, valueStr, gasStr, gasPriceStr, codeSt
 	if len(toStr) == 0 {
 		contractCreation = true
 	}
+	to, err := self.resolveAddr(toStr)
+	if err != nil {
+		return "", err
+	}
 
 	var tx *types.Transaction
 	if contractCreation {
@@ -669,14 +988,23 @@ func (self *XEth) Transact(fromStr, toStr, valueStr, gasStr, gasPriceStr, codeSt
 		tx = types.NewTransactionMessage(to, value.BigInt(), gas, price, data)
 	}
 
+	// reject transactions the pool would refuse before asking to unlock
+	if tx.Gas().Cmp(core.IntrinsicGas(data, contractCreation)) < 0 {
+		return "", core.ErrIntrinsicGas
+	}
 	state := self.backend.ChainManager().TxState()
+	cost := new(big.Int).Mul(tx.Gas(), tx.GasPrice())
+	if state.GetBalance(from).Cmp(cost.Add(cost, tx.Value())) < 0 {
+		return "", core.ErrInsufficientFunds
+	}
+
 	nonce := state.NewNonce(from)
 	tx.SetNonce(nonce)
 
 	if err := self.sign(tx, from, false); err != nil {
 		return "", err
 	}
-	if err := self.backend.TxPool().Add(tx); err != nil {
+	if err := self.backend.TxPool().AddLocal(tx); err != nil {
 		return "", err
 	}
 
@@ -689,14 +1017,53 @@ func (self *XEth) Transact(fromStr, toStr, valueStr, gasStr, gasPriceStr, codeSt
 	return tx.Hash().Hex(), nil
 }
 
+// Resend replaces a pending transaction of the pool with one paying the
+// given gas price and using the same nonce. A zero gas limit keeps the
+// limit of the pending transaction. The new transaction hash is returned.
+func (self *XEth) Resend(hashStr string, price, gas *big.Int) (string, error) {
+	pool := self.backend.TxPool()
+	old := pool.GetTransaction(common.HexToHash(hashStr))
+	if old == nil {
+		return "", fmt.Errorf("transaction %s is not pending", hashStr)
+	}
+	if price.Cmp(old.GasPrice()) <= 0 {
+		return "", fmt.Errorf("gas price must be higher than %v", old.GasPrice())
+	}
+	if gas.Cmp(big.NewInt(0)) == 0 {
+		gas = old.Gas()
+	}
+	from, err := old.From()
+	if err != nil {
+		return "", err
+	}
+
+	var tx *types.Transaction
+	if to := old.To(); to != nil {
+		tx = types.NewTransactionMessage(*to, old.Value(), gas, price, old.Data())
+	} else {
+		tx = types.NewContractCreationTx(old.Value(), gas, price, old.Data())
+	}
+	tx.SetNonce(old.Nonce())
+	if err := self.sign(tx, from, false); err != nil {
+		return "", err
+	}
+
+	pool.RemoveSet(types.Transactions{old})
+	if err := pool.AddLocal(tx); err != nil {
+		pool.AddLocal(old)
+		return "", err
+	}
+	return tx.Hash().Hex(), nil
+}
+
 func (self *XEth) sign(tx *types.Transaction, from common.Address, didUnlock bool) error {
-	sig, err := self.backend.AccountManager().Sign(accounts.Account{Address: from.Bytes()}, tx.Hash().Bytes())
+	sig, err := self.backend.AccountManager().SignTx(accounts.Account{Address: from.Bytes()}, tx)
 	if err == accounts.ErrLocked {
 		if didUnlock {
 			return fmt.Errorf("sender account still locked after successful unlock")
 		}
 		if !self.frontend.UnlockAccount(from.Bytes()) {
-			return fmt.Errorf("could not unlock sender account")
+			return accounts.ErrLocked
 		}
 		// retry signing, the account should now be unlocked.
 		return self.sign(tx, from, true)
@@ -757,3 +1124,19 @@ func (l *logFilter) get() state.Logs {
 	l.logs = nil
 	return tmp
 }
+
+type hashFilter struct {
+	hashes  []common.Hash
+	timeout time.Time
+}
+
+func (h *hashFilter) add(hashes ...common.Hash) {
+	h.hashes = append(h.hashes, hashes...)
+}
+
+func (h *hashFilter) get() []common.Hash {
+	h.timeout = time.Now()
+	tmp := h.hashes
+	h.hashes = nil
+	return tmp
+}